@@ -2,24 +2,35 @@ package main
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/policy"
 	"github.com/cuongbtq/practice-be/internal/api/router"
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+	"github.com/cuongbtq/practice-be/internal/app"
 	"github.com/cuongbtq/practice-be/internal/config"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/jobtype"
+	"github.com/cuongbtq/practice-be/shared/leaderelection"
 	"github.com/cuongbtq/practice-be/shared/logger"
+	"github.com/cuongbtq/practice-be/shared/messaging"
 	"github.com/cuongbtq/practice-be/shared/postgresql"
-	"github.com/cuongbtq/practice-be/shared/rabbitmq"
+	"github.com/cuongbtq/practice-be/shared/resultstore"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+)
+
+// Advisory lock keys API instances compete for to run each background task. Changing one
+// lets old and new instances run that task concurrently.
+const (
+	dependencyResolverLockID int64 = 0x6a6f62_0001
+	retentionCleanerLockID   int64 = 0x6a6f62_0002
+	eventRelayLockID         int64 = 0x6a6f62_0003
+	outboxRelayLockID        int64 = 0x6a6f62_0004
 )
 
 func main() {
@@ -29,59 +40,140 @@ func main() {
 }
 
 func run() error {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables or flags")
+	a, err := app.New(app.Options{
+		Name:              "API service",
+		ConfigEnv:         "API_SERVICE_CONFIG_PATH",
+		DefaultConfigPath: "configs/api-service/config.yaml",
+		Profile:           config.ProfileAPI,
+	})
+	if err != nil {
+		return err
 	}
+	return a.Run(context.Background(), setup)
+}
 
-	// Parse command-line flags
-	defaultConfigPath := os.Getenv("API_SERVICE_CONFIG_PATH")
-	if defaultConfigPath == "" {
-		defaultConfigPath = "configs/api-service/config.yaml"
+// setup connects the API service to its dependencies and adds its background tasks and
+// HTTP server to the lifecycle
+func setup(a *app.App) error {
+	cfg := a.Config
+	appLogger := a.Logger
+
+	if cfg.Server.AdminToken == "" {
+		if cfg.App.Environment == "development" {
+			appLogger.Warn("server.admin_token is not set, /admin routes are unauthenticated")
+		} else {
+			appLogger.Warn("server.admin_token is not set, /admin routes are disabled")
+		}
 	}
-	configPath := flag.String("config", defaultConfigPath, "Path to configuration file")
-	flag.Parse()
 
-	// Load configuration
-	cfg, err := config.Load(*configPath)
+	if err := a.ConnectDatabase(); err != nil {
+		return err
+	}
+	if err := a.ConnectBroker(); err != nil {
+		return err
+	}
+	dbClient := a.DB
+	jobBroker := a.Broker
+
+	// Wrapped around chaos so injected failures trip it too
+	var circuitBreaker *broker.CircuitBreaker
+	if breaker := cfg.Policies.CircuitBreaker; breaker.Enabled {
+		circuitBreaker = broker.NewCircuitBreaker(jobBroker, broker.CircuitBreakerOptions{
+			FailureThreshold: breaker.FailureThreshold,
+			OpenDuration:     breaker.OpenDuration,
+			HalfOpenProbes:   breaker.HalfOpenProbes,
+		})
+		jobBroker = circuitBreaker
+	}
+
+	policies := initPolicies(&cfg.Policies, appLogger.Logger)
+
+	results, err := initResultStore(&cfg.Results)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return fmt.Errorf("failed to initialize result store: %w", err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid config: %w", err)
+	schemas, err := initPayloadSchemas(&cfg.Payloads)
+	if err != nil {
+		return fmt.Errorf("failed to load payload schemas: %w", err)
 	}
 
-	// Initialize logger
-	appLogger, err := initLogger(&cfg.Logging)
+	resultSchemas, err := initResultSchemas(&cfg.Results)
 	if err != nil {
-		return fmt.Errorf("failed to initialize logger: %w", err)
+		return fmt.Errorf("failed to load result schemas: %w", err)
 	}
 
-	appLogger.Info("Starting API service",
-		slog.String("app", cfg.App.Name),
-		slog.String("version", cfg.App.Version),
-		slog.String("environment", cfg.App.Environment),
-	)
+	jobTypes, err := initJobTypes(&cfg.Validation)
+	if err != nil {
+		return fmt.Errorf("failed to load job types: %w", err)
+	}
 
-	// Initialize PostgreSQL client
-	dbClient, err := initPostgreSQL(&cfg.Database, appLogger.Logger)
+	messageCodec, err := messaging.Lookup(cfg.Broker.Encoding)
 	if err != nil {
-		return fmt.Errorf("failed to initialize database: %w", err)
+		return fmt.Errorf("failed to select message encoding: %w", err)
 	}
 
-	appLogger.Info("Database connection established")
+	handlerDeps := initHandlerDeps(cfg, appLogger, dbClient, jobBroker, policies, results, schemas)
+	handlerDeps.ResultSchemas = resultSchemas
+	handlerDeps.Validation.Definitions = jobTypes
+	handlerDeps.MessageCodec = messageCodec
+	if circuitBreaker != nil {
+		handlerDeps.CircuitBreaker = circuitBreaker
+	}
 
-	// Initialize RabbitMQ client
-	rabbitClient, err := initRabbitMQ(&cfg.RabbitMQ, appLogger.Logger)
-	if err != nil {
-		return fmt.Errorf("failed to initialize RabbitMQ: %w", err)
+	// Degradation policies outlive individual requests: deferred messages are republished
+	// in the background, routed like the jobs' first publish, and requeued at shutdown
+	deferred := handler.NewDeferredPublisher(handlerDeps)
+	a.Go("policies", func(ctx context.Context) { policies.Run(ctx, deferred) })
+
+	// Jobs submitted with depends_on are queued in the background once their dependencies complete
+	resolver := handler.NewDependencyResolver(handlerDeps, handler.DependencyResolverOptions{
+		Interval:  cfg.Chaining.ResolveInterval,
+		BatchSize: cfg.Chaining.BatchSize,
+	})
+	runOnLeader(a, dependencyResolverLockID, "dependency_resolver", resolver.Run)
+
+	// Old terminal jobs are purged in the background, inside the configured window
+	if cfg.Retention.Enabled {
+		cleaner := handler.NewRetentionCleaner(handlerDeps, retentionOptions(&cfg.Retention))
+		handlerDeps.Retention = cleaner
+		runOnLeader(a, retentionCleanerLockID, "retention_cleaner", cleaner.Run)
 	}
 
-	appLogger.Info("RabbitMQ connection established")
+	// Jobs accepted in async ingestion mode are published from the outbox. The relay also
+	// runs in sync mode, so jobs queued before switching modes are still published.
+	outbox := handler.NewOutboxRelay(handlerDeps, handler.OutboxRelayOptions{
+		Interval:  cfg.Ingestion.RelayInterval,
+		BatchSize: cfg.Ingestion.BatchSize,
+	})
+	runOnLeader(a, outboxRelayLockID, "outbox_relay", outbox.Run)
+
+	// Job status transitions are published to the events exchange for external consumers
+	if cfg.Events.Enabled {
+		events, ok := jobBroker.(broker.EventPublisher)
+		if !ok {
+			return fmt.Errorf("%s broker does not support publishing events", cfg.Broker.Type)
+		}
+		relay := handler.NewEventRelay(handlerDeps, events, handler.EventRelayOptions{
+			Interval:  cfg.Events.Interval,
+			BatchSize: cfg.Events.BatchSize,
+		})
+		handlerDeps.EventRelay = relay
+		runOnLeader(a, eventRelayLockID, "event_relay", relay.Run)
+	}
+
+	// Mutating requests are rejected while the config file or the admin API sets maintenance mode
+	maintenance := handler.NewMaintenanceMode(handlerDeps, cfg.Maintenance.RefreshInterval)
+	maintenance.Configure(cfg.Maintenance.Enabled, cfg.Maintenance.Message)
+	a.OnReload(func(newCfg *config.Config, _ config.Changes) error {
+		maintenance.Configure(newCfg.Maintenance.Enabled, newCfg.Maintenance.Message)
+		return nil
+	})
+	handlerDeps.Maintenance = maintenance
+	a.Go("maintenance", maintenance.Run)
 
 	// Initialize router
-	r := initRouter(cfg.App.Environment, appLogger.Logger, dbClient, rabbitClient)
+	r := initRouter(cfg, handlerDeps)
 
 	// Create HTTP server
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -93,132 +185,232 @@ func run() error {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	appLogger.Info("Starting HTTP server",
-		slog.String("address", addr),
-		slog.Duration("read_timeout", cfg.Server.ReadTimeout),
-		slog.Duration("write_timeout", cfg.Server.WriteTimeout),
-	)
-
-	// Start server in goroutine
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			appLogger.Error("Server failed to start",
-				slog.Any("error", err),
+	// Added last, so in-flight requests finish before the background tasks and connections stop
+	a.Append(app.Hook{
+		Name: "http_server",
+		Start: func(context.Context) error {
+			appLogger.Info("Starting HTTP server",
+				slog.String("address", addr),
+				slog.Duration("read_timeout", cfg.Server.ReadTimeout),
+				slog.Duration("write_timeout", cfg.Server.WriteTimeout),
 			)
-			os.Exit(1)
-		}
-	}()
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					a.Fail(fmt.Errorf("server failed to start: %w", err))
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	})
+	return nil
+}
 
-	appLogger.Info("API service is running",
-		slog.String("address", addr),
-	)
+// runOnLeader adds a background task to the lifecycle of a, run on the instance holding
+// lockID only when leader election is enabled
+func runOnLeader(a *app.App, lockID int64, task string, run func(context.Context)) {
+	cfg := &a.Config.LeaderElection
+	if !cfg.Enabled {
+		a.Go(task, run)
+		return
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	elector := leaderelection.New(a.DB.GetDB().DB, leaderelection.Config{
+		LockID:        lockID,
+		RenewInterval: cfg.RenewInterval,
+		OnAcquire:     run,
+	}, a.Logger.Logger.With(slog.String("task", task)))
+	a.Go(task, elector.Run)
+}
 
-	appLogger.Info("Shutting down server...")
+// retentionOptions converts the retention config, which Validate has already checked
+func retentionOptions(cfg *config.RetentionConfig) handler.RetentionOptions {
+	opts := handler.RetentionOptions{
+		MaxAge:    cfg.MaxAge,
+		Interval:  cfg.Interval,
+		BatchSize: cfg.BatchSize,
+		Archive:   cfg.Archive,
+	}
+	// time.Parse yields the offset from midnight on year 0
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	if start, err := time.Parse(config.RetentionWindowLayout, cfg.WindowStart); err == nil {
+		opts.WindowStart = start.Sub(midnight)
+	}
+	if end, err := time.Parse(config.RetentionWindowLayout, cfg.WindowEnd); err == nil {
+		opts.WindowEnd = end.Sub(midnight)
+	}
+	return opts
+}
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+// initPolicies builds the policy engine consulted by handlers when a dependency fails
+func initPolicies(cfg *config.PoliciesConfig, logger *slog.Logger) *policy.Engine {
+	return policy.NewEngine(policy.Options{
+		BrokerUnavailable:     policy.BrokerAction(cfg.BrokerUnavailable),
+		DatabaseUnavailable:   policy.DatabaseAction(cfg.DatabaseUnavailable),
+		StaleReadCacheSize:    cfg.StaleReadCacheSize,
+		DeferredQueueSize:     cfg.Deferred.QueueSize,
+		DeferredRetryInterval: cfg.Deferred.RetryInterval,
+		MaxPendingJobs:        cfg.Backlog.MaxPending,
+		BacklogRetryAfter:     cfg.Backlog.RetryAfter,
+		BacklogCheckInterval:  cfg.Backlog.CheckInterval,
+	}, logger)
+}
 
-	// Cleanup function to close all resources
-	cleanup := func() {
-		cancel()
-		if dbClient != nil {
-			dbClient.Close()
-		}
-		if rabbitClient != nil {
-			rabbitClient.Close()
-		}
+// initResultStore builds the store used to resolve offloaded job results
+func initResultStore(cfg *config.ResultsConfig) (resultstore.Store, error) {
+	if cfg.Backend != "s3" {
+		return resultstore.InlineStore{}, nil
 	}
-	defer cleanup()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		appLogger.Error("Server forced to shutdown",
-			slog.Any("error", err),
-		)
-		return err
+	return resultstore.NewS3Store(resultstore.S3Config{
+		Endpoint:        cfg.S3.Endpoint,
+		Region:          cfg.S3.Region,
+		Bucket:          cfg.S3.Bucket,
+		Prefix:          cfg.S3.Prefix,
+		AccessKeyID:     cfg.S3.AccessKeyID,
+		SecretAccessKey: cfg.S3.SecretAccessKey,
+		UsePathStyle:    cfg.S3.UsePathStyle,
+		InlineLimit:     cfg.InlineLimitBytes,
+		URLExpiry:       cfg.URLExpiry,
+	}, nil)
+}
+
+// initPayloadSchemas loads the per-job-type payload schemas, if configured
+func initPayloadSchemas(cfg *config.PayloadsConfig) (schema.Registry, error) {
+	if cfg.SchemaDir == "" {
+		return nil, nil
 	}
+	return schema.LoadDir(cfg.SchemaDir)
+}
 
-	appLogger.Info("Server shutdown complete")
-	return nil
+// initResultSchemas loads the per-job-type result schemas, if configured
+func initResultSchemas(cfg *config.ResultsConfig) (schema.Registry, error) {
+	if cfg.SchemaDir == "" {
+		return nil, nil
+	}
+	return schema.LoadDir(cfg.SchemaDir)
+}
+
+// initJobTypes loads the job type definitions shared with the workers, if configured
+func initJobTypes(cfg *config.ValidationConfig) (*jobtype.Registry, error) {
+	if cfg.JobTypesFile == "" {
+		return nil, nil
+	}
+	return jobtype.Load(cfg.JobTypesFile)
 }
 
-// initLogger initializes and configures the application logger
-func initLogger(cfg *config.LoggingConfig) (*logger.Logger, error) {
-	loggerCfg := &logger.Config{
-		Level:        cfg.Level,
-		Format:       cfg.Format,
-		Output:       cfg.Output,
-		EnableSource: cfg.EnableCaller,
-		TimeFormat:   time.RFC3339,
+// maxPayloadBytes returns the configured payload limit, capped at the largest job
+// payload that fits in a broker message
+func maxPayloadBytes(configured, maxMessageBytes int) int {
+	const envelopeBytes = 1024
+	limit := maxMessageBytes
+	if maxMessageBytes > envelopeBytes {
+		limit = maxMessageBytes - envelopeBytes
 	}
+	if configured > 0 && (limit <= 0 || configured < limit) {
+		return configured
+	}
+	return limit
+}
 
-	return logger.New(loggerCfg)
+// maxBodyBytes returns the configured request body limit or, when unset, one that fits
+// a job payload of payloadLimit bytes. Payloads are JSON strings inside the request, so
+// escaping may double their size.
+func maxBodyBytes(configured int64, payloadLimit int) int64 {
+	const requestOverheadBytes = 64 << 10
+	if configured > 0 || payloadLimit <= 0 {
+		return configured
+	}
+	return 2*int64(payloadLimit) + requestOverheadBytes
 }
 
-// initPostgreSQL initializes the PostgreSQL database client
-func initPostgreSQL(cfg *config.DatabaseConfig, logger *slog.Logger) (*postgresql.Client, error) {
-	dbConfig := &postgresql.Config{
-		Host:            cfg.Host,
-		Port:            cfg.Port,
-		User:            cfg.User,
-		Password:        cfg.Password,
-		Database:        cfg.Database,
-		SSLMode:         cfg.SSLMode,
-		MaxOpenConns:    cfg.MaxOpenConns,
-		MaxIdleConns:    cfg.MaxIdleConns,
-		ConnMaxLifetime: cfg.ConnMaxLifetime,
-		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
-	}
-
-	return postgresql.NewClient(dbConfig, logger)
+// tenancyOptions converts the tenancy config into handler options
+func tenancyOptions(cfg config.TenancyConfig) handler.TenancyOptions {
+	quota := func(q config.TenantQuotaConfig) handler.TenantQuota {
+		return handler.TenantQuota{MaxPendingJobs: q.MaxPendingJobs, MaxJobsPerDay: q.MaxJobsPerDay}
+	}
+
+	quotas := make(map[string]handler.TenantQuota, len(cfg.Quotas.Tenants))
+	for id, q := range cfg.Quotas.Tenants {
+		quotas[id] = quota(q)
+	}
+
+	return handler.TenancyOptions{
+		Enabled:      cfg.Enabled,
+		Header:       cfg.Header,
+		Tokens:       cfg.Tokens,
+		DefaultQuota: quota(cfg.Quotas.Default),
+		Quotas:       quotas,
+	}
 }
 
-// initRabbitMQ initializes the RabbitMQ client
-func initRabbitMQ(cfg *config.RabbitMQConfig, logger *slog.Logger) (*rabbitmq.Client, error) {
-	rabbitConfig := &rabbitmq.Config{
-		Host:               cfg.Host,
-		Port:               cfg.Port,
-		User:               cfg.User,
-		Password:           cfg.Password,
-		VHost:              cfg.VHost,
-		ExchangeName:       cfg.Exchange.Name,
-		ExchangeType:       cfg.Exchange.Type,
-		ExchangeDurable:    cfg.Exchange.Durable,
-		ExchangeAutoDelete: cfg.Exchange.AutoDelete,
-		QueueName:          cfg.Queue.Name,
-		QueueDurable:       cfg.Queue.Durable,
-		QueueAutoDelete:    cfg.Queue.AutoDelete,
-		QueueExclusive:     cfg.Queue.Exclusive,
-		RoutingKey:         cfg.RoutingKey,
-		RetryAttempts:      cfg.Connection.RetryAttempts,
-		RetryInterval:      cfg.Connection.RetryInterval,
-		Heartbeat:          cfg.Connection.Heartbeat,
-		ConnectionTimeout:  cfg.Connection.ConnectionTimeout,
-	}
-
-	return rabbitmq.NewClient(rabbitConfig, logger)
+// initHandlerDeps builds the dependencies shared by the HTTP handlers and background tasks
+func initHandlerDeps(cfg *config.Config, appLogger *logger.Logger, dbClient *postgresql.Client, jobBroker broker.Broker, policies *policy.Engine, results resultstore.Store, schemas schema.Registry) *handler.Dependencies {
+	payloadLimit := maxPayloadBytes(cfg.Payloads.MaxBytes, cfg.MaxMessageBytes())
+
+	return &handler.Dependencies{
+		Logger:       appLogger.Logger,
+		LogLevel:     appLogger,
+		AdminToken:   cfg.Server.AdminToken,
+		CursorKey:    cfg.Server.CursorKey,
+		DBClient:     dbClient,
+		QueryMetrics: dbClient,
+		Broker:       jobBroker,
+		// Leave headroom for the job message envelope around the payload
+		MaxPayloadBytes: payloadLimit,
+		PayloadSchemas:  schemas,
+		App: handler.AppInfo{
+			Name:        cfg.App.Name,
+			Version:     cfg.App.Version,
+			Environment: cfg.App.Environment,
+		},
+		Estimation: handler.EstimationOptions{
+			HistoryWindow:  cfg.Estimation.HistoryWindow,
+			WorkerCapacity: cfg.Estimation.WorkerCapacity,
+		},
+		JobHealth: handler.JobHealthOptions{
+			HeartbeatTimeout: cfg.JobHealth.HeartbeatTimeout,
+			PendingSLA:       cfg.JobHealth.PendingSLA,
+			WorkerTimeout:    cfg.JobHealth.WorkerTimeout,
+		},
+		CORS: handler.CORSOptions{
+			AllowAllOrigins:  cfg.Server.CORS.AllowAllOrigins,
+			AllowedOrigins:   cfg.Server.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.Server.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.Server.CORS.AllowedHeaders,
+			AllowCredentials: cfg.Server.CORS.AllowCredentials,
+			MaxAge:           cfg.Server.CORS.MaxAge,
+		},
+		Compression: handler.CompressionOptions{
+			Enabled:       cfg.Server.Compression.Enabled,
+			MinSize:       cfg.Server.Compression.MinSize,
+			ExcludedPaths: cfg.Server.Compression.ExcludedPaths,
+		},
+		Limits: handler.RequestLimitsOptions{
+			MaxBodyBytes:   maxBodyBytes(cfg.Server.Limits.MaxBodyBytes, payloadLimit),
+			HandlerTimeout: cfg.Server.Limits.HandlerTimeout,
+			RouteTimeouts:  cfg.Server.Limits.RouteTimeouts,
+		},
+		Validation: handler.ValidationOptions{
+			JobTypes:          cfg.Validation.JobTypes,
+			RequireUUIDUserID: cfg.Validation.RequireUUIDUserID,
+		},
+		Tenancy:     tenancyOptions(cfg.Tenancy),
+		Policies:    policies,
+		Results:     results,
+		AsyncCreate: cfg.Ingestion.Mode == config.IngestionAsync,
+	}
 }
 
 // initRouter initializes the Gin router with all routes and middleware
-func initRouter(environment string, logger *slog.Logger, dbClient *postgresql.Client, rabbitClient *rabbitmq.Client) *gin.Engine {
+func initRouter(cfg *config.Config, handlerDeps *handler.Dependencies) *gin.Engine {
 	// Set Gin mode based on environment
-	if environment == "production" {
+	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	// Initialize handler dependencies
-	handlerDeps := &handler.Dependencies{
-		Logger:       logger,
-		DBClient:     dbClient,
-		RabbitClient: rabbitClient,
-	}
-
 	// Setup router
 	return router.SetupRouter(handlerDeps)
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+server:
+  port: 8080
+  admin_token: admin-secret
+database:
+  host: localhost
+  port: 5432
+  database: jobs_db
+  password: db-secret
+  sslmode: disable
+rabbitmq:
+  host: localhost
+  port: 5672
+  password: vault:secret/data/jobs#rabbitmq_password
+  exchange:
+    name: jobs_exchange
+  queue:
+    name: jobs_queue
+tenancy:
+  tokens:
+    tenant-token: acme
+app:
+  environment: development
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func noEnv(string) string { return "" }
+
+func TestRun(t *testing.T) {
+	t.Run("prints the effective config with secrets masked", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"--config", writeConfig(t, testConfig)}, &stdout, &stderr, noEnv)
+		require.NoError(t, err)
+
+		out := stdout.String()
+		assert.Contains(t, out, "admin_token: '******'")
+		assert.Contains(t, out, "password: '******'")
+		assert.Contains(t, out, "password: vault:secret/data/jobs#rabbitmq_password", "secret references are kept")
+		assert.Contains(t, out, "'******1': acme")
+		for _, secret := range []string{"admin-secret", "db-secret", "tenant-token"} {
+			assert.NotContains(t, out, secret)
+		}
+		assert.Contains(t, stderr.String(), "is valid for profile api")
+	})
+
+	t.Run("production rules", func(t *testing.T) {
+		production := strings.Replace(testConfig, "environment: development", "environment: production", 1)
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"--config", writeConfig(t, production), "--quiet"}, &stdout, &stderr, noEnv)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database sslmode must not be disable in production")
+		assert.Contains(t, err.Error(), "rabbitmq tls must be enabled in production")
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("worker profile", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"--config", writeConfig(t, testConfig), "--profile", "worker", "--quiet"}, &stdout, &stderr, noEnv)
+		require.NoError(t, err)
+		assert.Contains(t, stderr.String(), "is valid for profile worker")
+	})
+
+	t.Run("config path from the environment", func(t *testing.T) {
+		path := writeConfig(t, testConfig)
+		getenv := func(key string) string {
+			if key == "API_SERVICE_CONFIG_PATH" {
+				return path
+			}
+			return ""
+		}
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run([]string{"--quiet"}, &stdout, &stderr, getenv))
+		assert.Contains(t, stderr.String(), path)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"--config", writeConfig(t, testConfig), "--profile", "scheduler", "--quiet"}, &stdout, &stderr, noEnv)
+		assert.ErrorContains(t, err, `unknown config profile "scheduler"`)
+	})
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/client"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/google/uuid"
+)
+
+// newFlagSet creates a flag set for a command with the shared flags registered
+func newFlagSet(name, args string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: jobctl %s %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	return fs
+}
+
+// parse parses args into fs and validates the shared flags
+func parse(fs *flag.FlagSet, opts *options, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return opts.validate()
+}
+
+// jobIDArg returns the single positional job ID argument
+func jobIDArg(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s takes exactly one job ID", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
+
+func runCreate(ctx context.Context, opts *options, args []string) error {
+	fs := newFlagSet("create", "--type <job_type> --payload <json|@file> [flags]", opts)
+	jobType := fs.String("type", "", "Job type (required)")
+	payload := fs.String("payload", "", "JSON object payload, or @path to read it from a file, @- for stdin (required)")
+	userID := fs.String("user", "", "User ID (defaults to $USER)")
+	idempotencyKey := fs.String("idempotency-key", "", "Idempotency key (defaults to a random UUID)")
+	metadata := fs.String("metadata", "", "JSON object metadata, or @path")
+	orderingKey := fs.String("ordering-key", "", "Ordering key; jobs with the same key run in submission order")
+	if err := parse(fs, opts, args); err != nil {
+		return err
+	}
+
+	if *jobType == "" || *payload == "" {
+		return errors.New("create requires --type and --payload")
+	}
+
+	payloadJSON, err := readJSONArg(*payload)
+	if err != nil {
+		return fmt.Errorf("invalid --payload: %w", err)
+	}
+
+	req := dto.CreateJobRequest{
+		IdempotencyKey: *idempotencyKey,
+		UserID:         *userID,
+		JobType:        *jobType,
+		Payload:        string(payloadJSON),
+		OrderingKey:    *orderingKey,
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.New().String()
+	}
+	if req.UserID == "" {
+		req.UserID = opts.getenv("USER")
+	}
+	if *metadata != "" {
+		if req.Metadata, err = readJSONArg(*metadata); err != nil {
+			return fmt.Errorf("invalid --metadata: %w", err)
+		}
+	}
+
+	job, err := opts.client().CreateJob(ctx, &req)
+	if err != nil {
+		return err
+	}
+	return printJob(opts, job)
+}
+
+func runGet(ctx context.Context, opts *options, args []string) error {
+	fs := newFlagSet("get", "<job_id> [flags]", opts)
+	archived := fs.Bool("archived", false, "Look the job up in the archive of purged jobs")
+	if err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	if *archived {
+		archivedJob, err := opts.client().GetArchivedJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		return printArchivedJob(opts, archivedJob)
+	}
+
+	job, err := opts.client().GetJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	return printJob(opts, job)
+}
+
+func runList(ctx context.Context, opts *options, args []string) error {
+	fs := newFlagSet("list", "[flags]", opts)
+	var params client.ListJobsParams
+	fs.StringVar(&params.UserID, "user", "", "Only jobs of this user")
+	fs.StringVar(&params.JobType, "type", "", "Only jobs of this type")
+	status := fs.String("status", "", "Comma-separated statuses, e.g. FAILED,CANCELED")
+	since := fs.Duration("since", 0, "Only jobs created within this duration, e.g. 24h")
+	fs.StringVar(&params.Payload, "payload", "", "JSON object the payload must contain")
+	fs.StringVar(&params.Query, "query", "", "Full-text search over error messages")
+	fs.StringVar(&params.Sort, "sort", "", "created_at, updated_at, status or job_type with an optional _asc or _desc suffix")
+	fs.IntVar(&params.PageSize, "limit", 20, "Jobs per page (at most 100)")
+	all := fs.Bool("all", false, "Follow cursors and list every matching job")
+	watch := fs.Bool("watch", false, "Re-run the listing until interrupted")
+	interval := fs.Duration("interval", 2*time.Second, "Refresh interval for --watch")
+	if err := parse(fs, opts, args); err != nil {
+		return err
+	}
+
+	if *status != "" {
+		params.Statuses = strings.Split(*status, ",")
+	}
+	if *interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	list := func() error {
+		if *since > 0 {
+			params.CreatedAfter = time.Now().Add(-*since)
+		}
+		jobs, err := listJobs(ctx, opts.client(), params, *all)
+		if err != nil {
+			return err
+		}
+		return printJobs(opts, jobs)
+	}
+
+	if !*watch {
+		return list()
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if opts.output == formatTable {
+			fmt.Fprintf(opts.stdout, "Every %s: %s\n\n", *interval, time.Now().Format(time.RFC3339))
+		}
+		if err := list(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			fmt.Fprintln(opts.stdout)
+		}
+	}
+}
+
+// listJobs fetches the first page, or every page when all is set
+func listJobs(ctx context.Context, c *client.Client, params client.ListJobsParams, all bool) ([]dto.JobDTO, error) {
+	var jobs []dto.JobDTO
+	for {
+		resp, err := c.ListJobs(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, resp.Jobs...)
+
+		if !all || resp.NextCursor == "" {
+			return jobs, nil
+		}
+		params.Cursor = resp.NextCursor
+	}
+}
+
+func runCancel(ctx context.Context, opts *options, args []string) error {
+	fs := newFlagSet("cancel", "<job_id> [flags]", opts)
+	version := fs.Int("version", 0, "Cancel only if the job is still at this version (default: its current version)")
+	if err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	c := opts.client()
+	if *version == 0 {
+		current, err := c.GetJob(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		*version = current.Version
+	}
+
+	job, err := c.CancelJob(ctx, jobID, *version)
+	if err != nil {
+		return err
+	}
+	return printJob(opts, job)
+}
+
+func runRetry(ctx context.Context, opts *options, args []string) error {
+	fs := newFlagSet("retry", "<job_id> [flags]", opts)
+	reset := fs.Bool("reset-retry-count", false, "Reset retry_count so the job gets its full retry budget again")
+	if err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	job, err := opts.client().RetryJob(ctx, jobID, *reset)
+	if err != nil {
+		return err
+	}
+	return printJob(opts, job)
+}
+
+// readJSONArg returns value, or the contents of the file for @path (@- reads stdin).
+// The result must be a JSON object.
+func readJSONArg(value string) (json.RawMessage, error) {
+	data := []byte(value)
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.New("must be a JSON object")
+	}
+	return json.RawMessage(strings.TrimSpace(string(data))), nil
+}
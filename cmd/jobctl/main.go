@@ -0,0 +1,121 @@
+// Command jobctl manages jobs through the job API.
+//
+//	jobctl create --type send_email --payload @payload.json
+//	jobctl get <job_id>
+//	jobctl get --archived <job_id>
+//	jobctl list --status FAILED --watch
+//	jobctl cancel [--version N] <job_id>
+//	jobctl retry <job_id>
+//
+// The server and output format come from --server and --output, or from the
+// JOBCTL_SERVER and JOBCTL_OUTPUT environment variables.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/client"
+)
+
+const usage = `Usage: jobctl <command> [flags]
+
+Commands:
+  create   Submit a job
+  get      Show a job
+  list     List jobs
+  cancel   Cancel a job
+  retry    Retry a failed or canceled job
+
+Run "jobctl <command> -h" for the flags of a command.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "jobctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command in args and writes its output to stdout
+func run(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stdout, usage)
+		return errors.New("no command given")
+	}
+
+	commands := map[string]func(context.Context, *options, []string) error{
+		"create": runCreate,
+		"get":    runGet,
+		"list":   runList,
+		"cancel": runCancel,
+		"retry":  runRetry,
+	}
+
+	name, rest := args[0], args[1:]
+	if name == "-h" || name == "--help" || name == "help" {
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", name, usage)
+	}
+
+	return cmd(ctx, &options{stdout: stdout, getenv: getenv}, rest)
+}
+
+// options holds the flags shared by every command
+type options struct {
+	server  string
+	output  string
+	timeout time.Duration
+
+	stdout io.Writer
+	getenv func(string) string
+}
+
+// register adds the shared flags to fs, defaulting to the environment
+func (o *options) register(fs *flag.FlagSet) {
+	server := o.getenv("JOBCTL_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	output := o.getenv("JOBCTL_OUTPUT")
+	if output == "" {
+		output = formatTable
+	}
+
+	fs.StringVar(&o.server, "server", server, "Job API base URL (JOBCTL_SERVER)")
+	fs.StringVar(&o.output, "output", output, "Output format: table or json (JOBCTL_OUTPUT)")
+	fs.StringVar(&o.output, "o", output, "Shorthand for --output")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "Timeout for each API request")
+}
+
+// validate checks the shared flags after parsing
+func (o *options) validate() error {
+	if o.output != formatTable && o.output != formatJSON {
+		return fmt.Errorf("invalid output %q (must be table or json)", o.output)
+	}
+	return nil
+}
+
+func (o *options) client() *client.Client {
+	return client.New(o.server, &http.Client{Timeout: o.timeout})
+}
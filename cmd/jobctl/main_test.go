@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var created dto.CreateJobRequest
+	var canceled dto.CancelJobRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/jobs":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: "job-1", JobType: created.JobType, Status: "PENDING"})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/jobs":
+			if r.URL.Query().Get("cursor") == "" {
+				_ = json.NewEncoder(w).Encode(dto.ListJobsResponse{Jobs: []dto.JobDTO{{JobID: "job-1", Status: "FAILED"}}, NextCursor: "page-2"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(dto.ListJobsResponse{Jobs: []dto.JobDTO{{JobID: "job-2", Status: "FAILED", RetryExhausted: true}}})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/archive/jobs/job-old":
+			_ = json.NewEncoder(w).Encode(dto.ArchivedJobResponse{
+				Job:        dto.JobDTO{JobID: "job-old", JobType: "send_email", Status: "COMPLETED"},
+				ArchivedAt: "2026-03-01T03:00:00Z",
+				Events: []dto.JobEventDTO{
+					{ID: 1, NewStatus: "PENDING", ActorType: "user", CreatedAt: "2026-01-01T00:00:00Z"},
+				},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/jobs/job-1":
+			_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: "job-1", Status: "RUNNING", Version: 4})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/jobs/job-1/cancel":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&canceled))
+			_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: "job-1", Status: "CANCELED", Version: canceled.Version + 1})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/jobs/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Job not found"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	env := map[string]string{"JOBCTL_SERVER": server.URL, "USER": "operator"}
+	getenv := func(key string) string { return env[key] }
+
+	t.Run("create reads the payload from a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "payload.json")
+		require.NoError(t, os.WriteFile(path, []byte("{\"to\": \"a@b.c\"}\n"), 0o600))
+
+		var out bytes.Buffer
+		err := run(context.Background(), []string{"create", "--type", "send_email", "--payload", "@" + path}, &out, getenv)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"to": "a@b.c"}`, created.Payload)
+		assert.Equal(t, "operator", created.UserID)
+		assert.NotEmpty(t, created.IdempotencyKey)
+		assert.Contains(t, out.String(), "job-1")
+	})
+
+	t.Run("list follows cursors with --all", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run(context.Background(), []string{"list", "--status", "FAILED", "--all", "-o", "json"}, &out, getenv)
+		require.NoError(t, err)
+
+		var jobs []dto.JobDTO
+		require.NoError(t, json.Unmarshal(out.Bytes(), &jobs))
+		require.Len(t, jobs, 2)
+		assert.Equal(t, "job-2", jobs[1].JobID)
+	})
+
+	t.Run("list renders a table", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, run(context.Background(), []string{"list"}, &out, getenv))
+		assert.Contains(t, out.String(), "JOB ID")
+		assert.Contains(t, out.String(), "job-1")
+		assert.NotContains(t, out.String(), "job-2")
+	})
+
+	t.Run("get looks up archived jobs", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, run(context.Background(), []string{"get", "--archived", "job-old"}, &out, getenv))
+		assert.Contains(t, out.String(), "job-old")
+		assert.Contains(t, out.String(), "Archived:")
+		assert.Contains(t, out.String(), "2026-01-01T00:00:00Z")
+	})
+
+	t.Run("cancel sends the current version", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, run(context.Background(), []string{"cancel", "job-1"}, &out, getenv))
+		assert.Equal(t, 4, canceled.Version)
+		assert.Contains(t, out.String(), "CANCELED")
+
+		require.NoError(t, run(context.Background(), []string{"cancel", "--version", "2", "job-1"}, &bytes.Buffer{}, getenv))
+		assert.Equal(t, 2, canceled.Version)
+	})
+
+	t.Run("api errors are returned", func(t *testing.T) {
+		err := run(context.Background(), []string{"get", "missing"}, &bytes.Buffer{}, getenv)
+		assert.EqualError(t, err, "job api returned 404: Job not found")
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		assert.Error(t, run(context.Background(), nil, &bytes.Buffer{}, getenv))
+		assert.Error(t, run(context.Background(), []string{"launch"}, &bytes.Buffer{}, getenv))
+		assert.Error(t, run(context.Background(), []string{"get"}, &bytes.Buffer{}, getenv))
+		assert.Error(t, run(context.Background(), []string{"create", "--type", "x", "--payload", "[1]"}, &bytes.Buffer{}, getenv))
+		assert.Error(t, run(context.Background(), []string{"list", "-o", "yaml"}, &bytes.Buffer{}, getenv))
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printJob writes a single job in the selected format
+func printJob(opts *options, job *dto.JobDTO) error {
+	if opts.output == formatJSON {
+		return writeJSON(opts.stdout, job)
+	}
+
+	tw := tabwriter.NewWriter(opts.stdout, 0, 0, 2, ' ', 0)
+	row := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+		}
+	}
+	row("Job ID", job.JobID)
+	row("Type", job.JobType)
+	row("Status", job.Status+healthFlags(job))
+	row("User", job.UserID)
+	row("Idempotency Key", job.IdempotencyKey)
+	if job.OrderingKey != nil {
+		row("Ordering Key", *job.OrderingKey)
+	}
+	row("Retries", fmt.Sprintf("%d", job.RetryCount))
+	row("Created", job.CreatedAt)
+	row("Updated", job.UpdatedAt)
+	if job.ErrorMessage != nil {
+		row("Error", *job.ErrorMessage)
+	}
+	row("Payload", job.Payload)
+	row("Metadata", string(job.Metadata))
+	row("Result", string(job.Result))
+	row("Result URL", job.ResultURL)
+	return tw.Flush()
+}
+
+// printArchivedJob writes an archived job and its status history in the selected format
+func printArchivedJob(opts *options, archived *dto.ArchivedJobResponse) error {
+	if opts.output == formatJSON {
+		return writeJSON(opts.stdout, archived)
+	}
+
+	if err := printJob(opts, &archived.Job); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(opts.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Archived:\t%s\n\nTIME\tFROM\tTO\tACTOR\tREASON\n", archived.ArchivedAt)
+	for _, event := range archived.Events {
+		from, actor, reason := "-", event.ActorType, ""
+		if event.OldStatus != nil {
+			from = *event.OldStatus
+		}
+		if event.Actor != nil {
+			actor += " " + *event.Actor
+		}
+		if event.Reason != nil {
+			reason = *event.Reason
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", event.CreatedAt, from, event.NewStatus, actor, reason)
+	}
+	return tw.Flush()
+}
+
+// printJobs writes a job listing in the selected format
+func printJobs(opts *options, jobs []dto.JobDTO) error {
+	if opts.output == formatJSON {
+		if jobs == nil {
+			jobs = []dto.JobDTO{}
+		}
+		return writeJSON(opts.stdout, jobs)
+	}
+
+	if len(jobs) == 0 {
+		_, err := fmt.Fprintln(opts.stdout, "No jobs found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(opts.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB ID\tTYPE\tSTATUS\tRETRIES\tCREATED\tUPDATED")
+	for i := range jobs {
+		job := &jobs[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			job.JobID, job.JobType, job.Status+healthFlags(job), job.RetryCount, job.CreatedAt, job.UpdatedAt)
+	}
+	return tw.Flush()
+}
+
+// healthFlags renders the computed health flags after the status, e.g. " (stuck)"
+func healthFlags(job *dto.JobDTO) string {
+	switch {
+	case job.Stuck:
+		return " (stuck)"
+	case job.Overdue:
+		return " (overdue)"
+	case job.RetryExhausted:
+		return " (retries exhausted)"
+	default:
+		return ""
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/client"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+)
+
+// generator submits jobs at a fixed rate and polls them until they finish
+type generator struct {
+	opts   *options
+	runID  string
+	client *client.Client
+	filler string
+	slots  chan struct{} // Bounds the API requests in flight
+
+	mu       sync.Mutex
+	inFlight map[string]time.Time // Submission time of each unfinished job
+	report   report
+}
+
+// syntheticPayload is the payload of every generated job
+type syntheticPayload struct {
+	RunID string `json:"run_id"`
+	Seq   int    `json:"seq"`
+	Data  string `json:"data"`
+}
+
+func newGenerator(opts *options, runID string, c *client.Client) *generator {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	filler := make([]byte, opts.payloadBytes)
+	for i := range filler {
+		filler[i] = letters[rand.IntN(len(letters))]
+	}
+
+	return &generator{
+		opts:     opts,
+		runID:    runID,
+		client:   c,
+		filler:   string(filler),
+		slots:    make(chan struct{}, opts.concurrency),
+		inFlight: make(map[string]time.Time),
+	}
+}
+
+// run submits jobs for the configured duration, waits up to the drain timeout for them
+// to finish and returns what was measured
+func (g *generator) run(ctx context.Context) *report {
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		g.poll(pollCtx)
+	}()
+
+	start := time.Now()
+	g.submitAll(ctx)
+	g.report.submitDuration = time.Since(start)
+
+	g.drain(ctx)
+	stopPolling()
+	<-polled
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.report.unfinished = len(g.inFlight)
+	return &g.report
+}
+
+// submitAll submits one job per tick until the duration is up or ctx is canceled, and
+// waits for the submissions to return
+func (g *generator) submitAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / g.opts.rps))
+	defer ticker.Stop()
+	end := time.NewTimer(g.opts.duration)
+	defer end.Stop()
+
+	for seq := 1; ; seq++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-end.C:
+			return
+		case <-ticker.C:
+		}
+
+		// A full pool means the API is not keeping up; skipping keeps the rate honest
+		select {
+		case g.slots <- struct{}{}:
+		default:
+			g.mu.Lock()
+			g.report.skipped++
+			g.mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(seq int) {
+			defer wg.Done()
+			defer func() { <-g.slots }()
+			g.submit(ctx, seq)
+		}(seq)
+	}
+}
+
+func (g *generator) submit(ctx context.Context, seq int) {
+	payload, err := json.Marshal(syntheticPayload{RunID: g.runID, Seq: seq, Data: g.filler})
+	if err != nil {
+		panic(err) // Only strings and ints, cannot fail
+	}
+
+	req := &dto.CreateJobRequest{
+		IdempotencyKey: fmt.Sprintf("loadgen-%s-%d", g.runID, seq),
+		UserID:         g.opts.userID,
+		JobType:        g.opts.jobType,
+		Payload:        string(payload),
+	}
+
+	start := time.Now()
+	job, err := g.client.CreateJob(ctx, req)
+	elapsed := time.Since(start)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() == nil {
+			g.report.createErrors++
+			g.report.lastCreateError = err.Error()
+		}
+		return
+	}
+
+	g.report.submitted++
+	g.report.createLatencies = append(g.report.createLatencies, elapsed)
+	g.inFlight[job.JobID] = start
+}
+
+// poll checks every unfinished job once per poll interval until ctx is canceled
+func (g *generator) poll(ctx context.Context) {
+	ticker := time.NewTicker(g.opts.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		g.mu.Lock()
+		jobIDs := make([]string, 0, len(g.inFlight))
+		for jobID := range g.inFlight {
+			jobIDs = append(jobIDs, jobID)
+		}
+		g.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, jobID := range jobIDs {
+			select {
+			case g.slots <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+
+			wg.Add(1)
+			go func(jobID string) {
+				defer wg.Done()
+				defer func() { <-g.slots }()
+				g.check(ctx, jobID)
+			}(jobID)
+		}
+		wg.Wait()
+	}
+}
+
+// check fetches a job and records it once it has finished
+func (g *generator) check(ctx context.Context, jobID string) {
+	job, err := g.client.GetJob(ctx, jobID)
+	observed := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err != nil {
+		if ctx.Err() == nil {
+			g.report.pollErrors++
+		}
+		return
+	}
+
+	submitted, ok := g.inFlight[jobID]
+	if !ok {
+		return
+	}
+
+	switch job.Status {
+	case domain.JobStatusCompleted:
+		g.report.completed++
+		g.report.latencies = append(g.report.latencies, observed.Sub(submitted))
+	case domain.JobStatusFailed:
+		g.report.failed++
+	case domain.JobStatusCanceled:
+		g.report.canceled++
+	default:
+		return
+	}
+	delete(g.inFlight, jobID)
+}
+
+// drain waits until every submitted job has finished, the drain timeout passes or ctx
+// is canceled
+func (g *generator) drain(ctx context.Context) {
+	deadline := time.NewTimer(g.opts.drainTimeout)
+	defer deadline.Stop()
+
+	for {
+		g.mu.Lock()
+		remaining := len(g.inFlight)
+		g.mu.Unlock()
+		if remaining == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-time.After(g.opts.pollInterval):
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Command loadgen submits synthetic jobs to the job API at a fixed rate and reports
+// how long they take to finish, for capacity planning of worker concurrency and
+// prefetch settings.
+//
+//	loadgen --rps 50 --duration 2m --type send_email --payload-bytes 1024
+//
+// Latency is measured from submission until a poll first sees the job COMPLETED, FAILED
+// or CANCELED, so its resolution is --poll-interval. The server comes from --server or
+// the LOADGEN_SERVER environment variable.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/client"
+	"github.com/google/uuid"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args, generates load until the run ends and writes the report to stdout.
+// Canceling ctx stops submitting and reports what was measured so far.
+func run(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	server := getenv("LOADGEN_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+
+	var opts options
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage: loadgen [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.server, "server", server, "Job API base URL (LOADGEN_SERVER)")
+	fs.Float64Var(&opts.rps, "rps", 10, "Jobs submitted per second")
+	fs.DurationVar(&opts.duration, "duration", 30*time.Second, "How long to submit jobs for")
+	fs.StringVar(&opts.jobType, "type", "loadgen", "Job type of the submitted jobs")
+	fs.IntVar(&opts.payloadBytes, "payload-bytes", 256, "Size of the random filler in each payload")
+	fs.StringVar(&opts.userID, "user", "", "User ID of the submitted jobs (defaults to loadgen-<run id>)")
+	fs.IntVar(&opts.concurrency, "concurrency", 50, "Maximum API requests in flight; submissions beyond it are skipped")
+	fs.DurationVar(&opts.pollInterval, "poll-interval", 500*time.Millisecond, "How often unfinished jobs are checked")
+	fs.DurationVar(&opts.drainTimeout, "drain-timeout", 2*time.Minute, "How long to wait for jobs to finish after submitting stops")
+	fs.DurationVar(&opts.timeout, "timeout", 10*time.Second, "Timeout for each API request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	runID := uuid.New().String()[:8]
+	if opts.userID == "" {
+		opts.userID = "loadgen-" + runID
+	}
+
+	fmt.Fprintf(stdout, "Run %s: %g jobs/s of %s for %s against %s\n", runID, opts.rps, opts.jobType, opts.duration, opts.server)
+
+	gen := newGenerator(&opts, runID, client.New(opts.server, &http.Client{Timeout: opts.timeout}))
+	report := gen.run(ctx)
+	return report.print(stdout)
+}
+
+// options holds the parsed flags
+type options struct {
+	server       string
+	rps          float64
+	duration     time.Duration
+	jobType      string
+	payloadBytes int
+	userID       string
+	concurrency  int
+	pollInterval time.Duration
+	drainTimeout time.Duration
+	timeout      time.Duration
+}
+
+func (o *options) validate() error {
+	switch {
+	case o.rps <= 0:
+		return fmt.Errorf("invalid --rps %g (must be positive)", o.rps)
+	case o.duration <= 0:
+		return fmt.Errorf("invalid --duration %s (must be positive)", o.duration)
+	case o.jobType == "":
+		return errors.New("--type must not be empty")
+	case o.payloadBytes < 0:
+		return fmt.Errorf("invalid --payload-bytes %d (must not be negative)", o.payloadBytes)
+	case o.concurrency <= 0:
+		return fmt.Errorf("invalid --concurrency %d (must be positive)", o.concurrency)
+	case o.pollInterval <= 0:
+		return fmt.Errorf("invalid --poll-interval %s (must be positive)", o.pollInterval)
+	case o.drainTimeout < 0:
+		return fmt.Errorf("invalid --drain-timeout %s (must not be negative)", o.drainTimeout)
+	}
+	return nil
+}
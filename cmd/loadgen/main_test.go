@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	// Jobs complete on their second poll, except every fifth, which fails
+	var mu sync.Mutex
+	polls := map[string]int{}
+	var created []dto.CreateJobRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/jobs":
+			var req dto.CreateJobRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			created = append(created, req)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: fmt.Sprintf("job-%d", len(created)), Status: "PENDING"})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/jobs/"):
+			jobID := strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/")
+			polls[jobID]++
+			status := "RUNNING"
+			if polls[jobID] > 1 {
+				status = "COMPLETED"
+				if strings.HasSuffix(jobID, "5") || strings.HasSuffix(jobID, "0") {
+					status = "FAILED"
+				}
+			}
+			_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: jobID, Status: status})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	getenv := func(key string) string {
+		if key == "LOADGEN_SERVER" {
+			return server.URL
+		}
+		return ""
+	}
+
+	var out bytes.Buffer
+	err := run(context.Background(), []string{
+		"--rps", "100", "--duration", "200ms", "--type", "send_email", "--payload-bytes", "32",
+		"--poll-interval", "10ms", "--drain-timeout", "5s",
+	}, &out, getenv)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, created)
+
+	var payload syntheticPayload
+	require.NoError(t, json.Unmarshal([]byte(created[0].Payload), &payload))
+	assert.Equal(t, 1, payload.Seq)
+	assert.Len(t, payload.Data, 32)
+	assert.Equal(t, "send_email", created[0].JobType)
+	assert.Equal(t, "loadgen-"+payload.RunID, created[0].UserID)
+
+	report := out.String()
+	assert.Regexp(t, fmt.Sprintf(`Submitted:\s+%d in`, len(created)), report)
+	assert.Regexp(t, `Unfinished:\s+0\n`, report)
+	assert.Contains(t, report, "end-to-end")
+	assert.NotContains(t, report, "Last create error")
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	getenv := func(string) string { return "" }
+	for _, args := range [][]string{
+		{"--rps", "0"},
+		{"--duration", "-1s"},
+		{"--type", ""},
+		{"--concurrency", "0"},
+		{"--poll-interval", "0s"},
+	} {
+		assert.Error(t, run(context.Background(), args, &bytes.Buffer{}, getenv), args)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var samples []time.Duration
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(samples, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(samples, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(samples, 100))
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 50))
+}
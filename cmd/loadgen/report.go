@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// report holds the counters and latencies of one run
+type report struct {
+	submitDuration time.Duration
+
+	submitted    int
+	skipped      int // Ticks dropped because --concurrency requests were already in flight
+	createErrors int
+	completed    int
+	failed       int
+	canceled     int
+	unfinished   int // Still not finished when the run ended
+	pollErrors   int
+
+	lastCreateError string
+
+	createLatencies []time.Duration // POST /api/v1/jobs round trips
+	latencies       []time.Duration // Submission to observed completion
+}
+
+// percentiles are the columns of the latency table
+var percentiles = []float64{50, 90, 95, 99, 100}
+
+func (r *report) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rate := 0.0
+	if r.submitDuration > 0 {
+		rate = float64(r.submitted) / r.submitDuration.Seconds()
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Submitted:\t%d in %s (%.1f jobs/s)\n", r.submitted, r.submitDuration.Round(time.Millisecond), rate)
+	fmt.Fprintf(tw, "Skipped:\t%d\n", r.skipped)
+	fmt.Fprintf(tw, "Create errors:\t%d\n", r.createErrors)
+	fmt.Fprintf(tw, "Completed:\t%d\n", r.completed)
+	fmt.Fprintf(tw, "Failed:\t%d\n", r.failed)
+	fmt.Fprintf(tw, "Canceled:\t%d\n", r.canceled)
+	fmt.Fprintf(tw, "Unfinished:\t%d\n", r.unfinished)
+	fmt.Fprintf(tw, "Poll errors:\t%d\n", r.pollErrors)
+	if r.lastCreateError != "" {
+		fmt.Fprintf(tw, "Last create error:\t%s\n", r.lastCreateError)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "LATENCY\tP50\tP90\tP95\tP99\tMAX")
+	latencyRow(tw, "create", r.createLatencies)
+	latencyRow(tw, "end-to-end", r.latencies)
+
+	return tw.Flush()
+}
+
+// latencyRow writes the percentiles of samples, or dashes when there are none
+func latencyRow(w io.Writer, name string, samples []time.Duration) {
+	fmt.Fprint(w, name)
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	for _, p := range percentiles {
+		if len(sorted) == 0 {
+			fmt.Fprint(w, "\t-")
+			continue
+		}
+		fmt.Fprintf(w, "\t%s", percentile(sorted, p).Round(time.Millisecond))
+	}
+	fmt.Fprintln(w)
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted, which must not be empty
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(float64(len(sorted))*p/100)) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
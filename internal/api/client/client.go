@@ -0,0 +1,212 @@
+// Package client is a Go client for the job API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+)
+
+// APIError is returned for non-2xx responses
+type APIError struct {
+	StatusCode int
+	Message    string // "error" field of the response body
+	Details    string // "details" field of the response body, if any
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("job api returned %d", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Details != "" {
+		msg += " (" + e.Details + ")"
+	}
+	return msg
+}
+
+// Client calls the job API at a base URL such as http://localhost:8080
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a Client. A nil httpClient uses http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// ListJobsParams filters and paginates ListJobs. Zero values are omitted.
+type ListJobsParams struct {
+	UserID        string
+	JobType       string
+	Statuses      []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Payload       string // JSON object the payload must contain
+	Query         string // Full-text search over error_message
+	Sort          string // e.g. updated_at_asc
+	PageSize      int
+	Cursor        string
+}
+
+// CreateJob submits a job
+func (c *Client) CreateJob(ctx context.Context, req *dto.CreateJobRequest) (*dto.JobDTO, error) {
+	var job dto.JobDTO
+	if err := c.do(ctx, http.MethodPost, "/api/v1/jobs", nil, req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob fetches a job by ID
+func (c *Client) GetJob(ctx context.Context, jobID string) (*dto.JobDTO, error) {
+	var job dto.JobDTO
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs fetches one page of jobs; pass the returned NextCursor to get the next one
+func (c *Client) ListJobs(ctx context.Context, params ListJobsParams) (*dto.ListJobsResponse, error) {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("user_id", params.UserID)
+	set("job_type", params.JobType)
+	set("status", strings.Join(params.Statuses, ","))
+	set("payload", params.Payload)
+	set("q", params.Query)
+	set("sort", params.Sort)
+	set("cursor", params.Cursor)
+	if !params.CreatedAfter.IsZero() {
+		query.Set("created_after", params.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if !params.CreatedBefore.IsZero() {
+		query.Set("created_before", params.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	if params.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(params.PageSize))
+	}
+
+	var resp dto.ListJobsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelJob cancels a waiting, pending or running job, provided it is still at version
+func (c *Client) CancelJob(ctx context.Context, jobID string, version int) (*dto.JobDTO, error) {
+	var job dto.JobDTO
+	req := dto.CancelJobRequest{Version: version}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/jobs/"+url.PathEscape(jobID)+"/cancel", nil, &req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RetryJob re-queues a failed or canceled job
+func (c *Client) RetryJob(ctx context.Context, jobID string, resetRetryCount bool) (*dto.JobDTO, error) {
+	var job dto.JobDTO
+	req := dto.RetryJobRequest{ResetRetryCount: resetRetryCount}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/jobs/"+url.PathEscape(jobID)+"/retry", nil, &req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetArchivedJob fetches a purged job and its status history from the archive
+func (c *Client) GetArchivedJob(ctx context.Context, jobID string) (*dto.ArchivedJobResponse, error) {
+	var archived dto.ArchivedJobResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/archive/jobs/"+url.PathEscape(jobID), nil, nil, &archived); err != nil {
+		return nil, err
+	}
+	return &archived, nil
+}
+
+// do sends a JSON request and decodes a 2xx JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeAPIError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// decodeAPIError builds an APIError from an error response body
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Error   string          `json:"error"`
+		Details json.RawMessage `json:"details"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(raw, &body); err != nil {
+		apiErr.Message = strings.TrimSpace(string(raw))
+		return apiErr
+	}
+
+	apiErr.Message = body.Error
+	// details is usually a string but may be any JSON value
+	if len(body.Details) > 0 && string(body.Details) != "null" {
+		var details string
+		if json.Unmarshal(body.Details, &details) != nil {
+			details = string(body.Details)
+		}
+		apiErr.Details = details
+	}
+	return apiErr
+}
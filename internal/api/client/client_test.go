@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateJob(t *testing.T) {
+	var got dto.CreateJobRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/jobs", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: "job-1", JobType: got.JobType, Status: "PENDING"})
+	}))
+	defer server.Close()
+
+	job, err := New(server.URL+"/", nil).CreateJob(context.Background(), &dto.CreateJobRequest{
+		IdempotencyKey: "key-1",
+		UserID:         "user-1",
+		JobType:        "send_email",
+		Payload:        `{"to":"a@b.c"}`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.JobID)
+	assert.Equal(t, "send_email", got.JobType)
+	assert.Equal(t, `{"to":"a@b.c"}`, got.Payload)
+}
+
+func TestClient_ListJobs(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_ = json.NewEncoder(w).Encode(dto.ListJobsResponse{Jobs: []dto.JobDTO{{JobID: "job-1"}}, NextCursor: "next"})
+	}))
+	defer server.Close()
+
+	resp, err := New(server.URL, nil).ListJobs(context.Background(), ListJobsParams{
+		Statuses:     []string{"FAILED", "CANCELED"},
+		CreatedAfter: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		Sort:         "updated_at_asc",
+		PageSize:     50,
+		Cursor:       "abc",
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Jobs, 1)
+	assert.Equal(t, "next", resp.NextCursor)
+
+	assert.Equal(t, map[string][]string{
+		"status":        {"FAILED,CANCELED"},
+		"created_after": {"2025-12-01T00:00:00Z"},
+		"sort":          {"updated_at_asc"},
+		"page_size":     {"50"},
+		"cursor":        {"abc"},
+	}, query)
+}
+
+func TestClient_RetryJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/jobs/job-1/retry", r.URL.Path)
+
+		var req dto.RetryJobRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.ResetRetryCount)
+
+		_ = json.NewEncoder(w).Encode(dto.JobDTO{JobID: "job-1", Status: "PENDING"})
+	}))
+	defer server.Close()
+
+	job, err := New(server.URL, nil).RetryJob(context.Background(), "job-1", true)
+	require.NoError(t, err)
+	assert.Equal(t, "PENDING", job.Status)
+}
+
+func TestClient_GetArchivedJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/archive/jobs/job-1", r.URL.Path)
+
+		_ = json.NewEncoder(w).Encode(dto.ArchivedJobResponse{
+			Job:        dto.JobDTO{JobID: "job-1", Status: "COMPLETED"},
+			ArchivedAt: "2026-03-01T03:00:00Z",
+		})
+	}))
+	defer server.Close()
+
+	archived, err := New(server.URL, nil).GetArchivedJob(context.Background(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, "COMPLETED", archived.Job.Status)
+	assert.Equal(t, "2026-03-01T03:00:00Z", archived.ArchivedAt)
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr APIError
+	}{
+		{
+			name:    "error with string details",
+			status:  http.StatusBadRequest,
+			body:    `{"error":"Invalid request body","details":"job_type is required"}`,
+			wantErr: APIError{StatusCode: 400, Message: "Invalid request body", Details: "job_type is required"},
+		},
+		{
+			name:    "error without details",
+			status:  http.StatusNotFound,
+			body:    `{"error":"Job not found"}`,
+			wantErr: APIError{StatusCode: 404, Message: "Job not found"},
+		},
+		{
+			name:    "non-JSON body",
+			status:  http.StatusBadGateway,
+			body:    "bad gateway\n",
+			wantErr: APIError{StatusCode: 502, Message: "bad gateway"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := New(server.URL, nil).GetJob(context.Background(), "job-1")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.wantErr, *apiErr)
+		})
+	}
+}
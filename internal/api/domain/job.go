@@ -2,16 +2,48 @@ package domain
 
 import (
 	"errors"
+
+	"github.com/cuongbtq/practice-be/shared/jobstatus"
+)
+
+// JobStatus is the status of a job. The statuses and the changes allowed between them
+// are defined in shared/jobstatus, which the workers use too.
+type JobStatus = jobstatus.Status
+
+// Job statuses as stored in the status column
+const (
+	// JobStatusWaiting jobs are held until every job they depend on has completed
+	JobStatusWaiting   = string(jobstatus.Waiting)
+	JobStatusPending   = string(jobstatus.Pending)
+	JobStatusRunning   = string(jobstatus.Running)
+	JobStatusCompleted = string(jobstatus.Completed)
+	JobStatusFailed    = string(jobstatus.Failed)
+	JobStatusCanceled  = string(jobstatus.Canceled)
 )
 
+// Actor types of job events
 const (
-	JobStatusPending   = "PENDING"
-	JobStatusRunning   = "RUNNING"
-	JobStatusCompleted = "COMPLETED"
-	JobStatusFailed    = "FAILED"
-	JobStatusCanceled  = "CANCELED"
+	// ActorUser events come from API requests; the actor is the user ID when the request has one
+	ActorUser = "user"
+	// ActorWorker events come from workers; the actor is the worker ID
+	ActorWorker = "worker"
+	// ActorSystem events come from the API's background tasks; the actor names the task
+	ActorSystem = "system"
 )
 
 var (
-	ErrJobNotFound = errors.New("job not found")
+	ErrJobNotFound     = errors.New("job not found")
+	ErrJobNotRetryable = errors.New("job is not in a retryable state")
+	// ErrVersionConflict means the job was updated after the caller read the version it passed
+	ErrVersionConflict = errors.New("job was updated concurrently")
+	// ErrInvalidTransition means the job's status cannot change to the requested one
+	ErrInvalidTransition = jobstatus.ErrInvalidTransition
+	// ErrJobNotDeletable means the job is still active; only terminal jobs can be deleted
+	ErrJobNotDeletable = errors.New("job is not in a terminal state")
+	// ErrIdempotencyConflict means a job with the same idempotency key already exists
+	ErrIdempotencyConflict = errors.New("job with this idempotency key already exists")
+	// ErrDependencyNotFound means a job listed in depends_on does not exist
+	ErrDependencyNotFound = errors.New("dependency job not found")
+	// ErrWorkflowNotFound means no workflow has the requested ID
+	ErrWorkflowNotFound = errors.New("workflow not found")
 )
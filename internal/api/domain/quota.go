@@ -0,0 +1,6 @@
+package domain
+
+import "errors"
+
+// ErrQuotaNotFound means no quota is stored for the requested tenant or user
+var ErrQuotaNotFound = errors.New("quota not found")
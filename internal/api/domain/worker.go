@@ -0,0 +1,7 @@
+package domain
+
+const (
+	// WorkerStatusLive workers have sent a heartbeat within the worker timeout
+	WorkerStatusLive = "LIVE"
+	WorkerStatusDead = "DEAD"
+)
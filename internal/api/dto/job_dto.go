@@ -1,32 +1,346 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cuongbtq/practice-be/shared/messaging"
+)
+
 type CreateJobRequest struct {
-	IdempotencyKey string `json:"idempotency_key" binding:"required"`
-	UserID         string `json:"user_id" binding:"required"`
-	JobType        string `json:"job_type" binding:"required"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required,max=255"`
+	UserID         string `json:"user_id" binding:"required,max=100"`
+	JobType        string `json:"job_type" binding:"required,max=50"`
 	Payload        string `json:"payload" binding:"required"`
+	// Metadata is an optional caller-defined JSON object returned unchanged with the job
+	Metadata json.RawMessage `json:"metadata"`
+	// OrderingKey makes jobs with the same key dispatch in submission order
+	OrderingKey string `json:"ordering_key" binding:"omitempty,max=255"`
+	// DependsOn holds the job WAITING until every listed job has completed
+	DependsOn []string `json:"depends_on" binding:"omitempty,max=100,dive,uuid"`
+	// ExecuteAfter is the earliest time workers should run the job, RFC 3339
+	ExecuteAfter *time.Time `json:"execute_after"`
 }
 
 type ListJobsRequest struct {
 	UserID   string `form:"user_id"`
 	JobType  string `form:"job_type"`
-	Status   string `form:"status"`
+	Status   string `form:"status"` // Comma-separated, e.g. FAILED,CANCELED
 	PageSize int    `form:"page_size"`
 	Cursor   string `form:"cursor"`
+	// CreatedAfter and CreatedBefore bound created_at as [after, before), RFC 3339
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Payload is a JSON object the job payload must contain
+	Payload string `form:"payload"`
+	// Query is full-text searched in error_message
+	Query string `form:"q"`
+	// Sort is created_at, updated_at, status or job_type with an optional _asc or _desc suffix
+	Sort string `form:"sort"`
+	// Pagination selects cursor (default) or offset pagination with page numbers
+	Pagination string `form:"pagination"`
+	Page       int    `form:"page"` // 1-based, offset pagination only
+}
+
+// ExportJobsRequest takes the ListJobs filters; every matching job is exported
+type ExportJobsRequest struct {
+	UserID        string    `form:"user_id"`
+	JobType       string    `form:"job_type"`
+	Status        string    `form:"status"`
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Payload       string    `form:"payload"`
+	Query         string    `form:"q"`
+	Sort          string    `form:"sort"` // Defaults to created_at_asc
+	// After resumes an interrupted export after the job with this ID
+	After string `form:"after"`
 }
 
 type ListJobsResponse struct {
 	Jobs       []JobDTO `json:"jobs"`
 	NextCursor string   `json:"next_cursor,omitempty"`
+	// HasMore is true when another page follows this one
+	HasMore bool `json:"has_more"`
+	// Count is the number of jobs in this page
+	Count int `json:"count"`
+	// Filters echoes the filters, sort and page size the page was listed with
+	Filters ListJobsFilters `json:"filters"`
+	// Page, TotalCount and TotalPages are set in offset pagination mode
+	Page       int    `json:"page,omitempty"`
+	TotalCount *int64 `json:"total_count,omitempty"`
+	TotalPages *int64 `json:"total_pages,omitempty"`
+}
+
+// ListJobsFilters is the normalized form of the ListJobs query parameters
+type ListJobsFilters struct {
+	UserID        string          `json:"user_id,omitempty"`
+	JobType       string          `json:"job_type,omitempty"`
+	Statuses      []string        `json:"status,omitempty"`
+	CreatedAfter  *string         `json:"created_after,omitempty"`
+	CreatedBefore *string         `json:"created_before,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Query         string          `json:"q,omitempty"`
+	Sort          string          `json:"sort"` // e.g. created_at_desc
+	PageSize      int             `json:"page_size"`
+	Pagination    string          `json:"pagination"` // cursor or offset
+}
+
+// FieldError describes one request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`   // JSON path, e.g. steps[0].job_type
+	Rule    string `json:"rule"`    // Failed rule, e.g. required, max or uuid
+	Message string `json:"message"` // Human-readable explanation
+}
+
+type RetryJobRequest struct {
+	ResetRetryCount bool `json:"reset_retry_count"`
+}
+
+// CancelJobRequest carries the version of the job the client last read, so a cancel
+// never overwrites an update it has not seen
+type CancelJobRequest struct {
+	Version int `json:"version" binding:"required,min=1"`
 }
 
 type JobDTO struct {
-	JobID          string `json:"job_id"`
-	IdempotencyKey string `json:"idempotency_key"`
-	UserID         string `json:"user_id"`
-	JobType        string `json:"job_type"`
-	Payload        string `json:"payload"`
-	Status         string `json:"status"`
-	CreatedAt      string `json:"created_at"`
-	UpdatedAt      string `json:"updated_at"`
+	JobID          string          `json:"job_id"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	UserID         string          `json:"user_id"`
+	JobType        string          `json:"job_type"`
+	Payload        string          `json:"payload"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	OrderingKey    *string         `json:"ordering_key,omitempty"`
+	ExecuteAfter   *string         `json:"execute_after,omitempty"`
+	DependsOn      []string        `json:"depends_on,omitempty"`
+	WorkflowID     *string         `json:"workflow_id,omitempty"`
+	StepName       *string         `json:"step_name,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	ResultURL      string          `json:"result_url,omitempty"` // Presigned download URL for an offloaded result
+	Status         string          `json:"status"`
+	ErrorMessage   *string         `json:"error_message"`
+	RetryCount     int             `json:"retry_count"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+	// Version changes with every update; CancelJob requires the version the client last read
+	Version int `json:"version"`
+	// Health flags computed when the job is read
+	Stuck          bool `json:"stuck"`           // RUNNING without a recent heartbeat
+	Overdue        bool `json:"overdue"`         // PENDING for longer than the SLA
+	RetryExhausted bool `json:"retry_exhausted"` // FAILED with no retries left
+}
+
+// JobMessage is the message body published to the broker for a job
+type JobMessage = messaging.JobMessage
+
+// JobLifecycleEvent is the message body published to the events exchange for each job
+// status transition
+type JobLifecycleEvent struct {
+	Event      string  `json:"event"`    // job.created, job.started, job.completed, ...
+	EventID    int64   `json:"event_id"` // Increases with every event; consumers can drop duplicates by it
+	JobID      string  `json:"job_id"`
+	JobType    string  `json:"job_type"`
+	TenantID   string  `json:"tenant_id"`
+	UserID     string  `json:"user_id,omitempty"`
+	OldStatus  *string `json:"old_status"` // null for job.created
+	Status     string  `json:"status"`
+	ActorType  string  `json:"actor_type"`
+	Actor      *string `json:"actor,omitempty"`
+	Reason     *string `json:"reason,omitempty"`
+	OccurredAt string  `json:"occurred_at"`
+	// Metadata is the job's client-supplied metadata, omitted when it has none
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// WorkflowContext passes the results of earlier workflow steps to the next ones
+type WorkflowContext = messaging.WorkflowContext
+
+// JobTypeResponse describes what a job type accepts and returns
+type JobTypeResponse struct {
+	JobType     string `json:"job_type"`
+	Description string `json:"description,omitempty"`
+	// TimeoutSeconds bounds one execution of a job of this type
+	TimeoutSeconds int `json:"timeout_seconds"`
+	MaxRetries     int `json:"max_retries"`
+	// Enabled is false when new jobs of this type are rejected
+	Enabled bool `json:"enabled"`
+	// PayloadSchema is the JSON Schema job payloads must match, omitted when payloads are free-form
+	PayloadSchema json.RawMessage `json:"payload_schema,omitempty"`
+	// ResultSchema is the JSON Schema of the result returned in GetJob, omitted when results are free-form
+	ResultSchema json.RawMessage `json:"result_schema,omitempty"`
+}
+
+// ListJobTypesResponse lists the known job types, sorted by name
+type ListJobTypesResponse struct {
+	JobTypes []JobTypeResponse `json:"job_types"`
+}
+
+type JobTypeEstimateResponse struct {
+	JobType            string  `json:"job_type"`
+	HistoryWindow      string  `json:"history_window"`
+	SampleSize         int64   `json:"sample_size"`
+	P50DurationSeconds float64 `json:"p50_duration_seconds"`
+	P95DurationSeconds float64 `json:"p95_duration_seconds"`
+	QueueBacklog       int64   `json:"queue_backlog"`
+	WorkerCapacity     int     `json:"worker_capacity"`
+	// ExpectedQueueWaitSeconds is null when worker capacity is not configured
+	ExpectedQueueWaitSeconds *float64 `json:"expected_queue_wait_seconds"`
+}
+
+// JobExportSchemaVersion is the version of the JobExport document format
+const JobExportSchemaVersion = 1
+
+// JobExport is a self-contained job definition that can be imported into another environment
+type JobExport struct {
+	SchemaVersion int             `json:"schema_version"`
+	ExportedAt    string          `json:"exported_at"`
+	SourceJobID   string          `json:"source_job_id"`
+	UserID        string          `json:"user_id"`
+	JobType       string          `json:"job_type"`
+	Payload       json.RawMessage `json:"payload"`
+	// TypeConfigVersion and ExecutorVersion are null until job types and executors are versioned
+	TypeConfigVersion *string           `json:"type_config_version"`
+	ExecutorVersion   *string           `json:"executor_version"`
+	Environment       ExportEnvironment `json:"environment"`
+}
+
+// ExportEnvironment identifies the deployment a job was exported from
+type ExportEnvironment struct {
+	Service     string `json:"service"`
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// JobEventDTO is one status transition of a job
+type JobEventDTO struct {
+	ID        int64   `json:"id"`
+	OldStatus *string `json:"old_status"` // null for the event that created the job
+	NewStatus string  `json:"new_status"`
+	ActorType string  `json:"actor_type"` // user, worker or system
+	Actor     *string `json:"actor,omitempty"`
+	Reason    *string `json:"reason,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// ListJobEventsResponse is the status history of a job, oldest first
+type ListJobEventsResponse struct {
+	JobID  string        `json:"job_id"`
+	Events []JobEventDTO `json:"events"`
+}
+
+// JobLogDTO is one entry an executor logged while running a job
+type JobLogDTO struct {
+	ID       int64           `json:"id"`
+	Attempt  int             `json:"attempt"`
+	WorkerID *string         `json:"worker_id,omitempty"`
+	Level    string          `json:"level"`
+	Message  string          `json:"message"`
+	Attrs    json.RawMessage `json:"attrs,omitempty"`
+	LoggedAt string          `json:"logged_at"`
+}
+
+// ListJobLogsResponse is the execution log of a job, oldest first
+type ListJobLogsResponse struct {
+	JobID string      `json:"job_id"`
+	Logs  []JobLogDTO `json:"logs"`
+}
+
+// ArchivedJobResponse is a purged job looked up in the archive, with its status history
+type ArchivedJobResponse struct {
+	Job        JobDTO        `json:"job"`
+	ArchivedAt string        `json:"archived_at"`
+	Events     []JobEventDTO `json:"events"`
+}
+
+// LogLevelRequest changes the service log level
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse reports the current service log level
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message" binding:"max=500"`
+}
+
+// MaintenanceResponse reports whether the service is in maintenance mode
+type MaintenanceResponse struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	Source  string `json:"source,omitempty"` // config or admin
+}
+
+// WorkerDTO is a registered worker with the jobs it is running
+type WorkerDTO struct {
+	WorkerID        string   `json:"worker_id"`
+	Hostname        string   `json:"hostname,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	Concurrency     int      `json:"concurrency"`
+	Status          string   `json:"status"`
+	StartedAt       string   `json:"started_at"`
+	LastHeartbeatAt string   `json:"last_heartbeat_at"`
+	JobIDs          []string `json:"job_ids"`
+}
+
+// ListWorkersResponse lists the registered workers
+type ListWorkersResponse struct {
+	Workers []WorkerDTO `json:"workers"`
+}
+
+// CreateWorkflowRequest submits several jobs as the steps of one workflow
+type CreateWorkflowRequest struct {
+	IdempotencyKey string                `json:"idempotency_key" binding:"required,max=255"`
+	UserID         string                `json:"user_id" binding:"required,max=100"`
+	Name           string                `json:"name" binding:"omitempty,max=100"`
+	Steps          []WorkflowStepRequest `json:"steps" binding:"required,min=1,max=100,dive"`
+}
+
+// WorkflowStepRequest is one step of a workflow
+type WorkflowStepRequest struct {
+	Name     string          `json:"name" binding:"required,max=100"`
+	JobType  string          `json:"job_type" binding:"required,max=50"`
+	Payload  string          `json:"payload" binding:"required"`
+	Metadata json.RawMessage `json:"metadata"`
+	// DependsOn names the steps that must complete first. Omitted means the previous
+	// step, so a plain list runs in order; an empty list means no dependencies.
+	DependsOn []string `json:"depends_on"`
+}
+
+// WorkflowDTO is a workflow with its steps and their aggregated status
+type WorkflowDTO struct {
+	WorkflowID     string   `json:"workflow_id"`
+	IdempotencyKey string   `json:"idempotency_key"`
+	UserID         string   `json:"user_id"`
+	Name           string   `json:"name,omitempty"`
+	Status         string   `json:"status"`
+	Steps          []JobDTO `json:"steps"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+// QuotaRequest sets the limits of a tenant or user quota, 0 leaves a limit unset
+type QuotaRequest struct {
+	MaxJobsPerDay   int64 `json:"max_jobs_per_day" binding:"min=0"`
+	MaxRunningJobs  int64 `json:"max_running_jobs" binding:"min=0"`
+	MaxPayloadBytes int64 `json:"max_payload_bytes" binding:"min=0"`
+}
+
+// QuotaDTO is a stored quota of a tenant, or of one user within it
+type QuotaDTO struct {
+	TenantID        string `json:"tenant_id"`
+	UserID          string `json:"user_id,omitempty"`
+	MaxJobsPerDay   int64  `json:"max_jobs_per_day"`
+	MaxRunningJobs  int64  `json:"max_running_jobs"`
+	MaxPayloadBytes int64  `json:"max_payload_bytes"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// ListQuotasResponse lists stored quotas
+type ListQuotasResponse struct {
+	Quotas []QuotaDTO `json:"quotas"`
 }
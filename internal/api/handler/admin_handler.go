@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles operational endpoints under /admin
+type AdminHandler struct {
+	logger        *slog.Logger
+	logLevel      LogLevelController
+	maintenance   *MaintenanceMode
+	storage       storage.JobStorage
+	workerTimeout time.Duration
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(deps *Dependencies) *AdminHandler {
+	jobStorage := deps.JobStorage
+	if jobStorage == nil {
+		jobStorage = storage.NewStorage(deps.DBClient)
+	}
+
+	workerTimeout := deps.JobHealth.WorkerTimeout
+	if workerTimeout <= 0 {
+		workerTimeout = time.Minute
+	}
+
+	return &AdminHandler{
+		logger:        deps.Logger,
+		logLevel:      deps.LogLevel,
+		maintenance:   deps.Maintenance,
+		storage:       jobStorage,
+		workerTimeout: workerTimeout,
+	}
+}
+
+// GetLogLevel handles GET /admin/log-level
+// Returns the current log level
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevelResponse{
+		Level: h.logLevel.Level(),
+	})
+}
+
+// SetLogLevel handles PUT /admin/log-level
+// Changes the log level until the next restart or config reload
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req dto.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	previous := h.logLevel.Level()
+	if err := h.logLevel.SetLevel(req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid log level",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Logged at warn so the change is recorded whatever the new level is
+	h.logger.Warn("Log level changed",
+		slog.String("from", previous),
+		slog.String("to", h.logLevel.Level()),
+		slog.String("ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, dto.LogLevelResponse{
+		Level: h.logLevel.Level(),
+	})
+}
+
+// GetMaintenance handles GET /admin/maintenance
+// Returns whether the API is in maintenance mode
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, toMaintenanceResponse(h.maintenance.Status()))
+}
+
+// SetMaintenance handles PUT /admin/maintenance
+// Turns maintenance mode on or off for every API instance and worker
+func (h *AdminHandler) SetMaintenance(c *gin.Context) {
+	var req dto.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	maintenance, err := h.storage.SetMaintenance(c.Request.Context(), *req.Enabled, req.Message)
+	if err != nil {
+		if storage.IsUnavailable(err) {
+			h.logger.Error("Database unavailable", slog.String("error", err.Error()))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Database unavailable, retry later",
+			})
+			return
+		}
+
+		h.logger.Error("Failed to set maintenance mode", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to set maintenance mode",
+		})
+		return
+	}
+
+	// Other instances pick the change up on their next poll
+	h.maintenance.setStored(maintenance.Enabled, maintenance.Message)
+	h.logger.Warn("Maintenance mode set",
+		slog.Bool("enabled", maintenance.Enabled),
+		slog.String("ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, toMaintenanceResponse(h.maintenance.Status()))
+}
+
+// toMaintenanceResponse converts a maintenance status into its API representation
+func toMaintenanceResponse(status MaintenanceStatus) dto.MaintenanceResponse {
+	return dto.MaintenanceResponse{
+		Enabled: status.Enabled,
+		Message: status.Message,
+		Source:  status.Source,
+	}
+}
+
+// ListWorkers handles GET /admin/workers
+// Lists registered workers as LIVE or DEAD by heartbeat age, with the jobs each is running
+func (h *AdminHandler) ListWorkers(c *gin.Context) {
+	workers, err := h.storage.ListWorkers(c.Request.Context())
+	if err != nil {
+		if storage.IsUnavailable(err) {
+			h.logger.Error("Database unavailable", slog.String("error", err.Error()))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Database unavailable, retry later",
+			})
+			return
+		}
+
+		h.logger.Error("Failed to list workers", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list workers",
+		})
+		return
+	}
+
+	now := time.Now()
+	resp := dto.ListWorkersResponse{
+		Workers: make([]dto.WorkerDTO, 0, len(workers)),
+	}
+	for i := range workers {
+		resp.Workers = append(resp.Workers, h.toWorkerDTO(&workers[i], now))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// toWorkerDTO converts a worker into its API representation as of now
+func (h *AdminHandler) toWorkerDTO(worker *model.Worker, now time.Time) dto.WorkerDTO {
+	status := domain.WorkerStatusLive
+	if now.Sub(worker.LastHeartbeatAt) > h.workerTimeout {
+		status = domain.WorkerStatusDead
+	}
+
+	jobIDs := []string(worker.JobIDs)
+	if jobIDs == nil {
+		jobIDs = []string{}
+	}
+
+	return dto.WorkerDTO{
+		WorkerID:        worker.WorkerID,
+		Hostname:        worker.Hostname,
+		Version:         worker.Version,
+		Concurrency:     worker.Concurrency,
+		Status:          status,
+		StartedAt:       worker.StartedAt.Format(time.RFC3339),
+		LastHeartbeatAt: worker.LastHeartbeatAt.Format(time.RFC3339),
+		JobIDs:          jobIDs,
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogLevel is an in-memory LogLevelController
+type fakeLogLevel struct {
+	level string
+}
+
+func (f *fakeLogLevel) Level() string { return f.level }
+
+func (f *fakeLogLevel) SetLevel(level string) error {
+	switch level {
+	case "debug", "info", "warn", "error":
+		f.level = level
+		return nil
+	default:
+		return fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+	levels := &fakeLogLevel{level: "info"}
+	h := NewAdminHandler(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		LogLevel:   levels,
+		JobStorage: &mocks.JobStorage{},
+	})
+
+	r := gin.New()
+	r.GET("/admin/log-level", h.GetLogLevel)
+	r.PUT("/admin/log-level", h.SetLogLevel)
+
+	w := doRequest(r, http.MethodGet, "/admin/log-level", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	w = doRequest(r, http.MethodPut, "/admin/log-level", `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp dto.LogLevelResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "debug", resp.Level)
+	assert.Equal(t, "debug", levels.level)
+
+	w = doRequest(r, http.MethodPut, "/admin/log-level", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "debug", levels.level)
+
+	w = doRequest(r, http.MethodPut, "/admin/log-level", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminHandler_ListWorkers(t *testing.T) {
+	newRouter := func(store *mocks.JobStorage) *gin.Engine {
+		h := NewAdminHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			JobHealth:  JobHealthOptions{WorkerTimeout: 30 * time.Second},
+		})
+
+		r := gin.New()
+		r.GET("/admin/workers", h.ListWorkers)
+		return r
+	}
+
+	t.Run("marks workers without a recent heartbeat as dead", func(t *testing.T) {
+		now := time.Now().UTC()
+		store := &mocks.JobStorage{
+			ListWorkersFunc: func(context.Context) ([]model.Worker, error) {
+				return []model.Worker{
+					{WorkerID: "worker-1", Hostname: "host-a", Version: "1.2.0", Concurrency: 4, StartedAt: now.Add(-time.Hour), LastHeartbeatAt: now.Add(-5 * time.Second), JobIDs: []string{"job-1", "job-2"}},
+					{WorkerID: "worker-2", Hostname: "host-b", Concurrency: 2, StartedAt: now.Add(-2 * time.Hour), LastHeartbeatAt: now.Add(-time.Minute)},
+				}, nil
+			},
+		}
+
+		w := doRequest(newRouter(store), http.MethodGet, "/admin/workers", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListWorkersResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Workers, 2)
+		assert.Equal(t, domain.WorkerStatusLive, resp.Workers[0].Status)
+		assert.Equal(t, []string{"job-1", "job-2"}, resp.Workers[0].JobIDs)
+		assert.Equal(t, 4, resp.Workers[0].Concurrency)
+		assert.Equal(t, domain.WorkerStatusDead, resp.Workers[1].Status)
+		assert.Equal(t, []string{}, resp.Workers[1].JobIDs)
+	})
+
+	t.Run("no workers", func(t *testing.T) {
+		w := doRequest(newRouter(&mocks.JobStorage{}), http.MethodGet, "/admin/workers", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"workers":[]}`, w.Body.String())
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListWorkersFunc: func(context.Context) ([]model.Worker, error) {
+				return nil, errors.New("boom")
+			},
+		}
+
+		w := doRequest(newRouter(store), http.MethodGet, "/admin/workers", "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQuotaUserIDLength matches the user_id limit of jobs
+const maxQuotaUserIDLength = 100
+
+// ListQuotas handles GET /admin/quotas
+// Lists the stored quotas, of one tenant when tenant_id is given
+func (h *AdminHandler) ListQuotas(c *gin.Context) {
+	tenantID := c.Query("tenant_id")
+	if tenantID != "" && !tenant.Valid(tenantID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tenant ID",
+		})
+		return
+	}
+
+	quotas, err := h.storage.ListQuotas(c.Request.Context(), tenantID)
+	if err != nil {
+		h.respondQuotaStorageError(c, "Failed to list quotas", err)
+		return
+	}
+
+	resp := dto.ListQuotasResponse{
+		Quotas: make([]dto.QuotaDTO, 0, len(quotas)),
+	}
+	for i := range quotas {
+		resp.Quotas = append(resp.Quotas, toQuotaDTO(&quotas[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PutQuota handles PUT /admin/quotas/:tenant_id and PUT /admin/quotas/:tenant_id/users/:user_id
+// Creates or replaces the quota of a tenant, or of one user within it
+func (h *AdminHandler) PutQuota(c *gin.Context) {
+	// 1. Validate the tenant and user
+	tenantID, userID, ok := quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	// 2. Bind the limits
+	var req dto.QuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	// 3. Store the quota
+	quota := model.Quota{
+		TenantID:        tenantID,
+		UserID:          userID,
+		MaxJobsPerDay:   req.MaxJobsPerDay,
+		MaxRunningJobs:  req.MaxRunningJobs,
+		MaxPayloadBytes: req.MaxPayloadBytes,
+	}
+	if err := h.storage.UpsertQuota(c.Request.Context(), &quota); err != nil {
+		h.respondQuotaStorageError(c, "Failed to save quota", err)
+		return
+	}
+
+	// Logged at warn, like log level changes, so limit changes are on record
+	h.logger.Warn("Quota set",
+		slog.String("tenant_id", tenantID),
+		slog.String("user_id", userID),
+		slog.Int64("max_jobs_per_day", quota.MaxJobsPerDay),
+		slog.Int64("max_running_jobs", quota.MaxRunningJobs),
+		slog.Int64("max_payload_bytes", quota.MaxPayloadBytes),
+		slog.String("ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, toQuotaDTO(&quota))
+}
+
+// DeleteQuota handles DELETE /admin/quotas/:tenant_id and DELETE /admin/quotas/:tenant_id/users/:user_id
+// Removes the quota of a tenant or user; the tenancy config quotas still apply
+func (h *AdminHandler) DeleteQuota(c *gin.Context) {
+	tenantID, userID, ok := quotaSubject(c)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteQuota(c.Request.Context(), tenantID, userID); err != nil {
+		if errors.Is(err, domain.ErrQuotaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Quota not found",
+			})
+			return
+		}
+
+		h.respondQuotaStorageError(c, "Failed to delete quota", err)
+		return
+	}
+
+	h.logger.Warn("Quota deleted",
+		slog.String("tenant_id", tenantID),
+		slog.String("user_id", userID),
+		slog.String("ip", c.ClientIP()),
+	)
+
+	c.Status(http.StatusNoContent)
+}
+
+// quotaSubject returns the tenant and user of a quota route. It writes a 400 response
+// and returns false when either is invalid.
+func quotaSubject(c *gin.Context) (tenantID, userID string, ok bool) {
+	tenantID = c.Param("tenant_id")
+	if !tenant.Valid(tenantID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tenant ID",
+		})
+		return "", "", false
+	}
+
+	// The tenant route has no user_id parameter
+	userID = c.Param("user_id")
+	if len(userID) > maxQuotaUserIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return "", "", false
+	}
+
+	return tenantID, userID, true
+}
+
+// respondQuotaStorageError writes the response for a failed quota query
+func (h *AdminHandler) respondQuotaStorageError(c *gin.Context, message string, err error) {
+	if storage.IsUnavailable(err) {
+		h.logger.Error("Database unavailable", slog.String("error", err.Error()))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Database unavailable, retry later",
+		})
+		return
+	}
+
+	h.logger.Error(message, slog.String("error", err.Error()))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": message,
+	})
+}
+
+// toQuotaDTO converts a stored quota into its API representation
+func toQuotaDTO(quota *model.Quota) dto.QuotaDTO {
+	return dto.QuotaDTO{
+		TenantID:        quota.TenantID,
+		UserID:          quota.UserID,
+		MaxJobsPerDay:   quota.MaxJobsPerDay,
+		MaxRunningJobs:  quota.MaxRunningJobs,
+		MaxPayloadBytes: quota.MaxPayloadBytes,
+		CreatedAt:       quota.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       quota.UpdatedAt.Format(time.RFC3339),
+	}
+}
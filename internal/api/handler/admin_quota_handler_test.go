@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_Quotas(t *testing.T) {
+	newRouter := func(store *mocks.JobStorage) *gin.Engine {
+		h := NewAdminHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+		})
+
+		r := gin.New()
+		r.GET("/admin/quotas", h.ListQuotas)
+		r.PUT("/admin/quotas/:tenant_id", h.PutQuota)
+		r.DELETE("/admin/quotas/:tenant_id", h.DeleteQuota)
+		r.PUT("/admin/quotas/:tenant_id/users/:user_id", h.PutQuota)
+		r.DELETE("/admin/quotas/:tenant_id/users/:user_id", h.DeleteQuota)
+		return r
+	}
+
+	t.Run("lists the quotas of a tenant", func(t *testing.T) {
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		var listed string
+		store := &mocks.JobStorage{
+			ListQuotasFunc: func(_ context.Context, tenantID string) ([]model.Quota, error) {
+				listed = tenantID
+				return []model.Quota{
+					{TenantID: "acme", MaxJobsPerDay: 1000, CreatedAt: now, UpdatedAt: now},
+					{TenantID: "acme", UserID: "user-1", MaxRunningJobs: 2, CreatedAt: now, UpdatedAt: now},
+				}, nil
+			},
+		}
+
+		w := doRequest(newRouter(store), http.MethodGet, "/admin/quotas?tenant_id=acme", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme", listed)
+
+		var resp dto.ListQuotasResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Quotas, 2)
+		assert.Equal(t, int64(1000), resp.Quotas[0].MaxJobsPerDay)
+		assert.Empty(t, resp.Quotas[0].UserID)
+		assert.Equal(t, "user-1", resp.Quotas[1].UserID)
+		assert.Equal(t, "2026-03-01T12:00:00Z", resp.Quotas[1].UpdatedAt)
+	})
+
+	t.Run("no quotas", func(t *testing.T) {
+		w := doRequest(newRouter(&mocks.JobStorage{}), http.MethodGet, "/admin/quotas", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"quotas":[]}`, w.Body.String())
+	})
+
+	t.Run("sets a tenant quota", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		w := doRequest(newRouter(store), http.MethodPut, "/admin/quotas/acme", `{"max_jobs_per_day":1000,"max_running_jobs":10}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, store.UpsertedQuotas, 1)
+		assert.Equal(t, model.Quota{TenantID: "acme", MaxJobsPerDay: 1000, MaxRunningJobs: 10}, *store.UpsertedQuotas[0])
+	})
+
+	t.Run("sets a user quota", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		w := doRequest(newRouter(store), http.MethodPut, "/admin/quotas/acme/users/user-1", `{"max_payload_bytes":4096}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, store.UpsertedQuotas, 1)
+		assert.Equal(t, "user-1", store.UpsertedQuotas[0].UserID)
+		assert.Contains(t, w.Body.String(), `"user_id":"user-1"`)
+	})
+
+	t.Run("rejects invalid quotas", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		w := doRequest(newRouter(store), http.MethodPut, "/admin/quotas/acme", `{"max_jobs_per_day":-1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doRequest(newRouter(store), http.MethodPut, "/admin/quotas/acme%20corp", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.UpsertedQuotas)
+	})
+
+	t.Run("deletes a user quota", func(t *testing.T) {
+		var deleted []string
+		store := &mocks.JobStorage{
+			DeleteQuotaFunc: func(_ context.Context, tenantID, userID string) error {
+				deleted = []string{tenantID, userID}
+				return nil
+			},
+		}
+
+		w := doRequest(newRouter(store), http.MethodDelete, "/admin/quotas/acme/users/user-1", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, []string{"acme", "user-1"}, deleted)
+	})
+
+	t.Run("deletes a missing quota", func(t *testing.T) {
+		w := doRequest(newRouter(&mocks.JobStorage{}), http.MethodDelete, "/admin/quotas/acme", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			UpsertQuotaFunc: func(context.Context, *model.Quota) error {
+				return fmt.Errorf("failed to upsert quota: %w", driver.ErrBadConn)
+			},
+		}
+
+		w := doRequest(newRouter(store), http.MethodPut, "/admin/quotas/acme", `{}`)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,7 +12,9 @@ import (
 	"github.com/cuongbtq/practice-be/internal/api/storage"
 )
 
-// DecodeJobCursor decodes a base64-encoded cursor string into a JobCursor struct
+// DecodeJobCursor decodes a base64-encoded cursor string into a JobCursor struct.
+// Cursors are "<sort field>|<asc|desc>|<sort key>|<job_id>"; cursors issued before
+// sorting was configurable ("<created_at>|<job_id>") continue a created_at DESC listing.
 func DecodeJobCursor(cursorStr string) (*storage.JobCursor, error) {
 	if cursorStr == "" {
 		return nil, nil
@@ -22,26 +27,105 @@ func DecodeJobCursor(cursorStr string) (*storage.JobCursor, error) {
 	}
 
 	// Further decoding logic to parse decoded string into storage.JobCursor
-	decodedParts := strings.Split(string(decoded), "|")
-	if len(decodedParts) != 2 {
+	decodedParts := strings.SplitN(string(decoded), "|", 3)
+	if len(decodedParts) == 2 {
+		return decodeTimeKey(&storage.JobCursor{JobID: decodedParts[1]}, decodedParts[0])
+	}
+	if len(decodedParts) != 3 {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	// The sort key may contain separators (job types are free-form), the job ID cannot
+	sep := strings.LastIndex(decodedParts[2], "|")
+	if sep < 0 {
 		return nil, fmt.Errorf("invalid cursor format")
 	}
+	key, jobID := decodedParts[2][:sep], decodedParts[2][sep+1:]
 
-	var createdAt int64
-	_, err = fmt.Sscanf(decodedParts[0], "%d", &createdAt)
+	sort, err := parseJobSort(decodedParts[0] + "_" + decodedParts[1])
 	if err != nil {
-		return nil, fmt.Errorf("invalid createdAt in cursor: %w", err)
+		return nil, fmt.Errorf("invalid sort in cursor: %w", err)
 	}
 
-	return &storage.JobCursor{
-		CreatedAt: time.Unix(0, createdAt),
-		JobID:     decodedParts[1],
-	}, nil
+	cursor := &storage.JobCursor{Sort: sort, JobID: jobID}
+	if sort.IsTime() {
+		return decodeTimeKey(cursor, key)
+	}
+	cursor.TextKey = key
+	return cursor, nil
+}
+
+// decodeTimeKey sets the cursor's time key from Unix nanoseconds
+func decodeTimeKey(cursor *storage.JobCursor, key string) (*storage.JobCursor, error) {
+	var nanos int64
+	if _, err := fmt.Sscanf(key, "%d", &nanos); err != nil {
+		return nil, fmt.Errorf("invalid time key in cursor: %w", err)
+	}
+	cursor.TimeKey = time.Unix(0, nanos)
+	return cursor, nil
 }
 
 // EncodeJobCursor encodes a JobCursor struct into a base64-encoded string
 func EncodeJobCursor(cursor *storage.JobCursor) (string, error) {
+	key := cursor.TextKey
+	if cursor.Sort.IsTime() {
+		key = fmt.Sprintf("%d", cursor.TimeKey.UnixNano())
+	}
+
 	// Encode to base64
-	cs := fmt.Sprintf("%d|%s", cursor.CreatedAt.UnixNano(), cursor.JobID)
+	cs := fmt.Sprintf("%s|%s|%s|%s", cursor.Sort.Column(), sortDirection(cursor.Sort), key, cursor.JobID)
 	return base64.StdEncoding.EncodeToString([]byte(cs)), nil
 }
+
+// errCursorSignature is returned for cursors that were not signed with the current key
+var errCursorSignature = errors.New("invalid cursor signature")
+
+// CursorSigner appends an HMAC-SHA256 signature to the cursors handed to clients and
+// checks it on the cursors they send back, so a client cannot forge a cursor to start a
+// listing at an arbitrary position. A nil CursorSigner leaves cursors unsigned.
+type CursorSigner struct {
+	key []byte
+}
+
+// NewCursorSigner returns a CursorSigner using key, or nil when key is empty
+func NewCursorSigner(key string) *CursorSigner {
+	if key == "" {
+		return nil
+	}
+	return &CursorSigner{key: []byte(key)}
+}
+
+// Encode encodes and signs cursor as "<cursor>.<signature>"
+func (s *CursorSigner) Encode(cursor *storage.JobCursor) (string, error) {
+	encoded, err := EncodeJobCursor(cursor)
+	if err != nil || s == nil {
+		return encoded, err
+	}
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Decode checks the signature of a cursor from Encode and decodes it. Unsigned cursors
+// are rejected once a key is set.
+func (s *CursorSigner) Decode(cursorStr string) (*storage.JobCursor, error) {
+	if s == nil || cursorStr == "" {
+		return DecodeJobCursor(cursorStr)
+	}
+
+	// Base64 never contains a dot, so the signature follows the last one
+	sep := strings.LastIndexByte(cursorStr, '.')
+	if sep < 0 {
+		return nil, errCursorSignature
+	}
+	encoded, signature := cursorStr[:sep], cursorStr[sep+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, errCursorSignature
+	}
+	return DecodeJobCursor(encoded)
+}
+
+// sign returns the unpadded base64url HMAC-SHA256 of encoded
+func (s *CursorSigner) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
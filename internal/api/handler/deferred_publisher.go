@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/policy"
+)
+
+// DeferredPublisher republishes the job messages deferred by the broker_unavailable
+// policy. Messages go through the same ordered or sharded publish as when they were
+// deferred, and the jobs of the ones left at shutdown are requeued in the outbox.
+type DeferredPublisher struct {
+	jobs *JobHandler
+}
+
+var _ policy.Republisher = (*DeferredPublisher)(nil)
+
+// NewDeferredPublisher creates a republisher using the job publisher and storage of deps
+func NewDeferredPublisher(deps *Dependencies) *DeferredPublisher {
+	return &DeferredPublisher{jobs: NewJobHandler(deps)}
+}
+
+// RepublishJob publishes the deferred message of job once
+func (p *DeferredPublisher) RepublishJob(ctx context.Context, job *model.Job, body []byte, contentType string) error {
+	return p.jobs.sendJob(ctx, job, body, contentType)
+}
+
+// RequeueJobs adds the jobs to the outbox, for the outbox relay to publish
+func (p *DeferredPublisher) RequeueJobs(ctx context.Context, jobs []model.Job) error {
+	jobIDs := make([]string, len(jobs))
+	for i := range jobs {
+		jobIDs[i] = jobs[i].JobID
+	}
+	return p.jobs.storage.RequeueJobs(ctx, jobIDs)
+}
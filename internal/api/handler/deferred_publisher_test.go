@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredPublisher(t *testing.T) {
+	newPublisher := func(store *mocks.JobStorage, publisher JobPublisher) *DeferredPublisher {
+		return NewDeferredPublisher(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  publisher,
+		})
+	}
+
+	t.Run("republishes with the job's ordering key", func(t *testing.T) {
+		key := "account-42"
+		publisher := &orderedPublisher{}
+		p := newPublisher(&mocks.JobStorage{}, publisher)
+
+		require.NoError(t, p.RepublishJob(context.Background(), &model.Job{JobID: "job-1", OrderingKey: &key}, []byte("1"), "application/json"))
+		require.NoError(t, p.RepublishJob(context.Background(), &model.Job{JobID: "job-2"}, []byte("2"), "application/json"))
+
+		assert.Equal(t, []string{key, "job-2"}, publisher.keys)
+		assert.Equal(t, [][]byte{[]byte("1"), []byte("2")}, publisher.messages)
+	})
+
+	t.Run("requeues jobs in order", func(t *testing.T) {
+		var requeued []string
+		store := &mocks.JobStorage{
+			RequeueJobsFunc: func(_ context.Context, jobIDs []string) error {
+				requeued = jobIDs
+				return nil
+			},
+		}
+
+		err := newPublisher(store, &fakePublisher{}).RequeueJobs(context.Background(), []model.Job{{JobID: "job-1"}, {JobID: "job-2"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"job-1", "job-2"}, requeued)
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+)
+
+// DependencyResolverOptions configures how WAITING jobs are released
+type DependencyResolverOptions struct {
+	Interval  time.Duration // How often WAITING jobs are checked, 0 uses 5s
+	BatchSize int           // Jobs promoted per check at most, 0 uses 100
+}
+
+// DependencyResolver moves WAITING jobs to PENDING and publishes them once every job they
+// depend on has completed. Jobs whose dependencies can no longer complete are canceled.
+type DependencyResolver struct {
+	jobs *JobHandler
+	opts DependencyResolverOptions
+}
+
+// NewDependencyResolver creates a resolver that publishes through the same publisher and
+// policies as the job handlers
+func NewDependencyResolver(deps *Dependencies, opts DependencyResolverOptions) *DependencyResolver {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	return &DependencyResolver{
+		jobs: NewJobHandler(deps),
+		opts: opts,
+	}
+}
+
+// Run resolves dependencies every Interval until ctx is canceled
+func (r *DependencyResolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.resolve(ctx)
+		}
+	}
+}
+
+// resolve cancels blocked jobs, then promotes up to BatchSize ready jobs
+func (r *DependencyResolver) resolve(ctx context.Context) {
+	logger := r.jobs.logger
+
+	canceled, err := r.jobs.storage.CancelBlockedJobs(ctx)
+	if err != nil {
+		logger.Error("Failed to cancel blocked jobs", slog.String("error", err.Error()))
+	} else if canceled > 0 {
+		logger.Info("Canceled jobs with failed dependencies", slog.Int64("count", canceled))
+	}
+
+	promoted := 0
+	for promoted < r.opts.BatchSize {
+		job, err := r.jobs.storage.PromoteWaitingJob(ctx, func(job *model.Job) error {
+			return r.jobs.publishJob(ctx, job)
+		})
+		if err != nil {
+			// The job stays WAITING and is picked up again on the next check
+			logger.Error("Failed to promote waiting job", slog.String("error", err.Error()))
+			break
+		}
+		if job == nil {
+			break
+		}
+
+		logger.Debug("Dependencies completed, job queued", slog.String("job_id", job.JobID))
+		promoted++
+	}
+
+	if promoted > 0 {
+		logger.Info("Queued jobs with completed dependencies", slog.Int("count", promoted))
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyResolver_Resolve(t *testing.T) {
+	// readyStore promotes the given jobs one per call, then reports none ready
+	readyStore := func(jobIDs ...string) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			PromoteWaitingJobFunc: func(_ context.Context, publish func(*model.Job) error) (*model.Job, error) {
+				if len(jobIDs) == 0 {
+					return nil, nil
+				}
+				job := &model.Job{JobID: jobIDs[0], JobType: "send_email", Payload: `{}`, Status: domain.JobStatusPending}
+				if err := publish(job); err != nil {
+					return nil, err
+				}
+				jobIDs = jobIDs[1:]
+				return job, nil
+			},
+		}
+	}
+
+	newResolver := func(store *mocks.JobStorage, publisher JobPublisher, batchSize int) *DependencyResolver {
+		return NewDependencyResolver(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  publisher,
+		}, DependencyResolverOptions{BatchSize: batchSize})
+	}
+
+	t.Run("publishes every ready job", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		canceled := 0
+		store := readyStore("job-1", "job-2")
+		store.CancelBlockedJobsFunc = func(context.Context) (int64, error) {
+			canceled++
+			return 1, nil
+		}
+
+		newResolver(store, publisher, 0).resolve(context.Background())
+
+		assert.Equal(t, 1, canceled)
+		require.Len(t, publisher.messages, 2)
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[1], &msg))
+		assert.Equal(t, "job-2", msg.JobID)
+	})
+
+	t.Run("publishes workflow steps as the tenant of the job", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		workflowID, step := "wf-1", "load"
+		var lookedUpAs string
+		store := &mocks.JobStorage{
+			PromoteWaitingJobFunc: func(_ context.Context, publish func(*model.Job) error) (*model.Job, error) {
+				if len(publisher.messages) > 0 {
+					return nil, nil
+				}
+				job := &model.Job{JobID: "job-1", Payload: `{}`, TenantID: "acme", WorkflowID: &workflowID, StepName: &step}
+				return job, publish(job)
+			},
+			GetWorkflowFunc: func(ctx context.Context, _ string) (*model.Workflow, []model.Job, error) {
+				lookedUpAs = tenant.ID(ctx)
+				return &model.Workflow{WorkflowID: workflowID}, nil, nil
+			},
+		}
+
+		newResolver(store, publisher, 0).resolve(context.Background())
+
+		assert.Equal(t, "acme", lookedUpAs)
+		require.Len(t, publisher.messages, 1)
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+		assert.Equal(t, "acme", msg.TenantID)
+	})
+
+	t.Run("stops at the batch size", func(t *testing.T) {
+		publisher := &fakePublisher{}
+
+		newResolver(readyStore("job-1", "job-2", "job-3"), publisher, 2).resolve(context.Background())
+		assert.Len(t, publisher.messages, 2)
+	})
+
+	t.Run("stops when publishing fails", func(t *testing.T) {
+		publisher := &fakePublisher{err: errors.New("connection closed")}
+
+		newResolver(readyStore("job-1", "job-2"), publisher, 0).resolve(context.Background())
+		assert.Len(t, publisher.messages, 1)
+	})
+}
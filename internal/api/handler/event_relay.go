@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/shared/broker"
+)
+
+// EventRelayOptions configures how job events are published to the events exchange
+type EventRelayOptions struct {
+	Interval  time.Duration // How often unpublished events are looked for, 0 uses 1s
+	BatchSize int           // Events published per transaction, 0 uses 100
+}
+
+// EventRelayStats counts what the event relay has published since startup
+type EventRelayStats struct {
+	Published int64
+	Errors    int64 // Failed batches
+}
+
+// EventRelayStatsSource reports event relay counters
+type EventRelayStatsSource interface {
+	EventRelayStats() EventRelayStats
+}
+
+// EventRelay publishes every recorded job status transition, including those made by
+// workers, to the events exchange so other systems can subscribe to them. Events are
+// read from job_events and marked as published in the same transaction, so each one is
+// published at least once and the events of a job in order, even with a relay on every
+// instance.
+type EventRelay struct {
+	jobs   *JobHandler
+	events broker.EventPublisher
+	opts   EventRelayOptions
+
+	published atomic.Int64
+	errors    atomic.Int64
+}
+
+var _ EventRelayStatsSource = (*EventRelay)(nil)
+
+// NewEventRelay creates a relay publishing through events
+func NewEventRelay(deps *Dependencies, events broker.EventPublisher, opts EventRelayOptions) *EventRelay {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	return &EventRelay{
+		jobs:   NewJobHandler(deps),
+		events: events,
+		opts:   opts,
+	}
+}
+
+// Run publishes new events every Interval until ctx is canceled
+func (r *EventRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+// EventRelayStats returns the counters since startup
+func (r *EventRelay) EventRelayStats() EventRelayStats {
+	return EventRelayStats{
+		Published: r.published.Load(),
+		Errors:    r.errors.Load(),
+	}
+}
+
+// relay publishes batches of events until none are left
+func (r *EventRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.jobs.storage.PublishJobEvents(ctx, r.opts.BatchSize, func(event *model.LifecycleEvent) error {
+			return r.publish(ctx, event)
+		})
+		r.published.Add(int64(published))
+		if err != nil {
+			// The remaining events are published again on the next check
+			r.errors.Add(1)
+			r.jobs.logger.Error("Failed to publish job events", slog.String("error", err.Error()))
+			return
+		}
+
+		// A batch takes one event per job, so a short batch can still leave later
+		// events of its jobs behind
+		if published == 0 {
+			return
+		}
+	}
+}
+
+// publish sends one event to the events exchange
+func (r *EventRelay) publish(ctx context.Context, event *model.LifecycleEvent) error {
+	name := lifecycleEventName(&event.JobEvent)
+	body, err := json.Marshal(dto.JobLifecycleEvent{
+		Event:      "job." + name,
+		EventID:    event.ID,
+		JobID:      event.JobID,
+		JobType:    event.JobType,
+		TenantID:   event.TenantID,
+		UserID:     event.UserID,
+		OldStatus:  event.OldStatus,
+		Status:     event.NewStatus,
+		ActorType:  event.ActorType,
+		Actor:      event.Actor,
+		Reason:     event.Reason,
+		OccurredAt: event.CreatedAt.Format(time.RFC3339Nano),
+		Metadata:   rawJSON(event.Metadata),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal job event: %w", err)
+	}
+
+	return r.events.PublishEvent(ctx, lifecycleRoutingKey(event.JobType, name), body, "application/json")
+}
+
+// lifecycleEventName names the transition of event, e.g. "created" or "completed"
+func lifecycleEventName(event *model.JobEvent) string {
+	if event.OldStatus == nil {
+		return "created"
+	}
+
+	switch event.NewStatus {
+	case domain.JobStatusRunning:
+		return "started"
+	case domain.JobStatusPending:
+		// Retried, or released once its dependencies completed
+		return "queued"
+	default:
+		return strings.ToLower(event.NewStatus)
+	}
+}
+
+// lifecycleRoutingKey returns job.<type>.<event>. Dots in the job type are replaced, so
+// every key has three words for topic bindings such as job.*.failed.
+func lifecycleRoutingKey(jobType, name string) string {
+	return "job." + strings.ReplaceAll(jobType, ".", "_") + "." + name
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEventPublisher records published events and fails once err is set
+type fakeEventPublisher struct {
+	routingKeys []string
+	bodies      []string
+	err         error
+}
+
+func (p *fakeEventPublisher) PublishEvent(_ context.Context, routingKey string, body []byte, _ string) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.routingKeys = append(p.routingKeys, routingKey)
+	p.bodies = append(p.bodies, string(body))
+	return nil
+}
+
+func TestEventRelay_Relay(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := func(s string) *string { return &s }
+
+	// eventStore hands out the given events in batches of limit, like PublishJobEvents
+	eventStore := func(events ...model.LifecycleEvent) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			PublishJobEventsFunc: func(_ context.Context, limit int, publish func(*model.LifecycleEvent) error) (int, error) {
+				published := 0
+				for len(events) > 0 && published < limit {
+					if err := publish(&events[0]); err != nil {
+						return published, err
+					}
+					events = events[1:]
+					published++
+				}
+				return published, nil
+			},
+		}
+	}
+	metadata := `{"order_id":"o-1"}`
+	event := func(id int64, jobType string, oldStatus *string, newStatus string) model.LifecycleEvent {
+		return model.LifecycleEvent{
+			JobEvent: model.JobEvent{
+				ID:        id,
+				JobID:     "job-1",
+				OldStatus: oldStatus,
+				NewStatus: newStatus,
+				ActorType: domain.ActorSystem,
+				CreatedAt: createdAt,
+			},
+			JobType:  jobType,
+			TenantID: "acme",
+			UserID:   "user-1",
+			Metadata: &metadata,
+		}
+	}
+	newRelay := func(store *mocks.JobStorage, events *fakeEventPublisher) *EventRelay {
+		return NewEventRelay(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+		}, events, EventRelayOptions{BatchSize: 2})
+	}
+
+	t.Run("publishes every transition under its routing key", func(t *testing.T) {
+		store := eventStore(
+			event(1, "send.email", nil, domain.JobStatusPending),
+			event(2, "send.email", status(domain.JobStatusPending), domain.JobStatusRunning),
+			event(3, "send.email", status(domain.JobStatusRunning), domain.JobStatusFailed),
+			event(4, "send.email", status(domain.JobStatusFailed), domain.JobStatusPending),
+			event(5, "send.email", status(domain.JobStatusRunning), domain.JobStatusCompleted),
+		)
+		events := &fakeEventPublisher{}
+		relay := newRelay(store, events)
+
+		relay.relay(context.Background())
+
+		assert.Equal(t, []string{
+			"job.send_email.created",
+			"job.send_email.started",
+			"job.send_email.failed",
+			"job.send_email.queued",
+			"job.send_email.completed",
+		}, events.routingKeys)
+		assert.JSONEq(t, `{"event":"job.created","event_id":1,"job_id":"job-1","job_type":"send.email",`+
+			`"tenant_id":"acme","user_id":"user-1","old_status":null,"status":"PENDING","actor_type":"system",`+
+			`"occurred_at":"2026-03-01T12:00:00Z","metadata":{"order_id":"o-1"}}`, events.bodies[0])
+		assert.Equal(t, EventRelayStats{Published: 5}, relay.EventRelayStats())
+	})
+
+	t.Run("keeps going after a short batch", func(t *testing.T) {
+		// Batches hold one event per job, so they can be short while later events wait
+		pending := []model.LifecycleEvent{
+			event(1, "etl", nil, domain.JobStatusPending),
+			event(2, "etl", status(domain.JobStatusPending), domain.JobStatusRunning),
+		}
+		store := &mocks.JobStorage{
+			PublishJobEventsFunc: func(_ context.Context, _ int, publish func(*model.LifecycleEvent) error) (int, error) {
+				if len(pending) == 0 {
+					return 0, nil
+				}
+				if err := publish(&pending[0]); err != nil {
+					return 0, err
+				}
+				pending = pending[1:]
+				return 1, nil
+			},
+		}
+		events := &fakeEventPublisher{}
+		relay := newRelay(store, events)
+
+		relay.relay(context.Background())
+		assert.Equal(t, []string{"job.etl.created", "job.etl.started"}, events.routingKeys)
+	})
+
+	t.Run("stops at the first failed event", func(t *testing.T) {
+		store := eventStore(
+			event(1, "etl", nil, domain.JobStatusPending),
+			event(2, "etl", status(domain.JobStatusPending), domain.JobStatusRunning),
+		)
+		events := &fakeEventPublisher{err: errors.New("channel closed")}
+		relay := newRelay(store, events)
+
+		relay.relay(context.Background())
+		require.Equal(t, EventRelayStats{Errors: 1}, relay.EventRelayStats())
+
+		// The events are still unpublished, so the next check sends them
+		events.err = nil
+		relay.relay(context.Background())
+		assert.Equal(t, []string{"job.etl.created", "job.etl.started"}, events.routingKeys)
+		assert.Equal(t, EventRelayStats{Published: 2, Errors: 1}, relay.EventRelayStats())
+	})
+
+	t.Run("counts storage errors", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			PublishJobEventsFunc: func(context.Context, int, func(*model.LifecycleEvent) error) (int, error) {
+				return 0, errors.New("connection refused")
+			},
+		}
+		relay := newRelay(store, &fakeEventPublisher{})
+
+		relay.relay(context.Background())
+		assert.Equal(t, EventRelayStats{Errors: 1}, relay.EventRelayStats())
+	})
+}
+
+func TestLifecycleEventName(t *testing.T) {
+	status := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		event model.JobEvent
+		want  string
+	}{
+		{"created", model.JobEvent{NewStatus: domain.JobStatusPending}, "created"},
+		{"created waiting", model.JobEvent{NewStatus: domain.JobStatusWaiting}, "created"},
+		{"started", model.JobEvent{OldStatus: status(domain.JobStatusPending), NewStatus: domain.JobStatusRunning}, "started"},
+		{"released", model.JobEvent{OldStatus: status(domain.JobStatusWaiting), NewStatus: domain.JobStatusPending}, "queued"},
+		{"completed", model.JobEvent{OldStatus: status(domain.JobStatusRunning), NewStatus: domain.JobStatusCompleted}, "completed"},
+		{"canceled", model.JobEvent{OldStatus: status(domain.JobStatusPending), NewStatus: domain.JobStatusCanceled}, "canceled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lifecycleEventName(&tt.event))
+		})
+	}
+}
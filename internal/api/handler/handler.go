@@ -1,32 +1,235 @@
 package handler
 
 import (
+	"context"
 	"log/slog"
+	"time"
 
+	"github.com/cuongbtq/practice-be/internal/api/policy"
+	"github.com/cuongbtq/practice-be/internal/api/schema"
 	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/messaging"
 	"github.com/cuongbtq/practice-be/shared/postgresql"
-	"github.com/cuongbtq/practice-be/shared/rabbitmq"
+	"github.com/cuongbtq/practice-be/shared/resultstore"
 )
 
+// JobPublisher publishes job messages to the message broker
+type JobPublisher interface {
+	Publish(ctx context.Context, body []byte, contentType string) error
+}
+
+// OrderedPublisher is implemented by publishers that can keep messages with the same
+// ordering key in order, e.g. by routing them to the same broker partition
+type OrderedPublisher interface {
+	PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error
+}
+
+// EstimationOptions configures job duration and queue wait estimates
+type EstimationOptions struct {
+	HistoryWindow  time.Duration
+	WorkerCapacity int
+}
+
+// JobHealthOptions configures the stuck and overdue flags computed for job responses
+type JobHealthOptions struct {
+	HeartbeatTimeout time.Duration // RUNNING jobs without a heartbeat for this long are stuck, 0 disables
+	PendingSLA       time.Duration // PENDING jobs older than this are overdue, 0 disables
+	WorkerTimeout    time.Duration // Workers without a heartbeat for this long are dead, 0 uses 1m
+}
+
+// CompressionOptions configures gzip compression of responses
+type CompressionOptions struct {
+	Enabled       bool
+	MinSize       int      // Smaller responses are sent uncompressed
+	ExcludedPaths []string // Path prefixes never compressed
+}
+
+// CORSOptions configures which browser origins may call the API
+type CORSOptions struct {
+	// AllowAllOrigins answers every origin with "*", for local development. Credentials
+	// are never allowed in this mode.
+	AllowAllOrigins  bool
+	AllowedOrigins   []string // Exact origins, e.g. https://app.example.com
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // How long browsers may cache a preflight response
+}
+
+// RequestLimitsOptions bounds the size and handling time of requests
+type RequestLimitsOptions struct {
+	MaxBodyBytes   int64                    // Larger request bodies get 413, 0 disables the check
+	HandlerTimeout time.Duration            // Requests running longer get 408, 0 disables the deadline
+	RouteTimeouts  map[string]time.Duration // HandlerTimeout overrides keyed like "GET /api/v1/jobs/:job_id"
+}
+
+// TenancyOptions configures how API requests are mapped to tenants and how many jobs each
+// tenant may submit
+type TenancyOptions struct {
+	// Enabled resolves the tenant of every API request. Disabled, every request acts for
+	// tenant.DefaultID.
+	Enabled bool
+	// Header names the tenant of requests without a bearer token, empty requires a token
+	Header string
+	// Tokens maps API bearer tokens to the tenant each one authenticates
+	Tokens map[string]string
+	// DefaultQuota applies to tenants without an entry in Quotas
+	DefaultQuota TenantQuota
+	Quotas       map[string]TenantQuota
+}
+
+// TenantQuota limits the jobs of one tenant. Zero values disable a limit.
+type TenantQuota struct {
+	MaxPendingJobs int64 // Jobs PENDING or WAITING
+	MaxJobsPerDay  int64 // Jobs created since midnight UTC
+}
+
+// Quota returns the quota of tenantID
+func (o TenancyOptions) Quota(tenantID string) TenantQuota {
+	if quota, ok := o.Quotas[tenantID]; ok {
+		return quota
+	}
+	return o.DefaultQuota
+}
+
+// LogLevelController reads and changes the service log level at runtime
+type LogLevelController interface {
+	Level() string
+	SetLevel(level string) error
+}
+
+// AppInfo identifies the running service, e.g. in exported job documents
+type AppInfo struct {
+	Name        string
+	Version     string
+	Environment string
+}
+
 // Dependencies holds all dependencies needed by handlers
 type Dependencies struct {
-	Logger       *slog.Logger
-	DBClient     *postgresql.Client
-	RabbitClient *rabbitmq.Client
+	Logger   *slog.Logger
+	DBClient *postgresql.Client
+	Broker   broker.Broker
+	// MaxPayloadBytes rejects larger job payloads with 413, 0 disables the check
+	MaxPayloadBytes int
+	// PayloadSchemas rejects job payloads that do not match the schema for their job type
+	PayloadSchemas schema.Registry
+	// ResultSchemas describe the results of each job type to API clients
+	ResultSchemas schema.Registry
+	App           AppInfo
+	Estimation    EstimationOptions
+	JobHealth     JobHealthOptions
+	// Validation restricts job types and user IDs beyond the DTO binding tags
+	Validation ValidationOptions
+	// AsyncCreate answers job creation with 202 Accepted and a Location header instead
+	// of 201 Created
+	AsyncCreate bool
+	// Policies decides how handlers degrade when the database or broker fails.
+	// Defaults to rejecting requests on any failure.
+	Policies *policy.Engine
+	// LogLevel enables the /admin/log-level endpoints when set
+	LogLevel LogLevelController
+	// Maintenance rejects mutating API requests while set and enables the
+	// /admin/maintenance endpoints
+	Maintenance *MaintenanceMode
+	// AdminToken is the bearer token required by /admin routes. Empty leaves them open
+	// when App.Environment is development and disables them otherwise.
+	AdminToken string
+	// Compression gzips responses for clients that accept it
+	Compression CompressionOptions
+	// CORS lists the browser origins allowed to call the API. The zero value allows none.
+	CORS CORSOptions
+	// Limits caps request body sizes and handler run time. The zero value sets no limits.
+	Limits RequestLimitsOptions
+	// Tenancy scopes API requests to tenants. The zero value runs every request as the
+	// default tenant without quotas.
+	Tenancy TenancyOptions
+	// QueryMetrics enables the /metrics endpoint when set
+	QueryMetrics QueryStatsSource
+	// Retention adds the retention cleaner counters to /metrics when set
+	Retention RetentionStatsSource
+	// EventRelay adds the event relay counters to /metrics when set
+	EventRelay EventRelayStatsSource
+	// CircuitBreaker adds the broker circuit breaker state to /metrics when set
+	CircuitBreaker CircuitBreakerStatsSource
+	// CursorKey signs the list cursors handed to clients, empty leaves them unsigned
+	CursorKey string
+	// MessageCodec encodes the job messages published to the broker. Defaults to JSON.
+	MessageCodec messaging.Codec
+	// Results resolves offloaded job results to download URLs. Defaults to inline results only.
+	Results resultstore.Store
+	// JobStorage overrides the PostgreSQL storage built from DBClient (used in tests)
+	JobStorage storage.JobStorage
+	// Publisher overrides Broker as the job publisher (used in tests)
+	Publisher JobPublisher
 }
 
 // JobHandler handles job-related HTTP requests
 type JobHandler struct {
-	logger       *slog.Logger
-	rabbitClient *rabbitmq.Client
-	storage      *storage.Storage
+	logger     *slog.Logger
+	publisher  JobPublisher
+	storage    storage.JobStorage
+	maxPayload int
+	schemas    schema.Registry
+	app        AppInfo
+	estimation EstimationOptions
+	health     JobHealthOptions
+	policies   *policy.Engine
+	results    resultstore.Store
+	validation ValidationOptions
+	tenancy    TenancyOptions
+
+	resultSchemas schema.Registry
+	asyncCreate   bool
+	codec         messaging.Codec
+	cursors       *CursorSigner
 }
 
 // NewJobHandler creates a new JobHandler instance
 func NewJobHandler(deps *Dependencies) *JobHandler {
+	jobStorage := deps.JobStorage
+	if jobStorage == nil {
+		jobStorage = storage.NewStorage(deps.DBClient)
+	}
+
+	publisher := deps.Publisher
+	if publisher == nil && deps.Broker != nil {
+		publisher = deps.Broker
+	}
+
+	policies := deps.Policies
+	if policies == nil {
+		policies = policy.NewEngine(policy.Options{}, deps.Logger)
+	}
+
+	results := deps.Results
+	if results == nil {
+		results = resultstore.InlineStore{}
+	}
+
+	codec := deps.MessageCodec
+	if codec == nil {
+		codec = messaging.JSON
+	}
+
 	return &JobHandler{
-		logger:       deps.Logger,
-		rabbitClient: deps.RabbitClient,
-		storage:      storage.NewStorage(deps.DBClient),
+		logger:     deps.Logger,
+		publisher:  publisher,
+		storage:    jobStorage,
+		maxPayload: deps.MaxPayloadBytes,
+		schemas:    deps.PayloadSchemas,
+		app:        deps.App,
+		estimation: deps.Estimation,
+		health:     deps.JobHealth,
+		policies:   policies,
+		results:    results,
+		validation: deps.Validation,
+		tenancy:    deps.Tenancy,
+
+		resultSchemas: deps.ResultSchemas,
+		asyncCreate:   deps.AsyncCreate,
+		codec:         codec,
+		cursors:       NewCursorSigner(deps.CursorKey),
 	}
 }
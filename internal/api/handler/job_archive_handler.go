@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetArchivedJob handles GET /api/v1/archive/jobs/:job_id
+// Returns a job the retention cleaner archived before purging it, with its status history
+func (h *JobHandler) GetArchivedJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	h.logger.Info("GetArchivedJob called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_id", jobID),
+	)
+
+	// 1. Validate job_id format (UUID)
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Look the job up in the archive
+	archived, err := h.storage.GetArchivedJob(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.logger.Error("Archived job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Archived job not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to get archived job", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get archived job",
+		})
+		return
+	}
+
+	// 3. Return the job as it was when purged; archived jobs keep their offloaded results
+	resp := dto.ArchivedJobResponse{
+		Job:        toJobDTO(&archived.Job),
+		ArchivedAt: archived.ArchivedAt.Format(time.RFC3339),
+		Events:     make([]dto.JobEventDTO, len(archived.Events)),
+	}
+	h.setResultURL(c.Request.Context(), &resp.Job, &archived.Job)
+	for i := range archived.Events {
+		resp.Events[i] = toJobEventDTO(&archived.Events[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
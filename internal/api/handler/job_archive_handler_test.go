@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_GetArchivedJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	t.Run("returns the archived job and its history", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetArchivedJobFunc: func(_ context.Context, id string) (*model.ArchivedJob, error) {
+				return &model.ArchivedJob{
+					Job:        model.Job{JobID: id, JobType: "send_email", Payload: `{}`, Status: domain.JobStatusCompleted, CreatedAt: now, UpdatedAt: now},
+					ArchivedAt: now.Add(time.Hour),
+					Events: []model.JobEvent{
+						{ID: 1, JobID: id, NewStatus: domain.JobStatusPending, ActorType: domain.ActorUser, CreatedAt: now},
+					},
+				}, nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/archive/jobs/"+jobID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ArchivedJobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, jobID, resp.Job.JobID)
+		assert.Equal(t, domain.JobStatusCompleted, resp.Job.Status)
+		assert.Equal(t, "2026-03-01T03:00:00Z", resp.ArchivedAt)
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, domain.JobStatusPending, resp.Events[0].NewStatus)
+	})
+
+	tests := []struct {
+		name       string
+		jobID      string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid uuid", jobID: "not-a-uuid", wantStatus: http.StatusBadRequest},
+		{name: "not archived", jobID: jobID, err: domain.ErrJobNotFound, wantStatus: http.StatusNotFound},
+		{name: "storage error", jobID: jobID, err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{
+				GetArchivedJobFunc: func(context.Context, string) (*model.ArchivedJob, error) {
+					return nil, tt.err
+				},
+			}
+
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/archive/jobs/"+tt.jobID, "")
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListJobEvents handles GET /api/v1/jobs/:job_id/events
+// Returns every status transition of the job, oldest first
+func (h *JobHandler) ListJobEvents(c *gin.Context) {
+	jobID := c.Param("job_id")
+	h.logger.Info("ListJobEvents called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_id", jobID),
+	)
+
+	// 1. Validate job_id format (UUID)
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Query the job's history
+	events, err := h.storage.ListJobEvents(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to list job events", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list job events",
+		})
+		return
+	}
+
+	// 3. Return the history
+	resp := dto.ListJobEventsResponse{
+		JobID:  jobID,
+		Events: make([]dto.JobEventDTO, len(events)),
+	}
+	for i := range events {
+		resp.Events[i] = toJobEventDTO(&events[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func toJobEventDTO(event *model.JobEvent) dto.JobEventDTO {
+	return dto.JobEventDTO{
+		ID:        event.ID,
+		OldStatus: event.OldStatus,
+		NewStatus: event.NewStatus,
+		ActorType: event.ActorType,
+		Actor:     event.Actor,
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+	}
+}
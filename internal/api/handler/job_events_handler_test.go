@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_ListJobEvents(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+	ptr := func(s string) *string { return &s }
+
+	t.Run("returns the history", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobEventsFunc: func(_ context.Context, id string) ([]model.JobEvent, error) {
+				assert.Equal(t, jobID, id)
+				return []model.JobEvent{
+					{ID: 1, JobID: jobID, NewStatus: domain.JobStatusPending, ActorType: domain.ActorUser, Actor: ptr("user-1"), CreatedAt: now},
+					{ID: 2, JobID: jobID, OldStatus: ptr(domain.JobStatusPending), NewStatus: domain.JobStatusFailed,
+						ActorType: domain.ActorWorker, Actor: ptr("worker-1"), Reason: ptr("timeout"), CreatedAt: now},
+				}, nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/events", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListJobEventsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, jobID, resp.JobID)
+		require.Len(t, resp.Events, 2)
+		assert.Nil(t, resp.Events[0].OldStatus)
+		assert.Equal(t, domain.JobStatusPending, *resp.Events[1].OldStatus)
+		assert.Equal(t, domain.ActorWorker, resp.Events[1].ActorType)
+		assert.Equal(t, "timeout", *resp.Events[1].Reason)
+
+		// The creation event reports old_status as null rather than omitting it
+		assert.Contains(t, w.Body.String(), `"old_status":null`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobEventsFunc: func(context.Context, string) ([]model.JobEvent, error) {
+				return nil, domain.ErrJobNotFound
+			},
+		}
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/events", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/jobs/not-a-uuid/events", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/router/stream"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExportJob handles GET /api/v1/jobs/:job_id/export
+// Returns a self-contained job definition that can be imported into another environment
+func (h *JobHandler) ExportJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+
+	h.logger.Info("ExportJob called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_id", jobID),
+	)
+
+	// 1. Validate job_id format (UUID)
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Query job from database
+	job, err := h.storage.GetJobByID(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to get job", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export job",
+		})
+		return
+	}
+
+	// 3. Return the export document
+	c.JSON(http.StatusOK, dto.JobExport{
+		SchemaVersion: dto.JobExportSchemaVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		SourceJobID:   job.JobID,
+		UserID:        job.UserID,
+		JobType:       job.JobType,
+		Payload:       json.RawMessage(job.Payload),
+		Environment: dto.ExportEnvironment{
+			Service:     h.app.Name,
+			Version:     h.app.Version,
+			Environment: h.app.Environment,
+			Fingerprint: h.app.fingerprint(),
+		},
+	})
+}
+
+// ImportJob handles POST /api/v1/jobs/import
+// Creates a new PENDING job from a document produced by ExportJob
+func (h *JobHandler) ImportJob(c *gin.Context) {
+	h.logger.Info("ImportJob called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+	)
+
+	// 1. Validate the export document
+	var doc dto.JobExport
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		h.logger.Error("Invalid request body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	if err := validateJobExport(&doc); err != nil {
+		h.logger.Error("Invalid export document", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid export document",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if h.respondPayloadTooLarge(c, len(doc.Payload)) {
+		return
+	}
+
+	if h.respondPayloadInvalid(c, doc.JobType, doc.Payload) {
+		return
+	}
+
+	// 2. Build a fresh job; an Idempotency-Key header makes repeated imports safe
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.New().String()
+	}
+
+	now := time.Now().UTC()
+	job := model.Job{
+		JobID:          uuid.New().String(),
+		IdempotencyKey: idempotencyKey,
+		UserID:         doc.UserID,
+		JobType:        doc.JobType,
+		Payload:        string(doc.Payload),
+		Status:         domain.JobStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// 3. Create job record in database
+	if !h.insertJob(c, &job, false) {
+		return
+	}
+
+	h.logger.Info("Job imported",
+		slog.String("job_id", job.JobID),
+		slog.String("source_job_id", doc.SourceJobID),
+		slog.String("source_environment", doc.Environment.Environment),
+		slog.String("source_fingerprint", doc.Environment.Fingerprint),
+	)
+
+	// 4. Return the new job
+	c.JSON(http.StatusCreated, toJobDTO(&job))
+}
+
+// ExportJobs handles GET /api/v1/jobs/export
+// Streams every job matching the ListJobs filters as NDJSON or CSV, for offline analysis
+func (h *JobHandler) ExportJobs(c *gin.Context) {
+	h.logger.Info("ExportJobs called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("query", c.Request.URL.RawQuery),
+	)
+
+	// 1. Negotiate the format and parse the filters
+	format := c.NegotiateFormat(mimeNDJSON, mimeCSV)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"error": "Jobs can be exported as " + mimeNDJSON + " or " + mimeCSV,
+		})
+		return
+	}
+
+	var req dto.ExportJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid query parameters", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid query parameters", err))
+		return
+	}
+
+	if req.Sort == "" {
+		req.Sort = storage.SortCreatedAt + "_asc"
+	}
+
+	filter, err := parseJobFilter(&dto.ListJobsRequest{
+		UserID:        req.UserID,
+		JobType:       req.JobType,
+		Status:        req.Status,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Payload:       req.Payload,
+		Query:         req.Query,
+		Sort:          req.Sort,
+	})
+	if err != nil {
+		h.logger.Error("Invalid query parameters", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 2. Resume after the last job the client received
+	if req.After != "" {
+		if _, err := uuid.Parse(req.After); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "after must be a valid UUID",
+			})
+			return
+		}
+
+		after, err := h.storage.GetJobByID(c.Request.Context(), req.After)
+		if err != nil {
+			if errors.Is(err, domain.ErrJobNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "after job not found",
+				})
+				return
+			}
+
+			if h.respondDatabaseUnavailable(c, err) {
+				return
+			}
+
+			h.logger.Error("Failed to get job", slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to export jobs",
+			})
+			return
+		}
+		filter.Cursor = storage.NewJobCursor(filter.Sort, after)
+	}
+
+	// 3. Stream the jobs
+	contentType := mimeNDJSON
+	if format == mimeCSV {
+		contentType = mimeCSV + "; charset=utf-8"
+	}
+
+	err = stream.Serve(c, h.logger, stream.Options{ContentType: contentType}, func(c *gin.Context, out io.Writer) error {
+		w := &jobExportWriter{h: h, c: c, out: out, format: format, now: time.Now()}
+		if err := h.storage.ExportJobs(c.Request.Context(), filter, w.write); err != nil {
+			// Once jobs were sent the status is too; the client resumes with after
+			if w.started {
+				w.abort()
+			}
+			return err
+		}
+		return w.finish()
+	})
+	if err != nil {
+		c.Writer.Header().Del("Content-Disposition")
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to export jobs", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export jobs",
+		})
+	}
+}
+
+// validateJobExport checks that an export document can be imported by this version
+func validateJobExport(doc *dto.JobExport) error {
+	if doc.SchemaVersion != dto.JobExportSchemaVersion {
+		return fmt.Errorf("unsupported schema_version %d (expected %d)", doc.SchemaVersion, dto.JobExportSchemaVersion)
+	}
+
+	if doc.UserID == "" {
+		return errors.New("user_id is required")
+	}
+
+	if doc.JobType == "" {
+		return errors.New("job_type is required")
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(doc.Payload, &payload); err != nil {
+		return fmt.Errorf("payload must be a JSON object: %w", err)
+	}
+
+	return nil
+}
+
+// fingerprint returns a short stable hash of the service identity
+func (a AppInfo) fingerprint() string {
+	sum := sha256.Sum256([]byte(a.Name + "\x00" + a.Version + "\x00" + a.Environment))
+	return hex.EncodeToString(sum[:8])
+}
+
+// mimeCSV is the ExportJobs format with one row per job
+const mimeCSV = "text/csv"
+
+// exportFlushInterval is how many exported jobs are written between flushes
+const exportFlushInterval = 100
+
+// exportCSVHeader names the columns of a CSV export, in jobExportWriter.csvRecord order
+var exportCSVHeader = []string{
+	"job_id", "idempotency_key", "user_id", "job_type", "status", "error_message", "retry_count",
+	"payload", "metadata", "ordering_key", "result", "created_at", "updated_at",
+	"stuck", "overdue", "retry_exhausted",
+}
+
+// jobExportWriter writes the jobs of an export to the stream as they are read. The
+// headers are sent with the first job, so a failure before it still gets an error response.
+type jobExportWriter struct {
+	h      *JobHandler
+	c      *gin.Context
+	out    io.Writer
+	format string
+	now    time.Time // Health flags are computed as of the start of the export
+
+	started bool
+	written int
+	buf     *bufio.Writer
+	enc     *json.Encoder
+	csv     *csv.Writer
+}
+
+// write writes one job and flushes every exportFlushInterval jobs
+func (w *jobExportWriter) write(job *model.Job) error {
+	if !w.started {
+		w.start()
+	}
+
+	resp := toJobDTO(job)
+	w.h.health.annotateHealth(&resp, job, w.now)
+
+	var err error
+	if w.csv != nil {
+		err = w.csv.Write(csvRecord(&resp))
+	} else {
+		err = w.enc.Encode(resp)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.written++
+	if w.written%exportFlushInterval == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// start names the download, and writes the header row of a CSV export
+func (w *jobExportWriter) start() {
+	w.started = true
+
+	filename := "jobs.ndjson"
+	if w.format == mimeCSV {
+		filename = "jobs.csv"
+	}
+	w.c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if w.format == mimeCSV {
+		w.csv = csv.NewWriter(w.out)
+		_ = w.csv.Write(exportCSVHeader)
+		return
+	}
+
+	w.buf = bufio.NewWriter(w.out)
+	w.enc = json.NewEncoder(w.buf)
+}
+
+// flush sends what was buffered to the stream
+func (w *jobExportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.buf.Flush()
+}
+
+// finish completes a successful export, which may have matched no jobs
+func (w *jobExportWriter) finish() error {
+	if !w.started {
+		w.start()
+	}
+	return w.flush()
+}
+
+// abort ends a failed export. NDJSON gets an {"error": ...} line; CSV has no way to
+// signal it, so the file just ends and the client finds the last job it received.
+func (w *jobExportWriter) abort() {
+	if w.enc != nil {
+		_ = w.enc.Encode(gin.H{"error": "Failed to export jobs"})
+	}
+	_ = w.flush()
+}
+
+// csvRecord converts a job to a CSV row in exportCSVHeader order
+func csvRecord(job *dto.JobDTO) []string {
+	return []string{
+		job.JobID, job.IdempotencyKey, job.UserID, job.JobType, job.Status,
+		stringValue(job.ErrorMessage), strconv.Itoa(job.RetryCount),
+		job.Payload, string(job.Metadata), stringValue(job.OrderingKey), string(job.Result),
+		job.CreatedAt, job.UpdatedAt,
+		strconv.FormatBool(job.Stuck), strconv.FormatBool(job.Overdue), strconv.FormatBool(job.RetryExhausted),
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_ExportJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	t.Run("exports the job definition", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobByIDFunc: func(_ context.Context, id string) (*model.Job, error) {
+				return &model.Job{
+					JobID:     id,
+					UserID:    "user-1",
+					JobType:   "send_email",
+					Payload:   `{"to":"a@b.c"}`,
+					Status:    domain.JobStatusFailed,
+					CreatedAt: time.Now().UTC(),
+				}, nil
+			},
+		}
+		r := newTestRouter(store)
+
+		w := doRequest(r, http.MethodGet, "/api/v1/jobs/"+jobID+"/export", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var doc dto.JobExport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		assert.Equal(t, dto.JobExportSchemaVersion, doc.SchemaVersion)
+		assert.Equal(t, jobID, doc.SourceJobID)
+		assert.Equal(t, "send_email", doc.JobType)
+		assert.JSONEq(t, `{"to":"a@b.c"}`, string(doc.Payload))
+		assert.NotEmpty(t, doc.Environment.Fingerprint)
+		assert.Nil(t, doc.ExecutorVersion)
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobByIDFunc: func(context.Context, string) (*model.Job, error) {
+				return nil, domain.ErrJobNotFound
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/export", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid uuid", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/jobs/not-a-uuid/export", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestJobHandler_ImportJob(t *testing.T) {
+	validDoc := `{"schema_version":1,"source_job_id":"550e8400-e29b-41d4-a716-446655440000",` +
+		`"user_id":"user-1","job_type":"send_email","payload":{"to":"a@b.c"},` +
+		`"environment":{"environment":"production","fingerprint":"abc"}}`
+
+	tests := []struct {
+		name       string
+		body       string
+		createErr  error
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "valid document",
+			body:       validDoc,
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name:       "unsupported schema version",
+			body:       `{"schema_version":2,"user_id":"user-1","job_type":"send_email","payload":{}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing job type",
+			body:       `{"schema_version":1,"user_id":"user-1","payload":{}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "payload is not an object",
+			body:       `{"schema_version":1,"user_id":"user-1","job_type":"send_email","payload":[1]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "storage failure",
+			body:       validDoc,
+			createErr:  errors.New("insert failed"),
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{
+				CreateJobFunc: func(context.Context, *model.Job, func(*model.Job) error) error { return tt.createErr },
+			}
+
+			w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs/import", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			require.Len(t, store.CreatedJobs, tt.wantCalls)
+			if tt.wantStatus == http.StatusCreated {
+				created := store.CreatedJobs[0]
+				assert.NotEqual(t, "550e8400-e29b-41d4-a716-446655440000", created.JobID)
+				assert.Equal(t, domain.JobStatusPending, created.Status)
+				assert.JSONEq(t, `{"to":"a@b.c"}`, created.Payload)
+				assert.NotEmpty(t, created.IdempotencyKey)
+			}
+		})
+	}
+}
+
+func TestJobHandler_ExportJobs(t *testing.T) {
+	now := time.Now().UTC()
+	afterID := "550e8400-e29b-41d4-a716-446655440000"
+
+	export := func(r http.Handler, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/export"+query, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	exportJobs := func(n int, err error) func(context.Context, storage.JobFilter, func(*model.Job) error) error {
+		return func(_ context.Context, _ storage.JobFilter, write func(*model.Job) error) error {
+			for i := 0; i < n; i++ {
+				job := model.Job{
+					JobID:     fmt.Sprintf("job-%03d", i),
+					JobType:   "send_email",
+					Payload:   `{"to":"a@b.c"}`,
+					Status:    domain.JobStatusCompleted,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				if err := write(&job); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	t.Run("streams ndjson oldest first", func(t *testing.T) {
+		store := &mocks.JobStorage{ExportJobsFunc: exportJobs(250, nil)}
+
+		w := export(newTestRouter(store), "?status=COMPLETED", mimeNDJSON)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, mimeNDJSON, w.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		require.Len(t, lines, 250)
+		var last dto.JobDTO
+		require.NoError(t, json.Unmarshal([]byte(lines[249]), &last))
+		assert.Equal(t, "job-249", last.JobID)
+
+		require.Len(t, store.ListFilters, 1)
+		assert.Equal(t, []string{domain.JobStatusCompleted}, store.ListFilters[0].Statuses)
+		assert.Equal(t, storage.JobSort{Ascending: true}, store.ListFilters[0].Sort)
+		assert.Nil(t, store.ListFilters[0].Cursor)
+	})
+
+	t.Run("streams csv with a header row", func(t *testing.T) {
+		store := &mocks.JobStorage{ExportJobsFunc: exportJobs(2, nil)}
+
+		w := export(newTestRouter(store), "", mimeCSV)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, exportCSVHeader, records[0])
+		assert.Equal(t, "job-001", records[2][0])
+		assert.Equal(t, `{"to":"a@b.c"}`, records[2][7])
+	})
+
+	t.Run("empty csv export has only the header row", func(t *testing.T) {
+		store := &mocks.JobStorage{ExportJobsFunc: exportJobs(0, nil)}
+
+		w := export(newTestRouter(store), "", mimeCSV)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strings.Join(exportCSVHeader, ",")+"\n", w.Body.String())
+	})
+
+	t.Run("resumes after a job", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobByIDFunc: func(_ context.Context, id string) (*model.Job, error) {
+				return &model.Job{JobID: id, CreatedAt: now}, nil
+			},
+			ExportJobsFunc: exportJobs(1, nil),
+		}
+
+		w := export(newTestRouter(store), "?after="+afterID, mimeNDJSON)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, store.ListFilters, 1)
+		assert.Equal(t, &storage.JobCursor{Sort: storage.JobSort{Ascending: true}, TimeKey: now, JobID: afterID}, store.ListFilters[0].Cursor)
+	})
+
+	t.Run("unknown after job", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobByIDFunc: func(context.Context, string) (*model.Job, error) {
+				return nil, domain.ErrJobNotFound
+			},
+		}
+
+		w := export(newTestRouter(store), "?after="+afterID, mimeNDJSON)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.ListFilters)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"?after=job-1", "?status=DONE", "?sort=priority"} {
+			store := &mocks.JobStorage{}
+			w := export(newTestRouter(store), query, mimeNDJSON)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			assert.Empty(t, store.ListFilters, query)
+		}
+	})
+
+	t.Run("unsupported formats are not acceptable", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		w := export(newTestRouter(store), "", "application/json")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Empty(t, store.ListFilters)
+	})
+
+	t.Run("failure before the first job gets an error response", func(t *testing.T) {
+		store := &mocks.JobStorage{ExportJobsFunc: exportJobs(0, errors.New("boom"))}
+
+		w := export(newTestRouter(store), "", mimeNDJSON)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("failure after the first job ends with an error line", func(t *testing.T) {
+		store := &mocks.JobStorage{ExportJobsFunc: exportJobs(3, errors.New("connection reset"))}
+
+		w := export(newTestRouter(store), "", mimeNDJSON)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasSuffix(w.Body.String(), `{"error":"Failed to export jobs"}`+"\n"))
+	})
+}
@@ -1,20 +1,47 @@
 package handler
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cuongbtq/practice-be/internal/api/domain"
 	"github.com/cuongbtq/practice-be/internal/api/dto"
 	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/router/stream"
 	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/broker"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// maxMetadataBytes caps client-supplied job metadata
+const maxMetadataBytes = 4 << 10
+
+// maxListOffset caps offset pagination; deeper pages must use cursors
+const maxListOffset = 10000
+
+const (
+	paginationCursor = "cursor"
+	paginationOffset = "offset"
+)
+
+// mimeNDJSON is the ListJobs format that streams every matching job, one per line
+const mimeNDJSON = "application/x-ndjson"
+
+// listStreamBatchSize is how many jobs an NDJSON listing fetches per query
+const listStreamBatchSize = 100
+
 // CreateJob handles POST /api/v1/jobs
 // Creates a new background job for processing
 func (h *JobHandler) CreateJob(c *gin.Context) {
@@ -28,10 +55,13 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 	var req dto.CreateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid request body", slog.String("error", err.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	if fields := append(h.checkUserID(req.UserID), h.checkJobType("job_type", req.JobType)...); len(fields) > 0 {
+		h.logger.Warn("Invalid request fields", slog.Any("fields", fields))
+		c.JSON(http.StatusBadRequest, invalidFields("Invalid request body", fields))
 		return
 	}
 
@@ -46,6 +76,24 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
+	if h.respondPayloadTooLarge(c, len(req.Payload)) {
+		return
+	}
+
+	if h.respondPayloadInvalid(c, req.JobType, []byte(req.Payload)) {
+		return
+	}
+
+	metadata, err := parseMetadata(req.Metadata)
+	if err != nil {
+		h.logger.Error("Invalid metadata", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid metadata",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	// 2. Check idempotency key
 
 	job := model.Job{
@@ -54,43 +102,38 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		UserID:         req.UserID,
 		JobType:        req.JobType,
 		Payload:        req.Payload,
+		Metadata:       metadata,
 		Status:         "PENDING",
 		CreatedAt:      time.Now().UTC(),
 		UpdatedAt:      time.Now().UTC(),
 	}
+	if req.OrderingKey != "" {
+		job.OrderingKey = &req.OrderingKey
+	}
+	if req.ExecuteAfter != nil {
+		executeAfter := req.ExecuteAfter.UTC()
+		job.ExecuteAfter = &executeAfter
+	}
+	// The dependency resolver moves the job to PENDING once its dependencies complete
+	if len(req.DependsOn) > 0 {
+		job.DependsOn = uniqueStrings(req.DependsOn)
+		job.Status = domain.JobStatusWaiting
+	}
 
-	// 3. Create job record in database
-	err := h.storage.CreateJob(c.Request.Context(), &job)
-	if err != nil {
-		h.logger.Error("Failed to create job", slog.String("error", err.Error()))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create job",
-		})
+	// 3. Create job record in database and publish it; the job is not created if
+	// publishing fails. In async mode it is queued in the outbox instead.
+	if !h.insertJob(c, &job, h.asyncCreate) {
 		return
 	}
 
-	// 4. Publish message to RabbitMQ (Implement later)
-	// err = h.rabbitClient.Publish(c.Request.Context(), []byte(req.Payload), "application/json")
-	// if err != nil {
-	// 	h.logger.Error("Failed to publish job to RabbitMQ", slog.String("error", err.Error()))
-	// 	c.JSON(http.StatusInternalServerError, gin.H{
-	// 		"error":   "Failed to publish job",
-	// 		"details": err.Error(),
-	// 	})
-	// 	return
-	// }
-
-	// 5. Return job response
-	c.JSON(http.StatusCreated, dto.JobDTO{
-		JobID:          job.JobID,
-		IdempotencyKey: job.IdempotencyKey,
-		UserID:         job.UserID,
-		JobType:        job.JobType,
-		Payload:        job.Payload,
-		Status:         job.Status,
-		CreatedAt:      job.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:      job.UpdatedAt.Format(time.RFC3339),
-	})
+	// 4. Return job response. In async mode the job is only acknowledged; the outbox
+	// relay publishes it, and clients follow Location for its progress.
+	if h.asyncCreate {
+		c.Header("Location", "/api/v1/jobs/"+job.JobID)
+		c.JSON(http.StatusAccepted, toJobDTO(&job))
+		return
+	}
+	c.JSON(http.StatusCreated, toJobDTO(&job))
 }
 
 // GetJob handles GET /api/v1/jobs/:job_id
@@ -123,6 +166,22 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 			return
 		}
 
+		if storage.IsUnavailable(err) {
+			// The cache holds the jobs of every tenant
+			if stale, ok := h.policies.StaleJob(jobID); ok && stale.TenantID == tenant.ID(c.Request.Context()) {
+				h.logger.Warn("Database unavailable, serving stale job", slog.String("job_id", jobID))
+				c.Header("Warning", `110 - "Response is Stale"`)
+				resp := toJobDTO(stale)
+				h.health.annotateHealth(&resp, stale, time.Now())
+				c.JSON(http.StatusOK, resp)
+				return
+			}
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
 		h.logger.Error("Failed to get job", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get job",
@@ -130,17 +189,90 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 		return
 	}
 
-	// 3. Return job details
-	c.JSON(http.StatusOK, dto.JobDTO{
-		JobID:          job.JobID,
-		IdempotencyKey: job.IdempotencyKey,
-		UserID:         job.UserID,
-		JobType:        job.JobType,
-		Payload:        job.Payload,
-		Status:         job.Status,
-		CreatedAt:      job.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:      job.UpdatedAt.Format(time.RFC3339),
-	})
+	h.policies.RememberJob(job)
+
+	// 3. Return job details, with a download URL for an offloaded result
+	resp := toJobDTO(job)
+	h.health.annotateHealth(&resp, job, time.Now())
+	h.setResultURL(c.Request.Context(), &resp, job)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJobByIdempotencyKey handles GET /api/v1/jobs/by-idempotency-key/:key
+// Recovers the job a user created with an idempotency key, for clients that lost the
+// create response
+func (h *JobHandler) GetJobByIdempotencyKey(c *gin.Context) {
+	key := c.Param("key")
+	userID := c.Query("user_id")
+	h.logger.Info("GetJobByIdempotencyKey called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("user_id", userID),
+	)
+
+	// 1. Validate the key and the user it is scoped to
+	if len(key) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "idempotency key must be at most 255 characters",
+		})
+		return
+	}
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id is required",
+		})
+		return
+	}
+
+	// 2. Query job from database
+	job, err := h.storage.GetJobByIdempotencyKey(c.Request.Context(), userID, key)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to get job", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job",
+		})
+		return
+	}
+
+	h.policies.RememberJob(job)
+
+	// 3. Return job details, like GetJob
+	resp := toJobDTO(job)
+	h.health.annotateHealth(&resp, job, time.Now())
+	h.setResultURL(c.Request.Context(), &resp, job)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// setResultURL presigns the download URL of an offloaded result
+func (h *JobHandler) setResultURL(ctx context.Context, resp *dto.JobDTO, job *model.Job) {
+	if job.ResultRef == nil {
+		return
+	}
+
+	url, err := h.results.DownloadURL(ctx, *job.ResultRef)
+	if err != nil {
+		// The rest of the job is still useful; clients can retry for the URL
+		h.logger.Error("Failed to presign result URL",
+			slog.String("job_id", job.JobID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	resp.ResultURL = url
 }
 
 // ListJobs handles GET /api/v1/jobs
@@ -153,14 +285,19 @@ func (h *JobHandler) ListJobs(c *gin.Context) {
 	)
 
 	// TODO: Implement list jobs logic
-	// 1. Parse query parameters (status, job_type, user_id, limit, offset, sort)
+	// 1. Negotiate the response format and parse query parameters (status, job_type, user_id, limit, offset, sort)
+	format := c.NegotiateFormat(gin.MIMEJSON, mimeNDJSON)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"error": "Jobs can be listed as " + gin.MIMEJSON + " or " + mimeNDJSON,
+		})
+		return
+	}
+
 	var req dto.ListJobsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		h.logger.Error("Invalid query parameters", slog.String("error", err.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid query parameters",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid query parameters", err))
 		return
 	}
 
@@ -174,7 +311,7 @@ func (h *JobHandler) ListJobs(c *gin.Context) {
 	}
 
 	// 3. Decode cursor for pagination
-	cursor, err := DecodeJobCursor(req.Cursor)
+	cursor, err := h.cursors.Decode(req.Cursor)
 	if err != nil {
 		h.logger.Error("Invalid cursor", slog.String("error", err.Error()))
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -186,16 +323,41 @@ func (h *JobHandler) ListJobs(c *gin.Context) {
 	h.logger.Debug("Decoded cursor", slog.Any("cursor", cursor))
 
 	// 4. Build filter and query jobs from database
-	filter := storage.JobFilter{
-		UserID:   req.UserID,
-		JobType:  req.JobType,
-		Status:   req.Status,
-		PageSize: req.PageSize,
-		Cursor:   cursor,
+	filter, err := parseJobFilter(&req)
+	if err != nil {
+		h.logger.Error("Invalid query parameters", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+	if cursor != nil && cursor.Sort != filter.Sort {
+		h.logger.Error("Cursor does not match sort", slog.String("sort", req.Sort))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Cursor was issued for a different sort order",
+		})
+		return
+	}
+	filter.Cursor = cursor
+
+	// NDJSON streams every matching job rather than one page
+	if format == mimeNDJSON {
+		if req.Pagination == paginationOffset {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Offset pagination is not supported with " + mimeNDJSON,
+			})
+			return
+		}
+		filter.PageSize = listStreamBatchSize
 	}
 
 	jobs, err := h.storage.ListJobs(c.Request.Context(), filter)
 	if err != nil {
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
 		h.logger.Error("Failed to list jobs", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to list jobs",
@@ -203,34 +365,27 @@ func (h *JobHandler) ListJobs(c *gin.Context) {
 		return
 	}
 
+	if format == mimeNDJSON {
+		h.streamJobs(c, filter, jobs)
+		return
+	}
+
+	if req.Pagination == paginationOffset {
+		h.listJobsPage(c, &req, filter, jobs)
+		return
+	}
+
 	// 5. Prepare response with next cursor if more results exist
 	hasMore := len(jobs) > req.PageSize
 	if hasMore {
 		jobs = jobs[:req.PageSize]
 	}
 
-	jobResponse := make([]dto.JobDTO, len(jobs))
-	for i, job := range jobs {
-		jobResponse[i] = dto.JobDTO{
-			JobID:          job.JobID,
-			IdempotencyKey: job.IdempotencyKey,
-			UserID:         job.UserID,
-			JobType:        job.JobType,
-			Payload:        job.Payload,
-			Status:         job.Status,
-			CreatedAt:      job.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:      job.UpdatedAt.Format(time.RFC3339),
-		}
-	}
+	jobResponse := h.listedJobDTOs(jobs)
 
 	var nextCursor string
 	if hasMore {
-		lastJob := jobs[len(jobs)-1]
-		cursorObj := storage.JobCursor{
-			CreatedAt: lastJob.CreatedAt,
-			JobID:     lastJob.JobID,
-		}
-		nextCursor, err = EncodeJobCursor(&cursorObj)
+		nextCursor, err = h.cursors.Encode(storage.NewJobCursor(filter.Sort, &jobs[len(jobs)-1]))
 		if err != nil {
 			h.logger.Error("Failed to encode next cursor", slog.String("error", err.Error()))
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -243,11 +398,105 @@ func (h *JobHandler) ListJobs(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.ListJobsResponse{
 		Jobs:       jobResponse,
 		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		Count:      len(jobResponse),
+		Filters:    appliedJobFilters(&req, filter),
 	})
 }
 
+// listJobsPage writes an offset pagination response with total counts
+func (h *JobHandler) listJobsPage(c *gin.Context, req *dto.ListJobsRequest, filter storage.JobFilter, jobs []model.Job) {
+	total, err := h.storage.CountJobs(c.Request.Context(), filter)
+	if err != nil {
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to count jobs", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count jobs",
+		})
+		return
+	}
+
+	if len(jobs) > req.PageSize {
+		jobs = jobs[:req.PageSize]
+	}
+
+	jobResponse := h.listedJobDTOs(jobs)
+
+	pageSize := int64(req.PageSize)
+	totalPages := (total + pageSize - 1) / pageSize
+	c.JSON(http.StatusOK, dto.ListJobsResponse{
+		Jobs:       jobResponse,
+		HasMore:    int64(req.Page) < totalPages,
+		Count:      len(jobResponse),
+		Filters:    appliedJobFilters(req, filter),
+		Page:       req.Page,
+		TotalCount: &total,
+		TotalPages: &totalPages,
+	})
+}
+
+// streamJobs writes first and every later page of jobs matching filter as NDJSON, one
+// job per line. A failure after the first line ends the stream with an {"error": ...}
+// line, since the status code has already been sent.
+func (h *JobHandler) streamJobs(c *gin.Context, filter storage.JobFilter, first []model.Job) {
+	err := stream.Serve(c, h.logger, stream.Options{ContentType: mimeNDJSON}, func(c *gin.Context, w io.Writer) error {
+		// Flushed once per page rather than once per job
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		jobs := first
+		for {
+			hasMore := len(jobs) > filter.PageSize
+			if hasMore {
+				jobs = jobs[:filter.PageSize]
+			}
+
+			for _, job := range h.listedJobDTOs(jobs) {
+				if err := enc.Encode(job); err != nil {
+					return err
+				}
+			}
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+
+			if !hasMore {
+				return nil
+			}
+
+			var err error
+			filter.Cursor = storage.NewJobCursor(filter.Sort, &jobs[len(jobs)-1])
+			jobs, err = h.storage.ListJobs(c.Request.Context(), filter)
+			if err != nil {
+				_ = enc.Encode(gin.H{"error": "Failed to list jobs"})
+				_ = bw.Flush()
+				return err
+			}
+		}
+	})
+	if err != nil {
+		h.logger.Error("Failed to stream jobs", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list jobs",
+		})
+	}
+}
+
+// listedJobDTOs converts listed jobs to responses with their health flags
+func (h *JobHandler) listedJobDTOs(jobs []model.Job) []dto.JobDTO {
+	now := time.Now()
+	resp := make([]dto.JobDTO, len(jobs))
+	for i := range jobs {
+		resp[i] = toJobDTO(&jobs[i])
+		h.health.annotateHealth(&resp[i], &jobs[i], now)
+	}
+	return resp
+}
+
 // CancelJob handles POST /api/v1/jobs/:job_id/cancel
-// Cancels a pending or running job
+// Cancels a waiting, pending or running job if it is still at the version the client read
 func (h *JobHandler) CancelJob(c *gin.Context) {
 	jobID := c.Param("job_id")
 
@@ -258,29 +507,72 @@ func (h *JobHandler) CancelJob(c *gin.Context) {
 	)
 
 	// 1. Validate job_id format (UUID)
-	// if _, err := uuid.Parse(jobID); err != nil {
-	// 	h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
-	// 	c.JSON(http.StatusBadRequest, gin.H{
-	// 		"error": "job_id must be a valid UUID",
-	// 	})
-	// 	return
-	// }
-
-	// TODO: Implement cancel job logic
-	// 2. Check if job can be canceled (not in terminal state)
-	// 3. Update job status to CANCELED
-	// 4. Signal worker to stop execution (via RabbitMQ or database flag)
-	// 5. Return cancellation response
-
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "CancelJob endpoint - Not implemented yet",
-		"job_id":  jobID,
-		"status":  "todo",
-	})
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Parse request body
+	var req dto.CancelJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	// 3. Cancel the job unless it changed since the client read it
+	job, err := h.storage.CancelJob(c.Request.Context(), jobID, req.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrJobNotFound):
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+		case errors.Is(err, domain.ErrVersionConflict):
+			h.logger.Warn("Job version conflict",
+				slog.String("job_id", jobID),
+				slog.Int("version", req.Version),
+				slog.Int("current_version", job.Version),
+			)
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job was updated concurrently",
+				"job_id":  jobID,
+				"status":  job.Status,
+				"version": job.Version,
+				"message": "Read the job again and retry with its current version",
+			})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			h.logger.Warn("Job cannot be canceled", slog.String("job_id", jobID), slog.String("status", job.Status))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job cannot be canceled",
+				"job_id":  jobID,
+				"status":  job.Status,
+				"version": job.Version,
+				"message": "Only WAITING, PENDING or RUNNING jobs can be canceled",
+			})
+		case storage.IsUnavailable(err):
+			h.respondDatabaseUnavailable(c, err)
+		default:
+			h.logger.Error("Failed to cancel job", slog.String("job_id", jobID), slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to cancel job",
+			})
+		}
+		return
+	}
+
+	h.logger.Info("Job canceled", slog.String("job_id", job.JobID), slog.Int("version", job.Version))
+
+	// 4. Return updated job
+	c.JSON(http.StatusOK, toJobDTO(job))
 }
 
 // DeleteJob handles DELETE /api/v1/jobs/:job_id
-// Permanently deletes a job record from the database
+// Soft-deletes a terminal job; the retention cleaner removes it for good later
 func (h *JobHandler) DeleteJob(c *gin.Context) {
 	jobID := c.Param("job_id")
 
@@ -291,22 +583,549 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 	)
 
 	// 1. Validate job_id format (UUID)
-	// if _, err := uuid.Parse(jobID); err != nil {
-	// 	h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
-	// 	c.JSON(http.StatusBadRequest, gin.H{
-	// 		"error": "job_id must be a valid UUID",
-	// 	})
-	// 	return
-	// }
-
-	// TODO: Implement delete job logic
-	// 2. Check if job is in terminal state (COMPLETED, FAILED, CANCELED)
-	// 3. Delete job record from database
-	// 4. Return 204 No Content on success
-
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"message": "DeleteJob endpoint - Not implemented yet",
-		"job_id":  jobID,
-		"status":  "todo",
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Mark the job deleted if it is in a terminal state (COMPLETED, FAILED, CANCELED)
+	job, err := h.storage.DeleteJob(c.Request.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrJobNotFound):
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+		case errors.Is(err, domain.ErrJobNotDeletable):
+			h.logger.Warn("Job cannot be deleted", slog.String("job_id", jobID), slog.String("status", job.Status))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Cannot delete active job",
+				"job_id":  jobID,
+				"status":  job.Status,
+				"message": "Job must be in terminal state (COMPLETED, FAILED, or CANCELED) before deletion",
+			})
+		case storage.IsUnavailable(err):
+			h.respondDatabaseUnavailable(c, err)
+		default:
+			h.logger.Error("Failed to delete job", slog.String("job_id", jobID), slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to delete job",
+			})
+		}
+		return
+	}
+
+	h.logger.Info("Job deleted", slog.String("job_id", job.JobID))
+
+	// 3. Return 204 No Content on success
+	c.Status(http.StatusNoContent)
+}
+
+// RetryJob handles POST /api/v1/jobs/:job_id/retry
+// Resets a FAILED or CANCELED job back to PENDING and re-queues it
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+
+	h.logger.Info("RetryJob called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_id", jobID),
+	)
+
+	// 1. Validate job_id format (UUID)
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Parse optional request body
+	var req dto.RetryJobRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Invalid request body", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+			return
+		}
+	}
+
+	// 3. Reset job to PENDING and republish it; the reset is rolled back if publishing fails
+	job, err := h.storage.RetryJob(c.Request.Context(), jobID, req.ResetRetryCount, func(job *model.Job) error {
+		return h.publishJob(c.Request.Context(), job)
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrJobNotFound):
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+		case errors.Is(err, domain.ErrJobNotRetryable):
+			h.logger.Warn("Job cannot be retried", slog.String("job_id", jobID), slog.String("status", job.Status))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job cannot be retried",
+				"job_id":  jobID,
+				"status":  job.Status,
+				"message": "Only FAILED or CANCELED jobs can be retried",
+			})
+		case errors.Is(err, broker.ErrMessageTooLarge):
+			h.logger.Error("Job message too large to retry", slog.String("job_id", jobID), slog.String("error", err.Error()))
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Job message exceeds the broker size limit",
+			})
+		case storage.IsUnavailable(err):
+			h.respondDatabaseUnavailable(c, err)
+		case errors.Is(err, broker.ErrCircuitOpen):
+			h.respondBrokerUnavailable(c, err)
+		default:
+			h.logger.Error("Failed to retry job", slog.String("job_id", jobID), slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retry job",
+			})
+		}
+		return
+	}
+
+	h.logger.Info("Job retried",
+		slog.String("job_id", job.JobID),
+		slog.Bool("reset_retry_count", req.ResetRetryCount),
+	)
+
+	// 4. Return updated job
+	c.JSON(http.StatusOK, toJobDTO(job))
+}
+
+// publishJob publishes a job message to the jobs queue, deferring it with the
+// broker_unavailable policy if the broker rejects it
+func (h *JobHandler) publishJob(ctx context.Context, job *model.Job) error {
+	body, err := h.jobMessage(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	contentType := h.codec.ContentType()
+	if err := h.sendJob(ctx, job, body, contentType); err != nil {
+		// Deferring cannot help a message the broker will never accept, and republishing
+		// later could overtake newer jobs with the same ordering key
+		if errors.Is(err, broker.ErrMessageTooLarge) || job.OrderingKey != nil || !h.policies.Defer(job, body, contentType) {
+			return err
+		}
+
+		h.logger.Warn("Broker unavailable, deferring job message",
+			slog.String("job_id", job.JobID),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return nil
+}
+
+// jobMessage encodes the broker message of job with the configured codec
+func (h *JobHandler) jobMessage(ctx context.Context, job *model.Job) ([]byte, error) {
+	// Background tasks have no tenant of their own, so workflow steps are looked up as
+	// the tenant of the job
+	if job.TenantID != "" {
+		ctx = tenant.WithID(ctx, job.TenantID)
+	}
+
+	msg := dto.JobMessage{
+		JobID:        job.JobID,
+		UserID:       job.UserID,
+		JobType:      job.JobType,
+		Payload:      json.RawMessage(job.Payload),
+		Metadata:     rawJSON(job.Metadata),
+		OrderingKey:  job.OrderingKey,
+		TenantID:     job.TenantID,
+		ExecuteAfter: job.ExecuteAfter,
+	}
+	// Workflow steps receive the results of the steps that ran before them
+	if job.WorkflowID != nil {
+		wc, err := h.workflowContext(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		msg.Context = wc
+	}
+
+	return h.codec.Marshal(&msg)
+}
+
+// sendJob publishes the encoded message of job once, keyed for ordered publishers
+func (h *JobHandler) sendJob(ctx context.Context, job *model.Job, body []byte, contentType string) error {
+	if h.publisher == nil {
+		return errors.New("job publisher is not configured")
+	}
+
+	if ordered, ok := h.publisher.(OrderedPublisher); ok {
+		// Unkeyed jobs use their own ID so they still spread across partitions
+		key := job.JobID
+		if job.OrderingKey != nil {
+			key = *job.OrderingKey
+		}
+		return ordered.PublishOrdered(ctx, key, body, contentType)
+	}
+	return h.publisher.Publish(ctx, body, contentType)
+}
+
+// insertJob stores a new job, applying the backlog throttling policy and the tenant's
+// quota first, and publishes it unless it waits for dependencies. With queue, the job is
+// written to the outbox for the outbox relay to publish instead. It writes the error
+// response and returns false if the job was not created.
+func (h *JobHandler) insertJob(c *gin.Context, job *model.Job, queue bool) bool {
+	// Throttle new jobs while the PENDING backlog is over the configured limit
+	if throttled, retryAfter := h.policies.Throttled(c.Request.Context(), h.countPendingJobs); throttled {
+		h.logger.Warn("Job creation throttled, queue backlog too large")
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Job queue is backed up, retry later",
+		})
+		return false
+	}
+
+	if h.respondQuotaExceeded(c, []model.Job{*job}) {
+		return false
+	}
+
+	if err := h.createJob(c.Request.Context(), job, queue); err != nil {
+		if errors.Is(err, domain.ErrIdempotencyConflict) {
+			h.logger.Warn("Duplicate idempotency key", slog.String("idempotency_key", job.IdempotencyKey))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":           "Job with this idempotency key already exists",
+				"idempotency_key": job.IdempotencyKey,
+			})
+			return false
+		}
+
+		if errors.Is(err, domain.ErrDependencyNotFound) {
+			h.logger.Warn("Unknown job dependency", slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Dependency job not found",
+				"details": err.Error(),
+			})
+			return false
+		}
+
+		if errors.Is(err, broker.ErrMessageTooLarge) {
+			h.logger.Error("Job message too large to publish", slog.String("job_id", job.JobID), slog.String("error", err.Error()))
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Job message exceeds the broker size limit",
+			})
+			return false
+		}
+
+		if errors.Is(err, broker.ErrCircuitOpen) {
+			h.respondBrokerUnavailable(c, err)
+			return false
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return false
+		}
+
+		h.logger.Error("Failed to create job", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create job",
+		})
+		return false
+	}
+
+	return true
+}
+
+// createJob stores job, publishing or queueing it if it is PENDING. The dependency
+// resolver publishes WAITING jobs once they are promoted.
+func (h *JobHandler) createJob(ctx context.Context, job *model.Job, queue bool) error {
+	switch {
+	case job.Status != domain.JobStatusPending:
+		return h.storage.CreateJob(ctx, job, nil)
+	case queue:
+		return h.storage.QueueJob(ctx, job)
+	default:
+		return h.storage.CreateJob(ctx, job, func(job *model.Job) error {
+			return h.publishJob(ctx, job)
+		})
+	}
+}
+
+// countPendingJobs returns the PENDING backlog for the throttling policy
+func (h *JobHandler) countPendingJobs(ctx context.Context) (int64, error) {
+	return h.storage.CountJobsByStatus(ctx, domain.JobStatusPending)
+}
+
+// respondPayloadTooLarge writes a 413 response and returns true when a payload of size
+// bytes would not fit in a broker message
+func (h *JobHandler) respondPayloadTooLarge(c *gin.Context, size int) bool {
+	if h.maxPayload <= 0 || size <= h.maxPayload {
+		return false
+	}
+
+	h.logger.Warn("Job payload too large", slog.Int("size", size), slog.Int("limit", h.maxPayload))
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":             "Payload too large",
+		"max_payload_bytes": h.maxPayload,
+	})
+	return true
+}
+
+// respondPayloadInvalid writes a 400 response and returns true when payload does not
+// match the schema registered for jobType
+func (h *JobHandler) respondPayloadInvalid(c *gin.Context, jobType string, payload []byte) bool {
+	err := h.schemas.Validate(jobType, payload)
+	if err == nil {
+		return false
+	}
+
+	h.logger.Warn("Job payload does not match schema", slog.String("job_type", jobType), slog.String("error", err.Error()))
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Payload does not match the schema for job type " + jobType,
+		"details": err.Error(),
+	})
+	return true
+}
+
+// respondDatabaseUnavailable writes a 503 response and returns true when err means
+// the database could not be reached
+func (h *JobHandler) respondDatabaseUnavailable(c *gin.Context, err error) bool {
+	if !storage.IsUnavailable(err) {
+		return false
+	}
+
+	h.logger.Error("Database unavailable", slog.String("error", err.Error()))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "Database unavailable, retry later",
+	})
+	return true
+}
+
+// respondBrokerUnavailable writes a 503 response with a Retry-After header when err is a
+// publish failed fast by the broker circuit breaker
+func (h *JobHandler) respondBrokerUnavailable(c *gin.Context, err error) {
+	retryAfter := time.Second
+	var open *broker.CircuitOpenError
+	if errors.As(err, &open) && open.RetryAfter > retryAfter {
+		retryAfter = open.RetryAfter
+	}
+
+	h.logger.Warn("Broker unavailable, circuit breaker is open", slog.String("error", err.Error()))
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "Message broker unavailable, retry later",
+	})
+}
+
+// parseMetadata validates client-supplied metadata and returns it for storage.
+// Absent or null metadata is stored as NULL.
+func parseMetadata(raw json.RawMessage) (*string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	if len(raw) > maxMetadataBytes {
+		return nil, fmt.Errorf("metadata must be at most %d bytes", maxMetadataBytes)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+
+	metadata := string(raw)
+	return &metadata, nil
+}
+
+// parseJobFilter validates the ListJobs filters and converts them to a storage filter
+func parseJobFilter(req *dto.ListJobsRequest) (storage.JobFilter, error) {
+	filter := storage.JobFilter{
+		UserID:        req.UserID,
+		JobType:       req.JobType,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		ErrorQuery:    strings.TrimSpace(req.Query),
+		PageSize:      req.PageSize,
+	}
+
+	for _, status := range strings.Split(req.Status, ",") {
+		status = strings.ToUpper(strings.TrimSpace(status))
+		if status == "" {
+			continue
+		}
+		switch status {
+		case domain.JobStatusWaiting, domain.JobStatusPending, domain.JobStatusRunning,
+			domain.JobStatusCompleted, domain.JobStatusFailed, domain.JobStatusCanceled:
+			filter.Statuses = append(filter.Statuses, status)
+		default:
+			return storage.JobFilter{}, fmt.Errorf("unknown status %q", status)
+		}
+	}
+
+	sort, err := parseJobSort(req.Sort)
+	if err != nil {
+		return storage.JobFilter{}, err
+	}
+	filter.Sort = sort
+
+	switch req.Pagination {
+	case "", paginationCursor:
+	case paginationOffset:
+		if req.Cursor != "" {
+			return storage.JobFilter{}, errors.New("cursor cannot be combined with offset pagination")
+		}
+		if req.Page < 0 {
+			return storage.JobFilter{}, errors.New("page must be positive")
+		}
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		filter.Offset = (req.Page - 1) * req.PageSize
+		if filter.Offset > maxListOffset {
+			return storage.JobFilter{}, fmt.Errorf("offset pagination is limited to the first %d jobs, use cursor pagination", maxListOffset)
+		}
+	default:
+		return storage.JobFilter{}, fmt.Errorf("unknown pagination %q (must be cursor or offset)", req.Pagination)
+	}
+
+	if !req.CreatedAfter.IsZero() && !req.CreatedBefore.IsZero() && !req.CreatedAfter.Before(req.CreatedBefore) {
+		return storage.JobFilter{}, errors.New("created_after must be before created_before")
+	}
+
+	if req.Payload != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(req.Payload), &fields); err != nil {
+			return storage.JobFilter{}, errors.New("payload must be a JSON object")
+		}
+		filter.PayloadContains = req.Payload
+	}
+
+	return filter, nil
+}
+
+// appliedJobFilters describes the filters ListJobs applied, after defaults and limits
+func appliedJobFilters(req *dto.ListJobsRequest, filter storage.JobFilter) dto.ListJobsFilters {
+	applied := dto.ListJobsFilters{
+		UserID:     filter.UserID,
+		JobType:    filter.JobType,
+		Statuses:   filter.Statuses,
+		Query:      filter.ErrorQuery,
+		Sort:       formatJobSort(filter.Sort),
+		PageSize:   req.PageSize,
+		Pagination: paginationCursor,
+	}
+	if req.Pagination == paginationOffset {
+		applied.Pagination = paginationOffset
+	}
+	if !filter.CreatedAfter.IsZero() {
+		applied.CreatedAfter = formatTime(&filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		applied.CreatedBefore = formatTime(&filter.CreatedBefore)
+	}
+	if filter.PayloadContains != "" {
+		applied.Payload = json.RawMessage(filter.PayloadContains)
+	}
+	return applied
+}
+
+// formatJobSort formats sort the way parseJobSort reads it, e.g. created_at_desc
+func formatJobSort(sort storage.JobSort) string {
+	field := sort.Field
+	if field == "" {
+		field = storage.SortCreatedAt
+	}
+	if sort.Ascending {
+		return field + "_asc"
+	}
+	return field + "_desc"
+}
+
+// parseJobSort parses a sort parameter such as updated_at_asc or status. The direction
+// defaults to descending, and an empty value sorts by created_at descending.
+func parseJobSort(value string) (storage.JobSort, error) {
+	if value == "" {
+		return storage.JobSort{}, nil
+	}
+
+	field, ascending := value, false
+	if f, ok := strings.CutSuffix(value, "_asc"); ok {
+		field, ascending = f, true
+	} else if f, ok := strings.CutSuffix(value, "_desc"); ok {
+		field = f
+	}
+
+	switch field {
+	case storage.SortCreatedAt:
+		// Normalized to the zero value so cursors compare equal to the default sort
+		return storage.JobSort{Ascending: ascending}, nil
+	case storage.SortUpdatedAt, storage.SortStatus, storage.SortJobType:
+		return storage.JobSort{Field: field, Ascending: ascending}, nil
+	default:
+		return storage.JobSort{}, fmt.Errorf("unknown sort %q (must be created_at, updated_at, status or job_type, optionally suffixed with _asc or _desc)", value)
+	}
+}
+
+// sortDirection returns the sort parameter suffix for sort
+func sortDirection(sort storage.JobSort) string {
+	if sort.Ascending {
+		return "asc"
+	}
+	return "desc"
+}
+
+// rawJSON returns a nullable JSON column for API responses and job messages
+func rawJSON(value *string) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	return json.RawMessage(*value)
+}
+
+// formatTime formats an optional time as RFC 3339, nil stays nil
+func formatTime(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := value.Format(time.RFC3339)
+	return &formatted
+}
+
+// uniqueStrings returns values without duplicates, keeping the first occurrence of each
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// toJobDTO converts a job model into its API representation
+func toJobDTO(job *model.Job) dto.JobDTO {
+	return dto.JobDTO{
+		JobID:          job.JobID,
+		IdempotencyKey: job.IdempotencyKey,
+		UserID:         job.UserID,
+		JobType:        job.JobType,
+		Payload:        job.Payload,
+		Metadata:       rawJSON(job.Metadata),
+		OrderingKey:    job.OrderingKey,
+		ExecuteAfter:   formatTime(job.ExecuteAfter),
+		DependsOn:      job.DependsOn,
+		WorkflowID:     job.WorkflowID,
+		StepName:       job.StepName,
+		Result:         rawJSON(job.Result),
+		Status:         job.Status,
+		ErrorMessage:   job.ErrorMessage,
+		RetryCount:     job.RetryCount,
+		CreatedAt:      job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      job.UpdatedAt.Format(time.RFC3339),
+		Version:        job.Version,
+	}
 }
@@ -0,0 +1,1711 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/policy"
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/jobstatus"
+	"github.com/cuongbtq/practice-be/shared/messaging"
+	"github.com/cuongbtq/practice-be/shared/resultstore"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakePublisher records published messages and returns err
+type fakePublisher struct {
+	messages     [][]byte
+	contentTypes []string
+	err          error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, body []byte, contentType string) error {
+	p.messages = append(p.messages, body)
+	p.contentTypes = append(p.contentTypes, contentType)
+	return p.err
+}
+
+// newTestRouter wires a JobHandler backed by the given mock storage into a bare gin engine
+func newTestRouter(store *mocks.JobStorage) *gin.Engine {
+	return newTestRouterWithPublisher(store, &fakePublisher{})
+}
+
+func newTestRouterWithPublisher(store *mocks.JobStorage, publisher JobPublisher) *gin.Engine {
+	return newTestRouterWithPolicies(store, publisher, nil)
+}
+
+func newTestRouterWithPolicies(store *mocks.JobStorage, publisher JobPublisher, policies *policy.Engine) *gin.Engine {
+	h := NewJobHandler(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: store,
+		Publisher:  publisher,
+		Policies:   policies,
+	})
+
+	r := gin.New()
+	r.POST("/api/v1/jobs", h.CreateJob)
+	r.GET("/api/v1/jobs", h.ListJobs)
+	r.GET("/api/v1/jobs/:job_id", h.GetJob)
+	r.GET("/api/v1/jobs/by-idempotency-key/:key", h.GetJobByIdempotencyKey)
+	r.DELETE("/api/v1/jobs/:job_id", h.DeleteJob)
+	r.POST("/api/v1/jobs/:job_id/cancel", h.CancelJob)
+	r.POST("/api/v1/jobs/:job_id/retry", h.RetryJob)
+	r.GET("/api/v1/jobs/:job_id/export", h.ExportJob)
+	r.GET("/api/v1/jobs/export", h.ExportJobs)
+	r.GET("/api/v1/jobs/:job_id/events", h.ListJobEvents)
+	r.GET("/api/v1/jobs/:job_id/logs", h.ListJobLogs)
+	r.GET("/api/v1/archive/jobs/:job_id", h.GetArchivedJob)
+	r.POST("/api/v1/jobs/import", h.ImportJob)
+	r.POST("/api/v1/workflows", h.CreateWorkflow)
+	r.GET("/api/v1/workflows/:workflow_id", h.GetWorkflow)
+	r.POST("/api/v1/workflows/:workflow_id/cancel", h.CancelWorkflow)
+	return r
+}
+
+func doRequest(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestJobHandler_CreateJob(t *testing.T) {
+	validBody := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{\"to\":\"a@b.c\"}"}`
+
+	tests := []struct {
+		name       string
+		body       string
+		createErr  error
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "valid request",
+			body:       validBody,
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name:       "missing required fields",
+			body:       `{"job_type":"send_email"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  0,
+		},
+		{
+			name:       "payload is not valid JSON",
+			body:       `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"not-json"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  0,
+		},
+		{
+			name:       "metadata is not an object",
+			body:       `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","metadata":["order-1"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  0,
+		},
+		{
+			name:       "metadata over the size limit",
+			body:       `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","metadata":{"note":"` + strings.Repeat("x", maxMetadataBytes) + `"}}`,
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  0,
+		},
+		{
+			name:       "storage failure",
+			body:       validBody,
+			createErr:  errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  1,
+		},
+		{
+			name:       "duplicate idempotency key",
+			body:       validBody,
+			createErr:  fmt.Errorf("%w: duplicate key", domain.ErrIdempotencyConflict),
+			wantStatus: http.StatusConflict,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{
+				CreateJobFunc: func(context.Context, *model.Job, func(*model.Job) error) error {
+					return tt.createErr
+				},
+			}
+
+			w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Len(t, store.CreatedJobs, tt.wantCalls)
+
+			if tt.wantStatus == http.StatusCreated {
+				var resp dto.JobDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.NotEmpty(t, resp.JobID)
+				assert.Equal(t, domain.JobStatusPending, resp.Status)
+				assert.Equal(t, "send_email", resp.JobType)
+				assert.Equal(t, store.CreatedJobs[0].JobID, resp.JobID)
+			}
+		})
+	}
+}
+
+func TestJobHandler_CreateJob_Async(t *testing.T) {
+	store := &mocks.JobStorage{}
+	publisher := &fakePublisher{}
+	h := NewJobHandler(&Dependencies{
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage:  store,
+		Publisher:   publisher,
+		AsyncCreate: true,
+	})
+	r := gin.New()
+	r.POST("/api/v1/jobs", h.CreateJob)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/jobs",
+		`{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`)
+
+	// The job is queued in the outbox and left to the outbox relay
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Len(t, store.QueuedJobs, 1)
+	assert.Empty(t, store.CreatedJobs)
+	assert.Empty(t, publisher.messages)
+	var resp dto.JobDTO
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, store.QueuedJobs[0].JobID, resp.JobID)
+	assert.Equal(t, "/api/v1/jobs/"+resp.JobID, w.Header().Get("Location"))
+
+	// Jobs waiting for dependencies are published by the dependency resolver instead
+	w = doRequest(r, http.MethodPost, "/api/v1/jobs",
+		`{"idempotency_key":"key-2","user_id":"user-1","job_type":"send_email","payload":"{}","depends_on":["550e8400-e29b-41d4-a716-446655440000"]}`)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Len(t, store.QueuedJobs, 1)
+	require.Len(t, store.CreatedJobs, 1)
+	assert.Equal(t, domain.JobStatusWaiting, store.CreatedJobs[0].Status)
+}
+
+func TestJobHandler_CreateJob_Publish(t *testing.T) {
+	// publishingStore calls publish like Storage does, before the job would be committed
+	publishingStore := func() *mocks.JobStorage {
+		return &mocks.JobStorage{
+			CreateJobFunc: func(_ context.Context, job *model.Job, publish func(*model.Job) error) error {
+				if publish == nil {
+					return nil
+				}
+				if err := publish(job); err != nil {
+					return fmt.Errorf("failed to publish job: %w", err)
+				}
+				return nil
+			},
+		}
+	}
+	parentID := "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name          string
+		body          string
+		publishErr    error
+		wantStatus    int
+		wantPublished int
+	}{
+		{
+			name:          "pending job is published",
+			body:          `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{\"to\":\"a@b.c\"}"}`,
+			wantStatus:    http.StatusCreated,
+			wantPublished: 1,
+		},
+		{
+			name:       "waiting job is left to the dependency resolver",
+			body:       `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","depends_on":["` + parentID + `"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:          "publish failure",
+			body:          `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`,
+			publishErr:    errors.New("channel closed"),
+			wantStatus:    http.StatusInternalServerError,
+			wantPublished: 1,
+		},
+		{
+			name:          "broker circuit open",
+			body:          `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`,
+			publishErr:    &broker.CircuitOpenError{RetryAfter: 2500 * time.Millisecond},
+			wantStatus:    http.StatusServiceUnavailable,
+			wantPublished: 1,
+		},
+		{
+			name:          "message over the broker limit",
+			body:          `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`,
+			publishErr:    broker.ErrMessageTooLarge,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+			wantPublished: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := publishingStore()
+			publisher := &fakePublisher{err: tt.publishErr}
+
+			w := doRequest(newTestRouterWithPublisher(store, publisher), http.MethodPost, "/api/v1/jobs", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Len(t, publisher.messages, tt.wantPublished)
+
+			switch tt.wantStatus {
+			case http.StatusCreated:
+				if tt.wantPublished == 0 {
+					break
+				}
+				var msg dto.JobMessage
+				require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+				assert.Equal(t, store.CreatedJobs[0].JobID, msg.JobID)
+				assert.JSONEq(t, `{"to":"a@b.c"}`, string(msg.Payload))
+			case http.StatusServiceUnavailable:
+				assert.Equal(t, "3", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestJobHandler_GetJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name       string
+		jobID      string
+		job        *model.Job
+		getErr     error
+		wantStatus int
+	}{
+		{
+			name:  "existing job",
+			jobID: jobID,
+			job: &model.Job{
+				JobID:     jobID,
+				JobType:   "send_email",
+				Status:    domain.JobStatusCompleted,
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid uuid",
+			jobID:      "not-a-uuid",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			jobID:      jobID,
+			getErr:     domain.ErrJobNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "storage failure",
+			jobID:      jobID,
+			getErr:     errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{
+				GetJobByIDFunc: func(_ context.Context, _ string) (*model.Job, error) {
+					return tt.job, tt.getErr
+				},
+			}
+
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+tt.jobID, "")
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var resp dto.JobDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.jobID, resp.JobID)
+				assert.Equal(t, domain.JobStatusCompleted, resp.Status)
+			}
+		})
+	}
+}
+
+func TestJobHandler_GetJobByIdempotencyKey(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name       string
+		path       string
+		job        *model.Job
+		getErr     error
+		wantStatus int
+	}{
+		{
+			name:       "existing job",
+			path:       "/order-42?user_id=user-1",
+			job:        &model.Job{JobID: jobID, IdempotencyKey: "order-42", UserID: "user-1", Status: domain.JobStatusPending},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing user_id",
+			path:       "/order-42",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "key too long",
+			path:       "/" + strings.Repeat("k", 256) + "?user_id=user-1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			path:       "/order-42?user_id=user-2",
+			getErr:     domain.ErrJobNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "storage failure",
+			path:       "/order-42?user_id=user-1",
+			getErr:     errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser, gotKey string
+			store := &mocks.JobStorage{
+				GetJobByIdempotencyKeyFunc: func(_ context.Context, userID, key string) (*model.Job, error) {
+					gotUser, gotKey = userID, key
+					return tt.job, tt.getErr
+				},
+			}
+
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/by-idempotency-key"+tt.path, "")
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var resp dto.JobDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, jobID, resp.JobID)
+				assert.Equal(t, "user-1", gotUser)
+				assert.Equal(t, "order-42", gotKey)
+			}
+		})
+	}
+}
+
+// fakeResultStore presigns refs as https://results.example/<ref>
+type fakeResultStore struct {
+	err error
+}
+
+func (f fakeResultStore) Save(context.Context, string, []byte) (resultstore.Stored, error) {
+	return resultstore.Stored{}, errors.New("not implemented")
+}
+
+func (f fakeResultStore) DownloadURL(_ context.Context, ref string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://results.example/" + ref, nil
+}
+
+func (f fakeResultStore) Delete(context.Context, string) error {
+	return f.err
+}
+
+func TestJobHandler_GetJob_Results(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	inline := `{"sent":true}`
+	ref := "s3://results/550e8400.json"
+
+	getJob := func(results resultstore.Store, job *model.Job) dto.JobDTO {
+		h := NewJobHandler(&Dependencies{
+			Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: &mocks.JobStorage{
+				GetJobByIDFunc: func(context.Context, string) (*model.Job, error) { return job, nil },
+			},
+			Results: results,
+		})
+		r := gin.New()
+		r.GET("/api/v1/jobs/:job_id", h.GetJob)
+
+		w := doRequest(r, http.MethodGet, "/api/v1/jobs/"+jobID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("inline result is returned as-is", func(t *testing.T) {
+		resp := getJob(fakeResultStore{}, &model.Job{JobID: jobID, Result: &inline})
+		assert.JSONEq(t, inline, string(resp.Result))
+		assert.Empty(t, resp.ResultURL)
+	})
+
+	t.Run("offloaded result gets a download URL", func(t *testing.T) {
+		resp := getJob(fakeResultStore{}, &model.Job{JobID: jobID, ResultRef: &ref})
+		assert.Nil(t, resp.Result)
+		assert.Equal(t, "https://results.example/"+ref, resp.ResultURL)
+	})
+
+	t.Run("presign failure still returns the job", func(t *testing.T) {
+		resp := getJob(fakeResultStore{err: errors.New("invalid reference")}, &model.Job{JobID: jobID, ResultRef: &ref})
+		assert.Equal(t, jobID, resp.JobID)
+		assert.Empty(t, resp.ResultURL)
+	})
+}
+
+func TestJobHandler_ListJobs(t *testing.T) {
+	now := time.Now().UTC()
+	makeJobs := func(n int) []model.Job {
+		jobs := make([]model.Job, n)
+		for i := range jobs {
+			jobs[i] = model.Job{
+				JobID:     "job-" + string(rune('a'+i)),
+				Status:    domain.JobStatusPending,
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+				UpdatedAt: now,
+			}
+		}
+		return jobs
+	}
+
+	t.Run("returns next cursor when more results exist", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				return makeJobs(filter.PageSize + 1), nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs?page_size=2&status=PENDING", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListJobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Jobs, 2)
+		require.NotEmpty(t, resp.NextCursor)
+		assert.True(t, resp.HasMore)
+		assert.Equal(t, 2, resp.Count)
+
+		cursor, err := DecodeJobCursor(resp.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, "job-b", cursor.JobID)
+
+		require.Len(t, store.ListFilters, 1)
+		assert.Equal(t, []string{domain.JobStatusPending}, store.ListFilters[0].Statuses)
+		assert.Nil(t, store.ListFilters[0].Cursor)
+	})
+
+	t.Run("no next cursor on last page", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, _ storage.JobFilter) ([]model.Job, error) {
+				return makeJobs(1), nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs?page_size=2", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListJobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Jobs, 1)
+		assert.Empty(t, resp.NextCursor)
+		assert.False(t, resp.HasMore)
+		assert.Equal(t, 1, resp.Count)
+	})
+
+	t.Run("echoes the applied filters", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		query := "?user_id=user-1&job_type=send_email&status=failed,CANCELED&created_after=2026-10-01T00:00:00Z" +
+			"&payload=%7B%22to%22%3A%22a%40example.com%22%7D&q=timeout&sort=updated_at&page_size=500"
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Filters json.RawMessage `json:"filters"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.JSONEq(t, `{
+			"user_id": "user-1",
+			"job_type": "send_email",
+			"status": ["FAILED", "CANCELED"],
+			"created_after": "2026-10-01T00:00:00Z",
+			"payload": {"to": "a@example.com"},
+			"q": "timeout",
+			"sort": "updated_at_desc",
+			"page_size": 100,
+			"pagination": "cursor"
+		}`, string(resp.Filters))
+
+		// Without parameters the defaults are echoed
+		w = doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/jobs", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.JSONEq(t, `{"sort": "created_at_desc", "page_size": 10, "pagination": "cursor"}`, string(resp.Filters))
+	})
+
+	t.Run("page size is clamped", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  int
+		}{
+			{query: "", want: 10},
+			{query: "?page_size=0", want: 10},
+			{query: "?page_size=500", want: 100},
+		}
+
+		for _, tt := range tests {
+			store := &mocks.JobStorage{}
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+tt.query, "")
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Len(t, store.ListFilters, 1)
+			assert.Equal(t, tt.want, store.ListFilters[0].PageSize, "query %q", tt.query)
+		}
+	})
+
+	t.Run("search filters", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		query := "?status=failed,%20CANCELED&created_after=2025-12-01T00:00:00Z&created_before=2025-12-02T00:00:00Z" +
+			"&payload=%7B%22customer_id%22%3A%22c-1%22%7D&q=timeout"
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, store.ListFilters, 1)
+		filter := store.ListFilters[0]
+		assert.Equal(t, []string{domain.JobStatusFailed, domain.JobStatusCanceled}, filter.Statuses)
+		assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), filter.CreatedAfter.UTC())
+		assert.Equal(t, time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC), filter.CreatedBefore.UTC())
+		assert.Equal(t, `{"customer_id":"c-1"}`, filter.PayloadContains)
+		assert.Equal(t, "timeout", filter.ErrorQuery)
+	})
+
+	t.Run("invalid search filters", func(t *testing.T) {
+		for _, query := range []string{
+			"?status=PENDING,DONE",
+			"?created_after=yesterday",
+			"?created_after=2025-12-02T00:00:00Z&created_before=2025-12-01T00:00:00Z",
+			"?payload=%5B1%5D",
+		} {
+			store := &mocks.JobStorage{}
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, "query %q", query)
+			assert.Empty(t, store.ListFilters, "query %q", query)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs?cursor=bm90LWEtY3Vyc29y", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.ListFilters)
+	})
+
+	t.Run("signed cursors", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				return makeJobs(filter.PageSize + 1), nil
+			},
+		}
+		h := NewJobHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  &fakePublisher{},
+			CursorKey:  strings.Repeat("k", 32),
+		})
+		r := gin.New()
+		r.GET("/api/v1/jobs", h.ListJobs)
+
+		w := doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp dto.ListJobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2&cursor="+url.QueryEscape(resp.NextCursor), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NotNil(t, store.ListFilters[1].Cursor)
+
+		// A forged cursor, unsigned or with the signature of another cursor, is refused
+		forged, err := EncodeJobCursor(&storage.JobCursor{TimeKey: time.Now(), JobID: "job-z"})
+		require.NoError(t, err)
+		signature := resp.NextCursor[strings.LastIndex(resp.NextCursor, ".")+1:]
+		for _, cursor := range []string{forged, forged + "." + signature} {
+			w = doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2&cursor="+url.QueryEscape(cursor), "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, cursor)
+			assert.JSONEq(t, `{"error":"Invalid cursor"}`, w.Body.String())
+		}
+		assert.Len(t, store.ListFilters, 2)
+	})
+
+	t.Run("sort is applied and carried in the next cursor", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				return makeJobs(filter.PageSize + 1), nil
+			},
+		}
+		r := newTestRouter(store)
+
+		w := doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2&sort=status_asc", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp dto.ListJobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		sortByStatus := storage.JobSort{Field: storage.SortStatus, Ascending: true}
+		assert.Equal(t, sortByStatus, store.ListFilters[0].Sort)
+
+		cursor, err := DecodeJobCursor(resp.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, &storage.JobCursor{Sort: sortByStatus, TextKey: domain.JobStatusPending, JobID: "job-b"}, cursor)
+
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2&sort=status_asc&cursor="+resp.NextCursor, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, cursor, store.ListFilters[1].Cursor)
+
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs?page_size=2&sort=created_at&cursor="+resp.NextCursor, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "cursor from another sort order")
+		assert.Len(t, store.ListFilters, 2)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		for _, query := range []string{"?sort=payload", "?sort=created_at_up", "?sort=_asc"} {
+			store := &mocks.JobStorage{}
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, "query %q", query)
+			assert.Empty(t, store.ListFilters, "query %q", query)
+		}
+	})
+
+	t.Run("offset pagination returns totals", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				return makeJobs(filter.PageSize + 1), nil
+			},
+			CountJobsFunc: func(context.Context, storage.JobFilter) (int64, error) {
+				return 45, nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs?pagination=offset&page=3&page_size=20", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp dto.ListJobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Jobs, 20)
+		assert.Empty(t, resp.NextCursor)
+		assert.Equal(t, 3, resp.Page)
+		require.NotNil(t, resp.TotalCount)
+		assert.Equal(t, int64(45), *resp.TotalCount)
+		require.NotNil(t, resp.TotalPages)
+		assert.Equal(t, int64(3), *resp.TotalPages)
+		assert.False(t, resp.HasMore, "the last page")
+		assert.Equal(t, 20, resp.Count)
+		assert.Equal(t, "offset", resp.Filters.Pagination)
+
+		require.Len(t, store.ListFilters, 1)
+		assert.Equal(t, 40, store.ListFilters[0].Offset)
+	})
+
+	t.Run("cursor pagination omits totals", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/jobs", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "total_count")
+	})
+
+	t.Run("invalid offset pagination", func(t *testing.T) {
+		for _, query := range []string{
+			"?pagination=pages",
+			"?pagination=offset&page=-1",
+			"?pagination=offset&page=2&cursor=abc",
+			"?pagination=offset&page=1000&page_size=100",
+		} {
+			store := &mocks.JobStorage{}
+			w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, "query %q", query)
+			assert.Empty(t, store.ListFilters, "query %q", query)
+		}
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, _ storage.JobFilter) ([]model.Job, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs", "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestJobHandler_ListJobs_NDJSON(t *testing.T) {
+	now := time.Now().UTC()
+	makeJobs := func(prefix string, n int) []model.Job {
+		jobs := make([]model.Job, n)
+		for i := range jobs {
+			jobs[i] = model.Job{
+				JobID:     fmt.Sprintf("%s-%03d", prefix, i),
+				Status:    domain.JobStatusFailed,
+				CreatedAt: now.Add(-time.Duration(i) * time.Second),
+				UpdatedAt: now,
+			}
+		}
+		return jobs
+	}
+
+	list := func(r http.Handler, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs"+query, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("streams every page", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				if filter.Cursor == nil {
+					return makeJobs("first", filter.PageSize+1), nil
+				}
+				return makeJobs("second", 2), nil
+			},
+		}
+
+		w := list(newTestRouter(store), "?status=FAILED&page_size=5", "application/x-ndjson")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		require.Len(t, lines, listStreamBatchSize+2)
+		var last dto.JobDTO
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+		assert.Equal(t, "second-001", last.JobID)
+
+		// page_size is replaced by the batch size, and the second query resumes after the first
+		require.Len(t, store.ListFilters, 2)
+		assert.Equal(t, listStreamBatchSize, store.ListFilters[0].PageSize)
+		require.NotNil(t, store.ListFilters[1].Cursor)
+		assert.Equal(t, fmt.Sprintf("first-%03d", listStreamBatchSize-1), store.ListFilters[1].Cursor.JobID)
+	})
+
+	t.Run("failure after the first page ends with an error line", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobsFunc: func(_ context.Context, filter storage.JobFilter) ([]model.Job, error) {
+				if filter.Cursor == nil {
+					return makeJobs("first", filter.PageSize+1), nil
+				}
+				return nil, errors.New("connection reset")
+			},
+		}
+
+		w := list(newTestRouter(store), "", "application/x-ndjson")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasSuffix(w.Body.String(), `{"error":"Failed to list jobs"}`+"\n"))
+	})
+
+	t.Run("json stays the default", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		w := list(newTestRouter(store), "", "*/*")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("unsupported formats are not acceptable", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		w := list(newTestRouter(store), "", "text/csv")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Empty(t, store.ListFilters)
+	})
+
+	t.Run("offset pagination cannot be streamed", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		w := list(newTestRouter(store), "?pagination=offset&page=2", "application/x-ndjson")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.ListFilters)
+	})
+}
+
+func TestJobCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 12, 17, 10, 30, 0, 123, time.UTC)
+
+	cursors := []*storage.JobCursor{
+		{TimeKey: createdAt, JobID: "job-1"},
+		{Sort: storage.JobSort{Field: storage.SortUpdatedAt, Ascending: true}, TimeKey: createdAt, JobID: "job-1"},
+		{Sort: storage.JobSort{Field: storage.SortJobType}, TextKey: "reports|daily", JobID: "job-1"},
+	}
+	for _, want := range cursors {
+		encoded, err := EncodeJobCursor(want)
+		require.NoError(t, err)
+
+		got, err := DecodeJobCursor(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, want.Sort, got.Sort)
+		assert.True(t, want.TimeKey.Equal(got.TimeKey))
+		assert.Equal(t, want.TextKey, got.TextKey)
+		assert.Equal(t, want.JobID, got.JobID)
+	}
+
+	t.Run("cursors from before configurable sorting", func(t *testing.T) {
+		legacy := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%d|job-1", createdAt.UnixNano())))
+
+		got, err := DecodeJobCursor(legacy)
+		require.NoError(t, err)
+		assert.Equal(t, storage.JobSort{}, got.Sort)
+		assert.True(t, createdAt.Equal(got.TimeKey))
+		assert.Equal(t, "job-1", got.JobID)
+	})
+
+	t.Run("invalid sort in cursor", func(t *testing.T) {
+		_, err := DecodeJobCursor(base64.StdEncoding.EncodeToString([]byte("payload|asc|x|job-1")))
+		assert.Error(t, err)
+	})
+}
+
+func TestCursorSigner(t *testing.T) {
+	cursor := &storage.JobCursor{Sort: storage.JobSort{Field: storage.SortJobType}, TextKey: "reports", JobID: "job-1"}
+	signer := NewCursorSigner(strings.Repeat("k", 32))
+
+	signed, err := signer.Encode(cursor)
+	require.NoError(t, err)
+	got, err := signer.Decode(signed)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, got)
+
+	_, err = NewCursorSigner(strings.Repeat("x", 32)).Decode(signed)
+	assert.Error(t, err, "signed with another key")
+
+	unsigned, err := EncodeJobCursor(cursor)
+	require.NoError(t, err)
+	_, err = signer.Decode(unsigned)
+	assert.Error(t, err, "unsigned cursor")
+
+	// Without a key cursors stay unsigned
+	assert.Nil(t, NewCursorSigner(""))
+	encoded, err := NewCursorSigner("").Encode(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, unsigned, encoded)
+}
+
+func TestJobHandler_RetryJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	// retryingStore simulates the storage calling publish before commit
+	retryingStore := func(status string, retryErr error) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				job := &model.Job{
+					JobID:     id,
+					JobType:   "send_email",
+					Payload:   `{"to":"a@b.c"}`,
+					Status:    status,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				if retryErr != nil {
+					return job, retryErr
+				}
+				if err := publish(job); err != nil {
+					return nil, err
+				}
+				return job, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		jobID         string
+		body          string
+		store         *mocks.JobStorage
+		publishErr    error
+		wantStatus    int
+		wantPublished int
+	}{
+		{
+			name:          "failed job is reset and republished",
+			jobID:         jobID,
+			store:         retryingStore(domain.JobStatusPending, nil),
+			wantStatus:    http.StatusOK,
+			wantPublished: 1,
+		},
+		{
+			name:          "reset retry count is forwarded",
+			jobID:         jobID,
+			body:          `{"reset_retry_count":true}`,
+			store:         retryingStore(domain.JobStatusPending, nil),
+			wantStatus:    http.StatusOK,
+			wantPublished: 1,
+		},
+		{
+			name:       "invalid uuid",
+			jobID:      "not-a-uuid",
+			store:      &mocks.JobStorage{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed body",
+			jobID:      jobID,
+			body:       `{"reset_retry_count":`,
+			store:      &mocks.JobStorage{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			jobID:      jobID,
+			store:      retryingStore("", domain.ErrJobNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "running job cannot be retried",
+			jobID:      jobID,
+			store:      retryingStore(domain.JobStatusRunning, domain.ErrJobNotRetryable),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:          "publish failure",
+			jobID:         jobID,
+			store:         retryingStore(domain.JobStatusPending, nil),
+			publishErr:    errors.New("channel closed"),
+			wantStatus:    http.StatusInternalServerError,
+			wantPublished: 1,
+		},
+		{
+			name:          "broker circuit open",
+			jobID:         jobID,
+			store:         retryingStore(domain.JobStatusPending, nil),
+			publishErr:    &broker.CircuitOpenError{RetryAfter: 2500 * time.Millisecond},
+			wantStatus:    http.StatusServiceUnavailable,
+			wantPublished: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{err: tt.publishErr}
+			r := newTestRouterWithPublisher(tt.store, publisher)
+
+			w := doRequest(r, http.MethodPost, "/api/v1/jobs/"+tt.jobID+"/retry", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Len(t, publisher.messages, tt.wantPublished)
+
+			switch tt.wantStatus {
+			case http.StatusOK:
+				var resp dto.JobDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, domain.JobStatusPending, resp.Status)
+
+				var msg dto.JobMessage
+				require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+				assert.Equal(t, tt.jobID, msg.JobID)
+				assert.JSONEq(t, `{"to":"a@b.c"}`, string(msg.Payload))
+			case http.StatusConflict:
+				var resp map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, domain.JobStatusRunning, resp["status"])
+			case http.StatusServiceUnavailable:
+				assert.Equal(t, "3", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestJobHandler_RetryJob_WorkflowStep(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	workflowID, step, earlier := "wf-1", "notify", "render"
+	result := `{"url":"https://example.com/r.pdf"}`
+
+	store := &mocks.JobStorage{
+		RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+			job := &model.Job{
+				JobID:      id,
+				JobType:    "send_email",
+				Payload:    `{}`,
+				Status:     domain.JobStatusPending,
+				WorkflowID: &workflowID,
+				StepName:   &step,
+			}
+			return job, publish(job)
+		},
+		GetWorkflowFunc: func(_ context.Context, id string) (*model.Workflow, []model.Job, error) {
+			return &model.Workflow{WorkflowID: id}, []model.Job{
+				{JobID: "job-0", Status: domain.JobStatusCompleted, StepName: &earlier, Result: &result},
+			}, nil
+		},
+	}
+	publisher := &fakePublisher{}
+	r := newTestRouterWithPublisher(store, publisher)
+
+	w := doRequest(r, http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The retried step gets the results of the steps before it, like on its first run
+	require.Len(t, publisher.messages, 1)
+	var msg dto.JobMessage
+	require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+	require.NotNil(t, msg.Context)
+	assert.Equal(t, workflowID, msg.Context.WorkflowID)
+	assert.Equal(t, step, msg.Context.Step)
+	assert.JSONEq(t, result, string(msg.Context.Results[earlier]))
+}
+
+func TestJobHandler_CancelJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	// cancelingStore simulates the storage checking the version, then the status
+	cancelingStore := func(current *model.Job) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			CancelJobFunc: func(_ context.Context, id string, version int) (*model.Job, error) {
+				if current == nil {
+					return nil, domain.ErrJobNotFound
+				}
+				if current.Version != version {
+					return current, domain.ErrVersionConflict
+				}
+				if err := jobstatus.Check(domain.JobStatus(current.Status), jobstatus.Canceled); err != nil {
+					return current, err
+				}
+				return &model.Job{JobID: id, Status: domain.JobStatusCanceled, Version: version + 1}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		jobID       string
+		body        string
+		store       *mocks.JobStorage
+		wantStatus  int
+		wantVersion float64
+	}{
+		{
+			name:        "running job is canceled",
+			jobID:       jobID,
+			body:        `{"version":3}`,
+			store:       cancelingStore(&model.Job{Status: domain.JobStatusRunning, Version: 3}),
+			wantStatus:  http.StatusOK,
+			wantVersion: 4,
+		},
+		{
+			name:       "invalid uuid",
+			jobID:      "not-a-uuid",
+			body:       `{"version":1}`,
+			store:      &mocks.JobStorage{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing version",
+			jobID:      jobID,
+			body:       `{}`,
+			store:      &mocks.JobStorage{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			jobID:      jobID,
+			body:       `{"version":1}`,
+			store:      cancelingStore(nil),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:        "stale version",
+			jobID:       jobID,
+			body:        `{"version":2}`,
+			store:       cancelingStore(&model.Job{Status: domain.JobStatusCompleted, Version: 3}),
+			wantStatus:  http.StatusConflict,
+			wantVersion: 3,
+		},
+		{
+			name:        "completed job cannot be canceled",
+			jobID:       jobID,
+			body:        `{"version":3}`,
+			store:       cancelingStore(&model.Job{Status: domain.JobStatusCompleted, Version: 3}),
+			wantStatus:  http.StatusConflict,
+			wantVersion: 3,
+		},
+		{
+			name:  "database unavailable",
+			jobID: jobID,
+			body:  `{"version":1}`,
+			store: &mocks.JobStorage{
+				CancelJobFunc: func(context.Context, string, int) (*model.Job, error) {
+					return nil, fmt.Errorf("failed to cancel job: %w", driver.ErrBadConn)
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(newTestRouter(tt.store), http.MethodPost, "/api/v1/jobs/"+tt.jobID+"/cancel", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantVersion != 0 {
+				var resp map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantVersion, resp["version"])
+			}
+		})
+	}
+}
+
+func TestJobHandler_DeleteJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	deletingStore := func(status string, deleteErr error) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			DeleteJobFunc: func(_ context.Context, id string) (*model.Job, error) {
+				if errors.Is(deleteErr, domain.ErrJobNotFound) {
+					return nil, deleteErr
+				}
+				return &model.Job{JobID: id, Status: status}, deleteErr
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		jobID      string
+		store      *mocks.JobStorage
+		wantStatus int
+	}{
+		{
+			name:       "terminal job is deleted",
+			jobID:      jobID,
+			store:      deletingStore(domain.JobStatusCompleted, nil),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "invalid uuid",
+			jobID:      "not-a-uuid",
+			store:      &mocks.JobStorage{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			jobID:      jobID,
+			store:      deletingStore("", domain.ErrJobNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "running job cannot be deleted",
+			jobID:      jobID,
+			store:      deletingStore(domain.JobStatusRunning, domain.ErrJobNotDeletable),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "storage error",
+			jobID:      jobID,
+			store:      deletingStore("", errors.New("boom")),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(newTestRouter(tt.store), http.MethodDelete, "/api/v1/jobs/"+tt.jobID, "")
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			switch tt.wantStatus {
+			case http.StatusNoContent:
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, []string{tt.jobID}, tt.store.DeleteJobIDs)
+			case http.StatusConflict:
+				var resp map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "Cannot delete active job", resp["error"])
+				assert.Equal(t, domain.JobStatusRunning, resp["status"])
+			}
+		})
+	}
+}
+
+func TestJobHandler_Metadata(t *testing.T) {
+	metadata := `{"order_id":"order-1","attempt":2}`
+
+	t.Run("create stores and returns metadata", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","metadata":` + metadata + `}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, store.CreatedJobs, 1)
+		require.NotNil(t, store.CreatedJobs[0].Metadata)
+		assert.JSONEq(t, metadata, *store.CreatedJobs[0].Metadata)
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.JSONEq(t, metadata, string(resp.Metadata))
+	})
+
+	t.Run("jobs without metadata omit it", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","metadata":null}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, store.CreatedJobs, 1)
+		assert.Nil(t, store.CreatedJobs[0].Metadata)
+		assert.NotContains(t, w.Body.String(), `"metadata"`)
+	})
+
+	t.Run("retry echoes metadata in the job message", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				job := &model.Job{JobID: id, Payload: `{}`, Metadata: &metadata, Status: domain.JobStatusPending}
+				return job, publish(job)
+			},
+		}
+		publisher := &fakePublisher{}
+
+		w := doRequest(newTestRouterWithPublisher(store, publisher), http.MethodPost, "/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/retry", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		require.Len(t, publisher.messages, 1)
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+		assert.JSONEq(t, metadata, string(msg.Metadata))
+	})
+}
+
+func TestJobHandler_PublishJob_MessageCodec(t *testing.T) {
+	job := &model.Job{JobID: "550e8400-e29b-41d4-a716-446655440000", UserID: "user-1", JobType: "send_email", Payload: `{"to":"a@example.com"}`}
+
+	publisher := &fakePublisher{}
+	h := NewJobHandler(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: &mocks.JobStorage{},
+		Publisher:  publisher,
+	})
+	require.NoError(t, h.publishJob(context.Background(), job))
+	assert.Equal(t, []string{"application/json"}, publisher.contentTypes, "JSON by default")
+
+	publisher = &fakePublisher{}
+	h = NewJobHandler(&Dependencies{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage:   &mocks.JobStorage{},
+		Publisher:    publisher,
+		MessageCodec: messaging.Protobuf,
+	})
+	require.NoError(t, h.publishJob(context.Background(), job))
+	require.Len(t, publisher.messages, 1)
+
+	msg, err := messaging.Decode(publisher.contentTypes[0], publisher.messages[0])
+	require.NoError(t, err)
+	assert.Equal(t, job.JobID, msg.JobID)
+	assert.Equal(t, "user-1", msg.UserID)
+	assert.JSONEq(t, job.Payload, string(msg.Payload))
+}
+
+// orderedPublisher records the ordering key of each published message
+type orderedPublisher struct {
+	fakePublisher
+	keys []string
+}
+
+func (p *orderedPublisher) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	p.keys = append(p.keys, orderingKey)
+	return p.Publish(ctx, body, contentType)
+}
+
+func TestJobHandler_OrderingKey(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	orderingKey := "account-42"
+
+	retryStore := func(key *string) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				job := &model.Job{JobID: id, Payload: `{}`, OrderingKey: key, Status: domain.JobStatusPending}
+				if err := publish(job); err != nil {
+					return nil, err
+				}
+				return job, nil
+			},
+		}
+	}
+
+	t.Run("create stores the ordering key", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"apply_event","payload":"{}","ordering_key":"account-42"}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, store.CreatedJobs, 1)
+		require.NotNil(t, store.CreatedJobs[0].OrderingKey)
+		assert.Equal(t, orderingKey, *store.CreatedJobs[0].OrderingKey)
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.OrderingKey)
+		assert.Equal(t, orderingKey, *resp.OrderingKey)
+	})
+
+	t.Run("create rejects overlong keys", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"apply_event","payload":"{}","ordering_key":"` + strings.Repeat("k", 256) + `"}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("messages are routed by ordering key, falling back to the job ID", func(t *testing.T) {
+		publisher := &orderedPublisher{}
+
+		w := doRequest(newTestRouterWithPublisher(retryStore(&orderingKey), publisher), http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(newTestRouterWithPublisher(retryStore(nil), publisher), http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, []string{orderingKey, jobID}, publisher.keys)
+
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+		require.NotNil(t, msg.OrderingKey)
+		assert.Equal(t, orderingKey, *msg.OrderingKey)
+	})
+
+	t.Run("keyed messages are never deferred", func(t *testing.T) {
+		policies := policy.NewEngine(policy.Options{BrokerUnavailable: policy.BrokerDefer, DeferredQueueSize: 10},
+			slog.New(slog.NewTextHandler(io.Discard, nil)))
+		publisher := &orderedPublisher{fakePublisher: fakePublisher{err: errors.New("connection closed")}}
+
+		w := doRequest(newTestRouterWithPolicies(retryStore(&orderingKey), publisher, policies), http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestJobHandler_ExecuteAfter(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	executeAfter := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	t.Run("create stores the time in UTC", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","execute_after":"2026-03-01T11:30:00+02:00"}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, store.CreatedJobs, 1)
+		require.NotNil(t, store.CreatedJobs[0].ExecuteAfter)
+		assert.Equal(t, executeAfter, *store.CreatedJobs[0].ExecuteAfter)
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.ExecuteAfter)
+		assert.Equal(t, "2026-03-01T09:30:00Z", *resp.ExecuteAfter)
+	})
+
+	t.Run("create rejects invalid times", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","execute_after":"tomorrow"}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("messages carry the time", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				job := &model.Job{JobID: id, Payload: `{}`, ExecuteAfter: &executeAfter, Status: domain.JobStatusPending}
+				return job, publish(job)
+			},
+		}
+		publisher := &fakePublisher{}
+
+		w := doRequest(newTestRouterWithPublisher(store, publisher), http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+		require.NotNil(t, msg.ExecuteAfter)
+		assert.True(t, executeAfter.Equal(*msg.ExecuteAfter))
+	})
+}
+
+func TestJobHandler_DependsOn(t *testing.T) {
+	parentID := "11111111-1111-1111-1111-111111111111"
+
+	t.Run("jobs with dependencies start WAITING", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","depends_on":["` + parentID + `","` + parentID + `"]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.Len(t, store.CreatedJobs, 1)
+		assert.Equal(t, domain.JobStatusWaiting, store.CreatedJobs[0].Status)
+		assert.Equal(t, []string{parentID}, []string(store.CreatedJobs[0].DependsOn))
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, domain.JobStatusWaiting, resp.Status)
+		assert.Equal(t, []string{parentID}, resp.DependsOn)
+	})
+
+	t.Run("jobs without dependencies start PENDING", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, domain.JobStatusPending, store.CreatedJobs[0].Status)
+		assert.NotContains(t, w.Body.String(), "depends_on")
+	})
+
+	t.Run("dependencies must be job IDs", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","depends_on":["not-a-uuid"]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("unknown dependencies are rejected", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			CreateJobFunc: func(context.Context, *model.Job, func(*model.Job) error) error {
+				return fmt.Errorf("%w: %s", domain.ErrDependencyNotFound, parentID)
+			},
+		}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}","depends_on":["` + parentID + `"]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Dependency job not found")
+		assert.Contains(t, w.Body.String(), parentID)
+	})
+
+	t.Run("WAITING is a listable status", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs?status=waiting", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{domain.JobStatusWaiting}, store.ListFilters[0].Statuses)
+	})
+}
+
+func TestJobHandler_DegradationPolicies(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	createBody := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dbDown := fmt.Errorf("failed to get job: %w", driver.ErrBadConn)
+
+	t.Run("database down rejects writes with 503", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			CreateJobFunc: func(context.Context, *model.Job, func(*model.Job) error) error { return dbDown },
+		}
+		r := newTestRouter(store)
+
+		w := doRequest(r, http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("serve_stale_reads answers lookups from the last read", func(t *testing.T) {
+		otherTenantJobID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+		down := false
+		store := &mocks.JobStorage{
+			GetJobByIDFunc: func(ctx context.Context, id string) (*model.Job, error) {
+				if down {
+					return nil, dbDown
+				}
+				job := &model.Job{JobID: id, Status: domain.JobStatusRunning, TenantID: tenant.ID(ctx)}
+				if id == otherTenantJobID {
+					job.TenantID = "acme"
+				}
+				return job, nil
+			},
+		}
+		engine := policy.NewEngine(policy.Options{
+			DatabaseUnavailable: policy.DatabaseServeStaleReads,
+			StaleReadCacheSize:  10,
+		}, discard)
+		r := newTestRouterWithPolicies(store, &fakePublisher{}, engine)
+
+		w := doRequest(r, http.MethodGet, "/api/v1/jobs/"+jobID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		down = true
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs/"+jobID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Warning"), "Stale")
+
+		var resp dto.JobDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, domain.JobStatusRunning, resp.Status)
+
+		// Jobs never read before still fail
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs/6ba7b810-9dad-11d1-80b4-00c04fd430c8", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		// So do cached jobs of other tenants
+		down = false
+		require.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, "/api/v1/jobs/"+otherTenantJobID, "").Code)
+		down = true
+		w = doRequest(r, http.MethodGet, "/api/v1/jobs/"+otherTenantJobID, "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("backlog over limit throttles creation", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			CountJobsByStatusFunc: func(context.Context, string) (int64, error) { return 101, nil },
+		}
+		engine := policy.NewEngine(policy.Options{
+			MaxPendingJobs:    100,
+			BacklogRetryAfter: 30 * time.Second,
+		}, discard)
+		r := newTestRouterWithPolicies(store, &fakePublisher{}, engine)
+
+		w := doRequest(r, http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("broker down defers the retry message", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				job := &model.Job{JobID: id, Payload: "{}", Status: domain.JobStatusPending}
+				if err := publish(job); err != nil {
+					return nil, err
+				}
+				return job, nil
+			},
+		}
+		engine := policy.NewEngine(policy.Options{
+			BrokerUnavailable: policy.BrokerDefer,
+			DeferredQueueSize: 10,
+		}, discard)
+		r := newTestRouterWithPolicies(store, &fakePublisher{err: errors.New("connection closed")}, engine)
+
+		w := doRequest(r, http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestJobHandler_PayloadSchemas(t *testing.T) {
+	emailSchema, err := schema.Parse([]byte(`{"type":"object","required":["to"],"properties":{"to":{"type":"string"}}}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "payload matching the schema",
+			body:     `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{\"to\":\"someone@example.com\"}"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "payload violating the schema",
+			body:     `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{\"to\":42}"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "job type without a schema",
+			body:     `{"idempotency_key":"key-1","user_id":"user-1","job_type":"resize_image","payload":"{\"width\":\"wide\"}"}`,
+			wantCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{}
+			h := NewJobHandler(&Dependencies{
+				Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+				JobStorage:     store,
+				Publisher:      &fakePublisher{},
+				PayloadSchemas: schema.Registry{"send_email": emailSchema},
+			})
+			r := gin.New()
+			r.POST("/api/v1/jobs", h.CreateJob)
+
+			w := doRequest(r, http.MethodPost, "/api/v1/jobs", tt.body)
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode != http.StatusCreated {
+				assert.Contains(t, w.Body.String(), "$.to: expected string, got number")
+				assert.Empty(t, store.CreatedJobs)
+			}
+		})
+	}
+}
+
+func TestJobHandler_OversizedPayloads(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+
+	newRouter := func(store *mocks.JobStorage, publisher JobPublisher) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage:      store,
+			Publisher:       publisher,
+			MaxPayloadBytes: 16,
+		})
+		r := gin.New()
+		r.POST("/api/v1/jobs", h.CreateJob)
+		r.POST("/api/v1/jobs/:job_id/retry", h.RetryJob)
+		return r
+	}
+
+	t.Run("create rejects payloads over the limit", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{\"to\":\"someone@example.com\"}"}`
+
+		w := doRequest(newRouter(store, &fakePublisher{}), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("retry reports broker size rejections", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			RetryJobFunc: func(_ context.Context, id string, _ bool, publish func(*model.Job) error) (*model.Job, error) {
+				if err := publish(&model.Job{JobID: id, Payload: "{}"}); err != nil {
+					return nil, err
+				}
+				return &model.Job{JobID: id}, nil
+			},
+		}
+		publisher := &fakePublisher{err: fmt.Errorf("%w: 200 bytes (limit 100)", broker.ErrMessageTooLarge)}
+
+		w := doRequest(newRouter(store, publisher), http.MethodPost, "/api/v1/jobs/"+jobID+"/retry", "")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
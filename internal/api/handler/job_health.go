@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+)
+
+// annotateHealth sets the computed health flags on resp as of now
+func (o JobHealthOptions) annotateHealth(resp *dto.JobDTO, job *model.Job, now time.Time) {
+	switch job.Status {
+	case domain.JobStatusRunning:
+		if o.HeartbeatTimeout > 0 {
+			// Workers that never sent a heartbeat are judged from when the job last changed
+			lastSeen := job.UpdatedAt
+			if job.LastHeartbeat != nil {
+				lastSeen = *job.LastHeartbeat
+			}
+			resp.Stuck = now.Sub(lastSeen) > o.HeartbeatTimeout
+		}
+	case domain.JobStatusPending:
+		if o.PendingSLA > 0 {
+			resp.Overdue = now.Sub(job.CreatedAt) > o.PendingSLA
+		}
+	case domain.JobStatusFailed:
+		resp.RetryExhausted = job.RetryCount >= job.MaxRetries
+	}
+}
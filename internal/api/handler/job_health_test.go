@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHealthOptions_AnnotateHealth(t *testing.T) {
+	now := time.Date(2025, 12, 17, 12, 0, 0, 0, time.UTC)
+	opts := JobHealthOptions{HeartbeatTimeout: 2 * time.Minute, PendingSLA: 15 * time.Minute}
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+
+	tests := []struct {
+		name string
+		opts JobHealthOptions
+		job  model.Job
+		want dto.JobDTO
+	}{
+		{
+			name: "running with a recent heartbeat",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusRunning, UpdatedAt: now.Add(-time.Hour), LastHeartbeat: at(time.Minute)},
+		},
+		{
+			name: "running with a stale heartbeat",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusRunning, UpdatedAt: now.Add(-time.Hour), LastHeartbeat: at(5 * time.Minute)},
+			want: dto.JobDTO{Stuck: true},
+		},
+		{
+			name: "running without heartbeats falls back to updated_at",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusRunning, UpdatedAt: now.Add(-5 * time.Minute)},
+			want: dto.JobDTO{Stuck: true},
+		},
+		{
+			name: "pending past the SLA",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusPending, CreatedAt: now.Add(-time.Hour)},
+			want: dto.JobDTO{Overdue: true},
+		},
+		{
+			name: "pending within the SLA",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusPending, CreatedAt: now.Add(-time.Minute)},
+		},
+		{
+			name: "zero thresholds disable stuck and overdue",
+			job:  model.Job{Status: domain.JobStatusPending, CreatedAt: now.Add(-24 * time.Hour)},
+		},
+		{
+			name: "failed with retries left",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusFailed, RetryCount: 1, MaxRetries: 3},
+		},
+		{
+			name: "failed with no retries left",
+			opts: opts,
+			job:  model.Job{Status: domain.JobStatusFailed, RetryCount: 3, MaxRetries: 3},
+			want: dto.JobDTO{RetryExhausted: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got dto.JobDTO
+			tt.opts.annotateHealth(&got, &tt.job, now)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJobHandler_ListJobs_Health(t *testing.T) {
+	now := time.Now().UTC()
+	store := &mocks.JobStorage{
+		ListJobsFunc: func(context.Context, storage.JobFilter) ([]model.Job, error) {
+			return []model.Job{
+				{JobID: "job-a", Status: domain.JobStatusPending, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+				{JobID: "job-b", Status: domain.JobStatusPending, CreatedAt: now, UpdatedAt: now},
+			}, nil
+		},
+	}
+
+	h := NewJobHandler(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: store,
+		JobHealth:  JobHealthOptions{PendingSLA: 15 * time.Minute},
+	})
+	r := gin.New()
+	r.GET("/api/v1/jobs", h.ListJobs)
+
+	w := doRequest(r, http.MethodGet, "/api/v1/jobs", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp dto.ListJobsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Jobs, 2)
+	assert.True(t, resp.Jobs[0].Overdue)
+	assert.False(t, resp.Jobs[1].Overdue)
+}
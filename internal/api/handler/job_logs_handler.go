@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListJobLogs handles GET /api/v1/jobs/:job_id/logs
+// Returns what executors logged while running the job, oldest first
+func (h *JobHandler) ListJobLogs(c *gin.Context) {
+	jobID := c.Param("job_id")
+	h.logger.Info("ListJobLogs called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_id", jobID),
+	)
+
+	// 1. Validate job_id format (UUID)
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.logger.Error("Invalid job_id format", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "job_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Query the job's execution log
+	logs, err := h.storage.ListJobLogs(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.logger.Error("Job not found", slog.String("job_id", jobID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to list job logs", slog.String("job_id", jobID), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list job logs",
+		})
+		return
+	}
+
+	// 3. Return the log
+	resp := dto.ListJobLogsResponse{
+		JobID: jobID,
+		Logs:  make([]dto.JobLogDTO, len(logs)),
+	}
+	for i := range logs {
+		resp.Logs[i] = toJobLogDTO(&logs[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func toJobLogDTO(log *model.JobLog) dto.JobLogDTO {
+	return dto.JobLogDTO{
+		ID:       log.ID,
+		Attempt:  log.Attempt,
+		WorkerID: log.WorkerID,
+		Level:    log.Level,
+		Message:  log.Message,
+		Attrs:    rawJSON(log.Attrs),
+		LoggedAt: log.LoggedAt.Format(time.RFC3339Nano),
+	}
+}
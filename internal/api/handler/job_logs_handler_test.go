@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_ListJobLogs(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	loggedAt := time.Date(2026, 1, 15, 10, 30, 2, 123000000, time.UTC)
+	ptr := func(s string) *string { return &s }
+
+	t.Run("returns the log", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobLogsFunc: func(_ context.Context, id string) ([]model.JobLog, error) {
+				assert.Equal(t, jobID, id)
+				return []model.JobLog{
+					{ID: 1, JobID: jobID, WorkerID: ptr("worker-1"), Level: "INFO", Message: "connecting to SMTP", LoggedAt: loggedAt},
+					{ID: 2, JobID: jobID, WorkerID: ptr("worker-1"), Level: "ERROR", Message: "send failed",
+						Attrs: ptr(`{"host":"smtp.example.com"}`), LoggedAt: loggedAt},
+				}, nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/logs", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListJobLogsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, jobID, resp.JobID)
+		require.Len(t, resp.Logs, 2)
+		assert.Equal(t, "connecting to SMTP", resp.Logs[0].Message)
+		assert.Nil(t, resp.Logs[0].Attrs)
+		assert.JSONEq(t, `{"host":"smtp.example.com"}`, string(resp.Logs[1].Attrs))
+		assert.Equal(t, "2026-01-15T10:30:02.123Z", resp.Logs[1].LoggedAt)
+	})
+
+	t.Run("empty log", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobLogsFunc: func(context.Context, string) ([]model.JobLog, error) {
+				return []model.JobLog{}, nil
+			},
+		}
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/logs", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"logs":[]`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			ListJobLogsFunc: func(context.Context, string) ([]model.JobLog, error) {
+				return nil, domain.ErrJobNotFound
+			},
+		}
+		w := doRequest(newTestRouter(store), http.MethodGet, "/api/v1/jobs/"+jobID+"/logs", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/jobs/not-a-uuid/logs", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+	"github.com/cuongbtq/practice-be/shared/jobtype"
+	"github.com/gin-gonic/gin"
+)
+
+// EstimateJobType handles GET /api/v1/job-types/:job_type/estimate
+// Returns historical duration percentiles and the expected queue wait for a job type
+func (h *JobHandler) EstimateJobType(c *gin.Context) {
+	jobType := c.Param("job_type")
+
+	h.logger.Info("EstimateJobType called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_type", jobType),
+	)
+
+	// 1. Load duration statistics over the configured history window
+	since := time.Now().UTC().Add(-h.estimation.HistoryWindow)
+	stats, err := h.storage.GetJobTypeStats(c.Request.Context(), jobType, since)
+	if err != nil {
+		h.logger.Error("Failed to get job type stats", slog.String("job_type", jobType), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to estimate job type",
+		})
+		return
+	}
+
+	// 2. Estimate queue wait: the shared queue drains backlog * mean duration / capacity
+	var expectedWait *float64
+	if h.estimation.WorkerCapacity > 0 {
+		wait := float64(stats.QueueBacklog) * stats.AvgSeconds / float64(h.estimation.WorkerCapacity)
+		expectedWait = &wait
+	}
+
+	c.JSON(http.StatusOK, dto.JobTypeEstimateResponse{
+		JobType:                  jobType,
+		HistoryWindow:            h.estimation.HistoryWindow.String(),
+		SampleSize:               stats.SampleSize,
+		P50DurationSeconds:       stats.P50Seconds,
+		P95DurationSeconds:       stats.P95Seconds,
+		QueueBacklog:             stats.QueueBacklog,
+		WorkerCapacity:           h.estimation.WorkerCapacity,
+		ExpectedQueueWaitSeconds: expectedWait,
+	})
+}
+
+// ListJobTypes handles GET /api/v1/job-types
+// Returns every known job type with its schemas, timeout, retry limit and whether new
+// jobs of the type are accepted
+func (h *JobHandler) ListJobTypes(c *gin.Context) {
+	h.logger.Info("ListJobTypes called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+	)
+
+	names := h.jobTypeNames()
+	resp := dto.ListJobTypesResponse{JobTypes: make([]dto.JobTypeResponse, 0, len(names))}
+	for _, jobType := range names {
+		jt, err := h.describeJobType(jobType)
+		if err != nil {
+			h.logger.Error("Failed to encode job type schema", slog.String("job_type", jobType), slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to list job types",
+			})
+			return
+		}
+		resp.JobTypes = append(resp.JobTypes, jt)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJobType handles GET /api/v1/job-types/:job_type
+// Returns the payload and result schemas of a job type, so clients know what to submit
+// and what GetJob returns once the job completes
+func (h *JobHandler) GetJobType(c *gin.Context) {
+	jobType := c.Param("job_type")
+
+	h.logger.Info("GetJobType called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("job_type", jobType),
+	)
+
+	if !slices.Contains(h.jobTypeNames(), jobType) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job type not found",
+		})
+		return
+	}
+
+	jt, err := h.describeJobType(jobType)
+	if err != nil {
+		h.logger.Error("Failed to encode job type schema", slog.String("job_type", jobType), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job type",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, jt)
+}
+
+// jobTypeNames returns the known job types, sorted: the defined ones when a definitions
+// file is configured, else the configured list, else those with a payload or result schema
+func (h *JobHandler) jobTypeNames() []string {
+	if h.validation.Definitions.Len() > 0 {
+		var names []string
+		for _, d := range h.validation.Definitions.All() {
+			names = append(names, d.Name)
+		}
+		return names
+	}
+	if len(h.validation.JobTypes) > 0 {
+		names := slices.Clone(h.validation.JobTypes)
+		slices.Sort(names)
+		return slices.Compact(names)
+	}
+
+	var names []string
+	for _, registry := range []schema.Registry{h.schemas, h.resultSchemas} {
+		for jobType := range registry {
+			names = append(names, jobType)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// describeJobType builds the response for a known job type. Job types without a
+// definition report the jobs table defaults.
+func (h *JobHandler) describeJobType(jobType string) (dto.JobTypeResponse, error) {
+	d, ok := h.validation.Definitions.Lookup(jobType)
+	if !ok {
+		d = jobtype.Definition{Name: jobType, Timeout: jobtype.DefaultTimeout, MaxRetries: jobtype.DefaultMaxRetries}
+	}
+
+	resp := dto.JobTypeResponse{
+		JobType:        jobType,
+		Description:    d.Description,
+		TimeoutSeconds: int(d.Timeout.Seconds()),
+		MaxRetries:     d.MaxRetries,
+		// Reported as the API applies it, so types outside validation.job_types show disabled
+		Enabled: h.checkJobType("job_type", jobType) == nil,
+	}
+
+	// Publish the schemas as the documents they were loaded from
+	var err error
+	if s := h.schemas[jobType]; s != nil {
+		if resp.PayloadSchema, err = json.Marshal(s); err != nil {
+			return resp, err
+		}
+	}
+	if s := h.resultSchemas[jobType]; s != nil {
+		if resp.ResultSchema, err = json.Marshal(s); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/shared/jobtype"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_EstimateJobType(t *testing.T) {
+	stats := &model.JobTypeStats{
+		SampleSize:   120,
+		P50Seconds:   2.5,
+		P95Seconds:   9,
+		AvgSeconds:   4,
+		QueueBacklog: 50,
+	}
+
+	newRouter := func(store *mocks.JobStorage, opts EstimationOptions) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  &fakePublisher{},
+			Estimation: opts,
+		})
+		r := gin.New()
+		r.GET("/api/v1/job-types/:job_type/estimate", h.EstimateJobType)
+		return r
+	}
+
+	t.Run("computes queue wait from backlog and capacity", func(t *testing.T) {
+		var gotType string
+		var gotSince time.Time
+		store := &mocks.JobStorage{
+			GetJobTypeStatsFunc: func(_ context.Context, jobType string, since time.Time) (*model.JobTypeStats, error) {
+				gotType, gotSince = jobType, since
+				return stats, nil
+			},
+		}
+
+		r := newRouter(store, EstimationOptions{HistoryWindow: 24 * time.Hour, WorkerCapacity: 10})
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/send_email/estimate", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.JobTypeEstimateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "send_email", resp.JobType)
+		assert.Equal(t, int64(120), resp.SampleSize)
+		assert.Equal(t, 2.5, resp.P50DurationSeconds)
+		assert.Equal(t, 9.0, resp.P95DurationSeconds)
+		require.NotNil(t, resp.ExpectedQueueWaitSeconds)
+		assert.Equal(t, 20.0, *resp.ExpectedQueueWaitSeconds)
+
+		assert.Equal(t, "send_email", gotType)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), gotSince, time.Minute)
+	})
+
+	t.Run("no queue wait without worker capacity", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobTypeStatsFunc: func(_ context.Context, _ string, _ time.Time) (*model.JobTypeStats, error) {
+				return stats, nil
+			},
+		}
+
+		r := newRouter(store, EstimationOptions{HistoryWindow: time.Hour})
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/send_email/estimate", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.JobTypeEstimateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Nil(t, resp.ExpectedQueueWaitSeconds)
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetJobTypeStatsFunc: func(_ context.Context, _ string, _ time.Time) (*model.JobTypeStats, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		r := newRouter(store, EstimationOptions{HistoryWindow: time.Hour, WorkerCapacity: 1})
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/send_email/estimate", "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestJobHandler_GetJobType(t *testing.T) {
+	payloadSchema, err := schema.Parse([]byte(`{"type":"object","required":["to"],"properties":{"to":{"type":"string"}}}`))
+	require.NoError(t, err)
+	resultSchema, err := schema.Parse([]byte(`{"type":"object","properties":{"message_id":{"type":"string"}}}`))
+	require.NoError(t, err)
+
+	newRouter := func(validation ValidationOptions) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage:     &mocks.JobStorage{},
+			Publisher:      &fakePublisher{},
+			PayloadSchemas: schema.Registry{"send_email": payloadSchema},
+			ResultSchemas:  schema.Registry{"send_email": resultSchema},
+			Validation:     validation,
+		})
+		r := gin.New()
+		r.GET("/api/v1/job-types/:job_type", h.GetJobType)
+		return r
+	}
+
+	t.Run("returns payload and result schemas", func(t *testing.T) {
+		w := doRequest(newRouter(ValidationOptions{}), http.MethodGet, "/api/v1/job-types/send_email", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.JobTypeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "send_email", resp.JobType)
+		assert.JSONEq(t, `{"type":"object","required":["to"],"properties":{"to":{"type":"string"}}}`, string(resp.PayloadSchema))
+		assert.JSONEq(t, `{"type":"object","properties":{"message_id":{"type":"string"}}}`, string(resp.ResultSchema))
+	})
+
+	t.Run("job type without schemas", func(t *testing.T) {
+		w := doRequest(newRouter(ValidationOptions{}), http.MethodGet, "/api/v1/job-types/resize_image", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("configured job type without schemas", func(t *testing.T) {
+		r := newRouter(ValidationOptions{JobTypes: []string{"send_email", "resize_image"}})
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/resize_image", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"job_type":"resize_image","timeout_seconds":300,"max_retries":3,"enabled":true}`, w.Body.String())
+	})
+
+	t.Run("job type outside the configured list", func(t *testing.T) {
+		r := newRouter(ValidationOptions{JobTypes: []string{"resize_image"}})
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/send_email", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestJobHandler_ListJobTypes(t *testing.T) {
+	payloadSchema, err := schema.Parse([]byte(`{"type":"object"}`))
+	require.NoError(t, err)
+
+	newRouter := func(validation ValidationOptions) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage:     &mocks.JobStorage{},
+			Publisher:      &fakePublisher{},
+			PayloadSchemas: schema.Registry{"send_email": payloadSchema},
+			Validation:     validation,
+		})
+		r := gin.New()
+		r.GET("/api/v1/job-types", h.ListJobTypes)
+		r.GET("/api/v1/job-types/:job_type", h.GetJobType)
+		return r
+	}
+
+	listJobTypes := func(t *testing.T, r *gin.Engine) []dto.JobTypeResponse {
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp dto.ListJobTypesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.JobTypes
+	}
+
+	t.Run("lists definitions", func(t *testing.T) {
+		definitions, err := jobtype.NewRegistry(
+			jobtype.Definition{Name: "send_email", Timeout: 30 * time.Second, MaxRetries: 5, Enabled: true},
+			jobtype.Definition{Name: "resize_image", Timeout: time.Minute, Enabled: false},
+		)
+		require.NoError(t, err)
+
+		r := newRouter(ValidationOptions{Definitions: definitions})
+		jobTypes := listJobTypes(t, r)
+		require.Len(t, jobTypes, 2)
+
+		assert.Equal(t, "resize_image", jobTypes[0].JobType)
+		assert.Equal(t, 60, jobTypes[0].TimeoutSeconds)
+		assert.False(t, jobTypes[0].Enabled)
+		assert.Nil(t, jobTypes[0].PayloadSchema)
+
+		assert.Equal(t, "send_email", jobTypes[1].JobType)
+		assert.Equal(t, 30, jobTypes[1].TimeoutSeconds)
+		assert.Equal(t, 5, jobTypes[1].MaxRetries)
+		assert.True(t, jobTypes[1].Enabled)
+		assert.JSONEq(t, `{"type":"object"}`, string(jobTypes[1].PayloadSchema))
+
+		w := doRequest(r, http.MethodGet, "/api/v1/job-types/resize_image", "")
+		assert.Equal(t, http.StatusOK, w.Code, "disabled job types are still described")
+		w = doRequest(r, http.MethodGet, "/api/v1/job-types/generate_report", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("lists configured job types without definitions", func(t *testing.T) {
+		jobTypes := listJobTypes(t, newRouter(ValidationOptions{JobTypes: []string{"send_email", "generate_report"}}))
+		require.Len(t, jobTypes, 2)
+		assert.Equal(t, "generate_report", jobTypes[0].JobType)
+		assert.Equal(t, int(jobtype.DefaultTimeout.Seconds()), jobTypes[0].TimeoutSeconds)
+		assert.Equal(t, jobtype.DefaultMaxRetries, jobTypes[0].MaxRetries)
+		assert.True(t, jobTypes[0].Enabled)
+	})
+
+	t.Run("lists job types with schemas when nothing is configured", func(t *testing.T) {
+		jobTypes := listJobTypes(t, newRouter(ValidationOptions{}))
+		require.Len(t, jobTypes, 1)
+		assert.Equal(t, "send_email", jobTypes[0].JobType)
+	})
+}
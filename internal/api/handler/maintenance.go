@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+)
+
+// DefaultMaintenanceMessage is returned to rejected requests when no message is set
+const DefaultMaintenanceMessage = "The service is under maintenance, retry later"
+
+// Sources of the maintenance mode
+const (
+	MaintenanceSourceConfig = "config"
+	MaintenanceSourceAdmin  = "admin"
+)
+
+// MaintenanceStatus says whether the API is in maintenance
+type MaintenanceStatus struct {
+	Enabled bool
+	Message string
+	Source  string // MaintenanceSourceConfig or MaintenanceSourceAdmin, empty when disabled
+}
+
+// MaintenanceMode tracks the maintenance flag of the config file and the one stored
+// through PUT /admin/maintenance. While either is set, mutating API requests are
+// rejected. The stored flag is shared by every instance, so each one polls it. It is safe
+// for concurrent use.
+type MaintenanceMode struct {
+	storage  storage.JobStorage
+	logger   *slog.Logger
+	interval time.Duration
+
+	mu         sync.RWMutex
+	configured MaintenanceStatus
+	stored     MaintenanceStatus
+}
+
+// NewMaintenanceMode creates a MaintenanceMode polling the stored flag every interval,
+// 0 uses 10s
+func NewMaintenanceMode(deps *Dependencies, interval time.Duration) *MaintenanceMode {
+	jobStorage := deps.JobStorage
+	if jobStorage == nil {
+		jobStorage = storage.NewStorage(deps.DBClient)
+	}
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	return &MaintenanceMode{
+		storage:  jobStorage,
+		logger:   deps.Logger,
+		interval: interval,
+	}
+}
+
+// Configure sets the flag of the config file, e.g. on startup and config reload
+func (m *MaintenanceMode) Configure(enabled bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configured = MaintenanceStatus{Enabled: enabled, Message: message, Source: MaintenanceSourceConfig}
+}
+
+// Status returns the current mode. The config file's flag wins over the stored one.
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := m.stored
+	if m.configured.Enabled {
+		status = m.configured
+	}
+	if !status.Enabled {
+		return MaintenanceStatus{}
+	}
+	if status.Message == "" {
+		status.Message = DefaultMaintenanceMessage
+	}
+	return status
+}
+
+// setStored records the stored flag, logging when it changes
+func (m *MaintenanceMode) setStored(enabled bool, message string) {
+	m.mu.Lock()
+	previous := m.stored.Enabled
+	m.stored = MaintenanceStatus{Enabled: enabled, Message: message, Source: MaintenanceSourceAdmin}
+	m.mu.Unlock()
+
+	if enabled != previous {
+		m.logger.Warn("Maintenance mode changed", slog.Bool("enabled", enabled), slog.String("message", message))
+	}
+}
+
+// Run reads the stored flag now and then every interval until ctx is canceled
+func (m *MaintenanceMode) Run(ctx context.Context) {
+	m.refresh(ctx)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh reads the stored flag. When it cannot be read, e.g. while the database is
+// being migrated, the last known flag is kept.
+func (m *MaintenanceMode) refresh(ctx context.Context) {
+	maintenance, err := m.storage.GetMaintenance(ctx)
+	if err != nil {
+		m.logger.Warn("Failed to read maintenance flag", slog.String("error", err.Error()))
+		return
+	}
+	m.setStored(maintenance.Enabled, maintenance.Message)
+}
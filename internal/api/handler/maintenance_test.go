@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	store := &mocks.JobStorage{}
+	mode := NewMaintenanceMode(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: store,
+	}, 0)
+
+	assert.Equal(t, MaintenanceStatus{}, mode.Status())
+
+	t.Run("reads the stored flag", func(t *testing.T) {
+		store.GetMaintenanceFunc = func(context.Context) (*model.Maintenance, error) {
+			return &model.Maintenance{Enabled: true}, nil
+		}
+		mode.refresh(context.Background())
+		assert.Equal(t, MaintenanceStatus{Enabled: true, Message: DefaultMaintenanceMessage, Source: MaintenanceSourceAdmin}, mode.Status())
+	})
+
+	t.Run("keeps the last flag when the database is unavailable", func(t *testing.T) {
+		store.GetMaintenanceFunc = func(context.Context) (*model.Maintenance, error) {
+			return nil, errors.New("connection refused")
+		}
+		mode.refresh(context.Background())
+		assert.True(t, mode.Status().Enabled)
+	})
+
+	t.Run("config flag wins", func(t *testing.T) {
+		mode.Configure(true, "Migrating")
+		assert.Equal(t, MaintenanceStatus{Enabled: true, Message: "Migrating", Source: MaintenanceSourceConfig}, mode.Status())
+
+		mode.setStored(false, "")
+		assert.True(t, mode.Status().Enabled)
+		mode.Configure(false, "")
+		assert.False(t, mode.Status().Enabled)
+	})
+}
+
+func TestAdminHandler_Maintenance(t *testing.T) {
+	store := &mocks.JobStorage{}
+	deps := &Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: store,
+	}
+	deps.Maintenance = NewMaintenanceMode(deps, 0)
+	h := NewAdminHandler(deps)
+
+	r := gin.New()
+	r.GET("/admin/maintenance", h.GetMaintenance)
+	r.PUT("/admin/maintenance", h.SetMaintenance)
+
+	w := doRequest(r, http.MethodGet, "/admin/maintenance", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"enabled":false}`, w.Body.String())
+
+	w = doRequest(r, http.MethodPut, "/admin/maintenance", `{"enabled":true,"message":"Migrating"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"enabled":true,"message":"Migrating","source":"admin"}`, w.Body.String())
+	assert.True(t, deps.Maintenance.Status().Enabled)
+
+	t.Run("enabled is required", func(t *testing.T) {
+		w := doRequest(r, http.MethodPut, "/admin/maintenance", `{"message":"Migrating"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		store.SetMaintenanceFunc = func(context.Context, bool, string) (*model.Maintenance, error) {
+			return nil, fmt.Errorf("failed to set maintenance flag: %w", driver.ErrBadConn)
+		}
+		w := doRequest(r, http.MethodPut, "/admin/maintenance", `{"enabled":false}`)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.True(t, deps.Maintenance.Status().Enabled)
+	})
+}
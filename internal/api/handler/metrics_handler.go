@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/gin-gonic/gin"
+)
+
+// QueryStatsSource reports per-query database counters
+type QueryStatsSource interface {
+	QueryStats() map[string]postgresql.QueryStats
+}
+
+// CircuitBreakerStatsSource reports the broker circuit breaker state
+type CircuitBreakerStatsSource interface {
+	CircuitBreakerStats() broker.CircuitBreakerStats
+}
+
+// MetricsHandler serves service metrics in the Prometheus text format
+type MetricsHandler struct {
+	queries   QueryStatsSource
+	retention RetentionStatsSource
+	events    EventRelayStatsSource
+	breaker   CircuitBreakerStatsSource
+}
+
+// NewMetricsHandler creates a new MetricsHandler instance
+func NewMetricsHandler(deps *Dependencies) *MetricsHandler {
+	return &MetricsHandler{
+		queries:   deps.QueryMetrics,
+		retention: deps.Retention,
+		events:    deps.EventRelay,
+		breaker:   deps.CircuitBreaker,
+	}
+}
+
+// GetMetrics handles GET /metrics
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	stats := h.queries.QueryStats()
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	writeCounter := func(metric, help string, value func(postgresql.QueryStats) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", metric, help, metric)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s{query=%q} %s\n", metric, name, value(stats[name]))
+		}
+	}
+
+	writeCounter("db_queries_total", "Database queries executed.", func(s postgresql.QueryStats) string {
+		return fmt.Sprint(s.Count)
+	})
+	writeCounter("db_query_errors_total", "Database queries that returned an error.", func(s postgresql.QueryStats) string {
+		return fmt.Sprint(s.Errors)
+	})
+	writeCounter("db_slow_queries_total", "Database queries over the slow query threshold.", func(s postgresql.QueryStats) string {
+		return fmt.Sprint(s.Slow)
+	})
+	writeCounter("db_query_duration_seconds_total", "Time spent executing database queries.", func(s postgresql.QueryStats) string {
+		return fmt.Sprint(s.TotalDuration.Seconds())
+	})
+
+	if h.retention != nil {
+		retention := h.retention.RetentionStats()
+		for _, m := range []struct {
+			metric, help string
+			value        int64
+		}{
+			{"jobs_purged_total", "Terminal jobs removed by the retention cleaner.", retention.JobsPurged},
+			{"jobs_archived_total", "Purged jobs copied to the archive first.", retention.JobsArchived},
+			{"job_events_purged_total", "Job events removed with purged jobs.", retention.EventsPurged},
+			{"job_results_purged_total", "Offloaded results removed with purged jobs.", retention.ResultsPurged},
+			{"retention_errors_total", "Failed retention purge batches and result deletions.", retention.Errors},
+		} {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.metric, m.help, m.metric, m.metric, m.value)
+		}
+	}
+
+	if h.events != nil {
+		events := h.events.EventRelayStats()
+		for _, m := range []struct {
+			metric, help string
+			value        int64
+		}{
+			{"job_lifecycle_events_published_total", "Job events published to the events exchange.", events.Published},
+			{"event_relay_errors_total", "Failed event relay batches.", events.Errors},
+		} {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.metric, m.help, m.metric, m.metric, m.value)
+		}
+	}
+
+	if h.breaker != nil {
+		breaker := h.breaker.CircuitBreakerStats()
+		open := 0
+		if breaker.State != broker.CircuitClosed {
+			open = 1
+		}
+		fmt.Fprintf(&b, "# HELP broker_circuit_open Whether publishes to the broker fail fast (1) or go through (0).\n# TYPE broker_circuit_open gauge\nbroker_circuit_open %d\n", open)
+		for _, m := range []struct {
+			metric, help string
+			value        int64
+		}{
+			{"broker_circuit_opened_total", "Times the broker circuit breaker opened.", breaker.Opened},
+			{"broker_circuit_rejected_total", "Publishes failed fast while the circuit was open.", breaker.Rejected},
+		} {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.metric, m.help, m.metric, m.metric, m.value)
+		}
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
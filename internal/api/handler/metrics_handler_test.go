@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueryStats is a fixed QueryStatsSource
+type fakeQueryStats map[string]postgresql.QueryStats
+
+func (f fakeQueryStats) QueryStats() map[string]postgresql.QueryStats { return f }
+
+// fakeRetentionStats is a fixed RetentionStatsSource
+type fakeRetentionStats RetentionStats
+
+func (f fakeRetentionStats) RetentionStats() RetentionStats { return RetentionStats(f) }
+
+// fakeEventRelayStats is a fixed EventRelayStatsSource
+type fakeEventRelayStats EventRelayStats
+
+func (f fakeEventRelayStats) EventRelayStats() EventRelayStats { return EventRelayStats(f) }
+
+// fakeCircuitBreakerStats is a fixed CircuitBreakerStatsSource
+type fakeCircuitBreakerStats broker.CircuitBreakerStats
+
+func (f fakeCircuitBreakerStats) CircuitBreakerStats() broker.CircuitBreakerStats {
+	return broker.CircuitBreakerStats(f)
+}
+
+func TestMetricsHandler_GetMetrics(t *testing.T) {
+	h := NewMetricsHandler(&Dependencies{
+		QueryMetrics: fakeQueryStats{
+			"get_job":   {Count: 4, Errors: 1, Slow: 2, TotalDuration: 1500 * time.Millisecond},
+			"list_jobs": {Count: 1},
+		},
+	})
+
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := doRequest(r, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE db_queries_total counter\n")
+	assert.Contains(t, body, `db_queries_total{query="get_job"} 4`)
+	assert.Contains(t, body, `db_queries_total{query="list_jobs"} 1`)
+	assert.Contains(t, body, `db_query_errors_total{query="get_job"} 1`)
+	assert.Contains(t, body, `db_slow_queries_total{query="get_job"} 2`)
+	assert.Contains(t, body, `db_query_duration_seconds_total{query="get_job"} 1.5`)
+}
+
+func TestMetricsHandler_GetMetrics_Retention(t *testing.T) {
+	h := NewMetricsHandler(&Dependencies{
+		QueryMetrics: fakeQueryStats{},
+		Retention:    fakeRetentionStats{JobsPurged: 12, JobsArchived: 10, EventsPurged: 40, ResultsPurged: 3, Errors: 1},
+	})
+
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := doRequest(r, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE jobs_purged_total counter\njobs_purged_total 12\n")
+	assert.Contains(t, body, "jobs_archived_total 10\n")
+	assert.Contains(t, body, "job_events_purged_total 40\n")
+	assert.Contains(t, body, "job_results_purged_total 3\n")
+	assert.Contains(t, body, "retention_errors_total 1\n")
+}
+
+func TestMetricsHandler_GetMetrics_EventRelay(t *testing.T) {
+	h := NewMetricsHandler(&Dependencies{
+		QueryMetrics: fakeQueryStats{},
+		EventRelay:   fakeEventRelayStats{Published: 250, Errors: 2},
+	})
+
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := doRequest(r, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE job_lifecycle_events_published_total counter\njob_lifecycle_events_published_total 250\n")
+	assert.Contains(t, body, "event_relay_errors_total 2\n")
+	assert.NotContains(t, body, "jobs_purged_total")
+}
+
+func TestMetricsHandler_GetMetrics_CircuitBreaker(t *testing.T) {
+	h := NewMetricsHandler(&Dependencies{
+		QueryMetrics:   fakeQueryStats{},
+		CircuitBreaker: fakeCircuitBreakerStats{State: broker.CircuitOpen, Opened: 3, Rejected: 40},
+	})
+
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := doRequest(r, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE broker_circuit_open gauge\nbroker_circuit_open 1\n")
+	assert.Contains(t, body, "broker_circuit_opened_total 3\n")
+	assert.Contains(t, body, "broker_circuit_rejected_total 40\n")
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+)
+
+// OutboxRelayOptions configures how queued jobs are published
+type OutboxRelayOptions struct {
+	Interval  time.Duration // How often the outbox is looked at, 0 uses 1s
+	BatchSize int           // Jobs published per transaction, 0 uses 100
+}
+
+// OutboxRelay publishes the jobs written to the outbox by async job creation. An
+// outbox record is deleted in the transaction that publishes its job, so each job is
+// published at least once, and jobs with the same ordering key in order, even with a
+// relay on every instance.
+type OutboxRelay struct {
+	jobs *JobHandler
+	opts OutboxRelayOptions
+}
+
+// NewOutboxRelay creates a relay publishing with the job publisher of deps
+func NewOutboxRelay(deps *Dependencies, opts OutboxRelayOptions) *OutboxRelay {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	return &OutboxRelay{
+		jobs: NewJobHandler(deps),
+		opts: opts,
+	}
+}
+
+// Run publishes queued jobs every Interval until ctx is canceled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+// relay publishes batches of queued jobs until none are left
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.jobs.storage.PublishOutboxJobs(ctx, r.opts.BatchSize, func(job *model.Job) error {
+			return r.publish(ctx, job)
+		})
+		if published > 0 {
+			r.jobs.logger.Debug("Published queued jobs", slog.Int("count", published))
+		}
+		if err != nil {
+			// The remaining jobs are published again on the next check
+			r.jobs.logger.Error("Failed to publish queued jobs", slog.String("error", err.Error()))
+			return
+		}
+
+		// A batch takes one job per ordering key, so a short batch can still leave later
+		// jobs of its keys behind
+		if published == 0 {
+			return
+		}
+	}
+}
+
+// publish sends the message of one job. Failed messages are not deferred: their
+// outbox record stays, so the next check publishes them again.
+func (r *OutboxRelay) publish(ctx context.Context, job *model.Job) error {
+	body, err := r.jobs.jobMessage(ctx, job)
+	if err != nil {
+		return err
+	}
+	return r.jobs.sendJob(ctx, job, body, r.jobs.codec.ContentType())
+}
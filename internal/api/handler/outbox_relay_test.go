@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/policy"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRelay_Relay(t *testing.T) {
+	// outboxStore hands out the given jobs in batches of limit, like PublishOutboxJobs
+	outboxStore := func(jobs ...model.Job) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			PublishOutboxJobsFunc: func(_ context.Context, limit int, publish func(*model.Job) error) (int, error) {
+				published := 0
+				for len(jobs) > 0 && published < limit {
+					if err := publish(&jobs[0]); err != nil {
+						return published, err
+					}
+					jobs = jobs[1:]
+					published++
+				}
+				return published, nil
+			},
+		}
+	}
+	job := func(id string, orderingKey *string) model.Job {
+		return model.Job{
+			JobID:       id,
+			UserID:      "user-1",
+			JobType:     "send_email",
+			Payload:     `{"to":"a@b.c"}`,
+			OrderingKey: orderingKey,
+			Status:      domain.JobStatusPending,
+			TenantID:    "acme",
+		}
+	}
+	newRelay := func(store *mocks.JobStorage, publisher JobPublisher, policies *policy.Engine) *OutboxRelay {
+		return NewOutboxRelay(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  publisher,
+			Policies:   policies,
+		}, OutboxRelayOptions{BatchSize: 2})
+	}
+
+	t.Run("publishes every queued job", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		newRelay(outboxStore(job("job-1", nil), job("job-2", nil), job("job-3", nil)), publisher, nil).relay(context.Background())
+
+		require.Len(t, publisher.messages, 3)
+		var msg dto.JobMessage
+		require.NoError(t, json.Unmarshal(publisher.messages[2], &msg))
+		assert.Equal(t, "job-3", msg.JobID)
+		assert.Equal(t, "acme", msg.TenantID)
+		assert.JSONEq(t, `{"to":"a@b.c"}`, string(msg.Payload))
+	})
+
+	t.Run("keys ordered publishes", func(t *testing.T) {
+		key := "account-42"
+		publisher := &orderedPublisher{}
+		newRelay(outboxStore(job("job-1", &key), job("job-2", nil)), publisher, nil).relay(context.Background())
+
+		assert.Equal(t, []string{key, "job-2"}, publisher.keys)
+	})
+
+	t.Run("leaves failed jobs in the outbox instead of deferring them", func(t *testing.T) {
+		policies := policy.NewEngine(policy.Options{BrokerUnavailable: policy.BrokerDefer, DeferredQueueSize: 10},
+			slog.New(slog.NewTextHandler(io.Discard, nil)))
+		publisher := &fakePublisher{err: errors.New("connection closed")}
+		store := outboxStore(job("job-1", nil), job("job-2", nil))
+
+		newRelay(store, publisher, policies).relay(context.Background())
+
+		// A deferred message would have let the relay go on to job-2
+		assert.Len(t, publisher.messages, 1)
+
+		publisher.err = nil
+		newRelay(store, publisher, policies).relay(context.Background())
+		assert.Len(t, publisher.messages, 3)
+	})
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// RetentionOptions configures how old terminal jobs are purged
+type RetentionOptions struct {
+	MaxAge    time.Duration // Terminal jobs last updated longer ago are purged
+	Interval  time.Duration // How often the window is checked for work, 0 uses 10m
+	BatchSize int           // Jobs deleted per statement, 0 uses 500
+	// Archive copies jobs and their events to the archive tables before they are purged.
+	// Archived jobs keep their offloaded results.
+	Archive bool
+
+	// WindowStart and WindowEnd are offsets from midnight UTC between which purging
+	// runs. The window may wrap past midnight; equal offsets purge at any time.
+	WindowStart time.Duration
+	WindowEnd   time.Duration
+}
+
+// RetentionStats counts what the retention cleaner has removed since startup
+type RetentionStats struct {
+	JobsPurged    int64
+	JobsArchived  int64
+	EventsPurged  int64
+	ResultsPurged int64
+	Errors        int64 // Failed purge batches and result deletions
+}
+
+// RetentionStatsSource reports retention cleaner counters
+type RetentionStatsSource interface {
+	RetentionStats() RetentionStats
+}
+
+// RetentionCleaner permanently removes COMPLETED, FAILED and CANCELED jobs older than
+// MaxAge, including soft-deleted ones, together with their events and offloaded results,
+// optionally archiving them first. It works in batches so no statement holds locks on
+// many rows, and only inside the configured window so the deletes stay out of peak traffic.
+type RetentionCleaner struct {
+	jobs *JobHandler
+	opts RetentionOptions
+	now  func() time.Time
+
+	jobsPurged    atomic.Int64
+	jobsArchived  atomic.Int64
+	eventsPurged  atomic.Int64
+	resultsPurged atomic.Int64
+	errors        atomic.Int64
+}
+
+var _ RetentionStatsSource = (*RetentionCleaner)(nil)
+
+// NewRetentionCleaner creates a cleaner that deletes offloaded results from the same
+// result store the job handlers read them from
+func NewRetentionCleaner(deps *Dependencies, opts RetentionOptions) *RetentionCleaner {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+
+	return &RetentionCleaner{
+		jobs: NewJobHandler(deps),
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+// Run purges old jobs every Interval while inside the window, until ctx is canceled
+func (r *RetentionCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.purge(ctx)
+		}
+	}
+}
+
+// RetentionStats returns the counters since startup
+func (r *RetentionCleaner) RetentionStats() RetentionStats {
+	return RetentionStats{
+		JobsPurged:    r.jobsPurged.Load(),
+		JobsArchived:  r.jobsArchived.Load(),
+		EventsPurged:  r.eventsPurged.Load(),
+		ResultsPurged: r.resultsPurged.Load(),
+		Errors:        r.errors.Load(),
+	}
+}
+
+// inWindow reports whether now falls inside the purge window
+func (r *RetentionCleaner) inWindow(now time.Time) bool {
+	start, end := r.opts.WindowStart, r.opts.WindowEnd
+	if start == end {
+		return true
+	}
+
+	now = now.UTC()
+	offset := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if start < end {
+		return offset >= start && offset < end
+	}
+	// The window wraps past midnight
+	return offset >= start || offset < end
+}
+
+// purge deletes batches of old jobs until none are left or the window closes
+func (r *RetentionCleaner) purge(ctx context.Context) {
+	logger := r.jobs.logger
+
+	var jobs, archived, events, results int64
+	for ctx.Err() == nil && r.inWindow(r.now()) {
+		purged, err := r.jobs.storage.PurgeJobs(ctx, r.now().Add(-r.opts.MaxAge), r.opts.BatchSize, r.opts.Archive)
+		if err != nil {
+			// The remaining jobs are picked up again on the next check
+			r.errors.Add(1)
+			logger.Error("Failed to purge old jobs", slog.String("error", err.Error()))
+			break
+		}
+
+		r.jobsPurged.Add(purged.Jobs)
+		r.jobsArchived.Add(purged.Archived)
+		r.eventsPurged.Add(purged.Events)
+		jobs += purged.Jobs
+		archived += purged.Archived
+		events += purged.Events
+
+		// The rows are already gone, so a failed deletion leaves an orphaned object that
+		// is logged for manual cleanup
+		for _, ref := range purged.ResultRefs {
+			if err := r.jobs.results.Delete(ctx, ref); err != nil {
+				r.errors.Add(1)
+				logger.Error("Failed to delete purged job result", slog.String("result_ref", ref), slog.String("error", err.Error()))
+				continue
+			}
+			r.resultsPurged.Add(1)
+			results++
+		}
+
+		if purged.Jobs < int64(r.opts.BatchSize) {
+			break
+		}
+	}
+
+	if jobs > 0 {
+		logger.Info("Purged old jobs",
+			slog.Int64("jobs", jobs),
+			slog.Int64("archived", archived),
+			slog.Int64("events", events),
+			slog.Int64("results", results),
+		)
+	}
+}
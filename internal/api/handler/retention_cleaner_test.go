@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionCleaner_Purge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)
+
+	// purgingStore returns the given batches one per call, then nothing
+	purgingStore := func(cutoffs *[]time.Time, batches ...*model.PurgeResult) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			PurgeJobsFunc: func(_ context.Context, cutoff time.Time, _ int, _ bool) (*model.PurgeResult, error) {
+				*cutoffs = append(*cutoffs, cutoff)
+				if len(batches) == 0 {
+					return &model.PurgeResult{}, nil
+				}
+				batch := batches[0]
+				batches = batches[1:]
+				return batch, nil
+			},
+		}
+	}
+
+	newCleaner := func(store *mocks.JobStorage, results fakeResultStore, opts RetentionOptions) *RetentionCleaner {
+		cleaner := NewRetentionCleaner(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Results:    results,
+		}, opts)
+		cleaner.now = func() time.Time { return now }
+		return cleaner
+	}
+
+	t.Run("purges batches until one is short", func(t *testing.T) {
+		var cutoffs []time.Time
+		store := purgingStore(&cutoffs,
+			&model.PurgeResult{Jobs: 2, Events: 6, ResultRefs: pq.StringArray{"s3://results/job-1.json"}},
+			&model.PurgeResult{Jobs: 1, Events: 2},
+		)
+
+		cleaner := newCleaner(store, fakeResultStore{}, RetentionOptions{MaxAge: 24 * time.Hour, BatchSize: 2})
+		cleaner.purge(context.Background())
+
+		assert.Equal(t, []time.Time{now.Add(-24 * time.Hour), now.Add(-24 * time.Hour)}, cutoffs)
+		assert.Equal(t, RetentionStats{JobsPurged: 3, EventsPurged: 8, ResultsPurged: 1}, cleaner.RetentionStats())
+	})
+
+	t.Run("archives when enabled", func(t *testing.T) {
+		var archive []bool
+		store := &mocks.JobStorage{
+			PurgeJobsFunc: func(_ context.Context, _ time.Time, _ int, a bool) (*model.PurgeResult, error) {
+				archive = append(archive, a)
+				return &model.PurgeResult{Jobs: 2, Archived: 2, Events: 4}, nil
+			},
+		}
+
+		cleaner := newCleaner(store, fakeResultStore{}, RetentionOptions{MaxAge: time.Hour, Archive: true})
+		cleaner.purge(context.Background())
+
+		assert.Equal(t, []bool{true}, archive)
+		assert.Equal(t, RetentionStats{JobsPurged: 2, JobsArchived: 2, EventsPurged: 4}, cleaner.RetentionStats())
+	})
+
+	t.Run("counts failed result deletions", func(t *testing.T) {
+		var cutoffs []time.Time
+		store := purgingStore(&cutoffs, &model.PurgeResult{Jobs: 1, ResultRefs: pq.StringArray{"s3://results/job-1.json"}})
+
+		cleaner := newCleaner(store, fakeResultStore{err: errors.New("access denied")}, RetentionOptions{MaxAge: time.Hour})
+		cleaner.purge(context.Background())
+
+		assert.Equal(t, RetentionStats{JobsPurged: 1, Errors: 1}, cleaner.RetentionStats())
+	})
+
+	t.Run("stops on a storage error", func(t *testing.T) {
+		calls := 0
+		store := &mocks.JobStorage{
+			PurgeJobsFunc: func(context.Context, time.Time, int, bool) (*model.PurgeResult, error) {
+				calls++
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		cleaner := newCleaner(store, fakeResultStore{}, RetentionOptions{MaxAge: time.Hour})
+		cleaner.purge(context.Background())
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, int64(1), cleaner.RetentionStats().Errors)
+	})
+
+	t.Run("does nothing outside the window", func(t *testing.T) {
+		var cutoffs []time.Time
+		store := purgingStore(&cutoffs)
+
+		cleaner := newCleaner(store, fakeResultStore{}, RetentionOptions{
+			MaxAge:      time.Hour,
+			WindowStart: 3 * time.Hour,
+			WindowEnd:   5 * time.Hour,
+		})
+		cleaner.purge(context.Background())
+
+		assert.Empty(t, cutoffs)
+	})
+}
+
+func TestRetentionCleaner_InWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		now        time.Time
+		want       bool
+	}{
+		{name: "no window", now: at(14, 0), want: true},
+		{name: "inside", start: 2 * time.Hour, end: 5 * time.Hour, now: at(2, 0), want: true},
+		{name: "at the end", start: 2 * time.Hour, end: 5 * time.Hour, now: at(5, 0), want: false},
+		{name: "wraps past midnight, before", start: 22 * time.Hour, end: 4 * time.Hour, now: at(23, 30), want: true},
+		{name: "wraps past midnight, after", start: 22 * time.Hour, end: 4 * time.Hour, now: at(3, 59), want: true},
+		{name: "wraps past midnight, outside", start: 22 * time.Hour, end: 4 * time.Hour, now: at(12, 0), want: false},
+		{name: "converted to UTC", start: 2 * time.Hour, end: 5 * time.Hour, now: at(3, 0).In(time.FixedZone("UTC+7", 7*3600)), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaner := NewRetentionCleaner(&Dependencies{JobStorage: &mocks.JobStorage{}}, RetentionOptions{WindowStart: tt.start, WindowEnd: tt.end})
+			assert.Equal(t, tt.want, cleaner.inWindow(tt.now))
+		})
+	}
+}
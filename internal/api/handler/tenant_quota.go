@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the quotas reported in 429 responses
+const (
+	quotaMaxPendingJobs  = "max_pending_jobs"
+	quotaMaxJobsPerDay   = "max_jobs_per_day"
+	quotaMaxPayloadBytes = "max_payload_bytes"
+)
+
+// jobQuota is one limit checked before jobs are created
+type jobQuota struct {
+	name   string
+	userID string // Set for the quotas of one user within the tenant
+	limit  int64
+	// used returns how much of the quota is taken, nil for per-job limits
+	used func(*model.TenantUsage) int64
+}
+
+// respondQuotaExceeded writes a 429 response and returns true when creating jobs would
+// take the tenant of the request, or the user submitting them, over a quota. Quotas come
+// from the tenancy config and from the quotas stored through the admin API. It also
+// responds, and returns true, when the quotas or usage cannot be read.
+func (h *JobHandler) respondQuotaExceeded(c *gin.Context, jobs []model.Job) bool {
+	ctx := c.Request.Context()
+	tenantID := tenant.ID(ctx)
+	// Every job of one request is submitted by the same user
+	userID := jobs[0].UserID
+
+	stored, err := h.storage.GetQuotas(ctx, userID)
+	if err != nil {
+		h.respondQuotaCheckFailed(c, tenantID, err)
+		return true
+	}
+
+	configured := h.tenancy.Quota(tenantID)
+	quotas := []jobQuota{
+		{name: quotaMaxPendingJobs, limit: configured.MaxPendingJobs, used: func(u *model.TenantUsage) int64 { return u.PendingJobs }},
+		{name: quotaMaxJobsPerDay, limit: configured.MaxJobsPerDay, used: func(u *model.TenantUsage) int64 { return u.JobsToday }},
+	}
+	for _, q := range stored {
+		used := func(u *model.TenantUsage) int64 { return u.JobsToday }
+		if q.UserID != "" {
+			used = func(u *model.TenantUsage) int64 { return u.UserJobsToday }
+		}
+		quotas = append(quotas,
+			jobQuota{name: quotaMaxPayloadBytes, userID: q.UserID, limit: q.MaxPayloadBytes},
+			jobQuota{name: quotaMaxJobsPerDay, userID: q.UserID, limit: q.MaxJobsPerDay, used: used},
+		)
+	}
+
+	var largest int64
+	for i := range jobs {
+		largest = max(largest, int64(len(jobs[i].Payload)))
+	}
+
+	needsUsage := false
+	for _, q := range quotas {
+		if q.limit <= 0 {
+			continue
+		}
+		if q.used == nil && largest > q.limit {
+			h.writeQuotaExceeded(c, tenantID, q)
+			return true
+		}
+		needsUsage = needsUsage || q.used != nil
+	}
+	if !needsUsage {
+		return false
+	}
+
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	usage, err := h.storage.GetTenantUsage(ctx, userID, midnight)
+	if err != nil {
+		h.respondQuotaCheckFailed(c, tenantID, err)
+		return true
+	}
+
+	// Concurrent requests are checked against the same usage, so a tenant can briefly
+	// end up a few jobs over its quota
+	count := int64(len(jobs))
+	for _, q := range quotas {
+		if q.limit <= 0 || q.used == nil || q.used(usage)+count <= q.limit {
+			continue
+		}
+
+		if q.name == quotaMaxJobsPerDay {
+			// The daily count starts over at midnight UTC
+			c.Header("Retry-After", strconv.Itoa(int(midnight.Add(24*time.Hour).Sub(now).Seconds())+1))
+		}
+		h.writeQuotaExceeded(c, tenantID, q)
+		return true
+	}
+
+	return false
+}
+
+// respondQuotaCheckFailed writes the response for a failed quota or usage lookup
+func (h *JobHandler) respondQuotaCheckFailed(c *gin.Context, tenantID string, err error) {
+	if h.respondDatabaseUnavailable(c, err) {
+		return
+	}
+
+	h.logger.Error("Failed to check quota", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to check tenant quota",
+	})
+}
+
+// writeQuotaExceeded writes the 429 response for an exceeded quota
+func (h *JobHandler) writeQuotaExceeded(c *gin.Context, tenantID string, q jobQuota) {
+	h.logger.Warn("Quota exceeded",
+		slog.String("tenant_id", tenantID),
+		slog.String("user_id", q.userID),
+		slog.String("quota", q.name),
+		slog.Int64("limit", q.limit),
+	)
+
+	body := gin.H{
+		"error":     "Tenant quota exceeded",
+		"tenant_id": tenantID,
+		"quota":     q.name,
+		"limit":     q.limit,
+	}
+	if q.userID != "" {
+		body["error"] = "User quota exceeded"
+		body["user_id"] = q.userID
+	}
+	c.JSON(http.StatusTooManyRequests, body)
+}
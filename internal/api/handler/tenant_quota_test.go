@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_TenantQuota(t *testing.T) {
+	createBody := `{"idempotency_key":"key-1","user_id":"user-1","job_type":"send_email","payload":"{}"}`
+	workflowBody := `{"idempotency_key":"wf-1","user_id":"user-1","steps":[` +
+		`{"name":"extract","job_type":"etl","payload":"{}"},{"name":"load","job_type":"etl","payload":"{}"}]}`
+
+	opts := TenancyOptions{
+		Enabled:      true,
+		DefaultQuota: TenantQuota{MaxPendingJobs: 10},
+		Quotas: map[string]TenantQuota{
+			"acme": {MaxJobsPerDay: 100},
+		},
+	}
+
+	// newRouter serves the job routes for tenantID with the given usage
+	newRouter := func(store *mocks.JobStorage, tenantID string) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  &fakePublisher{},
+			Tenancy:    opts,
+		})
+
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+		})
+		r.POST("/api/v1/jobs", h.CreateJob)
+		r.POST("/api/v1/workflows", h.CreateWorkflow)
+		return r
+	}
+	usageStore := func(usage model.TenantUsage) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			GetTenantUsageFunc: func(context.Context, string, time.Time) (*model.TenantUsage, error) { return &usage, nil },
+		}
+	}
+
+	t.Run("creates jobs under the quota", func(t *testing.T) {
+		store := usageStore(model.TenantUsage{PendingJobs: 9})
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/jobs", createBody)
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Len(t, store.CreatedJobs, 1)
+		assert.Equal(t, "globex", store.CreatedJobs[0].TenantID)
+	})
+
+	t.Run("rejects jobs over the pending quota", func(t *testing.T) {
+		store := usageStore(model.TenantUsage{PendingJobs: 10})
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.JSONEq(t, `{"error":"Tenant quota exceeded","tenant_id":"globex","quota":"max_pending_jobs","limit":10}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("Retry-After"))
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("rejects jobs over the daily quota until midnight", func(t *testing.T) {
+		store := usageStore(model.TenantUsage{PendingJobs: 50, JobsToday: 100})
+
+		w := doRequest(newRouter(store, "acme"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), `"quota":"max_jobs_per_day"`)
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, retryAfter, 24*60*60+1)
+	})
+
+	t.Run("counts every step of a workflow", func(t *testing.T) {
+		store := usageStore(model.TenantUsage{PendingJobs: 9})
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/workflows", workflowBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Empty(t, store.CreatedWorkflows)
+	})
+
+	t.Run("skips the check without a quota", func(t *testing.T) {
+		opts.DefaultQuota = TenantQuota{}
+		defer func() { opts.DefaultQuota = TenantQuota{MaxPendingJobs: 10} }()
+		store := &mocks.JobStorage{
+			GetTenantUsageFunc: func(context.Context, string, time.Time) (*model.TenantUsage, error) {
+				t.Error("usage read without a quota")
+				return nil, nil
+			},
+		}
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("rejects jobs over a stored user quota", func(t *testing.T) {
+		store := usageStore(model.TenantUsage{JobsToday: 30, UserJobsToday: 20})
+		store.GetQuotasFunc = func(_ context.Context, userID string) ([]model.Quota, error) {
+			return []model.Quota{
+				{TenantID: "acme", MaxJobsPerDay: 1000},
+				{TenantID: "acme", UserID: userID, MaxJobsPerDay: 20},
+			}, nil
+		}
+
+		w := doRequest(newRouter(store, "acme"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.JSONEq(t, `{"error":"User quota exceeded","tenant_id":"acme","user_id":"user-1","quota":"max_jobs_per_day","limit":20}`, w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		assert.Empty(t, store.CreatedJobs)
+	})
+
+	t.Run("rejects payloads over a stored quota without reading usage", func(t *testing.T) {
+		opts.DefaultQuota = TenantQuota{}
+		defer func() { opts.DefaultQuota = TenantQuota{MaxPendingJobs: 10} }()
+		store := &mocks.JobStorage{
+			GetQuotasFunc: func(context.Context, string) ([]model.Quota, error) {
+				return []model.Quota{{TenantID: "globex", MaxPayloadBytes: 1}}, nil
+			},
+			GetTenantUsageFunc: func(context.Context, string, time.Time) (*model.TenantUsage, error) {
+				t.Error("usage read for a payload quota")
+				return nil, nil
+			},
+		}
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.JSONEq(t, `{"error":"Tenant quota exceeded","tenant_id":"globex","quota":"max_payload_bytes","limit":1}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			GetTenantUsageFunc: func(context.Context, string, time.Time) (*model.TenantUsage, error) {
+				return nil, fmt.Errorf("failed to get tenant usage: %w", driver.ErrBadConn)
+			},
+		}
+
+		w := doRequest(newRouter(store, "globex"), http.MethodPost, "/api/v1/jobs", createBody)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Empty(t, store.CreatedJobs)
+	})
+}
+
+func TestTenancyOptions_Quota(t *testing.T) {
+	opts := TenancyOptions{
+		DefaultQuota: TenantQuota{MaxPendingJobs: 10},
+		Quotas:       map[string]TenantQuota{"acme": {MaxJobsPerDay: 100}},
+	}
+
+	assert.Equal(t, TenantQuota{MaxJobsPerDay: 100}, opts.Quota("acme"))
+	assert.Equal(t, TenantQuota{MaxPendingJobs: 10}, opts.Quota("globex"))
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/shared/jobtype"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// ValidationOptions tightens request validation beyond the DTO binding tags
+type ValidationOptions struct {
+	// JobTypes lists the job types workers can run, empty accepts any job type
+	JobTypes []string
+	// Definitions, when not empty, also rejects job types it does not define or has disabled
+	Definitions *jobtype.Registry
+	// RequireUUIDUserID rejects user_id values that are not UUIDs
+	RequireUUIDUserID bool
+}
+
+func init() {
+	// Report validation failures by the field names clients send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// requestFieldName returns the JSON or query parameter name of a DTO field
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// invalidRequest builds the 400 body for a request that failed binding. Validation
+// failures are also listed per field under "fields".
+func invalidRequest(message string, err error) gin.H {
+	body := gin.H{
+		"error":   message,
+		"details": err.Error(),
+	}
+	if fields := fieldErrors(err); len(fields) > 0 {
+		body["fields"] = fields
+	}
+	return body
+}
+
+// invalidFields builds the 400 body for fields rejected after binding
+func invalidFields(message string, fields []dto.FieldError) gin.H {
+	details := make([]string, len(fields))
+	for i, f := range fields {
+		details[i] = f.Field + " " + f.Message
+	}
+	return gin.H{
+		"error":   message,
+		"details": strings.Join(details, "; "),
+		"fields":  fields,
+	}
+}
+
+// fieldErrors converts the validator errors in err, if any, to FieldErrors
+func fieldErrors(err error) []dto.FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	fields := make([]dto.FieldError, len(verrs))
+	for i, fe := range verrs {
+		// Namespace starts with the DTO type name, e.g. CreateJobRequest.depends_on[0]
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		fields[i] = dto.FieldError{
+			Field:   path,
+			Rule:    fe.Tag(),
+			Message: fieldMessage(fe),
+		}
+	}
+	return fields
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	unit := "characters"
+	if kind := fe.Kind(); kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map {
+		unit = "items"
+	}
+	if fe.Param() == "1" {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s %s", fe.Param(), unit)
+	case "min":
+		return fmt.Sprintf("must be at least %s %s", fe.Param(), unit)
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+// checkUserID applies ValidationOptions to a bound user_id
+func (h *JobHandler) checkUserID(userID string) []dto.FieldError {
+	if !h.validation.RequireUUIDUserID {
+		return nil
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return []dto.FieldError{{Field: "user_id", Rule: "uuid", Message: "must be a UUID"}}
+	}
+	return nil
+}
+
+// checkJobType applies ValidationOptions to a bound job_type found at field
+func (h *JobHandler) checkJobType(field, jobType string) []dto.FieldError {
+	if h.validation.Definitions.Len() > 0 {
+		d, ok := h.validation.Definitions.Lookup(jobType)
+		if !ok {
+			return []dto.FieldError{{Field: field, Rule: "defined", Message: "must be a defined job type"}}
+		}
+		if !d.Enabled {
+			return []dto.FieldError{{Field: field, Rule: "enabled", Message: "job type is disabled"}}
+		}
+	}
+	if len(h.validation.JobTypes) == 0 {
+		return nil
+	}
+	for _, allowed := range h.validation.JobTypes {
+		if jobType == allowed {
+			return nil
+		}
+	}
+	return []dto.FieldError{{
+		Field:   field,
+		Rule:    "oneof",
+		Message: "must be one of " + strings.Join(h.validation.JobTypes, ", "),
+	}}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/shared/jobtype"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_Validation(t *testing.T) {
+	newRouter := func(store *mocks.JobStorage, opts ValidationOptions) *gin.Engine {
+		h := NewJobHandler(&Dependencies{
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+			JobStorage: store,
+			Publisher:  &fakePublisher{},
+			Validation: opts,
+		})
+		r := gin.New()
+		r.POST("/api/v1/jobs", h.CreateJob)
+		r.POST("/api/v1/workflows", h.CreateWorkflow)
+		return r
+	}
+
+	strict := ValidationOptions{
+		JobTypes:          []string{"send_email", "export_csv"},
+		RequireUUIDUserID: true,
+	}
+	userID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	definitions, err := jobtype.NewRegistry(
+		jobtype.Definition{Name: "send_email", Enabled: true},
+		jobtype.Definition{Name: "resize_image", Enabled: false},
+	)
+	require.NoError(t, err)
+	defined := ValidationOptions{Definitions: definitions}
+
+	tests := []struct {
+		name   string
+		opts   ValidationOptions
+		path   string
+		body   string
+		fields []dto.FieldError
+	}{
+		{
+			name: "missing fields",
+			path: "/api/v1/jobs",
+			body: `{"job_type":"send_email"}`,
+			fields: []dto.FieldError{
+				{Field: "idempotency_key", Rule: "required", Message: "is required"},
+				{Field: "user_id", Rule: "required", Message: "is required"},
+				{Field: "payload", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name: "field lengths and formats",
+			path: "/api/v1/jobs",
+			body: `{"idempotency_key":"` + strings.Repeat("k", 256) + `","user_id":"user-1","job_type":"send_email",
+				"payload":"{}","depends_on":["not-a-uuid"]}`,
+			fields: []dto.FieldError{
+				{Field: "idempotency_key", Rule: "max", Message: "must be at most 255 characters"},
+				{Field: "depends_on[0]", Rule: "uuid", Message: "must be a UUID"},
+			},
+		},
+		{
+			name: "unknown job type and non-UUID user ID",
+			opts: strict,
+			path: "/api/v1/jobs",
+			body: `{"idempotency_key":"key-1","user_id":"user-1","job_type":"resize_image","payload":"{}"}`,
+			fields: []dto.FieldError{
+				{Field: "user_id", Rule: "uuid", Message: "must be a UUID"},
+				{Field: "job_type", Rule: "oneof", Message: "must be one of send_email, export_csv"},
+			},
+		},
+		{
+			name: "workflow steps",
+			opts: strict,
+			path: "/api/v1/workflows",
+			body: `{"idempotency_key":"wf-1","user_id":"` + userID + `","steps":[
+				{"name":"extract","job_type":"export_csv","payload":"{}"},
+				{"name":"resize","job_type":"resize_image","payload":"{}"}]}`,
+			fields: []dto.FieldError{
+				{Field: "steps[1].job_type", Rule: "oneof", Message: "must be one of send_email, export_csv"},
+			},
+		},
+		{
+			name: "undefined job type",
+			opts: defined,
+			path: "/api/v1/jobs",
+			body: `{"idempotency_key":"key-1","user_id":"user-1","job_type":"export_csv","payload":"{}"}`,
+			fields: []dto.FieldError{
+				{Field: "job_type", Rule: "defined", Message: "must be a defined job type"},
+			},
+		},
+		{
+			name: "disabled job type",
+			opts: defined,
+			path: "/api/v1/jobs",
+			body: `{"idempotency_key":"key-1","user_id":"user-1","job_type":"resize_image","payload":"{}"}`,
+			fields: []dto.FieldError{
+				{Field: "job_type", Rule: "enabled", Message: "job type is disabled"},
+			},
+		},
+		{
+			name: "workflow without steps",
+			path: "/api/v1/workflows",
+			body: `{"idempotency_key":"wf-1","user_id":"user-1","steps":[]}`,
+			fields: []dto.FieldError{
+				{Field: "steps", Rule: "min", Message: "must be at least 1 item"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.JobStorage{}
+			w := doRequest(newRouter(store, tt.opts), http.MethodPost, tt.path, tt.body)
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+			var resp struct {
+				Error   string           `json:"error"`
+				Details string           `json:"details"`
+				Fields  []dto.FieldError `json:"fields"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "Invalid request body", resp.Error)
+			assert.NotEmpty(t, resp.Details)
+			assert.Equal(t, tt.fields, resp.Fields)
+			assert.Empty(t, store.CreatedJobs)
+		})
+	}
+
+	t.Run("allowed job type and UUID user ID", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"key-1","user_id":"` + userID + `","job_type":"send_email","payload":"{}"}`
+		w := doRequest(newRouter(store, strict), http.MethodPost, "/api/v1/jobs", body)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
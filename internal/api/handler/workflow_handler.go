@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateWorkflow handles POST /api/v1/workflows
+// Creates the steps of a workflow as WAITING jobs; the dependency resolver queues each
+// step once the steps it depends on have completed
+func (h *JobHandler) CreateWorkflow(c *gin.Context) {
+	h.logger.Info("CreateWorkflow called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+	)
+
+	// 1. Validate request body
+	var req dto.CreateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, invalidRequest("Invalid request body", err))
+		return
+	}
+
+	fields := h.checkUserID(req.UserID)
+	for i, step := range req.Steps {
+		fields = append(fields, h.checkJobType(fmt.Sprintf("steps[%d].job_type", i), step.JobType)...)
+	}
+	if len(fields) > 0 {
+		h.logger.Warn("Invalid request fields", slog.Any("fields", fields))
+		c.JSON(http.StatusBadRequest, invalidFields("Invalid request body", fields))
+		return
+	}
+
+	// 2. Resolve step dependencies and reject unknown names and cycles
+	order, deps, err := orderWorkflowSteps(req.Steps)
+	if err != nil {
+		h.logger.Error("Invalid workflow steps", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 3. Validate each step as a job of its own
+	metadata := make([]*string, len(req.Steps))
+	for i, step := range req.Steps {
+		var payloadMap map[string]interface{}
+		if err := json.Unmarshal([]byte(step.Payload), &payloadMap); err != nil {
+			h.logger.Error("Invalid JSON payload", slog.String("step", step.Name), slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid JSON payload",
+				"details": fmt.Sprintf("step %s: %s", step.Name, err),
+			})
+			return
+		}
+
+		if h.respondPayloadTooLarge(c, len(step.Payload)) {
+			return
+		}
+
+		if h.respondPayloadInvalid(c, step.JobType, []byte(step.Payload)) {
+			return
+		}
+
+		if metadata[i], err = parseMetadata(step.Metadata); err != nil {
+			h.logger.Error("Invalid metadata", slog.String("step", step.Name), slog.String("error", err.Error()))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid metadata",
+				"details": fmt.Sprintf("step %s: %s", step.Name, err),
+			})
+			return
+		}
+	}
+
+	// 4. Build the workflow and one WAITING job per step, parents first
+	now := time.Now().UTC()
+	workflow := model.Workflow{
+		WorkflowID:     uuid.New().String(),
+		IdempotencyKey: req.IdempotencyKey,
+		UserID:         req.UserID,
+		Name:           req.Name,
+		CreatedAt:      now,
+	}
+
+	jobIDs := make(map[string]string, len(req.Steps))
+	steps := make([]model.Job, 0, len(req.Steps))
+	for _, i := range order {
+		step := req.Steps[i]
+		stepName := step.Name
+		job := model.Job{
+			JobID: uuid.New().String(),
+			// Step keys only need to be unique; the workflow key guards against resubmission
+			IdempotencyKey: workflow.WorkflowID + ":" + step.Name,
+			UserID:         req.UserID,
+			JobType:        step.JobType,
+			Payload:        step.Payload,
+			Metadata:       metadata[i],
+			Status:         domain.JobStatusWaiting,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			WorkflowID:     &workflow.WorkflowID,
+			StepName:       &stepName,
+		}
+		for _, parent := range deps[i] {
+			job.DependsOn = append(job.DependsOn, jobIDs[parent])
+		}
+
+		jobIDs[step.Name] = job.JobID
+		steps = append(steps, job)
+	}
+
+	// 5. Create the workflow, applying the same backlog throttling and tenant quota as single jobs
+	if throttled, retryAfter := h.policies.Throttled(c.Request.Context(), h.countPendingJobs); throttled {
+		h.logger.Warn("Workflow creation throttled, queue backlog too large")
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Job queue is backed up, retry later",
+		})
+		return
+	}
+
+	if h.respondQuotaExceeded(c, steps) {
+		return
+	}
+
+	if err := h.storage.CreateWorkflow(c.Request.Context(), &workflow, steps); err != nil {
+		if errors.Is(err, domain.ErrIdempotencyConflict) {
+			h.logger.Warn("Duplicate idempotency key", slog.String("idempotency_key", workflow.IdempotencyKey))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":           "Workflow with this idempotency key already exists",
+				"idempotency_key": workflow.IdempotencyKey,
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to create workflow", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create workflow",
+		})
+		return
+	}
+
+	h.logger.Info("Workflow created",
+		slog.String("workflow_id", workflow.WorkflowID),
+		slog.Int("steps", len(steps)),
+	)
+
+	// 6. Return the workflow with its steps
+	c.JSON(http.StatusCreated, toWorkflowDTO(&workflow, steps))
+}
+
+// GetWorkflow handles GET /api/v1/workflows/:workflow_id
+// Retrieves a workflow with its steps and aggregated status
+func (h *JobHandler) GetWorkflow(c *gin.Context) {
+	workflowID := c.Param("workflow_id")
+	h.logger.Info("GetWorkflow called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("workflow_id", workflowID),
+	)
+
+	// 1. Validate workflow_id format (UUID)
+	if _, err := uuid.Parse(workflowID); err != nil {
+		h.logger.Error("Invalid workflow_id format", slog.String("workflow_id", workflowID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "workflow_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Query the workflow and its steps
+	workflow, steps, ok := h.loadWorkflow(c, workflowID)
+	if !ok {
+		return
+	}
+
+	// 3. Return the workflow
+	c.JSON(http.StatusOK, toWorkflowDTO(workflow, steps))
+}
+
+// CancelWorkflow handles POST /api/v1/workflows/:workflow_id/cancel
+// Cancels every step that has not started; running steps are left to finish
+func (h *JobHandler) CancelWorkflow(c *gin.Context) {
+	workflowID := c.Param("workflow_id")
+	h.logger.Info("CancelWorkflow called",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("workflow_id", workflowID),
+	)
+
+	// 1. Validate workflow_id format (UUID)
+	if _, err := uuid.Parse(workflowID); err != nil {
+		h.logger.Error("Invalid workflow_id format", slog.String("workflow_id", workflowID), slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "workflow_id must be a valid UUID",
+		})
+		return
+	}
+
+	// 2. Cancel the WAITING and PENDING steps
+	canceled, err := h.storage.CancelWorkflow(c.Request.Context(), workflowID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWorkflowNotFound) {
+			h.logger.Error("Workflow not found", slog.String("workflow_id", workflowID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Workflow not found",
+			})
+			return
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return
+		}
+
+		h.logger.Error("Failed to cancel workflow", slog.String("workflow_id", workflowID), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cancel workflow",
+		})
+		return
+	}
+
+	h.logger.Info("Workflow canceled",
+		slog.String("workflow_id", workflowID),
+		slog.Int64("canceled_steps", canceled),
+	)
+
+	// 3. Return the updated workflow
+	workflow, steps, ok := h.loadWorkflow(c, workflowID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toWorkflowDTO(workflow, steps))
+}
+
+// loadWorkflow fetches a workflow and its steps.
+// It writes the error response and returns false if the workflow could not be loaded.
+func (h *JobHandler) loadWorkflow(c *gin.Context, workflowID string) (*model.Workflow, []model.Job, bool) {
+	workflow, steps, err := h.storage.GetWorkflow(c.Request.Context(), workflowID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWorkflowNotFound) {
+			h.logger.Error("Workflow not found", slog.String("workflow_id", workflowID))
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Workflow not found",
+			})
+			return nil, nil, false
+		}
+
+		if h.respondDatabaseUnavailable(c, err) {
+			return nil, nil, false
+		}
+
+		h.logger.Error("Failed to get workflow", slog.String("workflow_id", workflowID), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get workflow",
+		})
+		return nil, nil, false
+	}
+
+	return workflow, steps, true
+}
+
+// workflowContext collects the inline results of the completed steps of job's workflow.
+// Offloaded results are not included.
+func (h *JobHandler) workflowContext(ctx context.Context, job *model.Job) (*dto.WorkflowContext, error) {
+	_, steps, err := h.storage.GetWorkflow(ctx, *job.WorkflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow context: %w", err)
+	}
+
+	wc := &dto.WorkflowContext{
+		WorkflowID: *job.WorkflowID,
+		Results:    map[string]json.RawMessage{},
+	}
+	if job.StepName != nil {
+		wc.Step = *job.StepName
+	}
+	for _, step := range steps {
+		if step.Status == domain.JobStatusCompleted && step.StepName != nil && step.Result != nil {
+			wc.Results[*step.StepName] = json.RawMessage(*step.Result)
+		}
+	}
+	return wc, nil
+}
+
+// orderWorkflowSteps resolves the dependencies of each step and returns the step indexes
+// in an order where every step comes after the steps it depends on. A step without
+// depends_on depends on the previous step. Unknown step names and cycles are errors.
+func orderWorkflowSteps(steps []dto.WorkflowStepRequest) ([]int, [][]string, error) {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if _, ok := index[step.Name]; ok {
+			return nil, nil, fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		index[step.Name] = i
+	}
+
+	deps := make([][]string, len(steps))
+	dependents := make([][]int, len(steps))
+	pending := make([]int, len(steps))
+	for i, step := range steps {
+		names := step.DependsOn
+		if names == nil && i > 0 {
+			names = []string{steps[i-1].Name}
+		}
+
+		for _, name := range uniqueStrings(names) {
+			parent, ok := index[name]
+			switch {
+			case !ok:
+				return nil, nil, fmt.Errorf("step %q depends on unknown step %q", step.Name, name)
+			case parent == i:
+				return nil, nil, fmt.Errorf("step %q depends on itself", step.Name)
+			}
+			deps[i] = append(deps[i], name)
+			dependents[parent] = append(dependents[parent], i)
+			pending[i]++
+		}
+	}
+
+	// Kahn's algorithm, taking ready steps in submission order
+	order := make([]int, 0, len(steps))
+	done := make([]bool, len(steps))
+	for len(order) < len(steps) {
+		next := -1
+		for i := range steps {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, step := range steps {
+				if !done[i] {
+					cycle = append(cycle, step.Name)
+				}
+			}
+			return nil, nil, fmt.Errorf("dependency cycle between steps %s", strings.Join(cycle, ", "))
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, child := range dependents[next] {
+			pending[child]--
+		}
+	}
+
+	return order, deps, nil
+}
+
+// workflowStatus aggregates step statuses: any FAILED step fails the workflow, then any
+// CANCELED step cancels it. Otherwise it is COMPLETED when every step is, RUNNING once a
+// step has started and PENDING before that.
+func workflowStatus(steps []model.Job) string {
+	counts := make(map[string]int)
+	for _, step := range steps {
+		counts[step.Status]++
+	}
+
+	switch {
+	case counts[domain.JobStatusFailed] > 0:
+		return domain.JobStatusFailed
+	case counts[domain.JobStatusCanceled] > 0:
+		return domain.JobStatusCanceled
+	case counts[domain.JobStatusCompleted] == len(steps):
+		return domain.JobStatusCompleted
+	case counts[domain.JobStatusRunning] > 0 || counts[domain.JobStatusCompleted] > 0:
+		return domain.JobStatusRunning
+	default:
+		return domain.JobStatusPending
+	}
+}
+
+// toWorkflowDTO converts a workflow and its steps into their API representation
+func toWorkflowDTO(workflow *model.Workflow, steps []model.Job) dto.WorkflowDTO {
+	resp := dto.WorkflowDTO{
+		WorkflowID:     workflow.WorkflowID,
+		IdempotencyKey: workflow.IdempotencyKey,
+		UserID:         workflow.UserID,
+		Name:           workflow.Name,
+		Status:         workflowStatus(steps),
+		Steps:          make([]dto.JobDTO, 0, len(steps)),
+		CreatedAt:      workflow.CreatedAt.Format(time.RFC3339),
+	}
+	for i := range steps {
+		resp.Steps = append(resp.Steps, toJobDTO(&steps[i]))
+	}
+	return resp
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobHandler_CreateWorkflow(t *testing.T) {
+	stepNames := func(steps []model.Job) []string {
+		names := make([]string, 0, len(steps))
+		for _, step := range steps {
+			names = append(names, *step.StepName)
+		}
+		return names
+	}
+
+	t.Run("steps without depends_on run in order", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"wf-1","user_id":"user-1","name":"etl","steps":[
+			{"name":"extract","job_type":"export_csv","payload":"{}"},
+			{"name":"load","job_type":"send_email","payload":"{}"}]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		require.Len(t, store.CreatedSteps, 1)
+		steps := store.CreatedSteps[0]
+		require.Len(t, steps, 2)
+		assert.Empty(t, steps[0].DependsOn)
+		assert.Equal(t, []string{steps[0].JobID}, []string(steps[1].DependsOn))
+		for _, step := range steps {
+			assert.Equal(t, domain.JobStatusWaiting, step.Status)
+			assert.Equal(t, store.CreatedWorkflows[0].WorkflowID, *step.WorkflowID)
+		}
+
+		var resp dto.WorkflowDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "etl", resp.Name)
+		assert.Equal(t, domain.JobStatusPending, resp.Status)
+		require.Len(t, resp.Steps, 2)
+		require.NotNil(t, resp.Steps[1].StepName)
+		assert.Equal(t, "load", *resp.Steps[1].StepName)
+	})
+
+	t.Run("explicit depends_on builds a DAG in dependency order", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"wf-1","user_id":"user-1","steps":[
+			{"name":"report","job_type":"send_email","payload":"{}","depends_on":["orders","users"]},
+			{"name":"orders","job_type":"export_csv","payload":"{}","depends_on":[]},
+			{"name":"users","job_type":"export_csv","payload":"{}","depends_on":[]}]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		steps := store.CreatedSteps[0]
+		assert.Equal(t, []string{"orders", "users", "report"}, stepNames(steps))
+		assert.Empty(t, steps[0].DependsOn)
+		assert.Empty(t, steps[1].DependsOn)
+		assert.Equal(t, []string{steps[0].JobID, steps[1].JobID}, []string(steps[2].DependsOn))
+	})
+
+	t.Run("invalid step graphs are rejected", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			steps   string
+			details string
+		}{
+			{
+				name:    "cycle",
+				steps:   `[{"name":"a","job_type":"send_email","payload":"{}","depends_on":["b"]},{"name":"b","job_type":"send_email","payload":"{}","depends_on":["a"]}]`,
+				details: "dependency cycle between steps a, b",
+			},
+			{
+				name:    "unknown step",
+				steps:   `[{"name":"a","job_type":"send_email","payload":"{}","depends_on":["missing"]}]`,
+				details: `step \"a\" depends on unknown step \"missing\"`,
+			},
+			{
+				name:    "self dependency",
+				steps:   `[{"name":"a","job_type":"send_email","payload":"{}","depends_on":["a"]}]`,
+				details: `step \"a\" depends on itself`,
+			},
+			{
+				name:    "duplicate name",
+				steps:   `[{"name":"a","job_type":"send_email","payload":"{}"},{"name":"a","job_type":"send_email","payload":"{}"}]`,
+				details: `duplicate step name \"a\"`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				store := &mocks.JobStorage{}
+				body := `{"idempotency_key":"wf-1","user_id":"user-1","steps":` + tt.steps + `}`
+
+				w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows", body)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), tt.details)
+				assert.Empty(t, store.CreatedWorkflows)
+			})
+		}
+	})
+
+	t.Run("invalid step payload", func(t *testing.T) {
+		store := &mocks.JobStorage{}
+		body := `{"idempotency_key":"wf-1","user_id":"user-1","steps":[{"name":"a","job_type":"send_email","payload":"not json"}]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "step a")
+	})
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			CreateWorkflowFunc: func(context.Context, *model.Workflow, []model.Job) error {
+				return domain.ErrIdempotencyConflict
+			},
+		}
+		body := `{"idempotency_key":"wf-1","user_id":"user-1","steps":[{"name":"a","job_type":"send_email","payload":"{}"}]}`
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows", body)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestJobHandler_GetWorkflow(t *testing.T) {
+	workflowID := "99999999-9999-9999-9999-999999999999"
+	now := time.Now().UTC()
+
+	workflowStore := func(statuses ...string) *mocks.JobStorage {
+		return &mocks.JobStorage{
+			GetWorkflowFunc: func(context.Context, string) (*model.Workflow, []model.Job, error) {
+				steps := make([]model.Job, 0, len(statuses))
+				for _, status := range statuses {
+					steps = append(steps, model.Job{JobID: "job", Status: status, Payload: `{}`, CreatedAt: now, UpdatedAt: now})
+				}
+				return &model.Workflow{WorkflowID: workflowID, CreatedAt: now}, steps, nil
+			},
+		}
+	}
+
+	t.Run("aggregates step statuses", func(t *testing.T) {
+		tests := []struct {
+			statuses []string
+			want     string
+		}{
+			{[]string{domain.JobStatusWaiting, domain.JobStatusPending}, domain.JobStatusPending},
+			{[]string{domain.JobStatusCompleted, domain.JobStatusWaiting}, domain.JobStatusRunning},
+			{[]string{domain.JobStatusCompleted, domain.JobStatusCompleted}, domain.JobStatusCompleted},
+			{[]string{domain.JobStatusCompleted, domain.JobStatusCanceled}, domain.JobStatusCanceled},
+			{[]string{domain.JobStatusFailed, domain.JobStatusCanceled}, domain.JobStatusFailed},
+		}
+
+		for _, tt := range tests {
+			w := doRequest(newTestRouter(workflowStore(tt.statuses...)), http.MethodGet, "/api/v1/workflows/"+workflowID, "")
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp dto.WorkflowDTO
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Status, "statuses %v", tt.statuses)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/workflows/"+workflowID, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := doRequest(newTestRouter(&mocks.JobStorage{}), http.MethodGet, "/api/v1/workflows/not-a-uuid", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestJobHandler_CancelWorkflow(t *testing.T) {
+	workflowID := "99999999-9999-9999-9999-999999999999"
+
+	t.Run("cancels and returns the workflow", func(t *testing.T) {
+		var canceled []string
+		store := &mocks.JobStorage{
+			CancelWorkflowFunc: func(_ context.Context, id string) (int64, error) {
+				canceled = append(canceled, id)
+				return 1, nil
+			},
+			GetWorkflowFunc: func(context.Context, string) (*model.Workflow, []model.Job, error) {
+				return &model.Workflow{WorkflowID: workflowID}, []model.Job{
+					{JobID: "job-1", Status: domain.JobStatusCompleted, Payload: `{}`},
+					{JobID: "job-2", Status: domain.JobStatusCanceled, Payload: `{}`},
+				}, nil
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows/"+workflowID+"/cancel", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{workflowID}, canceled)
+
+		var resp dto.WorkflowDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, domain.JobStatusCanceled, resp.Status)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := &mocks.JobStorage{
+			CancelWorkflowFunc: func(context.Context, string) (int64, error) {
+				return 0, domain.ErrWorkflowNotFound
+			},
+		}
+
+		w := doRequest(newTestRouter(store), http.MethodPost, "/api/v1/workflows/"+workflowID+"/cancel", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestJobHandler_PublishWorkflowStep(t *testing.T) {
+	workflowID := "99999999-9999-9999-9999-999999999999"
+	extract, load := "extract", "load"
+	result := `{"rows":3}`
+
+	store := &mocks.JobStorage{
+		GetWorkflowFunc: func(context.Context, string) (*model.Workflow, []model.Job, error) {
+			return &model.Workflow{WorkflowID: workflowID}, []model.Job{
+				{JobID: "job-1", StepName: &extract, Status: domain.JobStatusCompleted, Result: &result},
+				{JobID: "job-2", StepName: &load, Status: domain.JobStatusPending},
+			}, nil
+		},
+	}
+	publisher := &fakePublisher{}
+	h := NewJobHandler(&Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: store,
+		Publisher:  publisher,
+	})
+
+	job := &model.Job{JobID: "job-2", JobType: "send_email", Payload: `{}`, WorkflowID: &workflowID, StepName: &load}
+	require.NoError(t, h.publishJob(context.Background(), job))
+
+	require.Len(t, publisher.messages, 1)
+	var msg dto.JobMessage
+	require.NoError(t, json.Unmarshal(publisher.messages[0], &msg))
+	require.NotNil(t, msg.Context)
+	assert.Equal(t, workflowID, msg.Context.WorkflowID)
+	assert.Equal(t, "load", msg.Context.Step)
+	assert.JSONEq(t, result, string(msg.Context.Results["extract"]))
+	assert.NotContains(t, msg.Context.Results, "load")
+}
@@ -1,14 +1,147 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type Job struct {
-	JobID          string    `db:"job_id"`
+	JobID          string     `db:"job_id"`
+	IdempotencyKey string     `db:"idempotency_key"`
+	UserID         string     `db:"user_id"`
+	JobType        string     `db:"job_type"`
+	Payload        string     `db:"payload"`
+	Metadata       *string    `db:"metadata"`
+	OrderingKey    *string    `db:"ordering_key"`  // Jobs with the same key are dispatched in submission order
+	ExecuteAfter   *time.Time `db:"execute_after"` // Earliest time workers should run the job
+	Result         *string    `db:"result"`        // Inline result
+	ResultRef      *string    `db:"result_ref"`    // Object store reference for an offloaded result
+	Status         string     `db:"status"`
+	ErrorMessage   *string    `db:"error_message"`
+	RetryCount     int        `db:"retry_count"`
+	MaxRetries     int        `db:"max_retries"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	LastHeartbeat  *time.Time `db:"last_heartbeat_at"`
+	Version        int        `db:"version"` // Incremented by every update, for optimistic concurrency
+	// TenantID is set by the storage layer from the request context
+	TenantID string `db:"tenant_id"`
+
+	// DependsOn lists the jobs that must complete before this one is queued
+	DependsOn pq.StringArray `db:"depends_on"`
+	// WorkflowID and StepName are set for jobs submitted as workflow steps
+	WorkflowID *string `db:"workflow_id"`
+	StepName   *string `db:"step_name"`
+}
+
+// JobEvent is one status transition of a job
+type JobEvent struct {
+	ID        int64     `db:"id"`
+	JobID     string    `db:"job_id"`
+	OldStatus *string   `db:"old_status"` // Nil for the event that created the job
+	NewStatus string    `db:"new_status"`
+	ActorType string    `db:"actor_type"` // domain.ActorUser, ActorWorker or ActorSystem
+	Actor     *string   `db:"actor"`
+	Reason    *string   `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// JobLog is one entry an executor logged while running a job
+type JobLog struct {
+	ID       int64     `db:"id"`
+	JobID    string    `db:"job_id"`
+	Attempt  int       `db:"attempt"`
+	WorkerID *string   `db:"worker_id"`
+	Level    string    `db:"level"`
+	Message  string    `db:"message"`
+	Attrs    *string   `db:"attrs"` // JSON object of the entry's attributes
+	LoggedAt time.Time `db:"logged_at"`
+}
+
+// LifecycleEvent is a job event with the job fields that external subscribers route
+// and filter on
+type LifecycleEvent struct {
+	JobEvent
+	JobType  string  `db:"job_type"`
+	TenantID string  `db:"tenant_id"`
+	UserID   string  `db:"user_id"`
+	Metadata *string `db:"metadata"` // The job's client-supplied metadata
+}
+
+// PurgeResult counts what one retention batch removed
+type PurgeResult struct {
+	Jobs     int64 `db:"jobs"`
+	Events   int64 `db:"events"`
+	Archived int64 `db:"archived"` // Jobs copied to jobs_archive before they were purged
+
+	// ResultRefs are the offloaded results of the purged jobs, which still have to be
+	// removed from the result store. Archived jobs keep their results.
+	ResultRefs pq.StringArray `db:"result_refs"`
+}
+
+// ArchivedJob is a purged job as kept in jobs_archive, with its status history
+type ArchivedJob struct {
+	Job
+	ArchivedAt time.Time `db:"archived_at"`
+
+	Events []JobEvent `db:"-"`
+}
+
+// Workflow groups the jobs submitted together as the steps of one pipeline
+type Workflow struct {
+	WorkflowID     string    `db:"workflow_id"`
 	IdempotencyKey string    `db:"idempotency_key"`
 	UserID         string    `db:"user_id"`
-	JobType        string    `db:"job_type"`
-	Payload        string    `db:"payload"`
-	Status         string    `db:"status"`
+	Name           string    `db:"name"`
 	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// Worker is a registered worker process
+type Worker struct {
+	WorkerID        string    `db:"worker_id"`
+	Hostname        string    `db:"hostname"`
+	Version         string    `db:"version"`
+	Concurrency     int       `db:"concurrency"`
+	StartedAt       time.Time `db:"started_at"`
+	LastHeartbeatAt time.Time `db:"last_heartbeat_at"`
+
+	// JobIDs lists the RUNNING jobs assigned to the worker
+	JobIDs pq.StringArray `db:"job_ids"`
+}
+
+// Maintenance is the maintenance flag stored through the admin API
+type Maintenance struct {
+	Enabled   bool      `db:"enabled"`
+	Message   string    `db:"message"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// JobTypeStats holds historical execution statistics for a job type
+type JobTypeStats struct {
+	SampleSize   int64   `db:"sample_size"`
+	P50Seconds   float64 `db:"p50_seconds"`
+	P95Seconds   float64 `db:"p95_seconds"`
+	AvgSeconds   float64 `db:"avg_seconds"`
+	QueueBacklog int64   `db:"queue_backlog"`
+}
+
+// TenantUsage is what a tenant currently uses of its job quotas
+type TenantUsage struct {
+	PendingJobs int64 `db:"pending_jobs"` // Jobs PENDING or WAITING
+	JobsToday   int64 `db:"jobs_today"`   // Jobs created since midnight UTC, deleted ones included
+	// UserJobsToday counts the JobsToday created by one user
+	UserJobsToday int64 `db:"user_jobs_today"`
+}
+
+// Quota limits the jobs of a tenant, or of one user within it when UserID is set.
+// Zero limits are unlimited.
+type Quota struct {
+	TenantID        string    `db:"tenant_id"`
+	UserID          string    `db:"user_id"`
+	MaxJobsPerDay   int64     `db:"max_jobs_per_day"`  // Jobs created since midnight UTC
+	MaxRunningJobs  int64     `db:"max_running_jobs"`  // Stored for whatever runs jobs, not enforced by the API
+	MaxPayloadBytes int64     `db:"max_payload_bytes"` // Largest payload of a single job
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
 }
@@ -0,0 +1,32 @@
+// Package openapi holds the hand-maintained OpenAPI 3 description of the job API
+// and a Swagger UI page that renders it. Tests keep the spec in sync with the
+// registered routes and DTOs.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpecPath is where the spec is served; the Swagger UI page loads it from here
+const SpecPath = "/api/v1/openapi.json"
+
+// Spec is the OpenAPI document served at SpecPath
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// ServeSpec handles GET /api/v1/openapi.json
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", Spec)
+}
+
+// ServeUI handles GET /docs
+func ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+}
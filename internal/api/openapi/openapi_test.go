@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type specSchema struct {
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+type specParameter struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+type document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) document {
+	t.Helper()
+	var doc document
+	require.NoError(t, json.Unmarshal(Spec, &doc))
+	return doc
+}
+
+// fieldNames returns the names of v's fields under the given struct tag
+func fieldNames(v interface{}, tag string) []string {
+	var names []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSpec_Version(t *testing.T) {
+	assert.True(t, strings.HasPrefix(loadSpec(t).OpenAPI, "3."))
+}
+
+func TestSpec_SchemasMatchDTOs(t *testing.T) {
+	doc := loadSpec(t)
+
+	dtos := map[string]interface{}{
+		"CreateJobRequest":      dto.CreateJobRequest{},
+		"RetryJobRequest":       dto.RetryJobRequest{},
+		"CancelJobRequest":      dto.CancelJobRequest{},
+		"CreateWorkflowRequest": dto.CreateWorkflowRequest{},
+		"WorkflowStep":          dto.WorkflowStepRequest{},
+		"Workflow":              dto.WorkflowDTO{},
+		"Job":                   dto.JobDTO{},
+		"ListJobsResponse":      dto.ListJobsResponse{},
+		"ListJobsFilters":       dto.ListJobsFilters{},
+		"JobType":               dto.JobTypeResponse{},
+		"ListJobTypesResponse":  dto.ListJobTypesResponse{},
+		"JobTypeEstimate":       dto.JobTypeEstimateResponse{},
+		"JobExport":             dto.JobExport{},
+		"ExportEnvironment":     dto.ExportEnvironment{},
+		"LogLevelRequest":       dto.LogLevelRequest{},
+		"LogLevelResponse":      dto.LogLevelResponse{},
+		"MaintenanceRequest":    dto.MaintenanceRequest{},
+		"MaintenanceResponse":   dto.MaintenanceResponse{},
+		"Worker":                dto.WorkerDTO{},
+		"ListWorkersResponse":   dto.ListWorkersResponse{},
+		"QuotaRequest":          dto.QuotaRequest{},
+		"Quota":                 dto.QuotaDTO{},
+		"ListQuotasResponse":    dto.ListQuotasResponse{},
+		"FieldError":            dto.FieldError{},
+		"JobEvent":              dto.JobEventDTO{},
+		"ListJobEventsResponse": dto.ListJobEventsResponse{},
+		"JobLog":                dto.JobLogDTO{},
+		"ListJobLogsResponse":   dto.ListJobLogsResponse{},
+		"ArchivedJob":           dto.ArchivedJobResponse{},
+	}
+
+	for name, v := range dtos {
+		schema, ok := doc.Components.Schemas[name]
+		if !assert.True(t, ok, "schema %s is missing", name) {
+			continue
+		}
+
+		var documented []string
+		for prop := range schema.Properties {
+			documented = append(documented, prop)
+		}
+		sort.Strings(documented)
+
+		assert.Equal(t, fieldNames(v, "json"), documented, "schema %s is out of date with %T", name, v)
+	}
+}
+
+func TestSpec_ListJobsParametersMatchDTO(t *testing.T) {
+	doc := loadSpec(t)
+
+	var operation struct {
+		Parameters []specParameter `json:"parameters"`
+	}
+	require.NoError(t, json.Unmarshal(doc.Paths["/api/v1/jobs"]["get"], &operation))
+
+	var documented []string
+	for _, param := range operation.Parameters {
+		if param.In == "query" {
+			documented = append(documented, param.Name)
+		}
+	}
+	sort.Strings(documented)
+
+	assert.Equal(t, fieldNames(dto.ListJobsRequest{}, "form"), documented)
+}
@@ -0,0 +1,264 @@
+package policy
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+)
+
+// BrokerAction is what happens to a job message when the broker rejects a publish
+type BrokerAction string
+
+const (
+	// BrokerReject fails the request, leaving the job untouched
+	BrokerReject BrokerAction = "reject"
+	// BrokerDefer accepts the request and republishes the message in the background
+	BrokerDefer BrokerAction = "defer"
+)
+
+// DatabaseAction is what happens to requests while the database is unreachable
+type DatabaseAction string
+
+const (
+	// DatabaseReject fails every request with 503
+	DatabaseReject DatabaseAction = "reject"
+	// DatabaseServeStaleReads rejects writes but answers job lookups from recently read jobs
+	DatabaseServeStaleReads DatabaseAction = "serve_stale_reads"
+)
+
+// Options configures an Engine. The zero value rejects on every failure and never throttles.
+type Options struct {
+	BrokerUnavailable     BrokerAction
+	DatabaseUnavailable   DatabaseAction
+	StaleReadCacheSize    int
+	DeferredQueueSize     int
+	DeferredRetryInterval time.Duration
+	MaxPendingJobs        int64         // Throttle job creation above this backlog, 0 disables
+	BacklogRetryAfter     time.Duration // Retry-After sent with throttled responses
+	BacklogCheckInterval  time.Duration // How long a pending job count is reused
+}
+
+// deferredFlushTimeout bounds the last attempt to publish deferred messages at shutdown,
+// and to requeue the ones left after it
+const deferredFlushTimeout = 5 * time.Second
+
+// Republisher sends deferred job messages
+type Republisher interface {
+	// RepublishJob publishes the message of job once more, routed like its first publish
+	RepublishJob(ctx context.Context, job *model.Job, body []byte, contentType string) error
+	// RequeueJobs hands jobs whose messages are still deferred at shutdown to a durable
+	// queue, in order, so they are published after a restart
+	RequeueJobs(ctx context.Context, jobs []model.Job) error
+}
+
+// PendingCounter returns the current number of PENDING jobs
+type PendingCounter func(ctx context.Context) (int64, error)
+
+// Engine decides how handlers degrade when a dependency fails, replacing a hard failure
+// with the behavior declared in the policies config
+type Engine struct {
+	opts   Options
+	logger *slog.Logger
+
+	backlogMu        sync.Mutex
+	pending          int64
+	pendingCheckedAt time.Time
+
+	staleMu    sync.Mutex
+	stale      map[string]model.Job
+	staleOrder []string
+
+	deferredMu sync.Mutex
+	deferred   []deferredMessage
+}
+
+// deferredMessage is a job message waiting to be republished
+type deferredMessage struct {
+	job         model.Job
+	body        []byte
+	contentType string
+}
+
+// NewEngine creates a policy Engine
+func NewEngine(opts Options, logger *slog.Logger) *Engine {
+	if opts.DeferredRetryInterval <= 0 {
+		opts.DeferredRetryInterval = 5 * time.Second
+	}
+
+	return &Engine{
+		opts:   opts,
+		logger: logger,
+		stale:  make(map[string]model.Job),
+	}
+}
+
+// Throttled reports whether job creation should be rejected because the PENDING backlog
+// exceeds the configured maximum, and the Retry-After to send if so. The count is cached
+// for BacklogCheckInterval; if it cannot be refreshed the last known count is used.
+func (e *Engine) Throttled(ctx context.Context, count PendingCounter) (bool, time.Duration) {
+	if e.opts.MaxPendingJobs <= 0 {
+		return false, 0
+	}
+
+	e.backlogMu.Lock()
+	defer e.backlogMu.Unlock()
+
+	if time.Since(e.pendingCheckedAt) >= e.opts.BacklogCheckInterval {
+		pending, err := count(ctx)
+		if err != nil {
+			e.logger.Warn("Failed to count pending jobs, using last known backlog",
+				slog.Int64("pending", e.pending),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			e.pending = pending
+			e.pendingCheckedAt = time.Now()
+		}
+	}
+
+	if e.pending > e.opts.MaxPendingJobs {
+		return true, e.opts.BacklogRetryAfter
+	}
+	return false, 0
+}
+
+// RememberJob keeps a copy of a successfully read job for serve_stale_reads.
+// The oldest job is evicted once StaleReadCacheSize is reached.
+func (e *Engine) RememberJob(job *model.Job) {
+	if e.opts.DatabaseUnavailable != DatabaseServeStaleReads || e.opts.StaleReadCacheSize <= 0 {
+		return
+	}
+
+	e.staleMu.Lock()
+	defer e.staleMu.Unlock()
+
+	if _, ok := e.stale[job.JobID]; !ok {
+		if len(e.staleOrder) >= e.opts.StaleReadCacheSize {
+			delete(e.stale, e.staleOrder[0])
+			e.staleOrder = e.staleOrder[1:]
+		}
+		e.staleOrder = append(e.staleOrder, job.JobID)
+	}
+	e.stale[job.JobID] = *job
+}
+
+// StaleJob returns the last copy of a job read before the database became unavailable.
+// It always misses unless the database policy is serve_stale_reads.
+func (e *Engine) StaleJob(jobID string) (*model.Job, bool) {
+	e.staleMu.Lock()
+	defer e.staleMu.Unlock()
+
+	job, ok := e.stale[jobID]
+	if !ok {
+		return nil, false
+	}
+	return &job, true
+}
+
+// Defer queues the message of a job that could not be published so Run can republish
+// it later. It returns false when the broker policy is reject or the deferred queue is
+// full, in which case the caller must fail the request.
+func (e *Engine) Defer(job *model.Job, body []byte, contentType string) bool {
+	if e.opts.BrokerUnavailable != BrokerDefer {
+		return false
+	}
+
+	e.deferredMu.Lock()
+	defer e.deferredMu.Unlock()
+
+	if len(e.deferred) >= e.opts.DeferredQueueSize {
+		e.logger.Error("Deferred message queue is full", slog.Int("size", len(e.deferred)))
+		return false
+	}
+
+	e.deferred = append(e.deferred, deferredMessage{job: *job, body: body, contentType: contentType})
+	return true
+}
+
+// Run republishes deferred messages every DeferredRetryInterval until ctx is canceled.
+// At shutdown it tries them once more, then requeues the jobs of the messages left, so
+// they are not stuck in PENDING without a message.
+func (e *Engine) Run(ctx context.Context, republisher Republisher) {
+	ticker := time.NewTicker(e.opts.DeferredRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already canceled, so the last attempt gets its own deadline
+			stopCtx, cancel := context.WithTimeout(context.Background(), deferredFlushTimeout)
+			defer cancel()
+			e.flushDeferred(stopCtx, republisher)
+			e.requeueDeferred(stopCtx, republisher)
+			return
+		case <-ticker.C:
+			e.flushDeferred(ctx, republisher)
+		}
+	}
+}
+
+// flushDeferred publishes deferred messages in order, stopping at the first failure
+func (e *Engine) flushDeferred(ctx context.Context, republisher Republisher) {
+	published := 0
+	for {
+		e.deferredMu.Lock()
+		if len(e.deferred) == 0 {
+			e.deferredMu.Unlock()
+			break
+		}
+		msg := e.deferred[0]
+		e.deferredMu.Unlock()
+
+		if err := republisher.RepublishJob(ctx, &msg.job, msg.body, msg.contentType); err != nil {
+			e.logger.Warn("Broker still unavailable, keeping deferred messages",
+				slog.Int("pending", e.deferredLen()),
+				slog.String("error", err.Error()),
+			)
+			break
+		}
+
+		e.deferredMu.Lock()
+		e.deferred = e.deferred[1:]
+		e.deferredMu.Unlock()
+		published++
+	}
+
+	if published > 0 {
+		e.logger.Info("Republished deferred messages", slog.Int("count", published))
+	}
+}
+
+// requeueDeferred hands the jobs of the messages still deferred to the republisher's
+// durable queue. They are dropped only if that fails too.
+func (e *Engine) requeueDeferred(ctx context.Context, republisher Republisher) {
+	e.deferredMu.Lock()
+	deferred := e.deferred
+	e.deferred = nil
+	e.deferredMu.Unlock()
+
+	if len(deferred) == 0 {
+		return
+	}
+
+	jobs := make([]model.Job, len(deferred))
+	for i := range deferred {
+		jobs[i] = deferred[i].job
+	}
+	if err := republisher.RequeueJobs(ctx, jobs); err != nil {
+		e.logger.Error("Dropping deferred messages on shutdown, their jobs stay PENDING",
+			slog.Int("count", len(jobs)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	e.logger.Info("Requeued deferred messages on shutdown", slog.Int("count", len(jobs)))
+}
+
+func (e *Engine) deferredLen() int {
+	e.deferredMu.Lock()
+	defer e.deferredMu.Unlock()
+	return len(e.deferred)
+}
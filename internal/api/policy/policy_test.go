@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(opts Options) *Engine {
+	return NewEngine(opts, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestEngine_Throttled(t *testing.T) {
+	t.Run("disabled without a threshold", func(t *testing.T) {
+		e := newTestEngine(Options{})
+		throttled, _ := e.Throttled(context.Background(), func(context.Context) (int64, error) {
+			t.Fatal("counter must not be called")
+			return 0, nil
+		})
+		assert.False(t, throttled)
+	})
+
+	t.Run("throttles above the threshold and caches the count", func(t *testing.T) {
+		calls := 0
+		count := func(context.Context) (int64, error) {
+			calls++
+			return 11, nil
+		}
+		e := newTestEngine(Options{MaxPendingJobs: 10, BacklogRetryAfter: time.Minute, BacklogCheckInterval: time.Hour})
+
+		throttled, retryAfter := e.Throttled(context.Background(), count)
+		assert.True(t, throttled)
+		assert.Equal(t, time.Minute, retryAfter)
+
+		throttled, _ = e.Throttled(context.Background(), count)
+		assert.True(t, throttled)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("count errors fall back to the last known backlog", func(t *testing.T) {
+		e := newTestEngine(Options{MaxPendingJobs: 10})
+
+		throttled, _ := e.Throttled(context.Background(), func(context.Context) (int64, error) {
+			return 0, errors.New("connection refused")
+		})
+		assert.False(t, throttled)
+	})
+}
+
+func TestEngine_StaleReads(t *testing.T) {
+	t.Run("evicts the oldest job", func(t *testing.T) {
+		e := newTestEngine(Options{DatabaseUnavailable: DatabaseServeStaleReads, StaleReadCacheSize: 2})
+
+		e.RememberJob(&model.Job{JobID: "a"})
+		e.RememberJob(&model.Job{JobID: "b"})
+		e.RememberJob(&model.Job{JobID: "a", Status: "RUNNING"})
+		e.RememberJob(&model.Job{JobID: "c"})
+
+		_, ok := e.StaleJob("a")
+		assert.False(t, ok)
+
+		job, ok := e.StaleJob("c")
+		require.True(t, ok)
+		assert.Equal(t, "c", job.JobID)
+	})
+
+	t.Run("reject policy keeps nothing", func(t *testing.T) {
+		e := newTestEngine(Options{DatabaseUnavailable: DatabaseReject, StaleReadCacheSize: 2})
+
+		e.RememberJob(&model.Job{JobID: "a"})
+		_, ok := e.StaleJob("a")
+		assert.False(t, ok)
+	})
+}
+
+// flakyRepublisher fails until healthy is set
+type flakyRepublisher struct {
+	mu           sync.Mutex
+	healthy      bool
+	published    [][]byte
+	contentTypes []string
+	jobIDs       []string
+	requeued     []string
+	requeueErr   error
+}
+
+func (p *flakyRepublisher) RepublishJob(_ context.Context, job *model.Job, body []byte, contentType string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.healthy {
+		return errors.New("connection closed")
+	}
+	p.published = append(p.published, body)
+	p.contentTypes = append(p.contentTypes, contentType)
+	p.jobIDs = append(p.jobIDs, job.JobID)
+	return nil
+}
+
+func (p *flakyRepublisher) RequeueJobs(_ context.Context, jobs []model.Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.requeueErr != nil {
+		return p.requeueErr
+	}
+	for _, job := range jobs {
+		p.requeued = append(p.requeued, job.JobID)
+	}
+	return nil
+}
+
+func TestEngine_Defer(t *testing.T) {
+	job := func(id string) *model.Job { return &model.Job{JobID: id} }
+
+	t.Run("reject policy never defers", func(t *testing.T) {
+		e := newTestEngine(Options{BrokerUnavailable: BrokerReject, DeferredQueueSize: 10})
+		assert.False(t, e.Defer(job("job-1"), []byte("{}"), "application/json"))
+	})
+
+	t.Run("full queue rejects", func(t *testing.T) {
+		e := newTestEngine(Options{BrokerUnavailable: BrokerDefer, DeferredQueueSize: 1})
+		assert.True(t, e.Defer(job("job-1"), []byte("1"), "application/json"))
+		assert.False(t, e.Defer(job("job-2"), []byte("2"), "application/json"))
+	})
+
+	t.Run("messages are republished in order once the broker recovers", func(t *testing.T) {
+		e := newTestEngine(Options{BrokerUnavailable: BrokerDefer, DeferredQueueSize: 10})
+		require.True(t, e.Defer(job("job-1"), []byte("1"), "application/json"))
+		require.True(t, e.Defer(job("job-2"), []byte("2"), "application/x-protobuf"))
+
+		republisher := &flakyRepublisher{}
+		e.flushDeferred(context.Background(), republisher)
+		assert.Equal(t, 2, e.deferredLen())
+
+		republisher.healthy = true
+		e.flushDeferred(context.Background(), republisher)
+		assert.Equal(t, 0, e.deferredLen())
+		assert.Equal(t, [][]byte{[]byte("1"), []byte("2")}, republisher.published)
+		assert.Equal(t, []string{"application/json", "application/x-protobuf"}, republisher.contentTypes, "each keeps its content type")
+		assert.Equal(t, []string{"job-1", "job-2"}, republisher.jobIDs, "each keeps its job for routing")
+	})
+
+	t.Run("messages left at shutdown are requeued", func(t *testing.T) {
+		e := newTestEngine(Options{BrokerUnavailable: BrokerDefer, DeferredQueueSize: 10, DeferredRetryInterval: time.Hour})
+		require.True(t, e.Defer(job("job-1"), []byte("1"), "application/json"))
+		require.True(t, e.Defer(job("job-2"), []byte("2"), "application/json"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		republisher := &flakyRepublisher{}
+		e.Run(ctx, republisher)
+
+		assert.Empty(t, republisher.published)
+		assert.Equal(t, []string{"job-1", "job-2"}, republisher.requeued)
+		assert.Equal(t, 0, e.deferredLen())
+	})
+
+	t.Run("messages are published at shutdown when the broker is back", func(t *testing.T) {
+		e := newTestEngine(Options{BrokerUnavailable: BrokerDefer, DeferredQueueSize: 10, DeferredRetryInterval: time.Hour})
+		require.True(t, e.Defer(job("job-1"), []byte("1"), "application/json"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		republisher := &flakyRepublisher{healthy: true}
+		e.Run(ctx, republisher)
+
+		assert.Equal(t, []string{"job-1"}, republisher.jobIDs)
+		assert.Empty(t, republisher.requeued)
+	})
+}
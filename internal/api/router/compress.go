@@ -0,0 +1,159 @@
+package router
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// GzipMiddleware compresses responses of at least opts.MinSize bytes for clients that
+// send Accept-Encoding: gzip. Event streams and paths under opts.ExcludedPaths are
+// never compressed. Streamed responses are compressed from their first flush.
+func GzipMiddleware(opts handler.CompressionOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) || excludedPath(c.Request.URL.Path, opts.ExcludedPaths) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: opts.MinSize}
+		c.Writer = w
+		defer w.close()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		// q=0 means the coding is not acceptable
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+func excludedPath(path string, excluded []string) bool {
+	for _, prefix := range excluded {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter holds back the body until it reaches minSize, then either compresses it
+// or, for responses that should not be compressed, passes it through unchanged
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+
+	buf     []byte
+	started bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if w.started {
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush starts the response if it has not started yet, so streams reach the client
+func (w *gzipWriter) Flush() {
+	if !w.started {
+		_ = w.start()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start picks compression or pass-through and writes the held back body
+func (w *gzipWriter) start() error {
+	w.started = true
+
+	header := w.Header()
+	if compressible(header, w.Status()) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// close sends what is still held back: uncompressed when the body stayed under minSize
+func (w *gzipWriter) close() {
+	if !w.started {
+		w.started = true
+		if len(w.buf) > 0 {
+			_, _ = w.ResponseWriter.Write(w.buf)
+		}
+		return
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func compressible(header http.Header, status int) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	return !strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/router/stream"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	large := strings.Repeat(`{"job_id":"550e8400-e29b-41d4-a716-446655440000"}`, 100)
+
+	r := gin.New()
+	r.Use(GzipMiddleware(handler.CompressionOptions{MinSize: 1024, ExcludedPaths: []string{"/raw"}}))
+	r.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	r.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/raw/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	r.GET("/events", stream.Handler(slog.New(slog.NewTextHandler(io.Discard, nil)), stream.Options{ContentType: "text/event-stream"},
+		func(c *gin.Context, w io.Writer) error {
+			_, err := io.WriteString(w, "data: hello\n\n")
+			return err
+		}))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantGzip       bool
+		wantBody       string
+	}{
+		{name: "large response", path: "/large", acceptEncoding: "gzip, deflate", wantGzip: true, wantBody: large},
+		{name: "client without gzip", path: "/large", acceptEncoding: "", wantBody: large},
+		{name: "gzip refused with q=0", path: "/large", acceptEncoding: "gzip;q=0, identity", wantBody: large},
+		{name: "below min size", path: "/small", acceptEncoding: "gzip", wantBody: "ok"},
+		{name: "excluded path", path: "/raw/large", acceptEncoding: "gzip", wantBody: large},
+		{name: "event stream", path: "/events", acceptEncoding: "gzip", wantBody: "data: hello\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			body := w.Body.String()
+			if tt.wantGzip {
+				assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+				assert.Less(t, w.Body.Len(), len(large))
+				body = gunzip(t, w.Body)
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+			}
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestGzipMiddleware_FlushesStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(GzipMiddleware(handler.CompressionOptions{MinSize: 1024}))
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		_, _ = c.Writer.WriteString("{\"n\":1}\n")
+		c.Writer.Flush()
+		time.Sleep(10 * time.Millisecond)
+		_, _ = c.Writer.WriteString("{\"n\":2}\n")
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Flushing before min_size is reached still compresses, so lines arrive as written
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "{\"n\":1}\n{\"n\":2}\n", gunzip(t, resp.Body))
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, acceptsGzip("gzip"))
+	assert.True(t, acceptsGzip("br;q=1.0, gzip;q=0.8"))
+	assert.True(t, acceptsGzip("*"))
+	assert.False(t, acceptsGzip(""))
+	assert.False(t, acceptsGzip("deflate, br"))
+	assert.False(t, acceptsGzip("gzip;q=0"))
+}
+
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,161 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLimitsMiddleware rejects request bodies over opts.MaxBodyBytes with 413 and
+// gives each request a context deadline of opts.HandlerTimeout, or the override for its
+// route, answering 408 when the handler runs past it. Handlers are not interrupted: the
+// deadline cancels their database and broker calls, and whatever they write afterwards
+// is replaced by the 408.
+func RequestLimitsMiddleware(opts handler.RequestLimitsOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.MaxBodyBytes > 0 && c.Request.ContentLength > opts.MaxBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, bodyTooLarge(opts.MaxBodyBytes))
+			return
+		}
+
+		w := &limitsWriter{ResponseWriter: c.Writer, ctx: c.Request.Context(), maxBodyBytes: opts.MaxBodyBytes}
+
+		// Chunked bodies have no Content-Length, so the limit is also enforced while reading
+		if opts.MaxBodyBytes > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			w.body = &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxBodyBytes)}
+			c.Request.Body = w.body
+		}
+
+		timeout := opts.HandlerTimeout
+		if override, ok := opts.RouteTimeouts[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = override
+		}
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+			w.ctx = ctx
+			w.timeout = timeout
+		}
+
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		// A handler that gave up without writing still owes the client a response
+		if !w.decided {
+			w.decide()
+		}
+	}
+}
+
+func bodyTooLarge(limit int64) gin.H {
+	return gin.H{
+		"error":   "Request body too large",
+		"details": fmt.Sprintf("request body must not exceed %d bytes", limit),
+	}
+}
+
+// limitedBody records whether the request body went over the limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitsWriter decides on the first write whether the handler's response stands. If the
+// body went over the limit or the deadline has passed, it writes 413 or 408 instead and
+// discards the handler's response.
+type limitsWriter struct {
+	gin.ResponseWriter
+	ctx          context.Context
+	body         *limitedBody
+	maxBodyBytes int64
+	timeout      time.Duration
+
+	decided  bool
+	rejected bool
+}
+
+// decide runs once, before anything reaches the client
+func (w *limitsWriter) decide() {
+	w.decided = true
+
+	switch {
+	case w.body != nil && w.body.exceeded:
+		w.reject(http.StatusRequestEntityTooLarge, bodyTooLarge(w.maxBodyBytes))
+	case w.timeout > 0 && errors.Is(w.ctx.Err(), context.DeadlineExceeded):
+		w.reject(http.StatusRequestTimeout, gin.H{
+			"error":   "Request timeout",
+			"details": fmt.Sprintf("request was not handled within %s", w.timeout),
+		})
+	}
+}
+
+func (w *limitsWriter) reject(status int, body gin.H) {
+	w.rejected = true
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(status)
+	_ = json.NewEncoder(w.ResponseWriter).Encode(body)
+}
+
+func (w *limitsWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.decide()
+	}
+	if !w.rejected {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *limitsWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+	if !w.rejected {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *limitsWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.rejected {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *limitsWriter) WriteString(s string) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.rejected {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to extend its write deadline
+func (w *limitsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
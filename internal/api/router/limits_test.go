@@ -0,0 +1,125 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimitsMiddleware_BodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestLimitsMiddleware(handler.RequestLimitsOptions{MaxBodyBytes: 16}))
+	r.POST("/api/v1/jobs", func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, body)
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within limit", body: `{"a":"b"}`, wantStatus: http.StatusCreated},
+		{name: "content length over limit", body: `{"a":"0123456789abcdef"}`, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked body over limit", body: `{"a":"0123456789abcdef"}`, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "invalid body within limit", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				// Hide the length so the limit can only be found while reading
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", body)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				var resp map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "Request body too large", resp["error"])
+				assert.Contains(t, resp["details"], "16 bytes")
+			}
+		})
+	}
+}
+
+func TestRequestLimitsMiddleware_Timeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestLimitsMiddleware(handler.RequestLimitsOptions{
+		HandlerTimeout: 20 * time.Millisecond,
+		RouteTimeouts:  map[string]time.Duration{"GET /slow/:id": 0},
+	}))
+	r.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/canceled", func(c *gin.Context) {
+		// Like a handler whose query fails with the context error
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": c.Request.Context().Err().Error()})
+	})
+	r.GET("/silent", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	r.GET("/slow/:id", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		time.Sleep(40 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "finishes in time", path: "/fast", wantStatus: http.StatusOK, wantBody: `{"status":"ok"}`},
+		{name: "handler responds after deadline", path: "/canceled", wantStatus: http.StatusRequestTimeout},
+		{name: "handler gives up without responding", path: "/silent", wantStatus: http.StatusRequestTimeout},
+		{name: "route override disables deadline", path: "/slow/1", wantStatus: http.StatusOK, wantBody: `{"deadline":false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusRequestTimeout {
+				var resp map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "Request timeout", resp["error"])
+				assert.Equal(t, "request was not handled within 20ms", resp["details"])
+			}
+		})
+	}
+}
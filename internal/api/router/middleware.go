@@ -1,9 +1,15 @@
 package router
 
 import (
+	"crypto/subtle"
 	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
 	"github.com/gin-gonic/gin"
 )
 
@@ -44,19 +50,158 @@ func LoggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	}
 }
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware answers cross-origin requests from the origins in opts. Requests from
+// other origins get no CORS headers, so browsers block them, and their preflight
+// requests are rejected with 403.
+func CORSMiddleware(opts handler.CORSOptions) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, origin := range opts.AllowedOrigins {
+		allowed[origin] = true
+	}
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		switch {
+		case opts.AllowAllOrigins:
+			header.Set("Access-Control-Allow-Origin", "*")
+		case allowed[origin]:
+			header.Set("Access-Control-Allow-Origin", origin)
+			if opts.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		case preflight:
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		default:
+			c.Next()
+			return
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", methods)
+			header.Set("Access-Control-Allow-Headers", headers)
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminAuthMiddleware requires "Authorization: Bearer <token>" on admin routes. Without
+// a token the routes are only open in the development environment; elsewhere they
+// answer 503 until server.admin_token is set.
+func AdminAuthMiddleware(token, environment string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Idempotency-Key")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		if token == "" {
+			if environment == "development" {
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Admin API is disabled, server.admin_token is not set",
+			})
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// MaintenanceMiddleware rejects requests other than GET, HEAD and OPTIONS with 503 while
+// the API is in maintenance mode, so reads keep working during e.g. database migrations
+func MaintenanceMiddleware(mode *handler.MaintenanceMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		status := mode.Status()
+		if !status.Enabled {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Service under maintenance",
+			"message": status.Message,
+		})
+	}
+}
+
+// TenantMiddleware resolves the tenant of an API request and stores it in the request
+// context. A bearer token listed in opts.Tokens authenticates its tenant; without one the
+// tenant comes from the opts.Header header. A header naming another tenant than the
+// token is rejected.
+func TenantMiddleware(opts handler.TenancyOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tenantID string
+		if opts.Header != "" {
+			tenantID = c.GetHeader(opts.Header)
+		}
+
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && len(opts.Tokens) > 0 {
+			tokenTenant, found := "", false
+			for candidate, id := range opts.Tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+					tokenTenant, found = id, true
+				}
+			}
+			if !found {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error": "Unauthorized",
+				})
+				return
+			}
+			if tenantID != "" && tenantID != tokenTenant {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "Token does not belong to tenant " + tenantID,
+				})
+				return
+			}
+			tenantID = tokenTenant
+		}
+
+		if tenantID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Tenant is required",
+			})
+			return
+		}
+		if !tenant.Valid(tenantID) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid tenant ID",
+			})
 			return
 		}
 
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
 		c.Next()
 	}
 }
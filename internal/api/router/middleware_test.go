@@ -0,0 +1,217 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(token, environment string) *gin.Engine {
+		r := gin.New()
+		r.GET("/admin/ping", AdminAuthMiddleware(token, environment), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		environment string
+		header      string
+		wantStatus  int
+	}{
+		{name: "no token in development", token: "", environment: "development", header: "", wantStatus: http.StatusOK},
+		{name: "no token in staging", token: "", environment: "staging", header: "", wantStatus: http.StatusServiceUnavailable},
+		{name: "no token in production", token: "", environment: "production", header: "Bearer anything", wantStatus: http.StatusServiceUnavailable},
+		{name: "valid token", token: "s3cret", environment: "production", header: "Bearer s3cret", wantStatus: http.StatusOK},
+		{name: "missing header", token: "s3cret", environment: "development", header: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", environment: "production", header: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "s3cret", environment: "production", header: "Basic s3cret", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/ping", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.token, tt.environment).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mode := handler.NewMaintenanceMode(&handler.Dependencies{
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage: &mocks.JobStorage{},
+	}, 0)
+	r := gin.New()
+	r.Use(MaintenanceMiddleware(mode))
+	r.GET("/api/v1/jobs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/jobs", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/jobs", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost).Code)
+
+	mode.Configure(true, "Upgrading the database")
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet).Code)
+	w := serve(http.MethodPost)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"Service under maintenance","message":"Upgrading the database"}`, w.Body.String())
+
+	mode.Configure(false, "")
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost).Code)
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	opts := handler.TenancyOptions{
+		Enabled: true,
+		Header:  "X-Tenant-ID",
+		Tokens:  map[string]string{"acme-token": "acme"},
+	}
+
+	newRouter := func(opts handler.TenancyOptions) *gin.Engine {
+		r := gin.New()
+		r.GET("/api/v1/jobs", TenantMiddleware(opts), func(c *gin.Context) {
+			c.String(http.StatusOK, tenant.ID(c.Request.Context()))
+		})
+		return r
+	}
+
+	headerless := opts
+	headerless.Header = ""
+
+	tests := []struct {
+		name       string
+		opts       handler.TenancyOptions
+		token      string
+		tenant     string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "tenant header", opts: opts, tenant: "globex", wantStatus: http.StatusOK, wantTenant: "globex"},
+		{name: "token", opts: opts, token: "acme-token", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "token and matching header", opts: opts, token: "acme-token", tenant: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "token and other tenant", opts: opts, token: "acme-token", tenant: "globex", wantStatus: http.StatusForbidden},
+		{name: "unknown token", opts: opts, token: "nope", tenant: "acme", wantStatus: http.StatusUnauthorized},
+		{name: "no tenant", opts: opts, wantStatus: http.StatusUnauthorized},
+		{name: "invalid tenant", opts: opts, tenant: "acme corp", wantStatus: http.StatusBadRequest},
+		{name: "header disabled", opts: headerless, tenant: "globex", wantStatus: http.StatusUnauthorized},
+		{name: "header disabled with token", opts: headerless, token: "acme-token", wantStatus: http.StatusOK, wantTenant: "acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.opts).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantTenant, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	restricted := handler.CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	allowAll := handler.CORSOptions{
+		AllowAllOrigins: true,
+		AllowedMethods:  []string{"GET"},
+		AllowedHeaders:  []string{"Content-Type"},
+	}
+
+	tests := []struct {
+		name            string
+		opts            handler.CORSOptions
+		method          string
+		origin          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantMaxAge      string
+	}{
+		{name: "same-origin request", opts: restricted, method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "allowed origin", opts: restricted, method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK, wantOrigin: "https://app.example.com", wantCredentials: "true"},
+		{name: "other origin", opts: restricted, method: http.MethodGet, origin: "https://evil.example.com",
+			wantStatus: http.StatusOK},
+		{name: "allowed preflight", opts: restricted, method: http.MethodOptions, origin: "https://app.example.com",
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com", wantCredentials: "true", wantMaxAge: "600"},
+		{name: "rejected preflight", opts: restricted, method: http.MethodOptions, origin: "https://evil.example.com",
+			wantStatus: http.StatusForbidden},
+		{name: "no origins configured", opts: handler.CORSOptions{}, method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK},
+		{name: "allow all", opts: allowAll, method: http.MethodGet, origin: "http://localhost:3000",
+			wantStatus: http.StatusOK, wantOrigin: "*"},
+		{name: "allow all preflight", opts: allowAll, method: http.MethodOptions, origin: "http://localhost:3000",
+			wantStatus: http.StatusNoContent, wantOrigin: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORSMiddleware(tt.opts))
+			r.GET("/api/v1/jobs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/api/v1/jobs", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantCredentials, w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, tt.wantMaxAge, w.Header().Get("Access-Control-Max-Age"))
+			if tt.wantStatus == http.StatusNoContent {
+				assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Methods"))
+				assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Headers"))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessHandler reports whether the service can serve every request: 200 once the
+// database and broker are reachable, 503 naming the unavailable ones otherwise. Unlike
+// /health, which only shows the process is up, it fails while the service runs degraded,
+// e.g. after starting with startup.allow_degraded, and recovers with its dependencies.
+func ReadinessHandler(deps *handler.Dependencies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := gin.H{}
+		ready := true
+
+		if deps.DBClient != nil {
+			checks["database"] = "ok"
+			if err := deps.DBClient.HealthCheck(c.Request.Context()); err != nil {
+				deps.Logger.Warn("Readiness check failed", slog.String("dependency", "database"), slog.String("error", err.Error()))
+				checks["database"] = "unavailable"
+				ready = false
+			}
+		}
+
+		if deps.Broker != nil {
+			checks["broker"] = "ok"
+			if !deps.Broker.IsConnected() {
+				checks["broker"] = "unavailable"
+				ready = false
+			}
+		}
+
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": checks})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+	}
+}
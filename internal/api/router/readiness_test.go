@@ -0,0 +1,64 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	check := func(t *testing.T, deps *handler.Dependencies) (int, map[string]any) {
+		t.Helper()
+		r := gin.New()
+		r.GET("/ready", ReadinessHandler(deps))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		code, body := check(t, &handler.Dependencies{Logger: logger, Broker: broker.NewMemory(broker.MemoryOptions{})})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", body["status"])
+		assert.Equal(t, map[string]any{"broker": "ok"}, body["checks"])
+	})
+
+	t.Run("broker not connected yet", func(t *testing.T) {
+		lazy := broker.NewLazy()
+		deps := &handler.Dependencies{Logger: logger, Broker: lazy}
+
+		code, body := check(t, deps)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not_ready", body["status"])
+		assert.Equal(t, map[string]any{"broker": "unavailable"}, body["checks"])
+
+		lazy.Set(broker.NewMemory(broker.MemoryOptions{}))
+		code, _ = check(t, deps)
+		assert.Equal(t, http.StatusOK, code, "readiness flips once the broker connects")
+	})
+
+	t.Run("database unreachable", func(t *testing.T) {
+		db := postgresql.Open(&postgresql.Config{Host: "127.0.0.1", Port: 1, Database: "jobs_db", SSLMode: "disable"}, logger)
+		t.Cleanup(func() { _ = db.Close() })
+
+		code, body := check(t, &handler.Dependencies{Logger: logger, DBClient: db, Broker: broker.NewMemory(broker.MemoryOptions{})})
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, map[string]any{"database": "unavailable", "broker": "ok"}, body["checks"])
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/openapi"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,7 +15,13 @@ func SetupRouter(deps *handler.Dependencies) *gin.Engine {
 	// Middleware
 	r.Use(gin.Recovery())
 	r.Use(LoggerMiddleware(deps.Logger))
-	r.Use(CORSMiddleware())
+	r.Use(CORSMiddleware(deps.CORS))
+	if deps.Compression.Enabled {
+		r.Use(GzipMiddleware(deps.Compression))
+	}
+	if deps.Limits.MaxBodyBytes > 0 || deps.Limits.HandlerTimeout > 0 || len(deps.Limits.RouteTimeouts) > 0 {
+		r.Use(RequestLimitsMiddleware(deps.Limits))
+	}
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -24,29 +31,137 @@ func SetupRouter(deps *handler.Dependencies) *gin.Engine {
 		})
 	})
 
+	// Readiness endpoint, failing while the database or broker is unreachable
+	r.GET("/ready", ReadinessHandler(deps))
+
+	// API documentation: OpenAPI spec and a Swagger UI page that renders it
+	r.GET(openapi.SpecPath, openapi.ServeSpec)
+	r.GET("/docs", openapi.ServeUI)
+
+	// Metrics endpoint
+	if deps.QueryMetrics != nil {
+		metricsHandler := handler.NewMetricsHandler(deps)
+		r.GET("/metrics", metricsHandler.GetMetrics)
+	}
+
 	// Initialize job handler
 	jobHandler := handler.NewJobHandler(deps)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
+	if deps.Tenancy.Enabled {
+		v1.Use(TenantMiddleware(deps.Tenancy))
+	}
+	if deps.Maintenance != nil {
+		v1.Use(MaintenanceMiddleware(deps.Maintenance))
+	}
 	{
 		jobs := v1.Group("/jobs")
 		{
 			// POST /api/v1/jobs - Create a new job
 			jobs.POST("", jobHandler.CreateJob)
 
+			// POST /api/v1/jobs/import - Create a job from an exported job document
+			jobs.POST("/import", jobHandler.ImportJob)
+
 			// GET /api/v1/jobs - List jobs with filtering and pagination
 			jobs.GET("", jobHandler.ListJobs)
 
+			// GET /api/v1/jobs/export - Stream every matching job as NDJSON or CSV
+			jobs.GET("/export", jobHandler.ExportJobs)
+
+			// GET /api/v1/jobs/by-idempotency-key/:key - Get the job a user created with a key
+			jobs.GET("/by-idempotency-key/:key", jobHandler.GetJobByIdempotencyKey)
+
 			// GET /api/v1/jobs/:job_id - Get job details
 			jobs.GET("/:job_id", jobHandler.GetJob)
 
+			// GET /api/v1/jobs/:job_id/export - Export a self-contained job definition
+			jobs.GET("/:job_id/export", jobHandler.ExportJob)
+
+			// GET /api/v1/jobs/:job_id/events - Status transition history of a job
+			jobs.GET("/:job_id/events", jobHandler.ListJobEvents)
+			// GET /api/v1/jobs/:job_id/logs - Execution log of a job
+			jobs.GET("/:job_id/logs", jobHandler.ListJobLogs)
+
 			// POST /api/v1/jobs/:job_id/cancel - Cancel a job
 			jobs.POST("/:job_id/cancel", jobHandler.CancelJob)
 
+			// POST /api/v1/jobs/:job_id/retry - Retry a failed or canceled job
+			jobs.POST("/:job_id/retry", jobHandler.RetryJob)
+
 			// DELETE /api/v1/jobs/:job_id - Delete a job
 			jobs.DELETE("/:job_id", jobHandler.DeleteJob)
 		}
+
+		workflows := v1.Group("/workflows")
+		{
+			// POST /api/v1/workflows - Submit a multi-step workflow
+			workflows.POST("", jobHandler.CreateWorkflow)
+
+			// GET /api/v1/workflows/:workflow_id - Get a workflow and its steps
+			workflows.GET("/:workflow_id", jobHandler.GetWorkflow)
+
+			// POST /api/v1/workflows/:workflow_id/cancel - Cancel the steps that have not started
+			workflows.POST("/:workflow_id/cancel", jobHandler.CancelWorkflow)
+		}
+
+		jobTypes := v1.Group("/job-types")
+		{
+			// GET /api/v1/job-types - List job types with their schemas and limits
+			jobTypes.GET("", jobHandler.ListJobTypes)
+
+			// GET /api/v1/job-types/:job_type - Payload and result schemas of a job type
+			jobTypes.GET("/:job_type", jobHandler.GetJobType)
+
+			// GET /api/v1/job-types/:job_type/estimate - Duration and queue wait estimate
+			jobTypes.GET("/:job_type/estimate", jobHandler.EstimateJobType)
+		}
+
+		archive := v1.Group("/archive")
+		{
+			// GET /api/v1/archive/jobs/:job_id - Look up a purged job in the archive
+			archive.GET("/jobs/:job_id", jobHandler.GetArchivedJob)
+		}
+	}
+
+	// Admin routes for operators
+	adminHandler := handler.NewAdminHandler(deps)
+	admin := r.Group("/admin", AdminAuthMiddleware(deps.AdminToken, deps.App.Environment))
+	{
+		// GET /admin/workers - Registered workers and the jobs they are running
+		admin.GET("/workers", adminHandler.ListWorkers)
+
+		// GET /admin/quotas - Stored tenant and user quotas
+		admin.GET("/quotas", adminHandler.ListQuotas)
+
+		// PUT /admin/quotas/:tenant_id - Set the quota of a tenant
+		admin.PUT("/quotas/:tenant_id", adminHandler.PutQuota)
+
+		// DELETE /admin/quotas/:tenant_id - Remove the quota of a tenant
+		admin.DELETE("/quotas/:tenant_id", adminHandler.DeleteQuota)
+
+		// PUT /admin/quotas/:tenant_id/users/:user_id - Set the quota of a user within a tenant
+		admin.PUT("/quotas/:tenant_id/users/:user_id", adminHandler.PutQuota)
+
+		// DELETE /admin/quotas/:tenant_id/users/:user_id - Remove the quota of a user
+		admin.DELETE("/quotas/:tenant_id/users/:user_id", adminHandler.DeleteQuota)
+
+		if deps.Maintenance != nil {
+			// GET /admin/maintenance - Whether the API is in maintenance mode
+			admin.GET("/maintenance", adminHandler.GetMaintenance)
+
+			// PUT /admin/maintenance - Turn maintenance mode on or off
+			admin.PUT("/maintenance", adminHandler.SetMaintenance)
+		}
+
+		if deps.LogLevel != nil {
+			// GET /admin/log-level - Current log level
+			admin.GET("/log-level", adminHandler.GetLogLevel)
+
+			// PUT /admin/log-level - Change the log level without a restart
+			admin.PUT("/log-level", adminHandler.SetLogLevel)
+		}
 	}
 
 	return r
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/openapi"
+	"github.com/cuongbtq/practice-be/internal/api/storage/mocks"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticLogLevel struct{}
+
+func (staticLogLevel) Level() string         { return "info" }
+func (staticLogLevel) SetLevel(string) error { return nil }
+
+type emptyQueryStats struct{}
+
+func (emptyQueryStats) QueryStats() map[string]postgresql.QueryStats { return nil }
+
+// newFullRouter registers every optional route group
+func newFullRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	deps := &handler.Dependencies{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		JobStorage:   &mocks.JobStorage{},
+		LogLevel:     staticLogLevel{},
+		QueryMetrics: emptyQueryStats{},
+	}
+	deps.Maintenance = handler.NewMaintenanceMode(deps, 0)
+	return SetupRouter(deps)
+}
+
+func TestSetupRouter_OpenAPISpecMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openapi.Spec, &spec))
+
+	ginParam := regexp.MustCompile(`:([a-z_]+)`)
+	routes := make(map[string]bool)
+	for _, route := range newFullRouter().Routes() {
+		if route.Path == openapi.SpecPath || route.Path == "/docs" {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		key := strings.ToLower(route.Method) + " " + path
+		routes[key] = true
+
+		_, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		assert.True(t, ok, "route %s %s is not documented in openapi.json", route.Method, path)
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			assert.True(t, routes[method+" "+path], "openapi.json documents %s %s, which is not routed", strings.ToUpper(method), path)
+		}
+	}
+}
+
+func TestSetupRouter_ServesDocs(t *testing.T) {
+	r := newFullRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.True(t, json.Valid(w.Body.Bytes()))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), openapi.SpecPath)
+}
@@ -0,0 +1,170 @@
+// Package stream writes streaming HTTP responses: SSE, NDJSON and CSV exports. It is
+// kept apart from the router so handlers can stream without importing the router.
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSEHeartbeat is an SSE comment line, ignored by EventSource clients
+var SSEHeartbeat = []byte(": heartbeat\n\n")
+
+// Options configures a streaming response
+type Options struct {
+	ContentType       string        // e.g. text/event-stream, application/x-ndjson
+	HeartbeatInterval time.Duration // 0 disables heartbeats
+	Heartbeat         []byte        // Written verbatim on every heartbeat tick
+}
+
+// Func writes a streaming response body to w, which flushes after every write.
+// c.Request.Context() is canceled as soon as the client disconnects or a write fails,
+// so implementations must stop their work (DB cursors, subscriptions, goroutines) when it is done.
+type Func func(c *gin.Context, w io.Writer) error
+
+// Handler adapts fn into a gin handler, answering 500 when fn fails before writing
+func Handler(logger *slog.Logger, opts Options, fn Func) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := Serve(c, logger, opts, fn); err != nil {
+			logger.Error("Stream failed before first write",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("error", err.Error()),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to stream response",
+			})
+		}
+	}
+}
+
+// Serve streams the body fn writes, flushing after every write, sending heartbeats
+// while idle and tearing everything down when the client goes away. Streams outlive
+// server.write_timeout, so the write deadline is lifted for this response only.
+//
+// Serve returns fn's error only when fn failed before writing anything, with the
+// streaming headers removed, so the caller can still answer with an error status.
+// Once the status has been sent, a failure is logged and the response just ends; fn
+// may write a last line saying so before returning its error.
+func Serve(c *gin.Context, logger *slog.Logger, opts Options, fn Func) error {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Debug("Write deadline not lifted", slog.String("error", err.Error()))
+	}
+
+	headers := map[string]string{
+		"Content-Type":      opts.ContentType,
+		"Cache-Control":     "no-cache",
+		"Connection":        "keep-alive",
+		"X-Accel-Buffering": "no",
+	}
+	for name, value := range headers {
+		c.Header(name, value)
+	}
+
+	sw := &streamWriter{w: c.Writer, cancel: cancel}
+
+	var wg sync.WaitGroup
+	if opts.HeartbeatInterval > 0 && len(opts.Heartbeat) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sw.heartbeat(ctx, opts.HeartbeatInterval, opts.Heartbeat)
+		}()
+	}
+
+	err := fn(c, sw)
+
+	// Stop the heartbeat before returning so nothing writes to a finished response
+	cancel()
+	wg.Wait()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled) || sw.Err() != nil:
+		logger.Debug("Stream closed by client",
+			slog.String("path", c.Request.URL.Path),
+		)
+	case !sw.Written():
+		for name := range headers {
+			c.Writer.Header().Del(name)
+		}
+		return err
+	default:
+		// Headers are already sent; the only thing left to do is log and close
+		logger.Error("Stream aborted",
+			slog.String("path", c.Request.URL.Path),
+			slog.String("error", err.Error()),
+		)
+	}
+	return nil
+}
+
+// streamWriter serializes writes from the handler and the heartbeat goroutine,
+// flushing after each one and canceling the stream on the first write error
+type streamWriter struct {
+	mu      sync.Mutex
+	w       gin.ResponseWriter
+	cancel  context.CancelFunc
+	err     error
+	written bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n, err := s.w.Write(p)
+	if err != nil {
+		s.err = err
+		s.cancel()
+		return n, err
+	}
+
+	s.written = true
+	s.w.Flush()
+	return n, nil
+}
+
+// Err returns the first write error, if any
+func (s *streamWriter) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Written reports whether any data has been sent to the client
+func (s *streamWriter) Written() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written
+}
+
+func (s *streamWriter) heartbeat(ctx context.Context, interval time.Duration, payload []byte) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Write(payload); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamServer(t *testing.T, opts Options, fn Func) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/stream", Handler(slog.New(slog.NewTextHandler(io.Discard, nil)), opts, fn))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestServe_WritesAndFlushes(t *testing.T) {
+	srv := newStreamServer(t, Options{ContentType: "application/x-ndjson"},
+		func(_ *gin.Context, w io.Writer) error {
+			for i := 0; i < 3; i++ {
+				if _, err := fmt.Fprintf(w, "{\"n\":%d}\n", i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	resp, err := http.Get(srv.URL + "/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"n\":0}\n{\"n\":1}\n{\"n\":2}\n", string(body))
+}
+
+func TestServe_SendsHeartbeats(t *testing.T) {
+	srv := newStreamServer(t, Options{
+		ContentType:       "text/event-stream",
+		HeartbeatInterval: 10 * time.Millisecond,
+		Heartbeat:         SSEHeartbeat,
+	}, func(c *gin.Context, _ io.Writer) error {
+		<-c.Request.Context().Done()
+		return c.Request.Context().Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": heartbeat\n", line)
+}
+
+func TestServe_CancelsOnClientDisconnect(t *testing.T) {
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+
+	srv := newStreamServer(t, Options{ContentType: "text/event-stream"},
+		func(c *gin.Context, w io.Writer) error {
+			if _, err := io.WriteString(w, "data: hello\n\n"); err != nil {
+				return err
+			}
+			close(started)
+			<-c.Request.Context().Done()
+			stopped <- c.Request.Context().Err()
+			return c.Request.Context().Err()
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-stopped:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream function was not canceled after client disconnect")
+	}
+}
+
+func TestServe_ErrorBeforeFirstWrite(t *testing.T) {
+	srv := newStreamServer(t, Options{ContentType: "application/x-ndjson"},
+		func(_ *gin.Context, _ io.Writer) error {
+			return errors.New("query failed")
+		})
+
+	resp, err := http.Get(srv.URL + "/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestServe_ReturnsErrorBeforeFirstWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	r := gin.New()
+	r.GET("/stream", func(c *gin.Context) {
+		err := Serve(c, logger, Options{ContentType: "application/x-ndjson"}, func(_ *gin.Context, _ io.Writer) error {
+			return errors.New("database unavailable")
+		})
+		require.EqualError(t, err, "database unavailable")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database unavailable"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+}
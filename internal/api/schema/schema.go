@@ -0,0 +1,273 @@
+// Package schema validates job payloads against per-job-type JSON Schemas.
+//
+// Only the subset of JSON Schema that payload contracts need is supported: type,
+// properties, required, additionalProperties (boolean), items, enum, minLength,
+// maxLength, minimum, maximum, minItems and maxItems. Schemas using any other
+// validation keyword are rejected when loaded rather than silently ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// annotationKeywords are accepted in schema documents but do not affect validation
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "examples": true, "default": true,
+}
+
+// Schema is a parsed JSON Schema document
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []json.RawMessage  `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	// document is the source of a parsed schema, so it is published with its annotations
+	document json.RawMessage
+}
+
+// MarshalJSON returns the document the schema was parsed from, or the schema's keywords
+// when it was built in code
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.document != nil {
+		return s.document, nil
+	}
+	type keywords Schema
+	return json.Marshal((*keywords)(s))
+}
+
+// Parse parses a schema document, rejecting keywords this package cannot enforce
+func Parse(data []byte) (*Schema, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("schema must be a JSON object: %w", err)
+	}
+
+	for keyword, value := range raw {
+		if annotationKeywords[keyword] {
+			continue
+		}
+		switch keyword {
+		case "properties":
+			var props map[string]json.RawMessage
+			if err := json.Unmarshal(value, &props); err != nil {
+				return nil, fmt.Errorf("invalid properties: %w", err)
+			}
+			for name, prop := range props {
+				if _, err := Parse(prop); err != nil {
+					return nil, fmt.Errorf("properties.%s: %w", name, err)
+				}
+			}
+		case "items":
+			if _, err := Parse(value); err != nil {
+				return nil, fmt.Errorf("items: %w", err)
+			}
+		case "type", "required", "additionalProperties", "enum",
+			"minLength", "maxLength", "minimum", "maximum", "minItems", "maxItems":
+		default:
+			return nil, fmt.Errorf("unsupported schema keyword %q", keyword)
+		}
+	}
+
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	switch s.Type {
+	case "", "object", "array", "string", "number", "integer", "boolean", "null":
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", s.Type)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	s.document = compact.Bytes()
+
+	return &s, nil
+}
+
+// Validate reports every place value violates the schema, or nil if it conforms
+func (s *Schema) Validate(payload []byte) error {
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	var errs []error
+	s.validate("$", value, &errs)
+	return errors.Join(errs...)
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		fail("expected %s, got %s", s.Type, typeOf(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		fail("value is not one of the allowed values")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(path+"."+name, v[name], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fail("unexpected property %q", name)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	}
+}
+
+func hasType(value interface{}, typ string) bool {
+	if typ == "integer" {
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	return typeOf(value) == typ
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// inEnum compares value with each allowed value by canonical JSON encoding
+func inEnum(value interface{}, allowed []json.RawMessage) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, candidate := range allowed {
+		var decoded interface{}
+		dec := json.NewDecoder(bytes.NewReader(candidate))
+		dec.UseNumber()
+		if dec.Decode(&decoded) != nil {
+			continue
+		}
+		if canonical, err := json.Marshal(decoded); err == nil && bytes.Equal(canonical, encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry maps job types to the schema their payloads must satisfy.
+// Job types without a schema accept any JSON object.
+type Registry map[string]*Schema
+
+// LoadDir loads every <job_type>.json file in dir into a Registry
+func LoadDir(dir string) (Registry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	registry := make(Registry, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+
+		s, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+		}
+
+		registry[strings.TrimSuffix(filepath.Base(path), ".json")] = s
+	}
+	return registry, nil
+}
+
+// Validate checks payload against the schema registered for jobType, if any
+func (r Registry) Validate(jobType string, payload []byte) error {
+	s, ok := r[jobType]
+	if !ok {
+		return nil
+	}
+	return s.Validate(payload)
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const emailSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "send_email payload",
+	"type": "object",
+	"required": ["to", "subject"],
+	"additionalProperties": false,
+	"properties": {
+		"to": {"type": "string", "minLength": 3, "maxLength": 254},
+		"subject": {"type": "string"},
+		"priority": {"type": "string", "enum": ["low", "normal", "high"]},
+		"retries": {"type": "integer", "minimum": 0, "maximum": 5},
+		"cc": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Parse([]byte(emailSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr []string
+	}{
+		{
+			name:    "valid payload",
+			payload: `{"to":"a@b.c","subject":"hi","priority":"high","retries":2,"cc":["x@y.z"]}`,
+		},
+		{
+			name:    "missing required properties",
+			payload: `{}`,
+			wantErr: []string{`$: missing required property "to"`, `$: missing required property "subject"`},
+		},
+		{
+			name:    "wrong type",
+			payload: `{"to":42,"subject":"hi"}`,
+			wantErr: []string{"$.to: expected string, got number"},
+		},
+		{
+			name:    "unexpected property",
+			payload: `{"to":"a@b.c","subject":"hi","bcc":"x"}`,
+			wantErr: []string{`$: unexpected property "bcc"`},
+		},
+		{
+			name:    "enum and bounds",
+			payload: `{"to":"ab","subject":"hi","priority":"urgent","retries":9}`,
+			wantErr: []string{
+				"$.priority: value is not one of the allowed values",
+				"$.retries: must be <= 5",
+				"$.to: must be at least 3 characters",
+			},
+		},
+		{
+			name:    "integer rejects fractions",
+			payload: `{"to":"a@b.c","subject":"hi","retries":1.5}`,
+			wantErr: []string{"$.retries: expected integer, got number"},
+		},
+		{
+			name:    "array items and length",
+			payload: `{"to":"a@b.c","subject":"hi","cc":["a",1,"c"]}`,
+			wantErr: []string{"$.cc: must have at most 2 items", "$.cc[1]: expected string, got number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.payload))
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestParse_RejectsUnsupportedKeywords(t *testing.T) {
+	_, err := Parse([]byte(`{"type":"object","properties":{"id":{"type":"string","pattern":"^[a-z]+$"}}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `properties.id: unsupported schema keyword "pattern"`)
+
+	_, err = Parse([]byte(`{"type":"date"}`))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestSchema_MarshalJSON(t *testing.T) {
+	s, err := Parse([]byte(emailSchema))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, emailSchema, string(data), "parsed schemas keep their annotations")
+
+	maxLength := 10
+	data, err = json.Marshal(&Schema{Type: "string", MaxLength: &maxLength})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"string","maxLength":10}`, string(data))
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "send_email.json"), []byte(emailSchema), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a schema"), 0o600))
+
+	registry, err := LoadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, registry, 1)
+
+	assert.Error(t, registry.Validate("send_email", []byte(`{}`)))
+	assert.NoError(t, registry.Validate("resize_image", []byte(`{}`)), "job types without a schema accept any payload")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"type":`), 0o600))
+	_, err = LoadDir(dir)
+	assert.ErrorContains(t, err, "broken.json")
+}
@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// IsUnavailable reports whether err means the database could not be reached,
+// as opposed to a failing query or constraint
+func IsUnavailable(err error) bool {
+	return errors.Is(postgresql.TranslateError(err), postgresql.ErrConnection)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// exportFetchSize is how many rows ExportJobs fetches from its cursor at a time
+const exportFetchSize = 500
+
+// ExportJobs passes every job matching filter to write in filter.Sort order, continuing
+// after filter.Cursor when set. Rows are read through a server-side cursor in a read-only
+// snapshot, so memory stays bounded however many jobs match and the export does not see
+// jobs created after it started. PageSize and Offset are ignored. It stops at the first
+// error write returns and returns it.
+func (s *Storage) ExportJobs(ctx context.Context, filter JobFilter, write func(*model.Job) error) error {
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Nothing is written, so the transaction is never committed
+	defer func() { _ = tx.Rollback() }()
+
+	conditions, args := filter.where(tenant.ID(ctx))
+
+	column := filter.Sort.Column()
+	if filter.Cursor != nil {
+		op := "<"
+		if filter.Sort.Ascending {
+			op = ">"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, job_id) %s (?, ?)", column, op))
+		args = append(args, filter.Cursor.key(), filter.Cursor.JobID)
+	}
+
+	query := `
+		DECLARE jobs_export NO SCROLL CURSOR FOR
+		SELECT
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version
+		FROM jobs`
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY %s %s, job_id %s", column, filter.Sort.direction(), filter.Sort.direction())
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to export jobs: %w", postgresql.TranslateError(err))
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM jobs_export", exportFetchSize)
+	for {
+		var jobs []model.Job
+		if err := tx.SelectContext(ctx, &jobs, fetch); err != nil {
+			return fmt.Errorf("failed to export jobs: %w", postgresql.TranslateError(err))
+		}
+
+		for i := range jobs {
+			if err := write(&jobs[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(jobs) < exportFetchSize {
+			return nil
+		}
+	}
+}
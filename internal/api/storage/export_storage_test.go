@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ExportJobs(t *testing.T) {
+	now := time.Now().UTC()
+	fetch := regexp.QuoteMeta("FETCH FORWARD 500 FROM jobs_export")
+
+	jobRows := func(from, n int) *sqlmock.Rows {
+		rows := sqlmock.NewRows(jobColumns)
+		for i := from; i < from+n; i++ {
+			rows.AddRow(fmt.Sprintf("job-%d", i), fmt.Sprintf("key-%d", i), "user-1", "send_email", `{}`, nil, nil, nil, nil,
+				domain.JobStatusCompleted, nil, 0, 3, now, now, nil)
+		}
+		return rows
+	}
+
+	t.Run("fetches until a short batch", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DECLARE jobs_export NO SCROLL CURSOR FOR")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(jobRows(0, exportFetchSize))
+		mock.ExpectQuery(fetch).WillReturnRows(jobRows(exportFetchSize, 2))
+		mock.ExpectRollback()
+
+		var written []string
+		err := s.ExportJobs(context.Background(), JobFilter{}, func(job *model.Job) error {
+			written = append(written, job.JobID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, written, exportFetchSize+2)
+		assert.Equal(t, "job-501", written[len(written)-1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("continues after the cursor", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		sort := JobSort{Field: SortCreatedAt, Ascending: true}
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("WHERE tenant_id = $1 AND deleted_at IS NULL AND job_type = $2 AND (created_at, job_id) > ($3, $4) "+
+			"ORDER BY created_at ASC, job_id ASC")).
+			WithArgs(tenant.DefaultID, "send_email", now, "job-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(jobRows(2, 1))
+		mock.ExpectRollback()
+
+		filter := JobFilter{JobType: "send_email", Sort: sort, Cursor: &JobCursor{Sort: sort, TimeKey: now, JobID: "job-1"}}
+		err := s.ExportJobs(context.Background(), filter, func(*model.Job) error { return nil })
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stops at the first write error", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DECLARE jobs_export")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(jobRows(0, 3))
+		mock.ExpectRollback()
+
+		errClosed := errors.New("connection closed")
+		calls := 0
+		err := s.ExportJobs(context.Background(), JobFilter{}, func(*model.Job) error {
+			calls++
+			return errClosed
+		})
+		assert.ErrorIs(t, err, errClosed)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DECLARE jobs_export")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		err := s.ExportJobs(context.Background(), JobFilter{}, func(*model.Job) error { return nil })
+		assert.ErrorContains(t, err, "failed to export jobs")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
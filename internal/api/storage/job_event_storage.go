@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// dependencyResolverActor is the actor of events caused by the dependency resolver task
+const dependencyResolverActor = "dependency-resolver"
+
+// insertJobEvent records a status transition with db, which should be the transaction
+// that made it
+func insertJobEvent(ctx context.Context, db sqlx.ExecerContext, event *model.JobEvent) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO job_events (job_id, old_status, new_status, actor_type, actor, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, event.JobID, event.OldStatus, event.NewStatus, event.ActorType, event.Actor, event.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to record job event: %w", postgresql.TranslateError(err))
+	}
+	return nil
+}
+
+// ListJobEvents returns every status transition of a job, oldest first
+func (s *Storage) ListJobEvents(ctx context.Context, jobID string) ([]model.JobEvent, error) {
+	var exists bool
+	err := s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (SELECT 1 FROM jobs WHERE job_id = $1 AND tenant_id = $2 AND deleted_at IS NULL)
+	`, jobID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", postgresql.TranslateError(err))
+	}
+	if !exists {
+		return nil, domain.ErrJobNotFound
+	}
+
+	query := `
+		SELECT id, job_id, old_status, new_status, actor_type, actor, reason, created_at
+		FROM job_events
+		WHERE job_id = $1
+		ORDER BY id
+	`
+
+	events := []model.JobEvent{}
+	if err := s.db.SelectContext(ctx, &events, query, jobID); err != nil {
+		return nil, fmt.Errorf("failed to list job events: %w", postgresql.TranslateError(err))
+	}
+
+	return events, nil
+}
+
+// ListJobLogs returns what executors logged while running a job, oldest first
+func (s *Storage) ListJobLogs(ctx context.Context, jobID string) ([]model.JobLog, error) {
+	var exists bool
+	err := s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (SELECT 1 FROM jobs WHERE job_id = $1 AND tenant_id = $2 AND deleted_at IS NULL)
+	`, jobID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", postgresql.TranslateError(err))
+	}
+	if !exists {
+		return nil, domain.ErrJobNotFound
+	}
+
+	query := `
+		SELECT id, job_id, attempt, worker_id, level, message, attrs, logged_at
+		FROM job_logs
+		WHERE job_id = $1
+		ORDER BY id
+	`
+
+	logs := []model.JobLog{}
+	if err := s.db.SelectContext(ctx, &logs, query, jobID); err != nil {
+		return nil, fmt.Errorf("failed to list job logs: %w", postgresql.TranslateError(err))
+	}
+
+	return logs, nil
+}
+
+// PublishJobEvents passes up to limit unpublished events of every tenant to publish,
+// oldest first, and marks the ones it accepted as published. It stops at the first
+// event publish rejects, and returns how many were published. Events locked by another
+// relay are skipped. A batch holds only the oldest unpublished event of each job, so
+// relays running side by side, e.g. on every instance when leader election is disabled,
+// never publish a later event of a job before an earlier one that another relay holds.
+func (s *Storage) PublishJobEvents(ctx context.Context, limit int, publish func(*model.LifecycleEvent) error) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		SELECT e.id, e.job_id, e.old_status, e.new_status, e.actor_type, e.actor, e.reason, e.created_at,
+			j.job_type, j.tenant_id, COALESCE(j.user_id, '') AS user_id, j.metadata
+		FROM job_events e
+		JOIN jobs j ON j.job_id = e.job_id
+		WHERE e.published_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM job_events earlier
+				WHERE earlier.job_id = e.job_id
+					AND earlier.id < e.id
+					AND earlier.published_at IS NULL
+			)
+		ORDER BY e.id
+		LIMIT $1
+		FOR UPDATE OF e SKIP LOCKED
+	`
+
+	var events []model.LifecycleEvent
+	if err := tx.SelectContext(ctx, &events, query, limit); err != nil {
+		return 0, fmt.Errorf("failed to list unpublished job events: %w", postgresql.TranslateError(err))
+	}
+
+	ids := make([]int64, 0, len(events))
+	var publishErr error
+	for i := range events {
+		if publishErr = publish(&events[i]); publishErr != nil {
+			break
+		}
+		ids = append(ids, events[i].ID)
+	}
+
+	if len(ids) > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE job_events SET published_at = NOW() WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return 0, fmt.Errorf("failed to mark job events published: %w", postgresql.TranslateError(err))
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to commit published job events: %w", postgresql.TranslateError(err))
+		}
+	}
+
+	if publishErr != nil {
+		return len(ids), fmt.Errorf("failed to publish job event: %w", publishErr)
+	}
+	return len(ids), nil
+}
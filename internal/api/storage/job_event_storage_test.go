@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ListJobEvents(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+	columns := []string{"id", "job_id", "old_status", "new_status", "actor_type", "actor", "reason", "created_at"}
+
+	t.Run("returns events oldest first", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_events")).
+			WithArgs(jobID).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, jobID, nil, domain.JobStatusPending, domain.ActorUser, "user-1", nil, now).
+				AddRow(2, jobID, domain.JobStatusPending, domain.JobStatusRunning, domain.ActorWorker, "worker-1", nil, now))
+
+		events, err := s.ListJobEvents(context.Background(), jobID)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Nil(t, events[0].OldStatus)
+		assert.Equal(t, domain.JobStatusPending, events[0].NewStatus)
+		assert.Equal(t, domain.JobStatusPending, *events[1].OldStatus)
+		assert.Equal(t, "worker-1", *events[1].Actor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := s.ListJobEvents(context.Background(), jobID)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_ListJobLogs(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+	columns := []string{"id", "job_id", "attempt", "worker_id", "level", "message", "attrs", "logged_at"}
+
+	t.Run("returns logs oldest first", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_logs")).
+			WithArgs(jobID).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, jobID, 0, "worker-1", "INFO", "connecting", nil, now).
+				AddRow(2, jobID, 0, "worker-1", "ERROR", "send failed", `{"host":"smtp.example.com"}`, now))
+
+		logs, err := s.ListJobLogs(context.Background(), jobID)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		assert.Nil(t, logs[0].Attrs)
+		assert.Equal(t, "ERROR", logs[1].Level)
+		assert.JSONEq(t, `{"host":"smtp.example.com"}`, *logs[1].Attrs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := s.ListJobLogs(context.Background(), jobID)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_PublishJobEvents(t *testing.T) {
+	now := time.Now().UTC()
+	columns := []string{"id", "job_id", "old_status", "new_status", "actor_type", "actor", "reason", "created_at", "job_type", "tenant_id", "user_id", "metadata"}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(7, "job-1", nil, domain.JobStatusPending, domain.ActorUser, "user-1", nil, now, "send_email", "acme", "user-1", `{"order_id":"o-1"}`).
+			AddRow(8, "job-2", domain.JobStatusPending, domain.JobStatusRunning, domain.ActorWorker, "worker-1", nil, now, "send_email", "acme", "user-1", nil)
+	}
+
+	t.Run("marks published events", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		// Only the oldest unpublished event of a job is taken
+		mock.ExpectQuery(`WHERE e\.published_at IS NULL\s+AND NOT EXISTS \(\s+SELECT 1 FROM job_events earlier`).
+			WithArgs(100).
+			WillReturnRows(rows())
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE job_events SET published_at = NOW() WHERE id = ANY($1)")).
+			WithArgs(pq.Array([]int64{7, 8})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		var published []string
+		var metadata []*string
+		n, err := s.PublishJobEvents(context.Background(), 100, func(e *model.LifecycleEvent) error {
+			published = append(published, e.JobType+":"+e.NewStatus)
+			metadata = append(metadata, e.Metadata)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{"send_email:PENDING", "send_email:RUNNING"}, published)
+		require.NotNil(t, metadata[0])
+		assert.Equal(t, `{"order_id":"o-1"}`, *metadata[0])
+		assert.Nil(t, metadata[1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keeps the events after a failed publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE e.published_at IS NULL")).
+			WillReturnRows(rows())
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE job_events SET published_at")).
+			WithArgs(pq.Array([]int64{7})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		n, err := s.PublishJobEvents(context.Background(), 100, func(e *model.LifecycleEvent) error {
+			if e.ID == 8 {
+				return assert.AnError
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE e.published_at IS NULL")).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		n, err := s.PublishJobEvents(context.Background(), 100, func(*model.LifecycleEvent) error { return nil })
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/lib/pq"
+)
+
+// outboxJob is a job_outbox row with the job it queues
+type outboxJob struct {
+	OutboxID int64 `db:"outbox_id"`
+	// Publishable is false once the job left PENDING or was deleted before being published
+	Publishable bool `db:"publishable"`
+	model.Job
+}
+
+// QueueJob inserts a new PENDING job together with a job_outbox record, in one
+// transaction, for PublishOutboxJobs to publish later
+func (s *Storage) QueueJob(ctx context.Context, job *model.Job) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertJob(ctx, tx, job); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO job_outbox (job_id, ordering_key) VALUES ($1, $2)
+	`, job.JobID, job.OrderingKey)
+	if err != nil {
+		return fmt.Errorf("failed to queue job: %w", postgresql.TranslateError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job: %w", postgresql.TranslateError(err))
+	}
+
+	return nil
+}
+
+// RequeueJobs adds outbox records for jobs that are already stored, in the order given,
+// so the outbox relay publishes them. Jobs that are no longer PENDING or already have a
+// record are skipped.
+func (s *Storage) RequeueJobs(ctx context.Context, jobIDs []string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO job_outbox (job_id, ordering_key)
+		SELECT j.job_id, j.ordering_key
+		FROM unnest($1::varchar[]) WITH ORDINALITY AS r(job_id, position)
+		JOIN jobs j ON j.job_id = r.job_id
+		WHERE j.status = $2
+			AND NOT EXISTS (SELECT 1 FROM job_outbox o WHERE o.job_id = j.job_id)
+		ORDER BY r.position
+	`, pq.Array(jobIDs), domain.JobStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to requeue jobs: %w", postgresql.TranslateError(err))
+	}
+	return nil
+}
+
+// PublishOutboxJobs passes up to limit jobs of the outbox to publish, oldest first, and
+// deletes the records of the ones it accepted. Records of jobs that are no longer
+// PENDING are deleted without publishing them. It stops at the first job publish
+// rejects, and returns how many were published. Records locked by another relay are
+// skipped, and a batch holds only the oldest record of each ordering key, so keyed jobs
+// are published in submission order even with relays running side by side.
+func (s *Storage) PublishOutboxJobs(ctx context.Context, limit int, publish func(*model.Job) error) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		SELECT o.id AS outbox_id, (j.status = $2 AND j.deleted_at IS NULL) AS publishable,
+			j.job_id, j.idempotency_key, j.user_id, j.job_type,
+			j.payload, j.metadata, j.ordering_key, j.execute_after,
+			j.status, j.retry_count, j.max_retries,
+			j.created_at, j.updated_at, j.version, j.workflow_id, j.step_name, j.tenant_id
+		FROM job_outbox o
+		JOIN jobs j ON j.job_id = o.job_id
+		WHERE o.ordering_key IS NULL
+			OR NOT EXISTS (
+				SELECT 1 FROM job_outbox earlier
+				WHERE earlier.ordering_key = o.ordering_key
+					AND earlier.id < o.id
+			)
+		ORDER BY o.id
+		LIMIT $1
+		FOR UPDATE OF o SKIP LOCKED
+	`
+
+	var jobs []outboxJob
+	if err := tx.SelectContext(ctx, &jobs, query, limit, domain.JobStatusPending); err != nil {
+		return 0, fmt.Errorf("failed to list outbox jobs: %w", postgresql.TranslateError(err))
+	}
+
+	ids := make([]int64, 0, len(jobs))
+	published := 0
+	var publishErr error
+	for i := range jobs {
+		if jobs[i].Publishable {
+			if publishErr = publish(&jobs[i].Job); publishErr != nil {
+				break
+			}
+			published++
+		}
+		ids = append(ids, jobs[i].OutboxID)
+	}
+
+	if len(ids) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM job_outbox WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return 0, fmt.Errorf("failed to delete outbox records: %w", postgresql.TranslateError(err))
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to commit published outbox jobs: %w", postgresql.TranslateError(err))
+		}
+	}
+
+	if publishErr != nil {
+		return published, fmt.Errorf("failed to publish job: %w", publishErr)
+	}
+	return published, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_QueueJob(t *testing.T) {
+	orderingKey := "account-42"
+	job := &model.Job{
+		JobID:          "550e8400-e29b-41d4-a716-446655440000",
+		IdempotencyKey: "key-1",
+		UserID:         "user-1",
+		JobType:        "send_email",
+		Payload:        `{}`,
+		OrderingKey:    &orderingKey,
+		Status:         domain.JobStatusPending,
+	}
+
+	t.Run("inserts the job with its outbox record", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_outbox (job_id, ordering_key)")).
+			WithArgs(job.JobID, job.OrderingKey).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.QueueJob(context.Background(), job))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_jobs_tenant_idempotency_key"})
+		mock.ExpectRollback()
+
+		err := s.QueueJob(context.Background(), job)
+		assert.ErrorIs(t, err, domain.ErrIdempotencyConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_RequeueJobs(t *testing.T) {
+	s, mock := newMockStorage(t)
+	jobIDs := []string{"job-1", "job-2"}
+
+	mock.ExpectExec(`FROM unnest\(\$1::varchar\[\]\) WITH ORDINALITY`).
+		WithArgs(pq.Array(jobIDs), domain.JobStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, s.RequeueJobs(context.Background(), jobIDs))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_PublishOutboxJobs(t *testing.T) {
+	now := time.Now().UTC()
+	columns := []string{"outbox_id", "publishable", "job_id", "idempotency_key", "user_id", "job_type",
+		"payload", "metadata", "ordering_key", "execute_after", "status", "retry_count", "max_retries",
+		"created_at", "updated_at", "version", "workflow_id", "step_name", "tenant_id"}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(3, true, "job-1", "key-1", "user-1", "send_email", `{}`, nil, nil, nil, domain.JobStatusPending, 0, 3, now, now, 1, nil, nil, "acme").
+			AddRow(4, false, "job-2", "key-2", "user-1", "send_email", `{}`, nil, nil, nil, domain.JobStatusCanceled, 0, 3, now, now, 2, nil, nil, "acme").
+			AddRow(5, true, "job-3", "key-3", "user-1", "send_email", `{}`, nil, nil, nil, domain.JobStatusPending, 0, 3, now, now, 1, nil, nil, "acme")
+	}
+
+	t.Run("deletes the records of published and no longer pending jobs", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		// Only the oldest record of an ordering key is taken
+		mock.ExpectQuery(`WHERE o\.ordering_key IS NULL\s+OR NOT EXISTS \(\s+SELECT 1 FROM job_outbox earlier`).
+			WithArgs(100, domain.JobStatusPending).
+			WillReturnRows(rows())
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM job_outbox WHERE id = ANY($1)")).
+			WithArgs(pq.Array([]int64{3, 4, 5})).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		var published []string
+		n, err := s.PublishOutboxJobs(context.Background(), 100, func(job *model.Job) error {
+			published = append(published, job.JobID+"@"+job.TenantID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{"job-1@acme", "job-3@acme"}, published)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keeps the records after a failed publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_outbox o")).
+			WillReturnRows(rows())
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM job_outbox")).
+			WithArgs(pq.Array([]int64{3, 4})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		n, err := s.PublishOutboxJobs(context.Background(), 100, func(job *model.Job) error {
+			if job.JobID == "job-3" {
+				return assert.AnError
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_outbox o")).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		n, err := s.PublishOutboxJobs(context.Background(), 100, func(*model.Job) error { return nil })
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
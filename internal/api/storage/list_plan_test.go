@@ -0,0 +1,153 @@
+//go:build integration
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/test/containers"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	planMigrationsDir = "../../../migrations"
+	// planJobs is large enough that the planner prefers an index over a sequential scan
+	// wherever one applies
+	planJobs = 100000
+)
+
+// queryPlan is a node of EXPLAIN (FORMAT JSON) output
+type queryPlan struct {
+	NodeType     string      `json:"Node Type"`
+	RelationName string      `json:"Relation Name"`
+	IndexName    string      `json:"Index Name"`
+	Plans        []queryPlan `json:"Plans"`
+}
+
+// walk calls fn for the node and every node below it
+func (p queryPlan) walk(fn func(queryPlan)) {
+	fn(p)
+	for _, child := range p.Plans {
+		child.walk(fn)
+	}
+}
+
+// TestListJobs_QueryPlans guards the job listing indexes: each ListJobs filter they serve
+// must be planned as an index scan on a large table, never a sequential scan of jobs.
+func TestListJobs_QueryPlans(t *testing.T) {
+	db := newPlanDatabase(t)
+
+	_, err := db.Exec(`
+		INSERT INTO jobs (job_id, user_id, job_type, status, payload, created_at, updated_at)
+		SELECT md5(i::text)::uuid::text,
+			'user-' || (i % 1000),
+			'type-' || (i % 10),
+			CASE WHEN i % 100 = 0 THEN 'PENDING'
+				ELSE (ARRAY['COMPLETED', 'FAILED', 'RUNNING', 'CANCELED'])[i % 4 + 1] END,
+			'{}',
+			NOW() - i * INTERVAL '1 second',
+			NOW() - i * INTERVAL '1 second'
+		FROM generate_series(1, $1) AS i`, planJobs)
+	require.NoError(t, err)
+	_, err = db.Exec("ANALYZE jobs")
+	require.NoError(t, err)
+
+	cursor := &JobCursor{TimeKey: time.Now().Add(-time.Hour), JobID: "ffffffff-ffff-ffff-ffff-ffffffffffff"}
+	tests := []struct {
+		name    string
+		filter  JobFilter
+		indexes []string // Any of these serves the query
+	}{
+		{
+			name:    "by user",
+			filter:  JobFilter{UserID: "user-7", PageSize: 20},
+			indexes: []string{"idx_jobs_tenant_user_created_at"},
+		},
+		{
+			name:    "by user after a cursor",
+			filter:  JobFilter{UserID: "user-7", PageSize: 20, Cursor: cursor},
+			indexes: []string{"idx_jobs_tenant_user_created_at"},
+		},
+		{
+			name:    "by status",
+			filter:  JobFilter{Statuses: []string{domain.JobStatusFailed}, PageSize: 20},
+			indexes: []string{"idx_jobs_tenant_status_created_at"},
+		},
+		{
+			name:    "by status oldest first after a cursor",
+			filter:  JobFilter{Statuses: []string{domain.JobStatusFailed}, PageSize: 20, Sort: JobSort{Ascending: true}, Cursor: cursor},
+			indexes: []string{"idx_jobs_tenant_status_created_at"},
+		},
+		{
+			name:    "pending",
+			filter:  JobFilter{Statuses: []string{domain.JobStatusPending}, PageSize: 20},
+			indexes: []string{"idx_jobs_pending_created_at", "idx_jobs_tenant_status_created_at"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.filter.listQuery(tenant.DefaultID)
+
+			var output []byte
+			require.NoError(t, db.QueryRow("EXPLAIN (FORMAT JSON) "+db.Rebind(query), args...).Scan(&output))
+			var plans []struct {
+				Plan queryPlan `json:"Plan"`
+			}
+			require.NoError(t, json.Unmarshal(output, &plans))
+			require.Len(t, plans, 1)
+
+			var used []string
+			plans[0].Plan.walk(func(node queryPlan) {
+				assert.False(t, node.NodeType == "Seq Scan" && node.RelationName == "jobs", "sequential scan of jobs:\n%s", output)
+				if node.IndexName != "" {
+					used = append(used, node.IndexName)
+				}
+			})
+			assert.Subset(t, tt.indexes, used, "unexpected indexes in plan:\n%s", output)
+			assert.NotEmpty(t, used, "no index in plan:\n%s", output)
+		})
+	}
+}
+
+// newPlanDatabase starts a PostgreSQL container for the test and migrates its database.
+// The container is removed when the test ends.
+func newPlanDatabase(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	postgres, err := containers.StartPostgres(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := postgres.Terminate(); err != nil {
+			t.Logf("failed to remove PostgreSQL container: %v", err)
+		}
+	})
+
+	db, err := sqlx.Connect("postgres", fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		postgres.Host, postgres.Port, postgres.User, postgres.Password, postgres.Database))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join(planMigrationsDir, "*.up.sql"))
+	require.NoError(t, err)
+	sort.Strings(files)
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		require.NoError(t, err)
+		_, err = db.Exec(string(migration))
+		require.NoError(t, err, "migration %s", filepath.Base(file))
+	}
+
+	return db
+}
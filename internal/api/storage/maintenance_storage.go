@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// GetMaintenance returns the stored maintenance flag
+func (s *Storage) GetMaintenance(ctx context.Context) (*model.Maintenance, error) {
+	query := `SELECT enabled, message, updated_at FROM maintenance`
+
+	var maintenance model.Maintenance
+	if err := s.db.GetContext(ctx, &maintenance, query); err != nil {
+		return nil, fmt.Errorf("failed to get maintenance flag: %w", postgresql.TranslateError(err))
+	}
+	return &maintenance, nil
+}
+
+// SetMaintenance stores the maintenance flag and returns it
+func (s *Storage) SetMaintenance(ctx context.Context, enabled bool, message string) (*model.Maintenance, error) {
+	query := `
+		INSERT INTO maintenance (id, enabled, message, updated_at)
+		VALUES (TRUE, $1, $2, NOW())
+		ON CONFLICT (id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, message = EXCLUDED.message, updated_at = EXCLUDED.updated_at
+		RETURNING enabled, message, updated_at
+	`
+
+	var maintenance model.Maintenance
+	if err := s.db.GetContext(ctx, &maintenance, query, enabled, message); err != nil {
+		return nil, fmt.Errorf("failed to set maintenance flag: %w", postgresql.TranslateError(err))
+	}
+	return &maintenance, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Maintenance(t *testing.T) {
+	now := time.Now().UTC()
+	columns := []string{"enabled", "message", "updated_at"}
+
+	t.Run("get", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT enabled, message, updated_at FROM maintenance")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(true, "Migrating", now))
+
+		maintenance, err := s.GetMaintenance(context.Background())
+		require.NoError(t, err)
+		assert.True(t, maintenance.Enabled)
+		assert.Equal(t, "Migrating", maintenance.Message)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO maintenance")).
+			WithArgs(false, "").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(false, "", now))
+
+		maintenance, err := s.SetMaintenance(context.Background(), false, "")
+		require.NoError(t, err)
+		assert.False(t, maintenance.Enabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("translates database errors", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM maintenance")).WillReturnError(assert.AnError)
+
+		_, err := s.GetMaintenance(context.Background())
+		assert.ErrorContains(t, err, "failed to get maintenance flag")
+	})
+}
@@ -0,0 +1,330 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/storage"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+)
+
+// JobStorage is a configurable in-memory mock of storage.JobStorage.
+// Each method delegates to the matching Func field when set and records its calls.
+type JobStorage struct {
+	CreateJobFunc              func(ctx context.Context, job *model.Job, publish func(*model.Job) error) error
+	QueueJobFunc               func(ctx context.Context, job *model.Job) error
+	RequeueJobsFunc            func(ctx context.Context, jobIDs []string) error
+	GetJobByIDFunc             func(ctx context.Context, jobID string) (*model.Job, error)
+	GetJobByIdempotencyKeyFunc func(ctx context.Context, userID, idempotencyKey string) (*model.Job, error)
+	ListJobsFunc               func(ctx context.Context, filter storage.JobFilter) ([]model.Job, error)
+	CountJobsFunc              func(ctx context.Context, filter storage.JobFilter) (int64, error)
+	ExportJobsFunc             func(ctx context.Context, filter storage.JobFilter, write func(*model.Job) error) error
+	RetryJobFunc               func(ctx context.Context, jobID string, resetRetryCount bool, publish func(*model.Job) error) (*model.Job, error)
+	CancelJobFunc              func(ctx context.Context, jobID string, version int) (*model.Job, error)
+	GetJobTypeStatsFunc        func(ctx context.Context, jobType string, since time.Time) (*model.JobTypeStats, error)
+	CountJobsByStatusFunc      func(ctx context.Context, status string) (int64, error)
+	PromoteWaitingJobFunc      func(ctx context.Context, publish func(*model.Job) error) (*model.Job, error)
+	CancelBlockedJobsFunc      func(ctx context.Context) (int64, error)
+	CreateWorkflowFunc         func(ctx context.Context, workflow *model.Workflow, steps []model.Job) error
+	GetWorkflowFunc            func(ctx context.Context, workflowID string) (*model.Workflow, []model.Job, error)
+	CancelWorkflowFunc         func(ctx context.Context, workflowID string) (int64, error)
+	ListWorkersFunc            func(ctx context.Context) ([]model.Worker, error)
+	ListJobEventsFunc          func(ctx context.Context, jobID string) ([]model.JobEvent, error)
+	ListJobLogsFunc            func(ctx context.Context, jobID string) ([]model.JobLog, error)
+	PublishJobEventsFunc       func(ctx context.Context, limit int, publish func(*model.LifecycleEvent) error) (int, error)
+	PublishOutboxJobsFunc      func(ctx context.Context, limit int, publish func(*model.Job) error) (int, error)
+	DeleteJobFunc              func(ctx context.Context, jobID string) (*model.Job, error)
+	PurgeJobsFunc              func(ctx context.Context, cutoff time.Time, limit int, archive bool) (*model.PurgeResult, error)
+	GetArchivedJobFunc         func(ctx context.Context, jobID string) (*model.ArchivedJob, error)
+	GetTenantUsageFunc         func(ctx context.Context, userID string, since time.Time) (*model.TenantUsage, error)
+	GetQuotasFunc              func(ctx context.Context, userID string) ([]model.Quota, error)
+	ListQuotasFunc             func(ctx context.Context, tenantID string) ([]model.Quota, error)
+	UpsertQuotaFunc            func(ctx context.Context, quota *model.Quota) error
+	DeleteQuotaFunc            func(ctx context.Context, tenantID, userID string) error
+	GetMaintenanceFunc         func(ctx context.Context) (*model.Maintenance, error)
+	SetMaintenanceFunc         func(ctx context.Context, enabled bool, message string) (*model.Maintenance, error)
+
+	CreatedJobs  []*model.Job
+	QueuedJobs   []*model.Job
+	ListFilters  []storage.JobFilter
+	GetJobIDs    []string
+	RetryJobIDs  []string
+	DeleteJobIDs []string
+
+	CreatedWorkflows []*model.Workflow
+	CreatedSteps     [][]model.Job
+
+	UpsertedQuotas []*model.Quota
+}
+
+var _ storage.JobStorage = (*JobStorage)(nil)
+
+// CreateJob records the job for the tenant of ctx, like Storage, and calls CreateJobFunc if set
+func (m *JobStorage) CreateJob(ctx context.Context, job *model.Job, publish func(*model.Job) error) error {
+	job.TenantID = tenant.ID(ctx)
+	m.CreatedJobs = append(m.CreatedJobs, job)
+	if m.CreateJobFunc != nil {
+		return m.CreateJobFunc(ctx, job, publish)
+	}
+	return nil
+}
+
+// QueueJob records the job for the tenant of ctx, like Storage, and calls QueueJobFunc if set
+func (m *JobStorage) QueueJob(ctx context.Context, job *model.Job) error {
+	job.TenantID = tenant.ID(ctx)
+	m.QueuedJobs = append(m.QueuedJobs, job)
+	if m.QueueJobFunc != nil {
+		return m.QueueJobFunc(ctx, job)
+	}
+	return nil
+}
+
+// RequeueJobs calls RequeueJobsFunc if set
+func (m *JobStorage) RequeueJobs(ctx context.Context, jobIDs []string) error {
+	if m.RequeueJobsFunc != nil {
+		return m.RequeueJobsFunc(ctx, jobIDs)
+	}
+	return nil
+}
+
+// GetJobByID records the lookup and calls GetJobByIDFunc if set
+func (m *JobStorage) GetJobByID(ctx context.Context, jobID string) (*model.Job, error) {
+	m.GetJobIDs = append(m.GetJobIDs, jobID)
+	if m.GetJobByIDFunc != nil {
+		return m.GetJobByIDFunc(ctx, jobID)
+	}
+	return nil, nil
+}
+
+// GetJobByIdempotencyKey calls GetJobByIdempotencyKeyFunc if set
+func (m *JobStorage) GetJobByIdempotencyKey(ctx context.Context, userID, idempotencyKey string) (*model.Job, error) {
+	if m.GetJobByIdempotencyKeyFunc != nil {
+		return m.GetJobByIdempotencyKeyFunc(ctx, userID, idempotencyKey)
+	}
+	return nil, nil
+}
+
+// ListJobs records the filter and calls ListJobsFunc if set
+func (m *JobStorage) ListJobs(ctx context.Context, filter storage.JobFilter) ([]model.Job, error) {
+	m.ListFilters = append(m.ListFilters, filter)
+	if m.ListJobsFunc != nil {
+		return m.ListJobsFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+// CountJobs calls CountJobsFunc if set, otherwise returns 0
+func (m *JobStorage) CountJobs(ctx context.Context, filter storage.JobFilter) (int64, error) {
+	if m.CountJobsFunc != nil {
+		return m.CountJobsFunc(ctx, filter)
+	}
+	return 0, nil
+}
+
+// ExportJobs records the filter and calls ExportJobsFunc if set
+func (m *JobStorage) ExportJobs(ctx context.Context, filter storage.JobFilter, write func(*model.Job) error) error {
+	m.ListFilters = append(m.ListFilters, filter)
+	if m.ExportJobsFunc != nil {
+		return m.ExportJobsFunc(ctx, filter, write)
+	}
+	return nil
+}
+
+// RetryJob records the job ID and calls RetryJobFunc if set
+func (m *JobStorage) RetryJob(ctx context.Context, jobID string, resetRetryCount bool, publish func(*model.Job) error) (*model.Job, error) {
+	m.RetryJobIDs = append(m.RetryJobIDs, jobID)
+	if m.RetryJobFunc != nil {
+		return m.RetryJobFunc(ctx, jobID, resetRetryCount, publish)
+	}
+	return nil, nil
+}
+
+// CancelJob calls CancelJobFunc if set
+func (m *JobStorage) CancelJob(ctx context.Context, jobID string, version int) (*model.Job, error) {
+	if m.CancelJobFunc != nil {
+		return m.CancelJobFunc(ctx, jobID, version)
+	}
+	return nil, nil
+}
+
+// GetJobTypeStats calls GetJobTypeStatsFunc if set, otherwise returns empty stats
+func (m *JobStorage) GetJobTypeStats(ctx context.Context, jobType string, since time.Time) (*model.JobTypeStats, error) {
+	if m.GetJobTypeStatsFunc != nil {
+		return m.GetJobTypeStatsFunc(ctx, jobType, since)
+	}
+	return &model.JobTypeStats{}, nil
+}
+
+// CountJobsByStatus calls CountJobsByStatusFunc if set, otherwise returns 0
+func (m *JobStorage) CountJobsByStatus(ctx context.Context, status string) (int64, error) {
+	if m.CountJobsByStatusFunc != nil {
+		return m.CountJobsByStatusFunc(ctx, status)
+	}
+	return 0, nil
+}
+
+// PromoteWaitingJob calls PromoteWaitingJobFunc if set, otherwise reports no ready job
+func (m *JobStorage) PromoteWaitingJob(ctx context.Context, publish func(*model.Job) error) (*model.Job, error) {
+	if m.PromoteWaitingJobFunc != nil {
+		return m.PromoteWaitingJobFunc(ctx, publish)
+	}
+	return nil, nil
+}
+
+// CancelBlockedJobs calls CancelBlockedJobsFunc if set, otherwise returns 0
+func (m *JobStorage) CancelBlockedJobs(ctx context.Context) (int64, error) {
+	if m.CancelBlockedJobsFunc != nil {
+		return m.CancelBlockedJobsFunc(ctx)
+	}
+	return 0, nil
+}
+
+// CreateWorkflow records the workflow and its steps and calls CreateWorkflowFunc if set
+func (m *JobStorage) CreateWorkflow(ctx context.Context, workflow *model.Workflow, steps []model.Job) error {
+	m.CreatedWorkflows = append(m.CreatedWorkflows, workflow)
+	m.CreatedSteps = append(m.CreatedSteps, steps)
+	if m.CreateWorkflowFunc != nil {
+		return m.CreateWorkflowFunc(ctx, workflow, steps)
+	}
+	return nil
+}
+
+// GetWorkflow calls GetWorkflowFunc if set, otherwise reports the workflow as missing
+func (m *JobStorage) GetWorkflow(ctx context.Context, workflowID string) (*model.Workflow, []model.Job, error) {
+	if m.GetWorkflowFunc != nil {
+		return m.GetWorkflowFunc(ctx, workflowID)
+	}
+	return nil, nil, domain.ErrWorkflowNotFound
+}
+
+// CancelWorkflow calls CancelWorkflowFunc if set, otherwise returns 0
+func (m *JobStorage) CancelWorkflow(ctx context.Context, workflowID string) (int64, error) {
+	if m.CancelWorkflowFunc != nil {
+		return m.CancelWorkflowFunc(ctx, workflowID)
+	}
+	return 0, nil
+}
+
+// ListWorkers calls ListWorkersFunc if set, otherwise returns no workers
+func (m *JobStorage) ListWorkers(ctx context.Context) ([]model.Worker, error) {
+	if m.ListWorkersFunc != nil {
+		return m.ListWorkersFunc(ctx)
+	}
+	return []model.Worker{}, nil
+}
+
+// ListJobLogs calls ListJobLogsFunc if set
+func (m *JobStorage) ListJobLogs(ctx context.Context, jobID string) ([]model.JobLog, error) {
+	if m.ListJobLogsFunc != nil {
+		return m.ListJobLogsFunc(ctx, jobID)
+	}
+	return nil, nil
+}
+
+// ListJobEvents calls ListJobEventsFunc if set, otherwise returns no events
+func (m *JobStorage) ListJobEvents(ctx context.Context, jobID string) ([]model.JobEvent, error) {
+	if m.ListJobEventsFunc != nil {
+		return m.ListJobEventsFunc(ctx, jobID)
+	}
+	return []model.JobEvent{}, nil
+}
+
+// PublishJobEvents calls PublishJobEventsFunc if set, otherwise publishes nothing
+func (m *JobStorage) PublishJobEvents(ctx context.Context, limit int, publish func(*model.LifecycleEvent) error) (int, error) {
+	if m.PublishJobEventsFunc != nil {
+		return m.PublishJobEventsFunc(ctx, limit, publish)
+	}
+	return 0, nil
+}
+
+// PublishOutboxJobs calls PublishOutboxJobsFunc if set, otherwise publishes nothing
+func (m *JobStorage) PublishOutboxJobs(ctx context.Context, limit int, publish func(*model.Job) error) (int, error) {
+	if m.PublishOutboxJobsFunc != nil {
+		return m.PublishOutboxJobsFunc(ctx, limit, publish)
+	}
+	return 0, nil
+}
+
+// DeleteJob records the job ID and calls DeleteJobFunc if set
+func (m *JobStorage) DeleteJob(ctx context.Context, jobID string) (*model.Job, error) {
+	m.DeleteJobIDs = append(m.DeleteJobIDs, jobID)
+	if m.DeleteJobFunc != nil {
+		return m.DeleteJobFunc(ctx, jobID)
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+// PurgeJobs calls PurgeJobsFunc if set, otherwise purges nothing
+func (m *JobStorage) PurgeJobs(ctx context.Context, cutoff time.Time, limit int, archive bool) (*model.PurgeResult, error) {
+	if m.PurgeJobsFunc != nil {
+		return m.PurgeJobsFunc(ctx, cutoff, limit, archive)
+	}
+	return &model.PurgeResult{}, nil
+}
+
+// GetArchivedJob calls GetArchivedJobFunc if set, otherwise reports the job as not archived
+func (m *JobStorage) GetArchivedJob(ctx context.Context, jobID string) (*model.ArchivedJob, error) {
+	if m.GetArchivedJobFunc != nil {
+		return m.GetArchivedJobFunc(ctx, jobID)
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+// GetTenantUsage calls GetTenantUsageFunc if set, otherwise reports no usage
+func (m *JobStorage) GetTenantUsage(ctx context.Context, userID string, since time.Time) (*model.TenantUsage, error) {
+	if m.GetTenantUsageFunc != nil {
+		return m.GetTenantUsageFunc(ctx, userID, since)
+	}
+	return &model.TenantUsage{}, nil
+}
+
+// GetQuotas calls GetQuotasFunc if set, otherwise returns no quotas
+func (m *JobStorage) GetQuotas(ctx context.Context, userID string) ([]model.Quota, error) {
+	if m.GetQuotasFunc != nil {
+		return m.GetQuotasFunc(ctx, userID)
+	}
+	return []model.Quota{}, nil
+}
+
+// ListQuotas calls ListQuotasFunc if set, otherwise returns no quotas
+func (m *JobStorage) ListQuotas(ctx context.Context, tenantID string) ([]model.Quota, error) {
+	if m.ListQuotasFunc != nil {
+		return m.ListQuotasFunc(ctx, tenantID)
+	}
+	return []model.Quota{}, nil
+}
+
+// UpsertQuota records the quota and calls UpsertQuotaFunc if set
+func (m *JobStorage) UpsertQuota(ctx context.Context, quota *model.Quota) error {
+	m.UpsertedQuotas = append(m.UpsertedQuotas, quota)
+	if m.UpsertQuotaFunc != nil {
+		return m.UpsertQuotaFunc(ctx, quota)
+	}
+	return nil
+}
+
+// DeleteQuota calls DeleteQuotaFunc if set, otherwise reports the quota as missing
+func (m *JobStorage) DeleteQuota(ctx context.Context, tenantID, userID string) error {
+	if m.DeleteQuotaFunc != nil {
+		return m.DeleteQuotaFunc(ctx, tenantID, userID)
+	}
+	return domain.ErrQuotaNotFound
+}
+
+// GetMaintenance calls GetMaintenanceFunc if set, otherwise reports maintenance as off
+func (m *JobStorage) GetMaintenance(ctx context.Context) (*model.Maintenance, error) {
+	if m.GetMaintenanceFunc != nil {
+		return m.GetMaintenanceFunc(ctx)
+	}
+	return &model.Maintenance{}, nil
+}
+
+// SetMaintenance calls SetMaintenanceFunc if set, otherwise returns the flag as stored
+func (m *JobStorage) SetMaintenance(ctx context.Context, enabled bool, message string) (*model.Maintenance, error) {
+	if m.SetMaintenanceFunc != nil {
+		return m.SetMaintenanceFunc(ctx, enabled, message)
+	}
+	return &model.Maintenance{Enabled: enabled, Message: message, UpdatedAt: time.Now()}, nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+const quotaColumns = `tenant_id, user_id, max_jobs_per_day, max_running_jobs, max_payload_bytes, created_at, updated_at`
+
+// GetQuotas returns the stored quotas that apply to jobs of userID: the quota of
+// tenant.ID(ctx), then the quota of the user within it, when they exist
+func (s *Storage) GetQuotas(ctx context.Context, userID string) ([]model.Quota, error) {
+	query := `SELECT ` + quotaColumns + ` FROM quotas
+		WHERE tenant_id = $1 AND user_id IN ('', $2)
+		ORDER BY user_id`
+
+	quotas := []model.Quota{}
+	if err := s.db.SelectContext(ctx, &quotas, query, tenant.ID(ctx), userID); err != nil {
+		return nil, fmt.Errorf("failed to get quotas: %w", postgresql.TranslateError(err))
+	}
+
+	return quotas, nil
+}
+
+// ListQuotas returns the stored quotas of tenantID, or of every tenant when it is empty,
+// each tenant's own quota before those of its users
+func (s *Storage) ListQuotas(ctx context.Context, tenantID string) ([]model.Quota, error) {
+	query := `SELECT ` + quotaColumns + ` FROM quotas
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY tenant_id, user_id`
+
+	quotas := []model.Quota{}
+	if err := s.db.SelectContext(ctx, &quotas, query, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to list quotas: %w", postgresql.TranslateError(err))
+	}
+
+	return quotas, nil
+}
+
+// UpsertQuota stores quota, replacing the limits of an existing quota for the same
+// tenant and user, and fills in its timestamps
+func (s *Storage) UpsertQuota(ctx context.Context, quota *model.Quota) error {
+	query := `
+		INSERT INTO quotas (tenant_id, user_id, max_jobs_per_day, max_running_jobs, max_payload_bytes)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET
+			max_jobs_per_day = EXCLUDED.max_jobs_per_day,
+			max_running_jobs = EXCLUDED.max_running_jobs,
+			max_payload_bytes = EXCLUDED.max_payload_bytes,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := s.db.QueryRowxContext(ctx, query,
+		quota.TenantID,
+		quota.UserID,
+		quota.MaxJobsPerDay,
+		quota.MaxRunningJobs,
+		quota.MaxPayloadBytes,
+	).Scan(&quota.CreatedAt, &quota.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert quota: %w", postgresql.TranslateError(err))
+	}
+
+	return nil
+}
+
+// DeleteQuota removes the quota of tenantID, or of userID within it when set.
+// domain.ErrQuotaNotFound is returned if there is none.
+func (s *Storage) DeleteQuota(ctx context.Context, tenantID, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM quotas WHERE tenant_id = $1 AND user_id = $2`, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete quota: %w", postgresql.TranslateError(err))
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete quota: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrQuotaNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var quotaRowColumns = []string{"tenant_id", "user_id", "max_jobs_per_day", "max_running_jobs", "max_payload_bytes", "created_at", "updated_at"}
+
+func TestStorage_GetQuotas(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("returns the tenant and user quotas", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE tenant_id = $1 AND user_id IN ('', $2)")).
+			WithArgs("acme", "user-1").
+			WillReturnRows(sqlmock.NewRows(quotaRowColumns).
+				AddRow("acme", "", 1000, 10, 0, now, now).
+				AddRow("acme", "user-1", 50, 0, 4096, now, now))
+
+		quotas, err := s.GetQuotas(tenant.WithID(context.Background(), "acme"), "user-1")
+		require.NoError(t, err)
+		require.Len(t, quotas, 2)
+		assert.Equal(t, int64(1000), quotas[0].MaxJobsPerDay)
+		assert.Equal(t, "user-1", quotas[1].UserID)
+		assert.Equal(t, int64(4096), quotas[1].MaxPayloadBytes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("translates database errors", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM quotas")).
+			WillReturnError(assert.AnError)
+
+		_, err := s.GetQuotas(context.Background(), "user-1")
+		assert.ErrorContains(t, err, "failed to get quotas")
+	})
+}
+
+func TestStorage_ListQuotas(t *testing.T) {
+	s, mock := newMockStorage(t)
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE $1 = '' OR tenant_id = $1")).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows(quotaRowColumns).
+			AddRow("acme", "", 1000, 10, 0, now, now).
+			AddRow("globex", "", 0, 5, 0, now, now))
+
+	quotas, err := s.ListQuotas(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, quotas, 2)
+	assert.Equal(t, "globex", quotas[1].TenantID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_UpsertQuota(t *testing.T) {
+	s, mock := newMockStorage(t)
+	created := time.Now().UTC().Add(-time.Hour)
+	updated := time.Now().UTC()
+
+	mock.ExpectQuery(regexp.QuoteMeta("ON CONFLICT (tenant_id, user_id) DO UPDATE SET")).
+		WithArgs("acme", "user-1", int64(50), int64(2), int64(4096)).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, updated))
+
+	quota := &model.Quota{TenantID: "acme", UserID: "user-1", MaxJobsPerDay: 50, MaxRunningJobs: 2, MaxPayloadBytes: 4096}
+	require.NoError(t, s.UpsertQuota(context.Background(), quota))
+	assert.Equal(t, created, quota.CreatedAt)
+	assert.Equal(t, updated, quota.UpdatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_DeleteQuota(t *testing.T) {
+	t.Run("deletes the quota", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM quotas WHERE tenant_id = $1 AND user_id = $2")).
+			WithArgs("acme", "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, s.DeleteQuota(context.Background(), "acme", ""))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing quota", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM quotas")).
+			WithArgs("acme", "user-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, s.DeleteQuota(context.Background(), "acme", "user-1"), domain.ErrQuotaNotFound)
+	})
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// archivedJobColumns are the columns of jobs copied to jobs_archive. A column added to
+// jobs must be added to jobs_archive and here to be archived.
+const archivedJobColumns = `id, job_id, idempotency_key, user_id, job_type, status, priority,
+	payload, result, error_message, worker_id, retry_count, max_retries, timeout_seconds,
+	progress, created_at, updated_at, started_at, completed_at, last_heartbeat_at,
+	callback_url, metadata, result_ref, ordering_key, workflow_id, step_name, deleted_at,
+	tenant_id, version, execute_after`
+
+// DeleteJob soft-deletes a COMPLETED, FAILED or CANCELED job, hiding it from every read
+// until the retention cleaner purges it. If the job exists but is still active, the
+// current job is returned together with domain.ErrJobNotDeletable.
+func (s *Storage) DeleteJob(ctx context.Context, jobID string) (*model.Job, error) {
+	// updated_at is left alone so deleting does not postpone the purge
+	query := `
+		UPDATE jobs
+		SET deleted_at = NOW(),
+			version = version + 1
+		WHERE job_id = $1 AND tenant_id = $5 AND status IN ($2, $3, $4) AND deleted_at IS NULL
+		RETURNING
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version
+	`
+
+	var job model.Job
+	err := s.db.GetContext(ctx, &job, query,
+		jobID,
+		domain.JobStatusCompleted,
+		domain.JobStatusFailed,
+		domain.JobStatusCanceled,
+		tenant.ID(ctx),
+	)
+	if err == nil {
+		return &job, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to delete job: %w", postgresql.TranslateError(err))
+	}
+
+	// Nothing updated: either the job does not exist or it is still active
+	current, err := s.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return current, domain.ErrJobNotDeletable
+}
+
+// PurgeJobs permanently removes up to limit COMPLETED, FAILED or CANCELED jobs of every
+// tenant last updated before cutoff, soft-deleted or not, together with their events.
+// With archive, the jobs and events are copied to jobs_archive and job_events_archive by
+// the same statement. Jobs that an unfinished job depends on are kept until it finishes,
+// and jobs locked by another transaction are skipped. Unless the jobs were archived,
+// their offloaded results are returned for the caller to delete.
+func (s *Storage) PurgeJobs(ctx context.Context, cutoff time.Time, limit int, archive bool) (*model.PurgeResult, error) {
+	// Dependency rows of the purged jobs go with them through ON DELETE CASCADE. Every
+	// part of the statement sees the rows as they were before it, so the archive copies
+	// read the events and dependencies being deleted.
+	query := `
+		WITH purged AS (
+			DELETE FROM jobs
+			WHERE job_id IN (
+				SELECT j.job_id
+				FROM jobs j
+				WHERE j.status IN ($2, $3, $4)
+					AND j.updated_at < $1
+					AND NOT EXISTS (
+						SELECT 1
+						FROM job_dependencies d
+						JOIN jobs c ON c.job_id = d.job_id
+						WHERE d.depends_on_job_id = j.job_id AND c.status NOT IN ($2, $3, $4)
+					)
+				ORDER BY j.updated_at
+				LIMIT $5
+				FOR UPDATE OF j SKIP LOCKED
+			)
+			RETURNING *
+		), events AS (
+			DELETE FROM job_events e
+			USING purged
+			WHERE e.job_id = purged.job_id
+			RETURNING e.*
+		), archived AS (
+			INSERT INTO jobs_archive (` + archivedJobColumns + `, depends_on, archived_at)
+			SELECT ` + archivedJobColumns + `,
+				ARRAY(
+					SELECT depends_on_job_id FROM job_dependencies d
+					WHERE d.job_id = purged.job_id
+					ORDER BY depends_on_job_id
+				),
+				NOW()
+			FROM purged
+			WHERE $6
+			RETURNING job_id
+		), archived_events AS (
+			INSERT INTO job_events_archive
+			SELECT * FROM events
+			WHERE $6
+		)
+		SELECT
+			(SELECT COUNT(*) FROM purged) AS jobs,
+			(SELECT COUNT(*) FROM events) AS events,
+			(SELECT COUNT(*) FROM archived) AS archived,
+			ARRAY(SELECT result_ref FROM purged WHERE result_ref IS NOT NULL AND NOT $6) AS result_refs
+	`
+
+	var result model.PurgeResult
+	err := s.db.GetContext(ctx, &result, query,
+		cutoff,
+		domain.JobStatusCompleted,
+		domain.JobStatusFailed,
+		domain.JobStatusCanceled,
+		limit,
+		archive,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge jobs: %w", postgresql.TranslateError(err))
+	}
+
+	return &result, nil
+}
+
+// GetArchivedJob returns a job of the tenant of ctx copied to jobs_archive when it was
+// purged, with its events oldest first
+func (s *Storage) GetArchivedJob(ctx context.Context, jobID string) (*model.ArchivedJob, error) {
+	var job model.ArchivedJob
+	err := s.db.GetContext(ctx, &job, `
+		SELECT
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id,
+			depends_on, archived_at
+		FROM jobs_archive
+		WHERE job_id = $1 AND tenant_id = $2
+	`, jobID, tenant.ID(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get archived job: %w", postgresql.TranslateError(err))
+	}
+
+	job.Events = []model.JobEvent{}
+	err = s.db.SelectContext(ctx, &job.Events, `
+		SELECT id, job_id, old_status, new_status, actor_type, actor, reason, created_at
+		FROM job_events_archive
+		WHERE job_id = $1
+		ORDER BY id
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived job events: %w", postgresql.TranslateError(err))
+	}
+
+	return &job, nil
+}
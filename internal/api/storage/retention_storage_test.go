@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_DeleteJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	t.Run("soft-deletes terminal job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SET deleted_at = NOW()")).
+			WithArgs(jobID, domain.JobStatusCompleted, domain.JobStatusFailed, domain.JobStatusCanceled, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCompleted, nil, 0, 3, now, now, nil))
+
+		job, err := s.DeleteJob(context.Background(), jobID)
+		require.NoError(t, err)
+		assert.Equal(t, jobID, job.JobID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns current job when still active", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SET deleted_at = NOW()")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE job_id = $1 AND tenant_id = $2 AND deleted_at IS NULL")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(append(jobColumns, "depends_on")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusRunning, nil, 0, 3, now, now, nil, "{}"))
+
+		job, err := s.DeleteJob(context.Background(), jobID)
+		assert.ErrorIs(t, err, domain.ErrJobNotDeletable)
+		require.NotNil(t, job)
+		assert.Equal(t, domain.JobStatusRunning, job.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown or already deleted job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SET deleted_at = NOW()")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WillReturnError(sql.ErrNoRows)
+
+		job, err := s.DeleteJob(context.Background(), jobID)
+		assert.Nil(t, job)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_PurgeJobs(t *testing.T) {
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"jobs", "events", "archived", "result_refs"}
+
+	t.Run("purges without archiving", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM jobs")).
+			WithArgs(cutoff, domain.JobStatusCompleted, domain.JobStatusFailed, domain.JobStatusCanceled, 500, false).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(3, 9, 0, "{s3://results/jobs/job-1.json}"))
+
+		result, err := s.PurgeJobs(context.Background(), cutoff, 500, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.Jobs)
+		assert.Equal(t, int64(9), result.Events)
+		assert.Equal(t, pq.StringArray{"s3://results/jobs/job-1.json"}, result.ResultRefs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("archives in the same statement", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO jobs_archive")).
+			WithArgs(cutoff, domain.JobStatusCompleted, domain.JobStatusFailed, domain.JobStatusCanceled, 500, true).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 9, 3, "{}"))
+
+		result, err := s.PurgeJobs(context.Background(), cutoff, 500, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.Archived)
+		assert.Empty(t, result.ResultRefs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_GetArchivedJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	t.Run("returns job and events", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs_archive")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(append(jobColumns, "workflow_id", "step_name", "depends_on", "archived_at")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCompleted, nil, 0, 3, now, now, nil, nil, nil, "{}", now))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_events_archive")).
+			WithArgs(jobID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "old_status", "new_status", "actor_type", "actor", "reason", "created_at"}).
+				AddRow(1, jobID, nil, domain.JobStatusPending, domain.ActorUser, "user-1", nil, now))
+
+		job, err := s.GetArchivedJob(context.Background(), jobID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobStatusCompleted, job.Status)
+		assert.Equal(t, now, job.ArchivedAt)
+		require.Len(t, job.Events, 1)
+		assert.Equal(t, domain.JobStatusPending, job.Events[0].NewStatus)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not archived", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs_archive")).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := s.GetArchivedJob(context.Background(), jobID)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
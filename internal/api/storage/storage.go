@@ -5,37 +5,180 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cuongbtq/practice-be/internal/api/domain"
 	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/jobstatus"
 	"github.com/cuongbtq/practice-be/shared/postgresql"
 	"github.com/jmoiron/sqlx"
 )
 
+// JobStorage defines the job persistence operations used by the API handlers.
+// Methods serving client requests only see the jobs and workflows of tenant.ID(ctx);
+// the ones used by background tasks and admin routes act on every tenant.
+type JobStorage interface {
+	CreateJob(ctx context.Context, job *model.Job, publish func(*model.Job) error) error
+	QueueJob(ctx context.Context, job *model.Job) error
+	RequeueJobs(ctx context.Context, jobIDs []string) error
+	GetJobByID(ctx context.Context, jobID string) (*model.Job, error)
+	GetJobByIdempotencyKey(ctx context.Context, userID, idempotencyKey string) (*model.Job, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]model.Job, error)
+	CountJobs(ctx context.Context, filter JobFilter) (int64, error)
+	ExportJobs(ctx context.Context, filter JobFilter, write func(*model.Job) error) error
+	RetryJob(ctx context.Context, jobID string, resetRetryCount bool, publish func(*model.Job) error) (*model.Job, error)
+	CancelJob(ctx context.Context, jobID string, version int) (*model.Job, error)
+	GetJobTypeStats(ctx context.Context, jobType string, since time.Time) (*model.JobTypeStats, error)
+	CountJobsByStatus(ctx context.Context, status string) (int64, error)
+	PromoteWaitingJob(ctx context.Context, publish func(*model.Job) error) (*model.Job, error)
+	CancelBlockedJobs(ctx context.Context) (int64, error)
+	CreateWorkflow(ctx context.Context, workflow *model.Workflow, steps []model.Job) error
+	GetWorkflow(ctx context.Context, workflowID string) (*model.Workflow, []model.Job, error)
+	CancelWorkflow(ctx context.Context, workflowID string) (int64, error)
+	ListWorkers(ctx context.Context) ([]model.Worker, error)
+	ListJobEvents(ctx context.Context, jobID string) ([]model.JobEvent, error)
+	ListJobLogs(ctx context.Context, jobID string) ([]model.JobLog, error)
+	PublishJobEvents(ctx context.Context, limit int, publish func(*model.LifecycleEvent) error) (int, error)
+	PublishOutboxJobs(ctx context.Context, limit int, publish func(*model.Job) error) (int, error)
+	DeleteJob(ctx context.Context, jobID string) (*model.Job, error)
+	PurgeJobs(ctx context.Context, cutoff time.Time, limit int, archive bool) (*model.PurgeResult, error)
+	GetArchivedJob(ctx context.Context, jobID string) (*model.ArchivedJob, error)
+	GetTenantUsage(ctx context.Context, userID string, since time.Time) (*model.TenantUsage, error)
+	GetQuotas(ctx context.Context, userID string) ([]model.Quota, error)
+	ListQuotas(ctx context.Context, tenantID string) ([]model.Quota, error)
+	UpsertQuota(ctx context.Context, quota *model.Quota) error
+	DeleteQuota(ctx context.Context, tenantID, userID string) error
+	GetMaintenance(ctx context.Context) (*model.Maintenance, error)
+	SetMaintenance(ctx context.Context, enabled bool, message string) (*model.Maintenance, error)
+}
+
+// Storage is the PostgreSQL implementation of JobStorage
 type Storage struct {
 	db *sqlx.DB
 }
 
+var _ JobStorage = (*Storage)(nil)
+
 func NewStorage(pg *postgresql.Client) *Storage {
+	return NewStorageWithDB(pg.GetDB())
+}
+
+// NewStorageWithDB creates a Storage on top of an existing sqlx.DB
+func NewStorageWithDB(db *sqlx.DB) *Storage {
 	return &Storage{
-		db: pg.GetDB(),
+		db: db,
 	}
 }
 
-// CreateJob inserts a new job record into the database
-func (s *Storage) CreateJob(ctx context.Context, job *model.Job) error {
+// CreateJob inserts a new job record into the database. A job with DependsOn is stored
+// together with its dependencies; domain.ErrDependencyNotFound is returned if any of them
+// does not exist. A non-nil publish is called before the job is committed, and the job is
+// not created if it fails.
+func (s *Storage) CreateJob(ctx context.Context, job *model.Job, publish func(*model.Job) error) error {
+	if len(job.DependsOn) == 0 && publish == nil {
+		return insertJob(ctx, s.db, job)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertJob(ctx, tx, job); err != nil {
+		return err
+	}
+
+	if len(job.DependsOn) > 0 {
+		if err := insertDependencies(ctx, tx, job); err != nil {
+			return err
+		}
+	}
+
+	if publish != nil {
+		if err := publish(job); err != nil {
+			return fmt.Errorf("failed to publish job: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit job: %w", postgresql.TranslateError(err))
+	}
+
+	return nil
+}
+
+// insertDependencies stores the dependencies of job with tx after checking that all of
+// them exist
+func insertDependencies(ctx context.Context, tx *sqlx.Tx, job *model.Job) error {
+	// Parents must exist before the job is created, so no dependency can point back at it.
+	// Jobs of other tenants count as missing.
+	var found []string
+	err := tx.SelectContext(ctx, &found, `
+		SELECT job_id FROM jobs WHERE job_id = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL
+	`, job.DependsOn, job.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to check dependencies: %w", postgresql.TranslateError(err))
+	}
+	if missing := missingIDs(job.DependsOn, found); len(missing) > 0 {
+		return fmt.Errorf("%w: %s", domain.ErrDependencyNotFound, strings.Join(missing, ", "))
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO job_dependencies (job_id, depends_on_job_id)
+		SELECT $1, unnest($2::varchar[])
+	`, job.JobID, job.DependsOn)
+	if err != nil {
+		return fmt.Errorf("failed to create job dependencies: %w", postgresql.TranslateError(err))
+	}
+
+	return nil
+}
+
+// missingIDs returns the IDs in want that are not in found
+func missingIDs(want, found []string) []string {
+	seen := make(map[string]bool, len(found))
+	for _, id := range found {
+		seen[id] = true
+	}
+
+	var missing []string
+	for _, id := range want {
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// insertJob inserts the job row with db, which may be a transaction. The job belongs to
+// the tenant of ctx.
+func insertJob(ctx context.Context, db sqlx.ExecerContext, job *model.Job) error {
+	job.TenantID = tenant.ID(ctx)
+	job.Version = 1 // The column default
+
+	// The creation event is inserted by the same statement
 	query := `
-		INSERT INTO jobs (
-			job_id, idempotency_key, user_id, job_type,
-			payload, status, created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4,
-			$5, $6, $7, $8
+		WITH job AS (
+			INSERT INTO jobs (
+				job_id, idempotency_key, user_id, job_type,
+				payload, metadata, ordering_key, status, created_at, updated_at,
+				workflow_id, step_name, tenant_id, execute_after
+			) VALUES (
+				$1, $2, $3, $4,
+				$5, $6, $7, $8, $9, $10,
+				$11, $12, $13, $14
+			)
+			RETURNING job_id, status, user_id
 		)
+		INSERT INTO job_events (job_id, new_status, actor_type, actor)
+		SELECT job_id, status, $15::varchar, NULLIF(user_id, '') FROM job
 	`
 
-	_, err := s.db.ExecContext(
+	_, err := db.ExecContext(
 		ctx,
 		query,
 		job.JobID,
@@ -43,12 +186,24 @@ func (s *Storage) CreateJob(ctx context.Context, job *model.Job) error {
 		job.UserID,
 		job.JobType,
 		job.Payload,
+		job.Metadata,
+		job.OrderingKey,
 		job.Status,
 		job.CreatedAt,
 		job.UpdatedAt,
+		job.WorkflowID,
+		job.StepName,
+		job.TenantID,
+		job.ExecuteAfter,
+		domain.ActorUser,
 	)
 
 	if err != nil {
+		err = postgresql.TranslateError(err)
+		// job_id is generated, so the only unique column a client controls is idempotency_key
+		if errors.Is(err, postgresql.ErrUniqueViolation) {
+			return fmt.Errorf("%w: %w", domain.ErrIdempotencyConflict, err)
+		}
 		return fmt.Errorf("failed to create job: %w", err)
 	}
 
@@ -61,40 +216,153 @@ func (s *Storage) GetJobByID(ctx context.Context, jobID string) (*model.Job, err
 	query := `
 		SELECT 
 			job_id, idempotency_key, user_id, job_type,
-			payload, status, created_at, updated_at
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id,
+			ARRAY(
+				SELECT depends_on_job_id FROM job_dependencies d
+				WHERE d.job_id = jobs.job_id
+				ORDER BY depends_on_job_id
+			) AS depends_on
+		FROM jobs
+		WHERE job_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`
+
+	err := s.db.GetContext(ctx, &job, query, jobID, tenant.ID(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get job: %w", postgresql.TranslateError(err))
+	}
+
+	return &job, nil
+}
+
+// GetJobByIdempotencyKey retrieves the job userID created with idempotencyKey, served by
+// the unique index on (tenant_id, user_id, idempotency_key)
+func (s *Storage) GetJobByIdempotencyKey(ctx context.Context, userID, idempotencyKey string) (*model.Job, error) {
+	var job model.Job
+	query := `
+		SELECT 
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id,
+			ARRAY(
+				SELECT depends_on_job_id FROM job_dependencies d
+				WHERE d.job_id = jobs.job_id
+				ORDER BY depends_on_job_id
+			) AS depends_on
 		FROM jobs
-		WHERE job_id = $1
+		WHERE tenant_id = $1 AND user_id = $2 AND idempotency_key = $3 AND deleted_at IS NULL
 	`
 
-	err := s.db.GetContext(ctx, &job, query, jobID)
+	err := s.db.GetContext(ctx, &job, query, tenant.ID(ctx), userID, idempotencyKey)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrJobNotFound
 		}
 
-		return nil, fmt.Errorf("failed to get job: %w", err)
+		return nil, fmt.Errorf("failed to get job: %w", postgresql.TranslateError(err))
 	}
 
 	return &job, nil
 }
 
 type JobFilter struct {
-	UserID   string
-	JobType  string
-	Status   string
-	PageSize int
-	Cursor   *JobCursor
+	UserID        string
+	JobType       string
+	Statuses      []string  // Jobs in any of these statuses
+	CreatedAfter  time.Time // Inclusive, zero disables
+	CreatedBefore time.Time // Exclusive, zero disables
+	// PayloadContains is a JSON object the payload must contain (payload @> ...)
+	PayloadContains string
+	// ErrorQuery is full-text searched in error_message
+	ErrorQuery string
+	Sort       JobSort
+	PageSize   int
+	Cursor     *JobCursor
+	Offset     int // Rows to skip in offset pagination, ignored with a Cursor
+}
+
+// Columns ListJobs can sort by
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortStatus    = "status"
+	SortJobType   = "job_type"
+)
+
+// JobSort orders ListJobs results; ties are broken by job_id in the same direction.
+// The zero value sorts newest first.
+type JobSort struct {
+	Field     string // One of the Sort* columns, empty means created_at
+	Ascending bool
 }
 
+// Column returns the sort column. Unknown fields fall back to created_at, so the
+// result is always safe to interpolate into SQL.
+func (s JobSort) Column() string {
+	switch s.Field {
+	case SortUpdatedAt, SortStatus, SortJobType:
+		return s.Field
+	default:
+		return SortCreatedAt
+	}
+}
+
+func (s JobSort) direction() string {
+	if s.Ascending {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// IsTime reports whether the sort column is a timestamp
+func (s JobSort) IsTime() bool {
+	return s.Column() == SortCreatedAt || s.Column() == SortUpdatedAt
+}
+
+// JobCursor marks the last job of a page. It is only valid for the sort it was
+// created with, since it holds that sort's key.
 type JobCursor struct {
-	CreatedAt time.Time
-	JobID     string
+	Sort    JobSort
+	TimeKey time.Time // Sort key for created_at and updated_at
+	TextKey string    // Sort key for status and job_type
+	JobID   string
 }
 
-// ListJobs retrieves jobs based on the provided filter and pagination cursor
-func (s *Storage) ListJobs(ctx context.Context, filter JobFilter) ([]model.Job, error) {
-	var conditions []string
-	var args []interface{}
+// NewJobCursor returns the cursor that continues after job in sort order
+func NewJobCursor(sort JobSort, job *model.Job) *JobCursor {
+	cursor := &JobCursor{Sort: sort, JobID: job.JobID}
+	switch sort.Column() {
+	case SortUpdatedAt:
+		cursor.TimeKey = job.UpdatedAt
+	case SortStatus:
+		cursor.TextKey = job.Status
+	case SortJobType:
+		cursor.TextKey = job.JobType
+	default:
+		cursor.TimeKey = job.CreatedAt
+	}
+	return cursor
+}
+
+func (c *JobCursor) key() interface{} {
+	if c.Sort.IsTime() {
+		return c.TimeKey
+	}
+	return c.TextKey
+}
+
+// where returns the WHERE conditions and arguments shared by ListJobs and CountJobs,
+// limited to the jobs of tenantID. Pagination fields are not included.
+func (filter JobFilter) where(tenantID string) ([]string, []interface{}) {
+	// Soft-deleted jobs are never listed
+	conditions := []string{"tenant_id = ?", "deleted_at IS NULL"}
+	args := []interface{}{tenantID}
 
 	// Build WHERE conditions
 	if filter.UserID != "" {
@@ -107,45 +375,460 @@ func (s *Storage) ListJobs(ctx context.Context, filter JobFilter) ([]model.Job,
 		args = append(args, filter.JobType)
 	}
 
-	if filter.Status != "" {
-		conditions = append(conditions, "status = ?")
-		args = append(args, filter.Status)
+	if len(filter.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ")
+		conditions = append(conditions, "status IN ("+placeholders+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+
+	if filter.PayloadContains != "" {
+		// Served by idx_jobs_payload (jsonb_path_ops)
+		conditions = append(conditions, "payload @> ?::jsonb")
+		args = append(args, filter.PayloadContains)
 	}
 
+	if filter.ErrorQuery != "" {
+		// Must match the idx_jobs_error_message_search expression to use the index
+		conditions = append(conditions, "to_tsvector('simple', coalesce(error_message, '')) @@ plainto_tsquery('simple', ?)")
+		args = append(args, filter.ErrorQuery)
+	}
+
+	return conditions, args
+}
+
+// ListJobs retrieves jobs based on the provided filter and pagination cursor
+func (s *Storage) ListJobs(ctx context.Context, filter JobFilter) ([]model.Job, error) {
+	query, args := filter.listQuery(tenant.ID(ctx))
+
+	var jobs []model.Job
+	err := s.db.SelectContext(ctx, &jobs, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", postgresql.TranslateError(err))
+	}
+
+	return jobs, nil
+}
+
+// listQuery returns the ListJobs query for the jobs of tenantID, with ? placeholders.
+// The query plans are checked against the job listing indexes by the integration tests.
+func (filter JobFilter) listQuery(tenantID string) (string, []interface{}) {
+	conditions, args := filter.where(tenantID)
+
+	column := filter.Sort.Column()
 	if filter.Cursor != nil {
-		conditions = append(conditions, "(created_at, job_id) < (?, ?)")
-		args = append(args, filter.Cursor.CreatedAt, filter.Cursor.JobID)
+		op := "<"
+		if filter.Sort.Ascending {
+			op = ">"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, job_id) %s (?, ?)", column, op))
+		args = append(args, filter.Cursor.key(), filter.Cursor.JobID)
 	}
 
 	// Build the query
 	query := `
 		SELECT 
 			job_id, idempotency_key, user_id, job_type,
-			payload, status, created_at, updated_at
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version
 		FROM jobs`
 
-	if len(conditions) > 0 {
-		query += " WHERE " + conditions[0]
-		for i := 1; i < len(conditions); i++ {
-			query += " AND " + conditions[i]
-		}
-	}
+	query += " WHERE " + strings.Join(conditions, " AND ")
 
-	// Order by created_at DESC, job_id DESC for consistent pagination
-	query += " ORDER BY created_at DESC, job_id DESC"
+	// Break ties by job_id for consistent pagination
+	query += fmt.Sprintf(" ORDER BY %s %s, job_id %s", column, filter.Sort.direction(), filter.Sort.direction())
 
 	// Fetch one extra to determine if there are more results
 	query += " LIMIT ?"
 	args = append(args, filter.PageSize+1)
 
-	// Rebind query for PostgreSQL ($1, $2, etc.)
-	query = s.db.Rebind(query)
+	if filter.Cursor == nil && filter.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, filter.Offset)
+	}
 
-	var jobs []model.Job
-	err := s.db.SelectContext(ctx, &jobs, query, args...)
+	return query, args
+}
+
+// CountJobs returns the number of jobs matching filter, ignoring its pagination fields
+func (s *Storage) CountJobs(ctx context.Context, filter JobFilter) (int64, error) {
+	conditions, args := filter.where(tenant.ID(ctx))
+
+	query := "SELECT COUNT(*) FROM jobs WHERE " + strings.Join(conditions, " AND ")
+
+	var count int64
+	if err := s.db.GetContext(ctx, &count, s.db.Rebind(query), args...); err != nil {
+		return 0, fmt.Errorf("failed to count jobs: %w", postgresql.TranslateError(err))
+	}
+
+	return count, nil
+}
+
+// RetryJob resets a FAILED or CANCELED job back to PENDING inside a transaction and
+// records the transition. publish is called with the updated job before commit, so the
+// reset is rolled back if the job cannot be re-queued. If the job exists but is not retryable, the current
+// job is returned together with domain.ErrJobNotRetryable, which also matches
+// domain.ErrInvalidTransition when the job's status may never change to PENDING.
+func (s *Storage) RetryJob(ctx context.Context, jobID string, resetRetryCount bool, publish func(*model.Job) error) (*model.Job, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list jobs: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
 	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
 
-	return jobs, nil
+	query := `
+		UPDATE jobs
+		SET status = $2,
+			version = version + 1,
+			error_message = NULL,
+			retry_count = CASE WHEN $3 THEN 0 ELSE retry_count END,
+			worker_id = NULL,
+			progress = 0,
+			started_at = NULL,
+			completed_at = NULL,
+			updated_at = NOW()
+		FROM (SELECT status AS old_status FROM jobs WHERE job_id = $1 FOR UPDATE) AS old
+		WHERE job_id = $1 AND tenant_id = $6 AND status IN ($4, $5) AND deleted_at IS NULL
+		RETURNING
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id, old.old_status
+	`
+
+	var retried struct {
+		model.Job
+		OldStatus string `db:"old_status"`
+	}
+	err = tx.GetContext(ctx, &retried, query,
+		jobID,
+		domain.JobStatusPending,
+		resetRetryCount,
+		domain.JobStatusFailed,
+		domain.JobStatusCanceled,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to retry job: %w", postgresql.TranslateError(err))
+		}
+
+		// Nothing updated: either the job does not exist or it is not retryable
+		current, err := currentJob(ctx, tx, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		// A retry puts the job back to PENDING, which not every status may change to
+		if err := jobstatus.Check(domain.JobStatus(current.Status), jobstatus.Pending); err != nil {
+			return current, fmt.Errorf("%w: %w", domain.ErrJobNotRetryable, err)
+		}
+		return current, domain.ErrJobNotRetryable
+	}
+
+	job := retried.Job
+	reason := "retry requested"
+	if resetRetryCount {
+		reason = "retry requested with the retry count reset"
+	}
+	err = insertJobEvent(ctx, tx, &model.JobEvent{
+		JobID:     job.JobID,
+		OldStatus: &retried.OldStatus,
+		NewStatus: job.Status,
+		ActorType: domain.ActorUser,
+		Reason:    &reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := publish(&job); err != nil {
+		return nil, fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit retry: %w", postgresql.TranslateError(err))
+	}
+
+	return &job, nil
+}
+
+// CancelJob cancels a WAITING, PENDING or RUNNING job inside a transaction and records
+// the transition, provided the job is still at version, the version the caller read. If
+// the job changed since, the current job is returned together with
+// domain.ErrVersionConflict, and if it can no longer be canceled, together with a
+// *jobstatus.TransitionError matching domain.ErrInvalidTransition.
+func (s *Storage) CancelJob(ctx context.Context, jobID string, version int) (*model.Job, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE jobs
+		SET status = $2,
+			version = version + 1,
+			updated_at = NOW()
+		FROM (SELECT status AS old_status FROM jobs WHERE job_id = $1 FOR UPDATE) AS old
+		WHERE job_id = $1 AND tenant_id = $7 AND version = $3 AND status IN ($4, $5, $6) AND deleted_at IS NULL
+		RETURNING
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id, old.old_status
+	`
+
+	var canceled struct {
+		model.Job
+		OldStatus string `db:"old_status"`
+	}
+	err = tx.GetContext(ctx, &canceled, query,
+		jobID,
+		domain.JobStatusCanceled,
+		version,
+		domain.JobStatusWaiting,
+		domain.JobStatusPending,
+		domain.JobStatusRunning,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to cancel job: %w", postgresql.TranslateError(err))
+		}
+
+		// Nothing updated: the job does not exist, changed since it was read or has finished
+		current, err := currentJob(ctx, tx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Version != version {
+			return current, domain.ErrVersionConflict
+		}
+		if err := jobstatus.Check(domain.JobStatus(current.Status), jobstatus.Canceled); err != nil {
+			return current, err
+		}
+		return current, domain.ErrVersionConflict
+	}
+
+	job := canceled.Job
+	reason := "cancel requested"
+	err = insertJobEvent(ctx, tx, &model.JobEvent{
+		JobID:     job.JobID,
+		OldStatus: &canceled.OldStatus,
+		NewStatus: job.Status,
+		ActorType: domain.ActorUser,
+		Reason:    &reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cancel: %w", postgresql.TranslateError(err))
+	}
+
+	return &job, nil
+}
+
+// currentJob reads a job of the tenant of ctx in tx, after an update matched no row, to
+// tell why
+func currentJob(ctx context.Context, tx *sqlx.Tx, jobID string) (*model.Job, error) {
+	var job model.Job
+	err := tx.GetContext(ctx, &job, `
+		SELECT 
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version
+		FROM jobs
+		WHERE job_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, jobID, tenant.ID(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", postgresql.TranslateError(err))
+	}
+	return &job, nil
+}
+
+// GetJobTypeStats returns duration percentiles for jobs of jobType completed since the given time,
+// plus the mean duration across all job types and the current PENDING backlog of the shared queue.
+// Workers are shared by every tenant, so the statistics are too.
+func (s *Storage) GetJobTypeStats(ctx context.Context, jobType string, since time.Time) (*model.JobTypeStats, error) {
+	query := `
+		WITH durations AS (
+			SELECT job_type, EXTRACT(EPOCH FROM (completed_at - started_at)) AS seconds
+			FROM jobs
+			WHERE status = $2
+				AND started_at IS NOT NULL
+				AND completed_at >= $3
+		)
+		SELECT
+			(SELECT COUNT(*) FROM durations WHERE job_type = $1) AS sample_size,
+			(SELECT COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds), 0)
+				FROM durations WHERE job_type = $1) AS p50_seconds,
+			(SELECT COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY seconds), 0)
+				FROM durations WHERE job_type = $1) AS p95_seconds,
+			(SELECT COALESCE(AVG(seconds), 0) FROM durations) AS avg_seconds,
+			(SELECT COUNT(*) FROM jobs WHERE status = $4) AS queue_backlog
+	`
+
+	var stats model.JobTypeStats
+	err := s.db.GetContext(ctx, &stats, query,
+		jobType,
+		domain.JobStatusCompleted,
+		since,
+		domain.JobStatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job type stats: %w", postgresql.TranslateError(err))
+	}
+
+	return &stats, nil
+}
+
+// CountJobsByStatus returns the number of jobs of every tenant currently in the given status
+func (s *Storage) CountJobsByStatus(ctx context.Context, status string) (int64, error) {
+	query := `SELECT COUNT(*) FROM jobs WHERE status = $1`
+
+	var count int64
+	if err := s.db.GetContext(ctx, &count, query, status); err != nil {
+		return 0, fmt.Errorf("failed to count jobs: %w", postgresql.TranslateError(err))
+	}
+
+	return count, nil
+}
+
+// PromoteWaitingJob moves one WAITING job whose dependencies have all COMPLETED to PENDING.
+// publish is called with the updated job before commit, so the promotion is rolled back if
+// the job cannot be queued. It returns nil when no job is ready. Jobs locked by another
+// resolver are skipped.
+func (s *Storage) PromoteWaitingJob(ctx context.Context, publish func(*model.Job) error) (*model.Job, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE jobs
+		SET status = $2,
+			version = version + 1,
+			updated_at = NOW()
+		WHERE job_id = (
+			SELECT j.job_id
+			FROM jobs j
+			WHERE j.status = $1
+				AND NOT EXISTS (
+					SELECT 1
+					FROM job_dependencies d
+					JOIN jobs p ON p.job_id = d.depends_on_job_id
+					WHERE d.job_id = j.job_id AND p.status <> $3
+				)
+			ORDER BY j.created_at
+			LIMIT 1
+			FOR UPDATE OF j SKIP LOCKED
+		)
+		RETURNING
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name, tenant_id
+	`
+
+	var job model.Job
+	err = tx.GetContext(ctx, &job, query,
+		domain.JobStatusWaiting,
+		domain.JobStatusPending,
+		domain.JobStatusCompleted,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to promote waiting job: %w", postgresql.TranslateError(err))
+	}
+
+	oldStatus, actor, reason := domain.JobStatusWaiting, dependencyResolverActor, "dependencies completed"
+	err = insertJobEvent(ctx, tx, &model.JobEvent{
+		JobID:     job.JobID,
+		OldStatus: &oldStatus,
+		NewStatus: job.Status,
+		ActorType: domain.ActorSystem,
+		Actor:     &actor,
+		Reason:    &reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := publish(&job); err != nil {
+		return nil, fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit promotion: %w", postgresql.TranslateError(err))
+	}
+
+	return &job, nil
+}
+
+// CancelBlockedJobs cancels WAITING jobs that can never run because a dependency was
+// CANCELED or FAILED with no retries left. Their own dependents are canceled on a later call.
+func (s *Storage) CancelBlockedJobs(ctx context.Context) (int64, error) {
+	query := `
+		WITH canceled AS (
+			UPDATE jobs
+			SET status = $2,
+				version = version + 1,
+				error_message = 'dependency ' || blocked.depends_on_job_id || ' is ' || blocked.status,
+				updated_at = NOW()
+			FROM (
+				SELECT DISTINCT ON (d.job_id) d.job_id, d.depends_on_job_id, p.status
+				FROM job_dependencies d
+				JOIN jobs p ON p.job_id = d.depends_on_job_id
+				WHERE p.status = $2 OR (p.status = $3 AND p.retry_count >= p.max_retries)
+				ORDER BY d.job_id, d.depends_on_job_id
+			) AS blocked
+			WHERE jobs.job_id = blocked.job_id AND jobs.status = $1
+			RETURNING jobs.job_id, jobs.error_message
+		)
+		INSERT INTO job_events (job_id, old_status, new_status, actor_type, actor, reason)
+		SELECT job_id, $1, $2, $4::varchar, $5::varchar, error_message FROM canceled
+	`
+
+	// One event is inserted per canceled job, so the rows affected are the jobs canceled
+	result, err := s.db.ExecContext(ctx, query,
+		domain.JobStatusWaiting,
+		domain.JobStatusCanceled,
+		domain.JobStatusFailed,
+		domain.ActorSystem,
+		dependencyResolverActor,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel blocked jobs: %w", postgresql.TranslateError(err))
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel blocked jobs: %w", err)
+	}
+	return count, nil
 }
@@ -0,0 +1,735 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/jobstatus"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jobColumns = []string{
+	"job_id", "idempotency_key", "user_id", "job_type",
+	"payload", "metadata", "ordering_key", "result", "result_ref",
+	"status", "error_message", "retry_count", "max_retries",
+	"created_at", "updated_at", "last_heartbeat_at",
+}
+
+// retriedColumns are the columns RetryJob reads back: the job and its status before the retry
+var retriedColumns = append(slices.Clone(jobColumns), "old_status")
+
+// newMockStorage returns a Storage backed by sqlmock
+func newMockStorage(t *testing.T) (*Storage, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewStorageWithDB(sqlx.NewDb(db, "postgres")), mock
+}
+
+func TestStorage_CreateJob(t *testing.T) {
+	now := time.Now().UTC()
+	job := &model.Job{
+		JobID:          "550e8400-e29b-41d4-a716-446655440000",
+		IdempotencyKey: "key-1",
+		UserID:         "user-1",
+		JobType:        "send_email",
+		Payload:        `{"to":"a@b.c"}`,
+		Status:         domain.JobStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	t.Run("inserts job with its creation event", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events (job_id, new_status, actor_type, actor)")).
+			WithArgs(job.JobID, job.IdempotencyKey, job.UserID, job.JobType,
+				job.Payload, job.Metadata, job.OrderingKey, job.Status, job.CreatedAt, job.UpdatedAt,
+				job.WorkflowID, job.StepName, tenant.DefaultID, job.ExecuteAfter, domain.ActorUser).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		require.NoError(t, s.CreateJob(context.Background(), job, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("wraps database error", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnError(errors.New("connection refused"))
+
+		err := s.CreateJob(context.Background(), job, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create job")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_jobs_tenant_idempotency_key"})
+
+		err := s.CreateJob(context.Background(), job, nil)
+		assert.ErrorIs(t, err, domain.ErrIdempotencyConflict)
+		assert.ErrorIs(t, err, postgresql.ErrUniqueViolation)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("publishes before commit", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		var published []string
+		err := s.CreateJob(context.Background(), job, func(j *model.Job) error {
+			published = append(published, j.JobID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{job.JobID}, published)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when publishing fails", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		publishErr := errors.New("channel closed")
+		err := s.CreateJob(context.Background(), job, func(*model.Job) error { return publishErr })
+		assert.ErrorIs(t, err, publishErr)
+		assert.Contains(t, err.Error(), "failed to publish job")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	parentIDs := pq.StringArray{"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"}
+	waiting := *job
+	waiting.Status = domain.JobStatusWaiting
+	waiting.DependsOn = parentIDs
+
+	t.Run("inserts dependencies in the same transaction", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT job_id FROM jobs WHERE job_id = ANY($1) AND tenant_id = $2")).
+			WithArgs(parentIDs, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow(parentIDs[0]).AddRow(parentIDs[1]))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_dependencies")).
+			WithArgs(job.JobID, parentIDs).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.CreateJob(context.Background(), &waiting, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects unknown dependencies", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT job_id FROM jobs WHERE job_id = ANY($1)")).
+			WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow(parentIDs[0]))
+		mock.ExpectRollback()
+
+		err := s.CreateJob(context.Background(), &waiting, nil)
+		assert.ErrorIs(t, err, domain.ErrDependencyNotFound)
+		assert.Contains(t, err.Error(), parentIDs[1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_GetJobByID(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	t.Run("returns job of the tenant", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE job_id = $1 AND tenant_id = $2")).
+			WithArgs(jobID, "acme").
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil))
+
+		job, err := s.GetJobByID(tenant.WithID(context.Background(), "acme"), jobID)
+		require.NoError(t, err)
+		assert.Equal(t, jobID, job.JobID)
+		assert.Equal(t, domain.JobStatusPending, job.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns dependencies", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM job_dependencies")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(append(jobColumns, "depends_on")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusWaiting, nil, 0, 3, now, now, nil, "{parent-1,parent-2}"))
+
+		job, err := s.GetJobByID(context.Background(), jobID)
+		require.NoError(t, err)
+		assert.Equal(t, pq.StringArray{"parent-1", "parent-2"}, job.DependsOn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("maps no rows to ErrJobNotFound", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnError(sql.ErrNoRows)
+
+		job, err := s.GetJobByID(context.Background(), jobID)
+		assert.Nil(t, job)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+	})
+}
+
+func TestStorage_GetJobByIdempotencyKey(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	t.Run("returns the job of the user", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE tenant_id = $1 AND user_id = $2 AND idempotency_key = $3 AND deleted_at IS NULL")).
+			WithArgs("acme", "user-1", "key-1").
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil))
+
+		job, err := s.GetJobByIdempotencyKey(tenant.WithID(context.Background(), "acme"), "user-1", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, jobID, job.JobID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("maps no rows to ErrJobNotFound", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WithArgs(tenant.DefaultID, "user-2", "key-1").
+			WillReturnError(sql.ErrNoRows)
+
+		job, err := s.GetJobByIdempotencyKey(context.Background(), "user-2", "key-1")
+		assert.Nil(t, job)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+	})
+}
+
+func TestStorage_ListJobs(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name      string
+		filter    JobFilter
+		wantQuery string
+		wantArgs  []driver.Value
+	}{
+		{
+			name:      "no filters",
+			filter:    JobFilter{PageSize: 10},
+			wantQuery: "FROM jobs WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC, job_id DESC LIMIT $2",
+			wantArgs:  []driver.Value{tenant.DefaultID, 11},
+		},
+		{
+			name: "all filters with cursor",
+			filter: JobFilter{
+				UserID:   "user-1",
+				JobType:  "send_email",
+				Statuses: []string{domain.JobStatusPending},
+				PageSize: 5,
+				Cursor:   &JobCursor{TimeKey: now, JobID: "job-1"},
+			},
+			wantQuery: "WHERE tenant_id = $1 AND deleted_at IS NULL AND user_id = $2 AND job_type = $3 AND status IN ($4) AND (created_at, job_id) < ($5, $6) " +
+				"ORDER BY created_at DESC, job_id DESC LIMIT $7",
+			wantArgs: []driver.Value{tenant.DefaultID, "user-1", "send_email", domain.JobStatusPending, now, "job-1", 6},
+		},
+		{
+			name: "search filters",
+			filter: JobFilter{
+				Statuses:        []string{domain.JobStatusFailed, domain.JobStatusCanceled},
+				CreatedAfter:    now.Add(-time.Hour),
+				CreatedBefore:   now,
+				PayloadContains: `{"customer_id":"c-1"}`,
+				ErrorQuery:      "connection refused",
+				PageSize:        10,
+			},
+			wantQuery: "WHERE tenant_id = $1 AND deleted_at IS NULL AND status IN ($2, $3) AND created_at >= $4 AND created_at < $5 AND payload @> $6::jsonb " +
+				"AND to_tsvector('simple', coalesce(error_message, '')) @@ plainto_tsquery('simple', $7) " +
+				"ORDER BY created_at DESC, job_id DESC LIMIT $8",
+			wantArgs: []driver.Value{
+				tenant.DefaultID, domain.JobStatusFailed, domain.JobStatusCanceled, now.Add(-time.Hour), now,
+				`{"customer_id":"c-1"}`, "connection refused", 11,
+			},
+		},
+		{
+			name: "ascending time sort with cursor",
+			filter: JobFilter{
+				Sort:     JobSort{Field: SortUpdatedAt, Ascending: true},
+				PageSize: 10,
+				Cursor:   &JobCursor{Sort: JobSort{Field: SortUpdatedAt, Ascending: true}, TimeKey: now, JobID: "job-1"},
+			},
+			wantQuery: "WHERE tenant_id = $1 AND deleted_at IS NULL AND (updated_at, job_id) > ($2, $3) ORDER BY updated_at ASC, job_id ASC LIMIT $4",
+			wantArgs:  []driver.Value{tenant.DefaultID, now, "job-1", 11},
+		},
+		{
+			name: "text sort with cursor",
+			filter: JobFilter{
+				Sort:     JobSort{Field: SortStatus},
+				PageSize: 10,
+				Cursor:   &JobCursor{Sort: JobSort{Field: SortStatus}, TextKey: domain.JobStatusFailed, JobID: "job-1"},
+			},
+			wantQuery: "WHERE tenant_id = $1 AND deleted_at IS NULL AND (status, job_id) < ($2, $3) ORDER BY status DESC, job_id DESC LIMIT $4",
+			wantArgs:  []driver.Value{tenant.DefaultID, domain.JobStatusFailed, "job-1", 11},
+		},
+		{
+			name:      "unknown sort field falls back to created_at",
+			filter:    JobFilter{Sort: JobSort{Field: "payload; DROP TABLE jobs"}, PageSize: 10},
+			wantQuery: "FROM jobs WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC, job_id DESC LIMIT $2",
+			wantArgs:  []driver.Value{tenant.DefaultID, 11},
+		},
+		{
+			name:      "offset pagination",
+			filter:    JobFilter{JobType: "send_email", PageSize: 20, Offset: 40},
+			wantQuery: "WHERE tenant_id = $1 AND deleted_at IS NULL AND job_type = $2 ORDER BY created_at DESC, job_id DESC LIMIT $3 OFFSET $4",
+			wantArgs:  []driver.Value{tenant.DefaultID, "send_email", 21, 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+
+			mock.ExpectQuery(regexp.QuoteMeta(tt.wantQuery)).
+				WithArgs(tt.wantArgs...).
+				WillReturnRows(sqlmock.NewRows(jobColumns).
+					AddRow("job-2", "key-2", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil))
+
+			jobs, err := s.ListJobs(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Len(t, jobs, 1)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestStorage_StatusChangesFollowLifecycle checks the status changes the queries of
+// Storage make against the shared transition table
+func TestStorage_StatusChangesFollowLifecycle(t *testing.T) {
+	changes := []struct {
+		method   string
+		from, to string
+	}{
+		{"RetryJob", domain.JobStatusFailed, domain.JobStatusPending},
+		{"RetryJob", domain.JobStatusCanceled, domain.JobStatusPending},
+		{"PromoteWaitingJob", domain.JobStatusWaiting, domain.JobStatusPending},
+		{"CancelBlockedJobs", domain.JobStatusWaiting, domain.JobStatusCanceled},
+		{"CancelWorkflow", domain.JobStatusWaiting, domain.JobStatusCanceled},
+		{"CancelWorkflow", domain.JobStatusPending, domain.JobStatusCanceled},
+		{"CancelJob", domain.JobStatusWaiting, domain.JobStatusCanceled},
+		{"CancelJob", domain.JobStatusPending, domain.JobStatusCanceled},
+		{"CancelJob", domain.JobStatusRunning, domain.JobStatusCanceled},
+	}
+
+	for _, change := range changes {
+		assert.True(t, jobstatus.CanTransition(domain.JobStatus(change.from), domain.JobStatus(change.to)),
+			"%s changes %s to %s", change.method, change.from, change.to)
+	}
+}
+
+func TestNewJobCursor(t *testing.T) {
+	created := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	job := &model.Job{JobID: "job-1", JobType: "send_email", Status: domain.JobStatusRunning, CreatedAt: created, UpdatedAt: updated}
+
+	assert.Equal(t, &JobCursor{TimeKey: created, JobID: "job-1"}, NewJobCursor(JobSort{}, job))
+	assert.Equal(t, &JobCursor{Sort: JobSort{Field: SortUpdatedAt}, TimeKey: updated, JobID: "job-1"},
+		NewJobCursor(JobSort{Field: SortUpdatedAt}, job))
+	assert.Equal(t, &JobCursor{Sort: JobSort{Field: SortJobType, Ascending: true}, TextKey: "send_email", JobID: "job-1"},
+		NewJobCursor(JobSort{Field: SortJobType, Ascending: true}, job))
+}
+
+func TestStorage_RetryJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+	noopPublish := func(*model.Job) error { return nil }
+
+	t.Run("resets failed job and commits after publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WithArgs(jobID, domain.JobStatusPending, true, domain.JobStatusFailed, domain.JobStatusCanceled, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(retriedColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil, domain.JobStatusFailed))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WithArgs(jobID, domain.JobStatusFailed, domain.JobStatusPending, domain.ActorUser, nil, "retry requested with the retry count reset").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		published := 0
+		job, err := s.RetryJob(context.Background(), jobID, true, func(*model.Job) error {
+			published++
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobStatusPending, job.Status)
+		assert.Equal(t, 1, published)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when publish fails", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnRows(sqlmock.NewRows(retriedColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil, domain.JobStatusCanceled))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		_, err := s.RetryJob(context.Background(), jobID, false, func(*model.Job) error {
+			return errors.New("channel closed")
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to publish job")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("passes workflow steps to publish with their workflow", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`RETURNING[\s\S]+workflow_id, step_name`).
+			WillReturnRows(sqlmock.NewRows(append(slices.Clone(jobColumns), "workflow_id", "step_name", "old_status")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil, "wf-1", "notify", domain.JobStatusFailed))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		var published *model.Job
+		_, err := s.RetryJob(context.Background(), jobID, false, func(job *model.Job) error {
+			published = job
+			return nil
+		})
+		require.NoError(t, err)
+		require.NotNil(t, published)
+		assert.Equal(t, "wf-1", *published.WorkflowID)
+		assert.Equal(t, "notify", *published.StepName)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns current job when not retryable", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusRunning, nil, 0, 3, now, now, nil))
+		mock.ExpectRollback()
+
+		job, err := s.RetryJob(context.Background(), jobID, false, noopPublish)
+		assert.ErrorIs(t, err, domain.ErrJobNotRetryable)
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		require.NotNil(t, job)
+		assert.Equal(t, domain.JobStatusRunning, job.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns ErrJobNotFound for unknown job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		job, err := s.RetryJob(context.Background(), jobID, false, noopPublish)
+		assert.Nil(t, job)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_CancelJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+	versionedColumns := append(slices.Clone(jobColumns), "version")
+
+	t.Run("cancels job at the expected version", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WithArgs(jobID, domain.JobStatusCanceled, 2, domain.JobStatusWaiting, domain.JobStatusPending, domain.JobStatusRunning, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(append(slices.Clone(versionedColumns), "old_status")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCanceled, nil, 0, 3, now, now, nil, 3, domain.JobStatusRunning))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WithArgs(jobID, domain.JobStatusRunning, domain.JobStatusCanceled, domain.ActorUser, nil, "cancel requested").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		job, err := s.CancelJob(context.Background(), jobID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobStatusCanceled, job.Status)
+		assert.Equal(t, 3, job.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns the workflow of canceled steps", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`RETURNING[\s\S]+workflow_id, step_name`).
+			WillReturnRows(sqlmock.NewRows(append(slices.Clone(versionedColumns), "workflow_id", "step_name", "old_status")).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCanceled, nil, 0, 3, now, now, nil, 3, "wf-1", "notify", domain.JobStatusPending))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		job, err := s.CancelJob(context.Background(), jobID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "wf-1", *job.WorkflowID)
+		assert.Equal(t, "notify", *job.StepName)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns current job on version mismatch", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WithArgs(jobID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows(versionedColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCompleted, nil, 0, 3, now, now, nil, 3))
+		mock.ExpectRollback()
+
+		job, err := s.CancelJob(context.Background(), jobID, 2)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		require.NotNil(t, job)
+		assert.Equal(t, 3, job.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects finished job at the current version", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WillReturnRows(sqlmock.NewRows(versionedColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusCompleted, nil, 0, 3, now, now, nil, 2))
+		mock.ExpectRollback()
+
+		job, err := s.CancelJob(context.Background(), jobID, 2)
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		require.NotNil(t, job)
+		assert.Equal(t, domain.JobStatusCompleted, job.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns ErrJobNotFound for unknown job", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		job, err := s.CancelJob(context.Background(), jobID, 1)
+		assert.Nil(t, job)
+		assert.ErrorIs(t, err, domain.ErrJobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_PromoteWaitingJob(t *testing.T) {
+	jobID := "550e8400-e29b-41d4-a716-446655440000"
+	now := time.Now().UTC()
+
+	t.Run("queues a ready job and commits after publish", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE OF j SKIP LOCKED")).
+			WithArgs(domain.JobStatusWaiting, domain.JobStatusPending, domain.JobStatusCompleted).
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WithArgs(jobID, domain.JobStatusWaiting, domain.JobStatusPending, domain.ActorSystem, dependencyResolverActor, "dependencies completed").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		var published []string
+		job, err := s.PromoteWaitingJob(context.Background(), func(job *model.Job) error {
+			published = append(published, job.JobID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobStatusPending, job.Status)
+		assert.Equal(t, []string{jobID}, published)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns nil when no job is ready", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		job, err := s.PromoteWaitingJob(context.Background(), func(*model.Job) error {
+			t.Fatal("publish must not be called")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Nil(t, job)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when publish fails", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs")).
+			WillReturnRows(sqlmock.NewRows(jobColumns).
+				AddRow(jobID, "key-1", "user-1", "send_email", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		_, err := s.PromoteWaitingJob(context.Background(), func(*model.Job) error {
+			return errors.New("channel closed")
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to publish job")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_CancelBlockedJobs(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+		WithArgs(domain.JobStatusWaiting, domain.JobStatusCanceled, domain.JobStatusFailed, domain.ActorSystem, dependencyResolverActor).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	count, err := s.CancelBlockedJobs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_GetJobTypeStats(t *testing.T) {
+	since := time.Now().UTC().Add(-24 * time.Hour)
+
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("percentile_cont(0.95)")).
+		WithArgs("send_email", domain.JobStatusCompleted, since, domain.JobStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"sample_size", "p50_seconds", "p95_seconds", "avg_seconds", "queue_backlog"}).
+			AddRow(42, 1.5, 6.0, 2.0, 17))
+
+	stats, err := s.GetJobTypeStats(context.Background(), "send_email", since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), stats.SampleSize)
+	assert.Equal(t, 1.5, stats.P50Seconds)
+	assert.Equal(t, 6.0, stats.P95Seconds)
+	assert.Equal(t, int64(17), stats.QueueBacklog)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_CountJobsByStatus(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM jobs WHERE status = $1")).
+		WithArgs(domain.JobStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(123))
+
+	count, err := s.CountJobsByStatus(context.Background(), domain.JobStatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_CountJobs(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	// Pagination fields do not affect the count
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM jobs WHERE tenant_id = $1 AND deleted_at IS NULL AND user_id = $2 AND status IN ($3, $4)")).
+		WithArgs(tenant.DefaultID, "user-1", domain.JobStatusFailed, domain.JobStatusCanceled).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(57))
+
+	count, err := s.CountJobs(context.Background(), JobFilter{
+		UserID:   "user-1",
+		Statuses: []string{domain.JobStatusFailed, domain.JobStatusCanceled},
+		PageSize: 20,
+		Offset:   40,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(57), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "bad connection", err: fmt.Errorf("failed to get job: %w", driver.ErrBadConn), want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "connection exception", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "cannot connect now", err: &pq.Error{Code: "57P03"}, want: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: false},
+		{name: "no rows", err: sql.ErrNoRows, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUnavailable(tt.err))
+		})
+	}
+}
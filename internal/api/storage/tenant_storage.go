@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// GetTenantUsage counts the jobs of tenant.ID(ctx) that are waiting to run and those
+// created since the given time, in total and by userID. Deleted jobs still count as created.
+func (s *Storage) GetTenantUsage(ctx context.Context, userID string, since time.Time) (*model.TenantUsage, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status IN ($2, $3)) AS pending_jobs,
+			COUNT(*) FILTER (WHERE created_at >= $4) AS jobs_today,
+			COUNT(*) FILTER (WHERE created_at >= $4 AND user_id = $5) AS user_jobs_today
+		FROM jobs
+		WHERE tenant_id = $1 AND (status IN ($2, $3) OR created_at >= $4)
+	`
+
+	var usage model.TenantUsage
+	err := s.db.GetContext(ctx, &usage, query,
+		tenant.ID(ctx),
+		domain.JobStatusPending,
+		domain.JobStatusWaiting,
+		since,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", postgresql.TranslateError(err))
+	}
+
+	return &usage, nil
+}
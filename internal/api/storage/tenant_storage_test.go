@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_GetTenantUsage(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("counts jobs of the tenant", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE tenant_id = $1")).
+			WithArgs("acme", domain.JobStatusPending, domain.JobStatusWaiting, since, "user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"pending_jobs", "jobs_today", "user_jobs_today"}).AddRow(4, 120, 7))
+
+		usage, err := s.GetTenantUsage(tenant.WithID(context.Background(), "acme"), "user-1", since)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), usage.PendingJobs)
+		assert.Equal(t, int64(120), usage.JobsToday)
+		assert.Equal(t, int64(7), usage.UserJobsToday)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("wraps database error", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM jobs")).
+			WillReturnError(errors.New("connection refused"))
+
+		_, err := s.GetTenantUsage(context.Background(), "user-1", since)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get tenant usage")
+	})
+}
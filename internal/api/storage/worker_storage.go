@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+)
+
+// ListWorkers returns every registered worker, most recently started first, with the
+// RUNNING jobs assigned to it
+func (s *Storage) ListWorkers(ctx context.Context) ([]model.Worker, error) {
+	query := `
+		SELECT w.worker_id, COALESCE(w.hostname, '') AS hostname, COALESCE(w.version, '') AS version,
+			w.concurrency, w.started_at, w.last_heartbeat_at,
+			ARRAY(
+				SELECT j.job_id FROM jobs j
+				WHERE j.worker_id = w.worker_id AND j.status = $1
+				ORDER BY j.id
+			) AS job_ids
+		FROM workers w
+		ORDER BY w.started_at DESC, w.id DESC
+	`
+
+	workers := []model.Worker{}
+	if err := s.db.SelectContext(ctx, &workers, query, domain.JobStatusRunning); err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", postgresql.TranslateError(err))
+	}
+
+	return workers, nil
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ListWorkers(t *testing.T) {
+	now := time.Now().UTC()
+	columns := []string{"worker_id", "hostname", "version", "concurrency", "started_at", "last_heartbeat_at", "job_ids"}
+
+	t.Run("returns workers with their running jobs", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM workers w")).
+			WithArgs(domain.JobStatusRunning).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("worker-1", "host-a", "1.2.0", 4, now, now, "{job-1,job-2}").
+				AddRow("worker-2", "", "", 1, now, now, "{}"))
+
+		workers, err := s.ListWorkers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, workers, 2)
+		assert.Equal(t, "worker-1", workers[0].WorkerID)
+		assert.Equal(t, pq.StringArray{"job-1", "job-2"}, workers[0].JobIDs)
+		assert.Empty(t, workers[1].JobIDs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("translates database errors", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM workers w")).
+			WillReturnError(assert.AnError)
+
+		_, err := s.ListWorkers(context.Background())
+		assert.ErrorContains(t, err, "failed to list workers")
+	})
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/lib/pq"
+)
+
+// CreateWorkflow inserts a workflow and its steps in one transaction for the tenant of ctx.
+// Each step's DependsOn must only reference other steps of the same workflow.
+func (s *Storage) CreateWorkflow(ctx context.Context, workflow *model.Workflow, steps []model.Job) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", postgresql.TranslateError(err))
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO workflows (workflow_id, idempotency_key, user_id, name, created_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, workflow.WorkflowID, workflow.IdempotencyKey, workflow.UserID, workflow.Name, workflow.CreatedAt, tenant.ID(ctx))
+	if err != nil {
+		err = postgresql.TranslateError(err)
+		// workflow_id is generated, so the only unique column a client controls is idempotency_key
+		if errors.Is(err, postgresql.ErrUniqueViolation) {
+			return fmt.Errorf("%w: %w", domain.ErrIdempotencyConflict, err)
+		}
+		return fmt.Errorf("failed to create workflow: %w", err)
+	}
+
+	var jobIDs, parentIDs pq.StringArray
+	for i := range steps {
+		if err := insertJob(ctx, tx, &steps[i]); err != nil {
+			return err
+		}
+		for _, parentID := range steps[i].DependsOn {
+			jobIDs = append(jobIDs, steps[i].JobID)
+			parentIDs = append(parentIDs, parentID)
+		}
+	}
+
+	if len(jobIDs) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO job_dependencies (job_id, depends_on_job_id)
+			SELECT unnest($1::varchar[]), unnest($2::varchar[])
+		`, jobIDs, parentIDs)
+		if err != nil {
+			return fmt.Errorf("failed to create step dependencies: %w", postgresql.TranslateError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit workflow: %w", postgresql.TranslateError(err))
+	}
+
+	return nil
+}
+
+// GetWorkflow retrieves a workflow and its steps in submission order
+func (s *Storage) GetWorkflow(ctx context.Context, workflowID string) (*model.Workflow, []model.Job, error) {
+	var workflow model.Workflow
+	err := s.db.GetContext(ctx, &workflow, `
+		SELECT workflow_id, idempotency_key, user_id, name, created_at
+		FROM workflows
+		WHERE workflow_id = $1 AND tenant_id = $2
+	`, workflowID, tenant.ID(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, domain.ErrWorkflowNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get workflow: %w", postgresql.TranslateError(err))
+	}
+
+	var steps []model.Job
+	err = s.db.SelectContext(ctx, &steps, `
+		SELECT
+			job_id, idempotency_key, user_id, job_type,
+			payload, metadata, ordering_key, execute_after, result, result_ref,
+			status, error_message, retry_count, max_retries,
+			created_at, updated_at, last_heartbeat_at, version, workflow_id, step_name,
+			ARRAY(
+				SELECT depends_on_job_id FROM job_dependencies d
+				WHERE d.job_id = jobs.job_id
+				ORDER BY depends_on_job_id
+			) AS depends_on
+		FROM jobs
+		WHERE workflow_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`, workflowID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get workflow steps: %w", postgresql.TranslateError(err))
+	}
+
+	return &workflow, steps, nil
+}
+
+// CancelWorkflow cancels every step of a workflow that has not started yet and returns how
+// many were canceled. Running steps are left to finish.
+func (s *Storage) CancelWorkflow(ctx context.Context, workflowID string) (int64, error) {
+	var exists bool
+	err := s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (SELECT 1 FROM workflows WHERE workflow_id = $1 AND tenant_id = $2)
+	`, workflowID, tenant.ID(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workflow: %w", postgresql.TranslateError(err))
+	}
+	if !exists {
+		return 0, domain.ErrWorkflowNotFound
+	}
+
+	// One event is inserted per canceled step, so the rows affected are the steps canceled
+	result, err := s.db.ExecContext(ctx, `
+		WITH canceled AS (
+			UPDATE jobs
+			SET status = $2,
+				version = version + 1,
+				error_message = 'workflow canceled',
+				updated_at = NOW()
+			FROM (
+				SELECT job_id, status AS old_status FROM jobs
+				WHERE workflow_id = $1 AND status IN ($3, $4)
+				FOR UPDATE
+			) AS old
+			WHERE jobs.job_id = old.job_id AND jobs.status IN ($3, $4)
+			RETURNING jobs.job_id, old.old_status
+		)
+		INSERT INTO job_events (job_id, old_status, new_status, actor_type, reason)
+		SELECT job_id, old_status, $2, $5::varchar, 'workflow canceled' FROM canceled
+	`, workflowID, domain.JobStatusCanceled, domain.JobStatusWaiting, domain.JobStatusPending, domain.ActorUser)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel workflow: %w", postgresql.TranslateError(err))
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel workflow: %w", err)
+	}
+	return count, nil
+}
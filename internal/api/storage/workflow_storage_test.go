@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/model"
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_CreateWorkflow(t *testing.T) {
+	now := time.Now().UTC()
+	workflowID := "99999999-9999-9999-9999-999999999999"
+	workflow := &model.Workflow{WorkflowID: workflowID, IdempotencyKey: "wf-1", UserID: "user-1", Name: "etl", CreatedAt: now}
+	steps := []model.Job{
+		{JobID: "job-1", IdempotencyKey: workflowID + ":extract", Status: domain.JobStatusWaiting, Payload: `{}`},
+		{JobID: "job-2", IdempotencyKey: workflowID + ":load", Status: domain.JobStatusWaiting, Payload: `{}`, DependsOn: pq.StringArray{"job-1"}},
+	}
+
+	t.Run("inserts workflow, steps and dependencies in one transaction for the tenant", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO workflows")).
+			WithArgs(workflowID, "wf-1", "user-1", "etl", now, "acme").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO jobs")).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_dependencies")).
+			WithArgs(pq.StringArray{"job-2"}, pq.StringArray{"job-1"}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.CreateWorkflow(tenant.WithID(context.Background(), "acme"), workflow, steps))
+		assert.Equal(t, "acme", steps[1].TenantID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO workflows")).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_workflows_tenant_idempotency_key"})
+		mock.ExpectRollback()
+
+		err := s.CreateWorkflow(context.Background(), workflow, steps)
+		assert.ErrorIs(t, err, domain.ErrIdempotencyConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStorage_GetWorkflow(t *testing.T) {
+	now := time.Now().UTC()
+	workflowID := "99999999-9999-9999-9999-999999999999"
+
+	t.Run("returns workflow with steps", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM workflows")).
+			WithArgs(workflowID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows([]string{"workflow_id", "idempotency_key", "user_id", "name", "created_at"}).
+				AddRow(workflowID, "wf-1", "user-1", "etl", now))
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE workflow_id = $1")).
+			WithArgs(workflowID).
+			WillReturnRows(sqlmock.NewRows(append(jobColumns, "workflow_id", "step_name", "depends_on")).
+				AddRow("job-1", "k1", "user-1", "extract", `{}`, nil, nil, `{"rows":3}`, nil, domain.JobStatusCompleted, nil, 0, 3, now, now, nil, workflowID, "extract", "{}").
+				AddRow("job-2", "k2", "user-1", "load", `{}`, nil, nil, nil, nil, domain.JobStatusPending, nil, 0, 3, now, now, nil, workflowID, "load", "{job-1}"))
+
+		workflow, steps, err := s.GetWorkflow(context.Background(), workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, "etl", workflow.Name)
+		require.Len(t, steps, 2)
+		assert.Equal(t, "load", *steps[1].StepName)
+		assert.Equal(t, pq.StringArray{"job-1"}, steps[1].DependsOn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("maps no rows to ErrWorkflowNotFound", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM workflows")).
+			WillReturnError(sql.ErrNoRows)
+
+		_, _, err := s.GetWorkflow(context.Background(), workflowID)
+		assert.ErrorIs(t, err, domain.ErrWorkflowNotFound)
+	})
+}
+
+func TestStorage_CancelWorkflow(t *testing.T) {
+	workflowID := "99999999-9999-9999-9999-999999999999"
+
+	t.Run("cancels steps that have not started", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WithArgs(workflowID, tenant.DefaultID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO job_events")).
+			WithArgs(workflowID, domain.JobStatusCanceled, domain.JobStatusWaiting, domain.JobStatusPending, domain.ActorUser).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		count, err := s.CancelWorkflow(context.Background(), workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown workflow", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := s.CancelWorkflow(context.Background(), workflowID)
+		assert.ErrorIs(t, err, domain.ErrWorkflowNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
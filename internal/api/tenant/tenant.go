@@ -0,0 +1,36 @@
+// Package tenant carries the tenant a request acts for through its context, so the
+// storage layer can scope every query to it.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultID owns every job created while multi-tenancy is disabled, and the jobs that
+// existed before it was introduced
+const DefaultID = "default"
+
+// validID matches tenant IDs; it fits the tenant_id columns
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+type idKey struct{}
+
+// WithID returns a copy of ctx acting for the tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// ID returns the tenant set by WithID, or DefaultID
+func ID(ctx context.Context) string {
+	if id, ok := ctx.Value(idKey{}).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+// Valid reports whether id can be used as a tenant ID: 1 to 100 letters, digits, '.',
+// '_' or '-'
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
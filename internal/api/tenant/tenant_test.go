@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID(t *testing.T) {
+	assert.Equal(t, DefaultID, ID(context.Background()))
+	assert.Equal(t, "acme", ID(WithID(context.Background(), "acme")))
+	assert.Equal(t, DefaultID, ID(WithID(context.Background(), "")))
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "acme", want: true},
+		{id: "acme-corp_2.eu", want: true},
+		{id: strings.Repeat("a", 100), want: true},
+		{id: "", want: false},
+		{id: strings.Repeat("a", 101), want: false},
+		{id: "acme corp", want: false},
+		{id: "acme/../other", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.want, Valid(tt.id))
+		})
+	}
+}
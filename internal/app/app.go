@@ -0,0 +1,259 @@
+// Package app bootstraps the service binaries. It loads and validates the config, sets
+// up logging, connects to the database and the message broker, and runs the service
+// until SIGINT or SIGTERM, then stops it in the reverse order it was started, so every
+// binary gets the same startup, reload and shutdown behavior.
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/config"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/logger"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/joho/godotenv"
+)
+
+// configPollInterval is how often the config file is checked for modifications
+const configPollInterval = 5 * time.Second
+
+// secretsTimeout bounds fetching secrets referenced from external stores
+const secretsTimeout = 10 * time.Second
+
+// Options describe the binary being bootstrapped
+type Options struct {
+	// Name is logged when the service starts and stops, e.g. "API service"
+	Name string
+	// ConfigEnv names the environment variable overriding DefaultConfigPath. Both are
+	// overridden by the -config flag.
+	ConfigEnv         string
+	DefaultConfigPath string
+	// Profile selects the config sections validated at startup
+	Profile config.Profile
+}
+
+// Hook is a step of the service lifecycle. Start runs when the service starts, in the
+// order hooks were added, and Stop runs on shutdown in the reverse order, so each step
+// stops before the steps it was built on. Either may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// App holds the config and shared resources of a service binary
+type App struct {
+	Name       string
+	Config     *config.Config
+	ConfigPath string
+	Logger     *logger.Logger
+	// DB and Broker are set by ConnectDatabase and ConnectBroker
+	DB     *postgresql.Client
+	Broker broker.Broker
+
+	profile         config.Profile
+	brokerPasswords passwordUpdater
+	reloaders       []config.ReloadFunc
+	hooks           []Hook
+	failed          chan error
+}
+
+// New loads the .env file, parses the command-line flags, loads, resolves and validates
+// the config and initializes the logger
+func New(opts Options) (*App, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables or flags")
+	}
+
+	defaultConfigPath := os.Getenv(opts.ConfigEnv)
+	if defaultConfigPath == "" {
+		defaultConfigPath = opts.DefaultConfigPath
+	}
+	configPath := flag.String("config", defaultConfigPath, "Path to configuration file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Fetch secrets referenced from external stores (e.g. vault:secret/data/jobs#db_password)
+	secretsCtx, cancelSecrets := context.WithTimeout(context.Background(), secretsTimeout)
+	err = config.ResolveSecrets(secretsCtx, cfg, nil)
+	cancelSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	if err := cfg.Validate(opts.Profile); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	appLogger, err := initLogger(&cfg.Logging, &cfg.App, opts.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	appLogger.Info("Starting "+opts.Name,
+		slog.String("app", cfg.App.Name),
+		slog.String("version", cfg.App.Version),
+		slog.String("environment", cfg.App.Environment),
+	)
+
+	a := newApp(opts.Name, cfg, appLogger)
+	a.ConfigPath = *configPath
+	a.profile = opts.Profile
+	return a, nil
+}
+
+func newApp(name string, cfg *config.Config, appLogger *logger.Logger) *App {
+	return &App{
+		Name:   name,
+		Config: cfg,
+		Logger: appLogger,
+		failed: make(chan error, 1),
+	}
+}
+
+// Append adds a hook to the lifecycle
+func (a *App) Append(hook Hook) {
+	a.hooks = append(a.hooks, hook)
+}
+
+// Go adds a background task to the lifecycle. It starts with the hooks added before it
+// and its context is canceled when it is stopped, after the hooks added after it.
+// Stopping waits for fn to return until the shutdown timeout.
+func (a *App) Go(name string, fn func(ctx context.Context)) {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	a.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("%s did not stop: %w", name, ctx.Err())
+			}
+		},
+	})
+}
+
+// OnReload adds fn to the functions applying a reloaded config, after the settings every
+// service reloads, such as the log level
+func (a *App) OnReload(fn config.ReloadFunc) {
+	a.reloaders = append(a.reloaders, fn)
+}
+
+// Fail shuts the service down after a component failed while it was running, such as
+// the HTTP server being unable to listen. Run returns err.
+func (a *App) Fail(err error) {
+	select {
+	case a.failed <- err:
+	default:
+		// Already shutting down for an earlier failure
+	}
+}
+
+// Run runs setup, which connects resources and adds hooks, then starts every hook and
+// waits for SIGINT, SIGTERM, a call to Fail or ctx to be done. It then stops the hooks
+// that were started, in reverse order and within server.shutdown_timeout, and closes
+// the logger last. The config file is watched for reloadable changes while it runs.
+func (a *App) Run(ctx context.Context, setup func(a *App) error) error {
+	// Deferred first so the log file is flushed after every other shutdown step
+	defer a.Logger.Close()
+
+	ctx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	err := setup(a)
+	if err == nil {
+		if a.ConfigPath != "" {
+			a.watchConfig()
+		}
+		err = a.wait(ctx)
+	}
+
+	a.Logger.Info("Shutting down " + a.Name)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
+	defer cancel()
+	if stopErr := a.stop(shutdownCtx); stopErr != nil {
+		err = errors.Join(err, stopErr)
+	}
+
+	if err != nil {
+		return err
+	}
+	a.Logger.Info(a.Name + " shutdown complete")
+	return nil
+}
+
+// wait starts the hooks and blocks until the service should shut down. Hooks that fail
+// to start are dropped, along with every hook after them, so only started hooks stop.
+func (a *App) wait(ctx context.Context) error {
+	for i, hook := range a.hooks {
+		if hook.Start == nil {
+			continue
+		}
+		if err := hook.Start(ctx); err != nil {
+			a.hooks = a.hooks[:i]
+			return fmt.Errorf("failed to start %s: %w", hook.Name, err)
+		}
+	}
+
+	a.Logger.Info(a.Name + " is running")
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-a.failed:
+		return err
+	}
+}
+
+// stop runs the Stop of every hook in reverse order and returns their errors
+func (a *App) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(a.hooks) - 1; i >= 0; i-- {
+		hook := a.hooks[i]
+		if hook.Stop == nil {
+			continue
+		}
+		if err := hook.Stop(ctx); err != nil {
+			a.Logger.Error("Failed to stop "+hook.Name, slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+		}
+	}
+	a.hooks = nil
+	return errors.Join(errs...)
+}
+
+// watchConfig reloads dynamic settings on SIGHUP or when the config file changes. It is
+// added last, so it stops before anything it reloads.
+func (a *App) watchConfig() {
+	watcher := config.NewWatcher(a.ConfigPath, a.profile, a.Config, a.Logger.Logger, configPollInterval, a.applyReload)
+	a.Go("config_watcher", watcher.Run)
+}
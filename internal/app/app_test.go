@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/config"
+	"github.com/cuongbtq/practice-be/shared/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp() *App {
+	cfg := config.Default()
+	cfg.Server.ShutdownTimeout = time.Second
+	return newApp("test service", cfg, logger.NewDefault())
+}
+
+// recordingHook appends "start <name>" and "stop <name>" to calls
+func recordingHook(name string, calls *[]string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			*calls = append(*calls, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			*calls = append(*calls, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestApp_Run(t *testing.T) {
+	t.Run("stops hooks in reverse order", func(t *testing.T) {
+		a := newTestApp()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls []string
+		err := a.Run(ctx, func(a *App) error {
+			a.Append(recordingHook("database", &calls, nil))
+			a.Append(Hook{Name: "resource", Stop: func(context.Context) error {
+				calls = append(calls, "stop resource")
+				return nil
+			}})
+			a.Append(recordingHook("server", &calls, nil))
+			a.Append(Hook{Name: "shutdown", Start: func(context.Context) error {
+				cancel()
+				return nil
+			}})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"start database", "start server", "stop server", "stop resource", "stop database"}, calls)
+	})
+
+	t.Run("stops only started hooks when one fails to start", func(t *testing.T) {
+		a := newTestApp()
+
+		var calls []string
+		err := a.Run(context.Background(), func(a *App) error {
+			a.Append(recordingHook("database", &calls, nil))
+			a.Append(recordingHook("server", &calls, errors.New("address in use")))
+			a.Append(recordingHook("watcher", &calls, nil))
+			return nil
+		})
+		assert.EqualError(t, err, "failed to start server: address in use")
+		assert.Equal(t, []string{"start database", "start server", "stop database"}, calls)
+	})
+
+	t.Run("stops what setup added when it fails", func(t *testing.T) {
+		a := newTestApp()
+		errBroker := errors.New("broker unreachable")
+
+		var calls []string
+		err := a.Run(context.Background(), func(a *App) error {
+			a.Append(recordingHook("database", &calls, nil))
+			return errBroker
+		})
+		assert.ErrorIs(t, err, errBroker)
+		assert.Equal(t, []string{"stop database"}, calls)
+	})
+
+	t.Run("shuts down on Fail", func(t *testing.T) {
+		a := newTestApp()
+		errListen := errors.New("listen tcp :8080: bind: address already in use")
+
+		var calls []string
+		err := a.Run(context.Background(), func(a *App) error {
+			a.Append(recordingHook("database", &calls, nil))
+			a.Append(Hook{Name: "server", Start: func(context.Context) error {
+				go a.Fail(errListen)
+				return nil
+			}})
+			return nil
+		})
+		assert.ErrorIs(t, err, errListen)
+		assert.Equal(t, []string{"start database", "stop database"}, calls)
+	})
+
+	t.Run("returns stop errors", func(t *testing.T) {
+		a := newTestApp()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := a.Run(ctx, func(a *App) error {
+			a.Append(Hook{Name: "broker", Stop: func(context.Context) error { return errors.New("channel closed") }})
+			return nil
+		})
+		assert.EqualError(t, err, "failed to stop broker: channel closed")
+	})
+}
+
+func TestApp_Go(t *testing.T) {
+	t.Run("cancels the task and waits for it", func(t *testing.T) {
+		a := newTestApp()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		stopped := false
+		err := a.Run(ctx, func(a *App) error {
+			a.Go("resolver", func(ctx context.Context) {
+				cancel()
+				<-ctx.Done()
+				stopped = true
+			})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, stopped)
+	})
+
+	t.Run("gives up at the shutdown timeout", func(t *testing.T) {
+		a := newTestApp()
+		a.Config.Server.ShutdownTimeout = 10 * time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		defer close(release)
+
+		err := a.Run(ctx, func(a *App) error {
+			a.Go("stuck", func(context.Context) {
+				cancel()
+				<-release
+			})
+			return nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "stuck did not stop")
+	})
+}
+
+func TestApp_OnReload(t *testing.T) {
+	a := newTestApp()
+
+	var applied []string
+	a.OnReload(func(cfg *config.Config, changes config.Changes) error {
+		applied = append(applied, changes.Reloadable...)
+		return nil
+	})
+	a.OnReload(func(*config.Config, config.Changes) error {
+		return errors.New("maintenance: boom")
+	})
+
+	newCfg := config.Default()
+	newCfg.Maintenance.Enabled = true
+	err := a.applyReload(newCfg, config.Diff(a.Config, newCfg))
+	assert.ErrorContains(t, err, "maintenance: boom")
+	assert.Equal(t, []string{"maintenance.enabled"}, applied)
+}
+
+func TestApp_DegradedStart(t *testing.T) {
+	newUnreachableApp := func(allowDegraded bool) *App {
+		a := newTestApp()
+		a.Config.Startup.AllowDegraded = allowDegraded
+		a.Config.Database.Host = "127.0.0.1"
+		a.Config.Database.Port = 1
+		a.Config.Database.Connect.WaitTimeout = 0
+		a.Config.RabbitMQ.Host = "127.0.0.1"
+		a.Config.RabbitMQ.Port = 1
+		a.Config.RabbitMQ.Connection.RetryAttempts = 1
+		return a
+	}
+
+	t.Run("unreachable dependencies fail startup by default", func(t *testing.T) {
+		a := newUnreachableApp(false)
+		assert.ErrorContains(t, a.ConnectDatabase(), "failed to initialize database")
+		assert.ErrorContains(t, a.ConnectBroker(), "failed to initialize")
+	})
+
+	t.Run("starts without unreachable dependencies", func(t *testing.T) {
+		a := newUnreachableApp(true)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var names []string
+		err := a.Run(ctx, func(a *App) error {
+			require.NoError(t, a.ConnectDatabase())
+			require.NoError(t, a.ConnectBroker())
+			require.NotNil(t, a.DB)
+			require.NotNil(t, a.Broker)
+			assert.False(t, a.Broker.IsConnected())
+
+			for _, hook := range a.hooks {
+				names = append(names, hook.Name)
+			}
+			a.Append(Hook{Name: "shutdown", Start: func(context.Context) error {
+				cancel()
+				return nil
+			}})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database", "database_connect", "broker", "broker_connect"}, names)
+	})
+}
+
+func TestApp_Reconnect(t *testing.T) {
+	a := newTestApp()
+	a.Config.Startup.RetryInterval = time.Millisecond
+	a.Config.Startup.MaxRetryInterval = 2 * time.Millisecond
+
+	attempts := 0
+	a.reconnect(context.Background(), "database", func(context.Context) error {
+		attempts++
+		if attempts < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	assert.Equal(t, 4, attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	a.reconnect(ctx, "broker", func(context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	})
+	assert.Equal(t, 1, attempts, "a canceled reconnect stops after the failed attempt")
+}
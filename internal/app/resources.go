@@ -0,0 +1,401 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/config"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/kafka"
+	"github.com/cuongbtq/practice-be/shared/logger"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/cuongbtq/practice-be/shared/rabbitmq"
+)
+
+// passwordRotateTimeout bounds connecting with a rotated database password
+const passwordRotateTimeout = 30 * time.Second
+
+// pingTimeout bounds each background attempt to reach the database
+const pingTimeout = 5 * time.Second
+
+// passwordUpdater is implemented by brokers that can switch to rotated credentials
+type passwordUpdater interface {
+	UpdatePassword(password string) error
+}
+
+// ConnectDatabase connects to PostgreSQL and closes the connection on shutdown, after
+// the hooks added later have stopped. With startup.allow_degraded, an unreachable
+// database does not fail startup: queries fail until a background task reconnects.
+func (a *App) ConnectDatabase() error {
+	dbConfig := postgresConfig(&a.Config.Database, &a.Config.Chaos)
+	dbClient, err := postgresql.NewClient(dbConfig, a.Logger.Logger)
+	if err != nil {
+		if !a.Config.Startup.AllowDegraded {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+		a.Logger.Warn("Starting without the database, connecting in the background", slog.Any("error", err))
+		dbClient = postgresql.Open(dbConfig, a.Logger.Logger)
+	}
+	a.DB = dbClient
+	a.Append(Hook{
+		Name: "database",
+		Stop: func(context.Context) error {
+			return dbClient.Close()
+		},
+	})
+
+	if err != nil {
+		// Added after the close hook, so it stops first
+		a.Go("database_connect", func(ctx context.Context) {
+			a.reconnect(ctx, "database", func(ctx context.Context) error {
+				ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+				defer cancel()
+				return dbClient.Ping(ctx)
+			})
+		})
+		return nil
+	}
+
+	a.Logger.Info("Database connection established")
+	return nil
+}
+
+// ConnectBroker connects to the message broker selected by broker.type, wrapped in fault
+// injection when chaos mode is enabled, and closes it on shutdown, after the hooks added
+// later have stopped. With startup.allow_degraded, an unreachable broker does not fail
+// startup: publishes fail with broker.ErrNotConnected until a background task connects.
+func (a *App) ConnectBroker() error {
+	cfg := a.Config
+	jobBroker, err := initBroker(cfg, a.Logger.Logger)
+	if err != nil {
+		if !cfg.Startup.AllowDegraded {
+			return fmt.Errorf("failed to initialize %s broker: %w", cfg.Broker.Type, err)
+		}
+		a.Logger.Warn("Starting without the broker, connecting in the background",
+			slog.String("broker", cfg.Broker.Type),
+			slog.Any("error", err),
+		)
+
+		lazy := broker.NewLazy()
+		jobBroker = lazy
+		a.brokerPasswords = lazyPasswords{lazy}
+		a.Append(Hook{
+			Name: "broker",
+			Stop: func(context.Context) error {
+				return lazy.Close()
+			},
+		})
+		a.Go("broker_connect", func(ctx context.Context) {
+			a.reconnect(ctx, "broker", func(context.Context) error {
+				connected, err := initBroker(cfg, a.Logger.Logger)
+				if err != nil {
+					return err
+				}
+				lazy.Set(connected)
+				return nil
+			})
+		})
+	} else {
+		a.Append(Hook{
+			Name: "broker",
+			Stop: func(context.Context) error {
+				return jobBroker.Close()
+			},
+		})
+
+		a.Logger.Info("Broker connection established", slog.String("broker", cfg.Broker.Type))
+
+		// Kept before chaos wrapping, which hides the concrete broker
+		a.brokerPasswords, _ = jobBroker.(passwordUpdater)
+	}
+
+	if cfg.Chaos.Enabled {
+		a.Logger.Warn("Chaos mode is enabled, broker and database faults are injected",
+			slog.Float64("publish_drop_rate", cfg.Chaos.PublishDropRate),
+			slog.Float64("publish_failure_rate", cfg.Chaos.PublishFailureRate),
+			slog.Duration("query_delay", cfg.Chaos.QueryDelay),
+			slog.Float64("query_delay_rate", cfg.Chaos.QueryDelayRate),
+		)
+		jobBroker = broker.NewChaos(jobBroker, broker.ChaosOptions{
+			PublishDropRate:    cfg.Chaos.PublishDropRate,
+			PublishFailureRate: cfg.Chaos.PublishFailureRate,
+		})
+	}
+
+	a.Broker = jobBroker
+	return nil
+}
+
+// reconnect calls connect until it succeeds or ctx is done, waiting startup.retry_interval
+// after the first failure and twice as long after each further one, up to
+// startup.max_retry_interval
+func (a *App) reconnect(ctx context.Context, dependency string, connect func(ctx context.Context) error) {
+	delay := a.Config.Startup.RetryInterval
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			a.Logger.Info("Connection established in the background",
+				slog.String("dependency", dependency),
+				slog.Int("attempt", attempt),
+			)
+			return
+		}
+		a.Logger.Warn("Background connection failed",
+			slog.String("dependency", dependency),
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, a.Config.Startup.MaxRetryInterval)
+	}
+}
+
+// lazyPasswords rotates the password of a broker connected in the background. Before it
+// connects there is nothing to rotate: it will connect with the password in the config.
+type lazyPasswords struct {
+	lazy *broker.Lazy
+}
+
+func (p lazyPasswords) UpdatePassword(password string) error {
+	if updater, ok := p.lazy.Unwrap().(passwordUpdater); ok {
+		return updater.UpdatePassword(password)
+	}
+	return nil
+}
+
+// applyReload applies reloaded settings: the log level and rotated database and broker
+// passwords, for the resources that are connected
+func (a *App) applyReload(newCfg *config.Config, changes config.Changes) error {
+	if err := a.Logger.SetLevel(newCfg.Logging.Level); err != nil {
+		a.Logger.Warn("Ignoring reloaded log level", slog.String("error", err.Error()))
+	}
+
+	var errs []error
+	if slices.Contains(changes.Reloadable, "database.password") && a.DB != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordRotateTimeout)
+		defer cancel()
+		if err := a.DB.UpdatePassword(ctx, newCfg.Database.Password); err != nil {
+			errs = append(errs, fmt.Errorf("database password: %w", err))
+		}
+	}
+	if slices.Contains(changes.Reloadable, "rabbitmq.password") && a.brokerPasswords != nil {
+		if err := a.brokerPasswords.UpdatePassword(newCfg.RabbitMQ.Password); err != nil {
+			errs = append(errs, fmt.Errorf("rabbitmq password: %w", err))
+		}
+	}
+	for _, reload := range a.reloaders {
+		if err := reload(newCfg, changes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// initLogger initializes and configures the application logger
+func initLogger(cfg *config.LoggingConfig, appCfg *config.AppConfig, service string) (*logger.Logger, error) {
+	environment := cfg.ErrorReporting.Environment
+	if environment == "" {
+		environment = appCfg.Environment
+	}
+
+	loggerCfg := &logger.Config{
+		Level:     cfg.Level,
+		Format:    cfg.Format,
+		Output:    cfg.Output,
+		TeeStdout: cfg.TeeStdout,
+		Rotation: logger.RotationConfig{
+			MaxSizeMB:  cfg.Rotation.MaxSizeMB,
+			MaxBackups: cfg.Rotation.MaxBackups,
+			MaxAge:     cfg.Rotation.MaxAge,
+			Compress:   cfg.Rotation.Compress,
+		},
+		RedactKeys: cfg.Redact,
+		Sampling: logger.SamplingConfig{
+			Initial:    cfg.Sampling.Initial,
+			Thereafter: cfg.Sampling.Thereafter,
+			Interval:   cfg.Sampling.Interval,
+		},
+		Reporting: logger.ReportConfig{
+			DSN:          cfg.ErrorReporting.DSN,
+			Environment:  environment,
+			Release:      appCfg.Version,
+			Service:      service,
+			RateLimit:    cfg.ErrorReporting.RateLimit,
+			RateInterval: cfg.ErrorReporting.RateInterval,
+		},
+		EnableSource: cfg.EnableCaller,
+		TimeFormat:   time.RFC3339,
+	}
+
+	return logger.New(loggerCfg)
+}
+
+// postgresConfig converts the database config, adding injected query delays in chaos mode
+func postgresConfig(cfg *config.DatabaseConfig, chaos *config.ChaosConfig) *postgresql.Config {
+	dbConfig := &postgresql.Config{
+		Host:               cfg.Host,
+		Port:               cfg.Port,
+		User:               cfg.User,
+		Password:           cfg.Password,
+		Database:           cfg.Database,
+		SSLMode:            cfg.SSLMode,
+		SSLRootCert:        cfg.SSLRootCert,
+		SSLCert:            cfg.SSLCert,
+		SSLKey:             cfg.SSLKey,
+		MaxOpenConns:       cfg.MaxOpenConns,
+		MinIdleConns:       cfg.MinIdleConns,
+		ConnMaxLifetime:    cfg.ConnMaxLifetime,
+		ConnMaxIdleTime:    cfg.ConnMaxIdleTime,
+		SlowQueryThreshold: cfg.SlowQueryThreshold,
+		QueryTimeout:       cfg.QueryTimeout,
+
+		StatementCacheCapacity: cfg.StatementCacheCapacity,
+
+		ConnectTimeout:          cfg.Connect.WaitTimeout,
+		ConnectRetryInterval:    cfg.Connect.RetryInterval,
+		ConnectMaxRetryInterval: cfg.Connect.MaxRetryInterval,
+	}
+
+	if chaos.Enabled {
+		dbConfig.InjectedDelay = chaos.QueryDelay
+		dbConfig.InjectedDelayRate = chaos.QueryDelayRate
+	}
+
+	return dbConfig
+}
+
+// initBroker connects to the message broker selected by broker.type
+func initBroker(cfg *config.Config, logger *slog.Logger) (broker.Broker, error) {
+	switch cfg.Broker.Type {
+	case "", config.BrokerRabbitMQ:
+		var eventsExchange string
+		if cfg.Events.Enabled {
+			eventsExchange = cfg.Events.Exchange
+		}
+		client, err := initRabbitMQ(&cfg.RabbitMQ, eventsExchange, logger)
+		if err != nil {
+			return nil, err
+		}
+		return rabbitmq.NewBroker(client), nil
+	case config.BrokerKafka:
+		var eventsTopic string
+		if cfg.Events.Enabled {
+			eventsTopic = cfg.Events.Exchange
+		}
+		return initKafka(&cfg.Kafka, eventsTopic, logger)
+	case config.BrokerMemory:
+		logger.Warn("Using the in-memory broker, messages are lost on restart and only reach consumers in this process")
+		return broker.NewMemory(broker.MemoryOptions{
+			QueueSize:          cfg.Broker.Memory.QueueSize,
+			DeliveryDelay:      cfg.Broker.Memory.DeliveryDelay,
+			PublishFailureRate: cfg.Broker.Memory.PublishFailureRate,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported broker type %q", cfg.Broker.Type)
+	}
+}
+
+// initKafka connects to Kafka. A non-empty eventsTopic receives job lifecycle events.
+func initKafka(cfg *config.KafkaConfig, eventsTopic string, logger *slog.Logger) (*kafka.Broker, error) {
+	return kafka.NewBroker(&kafka.Config{
+		Brokers:         cfg.Brokers,
+		Topic:           cfg.Topic,
+		GroupID:         cfg.GroupID,
+		DeadLetterTopic: cfg.DeadLetterTopic,
+		EventsTopic:     eventsTopic,
+		MaxMessageBytes: cfg.MaxMessageBytes,
+		WriteTimeout:    cfg.WriteTimeout,
+		DialTimeout:     cfg.DialTimeout,
+		TLS: kafka.TLSConfig{
+			Enabled:            cfg.TLS.Enabled,
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			ServerName:         cfg.TLS.ServerName,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		},
+		SASL: kafka.SASLConfig{
+			Mechanism: cfg.SASL.Mechanism,
+			Username:  cfg.SASL.Username,
+			Password:  cfg.SASL.Password,
+		},
+	}, logger)
+}
+
+// compressionConfig converts the configured compression, where none disables it
+func compressionConfig(cfg config.MessageCompressionConfig) rabbitmq.CompressionConfig {
+	algorithm := cfg.Algorithm
+	if algorithm == "none" {
+		algorithm = rabbitmq.CompressionNone
+	}
+	return rabbitmq.CompressionConfig{Algorithm: algorithm, MinBytes: cfg.MinBytes}
+}
+
+// initRabbitMQ initializes the RabbitMQ client. A non-empty eventsExchange is declared
+// for publishing job lifecycle events.
+func initRabbitMQ(cfg *config.RabbitMQConfig, eventsExchange string, logger *slog.Logger) (*rabbitmq.Client, error) {
+	rabbitConfig := &rabbitmq.Config{
+		Host:               cfg.Host,
+		Port:               cfg.Port,
+		User:               cfg.User,
+		Password:           cfg.Password,
+		VHost:              cfg.VHost,
+		ExchangeName:       cfg.Exchange.Name,
+		ExchangeType:       cfg.Exchange.Type,
+		ExchangeDurable:    cfg.Exchange.Durable,
+		ExchangeAutoDelete: cfg.Exchange.AutoDelete,
+		ExchangeArguments:  cfg.Exchange.Arguments,
+		QueueName:          cfg.Queue.Name,
+		QueueDurable:       cfg.Queue.Durable,
+		QueueAutoDelete:    cfg.Queue.AutoDelete,
+		QueueExclusive:     cfg.Queue.Exclusive,
+		QueueSingleActive:  cfg.Queue.SingleActiveConsumer,
+		QueueArguments:     cfg.Queue.Arguments,
+		RoutingKey:         cfg.RoutingKey,
+		Partitions:         cfg.Partitions,
+		Shards:             cfg.Sharding.Shards,
+		ShardField:         cfg.Sharding.Key,
+		ShardRoutingKey:    cfg.ShardRoutingKey(),
+		ConsumeShards:      cfg.Sharding.Consume,
+		MaxMessageBytes:    cfg.MaxMessageBytes,
+		Compression:        compressionConfig(cfg.Compression),
+		PrefetchCount:      cfg.Consumer.PrefetchCount,
+		ConsumerAutoAck:    cfg.Consumer.AutoAck,
+		ConsumerExclusive:  cfg.Consumer.Exclusive,
+		RetryAttempts:      cfg.Connection.RetryAttempts,
+		RetryInterval:      cfg.Connection.RetryInterval,
+		Heartbeat:          cfg.Connection.Heartbeat,
+		ConnectionTimeout:  cfg.Connection.ConnectionTimeout,
+		PublisherChannels:  cfg.Connection.PublisherChannels,
+		EventsExchange:     eventsExchange,
+		TLS: rabbitmq.TLSConfig{
+			Enabled:            cfg.TLS.Enabled,
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			ServerName:         cfg.TLS.ServerName,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		},
+	}
+	// Every queue is declared, so jobs routed to a queue wait there until a worker consumes it
+	for _, queue := range cfg.Queues {
+		rabbitConfig.Queues = append(rabbitConfig.Queues, rabbitmq.QueueBinding{
+			Name:        queue.Name,
+			RoutingKey:  queue.RoutingKey,
+			Prefetch:    queue.PrefetchCount,
+			Concurrency: queue.Concurrency,
+		})
+	}
+
+	return rabbitmq.NewClient(rabbitConfig, logger)
+}
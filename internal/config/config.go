@@ -1,10 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/cuongbtq/practice-be/internal/api/tenant"
+	"github.com/cuongbtq/practice-be/shared/logger"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,15 +20,61 @@ const (
 	MinPort = 1
 	// MaxPort is the maximum valid port number
 	MaxPort = 65535
+	// MinCursorKeyBytes is the minimum length of server.cursor_key, the size of an HMAC-SHA256 output
+	MinCursorKeyBytes = 32
 )
 
+// Broker types accepted by broker.type
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	// BrokerKafka publishes to and consumes from a Kafka topic, configured under kafka
+	BrokerKafka = "kafka"
+	// BrokerMemory keeps messages in process, for local development and tests
+	BrokerMemory = "memory"
+)
+
+// Job creation modes accepted by ingestion.mode
+const (
+	// IngestionSync answers job creation with 201 Created
+	IngestionSync = "sync"
+	// IngestionAsync answers job creation with 202 Accepted and a Location header
+	IngestionAsync = "async"
+)
+
+// RetentionWindowLayout is the time.Parse layout of retention window_start and window_end
+const RetentionWindowLayout = "15:04"
+
 // Config represents the complete application configuration
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	RabbitMQ RabbitMQConfig `yaml:"rabbitmq"`
-	Logging  LoggingConfig  `yaml:"logging"`
-	App      AppConfig      `yaml:"app"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Broker     BrokerConfig     `yaml:"broker"`
+	RabbitMQ   RabbitMQConfig   `yaml:"rabbitmq"`
+	Kafka      KafkaConfig      `yaml:"kafka"`
+	Logging    LoggingConfig    `yaml:"logging"`
+	App        AppConfig        `yaml:"app"`
+	Estimation EstimationConfig `yaml:"estimation"`
+	JobHealth  JobHealthConfig  `yaml:"job_health"`
+	Secrets    SecretsConfig    `yaml:"secrets"`
+	Policies   PoliciesConfig   `yaml:"policies"`
+	Results    ResultsConfig    `yaml:"results"`
+	Payloads   PayloadsConfig   `yaml:"payloads"`
+	Chaining   ChainingConfig   `yaml:"chaining"`
+	Retention  RetentionConfig  `yaml:"retention"`
+	Tenancy    TenancyConfig    `yaml:"tenancy"`
+	Events     EventsConfig     `yaml:"events"`
+
+	LeaderElection LeaderElectionConfig `yaml:"leader_election"`
+
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
+
+	Chaos ChaosConfig `yaml:"chaos"`
+
+	Validation ValidationConfig `yaml:"validation"`
+
+	Ingestion IngestionConfig `yaml:"ingestion"`
+
+	Startup StartupConfig `yaml:"startup"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -31,33 +84,219 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	IdleTimeout     time.Duration `yaml:"idle_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	AdminToken      string        `yaml:"admin_token" secret:"true"` // Bearer token for /admin routes, required in production
+	// CursorKey signs list cursors with HMAC-SHA256 so clients cannot forge them; empty
+	// leaves cursors unsigned. Changing it invalidates the cursors clients hold.
+	CursorKey     string `yaml:"cursor_key" secret:"true"`
+	CursorKeyFile string `yaml:"cursor_key_file"`
+
+	Compression CompressionConfig   `yaml:"compression"`
+	CORS        CORSConfig          `yaml:"cors"`
+	Limits      RequestLimitsConfig `yaml:"limits"`
+}
+
+// RequestLimitsConfig bounds the size and handling time of API requests
+type RequestLimitsConfig struct {
+	// MaxBodyBytes rejects larger request bodies with 413. 0 derives the limit from the
+	// job payload limit.
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// HandlerTimeout is the deadline of each request's context; requests still running
+	// after it get 408. 0 disables the deadline.
+	HandlerTimeout time.Duration `yaml:"handler_timeout"`
+	// RouteTimeouts overrides HandlerTimeout for routes keyed like "GET /api/v1/jobs/:job_id".
+	// 0 disables the deadline for that route.
+	RouteTimeouts map[string]time.Duration `yaml:"route_timeouts" env:"-"`
+}
+
+// CORSConfig controls which browser origins may call the API. With no origins listed,
+// cross-origin browser requests are refused.
+type CORSConfig struct {
+	// AllowAllOrigins allows every origin without credentials, for local development only
+	AllowAllOrigins  bool          `yaml:"allow_all_origins"`
+	AllowedOrigins   []string      `yaml:"allowed_origins"` // e.g. https://app.example.com
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"` // Cookies and Authorization headers from allowed origins
+	MaxAge           time.Duration `yaml:"max_age"`           // How long browsers cache preflight responses
+}
+
+// CompressionConfig controls gzip compression of HTTP responses
+type CompressionConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	MinSize       int      `yaml:"min_size"`       // Responses smaller than this many bytes are not compressed
+	ExcludedPaths []string `yaml:"excluded_paths"` // Path prefixes never compressed
 }
 
 // DatabaseConfig holds PostgreSQL connection configuration
 type DatabaseConfig struct {
-	Host            string        `yaml:"host"`
-	Port            int           `yaml:"port"`
-	User            string        `yaml:"user"`
-	Password        string        `yaml:"password"`
-	Database        string        `yaml:"database"`
-	SSLMode         string        `yaml:"sslmode"`
-	MaxOpenConns    int           `yaml:"max_open_conns"`
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	User         string `yaml:"user"`
+	Password     string `yaml:"password" secret:"true"`
+	PasswordFile string `yaml:"password_file"`
+	Database     string `yaml:"database" env:"DATABASE_NAME"`
+	SSLMode      string `yaml:"sslmode"`     // disable, require, verify-ca or verify-full
+	SSLRootCert  string `yaml:"sslrootcert"` // CA bundle for verify-ca and verify-full
+	SSLCert      string `yaml:"sslcert"`     // Client certificate, needs sslkey
+	SSLKey       string `yaml:"sslkey"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
+	// MinIdleConns is how many idle connections the pool keeps open ahead of demand
+	MinIdleConns int `yaml:"min_idle_conns"`
+	// MaxIdleConns is no longer supported: the pool closes idle connections after
+	// ConnMaxIdleTime instead of capping them. Validate rejects it so it is not
+	// mistaken for MinIdleConns.
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// StatementCacheCapacity is how many prepared statements each connection caches, 0
+	// disables the cache, e.g. behind PgBouncer in transaction mode
+	StatementCacheCapacity int `yaml:"statement_cache_capacity"`
+	// SlowQueryThreshold logs queries that take at least this long, 0 disables the log
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// QueryTimeout bounds each query run through postgresql.Client, 0 disables the timeout
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	// Connect controls how long startup waits for the database to accept connections
+	Connect DatabaseConnectConfig `yaml:"connect"`
+}
+
+// DatabaseConnectConfig controls the wait for PostgreSQL at startup, e.g. while it is still
+// starting next to the services in docker-compose
+type DatabaseConnectConfig struct {
+	WaitTimeout      time.Duration `yaml:"wait_timeout"`       // Total wait before startup fails, 0 tries once
+	RetryInterval    time.Duration `yaml:"retry_interval"`     // Delay after the first failed attempt, doubled after each further one
+	MaxRetryInterval time.Duration `yaml:"max_retry_interval"` // Longest delay between attempts
+}
+
+// BrokerConfig selects the message broker job messages go through
+type BrokerConfig struct {
+	Type string `yaml:"type"` // rabbitmq or kafka, configured in their own sections, or memory
+	// Encoding of published job messages: json or protobuf. Consumers decode each message
+	// by its content type, so they must be upgraded before publishers switch encodings.
+	Encoding string             `yaml:"encoding"`
+	Memory   MemoryBrokerConfig `yaml:"memory"`
+}
+
+// MemoryBrokerConfig holds settings for the in-process broker
+type MemoryBrokerConfig struct {
+	QueueSize          int           `yaml:"queue_size"`           // Messages waiting before publishing fails, 0 uses 10000
+	DeliveryDelay      time.Duration `yaml:"delivery_delay"`       // How long messages wait before delivery
+	PublishFailureRate float64       `yaml:"publish_failure_rate"` // Fraction of publishes that fail
 }
 
 // RabbitMQConfig holds RabbitMQ connection and exchange/queue configuration
 type RabbitMQConfig struct {
-	Host       string           `yaml:"host"`
-	Port       int              `yaml:"port"`
-	User       string           `yaml:"user"`
-	Password   string           `yaml:"password"`
-	VHost      string           `yaml:"vhost"`
-	Exchange   ExchangeConfig   `yaml:"exchange"`
-	Queue      QueueConfig      `yaml:"queue"`
-	RoutingKey string           `yaml:"routing_key"`
-	Connection ConnectionConfig `yaml:"connection"`
+	Host         string         `yaml:"host"`
+	Port         int            `yaml:"port"`
+	User         string         `yaml:"user"`
+	Password     string         `yaml:"password" secret:"true"`
+	PasswordFile string         `yaml:"password_file"`
+	VHost        string         `yaml:"vhost"`
+	Exchange     ExchangeConfig `yaml:"exchange"`
+	Queue        QueueConfig    `yaml:"queue"`
+	RoutingKey   string         `yaml:"routing_key"`
+	// Partitions > 0 spreads jobs over queue.name.0 .. queue.name.N-1 by ordering key through an
+	// x-consistent-hash exchange (rabbitmq_consistent_hash_exchange plugin)
+	Partitions int `yaml:"partitions"`
+	// Sharding spreads jobs over queue.name.shard.0 .. N-1 by user or job type, so each
+	// worker can consume a subset of the shards
+	Sharding ShardingConfig `yaml:"sharding"`
+	// MaxMessageBytes should not exceed the broker's max_message_size (128 MiB by default)
+	MaxMessageBytes int                      `yaml:"max_message_bytes"`
+	Compression     MessageCompressionConfig `yaml:"compression"`
+	Connection      ConnectionConfig         `yaml:"connection"`
+	Consumer        ConsumerConfig           `yaml:"consumer"`
+	TLS             TLSConfig                `yaml:"tls"`
+	// Queues are bound next to queue.name so one worker can serve several queues
+	// (e.g. jobs.high, jobs.low) with separate pools. Not overridable from the environment.
+	Queues []QueueBindingConfig `yaml:"queues" env:"-"`
+}
+
+// KafkaConfig holds Kafka connection and topic settings, used with broker.type kafka
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"` // Bootstrap brokers as host:port
+	Topic   string   `yaml:"topic"`   // Topic job messages are published to; it must already exist
+	GroupID string   `yaml:"group_id"`
+	// DeadLetterTopic receives messages consumers reject without requeueing, empty drops them
+	DeadLetterTopic string        `yaml:"dead_letter_topic"`
+	MaxMessageBytes int           `yaml:"max_message_bytes"` // Should not exceed the topic's max.message.bytes
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	DialTimeout     time.Duration `yaml:"dial_timeout"`
+	TLS             TLSConfig     `yaml:"tls"`
+	SASL            SASLConfig    `yaml:"sasl"`
+}
+
+// SASLConfig authenticates Kafka connections
+type SASLConfig struct {
+	Mechanism    string `yaml:"mechanism"` // plain, scram-sha-256 or scram-sha-512; empty disables SASL
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password" secret:"true"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+// MessageCompressionConfig compresses published message bodies. Consumers decompress
+// any supported content-encoding whatever this is set to, so it can be changed one
+// service at a time.
+type MessageCompressionConfig struct {
+	Algorithm string `yaml:"algorithm"` // none or gzip
+	MinBytes  int    `yaml:"min_bytes"` // Smaller bodies are sent uncompressed
+}
+
+// Job message fields jobs can be sharded by
+const (
+	ShardKeyUserID  = "user_id"
+	ShardKeyJobType = "job_type"
+)
+
+// ShardingConfig routes each job to one of Shards queues picked by consistent hashing of
+// its user or job type
+type ShardingConfig struct {
+	Shards int    `yaml:"shards"` // 0 disables sharding
+	Key    string `yaml:"key"`    // user_id or job_type
+	// RoutingKey is the routing key template jobs are published with, {shard} is replaced
+	// by the shard number. Empty uses routing_key + ".{shard}".
+	RoutingKey string `yaml:"routing_key"`
+	// Consume lists the shards rabbitmq.Client.Consume reads, empty reads every shard
+	Consume []int `yaml:"consume" env:"-"`
+}
+
+// ShardRoutingKey returns the routing key template of the shards
+func (c RabbitMQConfig) ShardRoutingKey() string {
+	if c.Sharding.RoutingKey != "" {
+		return c.Sharding.RoutingKey
+	}
+	return c.RoutingKey + ".{shard}"
+}
+
+// MaxMessageBytes returns the message size limit of the broker selected by broker.type,
+// 0 if it has none. The memory broker has no limit of its own and keeps the RabbitMQ one.
+func (c *Config) MaxMessageBytes() int {
+	if c.Broker.Type == BrokerKafka {
+		if c.Kafka.MaxMessageBytes == 0 {
+			return 1 << 20 // Kafka's default message.max.bytes
+		}
+		return c.Kafka.MaxMessageBytes
+	}
+	return c.RabbitMQ.MaxMessageBytes
+}
+
+// TLSConfig enables TLS for a connection. File paths point at PEM files.
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CAFile   string `yaml:"ca_file"`   // CA bundle the server certificate is verified against, empty uses the system roots
+	CertFile string `yaml:"cert_file"` // Client certificate, needs key_file
+	KeyFile  string `yaml:"key_file"`
+	// ServerName is checked against the server certificate, empty uses the host
+	ServerName string `yaml:"server_name"`
+	// InsecureSkipVerify accepts any server certificate; refused in production
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// QueueBindingConfig is an extra queue with its own routing key and consumer pool
+type QueueBindingConfig struct {
+	Name          string `yaml:"name"`
+	RoutingKey    string `yaml:"routing_key"`
+	PrefetchCount int    `yaml:"prefetch_count"` // 0 uses consumer.prefetch_count
+	Concurrency   int    `yaml:"concurrency"`    // Deliveries processed in parallel, 0 means 1
 }
 
 // ExchangeConfig holds RabbitMQ exchange configuration
@@ -66,6 +305,9 @@ type ExchangeConfig struct {
 	Type       string `yaml:"type"`
 	Durable    bool   `yaml:"durable"`
 	AutoDelete bool   `yaml:"auto_delete"`
+	// Arguments are passed when declaring the exchange, e.g. alternate-exchange. Not
+	// overridable from the environment.
+	Arguments map[string]any `yaml:"arguments" env:"-"`
 }
 
 // QueueConfig holds RabbitMQ queue configuration
@@ -74,6 +316,13 @@ type QueueConfig struct {
 	Durable    bool   `yaml:"durable"`
 	AutoDelete bool   `yaml:"auto_delete"`
 	Exclusive  bool   `yaml:"exclusive"`
+	// SingleActiveConsumer delivers to one consumer at a time, failing over to the next
+	// when it disconnects, so messages are processed strictly in order
+	SingleActiveConsumer bool `yaml:"single_active_consumer"`
+	// Arguments are passed when declaring the queue, its partitions and the extra queues,
+	// e.g. x-queue-type: quorum, x-message-ttl or x-max-length. Not overridable from the
+	// environment.
+	Arguments map[string]any `yaml:"arguments" env:"-"`
 }
 
 // ConnectionConfig holds RabbitMQ connection settings
@@ -82,15 +331,253 @@ type ConnectionConfig struct {
 	RetryInterval     time.Duration `yaml:"retry_interval"`
 	Heartbeat         time.Duration `yaml:"heartbeat"`
 	ConnectionTimeout time.Duration `yaml:"connection_timeout"`
+	PublisherChannels int           `yaml:"publisher_channels"` // Concurrent publishes, 0 uses 4
+}
+
+// ConsumerConfig holds RabbitMQ consumer settings
+type ConsumerConfig struct {
+	PrefetchCount int  `yaml:"prefetch_count"` // Unacknowledged deliveries per consumer
+	AutoAck       bool `yaml:"auto_ack"`
+	Exclusive     bool `yaml:"exclusive"`
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level            string `yaml:"level"`
-	Format           string `yaml:"format"`
-	Output           string `yaml:"output"`
-	EnableCaller     bool   `yaml:"enable_caller"`
-	EnableStackTrace bool   `yaml:"enable_stack_trace"`
+	Level            string            `yaml:"level"`
+	Format           string            `yaml:"format"`
+	Output           string            `yaml:"output"`     // stdout, stderr or a file path
+	TeeStdout        bool              `yaml:"tee_stdout"` // Also log to stdout when output is a file
+	Rotation         LogRotationConfig `yaml:"rotation"`
+	Redact           []string          `yaml:"redact"` // Attribute keys to mask in addition to the built-in list
+	Sampling         LogSamplingConfig `yaml:"sampling"`
+	EnableCaller     bool              `yaml:"enable_caller"`
+	EnableStackTrace bool              `yaml:"enable_stack_trace"`
+
+	ErrorReporting LogErrorReportingConfig `yaml:"error_reporting"`
+}
+
+// LogErrorReportingConfig forwards error records, with their attributes and stack, to Sentry
+type LogErrorReportingConfig struct {
+	DSN          string        `yaml:"dsn" secret:"true"` // Empty disables reporting
+	Environment  string        `yaml:"environment"`       // Defaults to app.environment
+	RateLimit    int           `yaml:"rate_limit"`        // Events per rate_interval, the rest are dropped
+	RateInterval time.Duration `yaml:"rate_interval"`
+}
+
+// LogSamplingConfig limits repeated debug messages: per interval, the first Initial
+// records with the same message are logged, then every Thereafter-th one
+type LogSamplingConfig struct {
+	Initial    int           `yaml:"initial"` // 0 disables sampling
+	Thereafter int           `yaml:"thereafter"`
+	Interval   time.Duration `yaml:"interval"`
+}
+
+// LogRotationConfig holds size-based rotation settings for file output
+type LogRotationConfig struct {
+	MaxSizeMB  int           `yaml:"max_size_mb"` // 0 disables rotation
+	MaxBackups int           `yaml:"max_backups"` // 0 keeps all rotated files
+	MaxAge     time.Duration `yaml:"max_age"`     // 0 keeps rotated files regardless of age
+	Compress   bool          `yaml:"compress"`
+}
+
+// EstimationConfig holds settings for job duration and queue wait estimates
+type EstimationConfig struct {
+	HistoryWindow  time.Duration `yaml:"history_window"`  // How far back completed jobs are sampled
+	WorkerCapacity int           `yaml:"worker_capacity"` // Jobs processed concurrently across the worker fleet
+}
+
+// JobHealthConfig holds thresholds for the stuck and overdue flags on job responses
+// and for listing workers as dead
+type JobHealthConfig struct {
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout"` // RUNNING jobs without a heartbeat for this long are stuck, 0 disables
+	PendingSLA       time.Duration `yaml:"pending_sla"`       // PENDING jobs older than this are overdue, 0 disables
+	WorkerTimeout    time.Duration `yaml:"worker_timeout"`    // Workers without a heartbeat for this long are dead
+}
+
+// PayloadsConfig holds limits and schemas applied to job payloads on submission
+type PayloadsConfig struct {
+	MaxBytes  int    `yaml:"max_bytes"`  // 0 uses the largest payload that fits in a broker message
+	SchemaDir string `yaml:"schema_dir"` // Directory of <job_type>.json schemas, empty disables validation
+}
+
+// ChainingConfig controls how jobs submitted with depends_on are released
+type ChainingConfig struct {
+	ResolveInterval time.Duration `yaml:"resolve_interval"` // How often WAITING jobs are checked, 0 uses 5s
+	BatchSize       int           `yaml:"batch_size"`       // Jobs queued per check at most, 0 uses 100
+}
+
+// RetentionConfig controls how long terminal jobs are kept before they are purged
+type RetentionConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	MaxAge    time.Duration `yaml:"max_age"`    // COMPLETED, FAILED and CANCELED jobs last updated longer ago are purged
+	Interval  time.Duration `yaml:"interval"`   // How often the cleaner looks for work, 0 uses 10m
+	BatchSize int           `yaml:"batch_size"` // Jobs deleted per statement, 0 uses 500
+	Archive   bool          `yaml:"archive"`    // Copy jobs and their events to jobs_archive before purging them
+	// WindowStart and WindowEnd limit purging to off-peak hours, as HH:MM in UTC. The
+	// window may wrap past midnight; leave both empty to purge at any time.
+	WindowStart string `yaml:"window_start"`
+	WindowEnd   string `yaml:"window_end"`
+}
+
+// TenancyConfig scopes API requests and jobs to tenants
+type TenancyConfig struct {
+	Enabled bool   `yaml:"enabled"` // false runs every request as the "default" tenant
+	Header  string `yaml:"header"`  // Header naming the tenant of requests without a token, empty requires a token
+	// Tokens maps API bearer tokens to the tenant each one authenticates. The tokens are
+	// secrets; keep them out of checked-in config files.
+	Tokens map[string]string  `yaml:"tokens" env:"-" secret:"true"`
+	Quotas TenantQuotasConfig `yaml:"quotas"`
+}
+
+// TenantQuotasConfig limits the jobs each tenant may submit
+type TenantQuotasConfig struct {
+	Default TenantQuotaConfig            `yaml:"default"`         // Applies to tenants not listed in tenants
+	Tenants map[string]TenantQuotaConfig `yaml:"tenants" env:"-"` // Keyed by tenant ID
+}
+
+// TenantQuotaConfig limits the jobs of one tenant, zero values disable a limit
+type TenantQuotaConfig struct {
+	MaxPendingJobs int64 `yaml:"max_pending_jobs"` // Jobs PENDING or WAITING at once
+	MaxJobsPerDay  int64 `yaml:"max_jobs_per_day"` // Jobs created since midnight UTC
+}
+
+// EventsConfig controls publishing job lifecycle events for external consumers
+type EventsConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Exchange  string        `yaml:"exchange"`   // Topic exchange, or Kafka topic, the events are published to
+	Interval  time.Duration `yaml:"interval"`   // How often unpublished events are looked for, 0 uses 1s
+	BatchSize int           `yaml:"batch_size"` // Events published per transaction, 0 uses 100
+}
+
+// IngestionConfig holds settings for accepting new jobs
+type IngestionConfig struct {
+	// Mode is sync or async. Async stores a created job with an outbox record and
+	// acknowledges it with 202 Accepted, leaving the outbox relay to publish it and
+	// clients to follow the Location header for its progress.
+	Mode          string        `yaml:"mode"`
+	RelayInterval time.Duration `yaml:"relay_interval"` // How often the outbox is looked at, 0 uses 1s
+	BatchSize     int           `yaml:"batch_size"`     // Outbox jobs published per transaction, 0 uses 100
+}
+
+// ValidationConfig restricts job submissions beyond the request format checks
+type ValidationConfig struct {
+	// JobTypes lists the job types the workers have executors for, empty accepts any
+	JobTypes []string `yaml:"job_types"`
+	// JobTypesFile is the job type definitions file shared with the workers. When set, job
+	// types it does not define or has disabled are rejected. Empty accepts any job type.
+	JobTypesFile string `yaml:"job_types_file"`
+	// RequireUUIDUserID rejects user_id values that are not UUIDs
+	RequireUUIDUserID bool `yaml:"require_uuid_user_id"`
+}
+
+// ChaosConfig injects faults into publishes and database queries for soak tests of the
+// retry and timeout paths. It is refused when app.environment is production.
+type ChaosConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	PublishDropRate    float64       `yaml:"publish_drop_rate"`    // Fraction of publishes silently discarded
+	PublishFailureRate float64       `yaml:"publish_failure_rate"` // Fraction of publishes that fail
+	QueryDelay         time.Duration `yaml:"query_delay"`          // Delayed queries wait half to all of this
+	QueryDelayRate     float64       `yaml:"query_delay_rate"`     // Fraction of queries delayed
+}
+
+// LeaderElectionConfig controls which instance runs the background loops that must run
+// only once across the deployment, such as the dependency resolver
+type LeaderElectionConfig struct {
+	Enabled       bool          `yaml:"enabled"`        // false runs the loops on every instance
+	RenewInterval time.Duration `yaml:"renew_interval"` // How often the leader lock is checked or retried, 0 uses 5s
+}
+
+// StartupConfig controls what a service does when the database or broker is unreachable
+// as it starts
+type StartupConfig struct {
+	// AllowDegraded starts the service anyway and connects in the background, with the
+	// readiness check failing until both are reachable. false aborts startup instead.
+	AllowDegraded    bool          `yaml:"allow_degraded"`
+	RetryInterval    time.Duration `yaml:"retry_interval"`     // First background reconnect delay, doubled after each failure
+	MaxRetryInterval time.Duration `yaml:"max_retry_interval"` // Longest delay between background reconnects
+}
+
+// MaintenanceConfig puts the API in maintenance mode, e.g. during database migrations:
+// it rejects mutating requests. The mode can also be set at runtime through
+// PUT /admin/maintenance.
+type MaintenanceConfig struct {
+	Enabled bool   `yaml:"enabled"` // Forces the mode on regardless of the admin API
+	Message string `yaml:"message"` // Returned to rejected requests
+	// RefreshInterval is how often each instance reads the flag set through the admin API
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// SecretsConfig holds settings for external secret stores
+type SecretsConfig struct {
+	Vault VaultConfig `yaml:"vault"`
+	// RefreshInterval is how often password files and secret references are re-read so
+	// rotated database and broker passwords are picked up, 0 disables
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// VaultConfig holds HashiCorp Vault connection settings.
+// Secret fields may reference Vault as vault:<path>#<key>, e.g. vault:secret/data/jobs#db_password.
+type VaultConfig struct {
+	Address   string        `yaml:"address" env:"VAULT_ADDR"`
+	Token     string        `yaml:"token" env:"VAULT_TOKEN" secret:"true"`
+	TokenFile string        `yaml:"token_file"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// PoliciesConfig declares how the service degrades when a dependency fails
+type PoliciesConfig struct {
+	BrokerUnavailable   string               `yaml:"broker_unavailable"`    // reject, defer
+	DatabaseUnavailable string               `yaml:"database_unavailable"`  // reject, serve_stale_reads
+	StaleReadCacheSize  int                  `yaml:"stale_read_cache_size"` // Jobs kept for serve_stale_reads
+	Deferred            DeferredPolicyConfig `yaml:"deferred"`
+	Backlog             BacklogPolicyConfig  `yaml:"backlog"`
+
+	CircuitBreaker CircuitBreakerPolicyConfig `yaml:"circuit_breaker"`
+}
+
+// DeferredPolicyConfig holds settings for messages deferred while the broker is down
+type DeferredPolicyConfig struct {
+	QueueSize     int           `yaml:"queue_size"`     // Messages held in memory before publishes are rejected again
+	RetryInterval time.Duration `yaml:"retry_interval"` // How often deferred messages are republished
+}
+
+// BacklogPolicyConfig holds settings for throttling job creation when the queue backs up
+type BacklogPolicyConfig struct {
+	MaxPending    int64         `yaml:"max_pending"`    // PENDING jobs above which creation is throttled, 0 disables
+	RetryAfter    time.Duration `yaml:"retry_after"`    // Retry-After sent with throttled responses
+	CheckInterval time.Duration `yaml:"check_interval"` // How long a pending job count is reused
+}
+
+// CircuitBreakerPolicyConfig holds settings for failing publishes fast while the broker
+// is degraded
+type CircuitBreakerPolicyConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	FailureThreshold int           `yaml:"failure_threshold"` // Consecutive failed publishes that open the circuit
+	OpenDuration     time.Duration `yaml:"open_duration"`     // How long publishes fail fast before a probe is let through
+	HalfOpenProbes   int           `yaml:"half_open_probes"`  // Successful probes needed to close the circuit
+}
+
+// ResultsConfig holds job result storage settings
+type ResultsConfig struct {
+	Backend          string        `yaml:"backend"`            // inline, s3
+	InlineLimitBytes int           `yaml:"inline_limit_bytes"` // Larger results are offloaded by the s3 backend
+	URLExpiry        time.Duration `yaml:"url_expiry"`         // Lifetime of presigned download URLs
+	S3               S3Config      `yaml:"s3"`
+	// SchemaDir is a directory of <job_type>.json schemas describing the results of each job
+	// type, published through GET /api/v1/job-types/{job_type}. Empty publishes none.
+	SchemaDir string `yaml:"schema_dir"`
+}
+
+// S3Config holds settings for an S3-compatible object store such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"` // e.g. https://s3.amazonaws.com or http://minio:9000
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key" secret:"true"`
+	SecretKeyFile   string `yaml:"secret_access_key_file"`
+	UsePathStyle    bool   `yaml:"use_path_style"` // Required by MinIO
 }
 
 // AppConfig holds application metadata
@@ -100,54 +587,1071 @@ type AppConfig struct {
 	Environment string `yaml:"environment"`
 }
 
-// Load reads and parses the configuration file
+// Default returns the configuration used for any field not set in the config file or environment
+func Default() *Config {
+	return &Config{
+		Broker: BrokerConfig{
+			Type:     BrokerRabbitMQ,
+			Encoding: "json",
+			Memory: MemoryBrokerConfig{
+				QueueSize: 10000,
+			},
+		},
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			Compression: CompressionConfig{
+				Enabled: true,
+				MinSize: 1024,
+			},
+			CORS: CORSConfig{
+				AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				AllowedHeaders: []string{"Authorization", "Content-Type", "Accept", "Accept-Encoding", "X-Idempotency-Key", "X-Tenant-ID"},
+				MaxAge:         10 * time.Minute,
+			},
+			Limits: RequestLimitsConfig{
+				HandlerTimeout: 8 * time.Second,
+				// Exports stream for as long as the filter matches jobs
+				RouteTimeouts: map[string]time.Duration{"GET /api/v1/jobs/export": 0},
+			},
+		},
+		Database: DatabaseConfig{
+			Port:               5432,
+			SSLMode:            "disable",
+			MaxOpenConns:       25,
+			ConnMaxLifetime:    5 * time.Minute,
+			ConnMaxIdleTime:    10 * time.Minute,
+			SlowQueryThreshold: 200 * time.Millisecond,
+			QueryTimeout:       30 * time.Second,
+
+			StatementCacheCapacity: 512,
+			Connect: DatabaseConnectConfig{
+				WaitTimeout:      30 * time.Second,
+				RetryInterval:    500 * time.Millisecond,
+				MaxRetryInterval: 5 * time.Second,
+			},
+		},
+		RabbitMQ: RabbitMQConfig{
+			Port:            5672,
+			VHost:           "/",
+			MaxMessageBytes: 128 << 20,
+			Compression: MessageCompressionConfig{
+				Algorithm: "none",
+				MinBytes:  1024,
+			},
+			Exchange: ExchangeConfig{
+				Type:    "direct",
+				Durable: true,
+			},
+			Queue: QueueConfig{
+				Durable: true,
+			},
+			Connection: ConnectionConfig{
+				RetryAttempts:     5,
+				RetryInterval:     5 * time.Second,
+				Heartbeat:         10 * time.Second,
+				ConnectionTimeout: 30 * time.Second,
+				PublisherChannels: 4,
+			},
+			Consumer: ConsumerConfig{
+				PrefetchCount: 10,
+			},
+			Sharding: ShardingConfig{
+				Key: ShardKeyUserID,
+			},
+		},
+		Kafka: KafkaConfig{
+			Topic:           "jobs",
+			GroupID:         "job-workers",
+			MaxMessageBytes: 1 << 20,
+			WriteTimeout:    10 * time.Second,
+			DialTimeout:     10 * time.Second,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+			Output: "stdout",
+			Rotation: LogRotationConfig{
+				MaxSizeMB:  100,
+				MaxBackups: 5,
+				MaxAge:     7 * 24 * time.Hour,
+			},
+			Sampling: LogSamplingConfig{
+				Interval: time.Second,
+			},
+			ErrorReporting: LogErrorReportingConfig{
+				RateLimit:    logger.DefaultReportRateLimit,
+				RateInterval: logger.DefaultReportRateInterval,
+			},
+		},
+		App: AppConfig{
+			Environment: "development",
+		},
+		Estimation: EstimationConfig{
+			HistoryWindow:  7 * 24 * time.Hour,
+			WorkerCapacity: 10,
+		},
+		Secrets: SecretsConfig{
+			Vault: VaultConfig{
+				Timeout: 5 * time.Second,
+			},
+		},
+		JobHealth: JobHealthConfig{
+			HeartbeatTimeout: 2 * time.Minute,
+			PendingSLA:       15 * time.Minute,
+			WorkerTimeout:    time.Minute,
+		},
+		Policies: PoliciesConfig{
+			BrokerUnavailable:   "reject",
+			DatabaseUnavailable: "reject",
+			StaleReadCacheSize:  1000,
+			Deferred: DeferredPolicyConfig{
+				QueueSize:     1000,
+				RetryInterval: 5 * time.Second,
+			},
+			Backlog: BacklogPolicyConfig{
+				RetryAfter:    30 * time.Second,
+				CheckInterval: 5 * time.Second,
+			},
+			CircuitBreaker: CircuitBreakerPolicyConfig{
+				FailureThreshold: 5,
+				OpenDuration:     30 * time.Second,
+				HalfOpenProbes:   1,
+			},
+		},
+		Chaining: ChainingConfig{
+			ResolveInterval: 5 * time.Second,
+			BatchSize:       100,
+		},
+		Retention: RetentionConfig{
+			MaxAge:    30 * 24 * time.Hour,
+			Interval:  10 * time.Minute,
+			BatchSize: 500,
+		},
+		Tenancy: TenancyConfig{
+			Header: "X-Tenant-ID",
+		},
+		Events: EventsConfig{
+			Exchange:  "job_events",
+			Interval:  time.Second,
+			BatchSize: 100,
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:       true,
+			RenewInterval: 5 * time.Second,
+		},
+		Maintenance: MaintenanceConfig{
+			RefreshInterval: 10 * time.Second,
+		},
+		Results: ResultsConfig{
+			Backend:          "inline",
+			InlineLimitBytes: 256 << 10,
+			URLExpiry:        15 * time.Minute,
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+		},
+		Ingestion: IngestionConfig{
+			Mode:          IngestionSync,
+			RelayInterval: time.Second,
+			BatchSize:     100,
+		},
+		Startup: StartupConfig{
+			RetryInterval:    time.Second,
+			MaxRetryInterval: 30 * time.Second,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the config file and environment variables.
+// Precedence is env > file > defaults. ${VAR} and ${VAR:-default} references in the
+// file are expanded before parsing; see EnvKeys for the supported override variables.
+// *_file secret fields are read last. References to external secret stores are left
+// as-is; call ResolveSecrets to fetch them.
 func Load(configPath string) (*Config, error) {
+	return load(configPath, os.LookupEnv)
+}
+
+func load(configPath string, lookupEnv func(string) (string, bool)) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	config := Default()
+	if err := yaml.Unmarshal(expandEnv(data, lookupEnv), config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	return &config, nil
+	if err := applyEnvOverrides(config, lookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+	}
+
+	if err := readSecretFiles(config); err != nil {
+		return nil, fmt.Errorf("failed to read secret files: %w", err)
+	}
+
+	return config, nil
+}
+
+// Profile selects the config sections a binary depends on
+type Profile string
+
+const (
+	// ProfileAPI validates everything the API service uses
+	ProfileAPI Profile = "api"
+	// ProfileWorker validates the database and broker sections used by workers
+	ProfileWorker Profile = "worker"
+)
+
+// Validate checks the sections required by profile and reports every violation at once
+func (c *Config) Validate(profile Profile) error {
+	var errs []error
+
+	switch profile {
+	case ProfileAPI:
+		errs = append(errs, c.validateServer()...)
+		errs = append(errs, c.validateDatabase()...)
+		errs = append(errs, c.validateBroker()...)
+		errs = append(errs, c.validateEstimation()...)
+		errs = append(errs, c.validatePayloads()...)
+		errs = append(errs, c.validateChaining()...)
+		errs = append(errs, c.validateRetention()...)
+		errs = append(errs, c.validateTenancy()...)
+		errs = append(errs, c.validateEvents()...)
+		errs = append(errs, c.validateLeaderElection()...)
+		errs = append(errs, c.validateSecrets()...)
+		errs = append(errs, c.validateJobHealth()...)
+		errs = append(errs, c.validatePolicies()...)
+		errs = append(errs, c.validateResults()...)
+		errs = append(errs, c.validateChaos()...)
+		errs = append(errs, c.validateErrorReporting()...)
+		errs = append(errs, c.validateMaintenance()...)
+		errs = append(errs, c.validateValidation()...)
+		errs = append(errs, c.validateIngestion()...)
+		errs = append(errs, c.validateStartup()...)
+	case ProfileWorker:
+		errs = append(errs, c.validateDatabase()...)
+		errs = append(errs, c.validateBroker()...)
+		errs = append(errs, c.validateConsumer()...)
+		errs = append(errs, c.validateResults()...)
+		errs = append(errs, c.validateChaos()...)
+		errs = append(errs, c.validateErrorReporting()...)
+		errs = append(errs, c.validateMaintenance()...)
+		errs = append(errs, c.validateStartup()...)
+	default:
+		return fmt.Errorf("unknown config profile %q", profile)
+	}
+
+	return errors.Join(errs...)
 }
 
-// Validate checks if the configuration is valid
-func (c *Config) Validate() error {
+func (c *Config) validateServer() []error {
+	var errs []error
+
 	if c.Server.Port < MinPort || c.Server.Port > MaxPort {
-		return fmt.Errorf("invalid server port: %d (must be between %d and %d)", c.Server.Port, MinPort, MaxPort)
+		errs = append(errs, fmt.Errorf("invalid server port: %d (must be between %d and %d)", c.Server.Port, MinPort, MaxPort))
+	}
+
+	if c.Server.Compression.MinSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid server compression min_size: %d (must not be negative)", c.Server.Compression.MinSize))
+	}
+
+	if c.Server.AdminToken == "" && c.App.Environment == "production" {
+		errs = append(errs, errors.New("server admin_token is required in production"))
+	}
+
+	if c.Server.CursorKey != "" && len(c.Server.CursorKey) < MinCursorKeyBytes {
+		errs = append(errs, fmt.Errorf("invalid server cursor_key: %d bytes (must be at least %d)", len(c.Server.CursorKey), MinCursorKeyBytes))
+	}
+
+	errs = append(errs, c.validateCORS()...)
+	errs = append(errs, c.validateRequestLimits()...)
+
+	for _, path := range c.Server.Compression.ExcludedPaths {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("invalid server compression excluded_paths entry: %q (must start with /)", path))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateCORS() []error {
+	var errs []error
+	cors := c.Server.CORS
+
+	if cors.AllowAllOrigins && cors.AllowCredentials {
+		errs = append(errs, errors.New("server cors allow_all_origins cannot be combined with allow_credentials"))
+	}
+
+	if cors.AllowAllOrigins && c.App.Environment == "production" {
+		errs = append(errs, errors.New("server cors allow_all_origins must not be enabled in production"))
+	}
+
+	for _, origin := range cors.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			errs = append(errs, fmt.Errorf("invalid server cors allowed_origins entry: %q (must be scheme://host[:port], use allow_all_origins for *)", origin))
+		}
 	}
 
+	if cors.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("invalid server cors max_age: %s (must not be negative)", cors.MaxAge))
+	}
+
+	return errs
+}
+
+func (c *Config) validateRequestLimits() []error {
+	var errs []error
+	limits := c.Server.Limits
+
+	if limits.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid server limits max_body_bytes: %d (must not be negative)", limits.MaxBodyBytes))
+	}
+
+	// The 408 response has to be written before the server's write deadline closes the connection
+	checkTimeout := func(name string, timeout time.Duration) {
+		switch {
+		case timeout < 0:
+			errs = append(errs, fmt.Errorf("invalid server limits %s: %s (must not be negative)", name, timeout))
+		case timeout > 0 && c.Server.WriteTimeout > 0 && timeout >= c.Server.WriteTimeout:
+			errs = append(errs, fmt.Errorf("invalid server limits %s: %s (must be less than server write_timeout %s)", name, timeout, c.Server.WriteTimeout))
+		}
+	}
+	checkTimeout("handler_timeout", limits.HandlerTimeout)
+
+	routes := make([]string, 0, len(limits.RouteTimeouts))
+	for route := range limits.RouteTimeouts {
+		routes = append(routes, route)
+	}
+	slices.Sort(routes)
+	for _, route := range routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method == "" || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("invalid server limits route_timeouts key: %q (must be \"METHOD /path\")", route))
+			continue
+		}
+		checkTimeout(fmt.Sprintf("route_timeouts[%q]", route), limits.RouteTimeouts[route])
+	}
+
+	return errs
+}
+
+func (c *Config) validateDatabase() []error {
+	var errs []error
+
 	if c.Database.Host == "" {
-		return fmt.Errorf("database host is required")
+		errs = append(errs, fmt.Errorf("database host is required"))
 	}
 
 	if c.Database.Port < MinPort || c.Database.Port > MaxPort {
-		return fmt.Errorf("invalid database port: %d (must be between %d and %d)", c.Database.Port, MinPort, MaxPort)
+		errs = append(errs, fmt.Errorf("invalid database port: %d (must be between %d and %d)", c.Database.Port, MinPort, MaxPort))
 	}
 
 	if c.Database.Database == "" {
-		return fmt.Errorf("database name is required")
+		errs = append(errs, fmt.Errorf("database name is required"))
+	}
+
+	switch c.Database.SSLMode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("invalid database sslmode: %q (must be disable, require, verify-ca or verify-full)", c.Database.SSLMode))
+	}
+
+	if c.Database.SSLMode == "disable" && c.App.Environment == "production" {
+		errs = append(errs, errors.New("database sslmode must not be disable in production"))
+	}
+
+	if (c.Database.SSLCert == "") != (c.Database.SSLKey == "") {
+		errs = append(errs, errors.New("database sslcert and sslkey must be set together"))
+	}
+
+	if c.Database.MaxIdleConns != 0 {
+		errs = append(errs, errors.New("database max_idle_conns is no longer supported (idle connections close after conn_max_idle_time; use min_idle_conns to keep some open)"))
+	}
+
+	if c.Database.MinIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("invalid database min_idle_conns: %d (must not be negative)", c.Database.MinIdleConns))
+	}
+
+	if c.Database.StatementCacheCapacity < 0 {
+		errs = append(errs, fmt.Errorf("invalid database statement_cache_capacity: %d (must not be negative)", c.Database.StatementCacheCapacity))
+	}
+
+	if c.Database.SlowQueryThreshold < 0 {
+		errs = append(errs, fmt.Errorf("invalid database slow_query_threshold: %s (must not be negative)", c.Database.SlowQueryThreshold))
+	}
+
+	if c.Database.QueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid database query_timeout: %s (must not be negative)", c.Database.QueryTimeout))
+	}
+
+	connect := c.Database.Connect
+	if connect.WaitTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid database connect wait_timeout: %s (must not be negative)", connect.WaitTimeout))
+	}
+	if connect.WaitTimeout > 0 && connect.RetryInterval <= 0 {
+		errs = append(errs, fmt.Errorf("invalid database connect retry_interval: %s (must be positive when wait_timeout is set)", connect.RetryInterval))
+	}
+	if connect.MaxRetryInterval < connect.RetryInterval {
+		errs = append(errs, fmt.Errorf("invalid database connect max_retry_interval: %s (must be at least retry_interval %s)", connect.MaxRetryInterval, connect.RetryInterval))
+	}
+
+	return errs
+}
+
+// validateBroker checks the broker type and the settings of the selected broker.
+// An empty type means rabbitmq.
+func (c *Config) validateBroker() []error {
+	var errs []error
+	switch c.Broker.Encoding {
+	case "", "json", "protobuf":
+	default:
+		errs = append(errs, fmt.Errorf("invalid broker encoding: %q (must be json or protobuf)", c.Broker.Encoding))
 	}
 
+	switch c.Broker.Type {
+	case "", BrokerRabbitMQ:
+		return append(errs, c.validateRabbitMQ()...)
+	case BrokerKafka:
+		return append(errs, c.validateKafka()...)
+	case BrokerMemory:
+		return append(errs, c.validateMemoryBroker()...)
+	default:
+		return append(errs, fmt.Errorf("invalid broker type: %q (must be %s, %s or %s)", c.Broker.Type, BrokerRabbitMQ, BrokerKafka, BrokerMemory))
+	}
+}
+
+func (c *Config) validateKafka() []error {
+	var errs []error
+	kafka := c.Kafka
+
+	if len(kafka.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka brokers are required"))
+	}
+	for i, address := range kafka.Brokers {
+		if _, port, err := net.SplitHostPort(address); err != nil || port == "" {
+			errs = append(errs, fmt.Errorf("invalid kafka brokers[%d]: %q (must be host:port)", i, address))
+		}
+	}
+
+	if kafka.Topic == "" {
+		errs = append(errs, errors.New("kafka topic is required"))
+	}
+
+	if kafka.GroupID == "" {
+		errs = append(errs, errors.New("kafka group_id is required"))
+	}
+
+	if kafka.DeadLetterTopic != "" && kafka.DeadLetterTopic == kafka.Topic {
+		errs = append(errs, fmt.Errorf("invalid kafka dead_letter_topic: %q (must differ from the topic)", kafka.DeadLetterTopic))
+	}
+
+	if kafka.MaxMessageBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid kafka max_message_bytes: %d (must not be negative)", kafka.MaxMessageBytes))
+	}
+
+	if kafka.WriteTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid kafka write_timeout: %s (must not be negative)", kafka.WriteTimeout))
+	}
+
+	if kafka.DialTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid kafka dial_timeout: %s (must not be negative)", kafka.DialTimeout))
+	}
+
+	switch kafka.SASL.Mechanism {
+	case "":
+	case "plain", "scram-sha-256", "scram-sha-512":
+		if kafka.SASL.Username == "" {
+			errs = append(errs, errors.New("kafka sasl username is required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid kafka sasl mechanism: %q (must be plain, scram-sha-256 or scram-sha-512)", kafka.SASL.Mechanism))
+	}
+
+	if !kafka.TLS.Enabled && c.App.Environment == "production" {
+		errs = append(errs, errors.New("kafka tls must be enabled in production"))
+	}
+
+	if kafka.TLS.Enabled {
+		if (kafka.TLS.CertFile == "") != (kafka.TLS.KeyFile == "") {
+			errs = append(errs, errors.New("kafka tls cert_file and key_file must be set together"))
+		}
+		if kafka.TLS.InsecureSkipVerify && c.App.Environment == "production" {
+			errs = append(errs, errors.New("kafka tls insecure_skip_verify must not be enabled in production"))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateMemoryBroker() []error {
+	var errs []error
+	memory := c.Broker.Memory
+
+	if memory.QueueSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid broker memory queue_size: %d (must not be negative)", memory.QueueSize))
+	}
+
+	if memory.DeliveryDelay < 0 {
+		errs = append(errs, fmt.Errorf("invalid broker memory delivery_delay: %s (must not be negative)", memory.DeliveryDelay))
+	}
+
+	if memory.PublishFailureRate < 0 || memory.PublishFailureRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid broker memory publish_failure_rate: %g (must be between 0 and 1)", memory.PublishFailureRate))
+	}
+
+	return errs
+}
+
+func (c *Config) validateRabbitMQ() []error {
+	var errs []error
+
 	if c.RabbitMQ.Host == "" {
-		return fmt.Errorf("rabbitmq host is required")
+		errs = append(errs, fmt.Errorf("rabbitmq host is required"))
 	}
 
 	if c.RabbitMQ.Port < MinPort || c.RabbitMQ.Port > MaxPort {
-		return fmt.Errorf("invalid rabbitmq port: %d (must be between %d and %d)", c.RabbitMQ.Port, MinPort, MaxPort)
+		errs = append(errs, fmt.Errorf("invalid rabbitmq port: %d (must be between %d and %d)", c.RabbitMQ.Port, MinPort, MaxPort))
 	}
 
 	if c.RabbitMQ.Exchange.Name == "" {
-		return fmt.Errorf("rabbitmq exchange name is required")
+		errs = append(errs, fmt.Errorf("rabbitmq exchange name is required"))
 	}
 
 	if c.RabbitMQ.Queue.Name == "" {
-		return fmt.Errorf("rabbitmq queue name is required")
+		errs = append(errs, fmt.Errorf("rabbitmq queue name is required"))
+	}
+
+	// An exclusive queue already has a single consumer connection, leaving nothing to fail over to
+	if c.RabbitMQ.Queue.SingleActiveConsumer && c.RabbitMQ.Queue.Exclusive {
+		errs = append(errs, fmt.Errorf("rabbitmq queue single_active_consumer cannot be combined with exclusive"))
+	}
+
+	if c.RabbitMQ.Partitions < 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq partitions: %d (must not be negative)", c.RabbitMQ.Partitions))
+	}
+
+	if c.RabbitMQ.Partitions > 0 {
+		if c.RabbitMQ.Exchange.Type != "x-consistent-hash" {
+			errs = append(errs, fmt.Errorf("rabbitmq partitions require exchange type x-consistent-hash, got %q", c.RabbitMQ.Exchange.Type))
+		}
+		// Several consumers on one partition would process its keys concurrently
+		if !c.RabbitMQ.Queue.SingleActiveConsumer {
+			errs = append(errs, fmt.Errorf("rabbitmq partitions require queue single_active_consumer to keep per-key order"))
+		}
+	}
+
+	errs = append(errs, c.validateSharding()...)
+
+	seen := map[string]bool{c.RabbitMQ.Queue.Name: true}
+	for i, queue := range c.RabbitMQ.Queues {
+		switch {
+		case queue.Name == "":
+			errs = append(errs, fmt.Errorf("rabbitmq queues[%d] name is required", i))
+		case seen[queue.Name]:
+			errs = append(errs, fmt.Errorf("rabbitmq queues[%d] name %q is already used", i, queue.Name))
+		}
+		seen[queue.Name] = true
+
+		if queue.RoutingKey == "" {
+			errs = append(errs, fmt.Errorf("rabbitmq queues[%d] routing_key is required", i))
+		}
+	}
+
+	if c.RabbitMQ.MaxMessageBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq max_message_bytes: %d (must not be negative)", c.RabbitMQ.MaxMessageBytes))
+	}
+
+	switch c.RabbitMQ.Compression.Algorithm {
+	case "", "none", "gzip":
+	default:
+		errs = append(errs, fmt.Errorf("invalid rabbitmq compression algorithm: %s (must be none or gzip)", c.RabbitMQ.Compression.Algorithm))
+	}
+	if c.RabbitMQ.Compression.MinBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq compression min_bytes: %d (must not be negative)", c.RabbitMQ.Compression.MinBytes))
+	}
+
+	errs = append(errs, validateAMQPArguments("exchange", c.RabbitMQ.Exchange.Arguments)...)
+	errs = append(errs, validateAMQPArguments("queue", c.RabbitMQ.Queue.Arguments)...)
+
+	switch queueType := c.RabbitMQ.Queue.Arguments["x-queue-type"]; queueType {
+	case nil, "classic":
+	case "quorum":
+		// Quorum queues are replicated, so they are always durable and never exclusive
+		if !c.RabbitMQ.Queue.Durable || c.RabbitMQ.Queue.AutoDelete || c.RabbitMQ.Queue.Exclusive {
+			errs = append(errs, errors.New("rabbitmq quorum queues must be durable and cannot be auto_delete or exclusive"))
+		}
+		if _, ok := c.RabbitMQ.Queue.Arguments["x-queue-mode"]; ok {
+			errs = append(errs, errors.New("rabbitmq queue argument x-queue-mode only applies to classic queues"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid rabbitmq queue argument x-queue-type: %v (must be classic or quorum)", queueType))
+	}
+
+	if !c.RabbitMQ.TLS.Enabled && c.App.Environment == "production" {
+		errs = append(errs, errors.New("rabbitmq tls must be enabled in production"))
+	}
+
+	if c.RabbitMQ.TLS.Enabled {
+		if (c.RabbitMQ.TLS.CertFile == "") != (c.RabbitMQ.TLS.KeyFile == "") {
+			errs = append(errs, errors.New("rabbitmq tls cert_file and key_file must be set together"))
+		}
+		if c.RabbitMQ.TLS.InsecureSkipVerify && c.App.Environment == "production" {
+			errs = append(errs, errors.New("rabbitmq tls insecure_skip_verify must not be enabled in production"))
+		}
+	}
+
+	if c.RabbitMQ.Connection.PublisherChannels < 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq connection publisher_channels: %d (must not be negative)", c.RabbitMQ.Connection.PublisherChannels))
+	}
+
+	return errs
+}
+
+// validateSharding checks that jobs can be routed to the shards and that the consumed
+// shards exist
+func (c *Config) validateSharding() []error {
+	var errs []error
+
+	sharding := c.RabbitMQ.Sharding
+	if sharding.Shards < 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq sharding shards: %d (must not be negative)", sharding.Shards))
+	}
+	if sharding.Shards <= 0 {
+		return errs
+	}
+
+	// Both replace the main queue, and each hashes a different key
+	if c.RabbitMQ.Partitions > 0 {
+		errs = append(errs, fmt.Errorf("rabbitmq sharding cannot be combined with partitions"))
+	}
+
+	if sharding.Key != ShardKeyUserID && sharding.Key != ShardKeyJobType {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq sharding key: %q (must be %s or %s)", sharding.Key, ShardKeyUserID, ShardKeyJobType))
+	}
+
+	// Shards are bound by routing key, which other exchange types ignore
+	if c.RabbitMQ.Exchange.Type != "direct" && c.RabbitMQ.Exchange.Type != "topic" {
+		errs = append(errs, fmt.Errorf("rabbitmq sharding requires exchange type direct or topic, got %q", c.RabbitMQ.Exchange.Type))
+	}
+
+	if !strings.Contains(c.RabbitMQ.ShardRoutingKey(), "{shard}") {
+		errs = append(errs, fmt.Errorf("rabbitmq sharding routing_key must contain {shard}, got %q", sharding.RoutingKey))
+	}
+
+	seen := make(map[int]bool, len(sharding.Consume))
+	for _, shard := range sharding.Consume {
+		switch {
+		case shard < 0 || shard >= sharding.Shards:
+			errs = append(errs, fmt.Errorf("invalid rabbitmq sharding consume shard: %d (must be between 0 and %d)", shard, sharding.Shards-1))
+		case seen[shard]:
+			errs = append(errs, fmt.Errorf("rabbitmq sharding consume shard %d is listed twice", shard))
+		}
+		seen[shard] = true
+	}
+
+	return errs
+}
+
+// validateAMQPArguments checks that every argument has a type AMQP tables can carry
+func validateAMQPArguments(name string, args map[string]any) []error {
+	var errs []error
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		switch args[key].(type) {
+		case string, bool, int, int64, float64:
+		default:
+			errs = append(errs, fmt.Errorf("invalid rabbitmq %s argument %s: %v (must be a string, number or boolean)", name, key, args[key]))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateConsumer() []error {
+	var errs []error
+
+	if c.RabbitMQ.Consumer.PrefetchCount <= 0 {
+		errs = append(errs, fmt.Errorf("invalid rabbitmq consumer prefetch_count: %d (must be greater than 0)", c.RabbitMQ.Consumer.PrefetchCount))
+	}
+
+	for i, queue := range c.RabbitMQ.Queues {
+		if queue.PrefetchCount < 0 {
+			errs = append(errs, fmt.Errorf("invalid rabbitmq queues[%d] prefetch_count: %d (must not be negative)", i, queue.PrefetchCount))
+		}
+		if queue.Concurrency < 0 {
+			errs = append(errs, fmt.Errorf("invalid rabbitmq queues[%d] concurrency: %d (must not be negative)", i, queue.Concurrency))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateEstimation() []error {
+	var errs []error
+
+	if c.Estimation.WorkerCapacity < 0 {
+		errs = append(errs, fmt.Errorf("invalid estimation worker capacity: %d (must not be negative)", c.Estimation.WorkerCapacity))
+	}
+
+	return errs
+}
+
+func (c *Config) validatePayloads() []error {
+	var errs []error
+
+	if c.Payloads.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid payloads max_bytes: %d (must not be negative)", c.Payloads.MaxBytes))
+	}
+
+	return errs
+}
+
+func (c *Config) validateChaining() []error {
+	var errs []error
+
+	if c.Chaining.ResolveInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid chaining resolve_interval: %s (must not be negative)", c.Chaining.ResolveInterval))
+	}
+
+	if c.Chaining.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid chaining batch_size: %d (must not be negative)", c.Chaining.BatchSize))
+	}
+
+	return errs
+}
+
+func (c *Config) validateTenancy() []error {
+	var errs []error
+	tenancy := c.Tenancy
+
+	if tenancy.Enabled && tenancy.Header == "" && len(tenancy.Tokens) == 0 {
+		errs = append(errs, errors.New("tenancy header or tokens are required when tenancy is enabled"))
+	}
+
+	tokens := make([]string, 0, len(tenancy.Tokens))
+	for token := range tenancy.Tokens {
+		tokens = append(tokens, token)
+	}
+	slices.Sort(tokens)
+	for i, token := range tokens {
+		// Report the position rather than the token, which is a secret
+		if token == "" {
+			errs = append(errs, fmt.Errorf("invalid tenancy tokens entry %d: token must not be empty", i))
+		}
+		if id := tenancy.Tokens[token]; !tenant.Valid(id) {
+			errs = append(errs, fmt.Errorf("invalid tenancy tokens entry %d: tenant %q (must be 1 to 100 letters, digits, '.', '_' or '-')", i, id))
+		}
+	}
+
+	checkQuota := func(name string, quota TenantQuotaConfig) {
+		if quota.MaxPendingJobs < 0 {
+			errs = append(errs, fmt.Errorf("invalid tenancy quotas %s max_pending_jobs: %d (must not be negative)", name, quota.MaxPendingJobs))
+		}
+		if quota.MaxJobsPerDay < 0 {
+			errs = append(errs, fmt.Errorf("invalid tenancy quotas %s max_jobs_per_day: %d (must not be negative)", name, quota.MaxJobsPerDay))
+		}
+	}
+	checkQuota("default", tenancy.Quotas.Default)
+
+	tenants := make([]string, 0, len(tenancy.Quotas.Tenants))
+	for id := range tenancy.Quotas.Tenants {
+		tenants = append(tenants, id)
+	}
+	slices.Sort(tenants)
+	for _, id := range tenants {
+		if !tenant.Valid(id) {
+			errs = append(errs, fmt.Errorf("invalid tenancy quotas tenants key: %q (must be 1 to 100 letters, digits, '.', '_' or '-')", id))
+			continue
+		}
+		checkQuota(fmt.Sprintf("tenants[%q]", id), tenancy.Quotas.Tenants[id])
+	}
+
+	return errs
+}
+
+func (c *Config) validateRetention() []error {
+	var errs []error
+	retention := c.Retention
+
+	if retention.Enabled && retention.MaxAge <= 0 {
+		errs = append(errs, fmt.Errorf("invalid retention max_age: %s (must be positive when retention is enabled)", retention.MaxAge))
+	}
+
+	if retention.Interval < 0 {
+		errs = append(errs, fmt.Errorf("invalid retention interval: %s (must not be negative)", retention.Interval))
+	}
+
+	if retention.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid retention batch_size: %d (must not be negative)", retention.BatchSize))
+	}
+
+	for _, window := range []struct{ name, value string }{
+		{"window_start", retention.WindowStart},
+		{"window_end", retention.WindowEnd},
+	} {
+		if window.value == "" {
+			continue
+		}
+		if _, err := time.Parse(RetentionWindowLayout, window.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid retention %s: %q (must be HH:MM)", window.name, window.value))
+		}
+	}
+
+	if (retention.WindowStart == "") != (retention.WindowEnd == "") {
+		errs = append(errs, errors.New("retention window_start and window_end must be set together"))
+	}
+
+	return errs
+}
+
+func (c *Config) validateEvents() []error {
+	var errs []error
+	events := c.Events
+
+	if events.Enabled && events.Exchange == "" {
+		errs = append(errs, errors.New("events exchange is required when events are enabled"))
+	}
+
+	if events.Enabled && events.Exchange != "" {
+		switch c.Broker.Type {
+		case "", BrokerRabbitMQ:
+			if events.Exchange == c.RabbitMQ.Exchange.Name {
+				errs = append(errs, fmt.Errorf("invalid events exchange: %q (must differ from the jobs exchange)", events.Exchange))
+			}
+		case BrokerKafka:
+			if events.Exchange == c.Kafka.Topic {
+				errs = append(errs, fmt.Errorf("invalid events exchange: %q (must differ from the kafka topic)", events.Exchange))
+			}
+		}
+	}
+
+	if events.Interval < 0 {
+		errs = append(errs, fmt.Errorf("invalid events interval: %s (must not be negative)", events.Interval))
+	}
+
+	if events.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid events batch_size: %d (must not be negative)", events.BatchSize))
+	}
+
+	return errs
+}
+
+func (c *Config) validateSecrets() []error {
+	var errs []error
+
+	if c.Secrets.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid secrets refresh_interval: %s (must not be negative)", c.Secrets.RefreshInterval))
+	}
+
+	return errs
+}
+
+func (c *Config) validateStartup() []error {
+	var errs []error
+	startup := c.Startup
+
+	if startup.RetryInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid startup retry_interval: %s (must not be negative)", startup.RetryInterval))
+	}
+
+	if startup.MaxRetryInterval < startup.RetryInterval {
+		errs = append(errs, fmt.Errorf("invalid startup max_retry_interval: %s (must be at least retry_interval %s)", startup.MaxRetryInterval, startup.RetryInterval))
+	}
+
+	return errs
+}
+
+func (c *Config) validateLeaderElection() []error {
+	var errs []error
+
+	if c.LeaderElection.RenewInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid leader_election renew_interval: %s (must not be negative)", c.LeaderElection.RenewInterval))
+	}
+
+	return errs
+}
+
+func (c *Config) validateMaintenance() []error {
+	var errs []error
+
+	if c.Maintenance.RefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid maintenance refresh_interval: %s (must not be negative)", c.Maintenance.RefreshInterval))
+	}
+
+	return errs
+}
+
+func (c *Config) validateValidation() []error {
+	var errs []error
+
+	for _, jobType := range c.Validation.JobTypes {
+		if jobType == "" || len(jobType) > 50 {
+			errs = append(errs, fmt.Errorf("invalid validation job_types entry: %q (must be 1 to 50 characters)", jobType))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateIngestion() []error {
+	var errs []error
+	switch c.Ingestion.Mode {
+	case "", IngestionSync, IngestionAsync:
+	default:
+		errs = append(errs, fmt.Errorf("invalid ingestion mode: %q (must be %s or %s)", c.Ingestion.Mode, IngestionSync, IngestionAsync))
+	}
+	if c.Ingestion.RelayInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid ingestion relay_interval: %s (must not be negative)", c.Ingestion.RelayInterval))
+	}
+	if c.Ingestion.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid ingestion batch_size: %d (must not be negative)", c.Ingestion.BatchSize))
+	}
+	return errs
+}
+
+func (c *Config) validateErrorReporting() []error {
+	var errs []error
+	reporting := c.Logging.ErrorReporting
+
+	if reporting.DSN != "" {
+		if _, err := logger.ParseSentryDSN(reporting.DSN); err != nil {
+			errs = append(errs, fmt.Errorf("invalid logging error_reporting dsn: %w", err))
+		}
+	}
+
+	if reporting.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("invalid logging error_reporting rate_limit: %d (must not be negative)", reporting.RateLimit))
+	}
+
+	if reporting.RateInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid logging error_reporting rate_interval: %s (must not be negative)", reporting.RateInterval))
+	}
+
+	return errs
+}
+
+func (c *Config) validateChaos() []error {
+	var errs []error
+	chaos := c.Chaos
+
+	if chaos.Enabled && c.App.Environment == "production" {
+		errs = append(errs, errors.New("chaos must not be enabled in production"))
+	}
+
+	if chaos.PublishDropRate < 0 || chaos.PublishDropRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid chaos publish_drop_rate: %g (must be between 0 and 1)", chaos.PublishDropRate))
+	}
+
+	if chaos.PublishFailureRate < 0 || chaos.PublishFailureRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid chaos publish_failure_rate: %g (must be between 0 and 1)", chaos.PublishFailureRate))
+	}
+
+	if chaos.QueryDelayRate < 0 || chaos.QueryDelayRate > 1 {
+		errs = append(errs, fmt.Errorf("invalid chaos query_delay_rate: %g (must be between 0 and 1)", chaos.QueryDelayRate))
+	}
+
+	if chaos.QueryDelay < 0 {
+		errs = append(errs, fmt.Errorf("invalid chaos query_delay: %s (must not be negative)", chaos.QueryDelay))
+	}
+
+	return errs
+}
+
+func (c *Config) validatePolicies() []error {
+	var errs []error
+
+	switch c.Policies.BrokerUnavailable {
+	case "", "reject", "defer":
+	default:
+		errs = append(errs, fmt.Errorf("invalid policies broker_unavailable: %q (must be reject or defer)", c.Policies.BrokerUnavailable))
+	}
+
+	switch c.Policies.DatabaseUnavailable {
+	case "", "reject", "serve_stale_reads":
+	default:
+		errs = append(errs, fmt.Errorf("invalid policies database_unavailable: %q (must be reject or serve_stale_reads)", c.Policies.DatabaseUnavailable))
+	}
+
+	if c.Policies.Backlog.MaxPending < 0 {
+		errs = append(errs, fmt.Errorf("invalid policies backlog max_pending: %d (must not be negative)", c.Policies.Backlog.MaxPending))
+	}
+
+	if breaker := c.Policies.CircuitBreaker; breaker.Enabled {
+		if breaker.FailureThreshold < 1 {
+			errs = append(errs, fmt.Errorf("invalid policies circuit_breaker failure_threshold: %d (must be at least 1)", breaker.FailureThreshold))
+		}
+		if breaker.OpenDuration <= 0 {
+			errs = append(errs, fmt.Errorf("invalid policies circuit_breaker open_duration: %s (must be positive)", breaker.OpenDuration))
+		}
+		if breaker.HalfOpenProbes < 1 {
+			errs = append(errs, fmt.Errorf("invalid policies circuit_breaker half_open_probes: %d (must be at least 1)", breaker.HalfOpenProbes))
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) validateJobHealth() []error {
+	var errs []error
+
+	if c.JobHealth.HeartbeatTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid job_health heartbeat_timeout: %s (must not be negative)", c.JobHealth.HeartbeatTimeout))
+	}
+
+	if c.JobHealth.PendingSLA < 0 {
+		errs = append(errs, fmt.Errorf("invalid job_health pending_sla: %s (must not be negative)", c.JobHealth.PendingSLA))
+	}
+
+	if c.JobHealth.WorkerTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid job_health worker_timeout: %s (must not be negative)", c.JobHealth.WorkerTimeout))
+	}
+
+	return errs
+}
+
+func (c *Config) validateResults() []error {
+	var errs []error
+
+	switch c.Results.Backend {
+	case "", "inline":
+		return nil
+	case "s3":
+	default:
+		return []error{fmt.Errorf("invalid results backend: %q (must be inline or s3)", c.Results.Backend)}
+	}
+
+	if c.Results.InlineLimitBytes < 0 {
+		errs = append(errs, fmt.Errorf("invalid results inline_limit_bytes: %d (must not be negative)", c.Results.InlineLimitBytes))
+	}
+
+	// SigV4 presigned URLs are valid for at most 7 days
+	if c.Results.URLExpiry < time.Second || c.Results.URLExpiry > 7*24*time.Hour {
+		errs = append(errs, fmt.Errorf("invalid results url_expiry: %s (must be between 1s and 168h)", c.Results.URLExpiry))
+	}
+
+	if c.Results.S3.Endpoint == "" {
+		errs = append(errs, fmt.Errorf("results s3 endpoint is required"))
+	}
+
+	if c.Results.S3.Bucket == "" {
+		errs = append(errs, fmt.Errorf("results s3 bucket is required"))
+	}
+
+	if c.Results.S3.AccessKeyID == "" || c.Results.S3.SecretAccessKey == "" {
+		errs = append(errs, fmt.Errorf("results s3 access_key_id and secret_access_key are required"))
 	}
 
-	return nil
+	return errs
 }
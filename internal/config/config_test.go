@@ -1,13 +1,27 @@
 package config
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// clearEnvOverrides unsets every config override variable for the duration of the test
+func clearEnvOverrides(t *testing.T) {
+	t.Helper()
+	for _, key := range EnvKeys() {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
 func TestLoad(t *testing.T) {
+	clearEnvOverrides(t)
+
 	tests := []struct {
 		name      string
 		filePath  string
@@ -179,6 +193,78 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			errString: "database name is required",
 		},
+		{
+			name: "negative slow query threshold",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:               "localhost",
+					Port:               5432,
+					Database:           "jobs_db",
+					SlowQueryThreshold: -time.Second,
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+			},
+			wantErr:   true,
+			errString: "invalid database slow_query_threshold",
+		},
+		{
+			name: "negative statement cache capacity",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:                   "localhost",
+					Port:                   5432,
+					Database:               "jobs_db",
+					StatementCacheCapacity: -1,
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+			},
+			wantErr:   true,
+			errString: "invalid database statement_cache_capacity",
+		},
+		{
+			name: "max idle conns is no longer supported",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:         "localhost",
+					Port:         5432,
+					Database:     "jobs_db",
+					MaxIdleConns: 5,
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+			},
+			wantErr:   true,
+			errString: "database max_idle_conns is no longer supported",
+		},
 		{
 			name: "empty rabbitmq host",
 			config: &Config{
@@ -248,72 +334,1219 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			errString: "rabbitmq queue name is required",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := tt.config.Validate()
-
-			if tt.wantErr {
-				require.Error(t, err)
-				assert.Contains(t, err.Error(), tt.errString)
-			} else {
-				require.NoError(t, err)
-			}
-		})
-	}
-}
-
-func TestLoad_ValidateIntegration(t *testing.T) {
-	t.Run("load and validate valid config", func(t *testing.T) {
-		cfg, err := Load("testdata/valid_config.yaml")
-		require.NoError(t, err)
-		require.NotNil(t, cfg)
-
-		err = cfg.Validate()
-		require.NoError(t, err)
-	})
-
-	t.Run("load config with invalid port", func(t *testing.T) {
-		cfg, err := Load("testdata/invalid_port.yaml")
-		require.NoError(t, err)
-		require.NotNil(t, cfg)
-
-		err = cfg.Validate()
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "invalid server port")
-	})
-
-	t.Run("load config with missing database", func(t *testing.T) {
-		cfg, err := Load("testdata/missing_database.yaml")
-		require.NoError(t, err)
-		require.NotNil(t, cfg)
-
-		err = cfg.Validate()
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "database name is required")
-	})
-}
-
-func TestPortConstants(t *testing.T) {
-	t.Run("port constants are correct", func(t *testing.T) {
-		assert.Equal(t, 1, MinPort)
-		assert.Equal(t, 65535, MaxPort)
-	})
-
-	t.Run("valid port range", func(t *testing.T) {
-		validPorts := []int{1, 80, 443, 8080, 65535}
-		for _, port := range validPorts {
-			assert.GreaterOrEqual(t, port, MinPort)
-			assert.LessOrEqual(t, port, MaxPort)
-		}
-	})
-
-	t.Run("invalid port range", func(t *testing.T) {
-		invalidPorts := []int{0, -1, 65536, 70000}
-		for _, port := range invalidPorts {
-			valid := port >= MinPort && port <= MaxPort
-			assert.False(t, valid, "port %d should be invalid", port)
-		}
-	})
+		{
+			name: "negative publisher channels",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host:       "localhost",
+					Port:       5672,
+					Exchange:   ExchangeConfig{Name: "jobs_exchange"},
+					Queue:      QueueConfig{Name: "jobs_queue"},
+					Connection: ConnectionConfig{PublisherChannels: -1},
+				},
+			},
+			wantErr:   true,
+			errString: "invalid rabbitmq connection publisher_channels: -1 (must not be negative)",
+		},
+		{
+			name: "single active consumer on an exclusive queue",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name:                 "jobs_queue",
+						Exclusive:            true,
+						SingleActiveConsumer: true,
+					},
+				},
+			},
+			wantErr:   true,
+			errString: "single_active_consumer cannot be combined with exclusive",
+		},
+		{
+			name: "negative estimation worker capacity",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Estimation: EstimationConfig{WorkerCapacity: -1},
+			},
+			wantErr:   true,
+			errString: "invalid estimation worker capacity",
+		},
+		{
+			name: "negative pending SLA",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				JobHealth: JobHealthConfig{PendingSLA: -time.Minute},
+			},
+			wantErr:   true,
+			errString: "invalid job_health pending_sla",
+		},
+		{
+			name: "unsupported broker type",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: "nats"},
+			},
+			wantErr:   true,
+			errString: `invalid broker type: "nats"`,
+		},
+		{
+			name: "kafka broker skips rabbitmq settings",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka: KafkaConfig{
+					Brokers: []string{"kafka-1:9092", "kafka-2:9092"},
+					Topic:   "jobs",
+					GroupID: "job-workers",
+					SASL:    SASLConfig{Mechanism: "scram-sha-512", Username: "jobs"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "kafka broker without brokers",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka:  KafkaConfig{Topic: "jobs", GroupID: "job-workers"},
+			},
+			wantErr:   true,
+			errString: "kafka brokers are required",
+		},
+		{
+			name: "kafka broker without port",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka:  KafkaConfig{Brokers: []string{"kafka-1"}, Topic: "jobs", GroupID: "job-workers"},
+			},
+			wantErr:   true,
+			errString: `invalid kafka brokers[0]: "kafka-1"`,
+		},
+		{
+			name: "kafka dead letter topic is the job topic",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka: KafkaConfig{
+					Brokers:         []string{"kafka-1:9092"},
+					Topic:           "jobs",
+					GroupID:         "job-workers",
+					DeadLetterTopic: "jobs",
+				},
+			},
+			wantErr:   true,
+			errString: "invalid kafka dead_letter_topic",
+		},
+		{
+			name: "kafka unsupported sasl mechanism",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka: KafkaConfig{
+					Brokers: []string{"kafka-1:9092"},
+					Topic:   "jobs",
+					GroupID: "job-workers",
+					SASL:    SASLConfig{Mechanism: "gssapi", Username: "jobs"},
+				},
+			},
+			wantErr:   true,
+			errString: `invalid kafka sasl mechanism: "gssapi"`,
+		},
+		{
+			name: "kafka events topic is the job topic",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerKafka},
+				Kafka:  KafkaConfig{Brokers: []string{"kafka-1:9092"}, Topic: "jobs", GroupID: "job-workers"},
+				Events: EventsConfig{Enabled: true, Exchange: "jobs"},
+			},
+			wantErr:   true,
+			errString: "must differ from the kafka topic",
+		},
+		{
+			name: "memory broker skips rabbitmq settings",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+			},
+			wantErr: false,
+		},
+		{
+			name: "memory broker failure rate out of range",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{
+					Type:   BrokerMemory,
+					Memory: MemoryBrokerConfig{PublishFailureRate: 1.5},
+				},
+			},
+			wantErr:   true,
+			errString: "invalid broker memory publish_failure_rate",
+		},
+		{
+			name: "chaos enabled in production",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+				App:    AppConfig{Environment: "production"},
+				Chaos:  ChaosConfig{Enabled: true},
+			},
+			wantErr:   true,
+			errString: "chaos must not be enabled in production",
+		},
+		{
+			name: "chaos drop rate above 1",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+				Chaos:  ChaosConfig{Enabled: true, PublishDropRate: 2},
+			},
+			wantErr:   true,
+			errString: "invalid chaos publish_drop_rate",
+		},
+		{
+			name: "empty allowed job type",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker:     BrokerConfig{Type: BrokerMemory},
+				Validation: ValidationConfig{JobTypes: []string{"send_email", ""}},
+			},
+			wantErr:   true,
+			errString: "invalid validation job_types entry",
+		},
+		{
+			name: "cors allow all with credentials",
+			config: &Config{
+				Server: ServerConfig{Port: 8080, CORS: CORSConfig{AllowAllOrigins: true, AllowCredentials: true}},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+			},
+			wantErr:   true,
+			errString: "server cors allow_all_origins cannot be combined with allow_credentials",
+		},
+		{
+			name: "cors origin with a path",
+			config: &Config{
+				Server: ServerConfig{Port: 8080, CORS: CORSConfig{AllowedOrigins: []string{"https://app.example.com/login"}}},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+			},
+			wantErr:   true,
+			errString: "invalid server cors allowed_origins entry",
+		},
+		{
+			name: "handler timeout not below write timeout",
+			config: &Config{
+				Server: ServerConfig{Port: 8080, WriteTimeout: 10 * time.Second, Limits: RequestLimitsConfig{HandlerTimeout: 10 * time.Second}},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+			},
+			wantErr:   true,
+			errString: "invalid server limits handler_timeout: 10s (must be less than server write_timeout 10s)",
+		},
+		{
+			name: "route timeout without method",
+			config: &Config{
+				Server: ServerConfig{Port: 8080, Limits: RequestLimitsConfig{RouteTimeouts: map[string]time.Duration{"/api/v1/jobs": time.Second}}},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				Broker: BrokerConfig{Type: BrokerMemory},
+			},
+			wantErr:   true,
+			errString: "invalid server limits route_timeouts key",
+		},
+		{
+			name: "negative leader election renew interval",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				LeaderElection: LeaderElectionConfig{RenewInterval: -time.Second},
+			},
+			wantErr:   true,
+			errString: "invalid leader_election renew_interval",
+		},
+		{
+			name: "negative payload max bytes",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Payloads: PayloadsConfig{MaxBytes: -1},
+			},
+			wantErr:   true,
+			errString: "invalid payloads max_bytes",
+		},
+		{
+			name: "invalid broker unavailable policy",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Policies: PoliciesConfig{BrokerUnavailable: "retry"},
+			},
+			wantErr:   true,
+			errString: "invalid policies broker_unavailable",
+		},
+		{
+			name: "invalid database unavailable policy",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Policies: PoliciesConfig{DatabaseUnavailable: "cache"},
+			},
+			wantErr:   true,
+			errString: "invalid policies database_unavailable",
+		},
+		{
+			name: "negative backlog threshold",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Policies: PoliciesConfig{Backlog: BacklogPolicyConfig{MaxPending: -1}},
+			},
+			wantErr:   true,
+			errString: "invalid policies backlog max_pending",
+		},
+		{
+			name: "invalid circuit breaker",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Policies: PoliciesConfig{CircuitBreaker: CircuitBreakerPolicyConfig{Enabled: true, OpenDuration: time.Minute, HalfOpenProbes: 1}},
+			},
+			wantErr:   true,
+			errString: "invalid policies circuit_breaker failure_threshold: 0 (must be at least 1)",
+		},
+		{
+			name: "invalid ingestion mode",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Ingestion: IngestionConfig{Mode: "batch"},
+			},
+			wantErr:   true,
+			errString: `invalid ingestion mode: "batch" (must be sync or async)`,
+		},
+		{
+			name: "negative ingestion batch size",
+			config: &Config{
+				Server: ServerConfig{Port: 8080},
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "jobs_db",
+				},
+				RabbitMQ: RabbitMQConfig{
+					Host: "localhost",
+					Port: 5672,
+					Exchange: ExchangeConfig{
+						Name: "jobs_exchange",
+					},
+					Queue: QueueConfig{
+						Name: "jobs_queue",
+					},
+				},
+				Ingestion: IngestionConfig{Mode: IngestionAsync, BatchSize: -1},
+			},
+			wantErr:   true,
+			errString: "invalid ingestion batch_size: -1 (must not be negative)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate(ProfileAPI)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errString)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_Results(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		return cfg
+	}
+
+	t.Run("inline backend needs no object store", func(t *testing.T) {
+		assert.NoError(t, newConfig().Validate(ProfileAPI))
+	})
+
+	t.Run("s3 backend requires the object store settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Results.Backend = "s3"
+		cfg.Results.URLExpiry = 8 * 24 * time.Hour
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			"invalid results url_expiry",
+			"results s3 endpoint is required",
+			"results s3 bucket is required",
+			"results s3 access_key_id and secret_access_key are required",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("valid s3 backend", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Results.Backend = "s3"
+		cfg.Results.S3 = S3Config{
+			Endpoint:        "http://minio:9000",
+			Region:          "us-east-1",
+			Bucket:          "job-results",
+			AccessKeyID:     "minio",
+			SecretAccessKey: "minio-secret",
+		}
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Results.Backend = "gcs"
+		assert.ErrorContains(t, cfg.Validate(ProfileAPI), "invalid results backend")
+	})
+}
+
+func TestConfig_Validate_Retention(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.Retention.Enabled = true
+		return cfg
+	}
+
+	t.Run("defaults purge at any time", func(t *testing.T) {
+		assert.NoError(t, newConfig().Validate(ProfileAPI))
+	})
+
+	t.Run("window wrapping past midnight", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Retention.WindowStart = "22:30"
+		cfg.Retention.WindowEnd = "04:00"
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Retention.MaxAge = 0
+		cfg.Retention.BatchSize = -1
+		cfg.Retention.WindowStart = "2am"
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			"invalid retention max_age: 0s (must be positive when retention is enabled)",
+			"invalid retention batch_size: -1",
+			`invalid retention window_start: "2am" (must be HH:MM)`,
+			"retention window_start and window_end must be set together",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("max age is not checked while disabled", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Retention.Enabled = false
+		cfg.Retention.MaxAge = 0
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+}
+
+func TestConfig_Validate_Secrets(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "localhost"
+	cfg.Database.Database = "jobs_db"
+	cfg.RabbitMQ.Host = "localhost"
+	cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+	cfg.RabbitMQ.Queue.Name = "jobs_queue"
+	cfg.Secrets.RefreshInterval = 5 * time.Minute
+	require.NoError(t, cfg.Validate(ProfileAPI))
+
+	cfg.Secrets.RefreshInterval = -time.Minute
+	assert.ErrorContains(t, cfg.Validate(ProfileAPI), "invalid secrets refresh_interval: -1m0s (must not be negative)")
+}
+
+func TestConfig_Validate_MessageCompression(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "localhost"
+	cfg.Database.Database = "jobs_db"
+	cfg.RabbitMQ.Host = "localhost"
+	cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+	cfg.RabbitMQ.Queue.Name = "jobs_queue"
+	cfg.RabbitMQ.Compression.Algorithm = "gzip"
+	require.NoError(t, cfg.Validate(ProfileAPI))
+
+	cfg.RabbitMQ.Compression = MessageCompressionConfig{Algorithm: "lz4", MinBytes: -1}
+	err := cfg.Validate(ProfileAPI)
+	assert.ErrorContains(t, err, "invalid rabbitmq compression algorithm: lz4 (must be none or gzip)")
+	assert.ErrorContains(t, err, "invalid rabbitmq compression min_bytes: -1 (must not be negative)")
+}
+
+func TestConfig_Validate_BrokerEncoding(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "localhost"
+	cfg.Database.Database = "jobs_db"
+	cfg.Broker.Type = BrokerMemory
+	cfg.Broker.Encoding = "protobuf"
+	require.NoError(t, cfg.Validate(ProfileAPI))
+	require.NoError(t, cfg.Validate(ProfileWorker))
+
+	cfg.Broker.Encoding = "msgpack"
+	assert.ErrorContains(t, cfg.Validate(ProfileAPI), `invalid broker encoding: "msgpack" (must be json or protobuf)`)
+}
+
+func TestConfig_Validate_CursorKey(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "localhost"
+	cfg.Database.Database = "jobs_db"
+	cfg.Broker.Type = BrokerMemory
+	cfg.Server.CursorKey = strings.Repeat("k", MinCursorKeyBytes)
+	require.NoError(t, cfg.Validate(ProfileAPI))
+
+	cfg.Server.CursorKey = "short"
+	assert.ErrorContains(t, cfg.Validate(ProfileAPI), "invalid server cursor_key: 5 bytes (must be at least 32)")
+}
+
+func TestConfig_Validate_ErrorReporting(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = "localhost"
+	cfg.Database.Database = "jobs_db"
+	cfg.RabbitMQ.Host = "localhost"
+	cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+	cfg.RabbitMQ.Queue.Name = "jobs_queue"
+	cfg.Logging.ErrorReporting.DSN = "https://abc123@o1.ingest.sentry.io/42"
+	require.NoError(t, cfg.Validate(ProfileAPI))
+	require.NoError(t, cfg.Validate(ProfileWorker))
+
+	cfg.Logging.ErrorReporting.DSN = "https://o1.ingest.sentry.io/42"
+	cfg.Logging.ErrorReporting.RateLimit = -1
+	err := cfg.Validate(ProfileWorker)
+	assert.ErrorContains(t, err, "invalid logging error_reporting dsn: invalid Sentry DSN: missing public key")
+	assert.ErrorContains(t, err, "invalid logging error_reporting rate_limit: -1 (must not be negative)")
+}
+
+func TestConfig_Validate_Events(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.Events.Enabled = true
+		return cfg
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		assert.NoError(t, newConfig().Validate(ProfileAPI))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Events.Exchange = ""
+		cfg.Events.Interval = -time.Second
+		cfg.Events.BatchSize = -1
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			"events exchange is required when events are enabled",
+			"invalid events interval: -1s",
+			"invalid events batch_size: -1",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("jobs exchange", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Events.Exchange = "jobs_exchange"
+		assert.ErrorContains(t, cfg.Validate(ProfileAPI), `invalid events exchange: "jobs_exchange" (must differ from the jobs exchange)`)
+	})
+
+	t.Run("exchange is not checked while disabled", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Events.Enabled = false
+		cfg.Events.Exchange = ""
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+}
+
+func TestConfig_Validate_Tenancy(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.Tenancy.Enabled = true
+		return cfg
+	}
+
+	t.Run("defaults resolve tenants from the header", func(t *testing.T) {
+		assert.NoError(t, newConfig().Validate(ProfileAPI))
+	})
+
+	t.Run("tokens without a header", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Tenancy.Header = ""
+		cfg.Tenancy.Tokens = map[string]string{"s3cret": "acme"}
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Tenancy.Header = ""
+		cfg.Tenancy.Tokens = map[string]string{"a-token": "acme", "b-token": "acme corp"}
+		cfg.Tenancy.Quotas.Default.MaxPendingJobs = -1
+		cfg.Tenancy.Quotas.Tenants = map[string]TenantQuotaConfig{
+			"acme":   {MaxJobsPerDay: -5},
+			"acme/x": {},
+		}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			`invalid tenancy tokens entry 1: tenant "acme corp"`,
+			"invalid tenancy quotas default max_pending_jobs: -1 (must not be negative)",
+			`invalid tenancy quotas tenants["acme"] max_jobs_per_day: -5 (must not be negative)`,
+			`invalid tenancy quotas tenants key: "acme/x"`,
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+		assert.NotContains(t, err.Error(), "b-token")
+	})
+
+	t.Run("enabled without a header or tokens", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Tenancy.Header = ""
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy header or tokens are required when tenancy is enabled")
+	})
+}
+
+func TestConfig_Validate_Partitions(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.RabbitMQ.Partitions = 4
+		return cfg
+	}
+
+	t.Run("requires a consistent-hash exchange and single active consumers", func(t *testing.T) {
+		err := newConfig().Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "require exchange type x-consistent-hash")
+		assert.Contains(t, err.Error(), "require queue single_active_consumer")
+	})
+
+	t.Run("valid partitioned topology", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Exchange.Type = "x-consistent-hash"
+		cfg.RabbitMQ.Queue.SingleActiveConsumer = true
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+}
+
+func TestConfig_Validate_Sharding(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.RabbitMQ.RoutingKey = "job.created"
+		cfg.RabbitMQ.Sharding.Shards = 4
+		return cfg
+	}
+
+	t.Run("valid sharded topology", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Sharding.Consume = []int{0, 3}
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+		assert.Equal(t, "job.created.{shard}", cfg.RabbitMQ.ShardRoutingKey())
+	})
+
+	t.Run("rejects an unroutable topology", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Partitions = 2
+		cfg.RabbitMQ.Exchange.Type = "x-consistent-hash"
+		cfg.RabbitMQ.Queue.SingleActiveConsumer = true
+		cfg.RabbitMQ.Sharding.Key = "tenant_id"
+		cfg.RabbitMQ.Sharding.RoutingKey = "job.created"
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sharding cannot be combined with partitions")
+		assert.Contains(t, err.Error(), `invalid rabbitmq sharding key: "tenant_id"`)
+		assert.Contains(t, err.Error(), "sharding requires exchange type direct or topic")
+		assert.Contains(t, err.Error(), "sharding routing_key must contain {shard}")
+	})
+
+	t.Run("rejects unknown and repeated consumed shards", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Sharding.Consume = []int{1, 4, 1}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid rabbitmq sharding consume shard: 4 (must be between 0 and 3)")
+		assert.Contains(t, err.Error(), "consume shard 1 is listed twice")
+	})
+}
+
+func TestConfig_Validate_QueueArguments(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.RabbitMQ.Queue.Durable = true
+		return cfg
+	}
+
+	t.Run("quorum queue", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Queue.Arguments = map[string]any{"x-queue-type": "quorum", "x-max-length": 10000, "x-overflow": "reject-publish"}
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("quorum queues are durable", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Queue.Durable = false
+		cfg.RabbitMQ.Queue.Arguments = map[string]any{"x-queue-type": "quorum", "x-queue-mode": "lazy"}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rabbitmq quorum queues must be durable and cannot be auto_delete or exclusive")
+		assert.Contains(t, err.Error(), "rabbitmq queue argument x-queue-mode only applies to classic queues")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.RabbitMQ.Queue.Arguments = map[string]any{"x-queue-type": "stream", "x-message-ttl": []any{1}}
+		cfg.RabbitMQ.Exchange.Arguments = map[string]any{"alternate-exchange": map[string]any{"name": "unrouted"}}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			"invalid rabbitmq exchange argument alternate-exchange: map[name:unrouted] (must be a string, number or boolean)",
+			"invalid rabbitmq queue argument x-message-ttl: [1] (must be a string, number or boolean)",
+			"invalid rabbitmq queue argument x-queue-type: stream (must be classic or quorum)",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+}
+
+func TestConfig_Validate_TLS(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		return cfg
+	}
+
+	t.Run("verified connections", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Database.SSLMode = "verify-full"
+		cfg.Database.SSLRootCert = "/etc/ssl/db/ca.pem"
+		cfg.Database.SSLCert = "/etc/ssl/db/client.pem"
+		cfg.Database.SSLKey = "/etc/ssl/db/client.key"
+		cfg.RabbitMQ.Port = 5671
+		cfg.RabbitMQ.TLS = TLSConfig{Enabled: true, CAFile: "/etc/ssl/rabbitmq/ca.pem", ServerName: "rabbitmq.internal"}
+		cfg.Server.AdminToken = "s3cret"
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.App.Environment = "production"
+		cfg.Database.SSLMode = "prefer"
+		cfg.Database.SSLCert = "/etc/ssl/db/client.pem"
+		cfg.RabbitMQ.TLS = TLSConfig{Enabled: true, KeyFile: "/etc/ssl/rabbitmq/client.key", InsecureSkipVerify: true}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			`invalid database sslmode: "prefer" (must be disable, require, verify-ca or verify-full)`,
+			"database sslcert and sslkey must be set together",
+			"rabbitmq tls cert_file and key_file must be set together",
+			"rabbitmq tls insecure_skip_verify must not be enabled in production",
+			"server admin_token is required in production",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("required in production", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.App.Environment = "production"
+		cfg.Database.SSLMode = "disable"
+
+		err := cfg.Validate(ProfileWorker)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database sslmode must not be disable in production")
+		assert.Contains(t, err.Error(), "rabbitmq tls must be enabled in production")
+
+		cfg.App.Environment = "staging"
+		assert.NoError(t, cfg.Validate(ProfileWorker))
+	})
+
+	t.Run("kafka", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.App.Environment = "production"
+		cfg.Database.SSLMode = "verify-full"
+		cfg.Broker.Type = BrokerKafka
+		cfg.Kafka.Brokers = []string{"kafka-1:9093"}
+
+		err := cfg.Validate(ProfileWorker)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "kafka tls must be enabled in production")
+		assert.NotContains(t, err.Error(), "rabbitmq tls")
+
+		cfg.Kafka.TLS = TLSConfig{Enabled: true, CertFile: "/etc/ssl/kafka/client.pem", InsecureSkipVerify: true}
+		err = cfg.Validate(ProfileWorker)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "kafka tls cert_file and key_file must be set together")
+		assert.Contains(t, err.Error(), "kafka tls insecure_skip_verify must not be enabled in production")
+
+		cfg.Kafka.TLS = TLSConfig{Enabled: true, CAFile: "/etc/ssl/kafka/ca.pem"}
+		assert.NoError(t, cfg.Validate(ProfileWorker))
+	})
+}
+
+func TestConfig_MaxMessageBytes(t *testing.T) {
+	cfg := Default()
+	assert.Equal(t, 128<<20, cfg.MaxMessageBytes())
+
+	cfg.Broker.Type = BrokerMemory
+	assert.Equal(t, 128<<20, cfg.MaxMessageBytes(), "the memory broker keeps the rabbitmq limit")
+
+	cfg.Broker.Type = BrokerKafka
+	assert.Equal(t, 1<<20, cfg.MaxMessageBytes())
+
+	cfg.Kafka.MaxMessageBytes = 0
+	assert.Equal(t, 1<<20, cfg.MaxMessageBytes(), "0 uses Kafka's default message.max.bytes")
+}
+
+func TestConfig_Validate_Profiles(t *testing.T) {
+	t.Run("all violations are reported at once", func(t *testing.T) {
+		cfg := &Config{
+			Server:   ServerConfig{Port: 0},
+			Database: DatabaseConfig{Port: 5432},
+			RabbitMQ: RabbitMQConfig{Host: "localhost", Port: 5672},
+		}
+
+		err := cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		for _, want := range []string{
+			"invalid server port",
+			"database host is required",
+			"database name is required",
+			"rabbitmq exchange name is required",
+			"rabbitmq queue name is required",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("worker profile ignores API-only sections", func(t *testing.T) {
+		cfg := &Config{
+			Server: ServerConfig{Port: 0},
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				Database: "jobs_db",
+			},
+			RabbitMQ: RabbitMQConfig{
+				Host:     "localhost",
+				Port:     5672,
+				Exchange: ExchangeConfig{Name: "jobs_exchange"},
+				Queue:    QueueConfig{Name: "jobs_queue"},
+			},
+			Estimation: EstimationConfig{WorkerCapacity: -1},
+		}
+		cfg.RabbitMQ.Consumer.PrefetchCount = 10
+
+		assert.NoError(t, cfg.Validate(ProfileWorker))
+		assert.Error(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("worker profile requires a prefetch count", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs_queue"
+		cfg.RabbitMQ.Consumer.PrefetchCount = 0
+
+		err := cfg.Validate(ProfileWorker)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "prefetch_count")
+		assert.NoError(t, cfg.Validate(ProfileAPI))
+	})
+
+	t.Run("extra queues", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.RabbitMQ.Host = "localhost"
+		cfg.RabbitMQ.Exchange.Name = "jobs_exchange"
+		cfg.RabbitMQ.Queue.Name = "jobs.default"
+		cfg.RabbitMQ.Queues = []QueueBindingConfig{
+			{Name: "jobs.high", RoutingKey: "job.created.high", PrefetchCount: 5, Concurrency: 8},
+		}
+		require.NoError(t, cfg.Validate(ProfileWorker))
+
+		cfg.RabbitMQ.Queues = append(cfg.RabbitMQ.Queues,
+			QueueBindingConfig{Name: "jobs.default", RoutingKey: "job.created.low"},
+			QueueBindingConfig{Name: "jobs.low", Concurrency: -1},
+		)
+		err := cfg.Validate(ProfileWorker)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `queues[1] name "jobs.default" is already used`)
+		assert.Contains(t, err.Error(), "queues[2] routing_key is required")
+		assert.Contains(t, err.Error(), "queues[2] concurrency")
+		assert.NotContains(t, cfg.Validate(ProfileAPI).Error(), "concurrency")
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		err := Default().Validate(Profile("scheduler"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown config profile")
+	})
+}
+
+func TestLoad_ValidateIntegration(t *testing.T) {
+	clearEnvOverrides(t)
+
+	t.Run("load and validate valid config", func(t *testing.T) {
+		cfg, err := Load("testdata/valid_config.yaml")
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+
+		err = cfg.Validate(ProfileAPI)
+		require.NoError(t, err)
+	})
+
+	t.Run("load config with invalid port", func(t *testing.T) {
+		cfg, err := Load("testdata/invalid_port.yaml")
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+
+		err = cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid server port")
+	})
+
+	t.Run("load config with missing database", func(t *testing.T) {
+		cfg, err := Load("testdata/missing_database.yaml")
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+
+		err = cfg.Validate(ProfileAPI)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database name is required")
+	})
+}
+
+func TestPortConstants(t *testing.T) {
+	t.Run("port constants are correct", func(t *testing.T) {
+		assert.Equal(t, 1, MinPort)
+		assert.Equal(t, 65535, MaxPort)
+	})
+
+	t.Run("valid port range", func(t *testing.T) {
+		validPorts := []int{1, 80, 443, 8080, 65535}
+		for _, port := range validPorts {
+			assert.GreaterOrEqual(t, port, MinPort)
+			assert.LessOrEqual(t, port, MaxPort)
+		}
+	})
+
+	t.Run("invalid port range", func(t *testing.T) {
+		invalidPorts := []int{0, -1, 65536, 70000}
+		for _, port := range invalidPorts {
+			valid := port >= MinPort && port <= MaxPort
+			assert.False(t, valid, "port %d should be invalid", port)
+		}
+	})
+}
+
+func TestConfig_Validate_StartupWait(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := Default()
+		cfg.Database.Host = "localhost"
+		cfg.Database.Database = "jobs_db"
+		cfg.Broker.Type = BrokerMemory
+		return cfg
+	}
+
+	require.NoError(t, newConfig().Validate(ProfileWorker))
+
+	cfg := newConfig()
+	cfg.Database.Connect = DatabaseConnectConfig{WaitTimeout: time.Minute, RetryInterval: 0}
+	cfg.Startup.MaxRetryInterval = 0
+
+	err := cfg.Validate(ProfileWorker)
+	require.Error(t, err)
+	for _, want := range []string{
+		"invalid database connect retry_interval: 0s (must be positive when wait_timeout is set)",
+		"invalid startup max_retry_interval: 0s (must be at least retry_interval 1s)",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	cfg = newConfig()
+	cfg.Database.Connect = DatabaseConnectConfig{}
+	assert.NoError(t, cfg.Validate(ProfileWorker), "a zero wait tries once")
 }
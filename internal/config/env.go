@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default} references in config files
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// expandEnv replaces ${VAR} and ${VAR:-default} references with values from lookup.
+// Unset variables without a default expand to an empty string. Bare $VAR is left
+// untouched so literal dollar signs (e.g. in passwords) survive.
+func expandEnv(data []byte, lookup func(string) (string, bool)) []byte {
+	return envVarPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := envVarPattern.FindSubmatch(match)
+		if value, ok := lookup(string(groups[1])); ok && value != "" {
+			return []byte(value)
+		}
+		return groups[2]
+	})
+}
+
+// EnvKeys returns every environment variable name that can override a config field
+func EnvKeys() []string {
+	var keys []string
+	walkEnvFields(reflect.ValueOf(&Config{}).Elem(), func(key string, _ reflect.Value) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// applyEnvOverrides sets config fields from environment variables.
+// The variable name is the upper-cased yaml path joined with underscores
+// (database.password -> DATABASE_PASSWORD) unless the field has an env tag;
+// env:"-" excludes a field. Empty values are ignored.
+func applyEnvOverrides(cfg *Config, lookup func(string) (string, bool)) error {
+	return walkEnvFields(reflect.ValueOf(cfg).Elem(), func(key string, field reflect.Value) error {
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			return nil
+		}
+
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// walkEnvFields calls fn for every leaf field of v with its environment variable name
+func walkEnvFields(v reflect.Value, fn func(key string, field reflect.Value) error) error {
+	return walkLeafFields(v, nil, func(path []string, sf reflect.StructField, field reflect.Value) error {
+		key := sf.Tag.Get("env")
+		if key == "-" {
+			return nil
+		}
+		if key == "" {
+			key = strings.ToUpper(strings.Join(path, "_"))
+		}
+		return fn(key, field)
+	})
+}
+
+// walkLeafFields calls fn for every non-struct field of v (time.Duration counts as a leaf)
+// with the yaml names leading to it
+func walkLeafFields(v reflect.Value, path []string, fn func(path []string, sf reflect.StructField, field reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldPath := append(append([]string{}, path...), name)
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			if err := walkLeafFields(field, fieldPath, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(fieldPath, sf, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField parses value into field according to its type
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		// Comma-separated, e.g. LOGGING_REDACT=card_number,ssn
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapLookup returns an env lookup function backed by a map
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+// writeConfig writes content to a temporary config file and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	t.Run("env overrides file values", func(t *testing.T) {
+		cfg, err := load("testdata/valid_config.yaml", mapLookup(map[string]string{
+			"DATABASE_PASSWORD":             "s3cret",
+			"DATABASE_NAME":                 "other_db",
+			"RABBITMQ_HOST":                 "rabbit.internal",
+			"RABBITMQ_QUEUE_NAME":           "jobs_high",
+			"SERVER_PORT":                   "9090",
+			"SERVER_READ_TIMEOUT":           "3s",
+			"RABBITMQ_EXCHANGE_DURABLE":     "false",
+			"RABBITMQ_CONNECTION_HEARTBEAT": "15s",
+			"LOGGING_LEVEL":                 "warn",
+			"LOGGING_REDACT":                "card_number, ssn",
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "s3cret", cfg.Database.Password)
+		assert.Equal(t, "other_db", cfg.Database.Database)
+		assert.Equal(t, "rabbit.internal", cfg.RabbitMQ.Host)
+		assert.Equal(t, "jobs_high", cfg.RabbitMQ.Queue.Name)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout)
+		assert.False(t, cfg.RabbitMQ.Exchange.Durable)
+		assert.Equal(t, 15*time.Second, cfg.RabbitMQ.Connection.Heartbeat)
+		assert.Equal(t, "warn", cfg.Logging.Level)
+		assert.Equal(t, []string{"card_number", "ssn"}, cfg.Logging.Redact)
+
+		// Untouched fields keep their file values
+		assert.Equal(t, "localhost", cfg.Database.Host)
+	})
+
+	t.Run("empty env values are ignored", func(t *testing.T) {
+		cfg, err := load("testdata/valid_config.yaml", mapLookup(map[string]string{
+			"DATABASE_HOST": "",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", cfg.Database.Host)
+	})
+
+	t.Run("defaults fill fields missing from file", func(t *testing.T) {
+		path := writeConfig(t, "database:\n  host: db\n  database: jobs_db\n")
+
+		cfg, err := load(path, mapLookup(nil))
+		require.NoError(t, err)
+
+		defaults := Default()
+		assert.Equal(t, "db", cfg.Database.Host)
+		assert.Equal(t, defaults.Server.Port, cfg.Server.Port)
+		assert.Equal(t, defaults.Database.Port, cfg.Database.Port)
+		assert.Equal(t, defaults.RabbitMQ.Connection.RetryAttempts, cfg.RabbitMQ.Connection.RetryAttempts)
+		assert.Equal(t, defaults.Logging.Level, cfg.Logging.Level)
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		_, err := load("testdata/valid_config.yaml", mapLookup(map[string]string{
+			"SERVER_PORT": "not-a-number",
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SERVER_PORT")
+	})
+}
+
+func TestLoad_EnvExpansion(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  host: ${DB_HOST}
+  password: ${DB_PASSWORD:-fallback}
+  user: pa$word
+  database: ${MISSING}
+`)
+
+	cfg, err := load(path, mapLookup(map[string]string{
+		"DB_HOST": "db.internal",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, "fallback", cfg.Database.Password)
+	assert.Equal(t, "pa$word", cfg.Database.User)
+	assert.Equal(t, "", cfg.Database.Database)
+}
+
+func TestEnvKeys(t *testing.T) {
+	keys := EnvKeys()
+
+	assert.Contains(t, keys, "SERVER_PORT")
+	assert.Contains(t, keys, "DATABASE_PASSWORD")
+	assert.Contains(t, keys, "DATABASE_NAME")
+	assert.NotContains(t, keys, "DATABASE_DATABASE")
+	assert.Contains(t, keys, "RABBITMQ_HOST")
+	assert.Contains(t, keys, "RABBITMQ_EXCHANGE_NAME")
+	assert.Contains(t, keys, "RABBITMQ_CONNECTION_RETRY_INTERVAL")
+	assert.Contains(t, keys, "LOGGING_LEVEL")
+	assert.NotContains(t, keys, "RABBITMQ_QUEUES")
+}
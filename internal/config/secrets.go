@@ -0,0 +1,216 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SecretResolver fetches secret values from an external store.
+// ref is the part of a secret reference after the "<scheme>:" prefix.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// readSecretFiles loads *_file secret fields, e.g. database.password_file.
+// Setting both a secret and its file variant is rejected as ambiguous.
+func readSecretFiles(cfg *Config) error {
+	secretFiles := []struct {
+		name  string
+		value *string
+		file  string
+	}{
+		{name: "database.password", value: &cfg.Database.Password, file: cfg.Database.PasswordFile},
+		{name: "rabbitmq.password", value: &cfg.RabbitMQ.Password, file: cfg.RabbitMQ.PasswordFile},
+		{name: "kafka.sasl.password", value: &cfg.Kafka.SASL.Password, file: cfg.Kafka.SASL.PasswordFile},
+		{name: "secrets.vault.token", value: &cfg.Secrets.Vault.Token, file: cfg.Secrets.Vault.TokenFile},
+		{name: "server.cursor_key", value: &cfg.Server.CursorKey, file: cfg.Server.CursorKeyFile},
+		{name: "results.s3.secret_access_key", value: &cfg.Results.S3.SecretAccessKey, file: cfg.Results.S3.SecretKeyFile},
+	}
+
+	for _, sf := range secretFiles {
+		if sf.file == "" {
+			continue
+		}
+
+		if *sf.value != "" {
+			return fmt.Errorf("%s and %s_file are mutually exclusive", sf.name, sf.name)
+		}
+
+		data, err := os.ReadFile(sf.file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_file: %w", sf.name, err)
+		}
+
+		*sf.value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return nil
+}
+
+// ResolveSecrets replaces secret fields holding "<scheme>:<ref>" references with values
+// from the matching external store. Only the vault scheme is built in; callers can pass
+// additional resolvers (e.g. an AWS Secrets Manager client) keyed by scheme.
+func ResolveSecrets(ctx context.Context, cfg *Config, extra map[string]SecretResolver) error {
+	resolvers := make(map[string]SecretResolver, len(extra)+1)
+	if cfg.Secrets.Vault.Address != "" {
+		resolvers["vault"] = NewVaultResolver(&cfg.Secrets.Vault)
+	}
+	for scheme, r := range extra {
+		resolvers[scheme] = r
+	}
+
+	return walkLeafFields(reflect.ValueOf(cfg).Elem(), nil, func(path []string, sf reflect.StructField, field reflect.Value) error {
+		if sf.Tag.Get("secret") != "true" || field.Kind() != reflect.String {
+			return nil
+		}
+
+		scheme, ref, ok := strings.Cut(field.String(), ":")
+		if !ok || !isSecretScheme(scheme) {
+			return nil
+		}
+
+		name := strings.Join(path, ".")
+		resolver, found := resolvers[scheme]
+		if !found {
+			return fmt.Errorf("%s references a %s secret but no %s resolver is configured", name, scheme, scheme)
+		}
+
+		value, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+
+		field.SetString(value)
+		return nil
+	})
+}
+
+// MaskedValue replaces secret values in Masked configs
+const MaskedValue = "******"
+
+// Masked returns a copy of the config with secret values replaced by MaskedValue, for
+// printing. References to external secret stores are kept, since they name a secret
+// without revealing it. Secret maps, such as tenancy tokens, keep their values and have
+// their keys masked.
+func (c *Config) Masked() *Config {
+	masked := *c
+	_ = walkLeafFields(reflect.ValueOf(&masked).Elem(), nil, func(_ []string, sf reflect.StructField, field reflect.Value) error {
+		if sf.Tag.Get("secret") != "true" {
+			return nil
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			scheme, _, isRef := strings.Cut(field.String(), ":")
+			if field.String() != "" && !(isRef && isSecretScheme(scheme)) {
+				field.SetString(MaskedValue)
+			}
+		case reflect.Map:
+			if field.Len() == 0 {
+				return nil
+			}
+			keys := field.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+			// A new map, so the original config keeps its keys
+			maskedMap := reflect.MakeMapWithSize(field.Type(), len(keys))
+			for i, key := range keys {
+				maskedKey := reflect.ValueOf(fmt.Sprintf("%s%d", MaskedValue, i+1)).Convert(field.Type().Key())
+				maskedMap.SetMapIndex(maskedKey, field.MapIndex(key))
+			}
+			field.Set(maskedMap)
+		}
+		return nil
+	})
+	return &masked
+}
+
+// isSecretScheme reports whether scheme looks like a secret store prefix rather than
+// part of a literal password (which may legitimately contain a colon)
+func isSecretScheme(scheme string) bool {
+	switch scheme {
+	case "vault", "aws-sm":
+		return true
+	default:
+		return false
+	}
+}
+
+// VaultResolver reads secrets from the HashiCorp Vault HTTP API.
+// References have the form <path>#<key>; both KV v1 and KV v2 responses are supported.
+type VaultResolver struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewVaultResolver creates a VaultResolver from Vault configuration
+func NewVaultResolver(cfg *VaultConfig) *VaultResolver {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &VaultResolver{
+		address: strings.TrimRight(cfg.Address, "/"),
+		token:   cfg.Token,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Resolve fetches the key from the secret at path
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %q (expected <path>#<key>)", ref)
+	}
+
+	endpoint, err := url.JoinPath(v.address, "v1", path)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	// KV v2 nests the secret under data.data
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %q not found in vault secret %s", key, path)
+	}
+
+	return value, nil
+}
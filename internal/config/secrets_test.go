@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSecret writes a secret file and returns its path
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	t.Run("password files are read and trimmed", func(t *testing.T) {
+		dbSecret := writeSecret(t, "db-pass\n")
+		mqSecret := writeSecret(t, "mq-pass")
+		path := writeConfig(t, `
+database:
+  host: localhost
+  database: jobs_db
+  password_file: `+dbSecret+`
+rabbitmq:
+  password_file: `+mqSecret+`
+`)
+
+		cfg, err := load(path, mapLookup(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "db-pass", cfg.Database.Password)
+		assert.Equal(t, "mq-pass", cfg.RabbitMQ.Password)
+	})
+
+	t.Run("file variant can come from env", func(t *testing.T) {
+		dbSecret := writeSecret(t, "from-env-file")
+
+		cfg, err := load(writeConfig(t, "database:\n  host: localhost\n"), mapLookup(map[string]string{
+			"DATABASE_PASSWORD_FILE": dbSecret,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "from-env-file", cfg.Database.Password)
+	})
+
+	t.Run("password and password_file are mutually exclusive", func(t *testing.T) {
+		dbSecret := writeSecret(t, "db-pass")
+		path := writeConfig(t, "database:\n  password: inline\n  password_file: "+dbSecret+"\n")
+
+		_, err := load(path, mapLookup(nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mutually exclusive")
+	})
+
+	t.Run("missing secret file", func(t *testing.T) {
+		path := writeConfig(t, "rabbitmq:\n  password_file: /nonexistent/secret\n")
+
+		_, err := load(path, mapLookup(nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rabbitmq.password_file")
+	})
+}
+
+// newVaultServer serves a KV v2 secret at secret/data/jobs
+func newVaultServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/jobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"db_password": "vault-db-pass",
+				},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultResolver_Resolve(t *testing.T) {
+	srv := newVaultServer(t)
+	resolver := NewVaultResolver(&VaultConfig{Address: srv.URL, Token: "test-token"})
+
+	tests := []struct {
+		name      string
+		ref       string
+		want      string
+		errString string
+	}{
+		{name: "existing key", ref: "secret/data/jobs#db_password", want: "vault-db-pass"},
+		{name: "missing key", ref: "secret/data/jobs#other", errString: "not found"},
+		{name: "missing secret", ref: "secret/data/other#db_password", errString: "status 404"},
+		{name: "malformed reference", ref: "secret/data/jobs", errString: "invalid vault reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := resolver.Resolve(context.Background(), tt.ref)
+			if tt.errString != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errString)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
+
+// staticResolver resolves every reference to a fixed value
+type staticResolver struct {
+	value string
+	err   error
+}
+
+func (s staticResolver) Resolve(context.Context, string) (string, error) {
+	return s.value, s.err
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Run("resolves vault references in secret fields", func(t *testing.T) {
+		srv := newVaultServer(t)
+		cfg := Default()
+		cfg.Secrets.Vault.Address = srv.URL
+		cfg.Secrets.Vault.Token = "test-token"
+		cfg.Database.Password = "vault:secret/data/jobs#db_password"
+		cfg.RabbitMQ.Password = "plain:password"
+
+		require.NoError(t, ResolveSecrets(context.Background(), cfg, nil))
+		assert.Equal(t, "vault-db-pass", cfg.Database.Password)
+		// Values without a known scheme are literals
+		assert.Equal(t, "plain:password", cfg.RabbitMQ.Password)
+	})
+
+	t.Run("extra resolvers handle other schemes", func(t *testing.T) {
+		cfg := Default()
+		cfg.RabbitMQ.Password = "aws-sm:jobs/rabbitmq#password"
+
+		err := ResolveSecrets(context.Background(), cfg, map[string]SecretResolver{
+			"aws-sm": staticResolver{value: "aws-pass"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "aws-pass", cfg.RabbitMQ.Password)
+	})
+
+	t.Run("reference without configured resolver", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.Password = "vault:secret/data/jobs#db_password"
+
+		err := ResolveSecrets(context.Background(), cfg, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database.password")
+	})
+
+	t.Run("resolver error", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.Password = "aws-sm:jobs/db#password"
+
+		err := ResolveSecrets(context.Background(), cfg, map[string]SecretResolver{
+			"aws-sm": staticResolver{err: errors.New("access denied")},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "access denied")
+	})
+}
+
+func TestConfig_Masked(t *testing.T) {
+	cfg := Default()
+	cfg.Server.AdminToken = "admin-token"
+	cfg.Database.Password = "db-pass"
+	cfg.RabbitMQ.Password = "vault:secret/data/jobs#rabbitmq_password"
+	cfg.Tenancy.Tokens = map[string]string{"token-b": "beta", "token-a": "acme"}
+
+	masked := cfg.Masked()
+	assert.Equal(t, MaskedValue, masked.Server.AdminToken)
+	assert.Equal(t, MaskedValue, masked.Database.Password)
+	assert.Equal(t, "vault:secret/data/jobs#rabbitmq_password", masked.RabbitMQ.Password, "references name a secret without revealing it")
+	assert.Empty(t, masked.Results.S3.SecretAccessKey, "unset secrets stay empty")
+	assert.Equal(t, map[string]string{MaskedValue + "1": "acme", MaskedValue + "2": "beta"}, masked.Tenancy.Tokens)
+
+	// The original is untouched
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+	assert.Equal(t, map[string]string{"token-b": "beta", "token-a": "acme"}, cfg.Tenancy.Tokens)
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// reloadableFields lists config paths that can be applied to a running service.
+// Any other change (ports, DSNs, queue topology) only takes effect after a restart.
+var reloadableFields = map[string]bool{
+	"logging.level":       true,
+	"database.password":   true,
+	"rabbitmq.password":   true,
+	"maintenance.enabled": true,
+	"maintenance.message": true,
+}
+
+// secretsResolveTimeout bounds external secret lookups during a reload
+const secretsResolveTimeout = 10 * time.Second
+
+// Changes describes the fields that differ between two configurations
+type Changes struct {
+	Reloadable      []string // Applied by the reload callback
+	RestartRequired []string // Ignored until the service is restarted
+}
+
+// Empty reports whether no fields changed
+func (c Changes) Empty() bool {
+	return len(c.Reloadable) == 0 && len(c.RestartRequired) == 0
+}
+
+// Diff compares two configurations and classifies each changed field by its yaml path
+func Diff(oldCfg, newCfg *Config) Changes {
+	var changed []string
+	diffFields(reflect.ValueOf(oldCfg).Elem(), reflect.ValueOf(newCfg).Elem(), "", &changed)
+
+	var changes Changes
+	for _, path := range changed {
+		if reloadableFields[path] {
+			changes.Reloadable = append(changes.Reloadable, path)
+		} else {
+			changes.RestartRequired = append(changes.RestartRequired, path)
+		}
+	}
+	return changes
+}
+
+// diffFields appends the yaml path of every leaf field that differs between a and b
+func diffFields(a, b reflect.Value, prefix string, changed *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct && fa.Type() != durationType {
+			diffFields(fa, fb, path, changed)
+			continue
+		}
+
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			*changed = append(*changed, path)
+		}
+	}
+}
+
+// ReloadFunc applies a reloaded configuration. Only fields listed in changes.Reloadable
+// should be acted upon; cfg is the full, validated configuration. On error the changes
+// are not recorded as applied, so the next reload tries them again.
+type ReloadFunc func(cfg *Config, changes Changes) error
+
+// Watcher reloads the configuration file on SIGHUP or when the modification time of it or
+// of a password file changes, and every secrets.refresh_interval
+type Watcher struct {
+	path         string
+	profile      Profile
+	logger       *slog.Logger
+	pollInterval time.Duration
+	onReload     ReloadFunc
+
+	current     *Config
+	modTime     time.Time
+	secretFiles map[string]time.Time // Password file modification times by path
+}
+
+// NewWatcher creates a Watcher for the config file at path, validating reloads against
+// profile. current is the configuration the service was started with. A pollInterval
+// of 0 disables file modification polling.
+func NewWatcher(path string, profile Profile, current *Config, logger *slog.Logger, pollInterval time.Duration, onReload ReloadFunc) *Watcher {
+	w := &Watcher{
+		path:         path,
+		profile:      profile,
+		logger:       logger,
+		pollInterval: pollInterval,
+		onReload:     onReload,
+		current:      current,
+		secretFiles:  make(map[string]time.Time),
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	for _, file := range []string{current.Database.PasswordFile, current.RabbitMQ.PasswordFile} {
+		if file == "" {
+			continue
+		}
+		w.secretFiles[file] = time.Time{}
+		if info, err := os.Stat(file); err == nil {
+			w.secretFiles[file] = info.ModTime()
+		}
+	}
+
+	return w
+}
+
+// Run watches for SIGHUP and file changes until ctx is canceled
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if w.pollInterval > 0 {
+		ticker := time.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	// Vault secrets can change without any file changing, so they are re-read periodically
+	var refresh <-chan time.Time
+	if w.current.Secrets.RefreshInterval > 0 {
+		ticker := time.NewTicker(w.current.Secrets.RefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.logger.Info("Received SIGHUP, reloading configuration", slog.String("path", w.path))
+			w.reloadAndLog(slog.LevelInfo)
+		case <-poll:
+			if w.fileChanged() {
+				w.logger.Info("Configuration file changed, reloading", slog.String("path", w.path))
+				w.reloadAndLog(slog.LevelInfo)
+			} else if file := w.secretFileChanged(); file != "" {
+				w.logger.Info("Password file changed, reloading", slog.String("path", file))
+				w.reloadAndLog(slog.LevelInfo)
+			}
+		case <-refresh:
+			w.reloadAndLog(slog.LevelDebug)
+		}
+	}
+}
+
+// reloadAndLog reloads and logs failures; an unchanged configuration is logged at unchangedLevel
+func (w *Watcher) reloadAndLog(unchangedLevel slog.Level) {
+	if _, err := w.reload(unchangedLevel); err != nil {
+		w.logger.Error("Failed to reload configuration, keeping current settings",
+			slog.String("path", w.path),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// Reload loads and validates the config file, applies reloadable changes through the
+// callback and returns the detected changes. The current configuration is kept on error.
+func (w *Watcher) Reload() (Changes, error) {
+	return w.reload(slog.LevelInfo)
+}
+
+func (w *Watcher) reload(unchangedLevel slog.Level) (Changes, error) {
+	cfg, err := Load(w.path)
+	if err != nil {
+		return Changes{}, err
+	}
+
+	// Resolve external secret references so they compare equal to the running values
+	ctx, cancel := context.WithTimeout(context.Background(), secretsResolveTimeout)
+	defer cancel()
+	if err := ResolveSecrets(ctx, cfg, nil); err != nil {
+		return Changes{}, err
+	}
+
+	if err := cfg.Validate(w.profile); err != nil {
+		return Changes{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	changes := Diff(w.current, cfg)
+	if changes.Empty() {
+		w.logger.Log(context.Background(), unchangedLevel, "Configuration unchanged")
+		return changes, nil
+	}
+
+	if len(changes.RestartRequired) > 0 {
+		w.logger.Warn("Configuration changes require a restart to take effect",
+			slog.Any("fields", changes.RestartRequired),
+		)
+	}
+
+	if len(changes.Reloadable) > 0 {
+		if err := w.onReload(cfg, changes); err != nil {
+			return changes, fmt.Errorf("failed to apply configuration: %w", err)
+		}
+		w.logger.Info("Configuration reloaded",
+			slog.Any("applied", changes.Reloadable),
+		)
+	}
+
+	// Only reloadable fields are live; keep the startup values for everything else so
+	// the same restart-required warning is repeated until the service is restarted
+	for _, path := range changes.Reloadable {
+		copyField(w.current, cfg, path)
+	}
+
+	return changes, nil
+}
+
+// fileChanged reports whether the config file modification time moved since the last check
+func (w *Watcher) fileChanged() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+
+	if info.ModTime().Equal(w.modTime) {
+		return false
+	}
+
+	w.modTime = info.ModTime()
+	return true
+}
+
+// secretFileChanged returns the first password file whose modification time moved since
+// the last check, or "" if none did
+func (w *Watcher) secretFileChanged() string {
+	files := make([]string, 0, len(w.secretFiles))
+	for file := range w.secretFiles {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	changed := ""
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.ModTime().Equal(w.secretFiles[file]) {
+			continue
+		}
+		w.secretFiles[file] = info.ModTime()
+		if changed == "" {
+			changed = file
+		}
+	}
+	return changed
+}
+
+// copyField copies the field at the dotted yaml path from src into dst
+func copyField(dst, src *Config, path string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, name := range strings.Split(path, ".") {
+		idx := fieldIndexByYAML(d.Type(), name)
+		if idx < 0 {
+			return
+		}
+		d, s = d.Field(idx), s.Field(idx)
+	}
+	d.Set(s)
+}
+
+func fieldIndexByYAML(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] == name {
+			return i
+		}
+	}
+	return -1
+}
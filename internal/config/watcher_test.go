@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	base, err := load("testdata/valid_config.yaml", mapLookup(nil))
+	require.NoError(t, err)
+
+	t.Run("identical configs", func(t *testing.T) {
+		other := *base
+		assert.True(t, Diff(base, &other).Empty())
+	})
+
+	t.Run("classifies reloadable and restart-required fields", func(t *testing.T) {
+		other := *base
+		other.Logging.Level = "warn"
+		other.Database.Password = "rotated"
+		other.Maintenance.Enabled = true
+		other.Server.Port = 9090
+		other.Database.Host = "db.internal"
+		other.RabbitMQ.Connection.Heartbeat = time.Minute
+
+		changes := Diff(base, &other)
+		assert.ElementsMatch(t, []string{"logging.level", "database.password", "maintenance.enabled"}, changes.Reloadable)
+		assert.ElementsMatch(t, []string{
+			"server.port",
+			"database.host",
+			"rabbitmq.connection.heartbeat",
+		}, changes.RestartRequired)
+	})
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	clearEnvOverrides(t)
+
+	original, err := os.ReadFile("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	path := writeConfig(t, string(original))
+	current, err := Load(path)
+	require.NoError(t, err)
+
+	var applied []*Config
+	var applyErr error
+	w := NewWatcher(path, ProfileAPI, current, slog.New(slog.NewTextHandler(io.Discard, nil)), 0,
+		func(cfg *Config, _ Changes) error {
+			applied = append(applied, cfg)
+			return applyErr
+		})
+
+	t.Run("no changes", func(t *testing.T) {
+		changes, err := w.Reload()
+		require.NoError(t, err)
+		assert.True(t, changes.Empty())
+		assert.Empty(t, applied)
+	})
+
+	t.Run("applies reloadable changes only", func(t *testing.T) {
+		updated := strings.Replace(string(original), "level: debug", "level: error", 1)
+		updated = strings.Replace(updated, "port: 8080", "port: 9090", 1)
+		require.NoError(t, os.WriteFile(path, []byte(updated), 0o600))
+
+		changes, err := w.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"logging.level"}, changes.Reloadable)
+		assert.Equal(t, []string{"server.port"}, changes.RestartRequired)
+
+		require.Len(t, applied, 1)
+		assert.Equal(t, "error", applied[0].Logging.Level)
+
+		// The restart-required field keeps its startup value
+		assert.Equal(t, "error", current.Logging.Level)
+		assert.Equal(t, 8080, current.Server.Port)
+	})
+
+	t.Run("invalid config keeps current settings", func(t *testing.T) {
+		updated := strings.Replace(string(original), "level: debug", "level: info", 1)
+		updated = strings.Replace(updated, "database: jobs_db", `database: ""`, 1)
+		require.NoError(t, os.WriteFile(path, []byte(updated), 0o600))
+
+		_, err := w.Reload()
+		require.Error(t, err)
+		assert.Len(t, applied, 1)
+		assert.Equal(t, "error", current.Logging.Level)
+	})
+
+	t.Run("failed apply keeps current settings", func(t *testing.T) {
+		applyErr = errors.New("password rejected")
+		defer func() { applyErr = nil }()
+
+		updated := strings.Replace(string(original), "level: debug", "level: error", 1)
+		updated = strings.Replace(updated, "password: postgres", "password: rotated", 1)
+		require.NoError(t, os.WriteFile(path, []byte(updated), 0o600))
+
+		_, err := w.Reload()
+		require.ErrorContains(t, err, "failed to apply configuration: password rejected")
+		assert.Len(t, applied, 2)
+		assert.Equal(t, "postgres", current.Database.Password)
+
+		// The next reload applies the password again
+		applyErr = nil
+		changes, err := w.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"database.password"}, changes.Reloadable)
+		assert.Equal(t, "rotated", current.Database.Password)
+	})
+}
+
+func TestWatcher_RunReloadsOnFileChange(t *testing.T) {
+	clearEnvOverrides(t)
+
+	original, err := os.ReadFile("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	path := writeConfig(t, string(original))
+	current, err := Load(path)
+	require.NoError(t, err)
+
+	reloaded := make(chan string, 1)
+	w := NewWatcher(path, ProfileAPI, current, slog.New(slog.NewTextHandler(io.Discard, nil)), 10*time.Millisecond,
+		func(cfg *Config, _ Changes) error {
+			reloaded <- cfg.Logging.Level
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	updated := strings.Replace(string(original), "level: debug", "level: warn", 1)
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0o600))
+	// Make sure the modification time moves even on coarse-grained filesystems
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	select {
+	case level := <-reloaded:
+		assert.Equal(t, "warn", level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded after file change")
+	}
+}
+
+func TestWatcher_RunReloadsOnPasswordFileChange(t *testing.T) {
+	clearEnvOverrides(t)
+
+	original, err := os.ReadFile("testdata/valid_config.yaml")
+	require.NoError(t, err)
+
+	passwordFile := filepath.Join(t.TempDir(), "db-password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("postgres\n"), 0o600))
+	path := writeConfig(t, strings.Replace(string(original), "password: postgres", "password_file: "+passwordFile, 1))
+	current, err := Load(path)
+	require.NoError(t, err)
+
+	reloaded := make(chan string, 1)
+	w := NewWatcher(path, ProfileAPI, current, slog.New(slog.NewTextHandler(io.Discard, nil)), 10*time.Millisecond,
+		func(cfg *Config, changes Changes) error {
+			assert.Equal(t, []string{"database.password"}, changes.Reloadable)
+			reloaded <- cfg.Database.Password
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	require.NoError(t, os.WriteFile(passwordFile, []byte("rotated\n"), 0o600))
+	future := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(passwordFile, future, future))
+
+	select {
+	case password := <-reloaded:
+		assert.Equal(t, "rotated", password)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded after password file change")
+	}
+}
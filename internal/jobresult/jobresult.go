@@ -0,0 +1,124 @@
+// Package jobresult gives job results a declared shape. Executors register the Go type
+// their job type returns together with its JSON Schema; Encode then serializes every
+// result of that job type the same way and checks it against the schema before it is
+// stored, so API clients can rely on the result_schema published for the job type.
+// Job types without a registered result type keep free-form results.
+package jobresult
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+)
+
+var (
+	// ErrWrongType is returned when a result is not of the type registered for its job type
+	ErrWrongType = errors.New("result has the wrong type for its job type")
+	// ErrInvalidResult is returned when a result does not match its job type's schema
+	ErrInvalidResult = errors.New("result does not match its schema")
+)
+
+// Registry holds the result type of each job type. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]resultType
+}
+
+// resultType is the registered result of a job type
+type resultType struct {
+	goType reflect.Type
+	schema *schema.Schema
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]resultType)}
+}
+
+// Register declares that executors of jobType return results of type T matching s. A
+// nil s only checks the Go type. Registering a job type twice is an error.
+func Register[T any](r *Registry, jobType string, s *schema.Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.types[jobType]; ok {
+		return fmt.Errorf("result type of job type %s is already registered", jobType)
+	}
+	r.types[jobType] = resultType{goType: reflect.TypeFor[T](), schema: s}
+	return nil
+}
+
+// Encode serializes the result of a jobType job. Results of registered job types must be
+// of the registered type, or a pointer to it, and match its schema. A nil result encodes
+// to nil, for jobs without a result.
+func (r *Registry) Encode(jobType string, result any) (json.RawMessage, error) {
+	if result == nil {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	typ, registered := r.types[jobType]
+	r.mu.RUnlock()
+
+	if registered {
+		v := reflect.ValueOf(result)
+		if v.Kind() == reflect.Pointer && v.Type().Elem() == typ.goType {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		if v.Type() != typ.goType {
+			return nil, fmt.Errorf("%w: %s returns %s, got %T", ErrWrongType, jobType, typ.goType, result)
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s result: %w", jobType, err)
+	}
+
+	if registered && typ.schema != nil {
+		if err := typ.schema.Validate(data); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidResult, jobType, err)
+		}
+	}
+	return data, nil
+}
+
+// Decode reads a stored result of a jobType job as T, which must be the registered type
+// when jobType has one
+func Decode[T any](r *Registry, jobType string, data []byte) (T, error) {
+	var result T
+
+	r.mu.RLock()
+	typ, registered := r.types[jobType]
+	r.mu.RUnlock()
+
+	if registered && typ.goType != reflect.TypeFor[T]() {
+		return result, fmt.Errorf("%w: %s returns %s, not %s", ErrWrongType, jobType, typ.goType, reflect.TypeFor[T]())
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("failed to decode %s result: %w", jobType, err)
+	}
+	return result, nil
+}
+
+// Schemas returns the result schema of every registered job type that has one, e.g. to
+// publish them next to the payload schemas
+func (r *Registry) Schemas() schema.Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schemas := make(schema.Registry, len(r.types))
+	for jobType, typ := range r.types {
+		if typ.schema != nil {
+			schemas[jobType] = typ.schema
+		}
+	}
+	return schemas
+}
@@ -0,0 +1,102 @@
+package jobresult
+
+import (
+	"testing"
+
+	"github.com/cuongbtq/practice-be/internal/api/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emailResult struct {
+	MessageID string `json:"message_id"`
+	Accepted  int    `json:"accepted"`
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	s, err := schema.Parse([]byte(`{
+		"type": "object",
+		"required": ["message_id", "accepted"],
+		"properties": {
+			"message_id": {"type": "string", "minLength": 1},
+			"accepted": {"type": "integer", "minimum": 0}
+		}
+	}`))
+	require.NoError(t, err)
+
+	r := NewRegistry()
+	require.NoError(t, Register[emailResult](r, "send_email", s))
+	return r
+}
+
+func TestRegister_Twice(t *testing.T) {
+	r := newTestRegistry(t)
+	assert.Error(t, Register[emailResult](r, "send_email", nil))
+}
+
+func TestEncode(t *testing.T) {
+	r := newTestRegistry(t)
+
+	t.Run("registered type", func(t *testing.T) {
+		data, err := r.Encode("send_email", emailResult{MessageID: "m-1", Accepted: 2})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message_id":"m-1","accepted":2}`, string(data))
+
+		data, err = r.Encode("send_email", &emailResult{MessageID: "m-1", Accepted: 2})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message_id":"m-1","accepted":2}`, string(data))
+	})
+
+	t.Run("no result", func(t *testing.T) {
+		data, err := r.Encode("send_email", nil)
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		data, err = r.Encode("send_email", (*emailResult)(nil))
+		require.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		_, err := r.Encode("send_email", map[string]any{"message_id": "m-1", "accepted": 2})
+		assert.ErrorIs(t, err, ErrWrongType)
+	})
+
+	t.Run("schema violation", func(t *testing.T) {
+		_, err := r.Encode("send_email", emailResult{Accepted: -1})
+		assert.ErrorIs(t, err, ErrInvalidResult)
+		assert.Contains(t, err.Error(), "$.message_id: must be at least 1 characters")
+		assert.Contains(t, err.Error(), "$.accepted: must be >= 0")
+	})
+
+	t.Run("unregistered job types are free-form", func(t *testing.T) {
+		data, err := r.Encode("export_csv", map[string]any{"rows": 3})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"rows":3}`, string(data))
+	})
+}
+
+func TestDecode(t *testing.T) {
+	r := newTestRegistry(t)
+
+	result, err := Decode[emailResult](r, "send_email", []byte(`{"message_id":"m-1","accepted":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, emailResult{MessageID: "m-1", Accepted: 2}, result)
+
+	_, err = Decode[map[string]any](r, "send_email", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrWrongType)
+
+	free, err := Decode[map[string]any](r, "export_csv", []byte(`{"rows":3}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"rows": float64(3)}, free)
+}
+
+func TestSchemas(t *testing.T) {
+	r := newTestRegistry(t)
+	require.NoError(t, Register[string](r, "ping", nil))
+
+	schemas := r.Schemas()
+	assert.Len(t, schemas, 1)
+	assert.Contains(t, schemas, "send_email")
+}
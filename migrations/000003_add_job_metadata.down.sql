@@ -0,0 +1,2 @@
+-- Drop client-supplied metadata
+ALTER TABLE jobs DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,2 @@
+-- Add client-supplied metadata, echoed back in responses and job messages
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS metadata JSONB;
@@ -0,0 +1,2 @@
+-- Drop offloaded result references
+ALTER TABLE jobs DROP COLUMN IF EXISTS result_ref;
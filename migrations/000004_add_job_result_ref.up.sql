@@ -0,0 +1,2 @@
+-- Reference to a result offloaded to object storage; small results stay in jobs.result
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result_ref VARCHAR(1024);
@@ -0,0 +1,2 @@
+-- Drop job ordering keys
+ALTER TABLE jobs DROP COLUMN IF EXISTS ordering_key;
@@ -0,0 +1,2 @@
+-- Jobs sharing an ordering key are dispatched in submission order
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS ordering_key VARCHAR(255);
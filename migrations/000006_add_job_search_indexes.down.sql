@@ -0,0 +1,3 @@
+-- Drop job search indexes
+DROP INDEX IF EXISTS idx_jobs_error_message_search;
+DROP INDEX IF EXISTS idx_jobs_payload;
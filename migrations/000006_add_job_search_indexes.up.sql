@@ -0,0 +1,6 @@
+-- Payload containment filters (payload @> '{...}') on GET /api/v1/jobs
+CREATE INDEX IF NOT EXISTS idx_jobs_payload ON jobs USING GIN (payload jsonb_path_ops);
+
+-- Full-text search over error messages; queries must use the same expression
+CREATE INDEX IF NOT EXISTS idx_jobs_error_message_search
+    ON jobs USING GIN (to_tsvector('simple', coalesce(error_message, '')));
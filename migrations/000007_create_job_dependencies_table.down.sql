@@ -0,0 +1,3 @@
+-- Drop job dependencies
+DROP INDEX IF EXISTS idx_jobs_waiting;
+DROP TABLE IF EXISTS job_dependencies;
@@ -0,0 +1,13 @@
+-- Jobs listed here stay WAITING until every job they depend on has COMPLETED.
+-- Dependencies are fixed at submission and must already exist, so the graph cannot contain cycles.
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id            VARCHAR(36) NOT NULL REFERENCES jobs(job_id) ON DELETE CASCADE,
+    depends_on_job_id VARCHAR(36) NOT NULL REFERENCES jobs(job_id) ON DELETE CASCADE,
+    PRIMARY KEY (job_id, depends_on_job_id)
+);
+
+-- Finding the dependents of a job that just finished
+CREATE INDEX IF NOT EXISTS idx_job_dependencies_depends_on ON job_dependencies(depends_on_job_id);
+
+-- The dependency resolver polls WAITING jobs
+CREATE INDEX IF NOT EXISTS idx_jobs_waiting ON jobs(created_at) WHERE status = 'WAITING';
@@ -0,0 +1,5 @@
+-- Drop workflows
+DROP INDEX IF EXISTS idx_jobs_workflow_id;
+ALTER TABLE jobs DROP COLUMN IF EXISTS step_name;
+ALTER TABLE jobs DROP COLUMN IF EXISTS workflow_id;
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,15 @@
+-- Workflows group jobs submitted together as the steps of one pipeline
+CREATE TABLE IF NOT EXISTS workflows (
+    id              BIGSERIAL PRIMARY KEY,
+    workflow_id     VARCHAR(36) NOT NULL UNIQUE,
+    idempotency_key VARCHAR(255) UNIQUE,
+    user_id         VARCHAR(100),
+    name            VARCHAR(100),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Steps are ordinary jobs tagged with their workflow and step name
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS workflow_id VARCHAR(36) REFERENCES workflows(workflow_id) ON DELETE CASCADE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS step_name VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_workflow_id ON jobs(workflow_id) WHERE workflow_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Drop workers
+DROP INDEX IF EXISTS idx_jobs_worker_id;
+DROP TABLE IF EXISTS workers;
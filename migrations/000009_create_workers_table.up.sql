@@ -0,0 +1,14 @@
+-- Workers register on startup and refresh last_heartbeat_at while they run.
+-- A worker is considered dead once its heartbeat is older than job_health.worker_timeout.
+CREATE TABLE IF NOT EXISTS workers (
+    id                BIGSERIAL PRIMARY KEY,
+    worker_id         VARCHAR(100) NOT NULL UNIQUE,
+    hostname          VARCHAR(255),
+    version           VARCHAR(50),
+    concurrency       INTEGER NOT NULL DEFAULT 1,
+    started_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Jobs are assigned through jobs.worker_id
+CREATE INDEX IF NOT EXISTS idx_jobs_worker_id ON jobs(worker_id) WHERE status = 'RUNNING';
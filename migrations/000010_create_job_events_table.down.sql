@@ -0,0 +1,14 @@
+-- Restore job_history
+ALTER INDEX IF EXISTS idx_job_events_created_at RENAME TO idx_job_history_created_at;
+DROP INDEX IF EXISTS idx_job_events_job_id;
+CREATE INDEX IF NOT EXISTS idx_job_history_job_id ON job_events(job_id);
+
+ALTER TABLE job_events ADD COLUMN worker_id VARCHAR(100);
+UPDATE job_events SET worker_id = actor WHERE actor_type = 'worker';
+ALTER TABLE job_events DROP COLUMN actor;
+ALTER TABLE job_events DROP COLUMN actor_type;
+
+ALTER TABLE job_events RENAME COLUMN reason TO error_message;
+ALTER TABLE job_events RENAME COLUMN new_status TO status_to;
+ALTER TABLE job_events RENAME COLUMN old_status TO status_from;
+ALTER TABLE job_events RENAME TO job_history;
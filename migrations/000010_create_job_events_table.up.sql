@@ -0,0 +1,21 @@
+-- job_events is the audit log of job status transitions and replaces job_history, which
+-- nothing wrote to. Every status change inserts one row in the same transaction as the
+-- change: the API for submissions, retries and cancellations (actor_type 'user', actor is
+-- the user ID when the request carries one), its background tasks (actor_type 'system',
+-- actor names the task) and workers for the transitions they make (actor_type 'worker',
+-- actor is the worker_id).
+ALTER TABLE job_history RENAME TO job_events;
+ALTER TABLE job_events RENAME COLUMN status_from TO old_status;
+ALTER TABLE job_events RENAME COLUMN status_to TO new_status;
+ALTER TABLE job_events RENAME COLUMN error_message TO reason;
+
+ALTER TABLE job_events ADD COLUMN actor_type VARCHAR(20) NOT NULL DEFAULT 'system';
+ALTER TABLE job_events ADD COLUMN actor VARCHAR(100);
+UPDATE job_events SET actor_type = 'worker', actor = worker_id WHERE worker_id IS NOT NULL;
+ALTER TABLE job_events ALTER COLUMN actor_type DROP DEFAULT;
+ALTER TABLE job_events DROP COLUMN worker_id;
+
+-- History is read per job in insertion order
+DROP INDEX IF EXISTS idx_job_history_job_id;
+CREATE INDEX IF NOT EXISTS idx_job_events_job_id ON job_events(job_id, id);
+ALTER INDEX IF EXISTS idx_job_history_created_at RENAME TO idx_job_events_created_at;
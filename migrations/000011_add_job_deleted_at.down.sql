@@ -0,0 +1,3 @@
+-- Drop soft delete
+DROP INDEX IF EXISTS idx_jobs_retention;
+ALTER TABLE jobs DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- Deleting a job through the API only sets deleted_at, which hides it from every read.
+-- The retention cleaner purges terminal jobs, deleted or not, once they are old enough.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- The retention cleaner scans terminal jobs by age
+CREATE INDEX IF NOT EXISTS idx_jobs_retention ON jobs(updated_at)
+    WHERE status IN ('COMPLETED', 'FAILED', 'CANCELED');
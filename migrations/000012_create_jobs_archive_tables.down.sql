@@ -0,0 +1,3 @@
+-- Drop the job archive
+DROP TABLE IF EXISTS job_events_archive;
+DROP TABLE IF EXISTS jobs_archive;
@@ -0,0 +1,15 @@
+-- With retention.archive enabled, the retention cleaner copies every job and its events
+-- here in the same statement that purges them, so archived jobs can still be looked up
+-- by job_id. Large payload and result values are compressed by TOAST.
+--
+-- The archive tables copy the columns of jobs and job_events in order, because rows are
+-- inserted with SELECT *. A migration that adds a column to jobs or job_events must add
+-- it to the archive table too, otherwise archiving fails instead of dropping the column.
+CREATE TABLE IF NOT EXISTS jobs_archive (LIKE jobs);
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS depends_on VARCHAR(36)[] NOT NULL DEFAULT '{}';
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE jobs_archive ADD PRIMARY KEY (job_id);
+
+CREATE TABLE IF NOT EXISTS job_events_archive (LIKE job_events);
+
+CREATE INDEX IF NOT EXISTS idx_job_events_archive_job_id ON job_events_archive(job_id, id);
@@ -0,0 +1,13 @@
+-- Drop tenant scoping
+DROP INDEX IF EXISTS idx_jobs_tenant_status;
+DROP INDEX IF EXISTS idx_jobs_tenant_created_at;
+
+-- Fails if two tenants used the same idempotency key; resolve the duplicates first
+DROP INDEX IF EXISTS idx_workflows_tenant_idempotency_key;
+ALTER TABLE workflows ADD CONSTRAINT workflows_idempotency_key_key UNIQUE (idempotency_key);
+DROP INDEX IF EXISTS idx_jobs_tenant_idempotency_key;
+ALTER TABLE jobs ADD CONSTRAINT jobs_idempotency_key_key UNIQUE (idempotency_key);
+
+ALTER TABLE jobs_archive DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE workflows DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE jobs DROP COLUMN IF EXISTS tenant_id;
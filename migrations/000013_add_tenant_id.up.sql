@@ -0,0 +1,19 @@
+-- Every job and workflow belongs to a tenant, and the API scopes every read and write
+-- to the tenant of the request. Existing rows, and every row created while multi-tenancy
+-- is disabled, belong to the default tenant.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+
+-- The retention cleaner copies jobs to the archive by column name, so the new column can
+-- be appended after archived_at
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+
+-- Idempotency keys only have to be unique within a tenant
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_idempotency_key_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_tenant_idempotency_key ON jobs(tenant_id, idempotency_key);
+ALTER TABLE workflows DROP CONSTRAINT IF EXISTS workflows_idempotency_key_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflows_tenant_idempotency_key ON workflows(tenant_id, idempotency_key);
+
+-- Job listings and the per-tenant quota checks
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_created_at ON jobs(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_status ON jobs(tenant_id, status);
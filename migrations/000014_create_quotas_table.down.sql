@@ -0,0 +1,3 @@
+-- Drop quotas
+DROP INDEX IF EXISTS idx_jobs_tenant_user_running;
+DROP TABLE IF EXISTS quotas;
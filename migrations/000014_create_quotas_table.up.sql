@@ -0,0 +1,18 @@
+-- Quotas managed through the /admin/quotas API. A row with an empty user_id limits a
+-- whole tenant, one with a user_id limits that user within the tenant. 0 means unlimited.
+-- The API checks max_jobs_per_day and max_payload_bytes when jobs are created; workers
+-- read max_running_jobs to cap how many jobs of a tenant or user they run at once.
+CREATE TABLE IF NOT EXISTS quotas (
+    id                BIGSERIAL PRIMARY KEY,
+    tenant_id         VARCHAR(100) NOT NULL,
+    user_id           VARCHAR(100) NOT NULL DEFAULT '',
+    max_jobs_per_day  BIGINT NOT NULL DEFAULT 0,
+    max_running_jobs  BIGINT NOT NULL DEFAULT 0,
+    max_payload_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, user_id)
+);
+
+-- Workers count the RUNNING jobs of a tenant and user before taking another one
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_user_running ON jobs(tenant_id, user_id) WHERE status = 'RUNNING';
@@ -0,0 +1,4 @@
+-- Drop event publishing state
+DROP INDEX IF EXISTS idx_job_events_unpublished;
+ALTER TABLE job_events_archive DROP COLUMN IF EXISTS published_at;
+ALTER TABLE job_events DROP COLUMN IF EXISTS published_at;
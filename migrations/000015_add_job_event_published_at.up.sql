@@ -0,0 +1,10 @@
+-- The event relay publishes job events to the events exchange and marks them published.
+-- Events recorded before the relay existed are treated as published, so enabling it does
+-- not replay the whole history.
+ALTER TABLE job_events ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+UPDATE job_events SET published_at = created_at WHERE published_at IS NULL;
+
+-- The retention cleaner archives events with SELECT *, so the archive needs the column too
+ALTER TABLE job_events_archive ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_job_events_unpublished ON job_events(id) WHERE published_at IS NULL;
@@ -0,0 +1,3 @@
+-- Fails if two users of a tenant used the same idempotency key; resolve the duplicates first
+DROP INDEX IF EXISTS idx_jobs_tenant_user_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_tenant_idempotency_key ON jobs(tenant_id, idempotency_key);
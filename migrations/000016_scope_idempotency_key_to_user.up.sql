@@ -0,0 +1,4 @@
+-- Idempotency keys only have to be unique per user, so a client that lost the response
+-- to a create can find its job again by user_id and idempotency_key
+DROP INDEX IF EXISTS idx_jobs_tenant_idempotency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_tenant_user_idempotency_key ON jobs(tenant_id, user_id, idempotency_key);
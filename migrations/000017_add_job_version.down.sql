@@ -0,0 +1,3 @@
+-- Drop optimistic concurrency
+ALTER TABLE jobs_archive DROP COLUMN IF EXISTS version;
+ALTER TABLE jobs DROP COLUMN IF EXISTS version;
@@ -0,0 +1,7 @@
+-- version counts the updates of a job. Writers that read a job before changing it pass
+-- the version they read and update only if it still matches, so a worker completing a
+-- job and a client canceling it cannot overwrite each other's change.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- The retention cleaner copies jobs to the archive by column name
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
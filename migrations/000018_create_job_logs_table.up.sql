@@ -0,0 +1,18 @@
+-- job_logs holds what executors logged while running a job, so users can see why a job
+-- failed without access to the worker logs. Workers capture each attempt's log in a
+-- bounded buffer and insert it when the attempt ends. attempt is the job's retry_count
+-- when the attempt started. Logs go with their job when it is purged and are not archived.
+CREATE TABLE IF NOT EXISTS job_logs (
+    id         BIGSERIAL PRIMARY KEY,
+    job_id     VARCHAR(36) NOT NULL REFERENCES jobs(job_id) ON DELETE CASCADE,
+    attempt    INTEGER NOT NULL DEFAULT 0,
+    worker_id  VARCHAR(100),
+    level      VARCHAR(10) NOT NULL,
+    message    TEXT NOT NULL,
+    attrs      JSONB,
+    logged_at  TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Logs are read per job in insertion order
+CREATE INDEX IF NOT EXISTS idx_job_logs_job_id ON job_logs(job_id, id);
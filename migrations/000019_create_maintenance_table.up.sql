@@ -0,0 +1,13 @@
+-- maintenance holds the maintenance flag set through PUT /admin/maintenance, e.g. around
+-- database migrations. It has exactly one row, so every API instance and worker reads the
+-- same flag: API instances poll it and reject mutating requests while it is set, and
+-- workers stop claiming new jobs. maintenance.enabled in the config file forces the mode
+-- on regardless of this row.
+CREATE TABLE IF NOT EXISTS maintenance (
+    id         BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled    BOOLEAN NOT NULL DEFAULT FALSE,
+    message    TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO maintenance (id) VALUES (TRUE) ON CONFLICT (id) DO NOTHING;
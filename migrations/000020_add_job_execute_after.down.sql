@@ -0,0 +1,3 @@
+-- Drop job execution delays
+ALTER TABLE jobs_archive DROP COLUMN IF EXISTS execute_after;
+ALTER TABLE jobs DROP COLUMN IF EXISTS execute_after;
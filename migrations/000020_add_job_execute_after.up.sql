@@ -0,0 +1,6 @@
+-- execute_after delays a job: it is carried in the job's broker messages and workers do
+-- not run the job before that time
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS execute_after TIMESTAMPTZ;
+
+-- The retention cleaner copies jobs to the archive by column name
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS execute_after TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Drop job listing indexes
+DROP INDEX IF EXISTS idx_jobs_pending_created_at;
+DROP INDEX IF EXISTS idx_jobs_tenant_status_created_at;
+DROP INDEX IF EXISTS idx_jobs_tenant_user_created_at;
@@ -0,0 +1,12 @@
+-- Composite indexes for GET /api/v1/jobs. Every listing is scoped to a tenant and sorted
+-- with job_id as the tie breaker, so the indexes lead with tenant_id and end with the
+-- sort key, letting a page (and a cursor continuing it) be read in index order. B-tree
+-- indexes are read backwards as well, so ascending and descending sorts both use them.
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_user_created_at
+    ON jobs(tenant_id, user_id, created_at DESC, job_id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_status_created_at
+    ON jobs(tenant_id, status, created_at, job_id);
+
+-- Pending jobs are a small part of a large table, so listing them gets a partial index
+CREATE INDEX IF NOT EXISTS idx_jobs_pending_created_at
+    ON jobs(tenant_id, created_at DESC, job_id DESC) WHERE status = 'PENDING';
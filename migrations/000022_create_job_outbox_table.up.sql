@@ -0,0 +1,13 @@
+-- job_outbox holds the jobs created with ingestion.mode async that still have to be
+-- published. A row is written in the transaction that creates its job, and the outbox
+-- relay deletes it once the job message is on the broker, so an accepted job is queued
+-- even if the API stops in between. ordering_key is copied from the job so the relay can
+-- keep keyed jobs in order without reading them.
+CREATE TABLE IF NOT EXISTS job_outbox (
+    id           BIGSERIAL PRIMARY KEY,
+    job_id       VARCHAR(36) NOT NULL REFERENCES jobs(job_id) ON DELETE CASCADE,
+    ordering_key VARCHAR(255),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_outbox_ordering_key ON job_outbox(ordering_key, id) WHERE ordering_key IS NOT NULL;
@@ -0,0 +1,230 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched by the error publishes fail with while the circuit is open
+var ErrCircuitOpen = errors.New("broker circuit breaker is open")
+
+// CircuitOpenError is returned instead of publishing while the circuit is open
+type CircuitOpenError struct {
+	RetryAfter time.Duration // Until the circuit lets a probe through
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrCircuitOpen, e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState string
+
+const (
+	// CircuitClosed passes every publish through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails every publish without trying the broker
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets probe publishes through to test whether the broker recovered
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreakerOptions configures a CircuitBreaker
+type CircuitBreakerOptions struct {
+	FailureThreshold int           // Consecutive failed publishes that open the circuit, 0 uses 5
+	OpenDuration     time.Duration // How long the circuit stays open before probing, 0 uses 30s
+	HalfOpenProbes   int           // Probes that must succeed to close the circuit again, 0 uses 1
+}
+
+// CircuitBreakerStats reports the state of a CircuitBreaker
+type CircuitBreakerStats struct {
+	State    CircuitState
+	Opened   int64 // Times the circuit opened
+	Rejected int64 // Publishes failed without trying the broker
+}
+
+// CircuitBreaker wraps a Broker and stops publishing to it once publishes keep failing or
+// it reports being disconnected, so callers fail fast instead of waiting on a degraded
+// broker. After OpenDuration, HalfOpenProbes publishes are let through one at a time; the
+// circuit closes when they all succeed and opens again when one fails.
+type CircuitBreaker struct {
+	Broker
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int       // Consecutive failures while closed
+	successes int       // Successful probes while half-open
+	probing   bool      // A probe is in flight
+	openUntil time.Time // When an open circuit turns half-open
+	opened    int64
+	rejected  int64
+}
+
+var (
+	_ Broker         = (*CircuitBreaker)(nil)
+	_ EventPublisher = (*CircuitBreaker)(nil)
+)
+
+// NewCircuitBreaker wraps b with a circuit breaker
+func NewCircuitBreaker(b Broker, opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = 30 * time.Second
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+
+	return &CircuitBreaker{
+		Broker: b,
+		opts:   opts,
+		now:    time.Now,
+		state:  CircuitClosed,
+	}
+}
+
+// Publish publishes through the wrapped broker unless the circuit is open
+func (b *CircuitBreaker) Publish(ctx context.Context, body []byte, contentType string) error {
+	return b.call(func() error {
+		return b.Broker.Publish(ctx, body, contentType)
+	})
+}
+
+// PublishOrdered is Publish for messages with an ordering key
+func (b *CircuitBreaker) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	return b.call(func() error {
+		return b.Broker.PublishOrdered(ctx, orderingKey, body, contentType)
+	})
+}
+
+// PublishEvent is Publish for lifecycle events, through a wrapped EventPublisher
+func (b *CircuitBreaker) PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error {
+	events, ok := b.Broker.(EventPublisher)
+	if !ok {
+		return ErrNoEventsExchange
+	}
+
+	return b.call(func() error {
+		return events.PublishEvent(ctx, routingKey, body, contentType)
+	})
+}
+
+// CircuitBreakerStats returns the current state and the counters since startup
+func (b *CircuitBreaker) CircuitBreakerStats() CircuitBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == CircuitOpen && !b.now().Before(b.openUntil) {
+		state = CircuitHalfOpen
+	}
+	return CircuitBreakerStats{State: state, Opened: b.opened, Rejected: b.rejected}
+}
+
+// call runs publish if the circuit allows it and records the outcome
+func (b *CircuitBreaker) call(publish func() error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = publish()
+	b.record(probe, err)
+	return err
+}
+
+// allow reports whether a publish may go ahead and whether it is a half-open probe
+func (b *CircuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.state == CircuitClosed && !b.Broker.IsConnected() {
+		b.open(now)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Before(b.openUntil) {
+			b.rejected++
+			return false, &CircuitOpenError{RetryAfter: b.openUntil.Sub(now)}
+		}
+		b.state = CircuitHalfOpen
+		b.successes = 0
+	case CircuitClosed:
+		return false, nil
+	}
+
+	// Half-open: one probe at a time, the others fail fast until the probes decide
+	if b.probing {
+		b.rejected++
+		return false, &CircuitOpenError{RetryAfter: time.Second}
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record counts the outcome of a publish, opening or closing the circuit
+func (b *CircuitBreaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	if !countsAsFailure(err) {
+		if probe {
+			b.successes++
+			if b.successes >= b.opts.HalfOpenProbes {
+				b.state = CircuitClosed
+			}
+		}
+		if b.state == CircuitClosed {
+			b.failures = 0
+		}
+		return
+	}
+
+	if probe {
+		b.open(b.now())
+		return
+	}
+	if b.state == CircuitClosed {
+		b.failures++
+		if b.failures >= b.opts.FailureThreshold {
+			b.open(b.now())
+		}
+	}
+}
+
+// open opens the circuit for OpenDuration from now
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openUntil = now.Add(b.opts.OpenDuration)
+	b.failures = 0
+	b.successes = 0
+	b.opened++
+}
+
+// countsAsFailure reports whether err says something about the broker's health. Messages
+// the broker refuses by size, a missing events exchange and publishes abandoned by the
+// caller do not.
+func countsAsFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrMessageTooLarge) &&
+		!errors.Is(err, ErrNoEventsExchange) &&
+		!errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,106 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	publish := func(b *CircuitBreaker) error {
+		return b.Publish(ctx, []byte("{}"), "application/json")
+	}
+	newBreaker := func(m *Memory, opts CircuitBreakerOptions) (*CircuitBreaker, *time.Time) {
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		b := NewCircuitBreaker(m, opts)
+		b.now = func() time.Time { return now }
+		return b, &now
+	}
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{PublishFailureRate: 1})
+		b, _ := newBreaker(m, CircuitBreakerOptions{FailureThreshold: 3, OpenDuration: time.Minute})
+
+		for range 3 {
+			assert.ErrorIs(t, publish(b), ErrInjectedFailure)
+		}
+
+		err := publish(b)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		var open *CircuitOpenError
+		require.ErrorAs(t, err, &open)
+		assert.Equal(t, time.Minute, open.RetryAfter)
+		assert.Equal(t, CircuitBreakerStats{State: CircuitOpen, Opened: 1, Rejected: 1}, b.CircuitBreakerStats())
+	})
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{})
+		b, _ := newBreaker(m, CircuitBreakerOptions{FailureThreshold: 2})
+
+		m.opts.PublishFailureRate = 1
+		assert.Error(t, publish(b))
+		m.opts.PublishFailureRate = 0
+		require.NoError(t, publish(b))
+		m.opts.PublishFailureRate = 1
+		assert.ErrorIs(t, publish(b), ErrInjectedFailure)
+		assert.Equal(t, CircuitClosed, b.CircuitBreakerStats().State)
+	})
+
+	t.Run("probes close the circuit", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{PublishFailureRate: 1})
+		b, now := newBreaker(m, CircuitBreakerOptions{FailureThreshold: 1, OpenDuration: time.Minute, HalfOpenProbes: 2})
+
+		assert.ErrorIs(t, publish(b), ErrInjectedFailure)
+		*now = now.Add(30 * time.Second)
+		var open *CircuitOpenError
+		require.ErrorAs(t, publish(b), &open)
+		assert.Equal(t, 30*time.Second, open.RetryAfter)
+
+		*now = now.Add(30 * time.Second)
+		assert.Equal(t, CircuitHalfOpen, b.CircuitBreakerStats().State)
+		m.opts.PublishFailureRate = 0
+		require.NoError(t, publish(b))
+		assert.Equal(t, CircuitHalfOpen, b.CircuitBreakerStats().State)
+		require.NoError(t, publish(b))
+		assert.Equal(t, CircuitClosed, b.CircuitBreakerStats().State)
+		assert.Equal(t, 2, m.Len())
+	})
+
+	t.Run("failed probe opens the circuit again", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{PublishFailureRate: 1})
+		b, now := newBreaker(m, CircuitBreakerOptions{FailureThreshold: 1, OpenDuration: time.Minute})
+
+		assert.Error(t, publish(b))
+		*now = now.Add(time.Minute)
+		assert.ErrorIs(t, publish(b), ErrInjectedFailure)
+		assert.ErrorIs(t, publish(b), ErrCircuitOpen)
+		assert.Equal(t, int64(2), b.CircuitBreakerStats().Opened)
+	})
+
+	t.Run("opens while the broker is disconnected", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{})
+		b, _ := newBreaker(m, CircuitBreakerOptions{})
+
+		require.NoError(t, m.Close())
+		assert.ErrorIs(t, publish(b), ErrCircuitOpen)
+		assert.ErrorIs(t, b.PublishOrdered(ctx, "key", []byte("{}"), "application/json"), ErrCircuitOpen)
+	})
+
+	t.Run("caller errors do not count", func(t *testing.T) {
+		b := NewCircuitBreaker(tooLarge{NewMemory(MemoryOptions{})}, CircuitBreakerOptions{FailureThreshold: 1})
+
+		assert.ErrorIs(t, publish(b), ErrMessageTooLarge)
+		assert.Equal(t, CircuitClosed, b.CircuitBreakerStats().State)
+	})
+}
+
+// tooLarge is a broker refusing every message by size
+type tooLarge struct{ *Memory }
+
+func (tooLarge) Publish(context.Context, []byte, string) error {
+	return ErrMessageTooLarge
+}
@@ -0,0 +1,45 @@
+// Package broker defines the messaging operations the services rely on, independent of
+// the message broker that provides them.
+package broker
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrMessageTooLarge is returned by Publish when a message exceeds the broker's size limit
+	ErrMessageTooLarge = errors.New("message exceeds maximum size")
+	// ErrNoEventsExchange is returned by PublishEvent when no events exchange is configured
+	ErrNoEventsExchange = errors.New("no events exchange configured")
+)
+
+// Delivery is a consumed message. Exactly one of Ack or Nack should be called once the
+// message has been handled; both are no-ops when the consumer auto-acknowledges.
+type Delivery interface {
+	Body() []byte
+	ContentType() string
+	Ack() error
+	// Nack rejects the message, returning it to the queue when requeue is true
+	Nack(requeue bool) error
+}
+
+// Broker publishes job messages and delivers them to consumers
+type Broker interface {
+	Publish(ctx context.Context, body []byte, contentType string) error
+	// PublishOrdered keeps messages sharing orderingKey in publish order for consumers.
+	// Brokers without ordering support behave like Publish.
+	PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error
+	// Consume delivers messages from the job queue until ctx is canceled or the broker
+	// is closed, then closes the channel
+	Consume(ctx context.Context, consumerTag string) (<-chan Delivery, error)
+	IsConnected() bool
+	Close() error
+}
+
+// EventPublisher is implemented by brokers that can publish job lifecycle events to an
+// events exchange, separate from the job queue, for external systems to subscribe to
+type EventPublisher interface {
+	// PublishEvent publishes an event with routingKey, e.g. job.send_email.completed
+	PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error
+}
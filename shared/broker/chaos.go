@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// ChaosOptions configures the faults Chaos injects
+type ChaosOptions struct {
+	// PublishDropRate is the fraction of publishes reported as sent but discarded, as when
+	// a message is lost between the publisher and the queue
+	PublishDropRate float64
+	// PublishFailureRate is the fraction of publishes that fail with ErrInjectedFailure
+	PublishFailureRate float64
+}
+
+// Chaos wraps a Broker and injects faults into its publishes, for soak tests of the
+// publish retry paths. Consume passes through. It must never be enabled in production.
+type Chaos struct {
+	Broker
+	opts ChaosOptions
+
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+var (
+	_ Broker         = (*Chaos)(nil)
+	_ EventPublisher = (*Chaos)(nil)
+)
+
+// NewChaos wraps b with fault injection
+func NewChaos(b Broker, opts ChaosOptions) *Chaos {
+	return &Chaos{Broker: b, opts: opts}
+}
+
+// Publish publishes through the wrapped broker unless the message is dropped or the call
+// is made to fail
+func (c *Chaos) Publish(ctx context.Context, body []byte, contentType string) error {
+	if err := c.inject(); err != nil {
+		return err
+	}
+	if c.drop() {
+		return nil
+	}
+	return c.Broker.Publish(ctx, body, contentType)
+}
+
+// PublishOrdered is Publish for messages with an ordering key
+func (c *Chaos) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	if err := c.inject(); err != nil {
+		return err
+	}
+	if c.drop() {
+		return nil
+	}
+	return c.Broker.PublishOrdered(ctx, orderingKey, body, contentType)
+}
+
+// PublishEvent is Publish for lifecycle events, through a wrapped EventPublisher
+func (c *Chaos) PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error {
+	events, ok := c.Broker.(EventPublisher)
+	if !ok {
+		return ErrNoEventsExchange
+	}
+
+	if err := c.inject(); err != nil {
+		return err
+	}
+	if c.drop() {
+		return nil
+	}
+	return events.PublishEvent(ctx, routingKey, body, contentType)
+}
+
+func (c *Chaos) inject() error {
+	if c.opts.PublishFailureRate > 0 && rand.Float64() < c.opts.PublishFailureRate {
+		c.failed.Add(1)
+		return ErrInjectedFailure
+	}
+	return nil
+}
+
+func (c *Chaos) drop() bool {
+	if c.opts.PublishDropRate > 0 && rand.Float64() < c.opts.PublishDropRate {
+		c.dropped.Add(1)
+		return true
+	}
+	return false
+}
+
+// Dropped returns how many publishes have been silently discarded
+func (c *Chaos) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Failed returns how many publishes have failed with ErrInjectedFailure
+func (c *Chaos) Failed() int64 {
+	return c.failed.Load()
+}
@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaos_Publish(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drops", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{})
+		c := NewChaos(m, ChaosOptions{PublishDropRate: 1})
+
+		require.NoError(t, c.Publish(ctx, []byte("1"), "application/json"))
+		require.NoError(t, c.PublishOrdered(ctx, "key", []byte("2"), "application/json"))
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, int64(2), c.Dropped())
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{})
+		c := NewChaos(m, ChaosOptions{PublishFailureRate: 1})
+
+		assert.ErrorIs(t, c.Publish(ctx, []byte("1"), "application/json"), ErrInjectedFailure)
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, int64(1), c.Failed())
+	})
+
+	t.Run("passes through", func(t *testing.T) {
+		m := NewMemory(MemoryOptions{})
+		c := NewChaos(m, ChaosOptions{})
+
+		require.NoError(t, c.Publish(ctx, []byte("1"), "application/json"))
+		assert.Equal(t, 1, m.Len())
+	})
+}
+
+func TestChaos_PublishEvent(t *testing.T) {
+	ctx := context.Background()
+
+	c := NewChaos(NewMemory(MemoryOptions{}), ChaosOptions{PublishFailureRate: 1})
+	assert.ErrorIs(t, c.PublishEvent(ctx, "job.send_email.created", []byte("{}"), "application/json"), ErrInjectedFailure)
+
+	c = NewChaos(NewMemory(MemoryOptions{}), ChaosOptions{})
+	assert.NoError(t, c.PublishEvent(ctx, "job.send_email.created", []byte("{}"), "application/json"))
+
+	// Brokers without an events exchange cannot publish events, chaos or not
+	c = NewChaos(struct{ Broker }{NewMemory(MemoryOptions{})}, ChaosOptions{})
+	assert.ErrorIs(t, c.PublishEvent(ctx, "job.send_email.created", []byte("{}"), "application/json"), ErrNoEventsExchange)
+}
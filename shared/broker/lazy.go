@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotConnected is returned by a Lazy broker until its broker is connected
+var ErrNotConnected = errors.New("broker is not connected")
+
+// Lazy is a Broker whose connection is made after it is handed out, so a service can
+// start while the message broker is unreachable and connect in the background. Until
+// Set is called, publishes and Consume fail with ErrNotConnected and IsConnected is
+// false. It is safe for concurrent use.
+type Lazy struct {
+	mu     sync.RWMutex
+	broker Broker
+	closed bool
+}
+
+// NewLazy returns a Lazy broker that is not connected yet
+func NewLazy() *Lazy {
+	return &Lazy{}
+}
+
+// Set connects l to b. It returns false, and closes b, when l was closed first or is
+// already connected.
+func (l *Lazy) Set(b Broker) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed || l.broker != nil {
+		_ = b.Close()
+		return false
+	}
+	l.broker = b
+	return true
+}
+
+// current returns the connected broker, or nil
+func (l *Lazy) current() Broker {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.broker
+}
+
+// Unwrap returns the connected broker, or nil, e.g. to reach optional interfaces such
+// as a password updater
+func (l *Lazy) Unwrap() Broker {
+	return l.current()
+}
+
+// Publish publishes through the connected broker
+func (l *Lazy) Publish(ctx context.Context, body []byte, contentType string) error {
+	b := l.current()
+	if b == nil {
+		return ErrNotConnected
+	}
+	return b.Publish(ctx, body, contentType)
+}
+
+// PublishOrdered publishes through the connected broker
+func (l *Lazy) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	b := l.current()
+	if b == nil {
+		return ErrNotConnected
+	}
+	return b.PublishOrdered(ctx, orderingKey, body, contentType)
+}
+
+// PublishEvent publishes through the connected broker, which must be an EventPublisher
+func (l *Lazy) PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error {
+	b := l.current()
+	if b == nil {
+		return ErrNotConnected
+	}
+	events, ok := b.(EventPublisher)
+	if !ok {
+		return ErrNoEventsExchange
+	}
+	return events.PublishEvent(ctx, routingKey, body, contentType)
+}
+
+// Consume consumes from the connected broker
+func (l *Lazy) Consume(ctx context.Context, consumerTag string) (<-chan Delivery, error) {
+	b := l.current()
+	if b == nil {
+		return nil, ErrNotConnected
+	}
+	return b.Consume(ctx, consumerTag)
+}
+
+// IsConnected reports whether the broker is connected and its connection is up
+func (l *Lazy) IsConnected() bool {
+	b := l.current()
+	return b != nil && b.IsConnected()
+}
+
+// Close closes the connected broker. Brokers set afterwards are closed at once.
+func (l *Lazy) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	if l.broker == nil {
+		return nil
+	}
+	return l.broker.Close()
+}
@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	ctx := context.Background()
+	l := NewLazy()
+
+	assert.False(t, l.IsConnected())
+	assert.ErrorIs(t, l.Publish(ctx, []byte("1"), "application/json"), ErrNotConnected)
+	assert.ErrorIs(t, l.PublishOrdered(ctx, "key", []byte("1"), "application/json"), ErrNotConnected)
+	assert.ErrorIs(t, l.PublishEvent(ctx, "job.a.created", []byte("{}"), "application/json"), ErrNotConnected)
+	_, err := l.Consume(ctx, "worker-1")
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	m := NewMemory(MemoryOptions{})
+	require.True(t, l.Set(m))
+	assert.True(t, l.IsConnected())
+	assert.Same(t, m, l.Unwrap())
+
+	require.NoError(t, l.Publish(ctx, []byte("1"), "application/json"))
+	deliveries, err := l.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), receive(t, deliveries).Body())
+
+	second := NewMemory(MemoryOptions{})
+	assert.False(t, l.Set(second), "a connected Lazy keeps its broker")
+	assert.False(t, second.IsConnected())
+
+	require.NoError(t, l.Close())
+	assert.False(t, m.IsConnected())
+	assert.False(t, l.IsConnected())
+}
+
+func TestLazy_SetAfterClose(t *testing.T) {
+	l := NewLazy()
+	require.NoError(t, l.Close())
+
+	m := NewMemory(MemoryOptions{})
+	assert.False(t, l.Set(m))
+	assert.False(t, m.IsConnected(), "brokers connected after shutdown are closed")
+}
@@ -0,0 +1,273 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInjectedFailure is returned by Memory.Publish for the fraction of calls set by
+	// MemoryOptions.PublishFailureRate
+	ErrInjectedFailure = errors.New("injected publish failure")
+	// ErrQueueFull is returned by Memory.Publish once QueueSize messages are waiting
+	ErrQueueFull = errors.New("memory broker queue is full")
+	// ErrClosed is returned once the broker has been closed
+	ErrClosed = errors.New("broker is closed")
+	// ErrAlreadySettled is returned when a delivery is acked or nacked a second time
+	ErrAlreadySettled = errors.New("delivery already acknowledged")
+)
+
+// MemoryOptions configures the in-memory broker
+type MemoryOptions struct {
+	QueueSize     int           // Messages waiting before Publish fails with ErrQueueFull, 0 uses 10000
+	DeliveryDelay time.Duration // How long a published or requeued message waits before delivery
+
+	// PublishFailureRate is the fraction of Publish calls that fail with ErrInjectedFailure
+	PublishFailureRate float64
+}
+
+// Memory is a Broker that keeps messages in process, for local development and tests.
+// Messages are delivered in publish order to whichever consumer is ready first, and
+// requeued messages go to the back of the queue.
+// Deliveries still unsettled when their consumer stops are requeued, as RabbitMQ does
+// when a channel closes. Nothing survives a restart.
+type Memory struct {
+	opts MemoryOptions
+
+	mu     sync.Mutex
+	queue  []*memoryMessage
+	ready  chan struct{} // Signaled when the queue gains a message
+	closed chan struct{}
+	once   sync.Once
+}
+
+var (
+	_ Broker         = (*Memory)(nil)
+	_ EventPublisher = (*Memory)(nil)
+)
+
+type memoryMessage struct {
+	body        []byte
+	contentType string
+	availableAt time.Time
+}
+
+// NewMemory creates an in-memory broker
+func NewMemory(opts MemoryOptions) *Memory {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10000
+	}
+
+	return &Memory{
+		opts:   opts,
+		ready:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// Publish queues a message for delivery after DeliveryDelay
+func (m *Memory) Publish(_ context.Context, body []byte, contentType string) error {
+	if !m.IsConnected() {
+		return ErrClosed
+	}
+
+	if m.opts.PublishFailureRate > 0 && rand.Float64() < m.opts.PublishFailureRate {
+		return ErrInjectedFailure
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) >= m.opts.QueueSize {
+		return ErrQueueFull
+	}
+
+	m.push(&memoryMessage{
+		body:        append([]byte(nil), body...),
+		contentType: contentType,
+	})
+	return nil
+}
+
+// PublishOrdered behaves like Publish; the single queue already keeps publish order
+func (m *Memory) PublishOrdered(ctx context.Context, _ string, body []byte, contentType string) error {
+	return m.Publish(ctx, body, contentType)
+}
+
+// Len returns the number of messages waiting for delivery
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// push appends msg, delayed by DeliveryDelay. The caller must hold mu.
+func (m *Memory) push(msg *memoryMessage) {
+	msg.availableAt = time.Now().Add(m.opts.DeliveryDelay)
+	m.queue = append(m.queue, msg)
+	m.signal()
+}
+
+// requeue queues msg again, e.g. after a nack or when its consumer stopped
+func (m *Memory) requeue(msg *memoryMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.push(msg)
+}
+
+// signal wakes a waiting consumer without blocking
+func (m *Memory) signal() {
+	select {
+	case m.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes the next message, or returns nil if the queue is empty
+func (m *Memory) pop() *memoryMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) == 0 {
+		return nil
+	}
+	msg := m.queue[0]
+	m.queue = m.queue[1:]
+	if len(m.queue) > 0 {
+		// Another consumer may be waiting for the rest
+		m.signal()
+	}
+	return msg
+}
+
+// Consume delivers messages until ctx is canceled or the broker is closed
+func (m *Memory) Consume(ctx context.Context, _ string) (<-chan Delivery, error) {
+	if !m.IsConnected() {
+		return nil, ErrClosed
+	}
+
+	deliveries := make(chan Delivery)
+	go m.consume(ctx, deliveries)
+	return deliveries, nil
+}
+
+func (m *Memory) consume(ctx context.Context, deliveries chan<- Delivery) {
+	var unsettled []*memoryDelivery
+	defer func() {
+		// Unsettled deliveries go back to the queue, as if the consumer's channel closed
+		for _, d := range unsettled {
+			if d.settled.CompareAndSwap(false, true) {
+				m.requeue(d.msg)
+			}
+		}
+		close(deliveries)
+	}()
+
+	for {
+		msg := m.pop()
+		if msg == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.closed:
+				return
+			case <-m.ready:
+				continue
+			}
+		}
+
+		if wait := time.Until(msg.availableAt); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				m.requeue(msg)
+				return
+			case <-m.closed:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+
+		d := &memoryDelivery{broker: m, msg: msg}
+		select {
+		case deliveries <- d:
+			unsettled = append(pending(unsettled), d)
+		case <-ctx.Done():
+			m.requeue(msg)
+			return
+		case <-m.closed:
+			return
+		}
+	}
+}
+
+// pending drops settled deliveries
+func pending(deliveries []*memoryDelivery) []*memoryDelivery {
+	var out []*memoryDelivery
+	for _, d := range deliveries {
+		if !d.settled.Load() {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// IsConnected reports whether the broker is still open
+func (m *Memory) IsConnected() bool {
+	select {
+	case <-m.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close stops every consumer. Messages still queued are discarded.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.closed) })
+	return nil
+}
+
+// memoryDelivery is a message handed to a Memory consumer
+type memoryDelivery struct {
+	broker  *Memory
+	msg     *memoryMessage
+	settled atomic.Bool
+}
+
+func (d *memoryDelivery) Body() []byte        { return d.msg.body }
+func (d *memoryDelivery) ContentType() string { return d.msg.contentType }
+
+// Ack settles the message
+func (d *memoryDelivery) Ack() error {
+	if !d.settled.CompareAndSwap(false, true) {
+		return ErrAlreadySettled
+	}
+	return nil
+}
+
+// Nack settles the message, queueing it again when requeue is true
+func (d *memoryDelivery) Nack(requeue bool) error {
+	if !d.settled.CompareAndSwap(false, true) {
+		return ErrAlreadySettled
+	}
+
+	if requeue {
+		d.broker.requeue(d.msg)
+	}
+	return nil
+}
+
+// PublishEvent discards the event: nothing outside the process can subscribe to it
+func (m *Memory) PublishEvent(_ context.Context, _ string, _ []byte, _ string) error {
+	if !m.IsConnected() {
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive waits for the next delivery
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-deliveries:
+		require.True(t, ok, "deliveries closed")
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+func TestMemory_PublishConsume(t *testing.T) {
+	m := NewMemory(MemoryOptions{})
+	ctx := context.Background()
+
+	require.NoError(t, m.Publish(ctx, []byte("1"), "application/json"))
+	require.NoError(t, m.PublishOrdered(ctx, "key", []byte("2"), "application/json"))
+
+	deliveries, err := m.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+
+	first := receive(t, deliveries)
+	assert.Equal(t, []byte("1"), first.Body())
+	assert.Equal(t, "application/json", first.ContentType())
+	require.NoError(t, first.Ack())
+	assert.ErrorIs(t, first.Ack(), ErrAlreadySettled)
+
+	assert.Equal(t, []byte("2"), receive(t, deliveries).Body())
+}
+
+func TestMemory_Nack(t *testing.T) {
+	m := NewMemory(MemoryOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, m.Publish(ctx, []byte("retry"), "application/json"))
+	deliveries, err := m.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+
+	require.NoError(t, receive(t, deliveries).Nack(true))
+	redelivered := receive(t, deliveries)
+	assert.Equal(t, []byte("retry"), redelivered.Body())
+
+	require.NoError(t, redelivered.Nack(false))
+	assert.ErrorIs(t, redelivered.Nack(true), ErrAlreadySettled)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestMemory_DeliveryDelay(t *testing.T) {
+	delay := 50 * time.Millisecond
+	m := NewMemory(MemoryOptions{DeliveryDelay: delay})
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, m.Publish(ctx, []byte("1"), "application/json"))
+	deliveries, err := m.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+
+	receive(t, deliveries)
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+}
+
+func TestMemory_FailureInjection(t *testing.T) {
+	m := NewMemory(MemoryOptions{PublishFailureRate: 1})
+
+	err := m.Publish(context.Background(), []byte("1"), "application/json")
+	assert.ErrorIs(t, err, ErrInjectedFailure)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestMemory_StoppedConsumerRequeuesUnsettled(t *testing.T) {
+	m := NewMemory(MemoryOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	require.NoError(t, m.Publish(ctx, []byte("1"), "application/json"))
+	deliveries, err := m.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+	unacked := receive(t, deliveries)
+
+	cancel()
+	for range deliveries {
+	}
+	assert.Equal(t, 1, m.Len())
+	assert.ErrorIs(t, unacked.Ack(), ErrAlreadySettled)
+
+	deliveries, err = m.Consume(context.Background(), "worker-2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), receive(t, deliveries).Body())
+}
+
+func TestMemory_QueueFull(t *testing.T) {
+	m := NewMemory(MemoryOptions{QueueSize: 1})
+	ctx := context.Background()
+
+	require.NoError(t, m.Publish(ctx, []byte("1"), "application/json"))
+	assert.ErrorIs(t, m.Publish(ctx, []byte("2"), "application/json"), ErrQueueFull)
+}
+
+func TestMemory_Close(t *testing.T) {
+	m := NewMemory(MemoryOptions{})
+	deliveries, err := m.Consume(context.Background(), "worker-1")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Close())
+	_, ok := <-deliveries
+	assert.False(t, ok)
+	assert.False(t, m.IsConnected())
+	assert.ErrorIs(t, m.Publish(context.Background(), []byte("1"), "application/json"), ErrClosed)
+
+	_, err = m.Consume(context.Background(), "worker-2")
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,82 @@
+// Package jobstatus defines the statuses a job goes through and which changes between
+// them are legal. It is shared by every service that writes job statuses, so they all
+// agree on the lifecycle.
+package jobstatus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is the status of a job
+type Status string
+
+const (
+	// Waiting jobs are held until every job they depend on has completed
+	Waiting   Status = "WAITING"
+	Pending   Status = "PENDING"
+	Running   Status = "RUNNING"
+	Completed Status = "COMPLETED"
+	Failed    Status = "FAILED"
+	Canceled  Status = "CANCELED"
+)
+
+// ErrInvalidTransition is matched by the error an illegal status change is rejected with
+var ErrInvalidTransition = errors.New("invalid job status transition")
+
+// TransitionError is returned for a status change the lifecycle does not allow
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s from %s to %s", ErrInvalidTransition, e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) match
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// transitions lists the statuses each status may change to
+var transitions = map[Status][]Status{
+	Waiting: {Pending, Canceled},
+	Pending: {Running, Canceled},
+	Running: {Completed, Failed, Canceled},
+	// Retries put finished jobs back in the queue
+	Failed:   {Pending},
+	Canceled: {Pending},
+}
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	switch s {
+	case Waiting, Pending, Running, Completed, Failed, Canceled:
+		return true
+	}
+	return false
+}
+
+// Terminal reports whether s is a final status a job only leaves when it is retried
+func (s Status) Terminal() bool {
+	return s == Completed || s == Failed || s == Canceled
+}
+
+// CanTransition reports whether a job may change from one status to another
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a *TransitionError if a job may not change from one status to another
+func Check(from, to Status) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
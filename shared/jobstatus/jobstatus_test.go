@@ -0,0 +1,63 @@
+package jobstatus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{Waiting, Pending, true},
+		{Waiting, Canceled, true},
+		{Waiting, Running, false},
+		{Pending, Running, true},
+		{Pending, Canceled, true},
+		{Pending, Completed, false},
+		{Running, Completed, true},
+		{Running, Failed, true},
+		{Running, Canceled, true},
+		{Running, Pending, false},
+		{Failed, Pending, true},
+		{Canceled, Pending, true},
+		{Completed, Pending, false},
+		{Completed, Failed, false},
+		{Pending, Pending, false},
+		{"UNKNOWN", Pending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(Running, Completed))
+
+	err := Check(Completed, Running)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	var transition *TransitionError
+	assert.True(t, errors.As(err, &transition))
+	assert.Equal(t, &TransitionError{From: Completed, To: Running}, transition)
+	assert.EqualError(t, err, "invalid job status transition from COMPLETED to RUNNING")
+}
+
+func TestStatus(t *testing.T) {
+	for status := range transitions {
+		assert.True(t, status.Valid(), status)
+	}
+	assert.True(t, Completed.Valid())
+	assert.False(t, Status("RETRYING").Valid())
+
+	assert.True(t, Completed.Terminal())
+	assert.True(t, Failed.Terminal())
+	assert.True(t, Canceled.Terminal())
+	assert.False(t, Running.Terminal())
+	assert.False(t, Waiting.Terminal())
+}
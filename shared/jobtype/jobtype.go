@@ -0,0 +1,150 @@
+// Package jobtype describes the job types workers have executors for. Workers and the API
+// service read the same definitions file: workers take each job type's timeout and retry
+// limit from it, and the API service rejects job types that are not defined or disabled
+// and publishes the definitions to clients.
+package jobtype
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults of a definition, the same as the jobs table column defaults
+const (
+	DefaultTimeout    = 300 * time.Second
+	DefaultMaxRetries = 3
+)
+
+// MaxNameLength is the longest job type jobs.job_type holds
+const MaxNameLength = 50
+
+// Definition is one job type
+type Definition struct {
+	Name string
+	// Timeout bounds one execution of a job of this type
+	Timeout time.Duration
+	// MaxRetries is how many times a failed job is retried
+	MaxRetries int
+	// Enabled job types are accepted by the API service and run by workers
+	Enabled bool
+	// Description is shown to API clients
+	Description string
+}
+
+// file is the layout of a definitions file
+type file struct {
+	JobTypes []struct {
+		Name        string        `yaml:"name"`
+		Timeout     time.Duration `yaml:"timeout"`     // 0 uses DefaultTimeout
+		MaxRetries  *int          `yaml:"max_retries"` // Unset uses DefaultMaxRetries
+		Enabled     *bool         `yaml:"enabled"`     // Unset enables the job type
+		Description string        `yaml:"description"`
+	} `yaml:"job_types"`
+}
+
+// Registry holds job type definitions by name. A nil Registry holds none.
+type Registry struct {
+	definitions map[string]Definition
+}
+
+// NewRegistry returns a Registry of definitions, which must have unique, valid names
+func NewRegistry(definitions ...Definition) (*Registry, error) {
+	r := &Registry{definitions: make(map[string]Definition, len(definitions))}
+	for _, d := range definitions {
+		switch {
+		case d.Name == "":
+			return nil, fmt.Errorf("job type name is required")
+		case len(d.Name) > MaxNameLength:
+			return nil, fmt.Errorf("job type %s: name is longer than %d characters", d.Name, MaxNameLength)
+		case d.Timeout < 0:
+			return nil, fmt.Errorf("job type %s: timeout must not be negative", d.Name)
+		case d.MaxRetries < 0:
+			return nil, fmt.Errorf("job type %s: max_retries must not be negative", d.Name)
+		}
+		if _, ok := r.definitions[d.Name]; ok {
+			return nil, fmt.Errorf("job type %s is defined twice", d.Name)
+		}
+		r.definitions[d.Name] = d
+	}
+	return r, nil
+}
+
+// Load reads a definitions file:
+//
+//	job_types:
+//	  - name: send_email
+//	    timeout: 30s
+//	    max_retries: 5
+//	    enabled: true
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job type definitions: %w", err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse job type definitions %s: %w", path, err)
+	}
+
+	definitions := make([]Definition, 0, len(f.JobTypes))
+	for _, t := range f.JobTypes {
+		d := Definition{
+			Name:        t.Name,
+			Timeout:     t.Timeout,
+			MaxRetries:  DefaultMaxRetries,
+			Enabled:     true,
+			Description: t.Description,
+		}
+		if d.Timeout == 0 {
+			d.Timeout = DefaultTimeout
+		}
+		if t.MaxRetries != nil {
+			d.MaxRetries = *t.MaxRetries
+		}
+		if t.Enabled != nil {
+			d.Enabled = *t.Enabled
+		}
+		definitions = append(definitions, d)
+	}
+
+	r, err := NewRegistry(definitions...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job type definitions %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Len returns the number of defined job types
+func (r *Registry) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.definitions)
+}
+
+// Lookup returns the definition of a job type
+func (r *Registry) Lookup(name string) (Definition, bool) {
+	if r == nil {
+		return Definition{}, false
+	}
+	d, ok := r.definitions[name]
+	return d, ok
+}
+
+// All returns every definition, ordered by name
+func (r *Registry) All() []Definition {
+	if r == nil {
+		return nil
+	}
+	definitions := make([]Definition, 0, len(r.definitions))
+	for _, d := range r.definitions {
+		definitions = append(definitions, d)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
@@ -0,0 +1,85 @@
+package jobtype
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job_types.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+job_types:
+  - name: send_email
+    timeout: 30s
+    max_retries: 5
+    description: Sends one email
+  - name: resize_image
+    max_retries: 0
+    enabled: false
+`), 0o600))
+
+	r, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Len())
+
+	email, ok := r.Lookup("send_email")
+	require.True(t, ok)
+	assert.Equal(t, Definition{
+		Name:        "send_email",
+		Timeout:     30 * time.Second,
+		MaxRetries:  5,
+		Enabled:     true,
+		Description: "Sends one email",
+	}, email)
+
+	resize, ok := r.Lookup("resize_image")
+	require.True(t, ok)
+	assert.Equal(t, DefaultTimeout, resize.Timeout)
+	assert.Equal(t, 0, resize.MaxRetries, "an explicit 0 disables retries")
+	assert.False(t, resize.Enabled)
+
+	_, ok = r.Lookup("unknown")
+	assert.False(t, ok)
+
+	all := r.All()
+	require.Len(t, all, 2)
+	assert.Equal(t, "resize_image", all[0].Name)
+	assert.Equal(t, "send_email", all[1].Name)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"duplicate":        "job_types:\n  - name: a\n  - name: a\n",
+		"missing name":     "job_types:\n  - timeout: 1s\n",
+		"long name":        "job_types:\n  - name: " + strings.Repeat("a", MaxNameLength+1) + "\n",
+		"negative retries": "job_types:\n  - name: a\n    max_retries: -1\n",
+		"not yaml":         "job_types: [",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "job_types.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := Load(path)
+			assert.ErrorContains(t, err, path)
+		})
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var r *Registry
+	assert.Equal(t, 0, r.Len())
+	assert.Nil(t, r.All())
+	_, ok := r.Lookup("send_email")
+	assert.False(t, ok)
+}
@@ -0,0 +1,363 @@
+// Package kafka implements broker.Broker on Apache Kafka, for deployments that already
+// run Kafka instead of RabbitMQ.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/segmentio/kafka-go"
+)
+
+// contentTypeHeader carries the content type of a message, which Kafka has no field for
+const contentTypeHeader = "content-type"
+
+const (
+	// defaultMaxMessageBytes is Kafka's default message.max.bytes
+	defaultMaxMessageBytes = 1 << 20
+	defaultWriteTimeout    = 10 * time.Second
+	defaultDialTimeout     = 10 * time.Second
+
+	// batchTimeout bounds how long a publish waits for others to share its request
+	batchTimeout = 10 * time.Millisecond
+)
+
+// Config holds Kafka connection and topic configuration
+type Config struct {
+	Brokers []string // Bootstrap brokers as host:port
+	Topic   string   // Topic job messages are published to and consumed from
+	GroupID string   // Consumer group Consume joins
+	// DeadLetterTopic receives messages nacked without requeueing, empty drops them
+	DeadLetterTopic string
+	// EventsTopic is the topic PublishEvent publishes to, empty disables events
+	EventsTopic     string
+	MaxMessageBytes int           // Messages larger than this are rejected before publishing, 0 uses 1 MiB
+	WriteTimeout    time.Duration // Per publish, 0 uses 10s
+	DialTimeout     time.Duration // Per connection attempt, 0 uses 10s
+	TLS             TLSConfig
+	SASL            SASLConfig
+}
+
+// writer publishes messages, implemented by kafka.Writer
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// reader consumes messages as a member of a consumer group, implemented by kafka.Reader
+type reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Broker publishes job messages to a Kafka topic and consumes them as a consumer group.
+//
+// Publish spreads messages over the partitions of the topic, PublishOrdered sends every
+// message with the same ordering key to the same partition. Ack commits the offset of the
+// message. Kafka tracks a consumer group's progress as one offset per partition, so acking
+// a message also acknowledges the earlier messages of its partition, and unsettled ones
+// are only redelivered after the group rebalances. Nack with requeue publishes the message
+// again at the end of the topic.
+type Broker struct {
+	config    Config
+	logger    *slog.Logger
+	writer    writer
+	newReader func(consumerTag string) reader
+
+	// connected is cleared when a publish fails and set again by the next one to succeed
+	connected atomic.Bool
+	// done is canceled by Close, which stops every consumer
+	done context.Context
+	stop context.CancelFunc
+}
+
+var (
+	_ broker.Broker         = (*Broker)(nil)
+	_ broker.EventPublisher = (*Broker)(nil)
+)
+
+// NewBroker connects to the first reachable broker of cfg.Brokers and checks that the
+// topic exists. Publishes and consumers open connections of their own as needed.
+func NewBroker(cfg *Config, logger *slog.Logger) (*Broker, error) {
+	config := withDefaults(*cfg)
+	tlsConfig, err := config.TLS.clientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka TLS config: %w", err)
+	}
+	mechanism, err := config.SASL.mechanism()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:       config.DialTimeout,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}
+	if err := checkTopic(dialer, config.Brokers, config.Topic); err != nil {
+		return nil, err
+	}
+
+	errorLogger := kafka.LoggerFunc(func(msg string, args ...any) {
+		logger.Warn("Kafka client error", slog.String("error", fmt.Sprintf(msg, args...)))
+	})
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: batchTimeout,
+		BatchBytes:   int64(config.MaxMessageBytes),
+		WriteTimeout: config.WriteTimeout,
+		ErrorLogger:  errorLogger,
+		Transport: &kafka.Transport{
+			DialTimeout: config.DialTimeout,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+	}
+	newReader := func(consumerTag string) reader {
+		consumerDialer := *dialer
+		consumerDialer.ClientID = consumerTag
+		return kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     config.Brokers,
+			GroupID:     config.GroupID,
+			Topic:       config.Topic,
+			Dialer:      &consumerDialer,
+			MaxBytes:    config.MaxMessageBytes,
+			ErrorLogger: errorLogger,
+		})
+	}
+
+	logger.Info("Connected to Kafka",
+		slog.Any("brokers", config.Brokers),
+		slog.String("topic", config.Topic),
+	)
+	return newBroker(config, logger, w, newReader), nil
+}
+
+// newBroker creates a Broker on w, with consumers created by newReader
+func newBroker(config Config, logger *slog.Logger, w writer, newReader func(consumerTag string) reader) *Broker {
+	b := &Broker{
+		config:    withDefaults(config),
+		logger:    logger,
+		writer:    w,
+		newReader: newReader,
+	}
+	b.done, b.stop = context.WithCancel(context.Background())
+	b.connected.Store(true)
+	return b
+}
+
+// withDefaults fills in the zero settings that have a default
+func withDefaults(config Config) Config {
+	if config.MaxMessageBytes <= 0 {
+		config.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaultWriteTimeout
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = defaultDialTimeout
+	}
+	return config
+}
+
+// checkTopic reads the partitions of topic from the first broker that accepts a connection
+func checkTopic(dialer *kafka.Dialer, brokers []string, topic string) error {
+	var errs []error
+	for _, address := range brokers {
+		ctx, cancel := context.WithTimeout(context.Background(), dialer.Timeout)
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", address, err))
+			continue
+		}
+
+		partitions, err := conn.ReadPartitions(topic)
+		conn.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read Kafka topic %s: %w", topic, err)
+		}
+		if len(partitions) == 0 {
+			return fmt.Errorf("kafka topic %s has no partitions", topic)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to connect to Kafka: %w", errors.Join(errs...))
+}
+
+// Publish publishes a job message to a partition picked round-robin
+func (b *Broker) Publish(ctx context.Context, body []byte, contentType string) error {
+	return b.publish(ctx, b.message(b.config.Topic, nil, body, contentType))
+}
+
+// PublishOrdered publishes a job message keyed by orderingKey, so every message with the
+// key goes to the same partition and is consumed in publish order. An empty key behaves
+// like Publish.
+func (b *Broker) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	var key []byte
+	if orderingKey != "" {
+		key = []byte(orderingKey)
+	}
+	return b.publish(ctx, b.message(b.config.Topic, key, body, contentType))
+}
+
+// PublishEvent publishes an event to the events topic, keyed by routingKey so the events
+// of one kind stay in order. Subscribers filter on the key.
+func (b *Broker) PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error {
+	if b.config.EventsTopic == "" {
+		return broker.ErrNoEventsExchange
+	}
+	return b.publish(ctx, b.message(b.config.EventsTopic, []byte(routingKey), body, contentType))
+}
+
+func (b *Broker) message(topic string, key, body []byte, contentType string) kafka.Message {
+	msg := kafka.Message{Topic: topic, Key: key, Value: body}
+	if contentType != "" {
+		msg.Headers = []kafka.Header{{Key: contentTypeHeader, Value: []byte(contentType)}}
+	}
+	return msg
+}
+
+func (b *Broker) publish(ctx context.Context, msg kafka.Message) error {
+	if b.done.Err() != nil {
+		return broker.ErrClosed
+	}
+	if len(msg.Value) > b.config.MaxMessageBytes {
+		return fmt.Errorf("%w: %d bytes (limit %d)", broker.ErrMessageTooLarge, len(msg.Value), b.config.MaxMessageBytes)
+	}
+
+	if err := b.writer.WriteMessages(ctx, msg); err != nil {
+		// A canceled publish says nothing about the brokers
+		if ctx.Err() == nil {
+			b.connected.Store(false)
+		}
+		return fmt.Errorf("failed to publish to Kafka topic %s: %w", msg.Topic, err)
+	}
+	b.connected.Store(true)
+	return nil
+}
+
+// Consume joins the consumer group and delivers messages from the topic until ctx is
+// canceled or the broker is closed. consumerTag is sent as the client ID of the consumer.
+func (b *Broker) Consume(ctx context.Context, consumerTag string) (<-chan broker.Delivery, error) {
+	if b.done.Err() != nil {
+		return nil, broker.ErrClosed
+	}
+
+	r := b.newReader(consumerTag)
+	deliveries := make(chan broker.Delivery)
+	go func() {
+		defer close(deliveries)
+		defer func() {
+			if err := r.Close(); err != nil {
+				b.logger.Warn("Failed to close Kafka consumer", slog.Any("error", err))
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(b.done, cancel)
+		defer stop()
+
+		for {
+			msg, err := r.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					b.logger.Error("Kafka consumer stopped", slog.Any("error", err))
+				}
+				return
+			}
+
+			select {
+			case deliveries <- &delivery{broker: b, reader: r, msg: msg}:
+			case <-ctx.Done():
+				// Uncommitted messages are delivered again after the group rebalances
+				return
+			}
+		}
+	}()
+
+	return deliveries, nil
+}
+
+// IsConnected reports whether the broker is open and the last publish, if any, reached Kafka
+func (b *Broker) IsConnected() bool {
+	return b.done.Err() == nil && b.connected.Load()
+}
+
+// Close stops every consumer and flushes the writer
+func (b *Broker) Close() error {
+	if b.done.Err() != nil {
+		return nil
+	}
+	b.stop()
+	return b.writer.Close()
+}
+
+// delivery adapts kafka.Message to broker.Delivery
+type delivery struct {
+	broker  *Broker
+	reader  reader
+	msg     kafka.Message
+	settled atomic.Bool
+}
+
+func (d *delivery) Body() []byte { return d.msg.Value }
+
+func (d *delivery) ContentType() string {
+	for _, header := range d.msg.Headers {
+		if header.Key == contentTypeHeader {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Ack commits the offset of the message
+func (d *delivery) Ack() error {
+	if !d.settled.CompareAndSwap(false, true) {
+		return broker.ErrAlreadySettled
+	}
+	return d.commit()
+}
+
+// Nack commits the offset of the message after publishing it again at the end of the
+// topic when requeue is true, or to the dead letter topic, if any, otherwise. If that
+// publish fails the offset is not committed.
+func (d *delivery) Nack(requeue bool) error {
+	if !d.settled.CompareAndSwap(false, true) {
+		return broker.ErrAlreadySettled
+	}
+
+	topic := d.broker.config.Topic
+	if !requeue {
+		topic = d.broker.config.DeadLetterTopic
+	}
+	if topic != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), d.broker.config.WriteTimeout)
+		defer cancel()
+		msg := kafka.Message{Topic: topic, Key: d.msg.Key, Value: d.msg.Value, Headers: d.msg.Headers}
+		if err := d.broker.publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return d.commit()
+}
+
+func (d *delivery) commit() error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.broker.config.WriteTimeout)
+	defer cancel()
+	if err := d.reader.CommitMessages(ctx, d.msg); err != nil {
+		return fmt.Errorf("failed to commit Kafka offset %d of partition %d: %w", d.msg.Offset, d.msg.Partition, err)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWriter records the messages written to it
+type fakeWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *fakeWriter) written() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]kafka.Message(nil), w.messages...)
+}
+
+// fakeReader hands out the messages sent on fetch and records the commits
+type fakeReader struct {
+	fetch chan kafka.Message
+
+	mu        sync.Mutex
+	committed []kafka.Message
+	closed    bool
+}
+
+func newFakeReader() *fakeReader {
+	return &fakeReader{fetch: make(chan kafka.Message, 10)}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.fetch:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *fakeReader) commits() []kafka.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]kafka.Message(nil), r.committed...)
+}
+
+func (r *fakeReader) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+func newTestBroker(config Config) (*Broker, *fakeWriter, *fakeReader) {
+	w := &fakeWriter{}
+	r := newFakeReader()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return newBroker(config, logger, w, func(string) reader { return r }), w, r
+}
+
+// receive waits for the next delivery
+func receive(t *testing.T, deliveries <-chan broker.Delivery) broker.Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-deliveries:
+		require.True(t, ok, "deliveries closed")
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+func TestBroker_Publish(t *testing.T) {
+	b, w, _ := newTestBroker(Config{Topic: "jobs", EventsTopic: "job-events"})
+	ctx := context.Background()
+
+	require.NoError(t, b.Publish(ctx, []byte("1"), "application/json"))
+	require.NoError(t, b.PublishOrdered(ctx, "user-1", []byte("2"), "application/x-protobuf"))
+	require.NoError(t, b.PublishOrdered(ctx, "", []byte("3"), ""))
+	require.NoError(t, b.PublishEvent(ctx, "job.send_email.completed", []byte("4"), "application/json"))
+
+	messages := w.written()
+	require.Len(t, messages, 4)
+
+	assert.Equal(t, "jobs", messages[0].Topic)
+	assert.Nil(t, messages[0].Key, "unordered messages are spread over the partitions")
+	assert.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte("application/json")}}, messages[0].Headers)
+
+	assert.Equal(t, []byte("user-1"), messages[1].Key, "messages with an ordering key share a partition")
+	assert.Equal(t, []byte("application/x-protobuf"), messages[1].Headers[0].Value)
+
+	assert.Nil(t, messages[2].Key)
+	assert.Empty(t, messages[2].Headers)
+
+	assert.Equal(t, "job-events", messages[3].Topic)
+	assert.Equal(t, []byte("job.send_email.completed"), messages[3].Key)
+}
+
+func TestBroker_PublishErrors(t *testing.T) {
+	t.Run("too large", func(t *testing.T) {
+		b, w, _ := newTestBroker(Config{Topic: "jobs", MaxMessageBytes: 4})
+
+		err := b.Publish(context.Background(), []byte("12345"), "application/json")
+		assert.ErrorIs(t, err, broker.ErrMessageTooLarge)
+		assert.Empty(t, w.written())
+	})
+
+	t.Run("no events topic", func(t *testing.T) {
+		b, _, _ := newTestBroker(Config{Topic: "jobs"})
+
+		err := b.PublishEvent(context.Background(), "job.send_email.completed", []byte("{}"), "application/json")
+		assert.ErrorIs(t, err, broker.ErrNoEventsExchange)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		b, w, _ := newTestBroker(Config{Topic: "jobs"})
+		w.err = errors.New("connection refused")
+
+		assert.Error(t, b.Publish(context.Background(), []byte("1"), "application/json"))
+		assert.False(t, b.IsConnected())
+
+		w.err = nil
+		require.NoError(t, b.Publish(context.Background(), []byte("1"), "application/json"))
+		assert.True(t, b.IsConnected())
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		b, w, _ := newTestBroker(Config{Topic: "jobs"})
+		w.err = context.Canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Error(t, b.Publish(ctx, []byte("1"), "application/json"))
+		assert.True(t, b.IsConnected(), "a canceled publish says nothing about the brokers")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		b, w, _ := newTestBroker(Config{Topic: "jobs"})
+		require.NoError(t, b.Close())
+
+		assert.True(t, w.closed)
+		assert.False(t, b.IsConnected())
+		assert.ErrorIs(t, b.Publish(context.Background(), []byte("1"), "application/json"), broker.ErrClosed)
+		_, err := b.Consume(context.Background(), "worker-1")
+		assert.ErrorIs(t, err, broker.ErrClosed)
+	})
+}
+
+func TestBroker_Consume(t *testing.T) {
+	b, _, r := newTestBroker(Config{Topic: "jobs"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := kafka.Message{
+		Topic:     "jobs",
+		Partition: 2,
+		Offset:    41,
+		Value:     []byte(`{"job_id":"1"}`),
+		Headers:   []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+	}
+	r.fetch <- msg
+
+	deliveries, err := b.Consume(ctx, "worker-1")
+	require.NoError(t, err)
+
+	d := receive(t, deliveries)
+	assert.Equal(t, msg.Value, d.Body())
+	assert.Equal(t, "application/json", d.ContentType())
+
+	require.NoError(t, d.Ack())
+	assert.Equal(t, []kafka.Message{msg}, r.commits())
+	assert.ErrorIs(t, d.Ack(), broker.ErrAlreadySettled)
+	assert.ErrorIs(t, d.Nack(true), broker.ErrAlreadySettled)
+	assert.Len(t, r.commits(), 1)
+}
+
+func TestBroker_Nack(t *testing.T) {
+	msg := kafka.Message{
+		Topic:   "jobs",
+		Offset:  7,
+		Key:     []byte("user-1"),
+		Value:   []byte("job"),
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+	}
+
+	tests := []struct {
+		name            string
+		deadLetterTopic string
+		requeue         bool
+		wantTopic       string // Topic the message is published to again, empty for none
+	}{
+		{name: "requeue", requeue: true, wantTopic: "jobs"},
+		{name: "requeue with a dead letter topic", deadLetterTopic: "jobs.dead", requeue: true, wantTopic: "jobs"},
+		{name: "reject to the dead letter topic", deadLetterTopic: "jobs.dead", wantTopic: "jobs.dead"},
+		{name: "reject without a dead letter topic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, w, r := newTestBroker(Config{Topic: "jobs", DeadLetterTopic: tt.deadLetterTopic})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			r.fetch <- msg
+			deliveries, err := b.Consume(ctx, "worker-1")
+			require.NoError(t, err)
+
+			require.NoError(t, receive(t, deliveries).Nack(tt.requeue))
+			assert.Equal(t, []kafka.Message{msg}, r.commits())
+
+			if tt.wantTopic == "" {
+				assert.Empty(t, w.written())
+				return
+			}
+			assert.Equal(t, []kafka.Message{{
+				Topic:   tt.wantTopic,
+				Key:     msg.Key,
+				Value:   msg.Value,
+				Headers: msg.Headers,
+			}}, w.written())
+		})
+	}
+
+	t.Run("publish fails", func(t *testing.T) {
+		b, w, r := newTestBroker(Config{Topic: "jobs"})
+		w.err = errors.New("connection refused")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r.fetch <- msg
+		deliveries, err := b.Consume(ctx, "worker-1")
+		require.NoError(t, err)
+
+		assert.Error(t, receive(t, deliveries).Nack(true))
+		assert.Empty(t, r.commits(), "the message must stay uncommitted to be delivered again")
+	})
+}
+
+func TestBroker_ConsumeStops(t *testing.T) {
+	t.Run("canceled", func(t *testing.T) {
+		b, _, r := newTestBroker(Config{Topic: "jobs"})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		deliveries, err := b.Consume(ctx, "worker-1")
+		require.NoError(t, err)
+		cancel()
+
+		assertClosed(t, deliveries)
+		assert.Eventually(t, r.isClosed, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("broker closed", func(t *testing.T) {
+		b, _, r := newTestBroker(Config{Topic: "jobs"})
+
+		deliveries, err := b.Consume(context.Background(), "worker-1")
+		require.NoError(t, err)
+		require.NoError(t, b.Close())
+
+		assertClosed(t, deliveries)
+		assert.Eventually(t, r.isClosed, time.Second, 10*time.Millisecond)
+	})
+}
+
+// assertClosed waits for deliveries to be closed
+func assertClosed(t *testing.T, deliveries <-chan broker.Delivery) {
+	t.Helper()
+	select {
+	case _, ok := <-deliveries:
+		assert.False(t, ok, "unexpected delivery")
+	case <-time.After(time.Second):
+		t.Fatal("deliveries not closed")
+	}
+}
+
+func TestSASLConfig_Mechanism(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		wantName  string
+		wantErr   bool
+	}{
+		{mechanism: ""},
+		{mechanism: SASLPlain, wantName: "PLAIN"},
+		{mechanism: SASLScramSHA256, wantName: "SCRAM-SHA-256"},
+		{mechanism: SASLScramSHA512, wantName: "SCRAM-SHA-512"},
+		{mechanism: "gssapi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			mechanism, err := SASLConfig{Mechanism: tt.mechanism, Username: "jobs", Password: "secret"}.mechanism()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantName == "" {
+				assert.Nil(t, mechanism)
+				return
+			}
+			assert.Equal(t, tt.wantName, mechanism.Name())
+		})
+	}
+}
+
+func TestTLSConfig_ClientConfig(t *testing.T) {
+	cfg, err := TLSConfig{}.clientConfig()
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "TLS is disabled by default")
+
+	cfg, err = TLSConfig{Enabled: true, ServerName: "kafka.internal"}.clientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "kafka.internal", cfg.ServerName)
+	assert.Nil(t, cfg.RootCAs, "the system roots are used without a CA file")
+
+	_, err = TLSConfig{Enabled: true, CertFile: "client.pem"}.clientConfig()
+	assert.Error(t, err, "a certificate needs its key")
+
+	_, err = TLSConfig{Enabled: true, CAFile: "missing.pem"}.clientConfig()
+	assert.Error(t, err)
+}
+
+func TestNewBroker_Unreachable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	_, err := NewBroker(&Config{
+		Brokers:     []string{"127.0.0.1:1"},
+		Topic:       "jobs",
+		DialTimeout: time.Second,
+	}, logger)
+	assert.ErrorContains(t, err, "failed to connect to Kafka")
+}
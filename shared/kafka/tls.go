@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms accepted by SASLConfig.Mechanism
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// TLSConfig enables TLS connections to the brokers. File paths point at PEM files.
+type TLSConfig struct {
+	Enabled  bool
+	CAFile   string // CA bundle the broker certificates are verified against, empty uses the system roots
+	CertFile string // Client certificate, for clusters that require one; needs KeyFile
+	KeyFile  string
+	// ServerName is checked against the broker certificates, empty uses the host dialed
+	ServerName string
+	// InsecureSkipVerify accepts any broker certificate. Only for testing.
+	InsecureSkipVerify bool
+}
+
+// clientConfig loads the certificates into a tls.Config, or returns nil when TLS is disabled
+func (t TLSConfig) clientConfig() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// SASLConfig authenticates the connections to the brokers
+type SASLConfig struct {
+	Mechanism string // plain, scram-sha-256 or scram-sha-512; empty disables SASL
+	Username  string
+	Password  string
+}
+
+// mechanism returns the SASL mechanism to authenticate with, or nil when SASL is disabled
+func (s SASLConfig) mechanism() (sasl.Mechanism, error) {
+	switch s.Mechanism {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: s.Username, Password: s.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, s.Username, s.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, s.Username, s.Password)
+	default:
+		return nil, fmt.Errorf("unsupported Kafka SASL mechanism %q", s.Mechanism)
+	}
+}
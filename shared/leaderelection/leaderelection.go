@@ -0,0 +1,180 @@
+// Package leaderelection runs a task on one instance at a time using a PostgreSQL
+// session-level advisory lock.
+//
+// The lock is held on a dedicated connection for as long as the instance leads, and
+// PostgreSQL releases it when that session ends, so a crashed leader is replaced once its
+// connection is gone. The session is checked every RenewInterval. A leader whose session
+// died can keep running for up to RenewInterval after another instance took over, so
+// tasks must tolerate a short overlap.
+package leaderelection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// releaseTimeout bounds the unlock query run when leadership ends
+const releaseTimeout = 5 * time.Second
+
+// Config holds leader election settings
+type Config struct {
+	LockID        int64         // Advisory lock key shared by every instance competing for the role
+	RenewInterval time.Duration // How often the held lock is checked or a free one retried, 0 uses 5s
+
+	// OnAcquire runs in its own goroutine while the lock is held. Its ctx is canceled
+	// when leadership is lost or Run returns.
+	OnAcquire func(ctx context.Context)
+	// OnLose is called once OnAcquire has returned, whenever leadership ends
+	OnLose func()
+}
+
+// Elector competes for an advisory lock and runs Config.OnAcquire while it holds it
+type Elector struct {
+	db     *sql.DB
+	config Config
+	logger *slog.Logger
+	leader atomic.Bool
+}
+
+// New creates an Elector. Run starts competing for the lock.
+func New(db *sql.DB, config Config, logger *slog.Logger) *Elector {
+	if config.RenewInterval <= 0 {
+		config.RenewInterval = 5 * time.Second
+	}
+
+	return &Elector{
+		db:     db,
+		config: config,
+		logger: logger.With(slog.Int64("lock_id", config.LockID)),
+	}
+}
+
+// IsLeader reports whether this instance currently holds the lock
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run tries to acquire the lock every RenewInterval and leads while it holds it.
+// It returns once ctx is canceled and the lock has been released.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := e.tryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Warn("Failed to acquire leadership", slog.String("error", err.Error()))
+		}
+		if conn != nil {
+			e.lead(ctx, conn, ticker)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquire returns the connection holding the lock, or nil if another instance holds it
+func (e *Elector) tryAcquire(ctx context.Context) (*sql.Conn, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.config.LockID).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, nil
+	}
+	return conn, nil
+}
+
+// lead runs OnAcquire until ctx is canceled or the session holding the lock fails
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn, ticker *time.Ticker) {
+	e.leader.Store(true)
+	e.logger.Info("Acquired leadership")
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.config.OnAcquire(leaderCtx)
+	}()
+
+	lost := false
+	for !lost && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			if err := conn.PingContext(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Warn("Lost leadership, lock session failed", slog.String("error", err.Error()))
+				lost = true
+			}
+		}
+	}
+
+	cancel()
+	<-done
+	e.leader.Store(false)
+	e.release(conn, lost)
+
+	if !lost {
+		e.logger.Info("Released leadership")
+	}
+	if e.config.OnLose != nil {
+		e.config.OnLose()
+	}
+}
+
+// discarder is a driver connection whose session outlives Close, e.g. because a pool of
+// the driver's own takes it back. Discard ends the session.
+type discarder interface {
+	Discard(ctx context.Context) error
+}
+
+// release unlocks and returns conn to the pool. A connection whose session failed or
+// could not be unlocked is discarded instead, which ends the session and its lock.
+func (e *Elector) release(conn *sql.Conn, lost bool) {
+	if !lost {
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+
+		// Closing alone would return the connection to the pool with the lock still held
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.config.LockID)
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+		e.logger.Warn("Failed to release advisory lock, discarding connection", slog.String("error", err.Error()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	_ = conn.Raw(func(dc any) error {
+		// Pooled connections, like those of postgresql.Client, keep their session when
+		// closed, so it has to be ended first for the lock to go with it
+		if d, ok := dc.(discarder); ok {
+			if err := d.Discard(ctx); err != nil {
+				e.logger.Warn("Failed to end lock session", slog.String("error", err.Error()))
+			}
+		}
+		return driver.ErrBadConn
+	})
+	if err := conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+		e.logger.Warn("Failed to close lock connection", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,184 @@
+package leaderelection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockID = int64(42)
+
+func newTestElector(t *testing.T, renew time.Duration, onAcquire func(context.Context), onLose func()) (*Elector, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, Config{
+		LockID:        testLockID,
+		RenewInterval: renew,
+		OnAcquire:     onAcquire,
+		OnLose:        onLose,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil))), mock
+}
+
+func expectTryLock(mock sqlmock.Sqlmock, acquired bool) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")).
+		WithArgs(testLockID).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(acquired))
+}
+
+// runElector runs e until the returned stop function is called
+func runElector(e *Elector) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestElector_LeadsAndReleases(t *testing.T) {
+	acquired := make(chan struct{})
+	var leaderCtx context.Context
+	lost := 0
+	e, mock := newTestElector(t, time.Hour,
+		func(ctx context.Context) {
+			leaderCtx = ctx
+			close(acquired)
+			<-ctx.Done()
+		},
+		func() { lost++ },
+	)
+
+	expectTryLock(mock, true)
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(testLockID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stop := runElector(e)
+	<-acquired
+	assert.True(t, e.IsLeader())
+
+	stop()
+	assert.False(t, e.IsLeader())
+	assert.Error(t, leaderCtx.Err())
+	assert.Equal(t, 1, lost)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestElector_LockHeldElsewhere(t *testing.T) {
+	e, mock := newTestElector(t, time.Hour,
+		func(context.Context) { t.Error("OnAcquire called without the lock") },
+		nil,
+	)
+
+	expectTryLock(mock, false)
+
+	stop := runElector(e)
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+	assert.False(t, e.IsLeader())
+	stop()
+}
+
+func TestElector_LosesLeadershipWhenSessionFails(t *testing.T) {
+	stopped := make(chan struct{})
+	lost := make(chan struct{})
+	e, mock := newTestElector(t, 10*time.Millisecond,
+		func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		},
+		func() { close(lost) },
+	)
+
+	expectTryLock(mock, true)
+	mock.ExpectPing().WillReturnError(errors.New("connection reset by peer"))
+
+	stop := runElector(e)
+	defer stop()
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("OnLose was not called")
+	}
+	<-stopped
+	assert.False(t, e.IsLeader())
+	// The failed session is discarded rather than unlocked
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// pooledConn is a driver connection whose session outlives Close, like a pgxpool one
+type pooledConn struct {
+	execErr   error
+	discarded bool
+	closed    bool
+}
+
+func (c *pooledConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *pooledConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c *pooledConn) Close() error                        { c.closed = true; return nil }
+
+func (c *pooledConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), c.execErr
+}
+
+func (c *pooledConn) Discard(context.Context) error {
+	c.discarded = true
+	return nil
+}
+
+// pooledConnector hands out conn
+type pooledConnector struct{ conn *pooledConn }
+
+func (c pooledConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c pooledConnector) Driver() driver.Driver                        { return nil }
+
+func TestElector_ReleaseEndsPooledSessions(t *testing.T) {
+	release := func(t *testing.T, pc *pooledConn, lost bool) {
+		db := sql.OpenDB(pooledConnector{conn: pc})
+		t.Cleanup(func() { db.Close() })
+		conn, err := db.Conn(context.Background())
+		require.NoError(t, err)
+
+		e := New(db, Config{LockID: testLockID, OnAcquire: func(context.Context) {}},
+			slog.New(slog.NewTextHandler(io.Discard, nil)))
+		e.release(conn, lost)
+	}
+
+	t.Run("unlocked sessions go back to the pool", func(t *testing.T) {
+		pc := &pooledConn{}
+		release(t, pc, false)
+		assert.False(t, pc.discarded)
+	})
+
+	t.Run("session that could not be unlocked is ended", func(t *testing.T) {
+		// Returning it to the pool would hand the lock to the next borrower
+		pc := &pooledConn{execErr: errors.New("statement timeout")}
+		release(t, pc, false)
+		assert.True(t, pc.discarded)
+		assert.True(t, pc.closed)
+	})
+
+	t.Run("failed session is ended", func(t *testing.T) {
+		pc := &pooledConn{}
+		release(t, pc, true)
+		assert.True(t, pc.discarded)
+		assert.True(t, pc.closed)
+	})
+}
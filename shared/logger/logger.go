@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/lmittmann/tint"
@@ -11,24 +13,35 @@ import (
 
 // Config holds logger configuration
 type Config struct {
-	Level        string    // debug, info, warn, error
-	Format       string    // json, console
-	Output       string    // stdout, stderr, or file path
-	EnableSource bool      // Enable source code location
-	TimeFormat   string    // Time format for console output
-	writer       io.Writer // Optional writer for testing (not exported)
+	Level        string         // debug, info, warn, error
+	Format       string         // json, console
+	Output       string         // stdout, stderr, or file path
+	Rotation     RotationConfig // Rotation settings when Output is a file path
+	TeeStdout    bool           // Also write to stdout when Output is a file path
+	RedactKeys   []string       // Attribute keys to mask in addition to DefaultRedactKeys
+	Sampling     SamplingConfig // Sampling of repeated debug messages
+	Reporting    ReportConfig   // Forwarding of error records to an error tracker
+	EnableSource bool           // Enable source code location
+	TimeFormat   string         // Time format for console output
+	writer       io.Writer      // Optional writer for testing (not exported)
 }
 
 // Logger wraps slog.Logger
 type Logger struct {
 	*slog.Logger
+	level  *slog.LevelVar
+	closer io.Closer // Log file, nil for stdout/stderr
+	// reports sends error records to the error tracker, nil when reporting is disabled
+	reports *reportQueue
 }
 
 // New creates a new logger instance
 func New(config *Config) (*Logger, error) {
-	level := parseLevel(config.Level)
+	level := new(slog.LevelVar)
+	level.Set(parseLevel(config.Level))
 
 	var writer io.Writer
+	var closer io.Closer
 
 	// Use test writer if provided (for testing)
 	if config.writer != nil {
@@ -40,16 +53,25 @@ func New(config *Config) (*Logger, error) {
 		case "stdout", "":
 			writer = os.Stdout
 		default:
-			// TODO: Support file output
-			writer = os.Stdout
+			file, err := openRotatingFile(config.Output, config.Rotation)
+			if err != nil {
+				return nil, err
+			}
+			writer, closer = file, file
+			if config.TeeStdout {
+				writer = io.MultiWriter(file, os.Stdout)
+			}
 		}
 	}
 
 	var handler slog.Handler
 
+	redact := newRedactor(config.RedactKeys)
+
 	opts := &slog.HandlerOptions{
-		Level:     level,
-		AddSource: config.EnableSource,
+		Level:       level,
+		AddSource:   config.EnableSource,
+		ReplaceAttr: redact,
 	}
 
 	switch config.Format {
@@ -63,29 +85,85 @@ func New(config *Config) (*Logger, error) {
 		}
 
 		handler = tint.NewHandler(writer, &tint.Options{
-			Level:      level,
-			AddSource:  config.EnableSource,
-			TimeFormat: timeFormat,
-			NoColor:    false, // Enable colors
+			Level:       level,
+			AddSource:   config.EnableSource,
+			TimeFormat:  timeFormat,
+			NoColor:     false, // Enable colors
+			ReplaceAttr: redact,
 		})
 	default:
 		handler = slog.NewJSONHandler(writer, opts)
 	}
 
+	if config.Sampling.enabled() {
+		handler = &samplingHandler{next: handler, sampler: newSampler(config.Sampling)}
+	}
+
+	var reports *reportQueue
+	if config.Reporting.enabled() {
+		queue, err := newReportQueue(config.Reporting)
+		if err != nil {
+			if closer != nil {
+				_ = closer.Close()
+			}
+			return nil, err
+		}
+		reports = queue
+		handler = &reportHandler{next: handler, queue: queue, redact: redact}
+	}
+
 	logger := slog.New(handler)
 
-	return &Logger{Logger: logger}, nil
+	return &Logger{Logger: logger, level: level, closer: closer, reports: reports}, nil
 }
 
 // NewDefault creates a logger with default settings (console format, info level)
 func NewDefault() *Logger {
+	level := new(slog.LevelVar)
 	handler := tint.NewHandler(os.Stdout, &tint.Options{
-		Level:      slog.LevelInfo,
+		Level:      level,
 		TimeFormat: time.TimeOnly,
 		NoColor:    false,
 	})
 
-	return &Logger{Logger: slog.New(handler)}
+	return &Logger{Logger: slog.New(handler), level: level}
+}
+
+// SetLevel changes the minimum level at runtime for this logger and every logger derived
+// from it. Unknown levels are rejected and leave the current level unchanged.
+func (l *Logger) SetLevel(level string) error {
+	if !validLevel(level) {
+		return fmt.Errorf("unknown log level %q (must be debug, info, warn or error)", level)
+	}
+	l.level.Set(parseLevel(level))
+	return nil
+}
+
+// Level returns the current minimum level as a lower-case string
+func (l *Logger) Level() string {
+	return strings.ToLower(l.level.Level().String())
+}
+
+// validLevel reports whether level is understood by parseLevel
+func validLevel(level string) bool {
+	switch level {
+	case "debug", "info", "warn", "warning", "error":
+		return true
+	default:
+		return false
+	}
+}
+
+// Close sends the error events still queued and closes the log file, if any. Loggers
+// derived with With* share the file, so Close should only be called once, on shutdown.
+func (l *Logger) Close() error {
+	if l.reports != nil {
+		l.reports.close()
+	}
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
 }
 
 // parseLevel converts string level to slog.Level
@@ -106,17 +184,17 @@ func parseLevel(level string) slog.Level {
 
 // WithGroup creates a new logger with a group namespace
 func (l *Logger) WithGroup(name string) *Logger {
-	return &Logger{Logger: l.Logger.WithGroup(name)}
+	return &Logger{Logger: l.Logger.WithGroup(name), level: l.level, closer: l.closer, reports: l.reports}
 }
 
 // WithAttrs creates a new logger with additional attributes
 func (l *Logger) WithAttrs(attrs ...slog.Attr) *Logger {
-	return &Logger{Logger: l.Logger.With(attrsToAny(attrs)...)}
+	return &Logger{Logger: l.Logger.With(attrsToAny(attrs)...), level: l.level, closer: l.closer, reports: l.reports}
 }
 
 // With creates a new logger with additional key-value pairs
 func (l *Logger) With(args ...any) *Logger {
-	return &Logger{Logger: l.Logger.With(args...)}
+	return &Logger{Logger: l.Logger.With(args...), level: l.level, closer: l.closer, reports: l.reports}
 }
 
 // attrsToAny converts []slog.Attr to []any
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -373,3 +375,51 @@ func TestLogger_MultipleAttributes(t *testing.T) {
 	assert.Equal(t, true, logEntry["bool_val"])
 	assert.Equal(t, 3.14, logEntry["float_val"])
 }
+
+func TestLogger_SetLevel(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	logger, err := New(&Config{
+		Level:  "info",
+		Format: "json",
+		writer: output,
+	})
+	require.NoError(t, err)
+
+	child := logger.With(slog.String("component", "test"))
+
+	child.Debug("hidden")
+	assert.Empty(t, output.String())
+
+	require.NoError(t, logger.SetLevel("debug"))
+	child.Debug("visible")
+	assert.Contains(t, output.String(), "visible")
+	assert.Equal(t, "debug", logger.Level())
+
+	output.Reset()
+	require.NoError(t, logger.SetLevel("error"))
+	child.Warn("hidden again")
+	assert.Empty(t, output.String())
+
+	assert.Error(t, logger.SetLevel("verbose"))
+	assert.Equal(t, "error", logger.Level())
+}
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "api.log")
+
+	logger, err := New(&Config{
+		Level:  "info",
+		Format: "json",
+		Output: path,
+	})
+	require.NoError(t, err)
+
+	logger.With(slog.String("component", "test")).Info("written to file")
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "written to file")
+	assert.Contains(t, string(data), `"component":"test"`)
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// RedactedValue replaces the value of redacted attributes
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are always redacted, in any group and regardless of case
+var DefaultRedactKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"cookie",
+}
+
+// newRedactor returns a slog ReplaceAttr func that masks attributes whose key matches
+// DefaultRedactKeys or extra, case-insensitively
+func newRedactor(extra []string) func(groups []string, a slog.Attr) slog.Attr {
+	keys := make(map[string]bool, len(DefaultRedactKeys)+len(extra))
+	for _, k := range DefaultRedactKeys {
+		keys[strings.ToLower(k)] = true
+	}
+	for _, k := range extra {
+		keys[strings.ToLower(k)] = true
+	}
+
+	return func(_ []string, a slog.Attr) slog.Attr {
+		if keys[strings.ToLower(a.Key)] {
+			return slog.String(a.Key, RedactedValue)
+		}
+		return a
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Redaction(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	logger, err := New(&Config{
+		Level:      "info",
+		Format:     "json",
+		RedactKeys: []string{"card_number"},
+		writer:     output,
+	})
+	require.NoError(t, err)
+
+	logger.With(slog.String("Authorization", "Bearer abc")).Info("request",
+		slog.String("password", "hunter2"),
+		slog.Group("payment", slog.String("card_number", "4111111111111111")),
+		slog.String("user", "alice"),
+	)
+
+	out := output.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "Bearer abc")
+	assert.NotContains(t, out, "4111111111111111")
+	assert.Contains(t, out, RedactedValue)
+	assert.Contains(t, out, `"user":"alice"`)
+}
+
+func TestLogger_RedactionConsole(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	logger, err := New(&Config{
+		Level:  "info",
+		Format: "console",
+		writer: output,
+	})
+	require.NoError(t, err)
+
+	logger.Info("login", slog.String("token", "s3cr3t"))
+
+	assert.NotContains(t, output.String(), "s3cr3t")
+	assert.Contains(t, output.String(), RedactedValue)
+}
@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Defaults of ReportConfig
+const (
+	DefaultReportRateLimit    = 10
+	DefaultReportRateInterval = time.Minute
+)
+
+const (
+	// reportQueueSize is how many events wait to be sent before new ones are dropped
+	reportQueueSize = 100
+	// reportTimeout bounds sending one event
+	reportTimeout = 5 * time.Second
+	// reportFlushTimeout bounds how long Close waits for queued events
+	reportFlushTimeout = 5 * time.Second
+)
+
+// ReportConfig forwards error records to an error tracker such as Sentry. The zero value
+// disables reporting.
+type ReportConfig struct {
+	DSN          string        // Sentry DSN, e.g. https://key@o1.ingest.sentry.io/42
+	Environment  string        // Tags every event, e.g. production
+	Release      string        // Version of the service
+	Service      string        // Tags every event with the reporting service
+	RateLimit    int           // Events per RateInterval, the rest are dropped; 0 uses DefaultReportRateLimit
+	RateInterval time.Duration // 0 uses DefaultReportRateInterval
+	Reporter     Reporter      // Sends events instead of a Sentry reporter for DSN, e.g. for another tracker
+}
+
+// enabled reports whether reporting is configured
+func (c ReportConfig) enabled() bool {
+	return c.DSN != "" || c.Reporter != nil
+}
+
+// Event is an error record forwarded to an error tracker
+type Event struct {
+	Time        time.Time
+	Level       slog.Level
+	Message     string
+	Attrs       map[string]any // Attributes of the record, redacted and keyed by their dotted path
+	Stack       []byte         // Stack of the goroutine that logged the record
+	Environment string
+	Release     string
+	Service     string
+}
+
+// Reporter sends events to an error tracker
+type Reporter interface {
+	Report(ctx context.Context, event *Event) error
+}
+
+// reportQueue rate limits events and sends them in the background, so logging an error
+// never waits on the tracker. It is shared by every derived handler.
+type reportQueue struct {
+	config   ReportConfig
+	reporter Reporter
+	now      func() time.Time
+
+	mu          sync.Mutex
+	closed      bool
+	windowStart time.Time
+	count       int
+
+	events chan *Event
+	done   chan struct{}
+}
+
+func newReportQueue(config ReportConfig) (*reportQueue, error) {
+	if config.RateLimit <= 0 {
+		config.RateLimit = DefaultReportRateLimit
+	}
+	if config.RateInterval <= 0 {
+		config.RateInterval = DefaultReportRateInterval
+	}
+
+	reporter := config.Reporter
+	if reporter == nil {
+		sentry, err := NewSentryReporter(config.DSN)
+		if err != nil {
+			return nil, err
+		}
+		reporter = sentry
+	}
+
+	q := &reportQueue{
+		config:   config,
+		reporter: reporter,
+		now:      time.Now,
+		events:   make(chan *Event, reportQueueSize),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q, nil
+}
+
+// send queues event, dropping it when over the rate limit, the queue is full or the
+// queue is closed
+func (q *reportQueue) send(event *Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	now := q.now()
+	if now.Sub(q.windowStart) >= q.config.RateInterval {
+		q.windowStart = now
+		q.count = 0
+	}
+	if q.count >= q.config.RateLimit {
+		return
+	}
+	q.count++
+
+	event.Environment = q.config.Environment
+	event.Release = q.config.Release
+	event.Service = q.config.Service
+	select {
+	case q.events <- event:
+	default:
+	}
+}
+
+func (q *reportQueue) run() {
+	defer close(q.done)
+	for event := range q.events {
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+		// A failed report cannot be logged without risking another one, so it is lost
+		_ = q.reporter.Report(ctx, event)
+		cancel()
+	}
+}
+
+// close stops accepting events and waits for the queued ones to be sent
+func (q *reportQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.events)
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(reportFlushTimeout):
+	}
+}
+
+// reportHandler forwards records at error level or above to its queue and passes every
+// record on to next
+type reportHandler struct {
+	next   slog.Handler
+	queue  *reportQueue
+	redact func(groups []string, a slog.Attr) slog.Attr
+
+	// attrs were added with WithAttrs, keyed by their dotted path
+	attrs  map[string]any
+	groups []string
+}
+
+func (h *reportHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+func (h *reportHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+		for k, v := range h.attrs {
+			attrs[k] = v
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			h.addAttr(attrs, h.groups, a)
+			return true
+		})
+		h.queue.send(&Event{
+			Time:    r.Time,
+			Level:   r.Level,
+			Message: r.Message,
+			Attrs:   attrs,
+			Stack:   debug.Stack(),
+		})
+	}
+
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *reportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = make(map[string]any, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		clone.attrs[k] = v
+	}
+	for _, a := range attrs {
+		h.addAttr(clone.attrs, h.groups, a)
+	}
+	return &clone
+}
+
+func (h *reportHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &clone
+}
+
+// addAttr adds the redacted a to attrs under its dotted path, flattening groups
+func (h *reportHandler) addAttr(attrs map[string]any, groups []string, a slog.Attr) {
+	a = h.redact(groups, a)
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, member := range value.Group() {
+			h.addAttr(attrs, groups, member)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	key := a.Key
+	for i := len(groups) - 1; i >= 0; i-- {
+		key = groups[i] + "." + key
+	}
+	switch value.Kind() {
+	case slog.KindTime:
+		attrs[key] = value.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		attrs[key] = value.Duration().String()
+	case slog.KindAny:
+		// Errors and other values without a JSON form are reported as their text
+		if err, ok := value.Any().(error); ok {
+			attrs[key] = err.Error()
+		} else {
+			attrs[key] = value.Any()
+		}
+	default:
+		attrs[key] = value.Any()
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingReporter keeps the events it is given
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (r *recordingReporter) Report(_ context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestLogger_Reporting(t *testing.T) {
+	t.Run("forwards error records with attributes and stack", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		reporter := &recordingReporter{}
+		logger, err := New(&Config{
+			Level:  "info",
+			Format: "json",
+			Reporting: ReportConfig{
+				Environment: "production",
+				Release:     "1.2.0",
+				Service:     "api-service",
+				Reporter:    reporter,
+			},
+			writer: output,
+		})
+		require.NoError(t, err)
+
+		child := logger.With("job_id", "job-1").WithGroup("db")
+		child.Info("query slow")
+		child.Error("query failed", "error", errors.New("connection reset"), "password", "hunter2")
+		require.NoError(t, logger.Close())
+
+		require.Len(t, reporter.events, 1)
+		event := reporter.events[0]
+		assert.Equal(t, "query failed", event.Message)
+		assert.Equal(t, "production", event.Environment)
+		assert.Equal(t, "1.2.0", event.Release)
+		assert.Equal(t, "api-service", event.Service)
+		assert.Equal(t, map[string]any{
+			"job_id":      "job-1",
+			"db.error":    "connection reset",
+			"db.password": RedactedValue,
+		}, event.Attrs)
+		assert.Contains(t, string(event.Stack), "report_test.go")
+		assert.Contains(t, output.String(), `"msg":"query failed"`)
+	})
+
+	t.Run("rate limits events", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		logger, err := New(&Config{
+			Format:    "json",
+			Reporting: ReportConfig{RateLimit: 2, RateInterval: time.Hour, Reporter: reporter},
+			writer:    io.Discard,
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			logger.Error("storm")
+		}
+		require.NoError(t, logger.Close())
+		assert.Len(t, reporter.events, 2)
+	})
+
+	t.Run("ignores records below error level", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		logger, err := New(&Config{
+			Level:     "debug",
+			Format:    "json",
+			Reporting: ReportConfig{Reporter: reporter},
+			writer:    io.Discard,
+		})
+		require.NoError(t, err)
+
+		logger.Warn("retrying")
+		logger.Error("gave up")
+		require.NoError(t, logger.Close())
+		require.Len(t, reporter.events, 1)
+		assert.Equal(t, "gave up", reporter.events[0].Message)
+	})
+
+	t.Run("invalid DSN", func(t *testing.T) {
+		_, err := New(&Config{Reporting: ReportConfig{DSN: "https://sentry.example.com/42"}})
+		assert.ErrorContains(t, err, "missing public key")
+	})
+}
+
+func TestParseSentryDSN(t *testing.T) {
+	dsn, err := ParseSentryDSN("https://abc123@sentry.example.com/relay/42")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sentry.example.com/relay/api/42/store/", dsn.Endpoint)
+	assert.Equal(t, "abc123", dsn.PublicKey)
+
+	for _, invalid := range []string{
+		"ftp://abc123@sentry.example.com/42",
+		"https://abc123@sentry.example.com/",
+		"https://abc123@/42",
+	} {
+		_, err := ParseSentryDSN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/42/store/", r.URL.Path)
+		auth = r.Header.Get("X-Sentry-Auth")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	reporter, err := NewSentryReporter("http://abc123@" + srv.Listener.Addr().String() + "/42")
+	require.NoError(t, err)
+
+	err = reporter.Report(context.Background(), &Event{
+		Time:        time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC),
+		Message:     "query failed",
+		Attrs:       map[string]any{"job_id": "job-1"},
+		Stack:       []byte("goroutine 1 [running]:"),
+		Environment: "production",
+		Release:     "1.2.0",
+		Service:     "api-service",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, auth, "sentry_key=abc123")
+	assert.Len(t, got["event_id"], 32)
+	assert.Equal(t, "2026-01-15T10:30:00Z", got["timestamp"])
+	assert.Equal(t, "error", got["level"])
+	assert.Equal(t, "query failed", got["message"])
+	assert.Equal(t, "production", got["environment"])
+	assert.Equal(t, "1.2.0", got["release"])
+	assert.Equal(t, map[string]any{"service": "api-service"}, got["tags"])
+	assert.Equal(t, map[string]any{"job_id": "job-1", "stack": "goroutine 1 [running]:"}, got["extra"])
+
+	t.Run("rejected event", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		reporter, err := NewSentryReporter("http://abc123@" + srv.Listener.Addr().String() + "/42")
+		require.NoError(t, err)
+		err = reporter.Report(context.Background(), &Event{Time: time.Now(), Message: "boom"})
+		assert.ErrorContains(t, err, "status 429")
+	})
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is appended to rotated file names; it sorts lexically in time order
+const backupTimeFormat = "20060102T150405.000"
+
+// RotationConfig controls log file rotation. The zero value never rotates.
+type RotationConfig struct {
+	MaxSizeMB  int           // Rotate once the file would exceed this size, 0 disables rotation
+	MaxBackups int           // Rotated files to keep, 0 keeps all
+	MaxAge     time.Duration // Delete rotated files older than this, 0 keeps all
+	Compress   bool          // Gzip rotated files
+}
+
+// rotatingFile is an io.WriteCloser that appends to a file and rotates it by size
+type rotatingFile struct {
+	path   string
+	config RotationConfig
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// cleanupMu serializes compression and pruning of rotated files
+	cleanupMu sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// openRotatingFile opens path for appending, creating parent directories as needed
+func openRotatingFile(path string, config RotationConfig) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, config: config}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating first if p would push it past MaxSizeMB
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	maxSize := int64(r.config.MaxSizeMB) * 1024 * 1024
+	if maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file to a timestamped backup and opens a fresh one.
+// Compression and pruning of backups happen in the background.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	backup := r.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil {
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.cleanup(backup)
+	}()
+
+	return nil
+}
+
+// cleanup compresses the new backup and removes backups beyond MaxBackups or MaxAge.
+// Failures are reported on stderr since the logger itself is the thing being maintained.
+func (r *rotatingFile) cleanup(backup string) {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	if r.config.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: failed to compress %s: %v\n", backup, err)
+		}
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: failed to list log backups: %v\n", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-r.config.MaxAge)
+	for i, path := range backups {
+		expired := r.config.MaxAge > 0 && backupTime(r.path, path).Before(cutoff)
+		excess := r.config.MaxBackups > 0 && i >= r.config.MaxBackups
+		if expired || excess {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "logger: failed to remove %s: %v\n", path, err)
+			}
+		}
+	}
+}
+
+// backups returns rotated files for this log, newest first
+func (r *rotatingFile) backups() ([]string, error) {
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, path := range matches {
+		if !backupTime(r.path, path).IsZero() {
+			backups = append(backups, path)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// backupTime parses the rotation time from a backup file name, or returns the zero time
+// if path is not a backup of base
+func backupTime(base, path string) time.Time {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(path, base+"."), ".gz")
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// Close flushes the log file to disk, closes it and waits for pending compression
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	syncErr := r.file.Sync()
+	closeErr := r.file.Close()
+	r.file = nil
+	r.wg.Wait()
+
+	if syncErr != nil {
+		return fmt.Errorf("failed to sync log file: %w", syncErr)
+	}
+	return closeErr
+}
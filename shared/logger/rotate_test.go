@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunk is just over half of a 1 MB rotation limit, so every second write rotates
+var chunk = bytes.Repeat([]byte("x"), 600*1024)
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+
+	r, err := openRotatingFile(path, RotationConfig{MaxSizeMB: 1})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Write(chunk)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond) // distinct backup timestamps
+	}
+	require.NoError(t, r.Close())
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
+
+func TestRotatingFile_PrunesAndCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+
+	r, err := openRotatingFile(path, RotationConfig{MaxSizeMB: 1, MaxBackups: 1, Compress: true})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := r.Write(chunk)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+	require.NoError(t, r.Close())
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, ".gz", filepath.Ext(backups[0]))
+
+	f, err := os.Open(backups[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, chunk, data)
+}
+
+func TestRotatingFile_PrunesByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.log")
+
+	old := path + "." + time.Now().Add(-48*time.Hour).UTC().Format(backupTimeFormat)
+	require.NoError(t, os.WriteFile(old, []byte("old"), 0o644))
+	unrelated := filepath.Join(dir, "api.log.notes")
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep"), 0o644))
+
+	r, err := openRotatingFile(path, RotationConfig{MaxSizeMB: 1, MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Write(chunk)
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(unrelated)
+	assert.NoError(t, err)
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestRotatingFile_WriteAfterClose(t *testing.T) {
+	r, err := openRotatingFile(filepath.Join(t.TempDir(), "api.log"), RotationConfig{})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, err = r.Write([]byte("late"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingConfig limits repeated debug messages. Within each Interval the first Initial
+// records with the same message are logged, then every Thereafter-th one. Records above
+// debug level are never sampled. The zero value disables sampling.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int // 0 drops everything after Initial
+	Interval   time.Duration
+}
+
+// enabled reports whether sampling is configured
+func (c SamplingConfig) enabled() bool {
+	return c.Initial > 0 && c.Interval > 0
+}
+
+// sampler counts debug messages per interval; it is shared by every derived handler
+type sampler struct {
+	config SamplingConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newSampler(config SamplingConfig) *sampler {
+	return &sampler{
+		config: config,
+		now:    time.Now,
+		counts: make(map[string]int),
+	}
+}
+
+// allow reports whether a record with msg should be logged
+func (s *sampler) allow(msg string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.windowStart) >= s.config.Interval {
+		s.windowStart = now
+		clear(s.counts)
+	}
+
+	s.counts[msg]++
+	n := s.counts[msg]
+
+	if n <= s.config.Initial {
+		return true
+	}
+	return s.config.Thereafter > 0 && (n-s.config.Initial)%s.config.Thereafter == 0
+}
+
+// samplingHandler drops debug records rejected by its sampler
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level <= slog.LevelDebug && !h.sampler.allow(r.Message) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
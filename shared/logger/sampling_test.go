@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	s := newSampler(SamplingConfig{Initial: 2, Thereafter: 3, Interval: time.Second})
+	s.now = func() time.Time { return now }
+
+	var allowed []int
+	for i := 1; i <= 8; i++ {
+		if s.allow("poll") {
+			allowed = append(allowed, i)
+		}
+	}
+	// First 2, then every 3rd after that
+	assert.Equal(t, []int{1, 2, 5, 8}, allowed)
+
+	// Other messages are counted separately
+	assert.True(t, s.allow("other"))
+
+	// A new interval starts over
+	now = now.Add(time.Second)
+	assert.True(t, s.allow("poll"))
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	logger, err := New(&Config{
+		Level:    "debug",
+		Format:   "json",
+		Sampling: SamplingConfig{Initial: 1, Interval: time.Hour},
+		writer:   output,
+	})
+	require.NoError(t, err)
+
+	child := logger.With("component", "poller")
+	for i := 0; i < 5; i++ {
+		child.Debug("polling")
+		logger.Warn("slow poll")
+	}
+
+	assert.Equal(t, 1, strings.Count(output.String(), `"msg":"polling"`))
+	assert.Equal(t, 5, strings.Count(output.String(), `"msg":"slow poll"`))
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sentryClient identifies this reporter to Sentry
+const sentryClient = "practice-be/1.0"
+
+// sentryReporter sends events to Sentry's store endpoint
+type sentryReporter struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+	hostname string
+}
+
+// SentryDSN is a parsed Sentry DSN
+type SentryDSN struct {
+	Endpoint  string // URL of the project's store endpoint
+	PublicKey string
+}
+
+// ParseSentryDSN parses a DSN of the form https://<key>@<host>[/<path>]/<project>
+func ParseSentryDSN(dsn string) (*SentryDSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Sentry DSN: scheme must be http or https")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing host")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if i < 0 || project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	return &SentryDSN{
+		Endpoint:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:i], project),
+		PublicKey: u.User.Username(),
+	}, nil
+}
+
+// NewSentryReporter returns a Reporter sending events to the Sentry project of dsn
+func NewSentryReporter(dsn string) (Reporter, error) {
+	parsed, err := ParseSentryDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &sentryReporter{
+		endpoint: parsed.Endpoint,
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s",
+			sentryClient, parsed.PublicKey),
+		client:   &http.Client{},
+		hostname: hostname,
+	}, nil
+}
+
+// sentryEvent is the subset of Sentry's event payload the reporter fills in
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Message     string            `json:"message"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+func (r *sentryReporter) Report(ctx context.Context, event *Event) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate event ID: %w", err)
+	}
+
+	level := "error"
+	if event.Level > slog.LevelError {
+		level = "fatal"
+	}
+	payload := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Message:     event.Message,
+		ServerName:  r.hostname,
+		Environment: event.Environment,
+		Release:     event.Release,
+		Extra:       make(map[string]any, len(event.Attrs)+1),
+	}
+	if event.Service != "" {
+		payload.Tags = map[string]string{"service": event.Service}
+	}
+	for k, v := range event.Attrs {
+		payload.Extra[k] = v
+	}
+	if len(event.Stack) > 0 {
+		payload.Extra["stack"] = string(event.Stack)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		// Attribute values that cannot be encoded are sent as their string form
+		for k, v := range payload.Extra {
+			payload.Extra[k] = fmt.Sprint(v)
+		}
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry rejected event with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package messaging defines the job message the API service publishes and workers
+// consume, and the encodings it can be sent in. The encoding is carried in the message's
+// content type, so a consumer decodes each message by the content type it arrived with
+// and publishers can switch encodings without a coordinated release.
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+)
+
+// Encodings publishers can be configured with
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// Content types of the encodings
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// JobMessageType is the fully qualified protobuf message name, sent as the proto
+// parameter of ContentTypeProtobuf
+const JobMessageType = "jobs.v1.JobMessage"
+
+// ErrUnsupportedContentType is returned for messages in an encoding this version
+// cannot decode
+var ErrUnsupportedContentType = errors.New("unsupported message content type")
+
+// JobMessage is the message body published to the broker for a job
+type JobMessage struct {
+	JobID       string          `json:"job_id"`
+	UserID      string          `json:"user_id"`
+	JobType     string          `json:"job_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	OrderingKey *string         `json:"ordering_key,omitempty"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	// ExecuteAfter is the earliest time a worker may run the job
+	ExecuteAfter *time.Time `json:"execute_after,omitempty"`
+	// Context is set for workflow steps
+	Context *WorkflowContext `json:"context,omitempty"`
+}
+
+// WorkflowContext passes the results of earlier workflow steps to the next ones
+type WorkflowContext struct {
+	WorkflowID string `json:"workflow_id"`
+	Step       string `json:"step"`
+	// Results holds the inline result of every completed step, keyed by step name
+	Results map[string]json.RawMessage `json:"results"`
+}
+
+// Codec encodes job messages in one encoding
+type Codec interface {
+	// ContentType is sent with every message the codec encodes
+	ContentType() string
+	Marshal(msg *JobMessage) ([]byte, error)
+	Unmarshal(body []byte, msg *JobMessage) error
+}
+
+// Lookup returns the codec of a configured encoding. Empty means JSON.
+func Lookup(encoding string) (Codec, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		return JSON, nil
+	case EncodingProtobuf:
+		return Protobuf, nil
+	default:
+		return nil, fmt.Errorf("unknown message encoding: %s (must be %s or %s)", encoding, EncodingJSON, EncodingProtobuf)
+	}
+}
+
+// Negotiate returns the codec that decodes messages of contentType. Messages without a
+// content type are JSON, as published before encodings could be configured. Protobuf
+// messages naming another message type in their proto parameter are refused rather
+// than decoded into the wrong fields.
+func Negotiate(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSON, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		return JSON, nil
+	case ContentTypeProtobuf:
+		if name, ok := params["proto"]; ok && name != JobMessageType {
+			return nil, fmt.Errorf("%w: %s (want proto=%s)", ErrUnsupportedContentType, contentType, JobMessageType)
+		}
+		return Protobuf, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// Decode decodes a job message by the content type it was published with
+func Decode(contentType string, body []byte) (*JobMessage, error) {
+	codec, err := Negotiate(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg JobMessage
+	if err := codec.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// JSON encodes job messages as JSON objects
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return ContentTypeJSON }
+
+func (jsonCodec) Marshal(msg *JobMessage) ([]byte, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job message: %w", err)
+	}
+	return body, nil
+}
+
+func (jsonCodec) Unmarshal(body []byte, msg *JobMessage) error {
+	if err := json.Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("failed to unmarshal job message: %w", err)
+	}
+	return nil
+}
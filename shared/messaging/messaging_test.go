@@ -0,0 +1,120 @@
+package messaging
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testMessage() *JobMessage {
+	key := "user-1:emails"
+	executeAfter := time.Date(2026, 10, 16, 9, 30, 0, 123456789, time.UTC)
+	return &JobMessage{
+		JobID:        "job-1",
+		UserID:       "user-1",
+		JobType:      "send_email",
+		Payload:      json.RawMessage(`{"to":"user@example.com"}`),
+		Metadata:     json.RawMessage(`{"source":"signup"}`),
+		OrderingKey:  &key,
+		TenantID:     "acme",
+		ExecuteAfter: &executeAfter,
+		Context: &WorkflowContext{
+			WorkflowID: "wf-1",
+			Step:       "notify",
+			Results:    map[string]json.RawMessage{"render": json.RawMessage(`{"subject":"Welcome"}`)},
+		},
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSON, Protobuf} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			body, err := codec.Marshal(testMessage())
+			require.NoError(t, err)
+
+			msg, err := Decode(codec.ContentType(), body)
+			require.NoError(t, err)
+			assert.Equal(t, testMessage(), msg)
+
+			// Unset optional fields stay unset
+			body, err = codec.Marshal(&JobMessage{JobID: "job-2", JobType: "send_email", Payload: json.RawMessage(`{}`)})
+			require.NoError(t, err)
+			msg, err = Decode(codec.ContentType(), body)
+			require.NoError(t, err)
+			assert.Equal(t, &JobMessage{JobID: "job-2", JobType: "send_email", Payload: json.RawMessage(`{}`)}, msg)
+		})
+	}
+}
+
+func TestProtobuf_EmptyOrderingKey(t *testing.T) {
+	key := ""
+	body, err := Protobuf.Marshal(&JobMessage{JobID: "job-1", OrderingKey: &key})
+	require.NoError(t, err)
+
+	msg, err := Decode(Protobuf.ContentType(), body)
+	require.NoError(t, err)
+	require.NotNil(t, msg.OrderingKey)
+	assert.Empty(t, *msg.OrderingKey)
+}
+
+func TestProtobuf_SchemaCompatibility(t *testing.T) {
+	body, err := Protobuf.Marshal(&JobMessage{JobID: "job-1", JobType: "send_email"})
+	require.NoError(t, err)
+
+	// Fields added by a newer version are skipped
+	newer := protowire.AppendTag(body, 42, protowire.VarintType)
+	newer = protowire.AppendVarint(newer, 7)
+	newer = protowire.AppendTag(newer, 43, protowire.BytesType)
+	newer = protowire.AppendString(newer, "priority")
+	msg, err := Decode(Protobuf.ContentType(), newer)
+	require.NoError(t, err)
+	assert.Equal(t, &JobMessage{JobID: "job-1", JobType: "send_email"}, msg)
+
+	// A known field sent with another type means the versions disagree on the schema
+	changed := protowire.AppendTag(body, fieldUserID, protowire.VarintType)
+	changed = protowire.AppendVarint(changed, 42)
+	_, err = Decode(Protobuf.ContentType(), changed)
+	assert.EqualError(t, err, "failed to unmarshal job message: field 2: unexpected wire type 0")
+
+	_, err = Decode(Protobuf.ContentType(), []byte{0x0a, 0x05, 'j'})
+	assert.ErrorContains(t, err, "failed to unmarshal job message")
+}
+
+func TestLookup(t *testing.T) {
+	for encoding, want := range map[string]Codec{"": JSON, "json": JSON, "protobuf": Protobuf} {
+		codec, err := Lookup(encoding)
+		require.NoError(t, err)
+		assert.Equal(t, want, codec, encoding)
+	}
+
+	_, err := Lookup("msgpack")
+	assert.EqualError(t, err, "unknown message encoding: msgpack (must be json or protobuf)")
+}
+
+func TestNegotiate(t *testing.T) {
+	for contentType, want := range map[string]Codec{
+		"":                                JSON,
+		"application/json":                JSON,
+		"application/json; charset=utf-8": JSON,
+		"application/x-protobuf":          Protobuf,
+		Protobuf.ContentType():            Protobuf,
+		"Application/X-Protobuf; proto=jobs.v1.JobMessage": Protobuf,
+	} {
+		codec, err := Negotiate(contentType)
+		require.NoError(t, err, contentType)
+		assert.Equal(t, want, codec, contentType)
+	}
+
+	for _, contentType := range []string{
+		"application/msgpack",
+		"application/x-protobuf; proto=jobs.v2.JobMessage",
+		"not a media type;",
+	} {
+		_, err := Negotiate(contentType)
+		assert.ErrorIs(t, err, ErrUnsupportedContentType, contentType)
+	}
+}
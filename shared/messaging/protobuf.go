@@ -0,0 +1,266 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf encodes job messages as jobs.v1.JobMessage, see job_message.proto. Fields
+// this version does not know are skipped, while a known field with another wire type
+// fails decoding, since the two versions disagree about the schema.
+var Protobuf Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf + "; proto=" + JobMessageType
+}
+
+// Field numbers of jobs.v1.JobMessage
+const (
+	fieldJobID        = 1
+	fieldUserID       = 2
+	fieldJobType      = 3
+	fieldPayload      = 4
+	fieldMetadata     = 5
+	fieldOrderingKey  = 6
+	fieldTenantID     = 7
+	fieldExecuteAfter = 8
+	fieldContext      = 9
+)
+
+// Field numbers of jobs.v1.WorkflowContext, its results map entries and
+// google.protobuf.Timestamp
+const (
+	fieldWorkflowID = 1
+	fieldStep       = 2
+	fieldResults    = 3
+
+	fieldMapKey   = 1
+	fieldMapValue = 2
+
+	fieldSeconds = 1
+	fieldNanos   = 2
+)
+
+func (protobufCodec) Marshal(msg *JobMessage) ([]byte, error) {
+	var b []byte
+	b = appendString(b, fieldJobID, msg.JobID)
+	b = appendString(b, fieldUserID, msg.UserID)
+	b = appendString(b, fieldJobType, msg.JobType)
+	b = appendBytes(b, fieldPayload, msg.Payload)
+	b = appendBytes(b, fieldMetadata, msg.Metadata)
+	if msg.OrderingKey != nil {
+		// Optional fields are sent even when empty, so an empty key is still a key
+		b = protowire.AppendTag(b, fieldOrderingKey, protowire.BytesType)
+		b = protowire.AppendString(b, *msg.OrderingKey)
+	}
+	b = appendString(b, fieldTenantID, msg.TenantID)
+	if msg.ExecuteAfter != nil {
+		var ts []byte
+		if s := msg.ExecuteAfter.Unix(); s != 0 {
+			ts = protowire.AppendTag(ts, fieldSeconds, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(s))
+		}
+		if n := msg.ExecuteAfter.Nanosecond(); n != 0 {
+			ts = protowire.AppendTag(ts, fieldNanos, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(n))
+		}
+		b = protowire.AppendTag(b, fieldExecuteAfter, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	if msg.Context != nil {
+		var wc []byte
+		wc = appendString(wc, fieldWorkflowID, msg.Context.WorkflowID)
+		wc = appendString(wc, fieldStep, msg.Context.Step)
+		for step, result := range msg.Context.Results {
+			var entry []byte
+			entry = appendString(entry, fieldMapKey, step)
+			entry = appendBytes(entry, fieldMapValue, result)
+			wc = protowire.AppendTag(wc, fieldResults, protowire.BytesType)
+			wc = protowire.AppendBytes(wc, entry)
+		}
+		b = protowire.AppendTag(b, fieldContext, protowire.BytesType)
+		b = protowire.AppendBytes(b, wc)
+	}
+	return b, nil
+}
+
+func (protobufCodec) Unmarshal(body []byte, msg *JobMessage) error {
+	*msg = JobMessage{}
+	err := decodeFields(body, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch num {
+		case fieldJobID:
+			return bytesField(num, typ, value, func(v []byte) { msg.JobID = string(v) })
+		case fieldUserID:
+			return bytesField(num, typ, value, func(v []byte) { msg.UserID = string(v) })
+		case fieldJobType:
+			return bytesField(num, typ, value, func(v []byte) { msg.JobType = string(v) })
+		case fieldPayload:
+			return bytesField(num, typ, value, func(v []byte) { msg.Payload = json.RawMessage(v) })
+		case fieldMetadata:
+			return bytesField(num, typ, value, func(v []byte) { msg.Metadata = json.RawMessage(v) })
+		case fieldOrderingKey:
+			return bytesField(num, typ, value, func(v []byte) {
+				key := string(v)
+				msg.OrderingKey = &key
+			})
+		case fieldTenantID:
+			return bytesField(num, typ, value, func(v []byte) { msg.TenantID = string(v) })
+		case fieldExecuteAfter:
+			if typ != protowire.BytesType {
+				return wireTypeError(num, typ)
+			}
+			t, err := decodeTimestamp(value)
+			if err != nil {
+				return err
+			}
+			msg.ExecuteAfter = &t
+			return nil
+		case fieldContext:
+			if typ != protowire.BytesType {
+				return wireTypeError(num, typ)
+			}
+			wc, err := decodeWorkflowContext(value)
+			if err != nil {
+				return err
+			}
+			msg.Context = wc
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal job message: %w", err)
+	}
+	return nil
+}
+
+// decodeWorkflowContext decodes a jobs.v1.WorkflowContext
+func decodeWorkflowContext(b []byte) (*WorkflowContext, error) {
+	wc := &WorkflowContext{Results: map[string]json.RawMessage{}}
+	err := decodeFields(b, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		switch num {
+		case fieldWorkflowID:
+			return bytesField(num, typ, value, func(v []byte) { wc.WorkflowID = string(v) })
+		case fieldStep:
+			return bytesField(num, typ, value, func(v []byte) { wc.Step = string(v) })
+		case fieldResults:
+			if typ != protowire.BytesType {
+				return wireTypeError(num, typ)
+			}
+			var step string
+			var result json.RawMessage
+			err := decodeFields(value, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+				switch num {
+				case fieldMapKey:
+					return bytesField(num, typ, value, func(v []byte) { step = string(v) })
+				case fieldMapValue:
+					return bytesField(num, typ, value, func(v []byte) { result = json.RawMessage(v) })
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("context results: %w", err)
+			}
+			wc.Results[step] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("context: %w", err)
+	}
+	return wc, nil
+}
+
+// decodeTimestamp decodes a google.protobuf.Timestamp
+func decodeTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := decodeFields(b, func(num protowire.Number, typ protowire.Type, _ []byte, varint uint64) error {
+		if num != fieldSeconds && num != fieldNanos {
+			return nil
+		}
+		if typ != protowire.VarintType {
+			return wireTypeError(num, typ)
+		}
+		if num == fieldSeconds {
+			seconds = int64(varint)
+		} else {
+			nanos = int64(int32(varint))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("execute_after: %w", err)
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// decodeFields calls field for every field of the message in b. Length-delimited
+// fields pass their contents as value and varint fields their number as varint; other
+// wire types are skipped.
+func decodeFields(b []byte, field func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var (
+			value  []byte
+			varint uint64
+		)
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := field(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bytesField sets a string or bytes field, which must be length-delimited
+func bytesField(num protowire.Number, typ protowire.Type, value []byte, set func([]byte)) error {
+	if typ != protowire.BytesType {
+		return wireTypeError(num, typ)
+	}
+	set(append([]byte(nil), value...))
+	return nil
+}
+
+func wireTypeError(num protowire.Number, typ protowire.Type) error {
+	return fmt.Errorf("field %d: unexpected wire type %d", num, typ)
+}
+
+// appendString appends a string field, omitting the proto3 default ""
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendBytes appends a bytes field, omitting the proto3 default of no bytes
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
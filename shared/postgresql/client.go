@@ -2,37 +2,69 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
 )
 
 // Config holds PostgreSQL connection configuration
 type Config struct {
-	Host            string
-	Port            int
-	User            string
-	Password        string
-	Database        string
-	SSLMode         string
-	MaxOpenConns    int
-	MaxIdleConns    int
+	Host         string
+	Port         int
+	User         string
+	Password     string
+	Database     string
+	SSLMode      string // disable, require, verify-ca or verify-full
+	SSLRootCert  string // CA bundle the server certificate is verified against
+	SSLCert      string // Client certificate, for servers that require one; needs SSLKey
+	SSLKey       string // Client key, readable only by its owner
+	MaxOpenConns int
+	// MinIdleConns is how many idle connections the pool keeps open ahead of demand, up
+	// to MaxOpenConns. Idle connections beyond it are closed after ConnMaxIdleTime.
+	MinIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	// StatementCacheCapacity is how many prepared statements each connection caches, so
+	// repeated queries skip parsing and planning. 0 disables the cache, e.g. behind
+	// PgBouncer in transaction mode.
+	StatementCacheCapacity int
+	// SlowQueryThreshold logs queries that take at least this long, 0 disables the log
+	SlowQueryThreshold time.Duration
+	// QueryTimeout bounds ExecContext, GetContext and SelectContext when ctx has no
+	// earlier deadline, 0 disables the timeout
+	QueryTimeout time.Duration
+	// InjectedDelay holds back the fraction InjectedDelayRate of queries by between half and
+	// all of this, to soak test behaviour under a slow database. Never set it in production.
+	InjectedDelay     time.Duration
+	InjectedDelayRate float64
+	// ConnectTimeout is how long NewClient waits for the database to accept connections,
+	// retrying after ConnectRetryInterval, doubled after each failure up to
+	// ConnectMaxRetryInterval. 0 tries once.
+	ConnectTimeout          time.Duration
+	ConnectRetryInterval    time.Duration
+	ConnectMaxRetryInterval time.Duration
 }
 
+// pingTimeout bounds each connection attempt of NewClient
+const pingTimeout = 5 * time.Second
+
 // Client represents a PostgreSQL database client
 type Client struct {
-	db     *sqlx.DB
-	config *Config
-	logger *slog.Logger
+	db        *sqlx.DB
+	connector *connector
+	config    *Config
+	logger    *slog.Logger
+	metrics   queryMetrics
 }
 
-// NewClient creates a new PostgreSQL client
-func NewClient(config *Config, logger *slog.Logger) (*Client, error) {
+// buildDSN returns the libpq-style connection string for config. With sslmode verify-full the
+// server certificate must be issued for Host.
+func buildDSN(config *Config) string {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host,
@@ -43,53 +75,104 @@ func NewClient(config *Config, logger *slog.Logger) (*Client, error) {
 		config.SSLMode,
 	)
 
+	for _, param := range []struct{ key, value string }{
+		{"sslrootcert", config.SSLRootCert},
+		{"sslcert", config.SSLCert},
+		{"sslkey", config.SSLKey},
+	} {
+		if param.value != "" {
+			dsn += " " + param.key + "=" + quoteDSNValue(param.value)
+		}
+	}
+	return dsn
+}
+
+// quoteDSNValue quotes v so paths with spaces or quotes survive the key=value format
+func quoteDSNValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// NewClient creates a new PostgreSQL client and verifies it can connect
+func NewClient(config *Config, logger *slog.Logger) (*Client, error) {
 	logger.Info("Connecting to PostgreSQL",
 		slog.String("host", config.Host),
 		slog.Int("port", config.Port),
 		slog.String("database", config.Database),
 	)
 
-	db, err := sqlx.Connect("postgres", dsn)
-	if err != nil {
-		logger.Error("Failed to connect to PostgreSQL",
-			slog.Any("error", err),
-		)
-		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
-	}
-
-	// Set connection pool settings
-	db.SetMaxOpenConns(config.MaxOpenConns)
-	db.SetMaxIdleConns(config.MaxIdleConns)
-	db.SetConnMaxLifetime(config.ConnMaxLifetime)
-	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
-
-	// Verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	client := Open(config, logger)
 
-	if err := db.PingContext(ctx); err != nil {
+	// Verify connection, waiting for a database that is still starting
+	if err := client.waitReady(); err != nil {
 		logger.Error("Failed to ping PostgreSQL",
 			slog.Any("error", err),
 		)
-		db.Close()
+		client.db.Close()
+		client.connector.close()
 		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
 
-	client := &Client{
-		db:     db,
-		config: config,
-		logger: logger,
-	}
-
 	logger.Info("Successfully connected to PostgreSQL",
 		slog.Int("max_open_conns", config.MaxOpenConns),
-		slog.Int("max_idle_conns", config.MaxIdleConns),
+		slog.Int("min_idle_conns", config.MinIdleConns),
 		slog.Duration("conn_max_lifetime", config.ConnMaxLifetime),
+		slog.Int("statement_cache_capacity", config.StatementCacheCapacity),
 	)
 
 	return client, nil
 }
 
+// Open creates a PostgreSQL client without connecting. Connections are opened when
+// queries need them, so queries fail until the database is reachable; use Ping to find
+// out when it is.
+func Open(config *Config, logger *slog.Logger) *Client {
+	// Connections come from a pgxpool.Pool, which owns the pool settings, caches prepared
+	// statements per connection and lets UpdatePassword rotate credentials
+	connector := newConnector(config)
+	db := sqlx.NewDb(sql.OpenDB(connector), "pgx")
+
+	// Idle connections stay in the pgx pool, database/sql hands them back after each use
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(0)
+
+	return &Client{
+		db:        db,
+		connector: connector,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// waitReady pings the database until it answers or Config.ConnectTimeout has passed
+func (c *Client) waitReady() error {
+	deadline := time.Now().Add(c.config.ConnectTimeout)
+	delay := c.config.ConnectRetryInterval
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := c.db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if delay <= 0 || time.Now().Add(delay).After(deadline) {
+			if attempt > 1 {
+				return fmt.Errorf("after %d attempts in %s: %w", attempt, c.config.ConnectTimeout, err)
+			}
+			return err
+		}
+
+		c.logger.Warn("PostgreSQL is not accepting connections yet, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.Any("error", err),
+		)
+		time.Sleep(delay)
+		delay = min(delay*2, max(c.config.ConnectMaxRetryInterval, c.config.ConnectRetryInterval))
+	}
+}
+
 // GetDB returns the underlying sqlx.DB instance
 func (c *Client) GetDB() *sqlx.DB {
 	return c.db
@@ -107,6 +190,7 @@ func (c *Client) Close() error {
 			return err
 		}
 	}
+	c.connector.close()
 
 	c.logger.Info("PostgreSQL connection closed successfully")
 	return nil
@@ -129,45 +213,93 @@ func (c *Client) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
 	return tx, nil
 }
 
-// ExecContext executes a query without returning any rows
+// ExecContext executes a query without returning any rows.
+// Name the query with WithQueryName to tell it apart in logs and QueryStats.
 func (c *Client) ExecContext(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	c.injectDelay(ctx)
 	_, err := c.db.ExecContext(ctx, query, args...)
+	c.observe(ctx, query, start, err)
 	if err != nil {
 		c.logger.Error("Failed to execute query",
 			slog.Any("error", err),
+			slog.String("query_name", queryName(ctx)),
+			slog.String("sql_state", SQLState(err)),
 			slog.String("query", query),
 		)
-		return fmt.Errorf("failed to execute query: %w", err)
+		return fmt.Errorf("failed to execute query: %w", TranslateError(err))
 	}
 	return nil
 }
 
 // GetContext executes a query and scans a single row into dest
 func (c *Client) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	c.injectDelay(ctx)
 	err := c.db.GetContext(ctx, dest, query, args...)
+	c.observe(ctx, query, start, err)
 	if err != nil {
 		c.logger.Error("Failed to get row",
 			slog.Any("error", err),
+			slog.String("query_name", queryName(ctx)),
+			slog.String("sql_state", SQLState(err)),
 			slog.String("query", query),
 		)
-		return fmt.Errorf("failed to get row: %w", err)
+		return fmt.Errorf("failed to get row: %w", TranslateError(err))
 	}
 	return nil
 }
 
 // SelectContext executes a query and scans multiple rows into dest
 func (c *Client) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := c.withQueryTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	c.injectDelay(ctx)
 	err := c.db.SelectContext(ctx, dest, query, args...)
+	c.observe(ctx, query, start, err)
 	if err != nil {
 		c.logger.Error("Failed to select rows",
 			slog.Any("error", err),
+			slog.String("query_name", queryName(ctx)),
+			slog.String("sql_state", SQLState(err)),
 			slog.String("query", query),
 		)
-		return fmt.Errorf("failed to select rows: %w", err)
+		return fmt.Errorf("failed to select rows: %w", TranslateError(err))
 	}
 	return nil
 }
 
+// withQueryTimeout applies QueryTimeout to ctx. A shorter deadline already on ctx wins.
+func (c *Client) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.QueryTimeout)
+}
+
+// injectDelay sleeps for half to all of InjectedDelay on the configured fraction of
+// queries. The delay counts against the query timeout, like a slow server would.
+func (c *Client) injectDelay(ctx context.Context) {
+	if c.config.InjectedDelay <= 0 || rand.Float64() >= c.config.InjectedDelayRate {
+		return
+	}
+
+	timer := time.NewTimer(c.config.InjectedDelay/2 + rand.N(c.config.InjectedDelay/2+1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
 // NamedExecContext executes a named query without returning any rows
 func (c *Client) NamedExecContext(ctx context.Context, query string, arg interface{}) error {
 	_, err := c.db.NamedExecContext(ctx, query, arg)
@@ -194,17 +326,21 @@ func (c *Client) NamedQueryContext(ctx context.Context, query string, arg interf
 	return rows, nil
 }
 
-// Stats returns database statistics
+// Stats returns connection pool statistics
 func (c *Client) Stats() string {
-	stats := c.db.Stats()
+	if c.connector.pool == nil {
+		return fmt.Sprintf("unavailable: %s", c.connector.err)
+	}
+
+	stats := c.connector.pool.Stat()
 	return fmt.Sprintf(
-		"MaxOpenConns: %d, OpenConns: %d, InUse: %d, Idle: %d, WaitCount: %d, WaitDuration: %s",
-		stats.MaxOpenConnections,
-		stats.OpenConnections,
-		stats.InUse,
-		stats.Idle,
-		stats.WaitCount,
-		stats.WaitDuration,
+		"MaxConns: %d, TotalConns: %d, InUse: %d, Idle: %d, WaitCount: %d, WaitDuration: %s",
+		stats.MaxConns(),
+		stats.TotalConns(),
+		stats.AcquiredConns(),
+		stats.IdleConns(),
+		stats.EmptyAcquireCount(),
+		stats.EmptyAcquireWaitTime(),
 	)
 }
 
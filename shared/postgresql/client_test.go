@@ -0,0 +1,93 @@
+package postgresql
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDSN(t *testing.T) {
+	config := &Config{
+		Host:     "db.internal",
+		Port:     5432,
+		User:     "postgres",
+		Password: "secret",
+		Database: "jobs_db",
+		SSLMode:  "disable",
+	}
+	assert.Equal(t, "host=db.internal port=5432 user=postgres password=secret dbname=jobs_db sslmode=disable", buildDSN(config))
+
+	config.SSLMode = "verify-full"
+	config.SSLRootCert = "/etc/ssl/db/ca.pem"
+	config.SSLCert = "/etc/ssl/db/client.pem"
+	config.SSLKey = `/etc/ssl/db keys/it's.key`
+	dsn := buildDSN(config)
+
+	assert.Contains(t, dsn, "sslmode=verify-full sslrootcert='/etc/ssl/db/ca.pem' sslcert='/etc/ssl/db/client.pem'")
+	assert.Contains(t, dsn, `sslkey='/etc/ssl/db keys/it\'s.key'`)
+
+	// pgx reads the CA file while parsing, so a missing one shows the path it unquoted
+	config.SSLRootCert = `/etc/ssl/db certs/it's.pem`
+	_, err := pgconn.ParseConfig(buildDSN(config))
+	assert.ErrorContains(t, err, `open /etc/ssl/db certs/it's.pem`)
+}
+
+func TestPoolConfig(t *testing.T) {
+	config := &Config{
+		Host:                   "localhost",
+		Port:                   5432,
+		SSLMode:                "disable",
+		MaxOpenConns:           25,
+		MinIdleConns:           5,
+		ConnMaxLifetime:        5 * time.Minute,
+		ConnMaxIdleTime:        10 * time.Minute,
+		StatementCacheCapacity: 256,
+	}
+
+	cfg, err := poolConfig(config)
+	require.NoError(t, err)
+	assert.EqualValues(t, 25, cfg.MaxConns)
+	assert.EqualValues(t, 5, cfg.MinIdleConns)
+	assert.Equal(t, 5*time.Minute, cfg.MaxConnLifetime)
+	assert.Equal(t, 10*time.Minute, cfg.MaxConnIdleTime)
+	assert.Equal(t, 256, cfg.ConnConfig.StatementCacheCapacity)
+	assert.Equal(t, pgx.QueryExecModeCacheStatement, cfg.ConnConfig.DefaultQueryExecMode)
+
+	// Without a cache nothing is prepared by name, which transaction pooling needs
+	config.StatementCacheCapacity = 0
+	cfg, err = poolConfig(config)
+	require.NoError(t, err)
+	assert.Equal(t, pgx.QueryExecModeDescribeExec, cfg.ConnConfig.DefaultQueryExecMode)
+}
+
+func TestNewClient_WaitsForDatabase(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	unreachable := func() *Config {
+		return &Config{Host: "127.0.0.1", Port: 1, Database: "jobs_db", SSLMode: "disable"}
+	}
+
+	t.Run("tries once without a wait timeout", func(t *testing.T) {
+		_, err := NewClient(unreachable(), logger)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "attempts")
+	})
+
+	t.Run("retries with backoff until the wait timeout", func(t *testing.T) {
+		config := unreachable()
+		config.ConnectTimeout = 100 * time.Millisecond
+		config.ConnectRetryInterval = 10 * time.Millisecond
+		config.ConnectMaxRetryInterval = 40 * time.Millisecond
+
+		start := time.Now()
+		_, err := NewClient(config, logger)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "attempts in 100ms")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,152 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// connector hands database/sql connections from a pgxpool.Pool. The pool logs in with
+// the current password, so a rotation only has to reset it.
+type connector struct {
+	pool  *pgxpool.Pool
+	conns driver.Connector
+	// err is why the pool could not be created, e.g. an unreadable sslrootcert. It is
+	// returned on every Connect, so Open stays lazy like the connections themselves.
+	err      error
+	password atomic.Pointer[string]
+}
+
+var _ driver.Connector = (*connector)(nil)
+
+func newConnector(config *Config) *connector {
+	c := &connector{}
+	c.password.Store(&config.Password)
+
+	cfg, err := poolConfig(config)
+	if err != nil {
+		c.err = err
+		return c
+	}
+	cfg.BeforeConnect = c.beforeConnect
+
+	// NewWithConfig does not connect, connections are opened on first use
+	c.pool, c.err = pgxpool.NewWithConfig(context.Background(), cfg)
+	if c.err == nil {
+		c.conns = stdlib.GetPoolConnector(c.pool)
+	}
+	return c
+}
+
+// poolConfig builds the pgxpool configuration for config. Statements are prepared and
+// cached per connection, up to StatementCacheCapacity of them; with 0 each query is
+// described on an unnamed statement instead, which works behind transaction pooling.
+func poolConfig(config *Config) (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(buildDSN(config))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxOpenConns > 0 {
+		cfg.MaxConns = int32(config.MaxOpenConns)
+	}
+	if config.MinIdleConns > 0 {
+		cfg.MinIdleConns = int32(min(config.MinIdleConns, int(cfg.MaxConns)))
+	}
+	if config.ConnMaxLifetime > 0 {
+		cfg.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		cfg.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
+
+	cfg.ConnConfig.StatementCacheCapacity = config.StatementCacheCapacity
+	if config.StatementCacheCapacity == 0 {
+		cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	}
+	return cfg, nil
+}
+
+// beforeConnect logs new pool connections in with the current password
+func (c *connector) beforeConnect(_ context.Context, config *pgx.ConnConfig) error {
+	config.Password = *c.password.Load()
+	return nil
+}
+
+// Connect acquires a connection from the pool. database/sql keeps no idle connections
+// of its own, so closing it returns the connection to the pool.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	conn, err := c.conns.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sc, ok := conn.(*stdlib.Conn); ok {
+		return &poolConn{Conn: sc}, nil
+	}
+	return conn, nil
+}
+
+// poolConn is a connection borrowed from the pool. Even when database/sql discards it,
+// e.g. after driver.ErrBadConn, closing it hands the session back to the pool with
+// everything it holds, such as advisory locks, unless Discard ended it first.
+type poolConn struct {
+	*stdlib.Conn
+}
+
+// Discard ends the session of the connection, releasing its session-level locks. Closing
+// it then drops it from the pool instead of reusing it. Reach it through sql.Conn.Raw.
+func (c *poolConn) Discard(ctx context.Context) error {
+	return c.Conn.Conn().Close(ctx)
+}
+
+// Driver returns the pgx database/sql driver
+func (c *connector) Driver() driver.Driver {
+	return stdlib.GetDefaultDriver()
+}
+
+// close closes every pool connection
+func (c *connector) close() {
+	if c.pool != nil {
+		c.pool.Close()
+	}
+}
+
+// UpdatePassword switches the pool to password, e.g. after the database credentials were
+// rotated. The password is checked on a connection of its own first, so a wrong one
+// leaves the pool as it was. Idle connections are closed right away and connections in
+// use once their query or transaction finishes; new ones log in with password.
+func (c *Client) UpdatePassword(ctx context.Context, password string) error {
+	if c.connector.err != nil {
+		return fmt.Errorf("failed to connect with the new password: %w", c.connector.err)
+	}
+
+	config := c.connector.pool.Config().ConnConfig
+	config.Password = password
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect with the new password: %w", TranslateError(err))
+	}
+	if err := conn.Close(ctx); err != nil {
+		c.logger.Warn("Failed to close PostgreSQL password check connection", slog.Any("error", err))
+	}
+
+	c.connector.password.Store(&password)
+
+	// Connections checked out of the pool are closed when they are returned
+	c.connector.pool.Reset()
+
+	c.logger.Info("PostgreSQL password rotated, reconnecting",
+		slog.Int("in_use", int(c.connector.pool.Stat().AcquiredConns())),
+	)
+	return nil
+}
@@ -0,0 +1,35 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnector_BeforeConnect(t *testing.T) {
+	c := newConnector(&Config{Host: "localhost", Port: 5432, Password: "old", SSLMode: "disable"})
+	require.NoError(t, c.err)
+	defer c.close()
+
+	config := &pgx.ConnConfig{}
+	require.NoError(t, c.beforeConnect(context.Background(), config))
+	assert.Equal(t, "old", config.Password)
+
+	// Connections opened after a rotation log in with the new password
+	password := "new"
+	c.password.Store(&password)
+	require.NoError(t, c.beforeConnect(context.Background(), config))
+	assert.Equal(t, "new", config.Password)
+}
+
+func TestConnector_InvalidConfig(t *testing.T) {
+	c := newConnector(&Config{Host: "localhost", Port: 5432, SSLMode: "bogus"})
+	defer c.close()
+
+	// Open does not fail, the error comes with the first connection
+	_, err := c.Connect(context.Background())
+	assert.Error(t, err)
+}
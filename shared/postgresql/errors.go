@@ -0,0 +1,102 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// Sentinel errors returned (wrapped) by TranslateError. Match them with errors.Is.
+var (
+	// ErrUniqueViolation means an insert or update hit a unique constraint (SQLSTATE 23505)
+	ErrUniqueViolation = errors.New("unique violation")
+	// ErrSerialization means the transaction lost a serialization or deadlock race
+	// (SQLSTATE 40001, 40P01) and can be retried as a whole
+	ErrSerialization = errors.New("serialization failure")
+	// ErrConnection means the database could not be reached (SQLSTATE class 08, 57P01-57P03,
+	// broken driver connections and network errors)
+	ErrConnection = errors.New("database connection failure")
+	// ErrTimeout means the statement was canceled or did not finish in time
+	// (SQLSTATE 57014, 55P03, context deadlines and network timeouts)
+	ErrTimeout = errors.New("database timeout")
+)
+
+var sentinels = []error{ErrUniqueViolation, ErrSerialization, ErrConnection, ErrTimeout}
+
+// TranslateError wraps err with the sentinel matching its SQLSTATE or failure mode, keeping
+// the original error in the chain. Errors that match no sentinel, and errors that were
+// already translated, are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			return err
+		}
+	}
+
+	if kind := classify(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+// SQLState returns the five-character SQLSTATE reported by PostgreSQL for err, or ""
+// when err did not come from the server (e.g. a network failure)
+func SQLState(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	// lib/pq errors still come from code using its driver directly, e.g. tests
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// classify returns the sentinel for err, or nil if it is not a recognized failure
+func classify(err error) error {
+	if code := SQLState(err); code != "" {
+		switch {
+		case code == "23505":
+			return ErrUniqueViolation
+		case code == "40001", code == "40P01":
+			return ErrSerialization
+		case code == "57014", code == "55P03":
+			return ErrTimeout
+		case strings.HasPrefix(code, "08"),
+			code == "57P01", code == "57P02", code == "57P03":
+			return ErrConnection
+		}
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+		return ErrConnection
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return ErrConnection
+	}
+
+	return nil
+}
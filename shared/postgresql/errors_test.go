@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		// Class 23: integrity constraint violation
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: ErrUniqueViolation},
+		{name: "foreign key violation", err: &pq.Error{Code: "23503"}, want: nil},
+		// Class 40: transaction rollback
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: ErrSerialization},
+		{name: "deadlock detected", err: &pq.Error{Code: "40P01"}, want: ErrSerialization},
+		// Class 08: connection exception
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: ErrConnection},
+		{name: "connection does not exist", err: &pq.Error{Code: "08003"}, want: ErrConnection},
+		// Class 57: operator intervention
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, want: ErrConnection},
+		{name: "cannot connect now", err: &pq.Error{Code: "57P03"}, want: ErrConnection},
+		{name: "statement timeout", err: &pq.Error{Code: "57014"}, want: ErrTimeout},
+		// Class 55: object not in prerequisite state
+		{name: "lock not available", err: &pq.Error{Code: "55P03"}, want: ErrTimeout},
+		// Class 42: syntax error or access rule violation
+		{name: "undefined table", err: &pq.Error{Code: "42P01"}, want: nil},
+		// pgx reports the same codes
+		{name: "pgx unique violation", err: &pgconn.PgError{Code: "23505"}, want: ErrUniqueViolation},
+		{name: "pgx serialization failure", err: &pgconn.PgError{Code: "40001"}, want: ErrSerialization},
+		{name: "pgx connection failure", err: &pgconn.PgError{Code: "08006"}, want: ErrConnection},
+		{name: "pgx statement timeout", err: &pgconn.PgError{Code: "57014"}, want: ErrTimeout},
+		{name: "pgx undefined table", err: &pgconn.PgError{Code: "42P01"}, want: nil},
+		// Driver and network failures
+		{name: "bad connection", err: driver.ErrBadConn, want: ErrConnection},
+		{name: "connection done", err: sql.ErrConnDone, want: ErrConnection},
+		{name: "dial refused", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: ErrConnection},
+		{name: "network timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}, want: ErrTimeout},
+		{name: "context deadline", err: context.DeadlineExceeded, want: ErrTimeout},
+		{name: "no rows", err: sql.ErrNoRows, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("failed to query: %w", tt.err)
+			got := TranslateError(wrapped)
+
+			// The original error always stays reachable
+			assert.True(t, errors.Is(got, tt.err))
+
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tt.want, errors.Is(got, sentinel), "sentinel %v", sentinel)
+			}
+		})
+	}
+}
+
+func TestTranslateError_Idempotent(t *testing.T) {
+	assert.Nil(t, TranslateError(nil))
+
+	once := TranslateError(&pq.Error{Code: "23505"})
+	assert.Same(t, once, TranslateError(once))
+}
+
+func TestSQLState(t *testing.T) {
+	assert.Equal(t, "40001", SQLState(fmt.Errorf("failed to commit: %w", &pq.Error{Code: "40001"})))
+	assert.Equal(t, "40P01", SQLState(fmt.Errorf("failed to commit: %w", &pgconn.PgError{Code: "40P01"})))
+	assert.Empty(t, SQLState(driver.ErrBadConn))
+	assert.Empty(t, SQLState(nil))
+}
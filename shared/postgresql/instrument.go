@@ -0,0 +1,99 @@
+package postgresql
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// unnamedQuery labels queries run without WithQueryName
+const unnamedQuery = "unnamed"
+
+type queryNameKey struct{}
+
+// WithQueryName tags queries run with ctx so their log lines and metrics can be told apart
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// queryName returns the name set by WithQueryName, or "unnamed"
+func queryName(ctx context.Context) string {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok && name != "" {
+		return name
+	}
+	return unnamedQuery
+}
+
+// QueryStats are the counters recorded for one query name
+type QueryStats struct {
+	Count         uint64
+	Errors        uint64
+	Slow          uint64
+	TotalDuration time.Duration
+}
+
+// queryMetrics accumulates QueryStats per query name
+type queryMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*QueryStats
+}
+
+func (m *queryMetrics) record(name string, duration time.Duration, slow bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stats == nil {
+		m.stats = make(map[string]*QueryStats)
+	}
+
+	s, ok := m.stats[name]
+	if !ok {
+		s = &QueryStats{}
+		m.stats[name] = s
+	}
+
+	s.Count++
+	s.TotalDuration += duration
+	if err != nil {
+		s.Errors++
+	}
+	if slow {
+		s.Slow++
+	}
+}
+
+func (m *queryMetrics) snapshot() map[string]QueryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]QueryStats, len(m.stats))
+	for name, s := range m.stats {
+		out[name] = *s
+	}
+	return out
+}
+
+// observe records a finished query and logs it when it exceeds SlowQueryThreshold
+func (c *Client) observe(ctx context.Context, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	name := queryName(ctx)
+	slow := c.config.SlowQueryThreshold > 0 && duration >= c.config.SlowQueryThreshold
+
+	c.metrics.record(name, duration, slow, err)
+
+	if slow {
+		c.logger.Warn("Slow query",
+			slog.String("query_name", name),
+			slog.Duration("duration", duration),
+			slog.Duration("threshold", c.config.SlowQueryThreshold),
+			slog.String("query", query),
+		)
+	}
+}
+
+// QueryStats returns a snapshot of the counters recorded by ExecContext, GetContext and
+// SelectContext, keyed by query name
+func (c *Client) QueryStats() map[string]QueryStats {
+	return c.metrics.snapshot()
+}
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, slowThreshold time.Duration) (*Client, sqlmock.Sqlmock, *bytes.Buffer) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	var logs bytes.Buffer
+	client := &Client{
+		db:     sqlx.NewDb(db, "postgres"),
+		config: &Config{SlowQueryThreshold: slowThreshold},
+		logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+	return client, mock, &logs
+}
+
+func TestClient_QueryStats(t *testing.T) {
+	client, mock, _ := newTestClient(t, 0)
+
+	mock.ExpectExec("UPDATE jobs").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT job_id").WillReturnError(errors.New("connection refused"))
+
+	ctx := WithQueryName(context.Background(), "count_jobs")
+	require.NoError(t, client.ExecContext(context.Background(), "UPDATE jobs SET status = 'FAILED'"))
+
+	var count int
+	require.NoError(t, client.GetContext(ctx, &count, "SELECT COUNT(*) FROM jobs"))
+
+	var ids []string
+	require.Error(t, client.SelectContext(ctx, &ids, "SELECT job_id FROM jobs"))
+
+	stats := client.QueryStats()
+	assert.Equal(t, uint64(1), stats["unnamed"].Count)
+	assert.Equal(t, uint64(2), stats["count_jobs"].Count)
+	assert.Equal(t, uint64(1), stats["count_jobs"].Errors)
+	assert.Zero(t, stats["count_jobs"].Slow)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClient_SlowQueryLog(t *testing.T) {
+	t.Run("logs queries over the threshold", func(t *testing.T) {
+		client, mock, logs := newTestClient(t, time.Millisecond)
+		mock.ExpectExec("DELETE FROM jobs").WillDelayFor(5 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx := WithQueryName(context.Background(), "delete_job")
+		require.NoError(t, client.ExecContext(ctx, "DELETE FROM jobs"))
+
+		assert.Contains(t, logs.String(), "Slow query")
+		assert.Contains(t, logs.String(), "query_name=delete_job")
+		assert.Equal(t, uint64(1), client.QueryStats()["delete_job"].Slow)
+	})
+
+	t.Run("zero threshold disables the log", func(t *testing.T) {
+		client, mock, logs := newTestClient(t, 0)
+		mock.ExpectExec("DELETE FROM jobs").WillDelayFor(5 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, client.ExecContext(context.Background(), "DELETE FROM jobs"))
+
+		assert.NotContains(t, logs.String(), "Slow query")
+	})
+}
+
+func TestClient_QueryTimeout(t *testing.T) {
+	t.Run("cancels queries that run too long", func(t *testing.T) {
+		client, mock, _ := newTestClient(t, 0)
+		client.config.QueryTimeout = time.Millisecond
+		mock.ExpectExec("UPDATE jobs").WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		start := time.Now()
+		require.Error(t, client.ExecContext(context.Background(), "UPDATE jobs SET status = 'FAILED'"))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("an earlier caller deadline wins", func(t *testing.T) {
+		client, _, _ := newTestClient(t, 0)
+		client.config.QueryTimeout = time.Hour
+
+		parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		want, _ := parent.Deadline()
+
+		ctx, cancelQuery := client.withQueryTimeout(parent)
+		defer cancelQuery()
+		got, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("zero disables the timeout", func(t *testing.T) {
+		client, _, _ := newTestClient(t, 0)
+
+		ctx, cancel := client.withQueryTimeout(context.Background())
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+}
+
+func TestClient_InjectedDelay(t *testing.T) {
+	client, mock, _ := newTestClient(t, 10*time.Millisecond)
+	client.config.InjectedDelay = 40 * time.Millisecond
+	client.config.InjectedDelayRate = 1
+
+	mock.ExpectExec("UPDATE jobs").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, client.ExecContext(context.Background(), "UPDATE jobs SET status = 'FAILED'"))
+	assert.Equal(t, uint64(1), client.QueryStats()["unnamed"].Slow)
+
+	// The delay runs down the query timeout
+	client.config.QueryTimeout = 5 * time.Millisecond
+	mock.ExpectExec("UPDATE jobs").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Error(t, client.ExecContext(context.Background(), "UPDATE jobs SET status = 'FAILED'"))
+}
@@ -0,0 +1,104 @@
+package rabbitmq
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Broker adapts Client to broker.Broker
+type Broker struct {
+	*Client
+}
+
+var (
+	_ broker.Broker         = (*Broker)(nil)
+	_ broker.EventPublisher = (*Broker)(nil)
+)
+
+// NewBroker wraps a connected Client as a broker.Broker
+func NewBroker(client *Client) *Broker {
+	return &Broker{Client: client}
+}
+
+// Consume delivers messages from the main queue until ctx is canceled or the
+// connection is closed. Compressed bodies are decompressed; messages that cannot be are
+// rejected without requeueing, so they dead-letter instead of being redelivered forever.
+func (b *Broker) Consume(ctx context.Context, consumerTag string) (<-chan broker.Delivery, error) {
+	messages, err := b.Client.Consume(consumerTag)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make(chan broker.Delivery)
+	go func() {
+		defer close(deliveries)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				if !b.decode(&msg) {
+					continue
+				}
+				select {
+				case deliveries <- delivery{msg: msg, autoAck: b.config.ConsumerAutoAck}:
+				case <-ctx.Done():
+					// Unacknowledged deliveries are redelivered once the channel closes
+					return
+				}
+			}
+		}
+	}()
+
+	return deliveries, nil
+}
+
+// decode replaces the body of msg with its decompressed form. It reports false, after
+// rejecting msg, when the body cannot be decompressed.
+func (b *Broker) decode(msg *amqp.Delivery) bool {
+	body, err := DecodeBody(msg.ContentEncoding, msg.Body)
+	if err != nil {
+		b.logger.Error("Rejecting message that cannot be decoded",
+			slog.String("content_encoding", msg.ContentEncoding),
+			slog.Any("error", err),
+		)
+		if !b.config.ConsumerAutoAck {
+			_ = msg.Nack(false, false)
+		}
+		return false
+	}
+	msg.Body = body
+	msg.ContentEncoding = ""
+	return true
+}
+
+// delivery adapts amqp.Delivery to broker.Delivery
+type delivery struct {
+	msg     amqp.Delivery
+	autoAck bool
+}
+
+func (d delivery) Body() []byte        { return d.msg.Body }
+func (d delivery) ContentType() string { return d.msg.ContentType }
+
+// Ack acknowledges the message
+func (d delivery) Ack() error {
+	if d.autoAck {
+		return nil
+	}
+	return d.msg.Ack(false)
+}
+
+// Nack rejects the message, requeueing it when requeue is true
+func (d delivery) Nack(requeue bool) error {
+	if d.autoAck {
+		return nil
+	}
+	return d.msg.Nack(false, requeue)
+}
@@ -0,0 +1,94 @@
+package rabbitmq
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_Publish_MessageTooLarge(t *testing.T) {
+	var b broker.Broker = NewBroker(&Client{
+		config: &Config{MaxMessageBytes: 8},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	err := b.Publish(context.Background(), []byte("0123456789"), "application/json")
+	assert.ErrorIs(t, err, broker.ErrMessageTooLarge)
+}
+
+func TestBroker_Consume_NotConnected(t *testing.T) {
+	b := NewBroker(&Client{config: &Config{}})
+
+	_, err := b.Consume(context.Background(), "worker-1")
+	assert.ErrorContains(t, err, "not connected")
+}
+
+// fakeAcknowledger records acks and nacks by delivery tag
+type fakeAcknowledger struct {
+	acks  []uint64
+	nacks map[uint64]bool // delivery tag to requeue
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, _ bool) error {
+	a.acks = append(a.acks, tag)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, _ bool, requeue bool) error {
+	a.nacks[tag] = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func TestDelivery_AckNack(t *testing.T) {
+	acker := &fakeAcknowledger{nacks: map[uint64]bool{}}
+	newDelivery := func(tag uint64, autoAck bool) delivery {
+		return delivery{
+			msg:     amqp.Delivery{Acknowledger: acker, DeliveryTag: tag, Body: []byte(`{"job_id":"1"}`), ContentType: "application/json"},
+			autoAck: autoAck,
+		}
+	}
+
+	d := newDelivery(1, false)
+	assert.Equal(t, []byte(`{"job_id":"1"}`), d.Body())
+	assert.Equal(t, "application/json", d.ContentType())
+	require.NoError(t, d.Ack())
+	require.NoError(t, newDelivery(2, false).Nack(true))
+	require.NoError(t, newDelivery(3, false).Nack(false))
+
+	// Auto-acknowledged deliveries were settled by the broker on delivery
+	require.NoError(t, newDelivery(4, true).Ack())
+	require.NoError(t, newDelivery(5, true).Nack(true))
+
+	assert.Equal(t, []uint64{1}, acker.acks)
+	assert.Equal(t, map[uint64]bool{2: true, 3: false}, acker.nacks)
+}
+
+func TestBroker_Decode(t *testing.T) {
+	b := NewBroker(&Client{
+		config: &Config{},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	acker := &fakeAcknowledger{nacks: map[uint64]bool{}}
+
+	compressed, err := CompressionConfig{Algorithm: CompressionGzip}.compress(Message{Body: []byte(`{"job_id":"1"}`)})
+	require.NoError(t, err)
+	msg := amqp.Delivery{Acknowledger: acker, DeliveryTag: 1, Body: compressed.Body, ContentEncoding: CompressionGzip}
+	require.True(t, b.decode(&msg))
+	assert.Equal(t, []byte(`{"job_id":"1"}`), msg.Body)
+	assert.Empty(t, msg.ContentEncoding)
+
+	// Undecodable messages are rejected so they dead-letter instead of being redelivered
+	msg = amqp.Delivery{Acknowledger: acker, DeliveryTag: 2, Body: []byte("{}"), ContentEncoding: "br"}
+	assert.False(t, b.decode(&msg))
+	assert.Equal(t, map[uint64]bool{2: false}, acker.nacks)
+}
@@ -2,13 +2,21 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cuongbtq/practice-be/shared/broker"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// ErrMessageTooLarge is returned by Publish when a message exceeds Config.MaxMessageBytes
+var ErrMessageTooLarge = broker.ErrMessageTooLarge
+
 // Config holds RabbitMQ connection configuration
 type Config struct {
 	Host               string
@@ -24,30 +32,98 @@ type Config struct {
 	QueueDurable       bool
 	QueueAutoDelete    bool
 	QueueExclusive     bool
+	QueueSingleActive  bool // Declare with x-single-active-consumer: one consumer receives deliveries at a time
 	RoutingKey         string
+	Partitions         int  // >0 binds <queue>.0 .. <queue>.N-1 to an x-consistent-hash exchange
+	Shards             int  // >0 binds <queue>.shard.0 .. <queue>.shard.N-1, see ShardRoutingKey
+	MaxMessageBytes    int  // Messages larger than this are rejected before publishing, 0 disables the check
+	PrefetchCount      int  // Unacknowledged deliveries per consumer, 0 means unlimited
+	ConsumerAutoAck    bool // Deliveries are acknowledged as soon as they are sent
+	ConsumerExclusive  bool // Only this consumer may read from the queue
 	RetryAttempts      int
 	RetryInterval      time.Duration
 	Heartbeat          time.Duration
 	ConnectionTimeout  time.Duration
+	PublisherChannels  int // Channels publishes are spread over, 0 uses 4
+	TLS                TLSConfig
+	Compression        CompressionConfig
+
+	// EventsExchange is the topic exchange PublishEvent publishes to, empty disables events
+	EventsExchange string
+
+	// ExchangeArguments and QueueArguments are passed when declaring the exchange and every
+	// queue, e.g. alternate-exchange or x-queue-type: quorum, x-message-ttl and x-max-length.
+	// Changing them on an existing exchange or queue fails the declaration.
+	ExchangeArguments amqp.Table
+	QueueArguments    amqp.Table
+
+	// Queues are declared and bound next to QueueName; consume each with ConsumeQueue
+	Queues []QueueBinding
+
+	// ShardField names the top-level string field of the JSON message body, e.g. user_id,
+	// whose hash picks the shard every publish is routed to. ShardRoutingKey is the routing
+	// key template of the shards, {shard} is replaced by the shard number.
+	ShardField      string
+	ShardRoutingKey string
+	// ConsumeShards lists the shards Consume reads from, empty reads every shard
+	ConsumeShards []int
+}
+
+// QueueBinding is an extra queue bound to the exchange, e.g. jobs.high next to jobs.default
+type QueueBinding struct {
+	Name        string
+	RoutingKey  string
+	Prefetch    int // Unacknowledged deliveries for this queue's consumer, 0 uses Config.PrefetchCount
+	Concurrency int // Deliveries the consumer processes in parallel; the client does not use it
 }
 
-// Client represents a RabbitMQ client
+// Message is a message published with PublishTo. Only Body is required.
+type Message struct {
+	Body        []byte
+	ContentType string
+	// ContentEncoding names how Body is compressed. Set, it skips Config.Compression.
+	ContentEncoding string
+	Headers         amqp.Table
+	Priority        uint8         // Only honored by queues declared with x-max-priority
+	Expiration      time.Duration // Dropped from the queue when not consumed in time, 0 never expires
+	CorrelationID   string
+}
+
+// defaultPublisherChannels is the publisher pool size when Config.PublisherChannels is 0
+const defaultPublisherChannels = 4
+
+// publisherDrainTimeout bounds the wait for publishes in flight on a replaced connection
+const publisherDrainTimeout = 30 * time.Second
+
+// Client represents a RabbitMQ client. It is safe for concurrent use: publishes take a
+// channel from a pool, while declarations and Consume use a channel of their own.
 type Client struct {
-	config      *Config
-	conn        *amqp.Connection
-	channel     *amqp.Channel
-	logger      *slog.Logger
-	closeChan   chan *amqp.Error
-	isConnected bool
+	config    *Config
+	logger    *slog.Logger
+	closeChan chan *amqp.Error
+	connected atomic.Bool
+
+	mu      sync.Mutex
+	session *session // Replaced by UpdatePassword
+}
+
+// session is one connection and the channels opened on it
+type session struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel // Declares the exchanges and queues and consumes the main queue
+
+	// publishers holds Config.PublisherChannels channels, nil until first used
+	publishers chan *amqp.Channel
+
+	consumerChannels []*amqp.Channel // Opened by ConsumeQueue, guarded by Client.mu
 }
 
 // NewClient creates a new RabbitMQ client
 func NewClient(config *Config, logger *slog.Logger) (*Client, error) {
 	client := &Client{
-		config:      config,
-		logger:      logger,
-		closeChan:   make(chan *amqp.Error),
-		isConnected: false,
+		config:    config,
+		logger:    logger,
+		closeChan: make(chan *amqp.Error),
 	}
 
 	if err := client.connect(); err != nil {
@@ -59,28 +135,18 @@ func NewClient(config *Config, logger *slog.Logger) (*Client, error) {
 
 // connect establishes connection to RabbitMQ with retry logic
 func (c *Client) connect() error {
-	var err error
-
-	dsn := fmt.Sprintf("amqp://%s:%s@%s:%d%s",
-		c.config.User,
-		c.config.Password,
-		c.config.Host,
-		c.config.Port,
-		c.config.VHost,
+	var (
+		conn *amqp.Connection
+		err  error
 	)
 
-	amqpConfig := amqp.Config{
-		Heartbeat: c.config.Heartbeat,
-		Locale:    "en_US",
-	}
-
 	for attempt := 1; attempt <= c.config.RetryAttempts; attempt++ {
 		c.logger.Info("Connecting to RabbitMQ",
 			slog.Int("attempt", attempt),
 			slog.Int("max_attempts", c.config.RetryAttempts),
 		)
 
-		c.conn, err = amqp.DialConfig(dsn, amqpConfig)
+		conn, err = c.dial(c.config.Password)
 		if err == nil {
 			c.logger.Info("Successfully connected to RabbitMQ")
 			break
@@ -100,96 +166,286 @@ func (c *Client) connect() error {
 		return fmt.Errorf("failed to connect to RabbitMQ after %d attempts: %w", c.config.RetryAttempts, err)
 	}
 
+	c.session, err = c.open(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	c.connected.Store(true)
+
+	c.logger.Info("RabbitMQ client initialized",
+		slog.String("exchange", c.config.ExchangeName),
+		slog.String("queue", c.config.QueueName),
+	)
+
+	return nil
+}
+
+// dial opens a connection logging in with password
+func (c *Client) dial(password string) (*amqp.Connection, error) {
+	scheme := "amqp"
+	amqpConfig := amqp.Config{
+		Heartbeat: c.config.Heartbeat,
+		Locale:    "en_US",
+	}
+
+	if c.config.TLS.Enabled {
+		var err error
+		scheme = "amqps"
+		amqpConfig.TLSClientConfig, err = c.config.TLS.clientConfig(c.config.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("%s://%s:%s@%s:%d%s",
+		scheme,
+		c.config.User,
+		password,
+		c.config.Host,
+		c.config.Port,
+		c.config.VHost,
+	)
+
+	return amqp.DialConfig(dsn, amqpConfig)
+}
+
+// open declares the topology on conn and prepares its channels
+func (c *Client) open(conn *amqp.Connection) (*session, error) {
 	// Create channel
-	c.channel, err = c.conn.Channel()
+	channel, err := conn.Channel()
 	if err != nil {
-		c.conn.Close()
-		return fmt.Errorf("failed to create channel: %w", err)
+		return nil, fmt.Errorf("failed to create channel: %w", err)
 	}
 
 	// Setup exchange and queue
-	if err := c.setup(); err != nil {
-		c.channel.Close()
-		c.conn.Close()
-		return fmt.Errorf("failed to setup exchange and queue: %w", err)
+	if err := c.setup(channel); err != nil {
+		channel.Close()
+		return nil, fmt.Errorf("failed to setup exchange and queue: %w", err)
 	}
 
 	// Monitor connection
 	c.closeChan = make(chan *amqp.Error)
-	c.channel.NotifyClose(c.closeChan)
-	c.isConnected = true
+	channel.NotifyClose(c.closeChan)
 
-	c.logger.Info("RabbitMQ client initialized",
-		slog.String("exchange", c.config.ExchangeName),
-		slog.String("queue", c.config.QueueName),
-	)
+	// Publisher channels are opened on first use, so idle slots cost nothing
+	size := c.config.PublisherChannels
+	if size <= 0 {
+		size = defaultPublisherChannels
+	}
+	publishers := make(chan *amqp.Channel, size)
+	for range size {
+		publishers <- nil
+	}
 
-	return nil
+	return &session{conn: conn, channel: channel, publishers: publishers}, nil
+}
+
+// current returns the session operations run on
+func (c *Client) current() *session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
 }
 
 // setup declares exchange, queue, and bindings
-func (c *Client) setup() error {
+func (c *Client) setup(channel *amqp.Channel) error {
 	// Declare exchange
-	err := c.channel.ExchangeDeclare(
+	err := channel.ExchangeDeclare(
 		c.config.ExchangeName,       // name
 		c.config.ExchangeType,       // type
 		c.config.ExchangeDurable,    // durable
 		c.config.ExchangeAutoDelete, // auto-deleted
 		false,                       // internal
 		false,                       // no-wait
-		nil,                         // arguments
+		c.config.ExchangeArguments,  // arguments
 	)
 	if err != nil {
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	// Declare queue
-	_, err = c.channel.QueueDeclare(
-		c.config.QueueName,       // name
-		c.config.QueueDurable,    // durable
-		c.config.QueueAutoDelete, // auto-delete
-		c.config.QueueExclusive,  // exclusive
-		false,                    // no-wait
-		nil,                      // arguments
-	)
-	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
+	// Events are only published; subscribers declare and bind their own queues
+	if c.config.EventsExchange != "" {
+		err = channel.ExchangeDeclare(
+			c.config.EventsExchange,     // name
+			amqp.ExchangeTopic,          // type
+			c.config.ExchangeDurable,    // durable
+			c.config.ExchangeAutoDelete, // auto-deleted
+			false,                       // internal
+			false,                       // no-wait
+			nil,                         // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare events exchange: %w", err)
+		}
 	}
 
-	// Bind queue to exchange
-	err = c.channel.QueueBind(
-		c.config.QueueName,    // queue name
-		c.config.RoutingKey,   // routing key
-		c.config.ExchangeName, // exchange
-		false,                 // no-wait
-		nil,                   // arguments
-	)
-	if err != nil {
-		return fmt.Errorf("failed to bind queue: %w", err)
+	// Declare queues and bind them to the exchange
+	for _, binding := range c.queueBindings() {
+		_, err = channel.QueueDeclare(
+			binding.queue,            // name
+			c.config.QueueDurable,    // durable
+			c.config.QueueAutoDelete, // auto-delete
+			c.config.QueueExclusive,  // exclusive
+			false,                    // no-wait
+			c.queueArguments(),       // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", binding.queue, err)
+		}
+
+		err = channel.QueueBind(
+			binding.queue,         // queue name
+			binding.key,           // routing key
+			c.config.ExchangeName, // exchange
+			false,                 // no-wait
+			nil,                   // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to bind queue %s: %w", binding.queue, err)
+		}
 	}
 
 	return nil
 }
 
-// Publish publishes a message to RabbitMQ
+// queueBinding is a queue to declare and the key it is bound to the exchange with
+type queueBinding struct {
+	queue string
+	key   string
+}
+
+// queueBindings returns the queues to declare. Partition queues are bound with weight "1"
+// so the consistent-hash exchange spreads keys evenly, and shard queues with their routing
+// key. Config.Queues follow the main queue.
+func (c *Client) queueBindings() []queueBinding {
+	var bindings []queueBinding
+	switch {
+	case c.config.Partitions > 0:
+		for _, queue := range c.PartitionQueues() {
+			bindings = append(bindings, queueBinding{queue: queue, key: "1"})
+		}
+	case c.config.Shards > 0:
+		for shard, queue := range c.ShardQueues() {
+			bindings = append(bindings, queueBinding{queue: queue, key: c.shardRoutingKey(shard)})
+		}
+	default:
+		bindings = append(bindings, queueBinding{queue: c.config.QueueName, key: c.config.RoutingKey})
+	}
+
+	for _, queue := range c.config.Queues {
+		bindings = append(bindings, queueBinding{queue: queue.Name, key: queue.RoutingKey})
+	}
+	return bindings
+}
+
+// PartitionQueues returns the partition queue names, or nil when partitioning is disabled
+func (c *Client) PartitionQueues() []string {
+	if c.config.Partitions <= 0 {
+		return nil
+	}
+
+	queues := make([]string, c.config.Partitions)
+	for i := range queues {
+		queues[i] = fmt.Sprintf("%s.%d", c.config.QueueName, i)
+	}
+	return queues
+}
+
+// queueArguments returns the optional x-arguments for the queue declaration
+func (c *Client) queueArguments() amqp.Table {
+	if len(c.config.QueueArguments) == 0 && !c.config.QueueSingleActive {
+		return nil
+	}
+
+	args := make(amqp.Table, len(c.config.QueueArguments)+1)
+	for key, value := range c.config.QueueArguments {
+		args[key] = value
+	}
+	if c.config.QueueSingleActive {
+		args["x-single-active-consumer"] = true
+	}
+	return args
+}
+
+// Publish publishes a message to RabbitMQ. With shards, it goes to the shard of its
+// Config.ShardField.
 func (c *Client) Publish(ctx context.Context, body []byte, contentType string) error {
-	if !c.isConnected {
+	routingKey := c.config.RoutingKey
+	if c.config.Shards > 0 {
+		routingKey = c.shardRoutingKey(Shard(shardValue(body, contentType, c.config.ShardField), c.config.Shards))
+	}
+	return c.PublishTo(ctx, c.config.ExchangeName, routingKey, Message{Body: body, ContentType: contentType})
+}
+
+// PublishOrdered publishes a message that must stay in order with other messages sharing
+// orderingKey. With partitions, the key is hashed to pick the partition queue, so one
+// consumer sees every message for a key in publish order. Without partitions it behaves
+// like Publish.
+func (c *Client) PublishOrdered(ctx context.Context, orderingKey string, body []byte, contentType string) error {
+	if c.config.Partitions <= 0 {
+		return c.Publish(ctx, body, contentType)
+	}
+	return c.PublishTo(ctx, c.config.ExchangeName, orderingKey, Message{Body: body, ContentType: contentType})
+}
+
+// PublishEvent publishes a message to the events exchange. It returns
+// broker.ErrNoEventsExchange when Config.EventsExchange is not set.
+func (c *Client) PublishEvent(ctx context.Context, routingKey string, body []byte, contentType string) error {
+	if c.config.EventsExchange == "" {
+		return broker.ErrNoEventsExchange
+	}
+	return c.PublishTo(ctx, c.config.EventsExchange, routingKey, Message{Body: body, ContentType: contentType})
+}
+
+// PublishTo publishes a message to any exchange with the given routing key, so different
+// job types or event streams can be routed through one client. The exchange must already
+// be declared; "" is the default exchange, which routes to the queue named routingKey.
+// Bodies are compressed per Config.Compression before the size check.
+func (c *Client) PublishTo(ctx context.Context, exchange, routingKey string, msg Message) error {
+	msg, err := c.config.Compression.compress(msg)
+	if err != nil {
+		return err
+	}
+
+	// The broker closes the channel on oversized messages; reject them up front instead
+	if c.config.MaxMessageBytes > 0 && len(msg.Body) > c.config.MaxMessageBytes {
+		return fmt.Errorf("%w: %d bytes (limit %d)", ErrMessageTooLarge, len(msg.Body), c.config.MaxMessageBytes)
+	}
+
+	if msg.Expiration < 0 {
+		return fmt.Errorf("invalid message expiration: %s (must not be negative)", msg.Expiration)
+	}
+
+	if !c.connected.Load() {
 		return fmt.Errorf("not connected to RabbitMQ")
 	}
 
-	err := c.channel.PublishWithContext(
-		ctx,
-		c.config.ExchangeName, // exchange
-		c.config.RoutingKey,   // routing key
-		false,                 // mandatory
-		false,                 // immediate
-		amqp.Publishing{
-			ContentType:  contentType,
-			Body:         body,
-			DeliveryMode: amqp.Persistent, // persistent
-			Timestamp:    time.Now(),
-		},
-	)
+	s := c.current()
+	for attempt := 1; ; attempt++ {
+		var ch *amqp.Channel
+		ch, err = s.acquirePublisher(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = ch.PublishWithContext(
+			ctx,
+			exchange,   // exchange
+			routingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			publishing(msg),
+		)
+		s.releasePublisher(ch)
+
+		// A channel-level error, such as publishing to an undeclared exchange, closes the
+		// channel after the publish that caused it returned. The next publish on it fails
+		// without reaching the broker, so it is retried once on a new channel.
+		if err == nil || attempt > 1 || !errors.Is(err, amqp.ErrClosed) || s.conn.IsClosed() {
+			break
+		}
+	}
 
 	if err != nil {
 		c.logger.Error("Failed to publish message to RabbitMQ",
@@ -199,27 +455,95 @@ func (c *Client) Publish(ctx context.Context, body []byte, contentType string) e
 	}
 
 	c.logger.Debug("Message published to RabbitMQ",
-		slog.Int("body_size", len(body)),
-		slog.String("content_type", contentType),
+		slog.String("exchange", exchange),
+		slog.String("routing_key", routingKey),
+		slog.Int("body_size", len(msg.Body)),
+		slog.String("content_type", msg.ContentType),
+		slog.String("content_encoding", msg.ContentEncoding),
 	)
 
 	return nil
 }
 
-// Consume starts consuming messages from the queue
+// acquirePublisher takes a channel from the publisher pool, waiting while all of them are
+// in use. A channel that was never opened, or was closed by a channel-level error, is
+// replaced with a new one.
+func (s *session) acquirePublisher(ctx context.Context) (*amqp.Channel, error) {
+	var ch *amqp.Channel
+	select {
+	case ch = <-s.publishers:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to get publisher channel: %w", ctx.Err())
+	}
+
+	if ch != nil && !ch.IsClosed() {
+		return ch, nil
+	}
+
+	ch, err := s.conn.Channel()
+	if err != nil {
+		s.publishers <- nil
+		return nil, fmt.Errorf("failed to open publisher channel: %w", err)
+	}
+	return ch, nil
+}
+
+// releasePublisher returns ch to the publisher pool. Closed channels are dropped and
+// reopened by the next publish that needs one.
+func (s *session) releasePublisher(ch *amqp.Channel) {
+	if ch.IsClosed() {
+		ch = nil
+	}
+	s.publishers <- ch
+}
+
+// publishing converts msg to the AMQP message sent to the broker
+func publishing(msg Message) amqp.Publishing {
+	p := amqp.Publishing{
+		Headers:         msg.Headers,
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		Body:            msg.Body,
+		DeliveryMode:    amqp.Persistent, // persistent
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationID,
+		Timestamp:       time.Now(),
+	}
+	if msg.Expiration > 0 {
+		// Per-message TTL is a string of milliseconds, rounded up so it never becomes 0
+		p.Expiration = strconv.FormatInt((msg.Expiration + time.Millisecond - 1).Milliseconds(), 10)
+	}
+	return p
+}
+
+// Consume starts consuming messages from the queue, or from Config.ConsumeShards when
+// sharding is enabled
 func (c *Client) Consume(consumerTag string) (<-chan amqp.Delivery, error) {
-	if !c.isConnected {
+	if !c.connected.Load() {
 		return nil, fmt.Errorf("not connected to RabbitMQ")
 	}
 
-	messages, err := c.channel.Consume(
-		c.config.QueueName, // queue
-		consumerTag,        // consumer tag
-		false,              // auto-ack
-		false,              // exclusive
-		false,              // no-local
-		false,              // no-wait
-		nil,                // args
+	if c.config.Shards > 0 {
+		return c.consumeShards(consumerTag)
+	}
+
+	channel := c.current().channel
+
+	// Prefetch is meaningless with auto-ack, the broker pushes without waiting for acks
+	if !c.config.ConsumerAutoAck {
+		if err := channel.Qos(c.config.PrefetchCount, 0, false); err != nil {
+			return nil, fmt.Errorf("failed to set prefetch count: %w", err)
+		}
+	}
+
+	messages, err := channel.Consume(
+		c.config.QueueName,         // queue
+		consumerTag,                // consumer tag
+		c.config.ConsumerAutoAck,   // auto-ack
+		c.config.ConsumerExclusive, // exclusive
+		false,                      // no-local
+		false,                      // no-wait
+		nil,                        // args
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to consume messages: %w", err)
@@ -228,44 +552,191 @@ func (c *Client) Consume(consumerTag string) (<-chan amqp.Delivery, error) {
 	c.logger.Info("Started consuming messages from RabbitMQ",
 		slog.String("queue", c.config.QueueName),
 		slog.String("consumer_tag", consumerTag),
+		slog.Int("prefetch_count", c.config.PrefetchCount),
+		slog.Bool("auto_ack", c.config.ConsumerAutoAck),
+		slog.Bool("exclusive", c.config.ConsumerExclusive),
 	)
 
 	return messages, nil
 }
 
+// ConsumeQueue starts consuming one of Config.Queues on a channel of its own, so its
+// prefetch limit is independent of the other queues
+func (c *Client) ConsumeQueue(queue QueueBinding, consumerTag string) (<-chan amqp.Delivery, error) {
+	if !c.connected.Load() {
+		return nil, fmt.Errorf("not connected to RabbitMQ")
+	}
+
+	_, messages, err := c.consumeQueue(c.current(), queue, consumerTag)
+	return messages, err
+}
+
+// consumeQueue consumes queue on a new channel of s and returns the channel. It is
+// registered in s, so it is closed with the session.
+func (c *Client) consumeQueue(s *session, queue QueueBinding, consumerTag string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create channel for queue %s: %w", queue.Name, err)
+	}
+
+	prefetch := queue.Prefetch
+	if prefetch == 0 {
+		prefetch = c.config.PrefetchCount
+	}
+
+	// Prefetch is meaningless with auto-ack, the broker pushes without waiting for acks
+	if !c.config.ConsumerAutoAck {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, nil, fmt.Errorf("failed to set prefetch count for queue %s: %w", queue.Name, err)
+		}
+	}
+
+	messages, err := ch.Consume(
+		queue.Name,                 // queue
+		consumerTag,                // consumer tag
+		c.config.ConsumerAutoAck,   // auto-ack
+		c.config.ConsumerExclusive, // exclusive
+		false,                      // no-local
+		false,                      // no-wait
+		nil,                        // args
+	)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("failed to consume messages from queue %s: %w", queue.Name, err)
+	}
+
+	c.mu.Lock()
+	s.consumerChannels = append(s.consumerChannels, ch)
+	c.mu.Unlock()
+
+	c.logger.Info("Started consuming messages from RabbitMQ",
+		slog.String("queue", queue.Name),
+		slog.String("consumer_tag", consumerTag),
+		slog.Int("prefetch_count", prefetch),
+		slog.Int("concurrency", queue.Concurrency),
+		slog.Bool("auto_ack", c.config.ConsumerAutoAck),
+		slog.Bool("exclusive", c.config.ConsumerExclusive),
+	)
+
+	return ch, messages, nil
+}
+
+// UpdatePassword reconnects with password, e.g. after the broker credentials were
+// rotated. The new connection is opened and the topology declared on it before it
+// replaces the old one, so a wrong password leaves the client as it was. Publishes in
+// flight on the old connection finish before it is closed. Its consumers see their
+// delivery channels close and must consume again, as after any disconnect.
+func (c *Client) UpdatePassword(password string) error {
+	if !c.connected.Load() {
+		return fmt.Errorf("not connected to RabbitMQ")
+	}
+
+	conn, err := c.dial(password)
+	if err != nil {
+		return fmt.Errorf("failed to connect with the new password: %w", err)
+	}
+	next, err := c.open(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	old, consumers := c.session, c.session.consumerChannels
+	c.session = next
+	c.mu.Unlock()
+
+	c.logger.Info("RabbitMQ password rotated, closing the old connection")
+	if err := old.close(c.logger, consumers); err != nil {
+		c.logger.Warn("Failed to close the old RabbitMQ connection", slog.Any("error", err))
+	}
+	return nil
+}
+
 // Close closes the RabbitMQ connection
 func (c *Client) Close() error {
 	c.logger.Info("Closing RabbitMQ connection")
 
-	c.isConnected = false
+	c.connected.Store(false)
+
+	c.mu.Lock()
+	s := c.session
+	var consumers []*amqp.Channel
+	if s != nil {
+		consumers, s.consumerChannels = s.consumerChannels, nil
+	}
+	c.mu.Unlock()
+
+	if s != nil {
+		if err := s.close(c.logger, consumers); err != nil {
+			return err
+		}
+	}
+
+	c.logger.Info("RabbitMQ connection closed successfully")
+	return nil
+}
+
+// close closes consumers, then the channels and connection of s. Publishes in flight are
+// waited for up to publisherDrainTimeout; any still running then fail with the connection.
+func (s *session) close(logger *slog.Logger, consumers []*amqp.Channel) error {
+	for _, ch := range consumers {
+		if err := ch.Close(); err != nil {
+			logger.Error("Failed to close RabbitMQ consumer channel",
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	timeout := time.After(publisherDrainTimeout)
+drain:
+	for range cap(s.publishers) {
+		select {
+		case ch := <-s.publishers:
+			if ch != nil && !ch.IsClosed() {
+				if err := ch.Close(); err != nil {
+					logger.Error("Failed to close RabbitMQ publisher channel",
+						slog.Any("error", err),
+					)
+				}
+			}
+		case <-timeout:
+			logger.Warn("Closing RabbitMQ connection with publishes in flight")
+			break drain
+		}
+	}
 
-	if c.channel != nil {
-		if err := c.channel.Close(); err != nil {
-			c.logger.Error("Failed to close RabbitMQ channel",
+	if s.channel != nil {
+		if err := s.channel.Close(); err != nil {
+			logger.Error("Failed to close RabbitMQ channel",
 				slog.Any("error", err),
 			)
 		}
 	}
 
-	if c.conn != nil {
-		if err := c.conn.Close(); err != nil {
-			c.logger.Error("Failed to close RabbitMQ connection",
+	if s.conn != nil {
+		if err := s.conn.Close(); err != nil {
+			logger.Error("Failed to close RabbitMQ connection",
 				slog.Any("error", err),
 			)
 			return err
 		}
 	}
 
-	c.logger.Info("RabbitMQ connection closed successfully")
 	return nil
 }
 
 // IsConnected returns the connection status
 func (c *Client) IsConnected() bool {
-	return c.isConnected && c.conn != nil && !c.conn.IsClosed()
+	if !c.connected.Load() {
+		return false
+	}
+	s := c.current()
+	return s != nil && s.conn != nil && !s.conn.IsClosed()
 }
 
 // GetChannel returns the channel for advanced operations
 func (c *Client) GetChannel() *amqp.Channel {
-	return c.channel
+	return c.current().channel
 }
@@ -0,0 +1,179 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/shared/broker"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Publish_MessageTooLarge(t *testing.T) {
+	c := &Client{
+		config: &Config{MaxMessageBytes: 8},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	err := c.Publish(context.Background(), []byte("0123456789"), "application/json")
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	// Within the limit the size check passes and the connection state decides
+	err = c.Publish(context.Background(), []byte("01234567"), "application/json")
+	assert.False(t, errors.Is(err, ErrMessageTooLarge))
+}
+
+func TestClient_Publish_CompressedWithinLimit(t *testing.T) {
+	c := &Client{
+		config: &Config{
+			MaxMessageBytes: 512,
+			Compression:     CompressionConfig{Algorithm: CompressionGzip, MinBytes: 256},
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// The limit applies to the compressed body that is sent to the broker
+	err := c.Publish(context.Background(), bytes.Repeat([]byte("a"), 4096), "application/json")
+	assert.False(t, errors.Is(err, ErrMessageTooLarge))
+}
+
+func TestClient_PublishEvent_NoEventsExchange(t *testing.T) {
+	c := &Client{
+		config: &Config{},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	err := c.PublishEvent(context.Background(), "job.send_email.completed", []byte("{}"), "application/json")
+	assert.ErrorIs(t, err, broker.ErrNoEventsExchange)
+}
+
+func TestClient_PublishTo_InvalidExpiration(t *testing.T) {
+	c := &Client{
+		config: &Config{},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	err := c.PublishTo(context.Background(), "jobs_exchange", "job.created", Message{Body: []byte("{}"), Expiration: -time.Second})
+	assert.EqualError(t, err, "invalid message expiration: -1s (must not be negative)")
+}
+
+func TestSession_AcquirePublisher(t *testing.T) {
+	pooled := &amqp.Channel{}
+	s := &session{publishers: make(chan *amqp.Channel, 1)}
+	s.publishers <- pooled
+
+	ch, err := s.acquirePublisher(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, pooled, ch)
+
+	// Every channel is in use until it is released
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.acquirePublisher(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	s.releasePublisher(ch)
+	ch, err = s.acquirePublisher(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, pooled, ch)
+}
+
+func TestClient_Close_NotConnected(t *testing.T) {
+	c := &Client{
+		config: &Config{},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	assert.NoError(t, c.Close())
+	assert.False(t, c.IsConnected())
+	assert.EqualError(t, c.UpdatePassword("rotated"), "not connected to RabbitMQ")
+}
+
+func TestPublishing(t *testing.T) {
+	p := publishing(Message{
+		Body:            []byte("{}"),
+		ContentType:     "application/json",
+		Headers:         amqp.Table{"job_type": "send_email"},
+		Priority:        5,
+		Expiration:      1500*time.Millisecond + time.Microsecond,
+		CorrelationID:   "job-1",
+		ContentEncoding: "gzip",
+	})
+	assert.Equal(t, "1501", p.Expiration)
+	assert.Equal(t, "gzip", p.ContentEncoding)
+	assert.Equal(t, uint8(5), p.Priority)
+	assert.Equal(t, "job-1", p.CorrelationId)
+	assert.Equal(t, amqp.Table{"job_type": "send_email"}, p.Headers)
+	assert.Equal(t, amqp.Persistent, p.DeliveryMode)
+	assert.Equal(t, "application/json", p.ContentType)
+
+	// Without options only the defaults are set
+	p = publishing(Message{Body: []byte("{}")})
+	assert.Empty(t, p.Expiration)
+	assert.Nil(t, p.Headers)
+	assert.Empty(t, p.CorrelationId)
+}
+
+func TestClient_QueueArguments(t *testing.T) {
+	c := &Client{config: &Config{}}
+	assert.Nil(t, c.queueArguments())
+
+	c.config.QueueSingleActive = true
+	assert.Equal(t, true, c.queueArguments()["x-single-active-consumer"])
+}
+
+func TestClient_QueueArguments_Configured(t *testing.T) {
+	c := &Client{config: &Config{
+		QueueArguments:    amqp.Table{"x-queue-type": "quorum", "x-max-length": 10000},
+		QueueSingleActive: true,
+	}}
+	assert.Equal(t, amqp.Table{
+		"x-queue-type":             "quorum",
+		"x-max-length":             10000,
+		"x-single-active-consumer": true,
+	}, c.queueArguments())
+
+	// The configured table is not modified
+	assert.Len(t, c.config.QueueArguments, 2)
+}
+
+func TestClient_QueueBindings(t *testing.T) {
+	c := &Client{config: &Config{QueueName: "jobs_queue", RoutingKey: "job.created"}}
+	assert.Equal(t, []queueBinding{{queue: "jobs_queue", key: "job.created"}}, c.queueBindings())
+	assert.Nil(t, c.PartitionQueues())
+
+	c.config.Partitions = 3
+	assert.Equal(t, []queueBinding{
+		{queue: "jobs_queue.0", key: "1"},
+		{queue: "jobs_queue.1", key: "1"},
+		{queue: "jobs_queue.2", key: "1"},
+	}, c.queueBindings())
+}
+
+func TestClient_QueueBindings_ExtraQueues(t *testing.T) {
+	c := &Client{config: &Config{
+		QueueName:  "jobs.default",
+		RoutingKey: "job.created",
+		Queues: []QueueBinding{
+			{Name: "jobs.high", RoutingKey: "job.created.high", Prefetch: 5, Concurrency: 8},
+			{Name: "jobs.low", RoutingKey: "job.created.low"},
+		},
+	}}
+	assert.Equal(t, []queueBinding{
+		{queue: "jobs.default", key: "job.created"},
+		{queue: "jobs.high", key: "job.created.high"},
+		{queue: "jobs.low", key: "job.created.low"},
+	}, c.queueBindings())
+}
+
+func TestClient_ConsumeQueue_NotConnected(t *testing.T) {
+	c := &Client{config: &Config{}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	_, err := c.ConsumeQueue(QueueBinding{Name: "jobs.high"}, "worker-1")
+	assert.EqualError(t, err, "not connected to RabbitMQ")
+}
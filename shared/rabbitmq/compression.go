@@ -0,0 +1,73 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Compression algorithms, sent as the message's content-encoding
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// ErrUnsupportedEncoding is returned when decoding a message body with a content-encoding
+// the client cannot decompress
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// CompressionConfig compresses published message bodies of at least MinBytes bytes
+type CompressionConfig struct {
+	Algorithm string // CompressionNone or CompressionGzip
+	MinBytes  int    // Smaller bodies are sent as is, compressing them rarely pays off
+}
+
+// compress returns msg with its body compressed when the configuration calls for it.
+// Messages that already carry a content-encoding are left alone.
+func (c CompressionConfig) compress(msg Message) (Message, error) {
+	if c.Algorithm == CompressionNone || msg.ContentEncoding != "" || len(msg.Body) < c.MinBytes {
+		return msg, nil
+	}
+
+	var buf bytes.Buffer
+	switch c.Algorithm {
+	case CompressionGzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(msg.Body); err != nil {
+			return msg, fmt.Errorf("failed to compress message: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return msg, fmt.Errorf("failed to compress message: %w", err)
+		}
+	default:
+		return msg, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, c.Algorithm)
+	}
+
+	msg.Body = buf.Bytes()
+	msg.ContentEncoding = c.Algorithm
+	return msg, nil
+}
+
+// DecodeBody returns body decompressed according to its content-encoding. Bodies without
+// one are returned as is.
+func DecodeBody(contentEncoding string, body []byte) ([]byte, error) {
+	switch contentEncoding {
+	case "", "identity":
+		return body, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress message: %w", err)
+		}
+		defer r.Close()
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress message: %w", err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, contentEncoding)
+	}
+}
@@ -0,0 +1,56 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionConfig_Compress(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"to":"user@example.com"}`), 100)
+	c := CompressionConfig{Algorithm: CompressionGzip, MinBytes: 1024}
+
+	msg, err := c.compress(Message{Body: body, ContentType: "application/json"})
+	require.NoError(t, err)
+	assert.Equal(t, CompressionGzip, msg.ContentEncoding)
+	assert.Less(t, len(msg.Body), len(body))
+	assert.Equal(t, "application/json", msg.ContentType)
+
+	decoded, err := DecodeBody(msg.ContentEncoding, msg.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, decoded)
+
+	// Bodies under the threshold are sent as is
+	msg, err = c.compress(Message{Body: []byte("{}")})
+	require.NoError(t, err)
+	assert.Empty(t, msg.ContentEncoding)
+	assert.Equal(t, []byte("{}"), msg.Body)
+
+	// Already encoded bodies are not compressed twice
+	msg, err = c.compress(Message{Body: body, ContentEncoding: "br"})
+	require.NoError(t, err)
+	assert.Equal(t, "br", msg.ContentEncoding)
+	assert.Equal(t, body, msg.Body)
+
+	// Compression is off by default
+	msg, err = CompressionConfig{}.compress(Message{Body: body})
+	require.NoError(t, err)
+	assert.Empty(t, msg.ContentEncoding)
+
+	_, err = CompressionConfig{Algorithm: "lz4"}.compress(Message{Body: body})
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func TestDecodeBody(t *testing.T) {
+	body, err := DecodeBody("", []byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), body)
+
+	_, err = DecodeBody(CompressionGzip, []byte("not gzip"))
+	assert.ErrorContains(t, err, "failed to decompress message")
+
+	_, err = DecodeBody("br", []byte("{}"))
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
@@ -0,0 +1,145 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cuongbtq/practice-be/shared/messaging"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Shard returns the shard of key among shards, using jump consistent hashing so that
+// growing from N to N+1 shards moves only 1/(N+1) of the keys
+func Shard(key string, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	k := h.Sum64()
+
+	var b, j int64 = -1, 0
+	for j < int64(shards) {
+		b = j
+		k = k*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((k>>33)+1)))
+	}
+	return int(b)
+}
+
+// shardValue returns the string field of the JSON body that is hashed to pick its shard.
+// Job messages in another encoding are read as their JSON form. Bodies without the
+// field all go to the shard of "".
+func shardValue(body []byte, contentType, field string) string {
+	if codec, err := messaging.Negotiate(contentType); err == nil && codec != messaging.JSON {
+		msg, err := messaging.Decode(contentType, body)
+		if err != nil {
+			return ""
+		}
+		if body, err = messaging.JSON.Marshal(msg); err != nil {
+			return ""
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	var value string
+	if err := json.Unmarshal(fields[field], &value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// ShardQueues returns the shard queue names, or nil when sharding is disabled
+func (c *Client) ShardQueues() []string {
+	if c.config.Shards <= 0 {
+		return nil
+	}
+
+	queues := make([]string, c.config.Shards)
+	for i := range queues {
+		queues[i] = fmt.Sprintf("%s.shard.%d", c.config.QueueName, i)
+	}
+	return queues
+}
+
+// shardRoutingKey returns the routing key of shard
+func (c *Client) shardRoutingKey(shard int) string {
+	return strings.ReplaceAll(c.config.ShardRoutingKey, "{shard}", strconv.Itoa(shard))
+}
+
+// consumedShards returns the shards Consume reads from
+func (c *Client) consumedShards() []int {
+	if len(c.config.ConsumeShards) > 0 {
+		return c.config.ConsumeShards
+	}
+
+	shards := make([]int, c.config.Shards)
+	for i := range shards {
+		shards[i] = i
+	}
+	return shards
+}
+
+// consumeShards consumes every shard queue in Config.ConsumeShards on a channel of its
+// own and merges their deliveries. The returned channel closes once all of them have.
+func (c *Client) consumeShards(consumerTag string) (<-chan amqp.Delivery, error) {
+	queues := c.ShardQueues()
+	s := c.current()
+
+	var opened []*amqp.Channel
+	var sources []<-chan amqp.Delivery
+	for _, shard := range c.consumedShards() {
+		ch, messages, err := c.consumeQueue(s, QueueBinding{Name: queues[shard]}, fmt.Sprintf("%s.shard.%d", consumerTag, shard))
+		if err != nil {
+			// The shards consumed so far would never be read
+			c.closeConsumers(s, opened)
+			return nil, err
+		}
+		opened = append(opened, ch)
+		sources = append(sources, messages)
+	}
+
+	merged := make(chan amqp.Delivery)
+	var wg sync.WaitGroup
+	for _, messages := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range messages {
+				merged <- msg
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged, nil
+}
+
+// closeConsumers closes channels opened by consumeQueue on s and stops tracking them
+func (c *Client) closeConsumers(s *session, channels []*amqp.Channel) {
+	c.mu.Lock()
+	s.consumerChannels = slices.DeleteFunc(s.consumerChannels, func(ch *amqp.Channel) bool {
+		return slices.Contains(channels, ch)
+	})
+	c.mu.Unlock()
+
+	for _, ch := range channels {
+		if err := ch.Close(); err != nil {
+			c.logger.Warn("Failed to close RabbitMQ consumer channel", slog.Any("error", err))
+		}
+	}
+}
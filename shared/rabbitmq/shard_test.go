@@ -0,0 +1,74 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cuongbtq/practice-be/shared/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShard(t *testing.T) {
+	assert.Equal(t, 0, Shard("user-1", 0))
+	assert.Equal(t, 0, Shard("user-1", 1))
+
+	counts := make([]int, 4)
+	for i := range 1000 {
+		key := fmt.Sprintf("user-%d", i)
+		shard := Shard(key, 4)
+		assert.Equal(t, shard, Shard(key, 4), "shard of %s is not stable", key)
+		counts[shard]++
+	}
+	for shard, count := range counts {
+		assert.Greater(t, count, 150, "shard %d gets too few keys", shard)
+	}
+}
+
+func TestShard_GrowingMovesFewKeys(t *testing.T) {
+	moved := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("user-%d", i)
+		before, after := Shard(key, 4), Shard(key, 5)
+		if before != after {
+			// Keys only ever move to the new shard
+			assert.Equal(t, 4, after)
+			moved++
+		}
+	}
+	assert.Less(t, moved, 300)
+}
+
+func TestShardValue(t *testing.T) {
+	body := []byte(`{"job_id":"j1","user_id":"user-1","job_type":"send_email"}`)
+	assert.Equal(t, "user-1", shardValue(body, "application/json", "user_id"))
+	assert.Equal(t, "send_email", shardValue(body, "application/json", "job_type"))
+	assert.Equal(t, "", shardValue(body, "application/json", "tenant_id"))
+	assert.Equal(t, "", shardValue([]byte(`{"user_id":42}`), "application/json", "user_id"))
+	assert.Equal(t, "", shardValue([]byte(`not json`), "application/json", "user_id"))
+
+	// Protobuf job messages shard the same as their JSON form
+	body, err := messaging.Protobuf.Marshal(&messaging.JobMessage{JobID: "j1", UserID: "user-1", JobType: "send_email"})
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", shardValue(body, messaging.Protobuf.ContentType(), "user_id"))
+	assert.Equal(t, "send_email", shardValue(body, messaging.Protobuf.ContentType(), "job_type"))
+}
+
+func TestClient_QueueBindings_Shards(t *testing.T) {
+	c := &Client{config: &Config{
+		QueueName:       "jobs_queue",
+		RoutingKey:      "job.created",
+		Shards:          3,
+		ShardRoutingKey: "job.created.{shard}",
+	}}
+	assert.Equal(t, []string{"jobs_queue.shard.0", "jobs_queue.shard.1", "jobs_queue.shard.2"}, c.ShardQueues())
+	assert.Equal(t, []queueBinding{
+		{queue: "jobs_queue.shard.0", key: "job.created.0"},
+		{queue: "jobs_queue.shard.1", key: "job.created.1"},
+		{queue: "jobs_queue.shard.2", key: "job.created.2"},
+	}, c.queueBindings())
+
+	assert.Equal(t, []int{0, 1, 2}, c.consumedShards())
+	c.config.ConsumeShards = []int{2}
+	assert.Equal(t, []int{2}, c.consumedShards())
+}
@@ -0,0 +1,57 @@
+package rabbitmq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig enables amqps connections. File paths point at PEM files.
+type TLSConfig struct {
+	Enabled  bool
+	CAFile   string // CA bundle the server certificate is verified against, empty uses the system roots
+	CertFile string // Client certificate, for brokers that require one; needs KeyFile
+	KeyFile  string
+	// ServerName is checked against the server certificate, empty uses Config.Host
+	ServerName string
+	// InsecureSkipVerify accepts any server certificate. Only for testing.
+	InsecureSkipVerify bool
+}
+
+// clientConfig loads the certificates into a tls.Config for dialing host
+func (t TLSConfig) clientConfig(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
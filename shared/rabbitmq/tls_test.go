@@ -0,0 +1,87 @@
+package rabbitmq
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key as PEM files in dir
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rabbitmq"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTLSConfig_ClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+
+	t.Run("system roots", func(t *testing.T) {
+		cfg, err := TLSConfig{Enabled: true}.clientConfig("rabbitmq.internal")
+		require.NoError(t, err)
+		assert.Equal(t, "rabbitmq.internal", cfg.ServerName)
+		assert.Nil(t, cfg.RootCAs)
+		assert.Empty(t, cfg.Certificates)
+		assert.False(t, cfg.InsecureSkipVerify)
+	})
+
+	t.Run("CA and client certificate", func(t *testing.T) {
+		cfg, err := TLSConfig{
+			Enabled:    true,
+			CAFile:     certFile,
+			CertFile:   certFile,
+			KeyFile:    keyFile,
+			ServerName: "broker.example.com",
+		}.clientConfig("10.0.0.5")
+		require.NoError(t, err)
+		assert.Equal(t, "broker.example.com", cfg.ServerName)
+		assert.NotNil(t, cfg.RootCAs)
+		assert.Len(t, cfg.Certificates, 1)
+	})
+
+	t.Run("CA file without certificates", func(t *testing.T) {
+		_, err := TLSConfig{Enabled: true, CAFile: keyFile}.clientConfig("localhost")
+		assert.ErrorContains(t, err, "no certificates found in CA file")
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		_, err := TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}.clientConfig("localhost")
+		assert.ErrorContains(t, err, "failed to read CA file")
+	})
+
+	t.Run("certificate without key", func(t *testing.T) {
+		_, err := TLSConfig{Enabled: true, CertFile: certFile}.clientConfig("localhost")
+		assert.EqualError(t, err, "client certificate and key must be set together")
+	})
+}
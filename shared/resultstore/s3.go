@@ -0,0 +1,282 @@
+package resultstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	refScheme     = "s3://"
+	amzDateFormat = "20060102T150405Z"
+	// maxURLExpiry is the longest lifetime SigV4 allows for a presigned URL
+	maxURLExpiry = 7 * 24 * time.Hour
+)
+
+// S3Config holds settings for an S3-compatible object store such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.amazonaws.com or http://minio:9000
+	Region          string
+	Bucket          string
+	Prefix          string // Prepended to object keys
+	AccessKeyID     string
+	SecretAccessKey string
+	UsePathStyle    bool          // Address buckets as endpoint/bucket instead of bucket.endpoint (MinIO)
+	InlineLimit     int           // Results up to this many bytes stay inline
+	URLExpiry       time.Duration // Lifetime of presigned download URLs
+}
+
+// S3Store offloads results larger than InlineLimit to an S3-compatible bucket.
+// Requests are signed with AWS Signature Version 4.
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store creates an S3Store. A nil client uses http.DefaultClient.
+func NewS3Store(config S3Config, client *http.Client) (*S3Store, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid object store endpoint %q", config.Endpoint)
+	}
+
+	if config.URLExpiry <= 0 || config.URLExpiry > maxURLExpiry {
+		return nil, fmt.Errorf("invalid presigned URL expiry %s (must be between 1s and %s)", config.URLExpiry, maxURLExpiry)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Save keeps results up to InlineLimit inline and uploads larger ones as <prefix><jobID>.json
+func (s *S3Store) Save(ctx context.Context, jobID string, result []byte) (Stored, error) {
+	if len(result) <= s.config.InlineLimit {
+		return Stored{Inline: json.RawMessage(result)}, nil
+	}
+
+	key := s.config.Prefix + jobID + ".json"
+	if err := s.send(ctx, http.MethodPut, key, result); err != nil {
+		return Stored{}, fmt.Errorf("failed to upload result: %w", err)
+	}
+
+	return Stored{Ref: refScheme + s.config.Bucket + "/" + key}, nil
+}
+
+// DownloadURL returns a presigned GET URL for a reference returned by Save
+func (s *S3Store) DownloadURL(_ context.Context, ref string) (string, error) {
+	key, err := s.objectKey(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return s.presign(http.MethodGet, key, s.now().UTC(), s.config.URLExpiry), nil
+}
+
+// Delete removes the object behind a reference returned by Save. Deleting an object that
+// no longer exists succeeds.
+func (s *S3Store) Delete(ctx context.Context, ref string) error {
+	key, err := s.objectKey(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := s.send(ctx, http.MethodDelete, key, nil); err != nil {
+		return fmt.Errorf("failed to delete result: %w", err)
+	}
+	return nil
+}
+
+// objectKey returns the key of a reference returned by Save
+func (s *S3Store) objectKey(ref string) (string, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(ref, refScheme), "/")
+	if !strings.HasPrefix(ref, refScheme) || !ok || key == "" {
+		return "", fmt.Errorf("invalid result reference %q", ref)
+	}
+	if bucket != s.config.Bucket {
+		return "", fmt.Errorf("result reference %q is not in bucket %q", ref, s.config.Bucket)
+	}
+	return key, nil
+}
+
+// send makes a method request for key with body using header-based SigV4 authentication
+func (s *S3Store) send(ctx context.Context, method, key string, body []byte) error {
+	host, path := s.location(key)
+	now := s.now().UTC()
+	payloadHash := sha256Hex(body)
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+host+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(headers)
+	canonicalRequest := strings.Join([]string{
+		method, path, "", canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, s.sign(now, canonicalRequest)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// DELETE answers 204, and 404 when the object is already gone
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case method == http.MethodDelete && (resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound):
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("object store returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// presign builds a query-authenticated URL for method on key, valid for expiry from now
+func (s *S3Store) presign(method, key string, now time.Time, expiry time.Duration) string {
+	host, path := s.location(key)
+	scope := s.scope(now)
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.config.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          now.Format(amzDateFormat),
+		"X-Amz-Expires":       strconv.Itoa(int(expiry.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	canonicalQuery := canonicalizeQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		method, path, canonicalQuery, "host:" + host + "\n", "host", "UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	return s.endpoint.Scheme + "://" + host + path + "?" + canonicalQuery +
+		"&X-Amz-Signature=" + s.sign(now, canonicalRequest)
+}
+
+// location returns the request host and escaped path for key
+func (s *S3Store) location(key string) (host, path string) {
+	if s.config.UsePathStyle {
+		return s.endpoint.Host, "/" + escapePath(s.config.Bucket+"/"+key)
+	}
+	return s.config.Bucket + "." + s.endpoint.Host, "/" + escapePath(key)
+}
+
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// sign returns the hex SigV4 signature of canonicalRequest
+func (s *S3Store) sign(now time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalizeHeaders returns the SignedHeaders list and the canonical header block
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalizeQuery returns params sorted by name with SigV4 escaping
+func canonicalizeQuery(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = escape(name) + "=" + escape(params[name])
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapePath escapes each segment of an object path, keeping the separators
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything except SigV4 unreserved characters
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package resultstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNotOffloaded is returned by DownloadURL when the store never offloads results
+var ErrNotOffloaded = errors.New("results are not offloaded")
+
+// Stored describes where a saved result lives. Exactly one field is set.
+type Stored struct {
+	Inline json.RawMessage // Result small enough to keep in the jobs row
+	Ref    string          // Object store reference for an offloaded result
+}
+
+// Store persists job results. Small results stay inline in the jobs row; a Store may
+// offload larger ones and return a reference that DownloadURL resolves later.
+type Store interface {
+	Save(ctx context.Context, jobID string, result []byte) (Stored, error)
+	DownloadURL(ctx context.Context, ref string) (string, error)
+	// Delete removes an offloaded result, e.g. when its job is purged
+	Delete(ctx context.Context, ref string) error
+}
+
+// InlineStore keeps every result in the jobs row
+type InlineStore struct{}
+
+// Save returns result unchanged for inline storage
+func (InlineStore) Save(_ context.Context, _ string, result []byte) (Stored, error) {
+	return Stored{Inline: json.RawMessage(result)}, nil
+}
+
+// DownloadURL always fails because InlineStore never returns references
+func (InlineStore) DownloadURL(context.Context, string) (string, error) {
+	return "", ErrNotOffloaded
+}
+
+// Delete always fails because InlineStore never returns references
+func (InlineStore) Delete(context.Context, string) error {
+	return ErrNotOffloaded
+}
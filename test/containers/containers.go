@@ -0,0 +1,104 @@
+//go:build integration
+
+// Package containers starts the PostgreSQL and RabbitMQ containers the integration tests
+// run against, with the images docker/docker-compose.yml uses. It needs a Docker daemon.
+package containers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	postgresImage = "postgres:15-alpine"
+	rabbitMQImage = "rabbitmq:3.12-management-alpine"
+
+	startupTimeout = 2 * time.Minute
+)
+
+// Service is a started container and how to reach it from the tests
+type Service struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	// Database is the database of PostgreSQL, the virtual host of RabbitMQ
+	Database string
+
+	container testcontainers.Container
+}
+
+// Terminate stops and removes the container
+func (s *Service) Terminate() error {
+	return testcontainers.TerminateContainer(s.container)
+}
+
+// StartPostgres starts an empty PostgreSQL server
+func StartPostgres(ctx context.Context) (*Service, error) {
+	s := &Service{User: "postgres", Password: "postgres", Database: "jobs_db"}
+	err := s.start(ctx, postgresImage, "5432/tcp", map[string]string{
+		"POSTGRES_USER":     s.User,
+		"POSTGRES_PASSWORD": s.Password,
+		"POSTGRES_DB":       s.Database,
+	},
+		// The server logs this twice, the first time before the init scripts ran and the
+		// temporary server was stopped again
+		wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start PostgreSQL: %w", err)
+	}
+	return s, nil
+}
+
+// StartRabbitMQ starts a RabbitMQ broker
+func StartRabbitMQ(ctx context.Context) (*Service, error) {
+	s := &Service{User: "guest", Password: "guest", Database: "/"}
+	err := s.start(ctx, rabbitMQImage, "5672/tcp", map[string]string{
+		"RABBITMQ_DEFAULT_USER":  s.User,
+		"RABBITMQ_DEFAULT_PASS":  s.Password,
+		"RABBITMQ_DEFAULT_VHOST": s.Database,
+	},
+		wait.ForLog("Server startup complete"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start RabbitMQ: %w", err)
+	}
+	return s, nil
+}
+
+// start runs image with env, waits until it logged ready and port accepts connections,
+// and fills in the address port is mapped to
+func (s *Service) start(ctx context.Context, image, port string, env map[string]string, ready *wait.LogStrategy) error {
+	container, err := testcontainers.Run(ctx, image,
+		testcontainers.WithExposedPorts(port),
+		testcontainers.WithEnv(env),
+		testcontainers.WithWaitStrategy(
+			ready.WithStartupTimeout(startupTimeout),
+			wait.ForListeningPort(port).WithStartupTimeout(startupTimeout),
+		),
+	)
+	// A container that failed to get ready is still running
+	s.container = container
+	if err != nil {
+		_ = s.Terminate()
+		return err
+	}
+
+	s.Host, err = container.Host(ctx)
+	if err != nil {
+		_ = s.Terminate()
+		return err
+	}
+	mapped, err := container.MappedPort(ctx, port)
+	if err != nil {
+		_ = s.Terminate()
+		return err
+	}
+	s.Port = int(mapped.Num())
+	return nil
+}
@@ -0,0 +1,168 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/domain"
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	key := uniqueKey(t)
+	req := dto.CreateJobRequest{
+		IdempotencyKey: key,
+		UserID:         "user-1",
+		JobType:        "send_email",
+		Payload:        `{"to":"user@example.com"}`,
+	}
+
+	// Create: stored PENDING and published
+	var created dto.JobDTO
+	require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/api/v1/jobs", req, &created))
+	assert.Equal(t, domain.JobStatusPending, created.Status)
+
+	msg := receiveJob(t, created.JobID)
+	assert.Equal(t, "send_email", msg.JobType)
+	assert.JSONEq(t, `{"to":"user@example.com"}`, string(msg.Payload))
+
+	// Complete: the API reports the worker's result
+	completeJob(t, created.JobID, `{"sent":true}`)
+
+	var job dto.JobDTO
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs/"+created.JobID, nil, &job))
+	assert.Equal(t, domain.JobStatusCompleted, job.Status)
+	assert.JSONEq(t, `{"sent":true}`, string(job.Result))
+
+	// Cancel: the version read before the worker completed the job is stale, and the
+	// current one names a job that can no longer be canceled
+	assert.Equal(t, created.Version+1, job.Version)
+	assert.Equal(t, http.StatusConflict, doJSON(t, http.MethodPost, "/api/v1/jobs/"+created.JobID+"/cancel",
+		dto.CancelJobRequest{Version: created.Version}, nil))
+	assert.Equal(t, http.StatusConflict, doJSON(t, http.MethodPost, "/api/v1/jobs/"+created.JobID+"/cancel",
+		dto.CancelJobRequest{Version: job.Version}, nil))
+
+	// Resubmitting is rejected by the unique index on (user_id, idempotency_key), and the
+	// key finds the job again
+	assert.Equal(t, http.StatusConflict, doJSON(t, http.MethodPost, "/api/v1/jobs", req, nil))
+
+	var byKey dto.JobDTO
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs/by-idempotency-key/"+key+"?user_id=user-1", nil, &byKey))
+	assert.Equal(t, created.JobID, byKey.JobID)
+	assert.Equal(t, http.StatusNotFound, doJSON(t, http.MethodGet, "/api/v1/jobs/by-idempotency-key/"+key+"?user_id=user-2", nil, nil))
+
+	var list dto.ListJobsResponse
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs?user_id=user-1&status=completed", nil, &list))
+	assert.Contains(t, jobIDs(list.Jobs), created.JobID)
+
+	// History: created by the user, completed by the worker
+	var history dto.ListJobEventsResponse
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs/"+created.JobID+"/events", nil, &history))
+	require.Len(t, history.Events, 2)
+	assert.Nil(t, history.Events[0].OldStatus)
+	assert.Equal(t, domain.JobStatusPending, history.Events[0].NewStatus)
+	assert.Equal(t, domain.ActorUser, history.Events[0].ActorType)
+	assert.Equal(t, domain.JobStatusCompleted, history.Events[1].NewStatus)
+	assert.Equal(t, domain.ActorWorker, history.Events[1].ActorType)
+}
+
+func TestJobDependencies(t *testing.T) {
+	var parent, child dto.JobDTO
+	require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/api/v1/jobs", dto.CreateJobRequest{
+		IdempotencyKey: uniqueKey(t) + "-parent",
+		UserID:         "user-1",
+		JobType:        "export_csv",
+		Payload:        `{}`,
+	}, &parent))
+	require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/api/v1/jobs", dto.CreateJobRequest{
+		IdempotencyKey: uniqueKey(t) + "-child",
+		UserID:         "user-1",
+		JobType:        "send_email",
+		Payload:        `{}`,
+		DependsOn:      []string{parent.JobID},
+	}, &child))
+	assert.Equal(t, domain.JobStatusWaiting, child.Status)
+
+	receiveJob(t, parent.JobID)
+	completeJob(t, parent.JobID, `{"rows":3}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.NewDependencyResolver(env.deps, handler.DependencyResolverOptions{Interval: 100 * time.Millisecond}).Run(ctx)
+
+	// The resolver publishes the child once the parent has completed
+	receiveJob(t, child.JobID)
+
+	var job dto.JobDTO
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs/"+child.JobID, nil, &job))
+	assert.Equal(t, domain.JobStatusPending, job.Status)
+	assert.Equal(t, []string{parent.JobID}, job.DependsOn)
+
+	var history dto.ListJobEventsResponse
+	require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, "/api/v1/jobs/"+child.JobID+"/events", nil, &history))
+	require.Len(t, history.Events, 2)
+	assert.Equal(t, domain.JobStatusWaiting, history.Events[0].NewStatus)
+	assert.Equal(t, domain.JobStatusWaiting, *history.Events[1].OldStatus)
+	assert.Equal(t, domain.JobStatusPending, history.Events[1].NewStatus)
+	assert.Equal(t, domain.ActorSystem, history.Events[1].ActorType)
+}
+
+func jobIDs(jobs []dto.JobDTO) []string {
+	ids := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.JobID)
+	}
+	return ids
+}
+
+func TestConcurrentCreates(t *testing.T) {
+	const jobs = 20
+	created := make([]string, jobs)
+
+	// Parallel subtests publish from many goroutines at once, sharing the publisher pool
+	t.Run("create", func(t *testing.T) {
+		for i := range jobs {
+			t.Run(strconv.Itoa(i), func(t *testing.T) {
+				t.Parallel()
+				req := dto.CreateJobRequest{
+					IdempotencyKey: uniqueKey(t),
+					UserID:         "user-1",
+					JobType:        "send_email",
+					Payload:        `{}`,
+				}
+
+				var job dto.JobDTO
+				require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/api/v1/jobs", req, &job))
+				created[i] = job.JobID
+			})
+		}
+	})
+
+	// Every job is published exactly once
+	pending := make(map[string]bool, jobs)
+	for _, jobID := range created {
+		pending[jobID] = true
+	}
+	timeout := time.After(receiveTimeout)
+	for len(pending) > 0 {
+		select {
+		case d, ok := <-env.deliveries:
+			require.True(t, ok, "consumer stopped")
+			var msg dto.JobMessage
+			require.NoError(t, json.Unmarshal(d.Body(), &msg))
+			require.NoError(t, d.Ack())
+			delete(pending, msg.JobID)
+		case <-timeout:
+			t.Fatalf("no message for %d jobs within %s", len(pending), receiveTimeout)
+		}
+	}
+}
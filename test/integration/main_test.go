@@ -0,0 +1,245 @@
+//go:build integration
+
+// Package integration runs the API service against real PostgreSQL and RabbitMQ.
+//
+// TestMain starts both in containers of their own, so the suite needs a Docker daemon
+// but no running services, and removes them when the run ends.
+//
+//	make test-integration
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongbtq/practice-be/internal/api/dto"
+	"github.com/cuongbtq/practice-be/internal/api/handler"
+	"github.com/cuongbtq/practice-be/internal/api/router"
+	"github.com/cuongbtq/practice-be/shared/broker"
+	"github.com/cuongbtq/practice-be/shared/postgresql"
+	"github.com/cuongbtq/practice-be/shared/rabbitmq"
+	"github.com/cuongbtq/practice-be/test/containers"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	migrationsDir  = "../../migrations"
+	receiveTimeout = 10 * time.Second
+)
+
+// env is shared by every test in the run
+var env struct {
+	db         *postgresql.Client
+	rabbit     *rabbitmq.Client
+	deps       *handler.Dependencies
+	server     *httptest.Server
+	deliveries <-chan broker.Delivery
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	postgres, err := containers.StartPostgres(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+	defer terminate(postgres)
+
+	rabbit, err := containers.StartRabbitMQ(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+	defer terminate(rabbit)
+
+	env.db, err = postgresql.NewClient(&postgresql.Config{
+		Host:         postgres.Host,
+		Port:         postgres.Port,
+		User:         postgres.User,
+		Password:     postgres.Password,
+		Database:     postgres.Database,
+		SSLMode:      "disable",
+		MaxOpenConns: 10,
+	}, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+	defer env.db.Close()
+
+	if err := migrate(env.db.GetDB(), migrationsDir); err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+
+	env.rabbit, err = rabbitmq.NewClient(&rabbitmq.Config{
+		Host:              rabbit.Host,
+		Port:              rabbit.Port,
+		User:              rabbit.User,
+		Password:          rabbit.Password,
+		VHost:             rabbit.Database,
+		ExchangeName:      "jobs_exchange",
+		ExchangeType:      "direct",
+		QueueName:         "jobs_queue",
+		RoutingKey:        "job.created",
+		PrefetchCount:     10,
+		RetryAttempts:     1,
+		RetryInterval:     time.Second,
+		Heartbeat:         10 * time.Second,
+		ConnectionTimeout: 10 * time.Second,
+	}, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+	defer env.rabbit.Close()
+
+	jobBroker := rabbitmq.NewBroker(env.rabbit)
+
+	// One consumer for the whole run; tests pick their messages out by job ID
+	env.deliveries, err = jobBroker.Consume(ctx, "integration")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration:", err)
+		return 1
+	}
+
+	env.deps = &handler.Dependencies{
+		Logger:   logger,
+		DBClient: env.db,
+		Broker:   jobBroker,
+	}
+	env.server = httptest.NewServer(router.SetupRouter(env.deps))
+	defer env.server.Close()
+
+	return m.Run()
+}
+
+// terminate removes a container started for the run
+func terminate(service *containers.Service) {
+	if err := service.Terminate(); err != nil {
+		fmt.Fprintln(os.Stderr, "integration: failed to remove container:", err)
+	}
+}
+
+// migrate applies every up migration in dir in version order
+func migrate(db *sqlx.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(sql)); err != nil {
+			return fmt.Errorf("migration %s failed: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// doJSON sends a request to the API and decodes the response body into out, if set
+func doJSON(t *testing.T, method, path string, body any, out any) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = strings.NewReader(string(data))
+	}
+
+	req, err := http.NewRequest(method, env.server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// receiveJob waits for the message of jobID, acking messages of other jobs on the way
+func receiveJob(t *testing.T, jobID string) dto.JobMessage {
+	t.Helper()
+
+	timeout := time.After(receiveTimeout)
+	for {
+		select {
+		case d, ok := <-env.deliveries:
+			if !ok {
+				t.Fatal("consumer stopped")
+			}
+			var msg dto.JobMessage
+			if err := json.Unmarshal(d.Body(), &msg); err != nil {
+				t.Fatalf("invalid job message: %v", err)
+			}
+			if err := d.Ack(); err != nil {
+				t.Fatalf("failed to ack: %v", err)
+			}
+			if msg.JobID == jobID {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no message for job %s within %s", jobID, receiveTimeout)
+		}
+	}
+}
+
+// completeJob does what a worker would: runs the job and stores its result
+func completeJob(t *testing.T, jobID, result string) {
+	t.Helper()
+
+	// Records the transition in job_events the way a worker does
+	_, err := env.db.GetDB().Exec(`
+		WITH completed AS (
+			UPDATE jobs SET status = 'COMPLETED', result = $2, started_at = NOW(), completed_at = NOW(), updated_at = NOW(), version = version + 1
+			FROM (SELECT status AS old_status FROM jobs WHERE job_id = $1 FOR UPDATE) AS old
+			WHERE job_id = $1
+			RETURNING job_id, old.old_status
+		)
+		INSERT INTO job_events (job_id, old_status, new_status, actor_type, actor)
+		SELECT job_id, old_status, 'COMPLETED', 'worker', 'integration-test' FROM completed
+	`, jobID, result)
+	if err != nil {
+		t.Fatalf("failed to complete job %s: %v", jobID, err)
+	}
+}
+
+// uniqueKey returns an idempotency key no other test uses
+func uniqueKey(t *testing.T) string {
+	return fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+}